@@ -25,6 +25,8 @@ func Handler(request events.SQSEvent) error {
 
 		if err == nil {
 			log.WithField("Owner", payload.Owner).Infof("Relocated %d medias to %s", len(payload.Ids), payload.FolderName)
+		} else if errors.Is(err, archive.RestoreInProgressError) {
+			log.WithError(err).WithField("Owner", payload.Owner).Infof("Relocation to %s will be retried once originals are restored from cold storage", payload.FolderName)
 		} else {
 			log.WithError(err).WithField("Owner", payload.Owner).Errorf("Failed to relocate %d medias to %s", len(payload.Ids), payload.FolderName)
 		}
@@ -100,6 +100,13 @@ var supportedRoutes = []AuthorizedRoute{
 			return nil
 		},
 	},
+	{
+		Route: Route{Pattern: "/api/v1/medias/search", Method: "GET"},
+		Authorize: func(ctx context.Context, authoriser *catalogacl.CatalogAuthorizer, user usermodel.CurrentUser, pathParams map[string]string) error {
+			// search-medias - no specific permission check needed (only the albums visible by the user are searched)
+			return nil
+		},
+	},
 	{
 		Route: Route{Pattern: "/api/v1/owners/{owner}/albums/{folderName}/medias", Method: "GET"},
 		Authorize: func(ctx context.Context, authoriser *catalogacl.CatalogAuthorizer, user usermodel.CurrentUser, pathParams map[string]string) error {
@@ -112,6 +119,17 @@ var supportedRoutes = []AuthorizedRoute{
 			return err
 		},
 	},
+	{
+		Route: Route{Pattern: "/api/v1/owners/{owner}/medias/geo", Method: "GET"},
+		Authorize: func(ctx context.Context, authoriser *catalogacl.CatalogAuthorizer, user usermodel.CurrentUser, pathParams map[string]string) error {
+			// list-medias-geo
+			err := authoriser.IsAuthorisedToViewMap(ctx, user, ownermodel.Owner(pathParams["owner"]))
+			if errors.Is(err, catalogacl.ErrAccessDenied) {
+				return aclcore.AccessForbiddenError
+			}
+			return err
+		},
+	},
 
 	// Catalog endpoints - mutations
 	{
@@ -3,10 +3,12 @@ package common
 import (
 	"context"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 	"github.com/thomasduchatelle/dphoto/pkg/acl/aclcore"
@@ -29,13 +31,38 @@ var (
 func init() {
 	initViper()
 
-	var err error
-	Factory, err = pkgfactory.StartAWSCloudBuilder(new(LambdaViperNames)).WithAdvancedAWSAsyncFeatures().Build(context.Background())
+	masterKey, err := base64.StdEncoding.DecodeString(viper.GetString(ArchiveMasterKeyB64))
+	if err != nil {
+		panic(fmt.Sprintf("environment variable '%s' must be encoded in base 64", ArchiveMasterKeyB64))
+	}
+
+	replicas, err := archiveReplicas()
+	if err != nil {
+		panic(err.Error())
+	}
+
+	Factory, err = pkgfactory.StartAWSCloudBuilder(new(LambdaViperNames)).
+		WithAdvancedAWSAsyncFeatures().
+		WithArchiveReplicas(replicas...).
+		WithArchiveEncryption(masterKey).
+		Build(context.Background())
 	if err != nil {
 		panic(fmt.Sprintf("failed to start AWS cloud factory: %v", err))
 	}
 }
 
+// archiveReplicas reads the replicas from a JSON list ; they must be the same as the ones of the CLI for relocations and deletions to reach them.
+func archiveReplicas() ([]pkgfactory.ArchiveReplicaConfig, error) {
+	value := viper.GetString(ArchiveReplicasJSON)
+	if value == "" {
+		return nil, nil
+	}
+
+	var replicas []pkgfactory.ArchiveReplicaConfig
+	err := json.Unmarshal([]byte(value), &replicas)
+	return replicas, errors.Wrapf(err, "environment variable '%s' must be a JSON list of replicas", ArchiveReplicasJSON)
+}
+
 func appAuthConfig() aclcore.OAuthConfig {
 	jwtKey, err := base64.StdEncoding.DecodeString(viper.GetString(JWTKeyB64))
 	if err != nil {
@@ -39,17 +39,27 @@ func GetCurrentUserFromContext(request *events.APIGatewayV2HTTPRequest) (usermod
 }
 
 func RequiresAuthenticated(request *events.APIGatewayV2HTTPRequest, process func(user usermodel.CurrentUser) (Response, error)) (Response, error) {
-	token, err := readToken(request)
+	user, err := Authenticate(request)
 	if err != nil {
 		return UnauthorizedResponse(err.Error())
 	}
 
+	return process(user)
+}
+
+// Authenticate decodes the access token of requests which have not been through the Lambda Authorizer, like the ones received on a function URL.
+func Authenticate(request *events.APIGatewayV2HTTPRequest) (usermodel.CurrentUser, error) {
+	token, err := readToken(request)
+	if err != nil {
+		return usermodel.CurrentUser{}, err
+	}
+
 	claims, err := jwtDecoder.Decode(token)
 	if err != nil {
-		return UnauthorizedResponse(err.Error())
+		return usermodel.CurrentUser{}, err
 	}
 
-	return process(claims.AsCurrentUser())
+	return claims.AsCurrentUser(), nil
 }
 
 func HandleError(err error) (Response, error) {
@@ -18,6 +18,9 @@ const (
 	SNSArchiveARN          = "SNS_ARCHIVE_ARN"
 	SQSArchiveURL          = "SQS_ARCHIVE_URL"
 	SQSArchiveRelocateURL  = "SQS_ARCHIVE_RELOCATE_URL"
+	ArchiveMasterKeyB64    = "ARCHIVE_MASTER_KEY_B64"
+	ArchiveReplicasJSON    = "ARCHIVE_REPLICAS_JSON"
+	MediaStreamingURL      = "MEDIA_STREAMING_URL"
 )
 
 func initViper() {
@@ -1,9 +1,11 @@
 package main
 
 import (
+	"context"
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
@@ -40,13 +42,23 @@ func Handler(request events.APIGatewayV2HTTPRequest) (common.Response, error) {
 	// Note: IsAuthorisedToViewMedia permission check is already done by the Lambda Authorizer
 
 	if width == 0 {
-		return redirectTo(archive.GetMediaOriginalURL(owner.Value(), mediaId.Value()))
+		url, err := archive.GetMediaOriginalURL(owner.Value(), mediaId.Value())
+		if errors.Is(err, archive.SignedURLNotSupportedError) {
+			return redirectToStreamingURL(&request)
+		}
+		if errors.Is(err, archive.RestoreInProgressError) {
+			return restoreInProgress(owner, mediaId)
+		}
+		return redirectTo(url, err)
 	}
 
 	content, contentType, err := archive.GetResizedImage(owner.Value(), mediaId.Value(), width, responseMaxContent)
 	if errors.Is(err, archive.NotFoundError) {
 		return common.NotFound(nil)
 	}
+	if errors.Is(err, archive.RestoreInProgressError) {
+		return restoreInProgress(owner, mediaId)
+	}
 	if errors.Is(err, archive.MediaOverflowError) {
 		log.WithField("Owner", owner).Infof("Media %s/%s with width=%d is over max allowed payload. Redirecting.", owner, mediaId, width)
 		return redirectTo(archive.GetResizedImageURL(owner.Value(), mediaId.Value(), width))
@@ -68,6 +80,17 @@ func Handler(request events.APIGatewayV2HTTPRequest) (common.Response, error) {
 	}, nil
 }
 
+// restoreInProgress tells the client the original is in cold storage and should be requested again later.
+func restoreInProgress(owner ownermodel.Owner, mediaId catalog.MediaId) (common.Response, error) {
+	log.WithField("Owner", owner).Infof("Original %s/%s is in cold storage, restore is in progress.", owner, mediaId)
+	return common.NewJsonResponse(202, map[string]string{
+		"code":    "restore-in-progress",
+		"message": "original is in cold storage, it will be available in a few hours",
+	}, map[string]string{
+		"Retry-After": "3600",
+	})
+}
+
 func redirectTo(url string, err error) (common.Response, error) {
 	if errors.Is(err, archive.NotFoundError) {
 		return common.NotFound(nil)
@@ -84,8 +107,17 @@ func redirectTo(url string, err error) (common.Response, error) {
 	}, nil
 }
 
+// Dispatch serves the requests received from API Gateway with Handler, and the ones received on the function URL with StreamOriginal.
+func Dispatch(ctx context.Context, request events.APIGatewayV2HTTPRequest) (any, error) {
+	if strings.Contains(request.RequestContext.DomainName, ".lambda-url.") {
+		return StreamOriginal(ctx, request)
+	}
+
+	return Handler(request)
+}
+
 func main() {
 	common.BootstrapCatalogAndArchiveDomains()
 
-	lambda.Start(Handler)
+	lambda.Start(Dispatch)
 }
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"github.com/thomasduchatelle/dphoto/api/lambdas/common"
+	"github.com/thomasduchatelle/dphoto/pkg/acl/aclcore"
+	"github.com/thomasduchatelle/dphoto/pkg/acl/catalogacl"
+	"github.com/thomasduchatelle/dphoto/pkg/archive"
+	"github.com/thomasduchatelle/dphoto/pkg/catalog"
+	"github.com/thomasduchatelle/dphoto/pkg/ownermodel"
+	"github.com/thomasduchatelle/dphoto/pkg/pkgfactory"
+)
+
+const (
+	accessTokenCookie = "dphoto-access-token"
+)
+
+// redirectToStreamingURL sends the client to the function URL of the lambda which streams the original: API Gateway responses cannot be
+// bigger than 6 MB. The access token is passed in the query because neither the cookie nor the header follow the redirection.
+func redirectToStreamingURL(request *events.APIGatewayV2HTTPRequest) (common.Response, error) {
+	streamingURL := viper.GetString(common.MediaStreamingURL)
+	if streamingURL == "" {
+		return common.InternalError(errors.Errorf("%s must be set to serve encrypted originals", common.MediaStreamingURL))
+	}
+
+	token := accessToken(request)
+	if token == "" {
+		return common.UnauthorizedResponse("no access token to pass to the streaming URL")
+	}
+
+	return redirectTo(fmt.Sprintf("%s%s?access_token=%s", strings.TrimSuffix(streamingURL, "/"), request.RawPath, url.QueryEscape(token)), nil)
+}
+
+// StreamOriginal decrypts the original while it is sent to the client, whatever its size: the lambda must be invoked through a function URL in
+// RESPONSE_STREAM mode. There is no authorizer in front of function URLs: the access token, and the permission to view the media, are checked here.
+func StreamOriginal(ctx context.Context, request events.APIGatewayV2HTTPRequest) (*events.LambdaFunctionURLStreamingResponse, error) {
+	owner, mediaId, ok := parseMediaPath(request.RawPath)
+	if !ok {
+		return streamed(common.NotFound(nil))
+	}
+
+	user, err := common.Authenticate(&request)
+	if err != nil {
+		return streamed(common.UnauthorizedResponse(err.Error()))
+	}
+
+	err = pkgfactory.AclCatalogAuthoriser(ctx).IsAuthorisedToViewMedia(ctx, user, owner, mediaId)
+	if errors.Is(err, catalogacl.ErrAccessDenied) {
+		err = errors.Wrapf(aclcore.AccessForbiddenError, err.Error())
+	}
+	if err != nil {
+		return streamed(common.HandleError(err))
+	}
+
+	reader, mediaType, err := archive.GetMediaOriginal(owner.Value(), mediaId.Value())
+	if errors.Is(err, archive.NotFoundError) {
+		return streamed(common.NotFound(nil))
+	}
+	if errors.Is(err, archive.RestoreInProgressError) {
+		return streamed(restoreInProgress(owner, mediaId))
+	}
+	if err != nil {
+		return streamed(common.InternalError(err))
+	}
+
+	log.WithField("Owner", owner).Infof("Original %s/%s is streamed decrypted", owner, mediaId)
+	return &events.LambdaFunctionURLStreamingResponse{
+		StatusCode: 200,
+		Headers: map[string]string{
+			"Content-Type":  mediaType,
+			"Cache-Control": fmt.Sprintf("max-age=%d", 3600*24),
+		},
+		Body: reader,
+	}, nil
+}
+
+// parseMediaPath reads the path '/api/v1/owners/{owner}/medias/{mediaId}/{filename}': function URLs do not extract the path parameters.
+func parseMediaPath(rawPath string) (ownermodel.Owner, catalog.MediaId, bool) {
+	segments := strings.Split(strings.Trim(rawPath, "/"), "/")
+	if len(segments) != 7 || strings.Join(segments[:3], "/") != "api/v1/owners" || segments[4] != "medias" || segments[3] == "" || segments[5] == "" {
+		return "", "", false
+	}
+
+	owner, err := url.PathUnescape(segments[3])
+	if err != nil {
+		return "", "", false
+	}
+	mediaId, err := url.PathUnescape(segments[5])
+	return ownermodel.Owner(owner), catalog.MediaId(mediaId), err == nil
+}
+
+// accessToken reads the token the way the Lambda Authorizer does: from the query, the cookie, or the header.
+func accessToken(request *events.APIGatewayV2HTTPRequest) string {
+	if token := request.QueryStringParameters["access_token"]; token != "" {
+		return token
+	}
+
+	cookies := append(request.Cookies, strings.Split(request.Headers["cookie"], ";")...)
+	for _, cookie := range cookies {
+		if value, found := strings.CutPrefix(strings.TrimSpace(cookie), accessTokenCookie+"="); found {
+			return value
+		}
+	}
+
+	if authorisation := request.Headers["authorization"]; strings.HasPrefix(strings.ToLower(authorisation), "bearer ") {
+		return authorisation[len("bearer "):]
+	}
+
+	return ""
+}
+
+func streamed(response common.Response, err error) (*events.LambdaFunctionURLStreamingResponse, error) {
+	return &events.LambdaFunctionURLStreamingResponse{
+		StatusCode: response.StatusCode,
+		Headers:    response.Headers,
+		Body:       strings.NewReader(response.Body),
+	}, err
+}
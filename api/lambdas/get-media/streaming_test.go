@@ -0,0 +1,75 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/thomasduchatelle/dphoto/pkg/catalog"
+	"github.com/thomasduchatelle/dphoto/pkg/ownermodel"
+)
+
+func TestParseMediaPath(t *testing.T) {
+	tests := []struct {
+		name        string
+		rawPath     string
+		wantOwner   ownermodel.Owner
+		wantMediaId catalog.MediaId
+		wantOk      bool
+	}{
+		{"it should read the owner and the media id", "/api/v1/owners/ironman/medias/id-01/image.jpg", "ironman", "id-01", true},
+		{"it should unescape the owner", "/api/v1/owners/tony%40stark.com/medias/id-01/image.jpg", "tony@stark.com", "id-01", true},
+		{"it should reject a path without filename", "/api/v1/owners/ironman/medias/id-01", "", "", false},
+		{"it should reject a path to another resource", "/api/v1/owners/ironman/albums/avenger/medias", "", "", false},
+		{"it should reject an empty media id", "/api/v1/owners/ironman/medias//image.jpg", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			owner, mediaId, ok := parseMediaPath(tt.rawPath)
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.wantOwner, owner)
+			assert.Equal(t, tt.wantMediaId, mediaId)
+		})
+	}
+}
+
+func TestAccessToken(t *testing.T) {
+	tests := []struct {
+		name    string
+		request events.APIGatewayV2HTTPRequest
+		want    string
+	}{
+		{
+			name:    "it should read the token from the query",
+			request: events.APIGatewayV2HTTPRequest{QueryStringParameters: map[string]string{"access_token": "token-01"}},
+			want:    "token-01",
+		},
+		{
+			name:    "it should read the token from the cookies",
+			request: events.APIGatewayV2HTTPRequest{Cookies: []string{"theme=dark", "dphoto-access-token=token-01"}},
+			want:    "token-01",
+		},
+		{
+			name:    "it should read the token from the cookie header",
+			request: events.APIGatewayV2HTTPRequest{Headers: map[string]string{"cookie": "theme=dark; dphoto-access-token=token-01"}},
+			want:    "token-01",
+		},
+		{
+			name:    "it should read the token from the authorization header",
+			request: events.APIGatewayV2HTTPRequest{Headers: map[string]string{"authorization": "Bearer token-01"}},
+			want:    "token-01",
+		},
+		{
+			name:    "it should return an empty token when there is none",
+			request: events.APIGatewayV2HTTPRequest{},
+			want:    "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, accessToken(&tt.request))
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	log "github.com/sirupsen/logrus"
+	"github.com/thomasduchatelle/dphoto/api/lambdas/common"
+	"github.com/thomasduchatelle/dphoto/pkg/acl/catalogacl"
+	"github.com/thomasduchatelle/dphoto/pkg/catalog"
+	"github.com/thomasduchatelle/dphoto/pkg/ownermodel"
+	"github.com/thomasduchatelle/dphoto/pkg/pkgfactory"
+)
+
+// FeatureCollection is the GeoJSON (RFC 7946) document listing the clusters.
+type FeatureCollection struct {
+	Type     string    `json:"type"` // Type is always 'FeatureCollection'
+	BBox     []float64 `json:"bbox"` // BBox is the requested bounding box: west, south, east, north
+	Features []Feature `json:"features"`
+}
+
+type Feature struct {
+	Type       string            `json:"type"` // Type is always 'Feature'
+	Geometry   Point             `json:"geometry"`
+	Properties ClusterProperties `json:"properties"`
+}
+
+type Point struct {
+	Type        string     `json:"type"`        // Type is always 'Point'
+	Coordinates [2]float64 `json:"coordinates"` // Coordinates are longitude and latitude, in this order
+}
+
+type ClusterProperties struct {
+	Count  int        `json:"count"`  // Count is the total number of medias in the cluster
+	Medias []GeoMedia `json:"medias"` // Medias are the most recent medias of the cluster, to be used as thumbnails
+}
+
+type GeoMedia struct {
+	Id         string    `json:"id"`
+	Owner      string    `json:"owner"`
+	FolderName string    `json:"folderName"`
+	Time       time.Time `json:"time"`
+}
+
+func Handler(request events.APIGatewayV2HTTPRequest) (common.Response, error) {
+	ctx := context.Background()
+
+	parser := common.NewArgParser(&request)
+	owner := ownermodel.Owner(parser.ReadPathParameterString("owner"))
+	bboxValue := request.QueryStringParameters["bbox"]
+	zoom := parser.ReadQueryParameterInt("zoom", true)
+
+	if parser.HasViolations() {
+		return parser.BadRequest()
+	}
+
+	bbox, err := catalog.ParseBoundingBox(bboxValue)
+	if err != nil {
+		return common.BadRequest(map[string]string{"error": err.Error()})
+	}
+	if zoom < 0 || zoom > catalog.MaxGeoZoom {
+		return common.BadRequest(map[string]string{"error": "zoom must be between 0 and 22"})
+	}
+
+	// Extract user from authorizer context (already authenticated and authorized by Lambda Authorizer)
+	user, err := common.GetCurrentUserFromContext(&request)
+	if err != nil {
+		return common.UnauthorizedResponse(err.Error())
+	}
+
+	albums, err := pkgfactory.AclMapAlbums(ctx).ListAlbumsOnMap(ctx, user, owner)
+	if errors.Is(err, catalogacl.ErrAccessDenied) {
+		return common.ForbiddenResponse(err.Error())
+	}
+	if err != nil {
+		return common.InternalError(err)
+	}
+
+	log.Infof("map of %s on %d albums within %+v at zoom %d", owner, len(albums), bbox, zoom)
+
+	clusters, err := pkgfactory.CatalogGeoMediaQueries(ctx).FindGeoClusters(ctx, catalog.GeoMediasRequest{
+		Albums:      albums,
+		BoundingBox: bbox,
+		Zoom:        zoom,
+	})
+	if err != nil {
+		return common.InternalError(err)
+	}
+
+	collection := FeatureCollection{
+		Type:     "FeatureCollection",
+		BBox:     []float64{bbox.West, bbox.South, bbox.East, bbox.North},
+		Features: make([]Feature, len(clusters)),
+	}
+	for i, cluster := range clusters {
+		medias := make([]GeoMedia, len(cluster.Representatives))
+		for j, media := range cluster.Representatives {
+			medias[j] = GeoMedia{
+				Id:         string(media.Id),
+				Owner:      media.AlbumId.Owner.String(),
+				FolderName: common.ConvertFolderNameForREST(media.AlbumId.FolderName),
+				Time:       media.DateTime,
+			}
+		}
+
+		collection.Features[i] = Feature{
+			Type: "Feature",
+			Geometry: Point{
+				Type:        "Point",
+				Coordinates: [2]float64{cluster.Longitude, cluster.Latitude},
+			},
+			Properties: ClusterProperties{
+				Count:  cluster.Count,
+				Medias: medias,
+			},
+		}
+	}
+
+	return common.NewJsonResponse(200, collection, map[string]string{
+		"Content-Type": "application/geo+json",
+	})
+}
+
+func main() {
+	common.BootstrapCatalogDomain()
+
+	lambda.Start(Handler)
+}
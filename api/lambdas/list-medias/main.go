@@ -14,11 +14,13 @@ import (
 )
 
 type Media struct {
-	Id       string    `json:"id"`       // Id is an encoded version of the business id of the media
-	Type     string    `json:"type"`     // Type is PHOTO or VIDEO
-	Filename string    `json:"filename"` // Filename is user-friendly and have the right extension
-	Time     time.Time `json:"time"`     // Time is the datetime at which the media has been taken
-	Source   string    `json:"source"`   // Source is the camera that capture the media, taken from the file metadata
+	Id          string    `json:"id"`                    // Id is an encoded version of the business id of the media
+	Type        string    `json:"type"`                  // Type is PHOTO or VIDEO
+	Filename    string    `json:"filename"`              // Filename is user-friendly and have the right extension
+	Time        time.Time `json:"time"`                  // Time is the datetime at which the media has been taken
+	Source      string    `json:"source"`                // Source is the camera that capture the media, taken from the file metadata
+	LiveVideoId string    `json:"liveVideoId,omitempty"` // LiveVideoId is the id of the video of a Live Photo ; the video is not listed on its own
+	BurstCount  int       `json:"burstCount,omitempty"`  // BurstCount is the number of photos in the burst represented by this one ; the other photos are not listed
 }
 
 func Handler(request events.APIGatewayV2HTTPRequest) (common.Response, error) {
@@ -38,7 +40,7 @@ func Handler(request events.APIGatewayV2HTTPRequest) (common.Response, error) {
 
 	log.Infof("list medias for album %s/%s", owner, folderName)
 
-	medias, err := pkgfactory.CatalogMediaQueries(ctx).ListMedias(ctx, albumId)
+	medias, err := pkgfactory.CatalogMediaQueries(ctx).ListGroupedMedias(ctx, albumId)
 	if err != nil {
 		return common.InternalError(err)
 	}
@@ -52,6 +54,10 @@ func Handler(request events.APIGatewayV2HTTPRequest) (common.Response, error) {
 			Time:     media.Details.DateTime,
 			Source:   strings.Join([]string{media.Details.Make, media.Details.Model}, " "),
 		}
+		if media.LiveVideo != nil {
+			resp[i].LiveVideoId = string(media.LiveVideo.Id)
+		}
+		resp[i].BurstCount = len(media.Burst)
 	}
 
 	return common.Ok(resp)
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	log "github.com/sirupsen/logrus"
+	"github.com/thomasduchatelle/dphoto/api/lambdas/common"
+	"github.com/thomasduchatelle/dphoto/pkg/catalog"
+	"github.com/thomasduchatelle/dphoto/pkg/catalogviews"
+	"github.com/thomasduchatelle/dphoto/pkg/pkgfactory"
+)
+
+type SearchResult struct {
+	Medias   []Media `json:"medias"`
+	NextPage string  `json:"nextPage,omitempty"` // NextPage is the value of the 'page' parameter to get the next results, absent on the last page
+}
+
+type Media struct {
+	Id         string    `json:"id"`         // Id is an encoded version of the business id of the media
+	Owner      string    `json:"owner"`      // Owner of the album containing the media
+	FolderName string    `json:"folderName"` // FolderName of the album containing the media
+	Type       string    `json:"type"`       // Type is PHOTO or VIDEO
+	Filename   string    `json:"filename"`   // Filename is user-friendly and have the right extension
+	Time       time.Time `json:"time"`       // Time is the datetime at which the media has been taken
+	Source     string    `json:"source"`     // Source is the camera that capture the media, taken from the file metadata
+}
+
+// Handler searches the medias of all the albums visible by the user. Parameters:
+//   - type: comma-separated types of media (IMAGE, VIDEO)
+//   - range: comma-separated date ranges as 'start/end', each side can be omitted (ex: '2023-07-01/2023-08-01,2023-12-24/')
+//   - make, model: camera, case-insensitive
+//   - orientation: LANDSCAPE, PORTRAIT, or SQUARE
+//   - minWidth, maxWidth, minHeight, maxHeight: dimensions in pixels
+//   - minDuration, maxDuration: duration of the videos in seconds
+//   - bbox: 'west,south,east,north'
+//   - filename: part of the filename
+//   - sort: DATE_DESC (default) or DATE_ASC
+//   - pageSize, page: pagination
+func Handler(request events.APIGatewayV2HTTPRequest) (common.Response, error) {
+	ctx := context.Background()
+	query := request.QueryStringParameters
+
+	parser := common.NewArgParser(&request)
+	search := catalog.MediaSearchRequest{
+		MediaSearchFilter: catalog.MediaSearchFilter{
+			Make:             query["make"],
+			Model:            query["model"],
+			Orientation:      catalog.SearchOrientation(strings.ToUpper(query["orientation"])),
+			MinWidth:         parser.ReadQueryParameterInt("minWidth", false),
+			MaxWidth:         parser.ReadQueryParameterInt("maxWidth", false),
+			MinHeight:        parser.ReadQueryParameterInt("minHeight", false),
+			MaxHeight:        parser.ReadQueryParameterInt("maxHeight", false),
+			MinDuration:      time.Duration(parser.ReadQueryParameterInt("minDuration", false)) * time.Second,
+			MaxDuration:      time.Duration(parser.ReadQueryParameterInt("maxDuration", false)) * time.Second,
+			FilenameContains: query["filename"],
+		},
+		Sort:      catalog.SearchSort(strings.ToUpper(query["sort"])),
+		PageSize:  parser.ReadQueryParameterInt("pageSize", false),
+		PageToken: query["page"],
+	}
+
+	if parser.HasViolations() {
+		return parser.BadRequest()
+	}
+
+	for _, mediaType := range splitList(query["type"]) {
+		search.Types = append(search.Types, catalog.MediaType(strings.ToUpper(mediaType)))
+	}
+
+	for _, value := range splitList(query["range"]) {
+		timeRange, err := parseRange(value)
+		if err != nil {
+			return common.BadRequest(map[string]string{"error": err.Error()})
+		}
+		search.Ranges = append(search.Ranges, timeRange)
+	}
+
+	if bboxValue, ok := query["bbox"]; ok {
+		bbox, err := catalog.ParseBoundingBox(bboxValue)
+		if err != nil {
+			return common.BadRequest(map[string]string{"error": err.Error()})
+		}
+		search.BoundingBox = &bbox
+	}
+
+	// Extract user from authorizer context (already authenticated and authorized by Lambda Authorizer)
+	user, err := common.GetCurrentUserFromContext(&request)
+	if err != nil {
+		return common.UnauthorizedResponse(err.Error())
+	}
+
+	albums, err := pkgfactory.AlbumView(ctx).ListAlbums(ctx, user, catalogviews.ListAlbumsFilter{})
+	if err != nil {
+		return common.HandleError(err)
+	}
+	for _, album := range albums {
+		search.Albums = append(search.Albums, album.AlbumId)
+	}
+
+	log.Infof("search medias in %d albums with %+v", len(search.Albums), search)
+
+	page, err := pkgfactory.CatalogMediaSearch(ctx).Search(ctx, search)
+	if errors.Is(err, catalog.InvalidSearchRequestError) {
+		return common.BadRequest(map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return common.InternalError(err)
+	}
+
+	resp := SearchResult{
+		Medias:   make([]Media, len(page.Content)),
+		NextPage: page.NextPage,
+	}
+	for i, media := range page.Content {
+		resp.Medias[i] = Media{
+			Id:         string(media.Id),
+			Owner:      media.AlbumId.Owner.String(),
+			FolderName: common.ConvertFolderNameForREST(media.AlbumId.FolderName),
+			Type:       string(media.Type),
+			Filename:   media.Filename,
+			Time:       media.Details.DateTime,
+			Source:     strings.TrimSpace(strings.Join([]string{media.Details.Make, media.Details.Model}, " ")),
+		}
+	}
+
+	return common.Ok(resp)
+}
+
+func splitList(value string) []string {
+	var values []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+
+	return values
+}
+
+// parseRange reads a range 'start/end' where start and end are dates (2006-01-02) or datetimes (2006-01-02T15:04:05)
+func parseRange(value string) (catalog.TimeRange, error) {
+	start, end, found := strings.Cut(value, "/")
+	if !found {
+		return catalog.TimeRange{}, fmt.Errorf("range must be 'start/end', got '%s'", value)
+	}
+
+	var timeRange catalog.TimeRange
+	var err error
+	if timeRange.Start, err = parseDate(start); err != nil {
+		return catalog.TimeRange{}, err
+	}
+	if timeRange.End, err = parseDate(end); err != nil {
+		return catalog.TimeRange{}, err
+	}
+
+	return timeRange, nil
+}
+
+func parseDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	for _, layout := range []string{"2006-01-02", "2006-01-02T15:04:05"} {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("date must be '2006-01-02' or '2006-01-02T15:04:05', got '%s'", value)
+}
+
+func main() {
+	common.BootstrapCatalogDomain()
+
+	lambda.Start(Handler)
+}
@@ -4,12 +4,16 @@ import (
 	"context"
 	"fmt"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/logrusorgru/aurora/v3"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/thomasduchatelle/dphoto/cmd/dphoto/cmd/backupui"
+	"github.com/thomasduchatelle/dphoto/cmd/dphoto/cmd/reportfile"
 	"github.com/thomasduchatelle/dphoto/cmd/dphoto/config"
 	"github.com/thomasduchatelle/dphoto/internal/printer"
 	"github.com/thomasduchatelle/dphoto/pkg/backup"
 	"github.com/thomasduchatelle/dphoto/pkg/backupadapters/analysiscache"
+	"github.com/thomasduchatelle/dphoto/pkg/backupadapters/backuprules"
 	"github.com/thomasduchatelle/dphoto/pkg/backupadapters/filesystemvolume"
 	"github.com/thomasduchatelle/dphoto/pkg/backupadapters/s3volume"
 	"github.com/thomasduchatelle/dphoto/pkg/ownermodel"
@@ -26,37 +30,102 @@ var (
 
 var (
 	backupCmdArg = struct {
-		noCache   bool
-		confirm   bool
-		rejectDir string
+		noCache        bool
+		confirm        bool
+		rejectDir      string
+		quarantine     string
+		reportFormat   string
+		reportFile     string
+		plan           string
+		profileReport  bool
+		metricsAddress string
+		traceFile      string
+		geotag         geotagArgs
 	}{}
 )
 
 var backupCmd = &cobra.Command{
-	Use:   "backup [--no-cache] [--ask] <source path>",
+	Use:   "backup [--no-cache] [--ask] [--gpx <track file>] <source path> | backup --plan <plan file> [<source path>]",
 	Short: "Backup photos and videos to personal cloud",
-	Args:  cobra.ExactArgs(1),
+	Args: func(cmd *cobra.Command, args []string) error {
+		if backupCmdArg.plan != "" {
+			return cobra.RangeArgs(0, 1)(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		ctx := context.Background()
-		volumePath := args[0]
+
+		var plan *backup.Plan
+		if backupCmdArg.plan != "" {
+			var err error
+			plan, err = readPlan(backupCmdArg.plan)
+			printer.FatalWithMessageIfError(err, 3, "--plan is invalid")
+		}
+
+		volumePath := ""
+		if len(args) > 0 {
+			volumePath = args[0]
+		} else {
+			volumePath = plan.Volume
+		}
 
 		progress := backupui.NewProgress()
 		volume, err := newSmartVolume(volumePath)
 		printer.FatalIfError(err, 1)
 
-		multiFilesBackup := pkgfactory.NewMultiFilesBackup(ctx)
+		reportFormat, err := parseReportFormat(backupCmdArg.reportFormat, backupCmdArg.reportFile)
+		printer.FatalWithMessageIfError(err, 3, "--report-format is invalid")
+		details := backup.NewDetailedReport()
+
+		profiling, err := newBackupProfiling(backupCmdArg.profileReport, backupCmdArg.metricsAddress, backupCmdArg.traceFile)
+		printer.FatalWithMessageIfError(err, 3, "--metrics-address is invalid")
+		ctx, endSpan := profiling.startSpan(ctx, volumePath)
+
 		options := []backup.Options{
+			profiling.options(),
 			backup.OptionsWithListener(progress),
-			backup.OptionsAnalyserDecorator(addCacheAnalysis(!backupCmdArg.noCache)),
 			backup.OptionsWithRejectDir(backupCmdArg.rejectDir),
+			backup.OptionsWithQuarantineFile(backupCmdArg.quarantine),
+			backup.OptionsWithDetailedReport(details),
+		}
+		configOptions, err := config.BackupOptions()
+		printer.FatalWithMessageIfError(err, 3, "Backup configuration is invalid")
+		options = append(options, configOptions...)
+
+		geotagOptions, err := backupCmdArg.geotag.options()
+		printer.FatalWithMessageIfError(err, 3, "--gpx is invalid")
+
+		var report backup.Report
+		if plan != nil {
+			// rules and analysis have been applied when the plan was recorded
+			report, err = pkgfactory.NewPlanBackup(ctx)(ctx, ownermodel.Owner(Owner), volume, plan, append(options, geotagOptions)...)
+			if errors.Is(err, backup.ErrPlanOutdated) {
+				progress.Stop()
+				printer.FatalWithMessageIfError(err, 4, "Plan is outdated, scan the volume again")
+			}
+		} else {
+			var rulesOptions backup.Options
+			rulesOptions, err = backupRulesOptions(volumePath)
+			printer.FatalWithMessageIfError(err, 3, "Backup rules are invalid")
+
+			// geotagging is applied after the cache: inferred locations are not cached
+			options = append(options, backup.OptionsAnalyserDecorator(addCacheAnalysis(!backupCmdArg.noCache)), geotagOptions, rulesOptions)
+			report, err = pkgfactory.NewMultiFilesBackup(ctx)(ctx, ownermodel.Owner(Owner), volume, options...)
+		}
+		endSpan(err)
+		if profilingErr := profiling.close(); profilingErr != nil {
+			printer.Error(profilingErr, "Trace couldn't be written")
 		}
-		options = append(options, config.BackupOptions()...)
-		report, err := multiFilesBackup(ctx, ownermodel.Owner(Owner), volume, options...)
 		printer.FatalIfError(err, 2)
 
 		progress.Stop()
 
 		backupui.PrintBackupStats(report, volumePath)
+		if backupCmdArg.profileReport {
+			backupui.PrintProfileReport(profiling.stats())
+		}
+		writeReportFile(backupCmdArg.reportFile, reportFormat, reportfile.FromBackup(volumePath, report, details))
 	},
 }
 
@@ -83,6 +152,14 @@ func init() {
 
 	backupCmd.Flags().BoolVarP(&backupCmdArg.noCache, "no-cache", "c", false, "set to true to ignore cache (and not building it)")
 	backupCmd.Flags().StringVar(&backupCmdArg.rejectDir, "rejects", "", "copy files that have not been backed up to this directory (same as --skip during scanning)")
+	backupCmd.Flags().StringVar(&backupCmdArg.quarantine, "quarantine", "", "list in this file the medias still failing to upload after the retries, and continue with the other ones")
+	backupCmd.Flags().StringVar(&backupCmdArg.reportFormat, "report-format", "", "format of the report file: json, csv, or html (default: deduced from --report-file extension)")
+	backupCmd.Flags().StringVar(&backupCmdArg.plan, "plan", "", "execute the plan saved by 'scan --save-plan' ; fails if files or albums changed since")
+	backupCmd.Flags().StringVar(&backupCmdArg.reportFile, "report-file", "", "write a full report (albums, skipped files and reasons, timings) into this file")
+	backupCmd.Flags().BoolVar(&backupCmdArg.profileReport, "profile-report", false, "print the throughput, latency and queue depth of each stage at the end of the backup")
+	backupCmd.Flags().StringVar(&backupCmdArg.metricsAddress, "metrics-address", "", "expose the metrics of each stage for Prometheus on this address (ex: ':9101') while the backup is running")
+	backupCmd.Flags().StringVar(&backupCmdArg.traceFile, "trace-file", "", "write the spans of each stage in this file (OTLP/JSON), or print them when '-'")
+	backupCmdArg.geotag.addFlags(backupCmd)
 
 	config.Listen(func(cfg config.Config) {
 		newS3Volume = func(volumePath string) (backup.SourceVolume, error) {
@@ -94,6 +171,52 @@ func init() {
 	})
 }
 
+// parseReportFormat is shared between 'backup' and 'scan' ; the format is ignored if no file is requested.
+func parseReportFormat(format, file string) (reportfile.Format, error) {
+	if file == "" {
+		return "", nil
+	}
+
+	return reportfile.ParseFormat(format, file)
+}
+
+// writeReportFile is shared between 'backup' and 'scan'
+func writeReportFile(file string, format reportfile.Format, report *reportfile.Report) {
+	if file == "" {
+		return
+	}
+
+	err := reportfile.WriteFile(file, format, report)
+	printer.FatalWithMessageIfError(err, 2, "Report couldn't be written")
+	printer.Success("Report written in %s", aurora.Cyan(file))
+}
+
+// backupRulesOptions is shared between 'backup' and 'scan': rules from the configuration are completed by the ignore file at the root of local volumes.
+func backupRulesOptions(volumePath string) (backup.Options, error) {
+	rules, err := config.ReadBackupRules()
+	if err != nil {
+		return backup.Options{}, err
+	}
+
+	if !strings.HasPrefix(volumePath, "s3://") {
+		volumeRules, err := backuprules.ReadIgnoreFile(volumePath)
+		if err != nil {
+			return backup.Options{}, err
+		}
+		rules = rules.Merge(volumeRules)
+	}
+
+	if rules.IsEmpty() {
+		return backup.Options{}, nil
+	}
+
+	filter, err := backuprules.NewFilter(rules)
+	if err != nil {
+		return backup.Options{}, err
+	}
+	return backup.OptionsWithPostAnalyseFilters(filter), nil
+}
+
 func newSmartVolume(volumePath string) (backup.SourceVolume, error) {
 	if strings.HasPrefix(volumePath, "s3://") {
 		return newS3Volume(volumePath)
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"encoding/json"
+	"github.com/pkg/errors"
+	"github.com/thomasduchatelle/dphoto/pkg/backup"
+	"os"
+)
+
+// savePlan writes the plan recorded by 'scan' so it can be executed later with 'backup --plan'
+func savePlan(file string, plan *backup.Plan) error {
+	content, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return errors.Wrapf(err, "failed to serialise the plan")
+	}
+
+	err = os.WriteFile(file, content, 0644)
+	return errors.Wrapf(err, "failed to write the plan in %s", file)
+}
+
+func readPlan(file string) (*backup.Plan, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the plan %s", file)
+	}
+
+	plan := new(backup.Plan)
+	err = json.Unmarshal(content, plan)
+	return plan, errors.Wrapf(err, "%s is not a valid plan", file)
+}
@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/thomasduchatelle/dphoto/pkg/backup"
+	"github.com/thomasduchatelle/dphoto/pkg/backup/chain"
+	"github.com/thomasduchatelle/dphoto/pkg/chainmetrics"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+const (
+	metricsPath      = "/metrics"
+	traceFileStdout  = "-"
+	traceServiceName = "dphoto"
+)
+
+// backupProfiling measures each stage of the backup for --profile-report, --metrics-address, and --trace-file.
+type backupProfiling struct {
+	recorder    *chainmetrics.Recorder // recorder is nil unless a report or the metrics are requested
+	tracer      *chainmetrics.Tracer   // tracer is nil unless a trace file is requested
+	traceFile   string
+	stopMetrics func()
+}
+
+func newBackupProfiling(profileReport bool, metricsAddress, traceFile string) (*backupProfiling, error) {
+	profiling := &backupProfiling{traceFile: traceFile}
+	if profileReport || metricsAddress != "" {
+		profiling.recorder = chainmetrics.NewRecorder()
+	}
+	if traceFile != "" {
+		profiling.tracer = chainmetrics.NewTracer(traceServiceName)
+	}
+
+	if metricsAddress != "" {
+		listener, err := net.Listen("tcp", metricsAddress)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to listen on %s for the metrics endpoint", metricsAddress)
+		}
+
+		mux := http.NewServeMux()
+		mux.Handle(metricsPath, profiling.recorder)
+		server := &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+		go func() {
+			if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.WithError(err).Errorln("Metrics endpoint stopped")
+			}
+		}()
+
+		profiling.stopMetrics = func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			_ = server.Shutdown(ctx)
+		}
+	}
+
+	return profiling, nil
+}
+
+// options returns the instrumentation to apply to the backup, if any.
+func (p *backupProfiling) options() backup.Options {
+	var instrumentations []chain.Instrumentation
+	if p.recorder != nil {
+		instrumentations = append(instrumentations, p.recorder)
+	}
+	if p.tracer != nil {
+		instrumentations = append(instrumentations, p.tracer)
+	}
+
+	return backup.OptionsWithInstrumentation(instrumentations...)
+}
+
+// startSpan returns a context in which the spans of the stages will be children of the backup span.
+func (p *backupProfiling) startSpan(ctx context.Context, volumePath string) (context.Context, func(err error)) {
+	if p.tracer == nil {
+		return ctx, func(err error) {}
+	}
+
+	return p.tracer.StartSpan(ctx, "backup", map[string]interface{}{"volume": volumePath})
+}
+
+// stats are nil when --profile-report has not been requested.
+func (p *backupProfiling) stats() []chainmetrics.LinkStats {
+	if p.recorder == nil {
+		return nil
+	}
+	return p.recorder.Stats()
+}
+
+// close writes the trace file and stops the metrics endpoint.
+func (p *backupProfiling) close() error {
+	if p.stopMetrics != nil {
+		p.stopMetrics()
+	}
+
+	switch p.traceFile {
+	case "":
+		return nil
+
+	case traceFileStdout:
+		return p.tracer.WriteText(os.Stdout)
+
+	default:
+		file, err := os.Create(p.traceFile)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create trace file %s", p.traceFile)
+		}
+		defer file.Close()
+
+		return errors.Wrapf(p.tracer.WriteOTLP(file), "failed to write trace file %s", p.traceFile)
+	}
+}
@@ -13,6 +13,7 @@ type BackupProgress struct {
 	uploadLine       *screen.ProgressLine
 	onAnalysedCalled bool
 	onUploadCalled   bool
+	bandwidth        string // bandwidth is the upload rate limit, displayed after the upload progress
 }
 
 func NewProgress() *BackupProgress {
@@ -59,14 +60,35 @@ func (p *BackupProgress) OnUploaded(done, total backup.MediaCounter) {
 	p.onUploadCalled = true
 	if !total.IsZero() {
 		p.uploadLine.SetBar(done.Size, total.Size)
-		p.uploadLine.SetExplanation(fmt.Sprintf("%s / %s", byteCountIEC(done.Size), byteCountIEC(total.Size)))
+		p.uploadLine.SetExplanation(fmt.Sprintf("%s / %s%s", byteCountIEC(done.Size), byteCountIEC(total.Size), p.bandwidth))
 
 		if done.Count == total.Count {
 			p.uploadLine.SwapSpinner(1)
 			p.uploadLine.SetLabel("Upload complete")
 		}
 	} else {
-		p.uploadLine.SetExplanation(fmt.Sprintf("%s", byteCountIEC(done.Size)))
+		p.uploadLine.SetExplanation(fmt.Sprintf("%s%s", byteCountIEC(done.Size), p.bandwidth))
+	}
+}
+
+func (p *BackupProgress) OnBandwidthLimit(limit backup.BandwidthLimit) {
+	p.bandwidth = BandwidthLimitLabel(limit)
+	if p.bandwidth != "" {
+		p.bandwidth = " " + p.bandwidth
+	}
+}
+
+// BandwidthLimitLabel describes the upload rate limit, or is empty when the rate is not limited.
+func BandwidthLimitLabel(limit backup.BandwidthLimit) string {
+	switch {
+	case limit.Global > 0 && limit.PerRoutine > 0:
+		return fmt.Sprintf("[limited to %s/s, %s/s per routine]", byteCountIEC(int(limit.Global)), byteCountIEC(int(limit.PerRoutine)))
+	case limit.Global > 0:
+		return fmt.Sprintf("[limited to %s/s]", byteCountIEC(int(limit.Global)))
+	case limit.PerRoutine > 0:
+		return fmt.Sprintf("[limited to %s/s per routine]", byteCountIEC(int(limit.PerRoutine)))
+	default:
+		return ""
 	}
 }
 
@@ -6,9 +6,13 @@ import (
 	"github.com/logrusorgru/aurora/v3"
 	"github.com/thomasduchatelle/dphoto/internal/printer"
 	"github.com/thomasduchatelle/dphoto/pkg/backup"
+	"sort"
 )
 
 func PrintBackupStats(tracker backup.Report, volumePath string) {
+	defer printQuarantined(tracker)
+	defer printFilteredOut(tracker)
+
 	if len(tracker.CountPerAlbum()) == 0 {
 		printer.Success("\n\nBackup of %s complete: %s.", aurora.Cyan(volumePath), aurora.Bold(aurora.Yellow("no new medias")))
 		return
@@ -58,6 +62,41 @@ func PrintBackupStats(tracker backup.Report, volumePath string) {
 	fmt.Println(table.String())
 }
 
+// printFilteredOut lists the reasons why medias have not been backed up, most frequent first.
+func printFilteredOut(tracker backup.Report) {
+	filteredOut := tracker.FilteredOut()
+	if len(filteredOut) == 0 {
+		return
+	}
+
+	reasons := make([]string, 0, len(filteredOut))
+	for reason := range filteredOut {
+		reasons = append(reasons, reason)
+	}
+	sort.Slice(reasons, func(i, j int) bool {
+		if filteredOut[reasons[i]].Count != filteredOut[reasons[j]].Count {
+			return filteredOut[reasons[i]].Count > filteredOut[reasons[j]].Count
+		}
+		return reasons[i] < reasons[j]
+	})
+
+	fmt.Println(aurora.Yellow("Medias filtered out by backup rules:"))
+	for _, reason := range reasons {
+		counter := filteredOut[reason]
+		fmt.Printf("  %s: %d (%s)\n", reason, counter.Count, byteCountIEC(counter.Size))
+	}
+}
+
+// printQuarantined warns about the medias that couldn't be backed up, they are listed in the quarantine file.
+func printQuarantined(tracker backup.Report) {
+	quarantined := tracker.Quarantined()
+	if quarantined.IsZero() {
+		return
+	}
+
+	fmt.Println(aurora.Red(fmt.Sprintf("%d medias (%s) couldn't be backed up and have been listed in the quarantine file.", quarantined.Count, byteCountIEC(quarantined.Size))))
+}
+
 func countAndSize(counter backup.MediaCounter) *simpletable.Cell {
 	if counter.Count == 0 {
 		return &simpletable.Cell{Align: simpletable.AlignCenter, Text: "-"}
@@ -0,0 +1,62 @@
+package backupui
+
+import (
+	"fmt"
+	"github.com/alexeyco/simpletable"
+	"github.com/logrusorgru/aurora/v3"
+	"github.com/thomasduchatelle/dphoto/pkg/chainmetrics"
+	"time"
+)
+
+// PrintProfileReport prints the time spent in each stage of the backup to find which one is the bottleneck.
+func PrintProfileReport(stats []chainmetrics.LinkStats) {
+	if len(stats) == 0 {
+		return
+	}
+
+	fmt.Println(aurora.Bold("\nProfile of the backup stages:"))
+
+	table := simpletable.New()
+	table.Header = &simpletable.Header{Cells: []*simpletable.Cell{
+		{Text: "Stage", Align: simpletable.AlignCenter},
+		{Text: "Items", Align: simpletable.AlignCenter},
+		{Text: "Throughput", Align: simpletable.AlignCenter},
+		{Text: "Mean", Align: simpletable.AlignCenter},
+		{Text: "p50", Align: simpletable.AlignCenter},
+		{Text: "p95", Align: simpletable.AlignCenter},
+		{Text: "Max queue", Align: simpletable.AlignCenter},
+		{Text: "Errors", Align: simpletable.AlignCenter},
+	}}
+
+	for _, s := range stats {
+		queue := "-"
+		if s.QueueCapacity > 0 {
+			queue = fmt.Sprintf("%d / %d", s.MaxQueueDepth, s.QueueCapacity)
+		}
+
+		table.Body.Cells = append(table.Body.Cells, []*simpletable.Cell{
+			{Text: s.Link},
+			{Align: simpletable.AlignRight, Text: fmt.Sprintf("%d", s.Items)},
+			{Align: simpletable.AlignRight, Text: fmt.Sprintf("%.1f/s", s.Throughput())},
+			{Align: simpletable.AlignRight, Text: formatLatency(s.MeanLatency())},
+			{Align: simpletable.AlignRight, Text: formatPercentile(s.Percentile(0.5))},
+			{Align: simpletable.AlignRight, Text: formatPercentile(s.Percentile(0.95))},
+			{Align: simpletable.AlignRight, Text: queue},
+			{Align: simpletable.AlignRight, Text: fmt.Sprintf("%d", s.Errors)},
+		})
+	}
+
+	fmt.Println(table.String())
+}
+
+func formatLatency(latency time.Duration) string {
+	return latency.Round(time.Millisecond).String()
+}
+
+// formatPercentile displays the bucket upper bound the percentile falls in.
+func formatPercentile(latency time.Duration) string {
+	if latency < 0 {
+		return fmt.Sprintf("> %s", chainmetrics.LatencyBuckets[len(chainmetrics.LatencyBuckets)-1])
+	}
+	return fmt.Sprintf("<= %s", latency)
+}
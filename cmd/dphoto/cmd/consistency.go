@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"github.com/logrusorgru/aurora/v3"
+	"github.com/spf13/cobra"
+	"github.com/thomasduchatelle/dphoto/cmd/dphoto/cmd/ui"
+	"github.com/thomasduchatelle/dphoto/internal/printer"
+	"github.com/thomasduchatelle/dphoto/pkg/archiveconsistency"
+	"github.com/thomasduchatelle/dphoto/pkg/ownermodel"
+	"github.com/thomasduchatelle/dphoto/pkg/pkgfactory"
+)
+
+var (
+	consistencyArgs = struct {
+		repair bool
+		yes    bool
+	}{}
+)
+
+var consistencyCmd = &cobra.Command{
+	Use:   "consistency [--repair] [--yes]",
+	Short: "Detect (and repair) drifts between the catalog and the archive",
+	Long: `Detect (and repair) drifts between the catalog and the archive:
+
+- MISSING_LOCATION: media in the catalog without any location in the archive ; must be backed up again
+- MISSING_OBJECT: media location pointing to a file that doesn't exist ; must be backed up again
+- MISPLACED: media stored in a folder that is not its album's one ; relocation is queued again with --repair
+- ORPHAN: file stored that no media references ; deleted with --repair, after confirmation
+`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		observer := new(printerDriftObserver)
+		reconciler := pkgfactory.ArchiveConsistencyReconciler(
+			ctx,
+			!consistencyArgs.repair,
+			archiveconsistency.ConfirmDeletionFunc(confirmOrphansDeletion),
+			archiveconsistency.DriftOptionObserver(observer),
+		)
+
+		err := reconciler.Reconcile(ctx, ownermodel.Owner(Owner))
+		printer.FatalWithMessageIfError(err, 1, "Consistency check failed for %s", Owner)
+
+		if observer.count == 0 {
+			printer.Success("Catalog and archive are consistent for %s", aurora.Cyan(Owner))
+		} else if consistencyArgs.repair {
+			printer.Success("%d drifts have been processed for %s", observer.count, aurora.Cyan(Owner))
+		} else {
+			printer.Info("%d drifts found for %s ; use --repair to reconcile them", observer.count, aurora.Cyan(Owner))
+		}
+	},
+}
+
+func confirmOrphansDeletion(ctx context.Context, keys []string) (bool, error) {
+	if consistencyArgs.yes {
+		return true, nil
+	}
+
+	return ui.NewSimpleForm().ReadConfirmation(fmt.Sprintf("Delete %d orphan files permanently?", len(keys)))
+}
+
+type printerDriftObserver struct {
+	count int
+}
+
+func (p *printerDriftObserver) OnDetectedDrifts(ctx context.Context, drifts []archiveconsistency.Drift) error {
+	for _, drift := range drifts {
+		switch {
+		case drift.MissingLocation != nil:
+			fmt.Printf("%-18s %s %s\n", aurora.Red("MISSING_LOCATION"), drift.MissingLocation.AlbumId, drift.MissingLocation.MediaId)
+		case drift.MissingObject != nil:
+			fmt.Printf("%-18s %s %s -> %s\n", aurora.Red("MISSING_OBJECT"), drift.MissingObject.AlbumId, drift.MissingObject.MediaId, drift.MissingObject.Key)
+		case drift.MisplacedMedia != nil:
+			fmt.Printf("%-18s %s %s -> %s\n", aurora.Yellow("MISPLACED"), drift.MisplacedMedia.AlbumId, drift.MisplacedMedia.MediaId, drift.MisplacedMedia.Key)
+		case drift.OrphanObject != nil:
+			fmt.Printf("%-18s %s\n", aurora.Yellow("ORPHAN"), drift.OrphanObject.Key)
+		}
+	}
+
+	p.count += len(drifts)
+	return nil
+}
+
+func init() {
+	opsCmd.AddCommand(consistencyCmd)
+
+	consistencyCmd.Flags().BoolVar(&consistencyArgs.repair, "repair", false, "re-queue relocation of misplaced medias and delete orphan files")
+	consistencyCmd.Flags().BoolVarP(&consistencyArgs.yes, "yes", "y", false, "do not ask for confirmation before deleting orphan files")
+}
@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"github.com/logrusorgru/aurora/v3"
+	"github.com/spf13/cobra"
+	"github.com/thomasduchatelle/dphoto/cmd/dphoto/config"
+	"github.com/thomasduchatelle/dphoto/cmd/dphoto/daemon"
+	"github.com/thomasduchatelle/dphoto/internal/printer"
+	"github.com/thomasduchatelle/dphoto/pkg/backup"
+	"github.com/thomasduchatelle/dphoto/pkg/ownermodel"
+	"github.com/thomasduchatelle/dphoto/pkg/pkgfactory"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+var (
+	daemonArgs = struct {
+		directories []string
+		noCache     bool
+	}{}
+)
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon [--dir <directory>]...",
+	Short: "Watch directories, and removable volumes, to backup new photos and videos as soon as they are available",
+	Long: fmt.Sprintf(`Watch directories, and removable volumes, to backup new photos and videos as soon as they are available.
+
+Directories are read from '%s' configuration when --dir is not used. Files are backed up once they haven't changed
+for '%s' (default %s), and after '%s' (default %s) without new files.
+
+Removable volumes (USB sticks, SD cards, cameras) listed in '%s' are backed up each time they are mounted. They are
+identified by the UUID of their filesystem (see 'ls -l /dev/disk/by-uuid'). Each volume can have its own owner,
+sub-directory, directory for rejects, and can have its files deleted once they are safely in the archive.
+
+The queue is persisted: files not backed up when the daemon stops are backed up when it restarts, as well as the files
+changed while it was stopped. Use 'dphoto daemon status' to see the queue and the last errors.
+`, config.DaemonDirectories, config.DaemonStableDelay, daemon.DefaultStableDelay, config.DaemonBatchDelay, daemon.DefaultBatchDelay, config.DaemonVolumes),
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		daemonConfig, err := config.DaemonConfig()
+		printer.FatalWithMessageIfError(err, 3, "Daemon configuration is invalid")
+		if len(daemonArgs.directories) > 0 {
+			daemonConfig.Directories = daemonArgs.directories
+		}
+		daemonConfig.Progress = os.Stdout
+
+		configOptions, err := config.BackupOptions()
+		printer.FatalWithMessageIfError(err, 3, "Backup configuration is invalid")
+
+		cache := addCacheAnalysis(!daemonArgs.noCache)
+		multiFilesBackup := pkgfactory.NewMultiFilesBackup(ctx)
+		watcher, err := daemon.New(daemonConfig, func(ctx context.Context, owner ownermodel.Owner, volume backup.SourceVolume, options ...backup.Options) (backup.Report, error) {
+			rulesOptions, err := backupRulesOptions(volume.String())
+			if err != nil {
+				return nil, err
+			}
+
+			options = append(options, backup.OptionsAnalyserDecorator(cache), rulesOptions)
+			options = append(options, configOptions...)
+			return multiFilesBackup(ctx, owner, volume, options...)
+		})
+		printer.FatalWithMessageIfError(err, 3, "Daemon configuration is invalid")
+
+		printer.Info("Watching %s and %d volumes ; status on %s (stop with Ctrl+C)", aurora.Cyan(daemonConfig.Directories), len(daemonConfig.Volumes), aurora.Cyan(daemonConfig.StatusAddress))
+		err = watcher.Run(ctx)
+		printer.FatalIfError(err, 2)
+	},
+}
+
+var daemonStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Print the queue and the last errors of the running daemon",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		status, err := daemon.FetchStatus(cmd.Context(), config.DaemonStatusAddressOrDefault())
+		printer.FatalWithMessageIfError(err, 1, "Daemon status couldn't be read")
+
+		fmt.Printf("Watching: %s\n", aurora.Cyan(status.Directories))
+		for _, volume := range status.Volumes {
+			state := aurora.Gray(12, "not mounted")
+			if volume.MountPoint != "" {
+				state = aurora.Green("mounted on " + volume.MountPoint)
+			}
+			if volume.Queued {
+				state = aurora.Yellow("mounted on " + volume.MountPoint + ", backup queued")
+			}
+			fmt.Printf("Volume %s (%s): %s\n", aurora.Cyan(volume.Name), volume.UUID, state)
+		}
+		if status.RunningSince != nil {
+			fmt.Printf("Backing up %s since %s\n", aurora.Cyan(status.RunningDir), status.RunningSince.Format(time.TimeOnly))
+		}
+		fmt.Printf("Waiting to be stable: %d, queued: %d\n", len(status.Pending), len(status.Queue))
+		for _, file := range status.Queue {
+			fmt.Printf("  %s\n", file)
+		}
+		if status.RetryAfter != nil {
+			fmt.Println(aurora.Yellow(fmt.Sprintf("Last backup failed, retrying after %s", status.RetryAfter.Format(time.TimeOnly))))
+		}
+
+		if len(status.Runs) > 0 {
+			fmt.Println("\nLast backups:")
+			for _, run := range status.Runs {
+				outcome := aurora.Green(fmt.Sprintf("%d backed up", run.BackedUp))
+				if run.Deleted > 0 {
+					outcome = aurora.Green(fmt.Sprintf("%d backed up, %d deleted", run.BackedUp, run.Deleted))
+				}
+				if run.Error != "" {
+					outcome = aurora.Red(run.Error)
+				}
+				fmt.Printf("  %s  %-40s %4d files  %s\n", run.Start.Format(time.DateTime), run.Directory, run.Files, outcome)
+			}
+		}
+
+		if len(status.Errors) > 0 {
+			fmt.Println("\nLast errors:")
+			for _, errorStatus := range status.Errors {
+				fmt.Printf("  %s  %s %s\n", errorStatus.Time.Format(time.DateTime), errorStatus.Path, aurora.Red(errorStatus.Error))
+			}
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(daemonCmd)
+	daemonCmd.AddCommand(daemonStatusCmd)
+
+	daemonCmd.Flags().StringArrayVar(&daemonArgs.directories, "dir", nil, "directory to watch (repeatable) ; overrides the configuration")
+	daemonCmd.Flags().BoolVarP(&daemonArgs.noCache, "no-cache", "c", false, "set to true to ignore cache (and not building it)")
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/logrusorgru/aurora/v3"
+	"github.com/spf13/cobra"
+	"github.com/thomasduchatelle/dphoto/cmd/dphoto/config"
+	"github.com/thomasduchatelle/dphoto/internal/printer"
+	"github.com/thomasduchatelle/dphoto/pkg/backup"
+	"github.com/thomasduchatelle/dphoto/pkg/backupadapters/gpxgeotag"
+	"github.com/thomasduchatelle/dphoto/pkg/catalog"
+	"github.com/thomasduchatelle/dphoto/pkg/geo"
+	"github.com/thomasduchatelle/dphoto/pkg/ownermodel"
+	"github.com/thomasduchatelle/dphoto/pkg/pkgfactory"
+	"time"
+)
+
+// geotagArgs is shared between 'backup' and 'ops geotag'
+type geotagArgs struct {
+	gpxFiles          []string
+	maxGap            time.Duration
+	cameraClockOffset time.Duration
+}
+
+var (
+	geotagCmdArg = struct {
+		folderName string
+		dryRun     bool
+		geotag     geotagArgs
+	}{}
+)
+
+var geotagCmd = &cobra.Command{
+	Use:   "geotag --album <folder name> --gpx <track file> [--max-gap 5m] [--camera-clock-offset 0s] [--dry-run]",
+	Short: "Locate the medias of an album from GPX tracks",
+	Long: `Locate the medias of an album that have no GPS coordinates from their capture time and the GPX tracks.
+
+The position is interpolated between the points of the tracks recorded before and after the media, when they are close
+enough (--max-gap). Medias backed up before the time zones were known are assumed to be captured in the backup time zone.
+`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		locator, err := geotagCmdArg.geotag.geotagger()
+		printer.FatalWithMessageIfError(err, 3, "--gpx is invalid")
+
+		zone, err := config.ReadBackupTimeZone()
+		printer.FatalWithMessageIfError(err, 3, "Backup configuration is invalid")
+
+		albumId := catalog.AlbumId{Owner: ownermodel.Owner(Owner), FolderName: catalog.NewFolderName(geotagCmdArg.folderName)}
+		locations, err := pkgfactory.GeotagMediasCase(ctx).Geotag(ctx, catalog.GeotagMediasRequest{
+			AlbumId:         albumId,
+			Locator:         locator,
+			DefaultTimeZone: zone,
+			DryRun:          geotagCmdArg.dryRun,
+		})
+		printer.FatalWithMessageIfError(err, 1, "Geotagging failed for %s", albumId)
+
+		for _, location := range locations {
+			fmt.Printf("%-60s %f, %f\n", location.Id, location.GPSLatitude, location.GPSLongitude)
+		}
+
+		if geotagCmdArg.dryRun {
+			printer.Info("%d medias would be located in %s", len(locations), aurora.Cyan(albumId.FolderName))
+		} else {
+			printer.Success("%d medias have been located in %s", len(locations), aurora.Cyan(albumId.FolderName))
+		}
+	},
+}
+
+func (g *geotagArgs) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringArrayVar(&g.gpxFiles, "gpx", nil, "locate the medias without GPS coordinates from this GPX track, can be repeated")
+	cmd.Flags().DurationVar(&g.maxGap, "max-gap", geo.DefaultTrackMaxGap, "maximum time between a media and the points of the track used to locate it")
+	cmd.Flags().DurationVar(&g.cameraClockOffset, "camera-clock-offset", 0, "how much the camera clock is ahead of the GPS (ex: '-1m30s' when it's late)")
+}
+
+func (g *geotagArgs) geotagger() (*geo.Geotagger, error) {
+	track, err := geo.ReadGPXFiles(g.gpxFiles...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &geo.Geotagger{
+		Track:             track,
+		MaxGap:            g.maxGap,
+		CameraClockOffset: g.cameraClockOffset,
+	}, nil
+}
+
+// options returns no option when no GPX file has been given.
+func (g *geotagArgs) options() (backup.Options, error) {
+	if len(g.gpxFiles) == 0 {
+		return backup.Options{}, nil
+	}
+
+	geotagger, err := g.geotagger()
+	if err != nil {
+		return backup.Options{}, err
+	}
+
+	zone, err := config.ReadBackupTimeZone()
+	return backup.OptionsAnalyserDecorator(&gpxgeotag.GeotagDecorator{
+		Locations:       geotagger,
+		DefaultTimeZone: zone,
+	}), err
+}
+
+func init() {
+	opsCmd.AddCommand(geotagCmd)
+
+	geotagCmd.Flags().StringVarP(&geotagCmdArg.folderName, "album", "a", "", "folder name of the album (expected to start with a /)")
+	geotagCmd.Flags().BoolVar(&geotagCmdArg.dryRun, "dry-run", false, "only list the locations that would be given to the medias")
+	geotagCmdArg.geotag.addFlags(geotagCmd)
+
+	_ = geotagCmd.MarkFlagRequired("album")
+	_ = geotagCmd.MarkFlagRequired("gpx")
+}
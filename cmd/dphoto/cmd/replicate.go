@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"github.com/logrusorgru/aurora/v3"
+	"github.com/spf13/cobra"
+	"github.com/thomasduchatelle/dphoto/internal/printer"
+	"github.com/thomasduchatelle/dphoto/pkg/archive"
+	"github.com/thomasduchatelle/dphoto/pkg/pkgfactory"
+	"os"
+)
+
+var (
+	replicateArgs = struct {
+		catchUp bool
+	}{}
+)
+
+var replicateCmd = &cobra.Command{
+	Use:   "replicate [--catch-up]",
+	Short: "Retry failed replications of originals on the secondary storages",
+	Long: `Retry failed replications of originals on the secondary storages configured in 'archive.replicas'.
+
+With --catch-up, all originals that have never been replicated are copied as well (back-fill of existing medias).
+`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		store := pkgfactory.ArchiveReplicatedStore(ctx)
+		if len(store.Replicas) == 0 {
+			printer.ErrorText("No replica configured, add them under 'archive.replicas' in the configuration.")
+			os.Exit(1)
+		}
+
+		var report archive.ReplicationReport
+		var err error
+		if replicateArgs.catchUp {
+			report, err = store.CatchUp(Owner, pkgfactory.ArchiveMainStoreWalker(ctx))
+		} else {
+			report, err = store.RetryFailedReplications(Owner)
+		}
+		printer.FatalWithMessageIfError(err, 2, "Replication failed for %s", Owner)
+
+		if report.Failed > 0 {
+			printer.ErrorText("%d originals have been replicated, %d failed and will be retried on next run.", report.Replicated, report.Failed)
+			os.Exit(3)
+		}
+		printer.Success("%d originals have been replicated for %s", report.Replicated, aurora.Cyan(Owner))
+	},
+}
+
+func init() {
+	opsCmd.AddCommand(replicateCmd)
+
+	replicateCmd.Flags().BoolVar(&replicateArgs.catchUp, "catch-up", false, "replicate all originals not yet replicated, not only the failed ones")
+}
@@ -0,0 +1,136 @@
+// Package reportfile writes the outcome of a scan or a backup in a machine-readable format.
+package reportfile
+
+import (
+	"github.com/thomasduchatelle/dphoto/pkg/backup"
+	"sort"
+	"time"
+)
+
+type Report struct {
+	Command     string    `json:"command"` // Command is either 'backup' or 'scan'
+	Volume      string    `json:"volume"`
+	GeneratedAt time.Time `json:"generatedAt"`
+	Albums      []Album   `json:"albums"`
+	Skipped     []Skipped `json:"skipped"`
+	Timings     []Timing  `json:"timings"`
+}
+
+type Counter struct {
+	Count int `json:"count"`
+	Size  int `json:"size"`
+}
+
+type Album struct {
+	Name    string     `json:"name"`              // Name is the album folder name (backup), or the suggested name (scan)
+	Path    string     `json:"path,omitempty"`    // Path is the relative path of the folder in the volume (scan only)
+	New     bool       `json:"new"`               // New is true when the album has been created by the backup
+	Start   *time.Time `json:"start,omitempty"`   // Start is the first day of the medias (scan only)
+	End     *time.Time `json:"end,omitempty"`     // End is the day following the last media (scan only)
+	Images  *Counter   `json:"images,omitempty"`  // Images is only known after a backup
+	Videos  *Counter   `json:"videos,omitempty"`  // Videos is only known after a backup
+	Others  *Counter   `json:"others,omitempty"`  // Others is only known after a backup
+	Total   Counter    `json:"total"`             // Total are the medias backed up (backup), or to back up (scan)
+	Rejects int        `json:"rejects,omitempty"` // Rejects is the number of medias that won't be backed up (scan only)
+}
+
+type Skipped struct {
+	Path    string `json:"path"`
+	Size    int    `json:"size"`
+	Reason  string `json:"reason"`
+	Details string `json:"details"`
+	Album   string `json:"album,omitempty"`
+}
+
+type Timing struct {
+	Stage   string        `json:"stage"`
+	Count   int           `json:"count"`
+	Elapsed time.Duration `json:"elapsedNs"` // Elapsed is the wall-clock time during which the stage was active
+	Busy    time.Duration `json:"busyNs"`    // Busy is the time spent by all the routines of the stage
+}
+
+// FromBackup creates a report from the outcome of a backup ; details is optional.
+func FromBackup(volume string, report backup.Report, details *backup.DetailedReport) *Report {
+	fileReport := newReport("backup", volume, details)
+
+	for folderName, album := range report.CountPerAlbum() {
+		images, videos, others := album.OfType(backup.MediaTypeImage), album.OfType(backup.MediaTypeVideo), album.OfType(backup.MediaTypeOther)
+		fileReport.Albums = append(fileReport.Albums, Album{
+			Name:   folderName,
+			New:    album.IsNew(),
+			Images: newCounter(images),
+			Videos: newCounter(videos),
+			Others: newCounter(others),
+			Total:  *newCounter(album.Total()),
+		})
+	}
+	sort.Slice(fileReport.Albums, func(i, j int) bool {
+		return fileReport.Albums[i].Name < fileReport.Albums[j].Name
+	})
+
+	return fileReport
+}
+
+// FromScan creates a report from the folders found during a scan ; details is optional.
+func FromScan(volume string, folders []*backup.ScannedFolder, details *backup.DetailedReport) *Report {
+	fileReport := newReport("scan", volume, details)
+
+	for _, folder := range folders {
+		total := backup.MediaCounterZero
+		for _, counter := range folder.Distribution {
+			total = total.AddCounter(counter)
+		}
+
+		album := Album{
+			Name:    folder.Name,
+			Path:    folder.RelativePath,
+			Total:   *newCounter(total),
+			Rejects: folder.RejectsCount,
+		}
+		if !folder.Start.IsZero() {
+			album.Start, album.End = &folder.Start, &folder.End
+		}
+		fileReport.Albums = append(fileReport.Albums, album)
+	}
+
+	return fileReport
+}
+
+func newReport(command, volume string, details *backup.DetailedReport) *Report {
+	report := &Report{
+		Command:     command,
+		Volume:      volume,
+		GeneratedAt: time.Now(),
+		Albums:      []Album{},
+		Skipped:     []Skipped{},
+		Timings:     []Timing{},
+	}
+	if details == nil {
+		return report
+	}
+
+	for _, skipped := range details.SkippedMedias() {
+		report.Skipped = append(report.Skipped, Skipped{
+			Path:    skipped.Path,
+			Size:    skipped.Size,
+			Reason:  string(skipped.Reason),
+			Details: skipped.Details,
+			Album:   skipped.Album,
+		})
+	}
+
+	for _, timing := range details.Timings() {
+		report.Timings = append(report.Timings, Timing{
+			Stage:   string(timing.Stage),
+			Count:   timing.Count,
+			Elapsed: timing.Elapsed(),
+			Busy:    timing.Busy,
+		})
+	}
+
+	return report
+}
+
+func newCounter(counter backup.MediaCounter) *Counter {
+	return &Counter{Count: counter.Count, Size: counter.Size}
+}
@@ -0,0 +1,182 @@
+package reportfile
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FormatJSON Format = "json"
+	FormatCSV  Format = "csv"
+	FormatHTML Format = "html"
+)
+
+type Format string
+
+// ParseFormat validates the requested format ; when empty, it is deduced from the file extension (JSON by default).
+func ParseFormat(format, file string) (Format, error) {
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(file)), ".")
+		if format == "htm" {
+			format = string(FormatHTML)
+		}
+		if format != string(FormatCSV) && format != string(FormatHTML) {
+			format = string(FormatJSON)
+		}
+	}
+
+	switch Format(strings.ToLower(format)) {
+	case FormatJSON, FormatCSV, FormatHTML:
+		return Format(strings.ToLower(format)), nil
+	default:
+		return "", errors.Errorf("report format '%s' is not supported, use json, csv or html", format)
+	}
+}
+
+// WriteFile creates, or replaces, the file with the report.
+func WriteFile(file string, format Format, report *Report) error {
+	output, err := os.Create(file)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create report file %s", file)
+	}
+	defer output.Close()
+
+	err = Write(output, format, report)
+	return errors.Wrapf(err, "failed to write report file %s", file)
+}
+
+func Write(writer io.Writer, format Format, report *Report) error {
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+
+	case FormatCSV:
+		return writeCSV(writer, report)
+
+	case FormatHTML:
+		return htmlTemplate.Execute(writer, report)
+
+	default:
+		return errors.Errorf("report format '%s' is not supported", format)
+	}
+}
+
+// writeCSV flattens the report: the first column is the type of the record (album, skipped, or timing).
+func writeCSV(writer io.Writer, report *Report) error {
+	csvWriter := csv.NewWriter(writer)
+	records := [][]string{
+		{"record", "name", "path", "new", "count", "size", "reason", "details", "elapsed_ms", "busy_ms"},
+	}
+
+	for _, album := range report.Albums {
+		records = append(records, []string{"album", album.Name, album.Path, strconv.FormatBool(album.New), strconv.Itoa(album.Total.Count), strconv.Itoa(album.Total.Size), "", rejectsDetails(album.Rejects), "", ""})
+	}
+	for _, skipped := range report.Skipped {
+		records = append(records, []string{"skipped", skipped.Album, skipped.Path, "", "1", strconv.Itoa(skipped.Size), skipped.Reason, skipped.Details, "", ""})
+	}
+	for _, timing := range report.Timings {
+		records = append(records, []string{"timing", timing.Stage, "", "", strconv.Itoa(timing.Count), "", "", "", milliseconds(timing.Elapsed), milliseconds(timing.Busy)})
+	}
+
+	err := csvWriter.WriteAll(records)
+	return errors.Wrapf(err, "failed to write CSV")
+}
+
+func rejectsDetails(rejects int) string {
+	if rejects == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d rejects", rejects)
+}
+
+func milliseconds(duration time.Duration) string {
+	return strconv.FormatInt(duration.Milliseconds(), 10)
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"date": func(date *time.Time) string {
+		if date == nil {
+			return ""
+		}
+		return date.Format("2006-01-02")
+	},
+	"count": func(counter *Counter) string {
+		if counter == nil || counter.Count == 0 {
+			return "-"
+		}
+		return fmt.Sprintf("%d (%s)", counter.Count, byteCountIEC(counter.Size))
+	},
+	"total": func(counter Counter) string {
+		return fmt.Sprintf("%d (%s)", counter.Count, byteCountIEC(counter.Size))
+	},
+	"size": byteCountIEC,
+	"duration": func(duration time.Duration) string {
+		return duration.Round(time.Millisecond).String()
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>DPhoto {{ .Command }} of {{ .Volume }}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+th { background: #eee; }
+</style>
+</head>
+<body>
+<h1>DPhoto {{ .Command }} of {{ .Volume }}</h1>
+<p>Generated at {{ .GeneratedAt.Format "2006-01-02 15:04:05" }}</p>
+
+<h2>Albums</h2>
+<table>
+<tr><th>New</th><th>Name</th><th>Path</th><th>Start</th><th>End</th><th>Photos</th><th>Videos</th><th>Others</th><th>Total</th><th>Rejects</th></tr>
+{{- range .Albums }}
+<tr><td>{{ if .New }}*{{ end }}</td><td>{{ .Name }}</td><td>{{ .Path }}</td><td>{{ date .Start }}</td><td>{{ date .End }}</td><td>{{ count .Images }}</td><td>{{ count .Videos }}</td><td>{{ count .Others }}</td><td>{{ total .Total }}</td><td>{{ .Rejects }}</td></tr>
+{{- end }}
+</table>
+
+<h2>Skipped medias ({{ len .Skipped }})</h2>
+<table>
+<tr><th>Path</th><th>Size</th><th>Reason</th><th>Details</th><th>Album</th></tr>
+{{- range .Skipped }}
+<tr><td>{{ .Path }}</td><td>{{ size .Size }}</td><td>{{ .Reason }}</td><td>{{ .Details }}</td><td>{{ .Album }}</td></tr>
+{{- end }}
+</table>
+
+<h2>Timings</h2>
+<table>
+<tr><th>Stage</th><th>Medias</th><th>Elapsed</th><th>Busy</th></tr>
+{{- range .Timings }}
+<tr><td>{{ .Stage }}</td><td>{{ .Count }}</td><td>{{ duration .Elapsed }}</td><td>{{ duration .Busy }}</td></tr>
+{{- end }}
+</table>
+</body>
+</html>
+`))
+
+func byteCountIEC(b int) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+	div, exp := int64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB",
+		float64(b)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,117 @@
+package reportfile
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/thomasduchatelle/dphoto/pkg/backup"
+	"github.com/thomasduchatelle/dphoto/pkg/backup/chain"
+	"testing"
+	"time"
+)
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		file    string
+		want    Format
+		wantErr bool
+	}{
+		{"it should use the requested format", "CSV", "report.txt", FormatCSV, false},
+		{"it should deduce the format from the extension", "", "report.html", FormatHTML, false},
+		{"it should default to JSON", "", "report", FormatJSON, false},
+		{"it should reject unknown formats", "xml", "report.xml", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFormat(tt.format, tt.file)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else if assert.NoError(t, err) {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	details := backup.NewDetailedReport()
+	_ = details.OnRejectedMedia(context.TODO(), backup.NewInMemoryMedia("folder1/<file>.jpg", time.Now(), []byte("content")), backup.ErrAnalyserNoDateTime)
+	details.OnConsumed(context.TODO(), chain.Measure{Link: string(backup.StageAnalyse), Items: 3, Start: time.Unix(0, 0), End: time.Unix(2, 0)})
+
+	report := FromBackup("/mnt/volume", ReportStub{
+		"/2024-summer": backup.NewAlbumReport(backup.MediaTypeImage, 2, 2048, true),
+	}, details)
+
+	t.Run("it should write all the records in CSV", func(t *testing.T) {
+		buffer := new(bytes.Buffer)
+		if assert.NoError(t, Write(buffer, FormatCSV, report)) {
+			assert.Equal(t, `record,name,path,new,count,size,reason,details,elapsed_ms,busy_ms
+album,/2024-summer,,true,2,2048,,,,
+skipped,,/ram/folder1/<file>.jpg,,1,7,analysis-error,media must have a date time included in the metadata,,
+timing,analyse,,,3,,,,2000,2000
+`, buffer.String())
+		}
+	})
+
+	t.Run("it should write a JSON that can be read back", func(t *testing.T) {
+		buffer := new(bytes.Buffer)
+		if assert.NoError(t, Write(buffer, FormatJSON, report)) {
+			var got Report
+			if assert.NoError(t, json.Unmarshal(buffer.Bytes(), &got)) {
+				assert.Equal(t, "backup", got.Command)
+				assert.Equal(t, []Album{{Name: "/2024-summer", New: true, Images: &Counter{2, 2048}, Videos: &Counter{}, Others: &Counter{}, Total: Counter{2, 2048}}}, got.Albums)
+				assert.Equal(t, report.Skipped, got.Skipped)
+				assert.Equal(t, report.Timings, got.Timings)
+			}
+		}
+	})
+
+	t.Run("it should escape the values in HTML", func(t *testing.T) {
+		buffer := new(bytes.Buffer)
+		if assert.NoError(t, Write(buffer, FormatHTML, report)) {
+			assert.Contains(t, buffer.String(), "<td>/ram/folder1/&lt;file&gt;.jpg</td>")
+			assert.Contains(t, buffer.String(), "<td>2 (2.0 KiB)</td>")
+		}
+	})
+}
+
+func TestFromScan(t *testing.T) {
+	start := time.Date(2024, 7, 14, 0, 0, 0, 0, time.UTC)
+
+	got := FromScan("/mnt/volume", []*backup.ScannedFolder{
+		{
+			Name:         "summer",
+			RelativePath: "2024/summer",
+			Start:        start,
+			End:          start.Add(48 * time.Hour),
+			Distribution: map[string]backup.MediaCounter{"2024-07-14": {Count: 2, Size: 20}, "2024-07-15": {Count: 1, Size: 10}},
+			RejectsCount: 1,
+		},
+	}, nil)
+
+	end := start.Add(48 * time.Hour)
+	assert.Equal(t, []Album{{Name: "summer", Path: "2024/summer", Start: &start, End: &end, Total: Counter{3, 30}, Rejects: 1}}, got.Albums)
+	assert.Empty(t, got.Skipped)
+}
+
+type ReportStub map[string]*backup.AlbumReport
+
+func (r ReportStub) Skipped() backup.MediaCounter {
+	return backup.MediaCounterZero
+}
+
+func (r ReportStub) CountPerAlbum() map[string]*backup.AlbumReport {
+	return r
+}
+
+func (r ReportStub) FilteredOut() map[string]backup.MediaCounter {
+	return nil
+}
+
+func (r ReportStub) Quarantined() backup.MediaCounter {
+	return backup.MediaCounterZero
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"github.com/logrusorgru/aurora/v3"
+	"github.com/spf13/cobra"
+	"github.com/thomasduchatelle/dphoto/internal/printer"
+	"github.com/thomasduchatelle/dphoto/pkg/archiverestore"
+	"github.com/thomasduchatelle/dphoto/pkg/catalog"
+	"github.com/thomasduchatelle/dphoto/pkg/ownermodel"
+	"github.com/thomasduchatelle/dphoto/pkg/pkgfactory"
+	"os"
+)
+
+var (
+	restoreArgs = struct {
+		albums   []string
+		since    string
+		parallel int
+	}{}
+)
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore [--album <folder name>] [--since <ISO date>] <destination>",
+	Short: "Download originals from the archive into a local directory",
+	Long: `Download originals from the archive into a local directory, one sub-directory per album.
+
+Files are named after their original filename and their modification time is set to the capture date. The content is
+verified against its signature, and files already present in the destination are skipped: the command can be resumed.
+`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		request := archiverestore.RestoreRequest{
+			Owner:       ownermodel.Owner(Owner),
+			Destination: args[0],
+			Parallel:    restoreArgs.parallel,
+		}
+		for _, album := range restoreArgs.albums {
+			request.Albums = append(request.Albums, catalog.NewFolderName(album))
+		}
+
+		if restoreArgs.since != "" {
+			var err error
+			request.Since, err = parseDate(restoreArgs.since)
+			printer.FatalWithMessageIfError(err, 2, "--since must be a date in ISO format")
+		}
+
+		restorer := pkgfactory.ArchiveRestorer(ctx, archiverestore.RestoreObserverFunc(printRestoredMedia))
+		report, err := restorer.Restore(ctx, request)
+		printer.FatalWithMessageIfError(err, 1, "Restore failed for %s", Owner)
+
+		if len(report.Failed) > 0 {
+			printer.ErrorText("%d originals restored, %d already present, %d failed.", report.Restored, report.Skipped, len(report.Failed))
+			os.Exit(3)
+		}
+		printer.Success("%d originals restored in %s, %d were already present.", report.Restored, aurora.Cyan(request.Destination), report.Skipped)
+	},
+}
+
+func printRestoredMedia(ctx context.Context, restored archiverestore.RestoredMedia) {
+	switch restored.Status {
+	case archiverestore.RestoreStatusRestored:
+		fmt.Printf("%-10s %s\n", aurora.Green(restored.Status), restored.Path)
+	case archiverestore.RestoreStatusFailed:
+		fmt.Printf("%-10s %s: %s\n", aurora.Red(restored.Status), restored.Path, restored.Error)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(restoreCmd)
+
+	restoreCmd.Flags().StringSliceVarP(&restoreArgs.albums, "album", "a", nil, "folder name of the album to restore, can be repeated (default: all albums)")
+	restoreCmd.Flags().StringVar(&restoreArgs.since, "since", "", "only restore medias taken from this date (YYYY-MM-DD)")
+	restoreCmd.Flags().IntVarP(&restoreArgs.parallel, "parallel", "p", archiverestore.DefaultParallel, "number of concurrent downloads")
+}
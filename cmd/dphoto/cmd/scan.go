@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"github.com/logrusorgru/aurora/v3"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	"github.com/thomasduchatelle/dphoto/cmd/dphoto/cmd/reportfile"
 	"github.com/thomasduchatelle/dphoto/cmd/dphoto/cmd/scanui"
 	"github.com/thomasduchatelle/dphoto/cmd/dphoto/cmd/ui"
 	"github.com/thomasduchatelle/dphoto/internal/printer"
@@ -19,6 +21,10 @@ var (
 		nonInteractive bool
 		skipRejects    bool
 		noCache        bool
+		groupBy        string
+		reportFormat   string
+		reportFile     string
+		savePlan       string
 	}{}
 )
 
@@ -32,13 +38,43 @@ var scan = &cobra.Command{
 		volume := args[0]
 
 		smartVolume, err := newSmartVolume(volume)
+		printer.FatalIfError(err, 1)
+
+		rulesOptions, err := backupRulesOptions(volume)
+		printer.FatalWithMessageIfError(err, 3, "Backup rules are invalid")
+
+		reportFormat, err := parseReportFormat(scanArgs.reportFormat, scanArgs.reportFile)
+		printer.FatalWithMessageIfError(err, 3, "--report-format is invalid")
+		details := backup.NewDetailedReport()
+
 		options := backup.ReduceOptions(
 			backup.OptionsSkipRejects(scanArgs.skipRejects),
 			backup.OptionsAnalyserDecorator(addCacheAnalysis(!scanArgs.noCache)),
+			rulesOptions,
 		)
-		recordRepository, err := scanui.ScanWithProgress(Owner, smartVolume, options)
+		switch scanArgs.groupBy {
+		case "folders":
+		case "events":
+			options = backup.ReduceOptions(options, backup.OptionsGroupByEvents(backup.DefaultEventClusteringParameters()))
+		default:
+			printer.FatalWithMessageIfError(errors.Errorf("'%s' is not supported", scanArgs.groupBy), 3, "--group-by must be 'folders' or 'events'")
+		}
+		plan := backup.NewPlan(ownermodel.Owner(Owner), volume)
+		if scanArgs.savePlan != "" {
+			options = backup.ReduceOptions(options, backup.OptionsRecordPlan(plan))
+		}
+		folders, err := scanui.ScanWithProgress(Owner, smartVolume, options, backup.OptionsWithDetailedReport(details))
 		printer.FatalIfError(err, 2)
 
+		if scanArgs.savePlan != "" {
+			err = savePlan(scanArgs.savePlan, plan)
+			printer.FatalWithMessageIfError(err, 2, "Plan couldn't be saved")
+			printer.Success("Plan to backup %d medias written in %s, execute it with 'dphoto backup --plan %s'", len(plan.Medias), aurora.Cyan(scanArgs.savePlan), scanArgs.savePlan)
+		}
+
+		writeReportFile(scanArgs.reportFile, reportFormat, reportfile.FromScan(volume, folders, details))
+		recordRepository := scanui.NewSuggestionRepository(Owner, folders)
+
 		if recordRepository.Count() == 0 {
 			fmt.Println(aurora.Yellow(fmt.Sprintf("No new media found on volume %s.", aurora.Cyan(volume))))
 		} else if scanArgs.nonInteractive {
@@ -60,6 +96,10 @@ func init() {
 	scan.Flags().BoolVarP(&scanArgs.nonInteractive, "non-interactive", "I", false, "Disable interactive output and only display the scan results.")
 	scan.Flags().BoolVarP(&scanArgs.skipRejects, "skip-errors", "s", false, "Unreadable files, or files without date, will be reported as 'rejects' and printed in rejected file.")
 	scan.Flags().BoolVarP(&scanArgs.noCache, "no-cache", "c", false, "set to true to ignore cache (and not building it)")
+	scan.Flags().StringVar(&scanArgs.reportFormat, "report-format", "", "format of the report file: json, csv, or html (default: deduced from --report-file extension)")
+	scan.Flags().StringVar(&scanArgs.reportFile, "report-file", "", "write a full report (folders, skipped files and reasons, timings) into this file")
+	scan.Flags().StringVar(&scanArgs.savePlan, "save-plan", "", "save the medias that would be backed up, and their albums, into this file ; execute it later with 'backup --plan'")
+	scan.Flags().StringVar(&scanArgs.groupBy, "group-by", "folders", "'folders' suggests an album per directory, 'events' splits medias on time gaps and distance (flat camera rolls)")
 }
 
 type uiCatalogAdapter struct {
@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"github.com/logrusorgru/aurora/v3"
-	"github.com/thomasduchatelle/dphoto/cmd/dphoto/cmd/ui"
 	"github.com/thomasduchatelle/dphoto/cmd/dphoto/config"
 	"github.com/thomasduchatelle/dphoto/internal/screen"
 	"github.com/thomasduchatelle/dphoto/pkg/backup"
@@ -18,17 +17,17 @@ type ScanProgress struct {
 	onAnalysedCalled bool
 }
 
-func ScanWithProgress(owner string, volume backup.SourceVolume, options ...backup.Options) (ui.SuggestionRecordRepositoryPort, error) {
-	suggestions, err := doScan(owner, volume, options...)
-	return NewSuggestionRepository(owner, suggestions), err
-}
-
-func doScan(owner string, volume backup.SourceVolume, options ...backup.Options) ([]*backup.ScannedFolder, error) {
+// ScanWithProgress scans the volume while displaying the progress ; use NewSuggestionRepository to display the results.
+func ScanWithProgress(owner string, volume backup.SourceVolume, options ...backup.Options) ([]*backup.ScannedFolder, error) {
 	ctx := context.TODO()
 
 	progress := newScanProgress()
 	options = append(options, backup.OptionsWithListener(progress))
-	options = append(options, config.BackupOptions()...)
+	configOptions, err := config.BackupOptions()
+	if err != nil {
+		return nil, err
+	}
+	options = append(options, configOptions...)
 
 	scanner := pkgfactory.NewMultiFilesScanner(ctx)
 	suggestions, err := scanner(ctx, owner, volume, options...)
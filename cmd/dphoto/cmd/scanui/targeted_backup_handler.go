@@ -53,7 +53,11 @@ func (t *TargetedBackupHandler) BackupSuggestion(record *ui.SuggestionRecord, ex
 			backup.OptionsWithListener(listener),
 			t.ScanOptions,
 		}
-		options = append(options, config.BackupOptions()...)
+		configOptions, err := config.BackupOptions()
+		if err != nil {
+			return err
+		}
+		options = append(options, configOptions...)
 
 		if existing != nil {
 			options = append(options, backup.OptionsOnlyAlbums(existing.FolderName))
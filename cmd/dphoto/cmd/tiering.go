@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/logrusorgru/aurora/v3"
+	"github.com/spf13/cobra"
+	"github.com/thomasduchatelle/dphoto/internal/printer"
+	"github.com/thomasduchatelle/dphoto/pkg/archive"
+	"github.com/thomasduchatelle/dphoto/pkg/ownermodel"
+	"github.com/thomasduchatelle/dphoto/pkg/pkgfactory"
+	"sort"
+	"time"
+)
+
+var (
+	tieringArgs = struct {
+		coldAfterMonths int
+		storageClass    string
+		dryRun          bool
+	}{}
+)
+
+var tieringCmd = &cobra.Command{
+	Use:   "tiering [--cold-after-months 12] [--storage-class GLACIER] [--dry-run]",
+	Short: "Move the originals of old albums to a cold storage class",
+	Long: `Move the originals of albums that ended more than N months ago to a cold (cheaper) storage class.
+
+Originals in GLACIER or DEEP_ARCHIVE classes are not immediately downloadable: the first download request triggers
+a restore, and the original is available for some days once the restore is complete (hours later).
+`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		albums, err := pkgfactory.AlbumQueries(ctx).FindAlbumsByOwner(ctx, ownermodel.Owner(Owner))
+		printer.FatalWithMessageIfError(err, 1, "Failed to list albums of %s", Owner)
+
+		tieringAlbums := make([]archive.TieringAlbum, len(albums))
+		for i, album := range albums {
+			tieringAlbums[i] = archive.TieringAlbum{
+				FolderName: string(album.FolderName),
+				End:        album.End,
+			}
+		}
+
+		coldAfter := time.Duration(tieringArgs.coldAfterMonths) * 30 * 24 * time.Hour
+		policy := pkgfactory.ArchiveTieringPolicy(ctx, coldAfter, tieringArgs.storageClass, tieringArgs.dryRun)
+		report, err := policy.Apply(Owner, tieringAlbums)
+		printer.FatalWithMessageIfError(err, 2, "Tiering failed for %s", Owner)
+
+		folders := make([]string, 0, len(report))
+		total := 0
+		for folder, count := range report {
+			folders = append(folders, folder)
+			total += count
+		}
+		sort.Strings(folders)
+		for _, folder := range folders {
+			fmt.Printf("%-40s %d\n", folder, report[folder])
+		}
+
+		if tieringArgs.dryRun {
+			printer.Info("%d originals would be moved to %s storage class", total, aurora.Cyan(tieringArgs.storageClass))
+		} else {
+			printer.Success("%d originals have been moved to %s storage class", total, aurora.Cyan(tieringArgs.storageClass))
+		}
+	},
+}
+
+func init() {
+	opsCmd.AddCommand(tieringCmd)
+
+	tieringCmd.Flags().IntVar(&tieringArgs.coldAfterMonths, "cold-after-months", 12, "age of the album, in months since its end, after which originals are moved to cold storage")
+	tieringCmd.Flags().StringVar(&tieringArgs.storageClass, "storage-class", "GLACIER", "S3 storage class used for cold originals (GLACIER_IR, GLACIER, DEEP_ARCHIVE)")
+	tieringCmd.Flags().BoolVar(&tieringArgs.dryRun, "dry-run", false, "only list the originals that would be moved")
+}
@@ -75,6 +75,23 @@ func (f *FormUseCase) ReadBool(label string, notation string) (bool, bool) {
 		return false, false
 	}
 
+	return parseBool(value)
+}
+
+// ReadConfirmation reads a boolean defaulting to false [y/N] ; an error is returned when the answer couldn't be read.
+func (f *FormUseCase) ReadConfirmation(label string) (bool, error) {
+	f.TerminalPort.Print(fmt.Sprintf("%s%s: ", aurora.Bold(aurora.White(label)), aurora.Gray(12, " [y/N]")))
+
+	value, err := f.TerminalPort.ReadAnswer()
+	if err != nil && strings.TrimSpace(value) == "" {
+		return false, errors.Wrapf(err, "failed to read the answer to '%s'", label)
+	}
+
+	confirmed, _ := parseBool(value)
+	return confirmed, nil
+}
+
+func parseBool(value string) (bool, bool) {
 	answer := strings.ToLower(strings.Trim(strings.TrimSuffix(value, "\n"), " "))
 	switch answer {
 	case "yes", "oui", "true", "y", "o", "1":
@@ -0,0 +1,48 @@
+package ui
+
+import (
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"testing"
+)
+
+func TestFormUseCase_ReadConfirmation(t *testing.T) {
+	tests := []struct {
+		name    string
+		answer  string
+		readErr error
+		want    bool
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{"it should confirm when the answer is yes", "y\n", nil, true, assert.NoError},
+		{"it should not confirm when the answer is no", "n\n", nil, false, assert.NoError},
+		{"it should not confirm when the answer is empty", "\n", nil, false, assert.NoError},
+		{"it should not confirm when the answer is not a boolean", "maybe\n", nil, false, assert.NoError},
+		{"it should accept the last answer without a new line", "yes", io.EOF, true, assert.NoError},
+		{"it should return the error when the answer couldn't be read", "", io.EOF, false, assert.Error},
+		{"it should return the error when the terminal failed", "", errors.New("TEST terminal failed"), false, assert.Error},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := &FormUseCase{TerminalPort: &terminalStub{answer: tt.answer, err: tt.readErr}}
+
+			got, err := form.ReadConfirmation("Delete?")
+			if tt.wantErr(t, err) {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+type terminalStub struct {
+	answer string
+	err    error
+}
+
+func (t *terminalStub) Print(question string) {}
+
+func (t *terminalStub) ReadAnswer() (string, error) {
+	return t.answer, t.err
+}
@@ -3,11 +3,13 @@ package config
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 	"github.com/thomasduchatelle/dphoto/internal/printer"
+	"github.com/thomasduchatelle/dphoto/pkg/archiveadapters/s3store"
 	"github.com/thomasduchatelle/dphoto/pkg/awssupport/awsfactory"
 	"github.com/thomasduchatelle/dphoto/pkg/pkgfactory"
 	"os"
@@ -87,6 +89,28 @@ func Connect(ignite, createConfigIfNotExist bool) (pkgfactory.Factory, error) {
 			}))
 		}
 
+		var replicas []pkgfactory.ArchiveReplicaConfig
+		err = viper.UnmarshalKey(ArchiveReplicas, &replicas)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid configuration for %s", ArchiveReplicas)
+		}
+		builder.WithArchiveReplicas(replicas...)
+
+		if masterKey := viper.GetString(ArchiveEncryptionMasterKey); masterKey != "" {
+			decoded, err := base64.StdEncoding.DecodeString(masterKey)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%s must be encoded in base 64", ArchiveEncryptionMasterKey)
+			}
+			builder.WithArchiveEncryption(decoded)
+		}
+
+		builder.WithArchiveMultipartUploads(s3store.MultipartOptions{
+			PartSize:    int64(viper.GetSizeInBytes(ArchiveUploadPartSize)),
+			Concurrency: viper.GetInt(ArchiveUploadConcurrency),
+			StaleAfter:  viper.GetDuration(ArchiveUploadStaleAfter),
+			States:      s3store.NewFileUploadStates(uploadStatesDirectory()),
+		})
+
 		factory, err := builder.Build(ctx)
 		if err != nil {
 			return nil, err
@@ -107,3 +131,10 @@ func Connect(ignite, createConfigIfNotExist bool) (pkgfactory.Factory, error) {
 
 	return nil, nil
 }
+
+// uploadStatesDirectory is in the backup cache so an interrupted upload is resumed by the next backup.
+func uploadStatesDirectory() string {
+	config := &viperConfig{Viper: viper.GetViper()}
+	defaultCacheDir := path.Join(config.GetStringOrDefault(LocalHome, os.ExpandEnv("$HOME/.dphoto")), "cache")
+	return path.Join(config.GetStringOrDefault(BackupCacheDirectory, defaultCacheDir), "uploads")
+}
@@ -1,13 +1,90 @@
 package config
 
 import (
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
 	"github.com/thomasduchatelle/dphoto/pkg/backup"
+	"github.com/thomasduchatelle/dphoto/pkg/backupadapters/backuprules"
+	"github.com/thomasduchatelle/dphoto/pkg/bandwidth"
+	"reflect"
+	"time"
 )
 
-func BackupOptions() []backup.Options {
-	return []backup.Options{
+func BackupOptions() ([]backup.Options, error) {
+	options := []backup.Options{
 		backup.OptionsConcurrentAnalyserRoutines(config.GetIntOrDefault(BackupConcurrencyAnalyser, 4)),
 		backup.OptionsConcurrentCataloguerRoutines(config.GetIntOrDefault(BackupConcurrencyCataloguer, 2)),
 		backup.OptionsConcurrentUploaderRoutines(config.GetIntOrDefault(BackupConcurrencyUploader, 2)),
 	}
+
+	parameters, err := readBackupBandwidth()
+	if err != nil {
+		return nil, err
+	}
+	if parameters != nil {
+		options = append(options, backup.OptionsWithBandwidth(*parameters))
+	}
+
+	zone, err := ReadBackupTimeZone()
+	if err != nil {
+		return nil, err
+	}
+	options = append(options, backup.OptionsWithDefaultTimeZone(zone))
+
+	return options, nil
+}
+
+// ReadBackupTimeZone returns the zone in which the camera clock is set ; it's the zone of the computer when none is configured.
+func ReadBackupTimeZone() (*time.Location, error) {
+	name := config.GetStringOrDefault(BackupTimeZone, "")
+	if name == "" {
+		return time.Local, nil
+	}
+
+	zone, err := time.LoadLocation(name)
+	return zone, errors.Wrapf(err, "invalid configuration for %s", BackupTimeZone)
+}
+
+// readBackupBandwidth returns nil when no limit is configured.
+func readBackupBandwidth() (*backup.BandwidthParameters, error) {
+	if !viper.IsSet(BackupBandwidth) {
+		return nil, nil
+	}
+
+	var bandwidthConfig bandwidth.Config
+	err := viper.UnmarshalKey(BackupBandwidth, &bandwidthConfig)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid configuration for %s", BackupBandwidth)
+	}
+
+	global, perRoutine, err := bandwidthConfig.Schedules()
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid configuration for %s", BackupBandwidth)
+	}
+
+	return &backup.BandwidthParameters{Global: global, PerRoutine: perRoutine}, nil
+}
+
+// ReadBackupRules reads the rules deciding which medias are backed up.
+func ReadBackupRules() (backuprules.Rules, error) {
+	var rules backuprules.Rules
+	err := viper.UnmarshalKey(BackupRules, &rules, viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+		yamlDateToStringHookFunc,
+	)))
+	return rules, errors.Wrapf(err, "invalid configuration for %s", BackupRules)
+}
+
+// yamlDateToStringHookFunc keeps dates written without quotes in YAML as strings (YAML parser is converting them into time.Time).
+func yamlDateToStringHookFunc(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+	if date, isTime := data.(time.Time); isTime && to.Kind() == reflect.String {
+		if date.Equal(date.Truncate(24 * time.Hour)) {
+			return date.Format("2006-01-02"), nil
+		}
+		return date.Format(time.RFC3339), nil
+	}
+
+	return data, nil
 }
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"github.com/thomasduchatelle/dphoto/cmd/dphoto/daemon"
+	"os"
+	"path"
+)
+
+// DaemonConfig reads the configuration of the daemon watching folders and removable volumes.
+func DaemonConfig() (daemon.Config, error) {
+	stableDelay := viper.GetDuration(DaemonStableDelay)
+	if stableDelay == 0 {
+		stableDelay = daemon.DefaultStableDelay
+	}
+
+	batchDelay := daemon.DefaultBatchDelay
+	if viper.IsSet(DaemonBatchDelay) {
+		batchDelay = viper.GetDuration(DaemonBatchDelay)
+	}
+
+	var volumes []daemon.VolumeConfig
+	err := viper.UnmarshalKey(DaemonVolumes, &volumes)
+	if err != nil {
+		return daemon.Config{}, errors.Wrapf(err, "invalid configuration for %s", DaemonVolumes)
+	}
+
+	return daemon.Config{
+		Owner:         config.GetString(Owner),
+		Directories:   viper.GetStringSlice(DaemonDirectories),
+		Volumes:       volumes,
+		StableDelay:   stableDelay,
+		BatchDelay:    batchDelay,
+		StateFile:     path.Join(config.GetStringOrDefault(LocalHome, os.ExpandEnv("$HOME/.dphoto")), "daemon", "state.json"),
+		StatusAddress: DaemonStatusAddressOrDefault(),
+	}, nil
+}
+
+func DaemonStatusAddressOrDefault() string {
+	return config.GetStringOrDefault(DaemonStatusAddress, daemon.DefaultStatusAddress)
+}
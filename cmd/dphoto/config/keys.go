@@ -10,11 +10,24 @@ const (
 	ArchiveCacheBucketName      = "archive.cache.bucketName"
 	ArchiveJobsSNSARN           = "archive.sns.arn"
 	ArchiveJobsSQSURL           = "archive.sqs.url"
+	ArchiveReplicas             = "archive.replicas"                   // ArchiveReplicas is a list of secondary storages (see pkgfactory.ArchiveReplicaConfig)
+	ArchiveEncryptionMasterKey  = "archive.encryption.masterKeyBase64" // ArchiveEncryptionMasterKey enables client-side encryption of originals ; 32 bytes encoded in base 64
+	ArchiveUploadPartSize       = "archive.upload.partSize"            // ArchiveUploadPartSize is the size of each part of a multipart upload, like "16MB" (minimum 5MB)
+	ArchiveUploadConcurrency    = "archive.upload.concurrency"         // ArchiveUploadConcurrency is the number of parts of a file uploaded in parallel
+	ArchiveUploadStaleAfter     = "archive.upload.staleAfter"          // ArchiveUploadStaleAfter is the age after which an interrupted upload is aborted instead of resumed (duration)
+	BackupBandwidth             = "backup.bandwidth"                   // BackupBandwidth limits the upload rate (see bandwidth.Config)
 	BackupCacheDirectory        = "backup.cache.dir"
 	BackupConcurrencyAnalyser   = "backup.concurrency.analyser"
 	BackupConcurrencyCataloguer = "backup.concurrency.cataloguer"
 	BackupConcurrencyUploader   = "backup.concurrency.uploader"
+	BackupRules                 = "backup.rules"    // BackupRules are the rules deciding which medias are backed up (see backuprules.Rules)
+	BackupTimeZone              = "backup.timezone" // BackupTimeZone is the zone of the medias without time zone nor GPS coordinates (ex: 'Europe/London') ; default to the zone of the computer
 	CatalogDynamodbTable        = "catalog.dynamodb.table"
+	DaemonDirectories           = "daemon.directories"   // DaemonDirectories are the local directories watched by 'dphoto daemon'
+	DaemonStableDelay           = "daemon.stableDelay"   // DaemonStableDelay is how long a file must not change before being backed up (duration)
+	DaemonBatchDelay            = "daemon.batchDelay"    // DaemonBatchDelay is how long the daemon waits for more files before starting a backup (duration)
+	DaemonStatusAddress         = "daemon.statusAddress" // DaemonStatusAddress is the local address of the status endpoint
+	DaemonVolumes               = "daemon.volumes"       // DaemonVolumes are the removable volumes backed up when mounted (see daemon.VolumeConfig)
 	LocalHome                   = "home.dir"
 	Owner                       = "owner"
 )
@@ -1,5 +0,0 @@
-package adapters
-
-import (
-	_ "github.com/thomasduchatelle/dphoto/dphoto/daemon/adapters/backup_adapter"
-)
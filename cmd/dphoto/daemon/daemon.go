@@ -0,0 +1,493 @@
+// Package daemon watches local directories and backs up new files as soon as they are stable ; it also backs up removable volumes when they are mounted.
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"github.com/fsnotify/fsnotify"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/thomasduchatelle/dphoto/pkg/backup"
+	"github.com/thomasduchatelle/dphoto/pkg/backupadapters/filesystemvolume"
+	"github.com/thomasduchatelle/dphoto/pkg/ownermodel"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultStableDelay = 10 * time.Second
+	DefaultBatchDelay  = 30 * time.Second
+
+	defaultTickInterval = time.Second
+	retryDelay          = 5 * time.Minute
+)
+
+type Config struct {
+	Owner         string          // Owner is used when the volume doesn't define one
+	Directories   []string        // Directories are watched recursively (hidden sub-directories are ignored)
+	Volumes       []VolumeConfig  // Volumes are backed up each time they are mounted
+	MountInfo     MountInfoSource // MountInfo is /proc/self/mountinfo by default
+	ResolveUUID   UUIDResolver    // ResolveUUID is using /dev/disk/by-uuid by default
+	Progress      io.Writer       // Progress receives the progress of the backups of volumes (optional)
+	StableDelay   time.Duration   // StableDelay is how long a file must not change before being queued
+	BatchDelay    time.Duration   // BatchDelay is how long the queue waits for more files before starting a backup
+	StateFile     string          // StateFile is where the queue and the history are persisted
+	StatusAddress string          // StatusAddress is the local address of the status endpoint ; disabled when empty
+}
+
+// BackupFunc backs up the medias of the volume.
+type BackupFunc func(ctx context.Context, owner ownermodel.Owner, volume backup.SourceVolume, options ...backup.Options) (backup.Report, error)
+
+type Daemon struct {
+	config       Config
+	backup       BackupFunc
+	now          func() time.Time
+	tickInterval time.Duration
+
+	lock          sync.Mutex
+	state         *State
+	tracker       *stabilityTracker
+	volumeWatcher *volumeWatcher // volumeWatcher is nil when no volume is configured
+	volumeQueue   []MountedVolume
+	lastQueued    time.Time
+	retryAfter    time.Time
+	runningSince  time.Time
+	running       *backupJob
+}
+
+// backupJob is either a batch of files from a watched directory, or a whole mounted volume.
+type backupJob struct {
+	directory string
+	files     []string       // files are nil when the whole directory is backed up
+	volume    *MountedVolume // volume is set when backing up a removable volume
+}
+
+type runResult struct {
+	job    backupJob
+	status RunStatus
+	errors []ErrorStatus
+}
+
+func New(config Config, backupFunc BackupFunc) (*Daemon, error) {
+	if len(config.Directories) == 0 && len(config.Volumes) == 0 {
+		return nil, errors.Errorf("at least one directory to watch, or one volume, is required")
+	}
+	for i, directory := range config.Directories {
+		absolute, err := filepath.Abs(directory)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid directory %s", directory)
+		}
+		config.Directories[i] = absolute
+	}
+	if config.StableDelay == 0 {
+		config.StableDelay = DefaultStableDelay
+	}
+
+	state, err := readState(config.StateFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.Progress == nil {
+		config.Progress = io.Discard
+	}
+
+	daemon := &Daemon{
+		config:       config,
+		backup:       backupFunc,
+		now:          time.Now,
+		tickInterval: defaultTickInterval,
+		state:        state,
+		tracker:      newStabilityTracker(config.StableDelay),
+	}
+
+	if len(config.Volumes) > 0 {
+		for _, volume := range config.Volumes {
+			if volume.UUID == "" {
+				return nil, errors.Errorf("volume %s must have a UUID", volume)
+			}
+		}
+
+		source, resolveUUID := config.MountInfo, config.ResolveUUID
+		if source == nil {
+			source = ProcMountInfo
+		}
+		if resolveUUID == nil {
+			resolveUUID = NewDevDiskUUIDResolver()
+		}
+		daemon.volumeWatcher = newVolumeWatcher(source, resolveUUID, config.Volumes)
+	}
+
+	return daemon, nil
+}
+
+// Run watches the directories until the context is cancelled ; a backup in progress is completed before returning.
+func (d *Daemon) Run(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return errors.Wrapf(err, "failed to create file watcher")
+	}
+	defer watcher.Close()
+
+	for _, directory := range d.config.Directories {
+		err = d.watchRecursively(watcher, directory)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = d.catchUp()
+	if err != nil {
+		return err
+	}
+
+	if d.config.StatusAddress != "" {
+		stopServer, err := d.serveStatus(d.config.StatusAddress)
+		if err != nil {
+			return err
+		}
+		defer stopServer()
+	}
+
+	log.WithFields(log.Fields{"Directories": d.config.Directories, "Volumes": len(d.config.Volumes)}).Infoln("Daemon started")
+
+	ticker := time.NewTicker(d.tickInterval)
+	defer ticker.Stop()
+
+	results := make(chan runResult, 1)
+	for {
+		select {
+		case <-ctx.Done():
+			if d.isRunning() {
+				d.complete(<-results)
+			}
+			log.Infoln("Daemon stopped")
+			return d.save()
+
+		case event, ok := <-watcher.Events:
+			if ok {
+				d.onEvent(watcher, event)
+			}
+
+		case err, ok := <-watcher.Errors:
+			if ok {
+				d.recordError("", err)
+			}
+
+		case result := <-results:
+			d.complete(result)
+
+		case <-ticker.C:
+			d.tick(ctx, results)
+		}
+	}
+}
+
+// catchUp queues the files changed while the daemon was stopped, and all files the first time it starts.
+func (d *Daemon) catchUp() error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	for _, directory := range d.config.Directories {
+		medias, err := filesystemvolume.New(directory).FindMedias(context.Background())
+		if err != nil {
+			return errors.Wrapf(err, "failed to list the files of %s", directory)
+		}
+
+		for _, media := range medias {
+			if media.LastModification().After(d.state.UpdatedAt) {
+				d.state.enqueue(media.String())
+				d.lastQueued = d.now()
+			}
+		}
+	}
+
+	return d.saveLocked()
+}
+
+func (d *Daemon) watchRecursively(watcher *fsnotify.Watcher, directory string) error {
+	return filepath.WalkDir(directory, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+		if path != directory && strings.HasPrefix(entry.Name(), ".") {
+			return filepath.SkipDir
+		}
+
+		return errors.Wrapf(watcher.Add(path), "failed to watch %s", path)
+	})
+}
+
+func (d *Daemon) onEvent(watcher *fsnotify.Watcher, event fsnotify.Event) {
+	if strings.HasPrefix(filepath.Base(event.Name), ".") {
+		return
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	switch {
+	case event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename):
+		d.tracker.Forget(event.Name)
+		if slices.Contains(d.state.Queue, event.Name) {
+			d.state.dequeue(event.Name)
+			d.logIfError(d.saveLocked())
+		}
+
+	case event.Has(fsnotify.Create) || event.Has(fsnotify.Write):
+		if !isDirectory(event.Name) {
+			d.tracker.Touch(event.Name, d.now())
+			return
+		}
+
+		// files might have been created before the directory is watched
+		err := d.watchRecursively(watcher, event.Name)
+		if err != nil {
+			d.recordErrorLocked(event.Name, err)
+		}
+		_ = filepath.WalkDir(event.Name, func(path string, entry fs.DirEntry, err error) error {
+			if err == nil && !entry.IsDir() {
+				d.tracker.Touch(path, d.now())
+			}
+			return nil
+		})
+	}
+}
+
+// tick queues the stable files, and the mounted volumes, then starts a backup if none is running ; volumes have priority.
+func (d *Daemon) tick(ctx context.Context, results chan runResult) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	now := d.now()
+	if stable := d.tracker.Stable(now); len(stable) > 0 {
+		d.state.enqueue(stable...)
+		d.lastQueued = now
+		d.logIfError(d.saveLocked())
+	}
+	d.pollVolumes()
+
+	if d.running != nil {
+		return
+	}
+
+	job, ok := d.nextJob(now)
+	if !ok {
+		return
+	}
+
+	d.running, d.runningSince = &job, now
+	go func() {
+		// a backup is never interrupted: the daemon waits for it to complete before stopping
+		results <- d.runBackup(context.WithoutCancel(ctx), job)
+	}()
+}
+
+// pollVolumes queues the volumes that have just been mounted.
+func (d *Daemon) pollVolumes() {
+	if d.volumeWatcher == nil {
+		return
+	}
+
+	mounted, unmounted, err := d.volumeWatcher.Poll()
+	if err != nil {
+		log.WithError(err).Warnln("Mounted volumes couldn't be read")
+		return
+	}
+
+	for _, volume := range mounted {
+		log.WithFields(log.Fields{"Volume": volume.String(), "MountPoint": volume.MountPoint}).Infoln("Volume mounted")
+		_, _ = fmt.Fprintf(d.config.Progress, "[%s] mounted on %s, backup queued\n", volume, volume.MountPoint)
+		d.volumeQueue = append(d.volumeQueue, volume)
+	}
+	for _, volume := range unmounted {
+		log.WithFields(log.Fields{"Volume": volume.String(), "MountPoint": volume.MountPoint}).Infoln("Volume unmounted")
+		d.volumeQueue = slices.DeleteFunc(d.volumeQueue, func(queued MountedVolume) bool {
+			return queued.MountPoint == volume.MountPoint
+		})
+	}
+}
+
+func (d *Daemon) nextJob(now time.Time) (backupJob, bool) {
+	if len(d.volumeQueue) > 0 {
+		volume := d.volumeQueue[0]
+		d.volumeQueue = d.volumeQueue[1:]
+		return backupJob{directory: volume.Root(), volume: &volume}, true
+	}
+
+	if len(d.state.Queue) == 0 || now.Sub(d.lastQueued) < d.config.BatchDelay || now.Before(d.retryAfter) {
+		return backupJob{}, false
+	}
+
+	directory, files := d.nextBatch()
+	return backupJob{directory: directory, files: files}, len(files) > 0
+}
+
+// nextBatch returns the queued files of the first directory having some ; files outside the watched directories are discarded.
+func (d *Daemon) nextBatch() (string, []string) {
+	for _, directory := range d.config.Directories {
+		var files []string
+		for _, file := range d.state.Queue {
+			if strings.HasPrefix(file, directory+string(filepath.Separator)) {
+				files = append(files, file)
+			}
+		}
+
+		if len(files) > 0 {
+			return directory, files
+		}
+	}
+
+	log.WithField("Files", d.state.Queue).Warnln("Queued files are not in a watched directory, they are discarded")
+	d.state.Queue = nil
+	return "", nil
+}
+
+func (d *Daemon) runBackup(ctx context.Context, job backupJob) runResult {
+	result := runResult{
+		job:    job,
+		status: RunStatus{Directory: job.directory, Start: d.now(), Files: len(job.files)},
+	}
+	log.WithFields(log.Fields{"Directory": job.directory, "Files": len(job.files)}).Infoln("Backup started")
+
+	owner := d.config.Owner
+	details := backup.NewDetailedReport()
+	options := []backup.Options{backup.OptionsSkipRejects(true), backup.OptionsWithDetailedReport(details)}
+
+	var volume backup.SourceVolume = filesystemvolume.NewFiles(job.directory, job.files)
+	if job.volume != nil {
+		result.status.Volume = job.volume.String()
+		if job.volume.Owner != "" {
+			owner = job.volume.Owner
+		}
+
+		volume = &recordingVolume{SourceVolume: filesystemvolume.New(job.directory)}
+		progress := newLineProgress(d.config.Progress, fmt.Sprintf("[%s]", job.volume))
+		options = append(options, backup.OptionsWithListener(progress), backup.OptionsWithRejectDir(job.volume.RejectDir))
+	}
+
+	report, err := d.backup(ctx, ownermodel.Owner(owner), volume, options...)
+	result.status.End = d.now()
+	if err != nil {
+		result.status.Error = err.Error()
+		return result
+	}
+
+	for _, album := range report.CountPerAlbum() {
+		result.status.BackedUp += album.Total().Count
+	}
+	for _, skipped := range details.SkippedMedias() {
+		if skipped.Reason == backup.SkipReasonAnalysisError {
+			result.errors = append(result.errors, ErrorStatus{Time: result.status.End, Path: skipped.Path, Error: skipped.Details})
+		}
+	}
+
+	if recording, isVolume := volume.(*recordingVolume); isVolume {
+		result.status.Files = len(recording.found)
+		if job.volume.DeleteAfterBackup {
+			deleted, failures := deleteBackedUpMedias(recording.found, details)
+			result.status.Deleted = deleted
+			for _, failure := range failures {
+				failure.Time = d.now()
+				result.errors = append(result.errors, failure)
+			}
+		}
+		_, _ = fmt.Fprintf(d.config.Progress, "[%s] backup complete: %d backed up, %d deleted from the volume\n", job.volume, result.status.BackedUp, result.status.Deleted)
+	}
+
+	return result
+}
+
+func (d *Daemon) complete(result runResult) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.running = nil
+	d.state.addRun(result.status)
+
+	switch {
+	case result.status.Error != "" && result.job.volume != nil:
+		// volumes are not retried: they might have been unmounted, they will be backed up next time they are mounted
+		log.WithField("Volume", result.status.Volume).Errorf("Backup failed: %s", result.status.Error)
+		_, _ = fmt.Fprintf(d.config.Progress, "[%s] backup failed: %s\n", result.job.volume, result.status.Error)
+		d.state.addError(ErrorStatus{Time: result.status.End, Path: result.job.directory, Error: result.status.Error})
+
+	case result.status.Error != "":
+		log.WithField("Directory", result.status.Directory).Errorf("Backup failed: %s", result.status.Error)
+		d.state.addError(ErrorStatus{Time: result.status.End, Error: result.status.Error})
+		d.retryAfter = d.now().Add(retryDelay)
+
+	default:
+		log.WithFields(log.Fields{"Directory": result.status.Directory, "BackedUp": result.status.BackedUp}).Infoln("Backup completed")
+		d.state.dequeue(result.job.files...)
+		if result.job.volume == nil {
+			d.retryAfter = time.Time{}
+		}
+	}
+	for _, errorStatus := range result.errors {
+		d.state.addError(errorStatus)
+	}
+
+	d.logIfError(d.saveLocked())
+}
+
+func (d *Daemon) recordError(path string, err error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.recordErrorLocked(path, err)
+}
+
+func (d *Daemon) recordErrorLocked(path string, err error) {
+	log.WithField("Path", path).WithError(err).Errorln("Daemon error")
+	d.state.addError(ErrorStatus{Time: d.now(), Path: path, Error: err.Error()})
+	d.logIfError(d.saveLocked())
+}
+
+func (d *Daemon) isRunning() bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	return d.running != nil
+}
+
+func (d *Daemon) save() error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	return d.saveLocked()
+}
+
+// saveLocked persists the state ; files pending stability are queued in the persisted state to not be lost on restart.
+func (d *Daemon) saveLocked() error {
+	if d.config.StateFile == "" {
+		return nil
+	}
+
+	persisted := *d.state
+	persisted.UpdatedAt = d.now()
+	persisted.Queue = slices.Clone(d.state.Queue)
+	persisted.enqueue(d.tracker.Pending()...)
+
+	return persisted.save(d.config.StateFile)
+}
+
+func (d *Daemon) logIfError(err error) {
+	if err != nil {
+		log.WithError(err).Errorln("Daemon state couldn't be saved")
+	}
+}
+
+func isDirectory(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
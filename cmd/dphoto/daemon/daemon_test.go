@@ -0,0 +1,192 @@
+package daemon
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thomasduchatelle/dphoto/pkg/backup"
+	"github.com/thomasduchatelle/dphoto/pkg/ownermodel"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDaemon(t *testing.T) {
+	directory := t.TempDir()
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	existing := filepath.Join(directory, "existing.jpg")
+	require.NoError(t, os.WriteFile(existing, []byte("existing"), 0644))
+
+	backupFake := new(BackupFuncFake)
+	daemon, err := New(Config{
+		Directories: []string{directory},
+		StableDelay: 50 * time.Millisecond,
+		BatchDelay:  200 * time.Millisecond,
+		StateFile:   stateFile,
+	}, backupFake.Backup)
+	require.NoError(t, err)
+	daemon.tickInterval = 10 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error)
+	go func() {
+		stopped <- daemon.Run(ctx)
+	}()
+
+	assert.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual([]string{existing}, backupFake.Files())
+	}, 2*time.Second, 10*time.Millisecond, "it should backup the files existing the first time the daemon starts")
+
+	newFile := filepath.Join(directory, "2024", "summer", "new.jpg")
+	require.NoError(t, os.MkdirAll(filepath.Dir(newFile), 0755))
+	require.NoError(t, os.WriteFile(newFile, []byte("new"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(directory, "2024", "notes.md"), []byte("not a media"), 0644))
+
+	assert.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual([]string{newFile, existing}, backupFake.Files())
+	}, 2*time.Second, 10*time.Millisecond, "it should backup the files created in new sub-directories once they are stable")
+
+	status := daemon.Status()
+	assert.Empty(t, status.Queue)
+	assert.Len(t, status.Runs, 2)
+
+	cancel()
+	assert.NoError(t, <-stopped)
+
+	state, err := readState(stateFile)
+	if assert.NoError(t, err) {
+		assert.Empty(t, state.Queue)
+		assert.Len(t, state.Runs, 2)
+		assert.False(t, state.UpdatedAt.IsZero())
+	}
+}
+
+func TestDaemon_failedBackupIsRetried(t *testing.T) {
+	directory := t.TempDir()
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	file := filepath.Join(directory, "photo.jpg")
+	require.NoError(t, os.WriteFile(file, []byte("photo"), 0644))
+
+	backupFake := &BackupFuncFake{Err: errors.New("network is down")}
+	daemon, err := New(Config{Directories: []string{directory}, StateFile: stateFile}, backupFake.Backup)
+	require.NoError(t, err)
+	daemon.tickInterval = 10 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error)
+	go func() {
+		stopped <- daemon.Run(ctx)
+	}()
+
+	assert.Eventually(t, func() bool {
+		return len(daemon.Status().Errors) > 0
+	}, 2*time.Second, 10*time.Millisecond)
+
+	cancel()
+	assert.NoError(t, <-stopped)
+
+	status := daemon.Status()
+	assert.Equal(t, []string{file}, status.Queue, "it should keep the files in the queue")
+	assert.NotNil(t, status.RetryAfter)
+	assert.Equal(t, "network is down", status.Errors[0].Error)
+
+	restarted, err := New(Config{Directories: []string{directory}, StateFile: stateFile}, backupFake.Backup)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{file}, restarted.Status().Queue, "it should restore the queue after a restart")
+	}
+}
+
+func TestDaemon_FetchStatus(t *testing.T) {
+	daemon, err := New(Config{Directories: []string{t.TempDir()}}, new(BackupFuncFake).Backup)
+	require.NoError(t, err)
+	daemon.state.enqueue("/photos/a.jpg")
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	_, err = FetchStatus(context.Background(), address)
+	assert.Error(t, err, "it should fail when the daemon is not running")
+
+	stop, err := daemon.serveStatus(address)
+	require.NoError(t, err)
+	defer stop()
+
+	status, err := FetchStatus(context.Background(), address)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"/photos/a.jpg"}, status.Queue)
+		assert.Equal(t, daemon.config.Directories, status.Directories)
+	}
+}
+
+type BackupFuncFake struct {
+	Err     error
+	Rejects map[string]error // Rejects are reported as analysis errors, by filename
+	lock    sync.Mutex
+	files   []string
+	owners  []ownermodel.Owner
+}
+
+func (b *BackupFuncFake) Backup(ctx context.Context, owner ownermodel.Owner, volume backup.SourceVolume, options ...backup.Options) (backup.Report, error) {
+	if b.Err != nil {
+		return nil, b.Err
+	}
+
+	medias, err := volume.FindMedias(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.owners = append(b.owners, owner)
+	details := backup.ReduceOptions(options...).DetailedReport
+	for _, media := range medias {
+		if reject, rejected := b.Rejects[media.MediaPath().Filename]; rejected {
+			_ = details.OnRejectedMedia(ctx, media, reject)
+			continue
+		}
+		b.files = append(b.files, media.String())
+	}
+	return ReportStub{}, nil
+}
+
+func (b *BackupFuncFake) Owners() []ownermodel.Owner {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return append([]ownermodel.Owner{}, b.owners...)
+}
+
+func (b *BackupFuncFake) Files() []string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	files := append([]string{}, b.files...)
+	sort.Strings(files)
+	return files
+}
+
+type ReportStub struct{}
+
+func (r ReportStub) Skipped() backup.MediaCounter {
+	return backup.MediaCounterZero
+}
+
+func (r ReportStub) CountPerAlbum() map[string]*backup.AlbumReport {
+	return nil
+}
+
+func (r ReportStub) FilteredOut() map[string]backup.MediaCounter {
+	return nil
+}
+
+func (r ReportStub) Quarantined() backup.MediaCounter {
+	return backup.MediaCounterZero
+}
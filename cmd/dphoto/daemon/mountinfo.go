@@ -0,0 +1,110 @@
+package daemon
+
+import (
+	"bufio"
+	"github.com/pkg/errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	procMountInfo = "/proc/self/mountinfo"
+	devDiskByUUID = "/dev/disk/by-uuid"
+)
+
+// Mount is a filesystem mounted on the host.
+type Mount struct {
+	MountPoint string
+	Device     string // Device is the source of the mount, like /dev/sdb1
+	FSType     string
+}
+
+// MountInfoSource returns the content of a mountinfo file (see proc(5)) ; it is replaced by a fake during tests.
+type MountInfoSource func() (io.ReadCloser, error)
+
+// UUIDResolver returns the UUID of the filesystem of a device, or an empty string if it doesn't have one.
+type UUIDResolver func(device string) string
+
+func ProcMountInfo() (io.ReadCloser, error) {
+	return os.Open(procMountInfo)
+}
+
+// parseMountInfo reads lines like '36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 - ext3 /dev/root rw,errors=continue'
+func parseMountInfo(reader io.Reader) ([]Mount, error) {
+	var mounts []Mount
+
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		separator := -1
+		for i, field := range fields {
+			if field == "-" && i >= 6 {
+				separator = i
+				break
+			}
+		}
+		if separator < 0 || len(fields) < separator+3 {
+			return nil, errors.Errorf("invalid mountinfo line: %s", scanner.Text())
+		}
+
+		mounts = append(mounts, Mount{
+			MountPoint: unescapeMountInfo(fields[4]),
+			FSType:     fields[separator+1],
+			Device:     unescapeMountInfo(fields[separator+2]),
+		})
+	}
+
+	return mounts, errors.Wrapf(scanner.Err(), "failed to read mountinfo")
+}
+
+// unescapeMountInfo decodes the octal sequences used for spaces, tabs, new lines, and backslashes.
+func unescapeMountInfo(value string) string {
+	if !strings.Contains(value, `\`) {
+		return value
+	}
+
+	builder := strings.Builder{}
+	for i := 0; i < len(value); i++ {
+		if value[i] == '\\' && i+3 < len(value) {
+			if code, err := strconv.ParseUint(value[i+1:i+4], 8, 8); err == nil {
+				builder.WriteByte(byte(code))
+				i += 3
+				continue
+			}
+		}
+		builder.WriteByte(value[i])
+	}
+
+	return builder.String()
+}
+
+// NewDevDiskUUIDResolver resolves UUIDs from the symbolic links maintained by udev in /dev/disk/by-uuid.
+func NewDevDiskUUIDResolver() UUIDResolver {
+	return func(device string) string {
+		return resolveUUIDFromLinks(devDiskByUUID, device)
+	}
+}
+
+func resolveUUIDFromLinks(directory, device string) string {
+	target, err := filepath.EvalSymlinks(device)
+	if err != nil {
+		return ""
+	}
+
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		return ""
+	}
+
+	for _, entry := range entries {
+		linked, err := filepath.EvalSymlinks(filepath.Join(directory, entry.Name()))
+		if err == nil && linked == target {
+			return entry.Name()
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,249 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/thomasduchatelle/dphoto/pkg/backup"
+	"github.com/thomasduchatelle/dphoto/pkg/bandwidth"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// removableMountPrefixes are where desktops and automounters mount removable medias ; unknown volumes mounted there are reported.
+var removableMountPrefixes = []string{"/media/", "/run/media/", "/mnt/"}
+
+// VolumeConfig is a removable volume (USB stick, SD card, camera) to backup when it is mounted.
+type VolumeConfig struct {
+	UUID              string `mapstructure:"uuid"`              // UUID of the filesystem, as listed in /dev/disk/by-uuid
+	Name              string `mapstructure:"name"`              // Name is only used for display
+	Owner             string `mapstructure:"owner"`             // Owner overrides the default owner
+	Path              string `mapstructure:"path"`              // Path is the sub-directory of the volume to backup (like DCIM) ; the whole volume by default
+	RejectDir         string `mapstructure:"rejectDir"`         // RejectDir is where files that couldn't be backed up are copied
+	DeleteAfterBackup bool   `mapstructure:"deleteAfterBackup"` // DeleteAfterBackup removes from the volume the files that are in the archive once the backup succeeded
+}
+
+func (v VolumeConfig) String() string {
+	if v.Name != "" {
+		return v.Name
+	}
+	return v.UUID
+}
+
+// MountedVolume is a configured volume currently mounted.
+type MountedVolume struct {
+	VolumeConfig
+	MountPoint string
+}
+
+// Root is the directory to backup.
+func (v MountedVolume) Root() string {
+	return filepath.Join(v.MountPoint, v.Path)
+}
+
+// volumeWatcher detects when configured volumes are mounted, or unmounted, by comparing consecutive reads of mountinfo.
+type volumeWatcher struct {
+	source      MountInfoSource
+	resolveUUID UUIDResolver
+	volumes     map[string]VolumeConfig
+	mounted     map[string]MountedVolume // mounted are indexed by mount point
+	unknown     map[string]interface{}   // unknown are the mount points of removable medias not configured, reported once
+}
+
+func newVolumeWatcher(source MountInfoSource, resolveUUID UUIDResolver, volumes []VolumeConfig) *volumeWatcher {
+	watcher := &volumeWatcher{
+		source:      source,
+		resolveUUID: resolveUUID,
+		volumes:     make(map[string]VolumeConfig),
+		mounted:     make(map[string]MountedVolume),
+		unknown:     make(map[string]interface{}),
+	}
+	for _, volume := range volumes {
+		watcher.volumes[strings.ToLower(volume.UUID)] = volume
+	}
+
+	return watcher
+}
+
+// Poll returns the volumes mounted, and unmounted, since the previous call ; volumes already mounted are returned by the first call.
+func (w *volumeWatcher) Poll() (mounted []MountedVolume, unmounted []MountedVolume, err error) {
+	reader, err := w.source()
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to read mounted filesystems")
+	}
+	defer reader.Close()
+
+	mounts, err := parseMountInfo(reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	current := make(map[string]interface{})
+	for _, mount := range mounts {
+		current[mount.MountPoint] = nil
+		if _, known := w.mounted[mount.MountPoint]; known || !strings.HasPrefix(mount.Device, "/dev/") {
+			continue
+		}
+
+		uuid := strings.ToLower(w.resolveUUID(mount.Device))
+		volume, configured := w.volumes[uuid]
+		if !configured || uuid == "" {
+			if _, reported := w.unknown[mount.MountPoint]; !reported && isRemovableMountPoint(mount.MountPoint) {
+				w.unknown[mount.MountPoint] = nil
+				log.WithFields(log.Fields{"MountPoint": mount.MountPoint, "Device": mount.Device, "UUID": uuid}).Infoln("Volume mounted is not configured to be backed up")
+			}
+			continue
+		}
+
+		mountedVolume := MountedVolume{VolumeConfig: volume, MountPoint: mount.MountPoint}
+		w.mounted[mount.MountPoint] = mountedVolume
+		mounted = append(mounted, mountedVolume)
+	}
+
+	for mountPoint, volume := range w.mounted {
+		if _, stillMounted := current[mountPoint]; !stillMounted {
+			delete(w.mounted, mountPoint)
+			unmounted = append(unmounted, volume)
+		}
+	}
+	for mountPoint := range w.unknown {
+		if _, stillMounted := current[mountPoint]; !stillMounted {
+			delete(w.unknown, mountPoint)
+		}
+	}
+
+	sort.Slice(mounted, func(i, j int) bool {
+		return mounted[i].MountPoint < mounted[j].MountPoint
+	})
+	return mounted, unmounted, nil
+}
+
+// Mounted returns the configured volumes currently mounted.
+func (w *volumeWatcher) Mounted() []MountedVolume {
+	mounted := make([]MountedVolume, 0, len(w.mounted))
+	for _, volume := range w.mounted {
+		mounted = append(mounted, volume)
+	}
+
+	sort.Slice(mounted, func(i, j int) bool {
+		return mounted[i].MountPoint < mounted[j].MountPoint
+	})
+	return mounted
+}
+
+func isRemovableMountPoint(mountPoint string) bool {
+	for _, prefix := range removableMountPrefixes {
+		if strings.HasPrefix(mountPoint, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// recordingVolume keeps the medias found by the backup: they are the only ones that can be deleted after it.
+type recordingVolume struct {
+	backup.SourceVolume
+	found []backup.FoundMedia
+}
+
+func (v *recordingVolume) FindMedias(ctx context.Context) ([]backup.FoundMedia, error) {
+	found, err := v.SourceVolume.FindMedias(ctx)
+	v.found = found
+	return found, err
+}
+
+// deleteBackedUpMedias removes the medias that are in the archive: backed up, or skipped because they were already in it.
+func deleteBackedUpMedias(found []backup.FoundMedia, details *backup.DetailedReport) (int, []ErrorStatus) {
+	notArchived := make(map[string]interface{})
+	for _, skipped := range details.SkippedMedias() {
+		if skipped.Reason != backup.SkipReasonAlreadyExists && skipped.Reason != backup.SkipReasonDuplicatedInVolume {
+			notArchived[skipped.Path] = nil
+		}
+	}
+
+	deleted := 0
+	var failures []ErrorStatus
+	for _, media := range found {
+		path := media.MediaPath().Absolute()
+		if _, kept := notArchived[path]; kept {
+			continue
+		}
+
+		err := os.Remove(path)
+		if err != nil {
+			failures = append(failures, ErrorStatus{Path: path, Error: fmt.Sprintf("failed to delete after backup: %s", err)})
+			continue
+		}
+		deleted++
+	}
+
+	return deleted, failures
+}
+
+// lineProgress writes the progress of a backup as plain lines, readable in a terminal, a journal, or a log file.
+type lineProgress struct {
+	lock     sync.Mutex
+	writer   io.Writer
+	prefix   string
+	total    int
+	analysed int
+	uploaded int
+	lastStep int
+}
+
+func newLineProgress(writer io.Writer, prefix string) *lineProgress {
+	return &lineProgress{writer: writer, prefix: prefix, lastStep: -1}
+}
+
+func (p *lineProgress) OnScanComplete(total backup.MediaCounter) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.total = total.Count
+	_, _ = fmt.Fprintf(p.writer, "%s %d files found\n", p.prefix, total.Count)
+}
+
+func (p *lineProgress) OnAnalysed(done, total backup.MediaCounter, others backup.ExtraCounts) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.analysed = done.Count
+	p.printStep()
+}
+
+func (p *lineProgress) OnUploaded(done, total backup.MediaCounter) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.uploaded = done.Count
+	p.printStep()
+}
+
+func (p *lineProgress) OnBandwidthLimit(limit backup.BandwidthLimit) {
+	_, _ = fmt.Fprintf(p.writer, "%s upload rate: %s, %s per routine\n", p.prefix, rateLabel(limit.Global), rateLabel(limit.PerRoutine))
+}
+
+func rateLabel(bytesPerSecond int64) string {
+	if bytesPerSecond == bandwidth.Unlimited {
+		return "unlimited"
+	}
+	return fmt.Sprintf("%.0f KiB/s", float64(bytesPerSecond)/1024)
+}
+
+// printStep only prints every 10%.
+func (p *lineProgress) printStep() {
+	if p.total == 0 {
+		return
+	}
+
+	step := 10 * (p.analysed + p.uploaded) / (2 * p.total)
+	if step > p.lastStep {
+		p.lastStep = step
+		_, _ = fmt.Fprintf(p.writer, "%s analysed %d/%d, uploaded %d/%d\n", p.prefix, p.analysed, p.total, p.uploaded, p.total)
+	}
+}
@@ -0,0 +1,165 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thomasduchatelle/dphoto/pkg/backup"
+	"github.com/thomasduchatelle/dphoto/pkg/ownermodel"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	mountInfoRoot = "23 28 0:22 / /proc rw,relatime - proc proc rw\n" +
+		"28 1 259:2 / / rw,relatime shared:1 - ext4 /dev/nvme0n1p2 rw\n"
+	mountInfoStick = "95 28 8:17 / /media/tony/My\\040Stick rw,nosuid,nodev,relatime shared:52 - vfat /dev/sdb1 rw,fmask=0022\n"
+)
+
+func TestParseMountInfo(t *testing.T) {
+	mounts, err := parseMountInfo(strings.NewReader(mountInfoRoot + mountInfoStick))
+	if assert.NoError(t, err) {
+		assert.Equal(t, []Mount{
+			{MountPoint: "/proc", Device: "proc", FSType: "proc"},
+			{MountPoint: "/", Device: "/dev/nvme0n1p2", FSType: "ext4"},
+			{MountPoint: "/media/tony/My Stick", Device: "/dev/sdb1", FSType: "vfat"},
+		}, mounts, "it should read mount points with escaped characters and optional fields")
+	}
+
+	_, err = parseMountInfo(strings.NewReader("not a mountinfo line\n"))
+	assert.Error(t, err)
+}
+
+func TestVolumeWatcher_Poll(t *testing.T) {
+	stick := VolumeConfig{UUID: "001B-9622", Name: "stick"}
+	source := &MountInfoFake{content: mountInfoRoot + mountInfoStick}
+	watcher := newVolumeWatcher(source.Read, UUIDResolverFake{"/dev/sdb1": "001b-9622", "/dev/nvme0n1p2": "root-uuid"}.Resolve, []VolumeConfig{stick})
+
+	mounted, unmounted, err := watcher.Poll()
+	if assert.NoError(t, err) {
+		assert.Equal(t, []MountedVolume{{VolumeConfig: stick, MountPoint: "/media/tony/My Stick"}}, mounted, "it should return configured volumes already mounted on the first poll")
+		assert.Empty(t, unmounted)
+	}
+
+	mounted, unmounted, err = watcher.Poll()
+	if assert.NoError(t, err) {
+		assert.Empty(t, mounted, "it should not return a volume still mounted")
+		assert.Empty(t, unmounted)
+	}
+
+	source.Set(mountInfoRoot)
+	mounted, unmounted, err = watcher.Poll()
+	if assert.NoError(t, err) {
+		assert.Empty(t, mounted)
+		assert.Equal(t, []MountedVolume{{VolumeConfig: stick, MountPoint: "/media/tony/My Stick"}}, unmounted, "it should detect the volume has been unmounted")
+	}
+
+	source.Set(mountInfoRoot + mountInfoStick)
+	mounted, _, err = watcher.Poll()
+	if assert.NoError(t, err) {
+		assert.Len(t, mounted, 1, "it should detect the volume is mounted again")
+	}
+}
+
+func TestDaemon_removableVolume(t *testing.T) {
+	mountPoint := t.TempDir()
+	dcim := filepath.Join(mountPoint, "DCIM")
+	require.NoError(t, os.MkdirAll(dcim, 0755))
+	for _, name := range []string{"photo_1.jpg", "photo_2.jpg", "unreadable.jpg"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dcim, name), []byte(name), 0644))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(mountPoint, "outside.jpg"), []byte("outside"), 0644))
+
+	source := &MountInfoFake{content: mountInfoRoot}
+	progress := new(SafeBuffer)
+	backupFake := &BackupFuncFake{Rejects: map[string]error{"unreadable.jpg": backup.ErrAnalyserNoDateTime}}
+	daemon, err := New(Config{
+		Owner:       "tony",
+		Volumes:     []VolumeConfig{{UUID: "001B-9622", Name: "stick", Owner: "pepper", Path: "DCIM", DeleteAfterBackup: true}},
+		MountInfo:   source.Read,
+		ResolveUUID: UUIDResolverFake{"/dev/sdb1": "001B-9622"}.Resolve,
+		Progress:    progress,
+	}, backupFake.Backup)
+	require.NoError(t, err)
+	daemon.tickInterval = 10 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error)
+	go func() {
+		stopped <- daemon.Run(ctx)
+	}()
+
+	source.Set(mountInfoRoot + "95 28 8:17 / " + mountPoint + " rw,relatime - vfat /dev/sdb1 rw\n")
+
+	assert.Eventually(t, func() bool {
+		status := daemon.Status()
+		return len(status.Runs) > 0 && status.RunningSince == nil
+	}, 2*time.Second, 10*time.Millisecond, "it should backup the volume once it is mounted")
+
+	cancel()
+	require.NoError(t, <-stopped)
+
+	assert.Equal(t, []ownermodel.Owner{"pepper"}, backupFake.Owners(), "it should use the owner of the volume")
+	assert.Equal(t, []string{filepath.Join(dcim, "photo_1.jpg"), filepath.Join(dcim, "photo_2.jpg")}, backupFake.Files(), "it should only backup the configured sub-directory")
+
+	remaining, _ := filepath.Glob(filepath.Join(dcim, "*"))
+	assert.Equal(t, []string{filepath.Join(dcim, "unreadable.jpg")}, remaining, "it should delete the backed up files and keep the others")
+	assert.FileExists(t, filepath.Join(mountPoint, "outside.jpg"))
+
+	status := daemon.Status()
+	assert.Equal(t, 2, status.Runs[0].Deleted)
+	assert.Equal(t, "stick", status.Runs[0].Volume)
+	assert.Equal(t, filepath.Join(dcim, "unreadable.jpg"), status.Errors[0].Path)
+	assert.Equal(t, []VolumeStatus{{UUID: "001B-9622", Name: "stick", MountPoint: mountPoint}}, status.Volumes)
+	assert.Contains(t, progress.String(), "[stick] backup complete: 0 backed up, 2 deleted from the volume")
+}
+
+type MountInfoFake struct {
+	lock    sync.Mutex
+	content string
+}
+
+func (m *MountInfoFake) Set(content string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.content = content
+}
+
+func (m *MountInfoFake) Read() (io.ReadCloser, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return io.NopCloser(strings.NewReader(m.content)), nil
+}
+
+type UUIDResolverFake map[string]string
+
+func (u UUIDResolverFake) Resolve(device string) string {
+	return u[device]
+}
+
+type SafeBuffer struct {
+	lock   sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (b *SafeBuffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.buffer.Write(p)
+}
+
+func (b *SafeBuffer) String() string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.buffer.String()
+}
@@ -0,0 +1,81 @@
+package daemon
+
+import (
+	"os"
+	"sort"
+	"time"
+)
+
+// stabilityTracker holds the files that have been changed until they haven't been modified for a while.
+type stabilityTracker struct {
+	delay time.Duration
+	stat  func(path string) (os.FileInfo, error)
+	files map[string]*trackedFile
+}
+
+type trackedFile struct {
+	size       int64
+	modTime    time.Time
+	lastChange time.Time
+}
+
+func newStabilityTracker(delay time.Duration) *stabilityTracker {
+	return &stabilityTracker{
+		delay: delay,
+		stat:  os.Stat,
+		files: make(map[string]*trackedFile),
+	}
+}
+
+// Touch records that the file has been changed.
+func (s *stabilityTracker) Touch(path string, now time.Time) {
+	if file, tracked := s.files[path]; tracked {
+		file.lastChange = now
+		return
+	}
+
+	s.files[path] = &trackedFile{size: -1, lastChange: now}
+}
+
+// Forget stops tracking a file (removed, or renamed).
+func (s *stabilityTracker) Forget(path string) {
+	delete(s.files, path)
+}
+
+// Stable returns, and stops tracking, the files that haven't changed for the delay ; a file is stable when its size and modification time are the same on two consecutive checks.
+func (s *stabilityTracker) Stable(now time.Time) []string {
+	var stable []string
+	for path, file := range s.files {
+		if now.Sub(file.lastChange) < s.delay {
+			continue
+		}
+
+		info, err := s.stat(path)
+		if err != nil {
+			delete(s.files, path)
+			continue
+		}
+
+		if info.Size() == file.size && info.ModTime().Equal(file.modTime) {
+			stable = append(stable, path)
+			delete(s.files, path)
+			continue
+		}
+
+		file.size, file.modTime, file.lastChange = info.Size(), info.ModTime(), now
+	}
+
+	sort.Strings(stable)
+	return stable
+}
+
+// Pending returns the files that are not yet stable.
+func (s *stabilityTracker) Pending() []string {
+	pending := make([]string, 0, len(s.files))
+	for path := range s.files {
+		pending = append(pending, path)
+	}
+
+	sort.Strings(pending)
+	return pending
+}
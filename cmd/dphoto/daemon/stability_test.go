@@ -0,0 +1,91 @@
+package daemon
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/fs"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestStabilityTracker(t *testing.T) {
+	start := time.Date(2024, 7, 14, 10, 0, 0, 0, time.UTC)
+	delay := 10 * time.Second
+
+	tests := []struct {
+		name        string
+		sizes       []int64 // sizes are returned by stat on each check, -1 when the file is removed
+		checks      []time.Duration
+		wantStable  []bool
+		wantPending []string
+	}{
+		{
+			name:        "it should not check the file before the delay",
+			sizes:       []int64{42},
+			checks:      []time.Duration{delay / 2},
+			wantStable:  []bool{false},
+			wantPending: []string{"/photos/a.jpg"},
+		},
+		{
+			name:        "it should be stable when the size didn't change between two checks",
+			sizes:       []int64{42, 42},
+			checks:      []time.Duration{delay, 2 * delay},
+			wantStable:  []bool{false, true},
+			wantPending: []string{},
+		},
+		{
+			name:        "it should wait again when the file is still growing",
+			sizes:       []int64{42, 84, 84},
+			checks:      []time.Duration{delay, 2 * delay, 3 * delay},
+			wantStable:  []bool{false, false, true},
+			wantPending: []string{},
+		},
+		{
+			name:        "it should forget the file when it has been removed",
+			sizes:       []int64{42, -1},
+			checks:      []time.Duration{delay, 2 * delay},
+			wantStable:  []bool{false, false},
+			wantPending: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			call := 0
+			tracker := newStabilityTracker(delay)
+			tracker.stat = func(path string) (os.FileInfo, error) {
+				size := tt.sizes[call]
+				call++
+				if size < 0 {
+					return nil, fs.ErrNotExist
+				}
+				return &fileInfoStub{size: size, modTime: start}, nil
+			}
+
+			tracker.Touch("/photos/a.jpg", start)
+			for i, check := range tt.checks {
+				stable := tracker.Stable(start.Add(check))
+				if tt.wantStable[i] {
+					assert.Equal(t, []string{"/photos/a.jpg"}, stable, "check %d", i)
+				} else {
+					assert.Empty(t, stable, "check %d", i)
+				}
+			}
+			assert.Equal(t, tt.wantPending, tracker.Pending())
+		})
+	}
+}
+
+type fileInfoStub struct {
+	os.FileInfo
+	size    int64
+	modTime time.Time
+}
+
+func (f *fileInfoStub) Size() int64 {
+	return f.size
+}
+
+func (f *fileInfoStub) ModTime() time.Time {
+	return f.modTime
+}
@@ -0,0 +1,104 @@
+package daemon
+
+import (
+	"encoding/json"
+	"github.com/pkg/errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+const (
+	maxRunsHistory   = 10
+	maxErrorsHistory = 20
+)
+
+// State is persisted after each change to resume the queue after a restart.
+type State struct {
+	UpdatedAt time.Time     `json:"updatedAt"` // UpdatedAt is used to catch up the files changed while the daemon was stopped
+	Queue     []string      `json:"queue"`     // Queue are the absolute paths of stable files waiting to be backed up
+	Runs      []RunStatus   `json:"runs"`      // Runs are the last backups, most recent first
+	Errors    []ErrorStatus `json:"errors"`    // Errors are the last errors, most recent first
+}
+
+type RunStatus struct {
+	Volume    string    `json:"volume,omitempty"` // Volume is the name of the removable volume backed up
+	Directory string    `json:"directory"`
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	Files     int       `json:"files"`             // Files is the number of files submitted to the backup
+	BackedUp  int       `json:"backedUp"`          // BackedUp is the number of medias uploaded (others were already backed up, or rejected)
+	Deleted   int       `json:"deleted,omitempty"` // Deleted is the number of files removed from the volume after the backup
+	Error     string    `json:"error,omitempty"`
+}
+
+type ErrorStatus struct {
+	Time  time.Time `json:"time"`
+	Path  string    `json:"path,omitempty"`
+	Error string    `json:"error"`
+}
+
+// readState returns an empty state when the file doesn't exist.
+func readState(file string) (*State, error) {
+	state := new(State)
+
+	content, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read daemon state %s", file)
+	}
+
+	err = json.Unmarshal(content, state)
+	return state, errors.Wrapf(err, "daemon state %s is corrupted", file)
+}
+
+// save writes the state in a temporary file first to never leave a partial state.
+func (s *State) save(file string) error {
+	err := os.MkdirAll(filepath.Dir(file), 0755)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create directory of %s", file)
+	}
+
+	content, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return errors.Wrapf(err, "failed to serialise daemon state")
+	}
+
+	err = os.WriteFile(file+".tmp", content, 0644)
+	if err != nil {
+		return errors.Wrapf(err, "failed to write daemon state %s", file)
+	}
+
+	return errors.Wrapf(os.Rename(file+".tmp", file), "failed to replace daemon state %s", file)
+}
+
+func (s *State) enqueue(paths ...string) {
+	for _, path := range paths {
+		if !slices.Contains(s.Queue, path) {
+			s.Queue = append(s.Queue, path)
+		}
+	}
+}
+
+func (s *State) dequeue(paths ...string) {
+	s.Queue = slices.DeleteFunc(s.Queue, func(queued string) bool {
+		return slices.Contains(paths, queued)
+	})
+}
+
+func (s *State) addRun(run RunStatus) {
+	s.Runs = append([]RunStatus{run}, s.Runs...)
+	if len(s.Runs) > maxRunsHistory {
+		s.Runs = s.Runs[:maxRunsHistory]
+	}
+}
+
+func (s *State) addError(errorStatus ErrorStatus) {
+	s.Errors = append([]ErrorStatus{errorStatus}, s.Errors...)
+	if len(s.Errors) > maxErrorsHistory {
+		s.Errors = s.Errors[:maxErrorsHistory]
+	}
+}
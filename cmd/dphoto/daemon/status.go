@@ -0,0 +1,141 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"net"
+	"net/http"
+	"slices"
+	"time"
+)
+
+const (
+	DefaultStatusAddress = "127.0.0.1:8765"
+
+	statusPath = "/status"
+)
+
+// VolumeStatus is a configured removable volume.
+type VolumeStatus struct {
+	UUID       string `json:"uuid"`
+	Name       string `json:"name,omitempty"`
+	MountPoint string `json:"mountPoint,omitempty"` // MountPoint is empty when the volume is not mounted
+	Queued     bool   `json:"queued"`
+}
+
+// Status is the state of the daemon exposed on the status endpoint.
+type Status struct {
+	Directories  []string       `json:"directories"`
+	Volumes      []VolumeStatus `json:"volumes"`
+	Pending      []string       `json:"pending"`                    // Pending are the files changed recently, waiting to be stable
+	Queue        []string       `json:"queue"`                      // Queue are the stable files waiting to be backed up
+	Running      []string       `json:"running"`                    // Running are the files being backed up
+	RunningDir   string         `json:"runningDirectory,omitempty"` // RunningDir is the directory, or the volume, being backed up
+	RunningSince *time.Time     `json:"runningSince,omitempty"`     // RunningSince is set when a backup is in progress
+	RetryAfter   *time.Time     `json:"retryAfter,omitempty"`       // RetryAfter is set when the last backup failed
+	Runs         []RunStatus    `json:"runs"`
+	Errors       []ErrorStatus  `json:"errors"`
+}
+
+func (d *Daemon) Status() Status {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	status := Status{
+		Directories: slices.Clone(d.config.Directories),
+		Pending:     d.tracker.Pending(),
+		Queue:       slices.Clone(d.state.Queue),
+		Runs:        slices.Clone(d.state.Runs),
+		Errors:      slices.Clone(d.state.Errors),
+	}
+	if d.running != nil {
+		since := d.runningSince
+		status.RunningSince, status.RunningDir, status.Running = &since, d.running.directory, slices.Clone(d.running.files)
+		status.Queue = slices.DeleteFunc(status.Queue, func(file string) bool { return slices.Contains(d.running.files, file) })
+	}
+	status.Volumes = d.volumesStatus()
+	if !d.retryAfter.IsZero() {
+		retryAfter := d.retryAfter
+		status.RetryAfter = &retryAfter
+	}
+
+	return status
+}
+
+func (d *Daemon) volumesStatus() []VolumeStatus {
+	mountPoints := make(map[string]string)
+	if d.volumeWatcher != nil {
+		for _, mounted := range d.volumeWatcher.Mounted() {
+			mountPoints[mounted.UUID] = mounted.MountPoint
+		}
+	}
+
+	volumes := make([]VolumeStatus, len(d.config.Volumes))
+	for i, volume := range d.config.Volumes {
+		volumes[i] = VolumeStatus{
+			UUID:       volume.UUID,
+			Name:       volume.Name,
+			MountPoint: mountPoints[volume.UUID],
+			Queued: slices.ContainsFunc(d.volumeQueue, func(queued MountedVolume) bool {
+				return queued.UUID == volume.UUID
+			}),
+		}
+	}
+
+	return volumes
+}
+
+// serveStatus starts the status endpoint in the background ; the returned function stops it.
+func (d *Daemon) serveStatus(address string) (func(), error) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to listen on %s for the status endpoint", address)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(statusPath, func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "application/json")
+		err := json.NewEncoder(writer).Encode(d.Status())
+		if err != nil {
+			log.WithError(err).Warnln("Status couldn't be written")
+		}
+	})
+
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.WithError(err).Errorln("Status endpoint stopped")
+		}
+	}()
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(ctx)
+	}, nil
+}
+
+// FetchStatus reads the status of a running daemon.
+func FetchStatus(ctx context.Context, address string) (*Status, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("http://%s%s", address, statusPath), nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid status address %s", address)
+	}
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return nil, errors.Wrapf(err, "daemon is not reachable on %s", address)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("daemon status endpoint responded %s", response.Status)
+	}
+
+	status := new(Status)
+	err = json.NewDecoder(response.Body).Decode(status)
+	return status, errors.Wrapf(err, "invalid status returned by the daemon")
+}
@@ -1,7 +1,9 @@
 import * as apigatewayv2 from 'aws-cdk-lib/aws-apigatewayv2';
 import {IHttpRouteAuthorizer} from 'aws-cdk-lib/aws-apigatewayv2';
+import * as lambda from 'aws-cdk-lib/aws-lambda';
 import {Construct} from 'constructs';
 import {createSingleRouteEndpoint} from '../utils/simple-go-endpoint';
+import {GoLangLambdaFunction} from '../utils/golang-lambda-function';
 import {Duration} from 'aws-cdk-lib';
 import {ArchiveAccessManager} from "./archive-access-manager";
 import {CatalogAccessManager} from "../catalog/catalog-access-manager";
@@ -14,6 +16,8 @@ export interface ArchiveEndpointsConstructProps {
     catalogStore: CatalogAccessManager;
     archivist: ArchivistAccessManager;
     authorizer?: IHttpRouteAuthorizer;
+    issuerUrl: string;
+    jwtEncryptionKey: string;
 }
 
 export class ArchiveEndpointsConstruct extends Construct {
@@ -31,8 +35,30 @@ export class ArchiveEndpointsConstruct extends Construct {
             authorizer: props.authorizer,
         });
 
-        props.catalogStore.grantCatalogReadAccess(getMedia.lambda);
-        props.archiveStore.grantReadAccessToRawAndCacheMedias(getMedia.lambda);
-        props.archivist.grantAccessToAsyncArchivist(getMedia.lambda);
+        // Encrypted originals are decrypted while they are streamed: API Gateway cannot return more than 6 MB, a function URL in
+        // RESPONSE_STREAM mode is used instead. The same code is deployed, it checks the access token itself.
+        const streamMedia = new GoLangLambdaFunction(this, 'StreamMedia', {
+            environmentName: props.environmentName,
+            functionName: 'stream-media',
+            artifactPath: '../../bin/get-media.zip',
+            memorySize: 1024,
+            timeout: Duration.minutes(15),
+            environment: {
+                COGNITO_OPENID_CONFIG_URL: `${props.issuerUrl}/.well-known/openid-configuration`,
+                DPHOTO_JWT_KEY_B64: props.jwtEncryptionKey,
+                DPHOTO_JWT_ISSUER: `https://${props.environmentName}.duchatelle/dphoto`,
+            },
+        });
+        const streamMediaUrl = streamMedia.function.addFunctionUrl({
+            authType: lambda.FunctionUrlAuthType.NONE,
+            invokeMode: lambda.InvokeMode.RESPONSE_STREAM,
+        });
+        getMedia.lambda.function.addEnvironment('MEDIA_STREAMING_URL', streamMediaUrl.url);
+
+        [getMedia.lambda, streamMedia].forEach(workload => {
+            props.catalogStore.grantCatalogReadAccess(workload);
+            props.archiveStore.grantReadAccessToRawAndCacheMedias(workload);
+            props.archivist.grantAccessToAsyncArchivist(workload);
+        });
     }
 }
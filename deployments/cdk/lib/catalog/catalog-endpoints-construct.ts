@@ -50,6 +50,22 @@ export class CatalogEndpointsConstruct extends Construct {
             method: apigatewayv2.HttpMethod.GET,
         });
         catalogStore.grantCatalogReadAccess(listMedias.lambda);
+
+        const listMediasGeo = createSingleRouteEndpoint(this, 'ListMediasGeo', {
+            ...endpointProps,
+            functionName: 'list-medias-geo',
+            path: '/api/v1/owners/{owner}/medias/geo',
+            method: apigatewayv2.HttpMethod.GET,
+        });
+        catalogStore.grantCatalogReadAccess(listMediasGeo.lambda);
+
+        const searchMedias = createSingleRouteEndpoint(this, 'SearchMedias', {
+            ...endpointProps,
+            functionName: 'search-medias',
+            path: '/api/v1/medias/search',
+            method: apigatewayv2.HttpMethod.GET,
+        });
+        catalogStore.grantCatalogReadAccess(searchMedias.lambda);
     }
 
     private amendTimelineEndpoints(endpointProps: {
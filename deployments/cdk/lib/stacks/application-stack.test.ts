@@ -120,6 +120,24 @@ describe('DPhotoApplicationStack', () => {
         expect(fakeArchivistAccessManager.hasBeenGrantedForAsyncArchivist(functionName(getMediaFunction))).toBe('');
     });
 
+    test('encrypted originals are streamed by a lambda behind a function URL', () => {
+        template.hasResourceProperties('AWS::Lambda::Url', {
+            AuthType: 'NONE',
+            InvokeMode: 'RESPONSE_STREAM',
+        });
+
+        const streamMediaFunction = template.findResources('AWS::Lambda::Function', {
+            Properties: {FunctionName: 'dphoto-test-stream-media'},
+        });
+        const [streamMedia] = Object.values(streamMediaFunction);
+        expect(streamMedia).toBeDefined();
+        expect(fakeCatalogAccessManager.hasBeenGrantedForCatalogRead(functionName(streamMedia))).toBe('');
+        expect(fakeArchiveAccessManager.hasBeenGrantedForRawAndCacheMedias(functionName(streamMedia))).toBe('');
+
+        const getMediaFunction = findLambdaByRoute(template, '/api/v1/owners/{owner}/medias/{mediaId}/{filename}', 'GET');
+        expect(getMediaFunction.Properties.Environment.Variables.MEDIA_STREAMING_URL).toBeDefined();
+    });
+
     test('user endpoints are served by lambdas', () => {
         // Test user endpoints
         const listUsersFunction = findLambdaByRoute(template, '/api/v1/users', 'GET');
@@ -96,6 +96,8 @@ export class ApplicationStack extends cdk.Stack {
             catalogStore: catalogAccessManager,
             archivist: archivistAccessManager,
             authorizer: lambdaAuthorizer.mediaAuthorizer,
+            issuerUrl: oauth2ClientConfig.cognitoIssuer,
+            jwtEncryptionKey,
         });
 
         // TODO AGENTS - Remove the construct (class definition and this instantiation) after Cognito switch over (it won't be used).
@@ -89,6 +89,16 @@ func (a *CatalogAuthorizer) IsAuthorisedToViewMedia(ctx context.Context, current
 	return nil
 }
 
+// IsAuthorisedToViewMap returns nil if the user can see at least one album of the owner on the map.
+func (a *CatalogAuthorizer) IsAuthorisedToViewMap(ctx context.Context, user usermodel.CurrentUser, owner ownermodel.Owner) error {
+	ownsAll, shared, err := readMapPermissions(ctx, a.HasPermissionPort, user, owner)
+	if err != nil || ownsAll || len(shared) > 0 {
+		return err
+	}
+
+	return errors.Wrapf(ErrAccessDenied, "user %s is not authorised to view the map of %s", user.UserId, owner)
+}
+
 func (a *CatalogAuthorizer) CanShareAlbum(ctx context.Context, user usermodel.CurrentUser, albumId catalog.AlbumId) error {
 	if user.Owner != nil && *user.Owner == albumId.Owner {
 		return nil
@@ -0,0 +1,67 @@
+package catalogacl
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/thomasduchatelle/dphoto/pkg/acl/aclcore"
+	"github.com/thomasduchatelle/dphoto/pkg/catalog"
+	"github.com/thomasduchatelle/dphoto/pkg/ownermodel"
+	"github.com/thomasduchatelle/dphoto/pkg/usermodel"
+)
+
+// MapAlbums lists the albums of an owner contributing to the map of a user: all of them for the owner, only the shared ones for the visitors.
+type MapAlbums struct {
+	HasPermissionPort     HasPermissionPort
+	FindAlbumsByOwnerPort catalog.FindAlbumsByOwnerPort
+}
+
+func (m *MapAlbums) ListAlbumsOnMap(ctx context.Context, user usermodel.CurrentUser, owner ownermodel.Owner) ([]catalog.AlbumId, error) {
+	ownsAll, shared, err := readMapPermissions(ctx, m.HasPermissionPort, user, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ownsAll {
+		if len(shared) == 0 {
+			return nil, errors.Wrapf(ErrAccessDenied, "user %s has no album of %s on its map", user.UserId, owner)
+		}
+		return shared, nil
+	}
+
+	albums, err := m.FindAlbumsByOwnerPort.FindAlbumsByOwner(ctx, owner)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list albums of %s", owner)
+	}
+
+	ids := make([]catalog.AlbumId, len(albums))
+	for i, album := range albums {
+		ids[i] = album.AlbumId
+	}
+	return ids, nil
+}
+
+// readMapPermissions returns true when the user can see all the albums of the owner, otherwise the albums that have been shared with the user.
+func readMapPermissions(ctx context.Context, permissions HasPermissionPort, user usermodel.CurrentUser, owner ownermodel.Owner) (bool, []catalog.AlbumId, error) {
+	if user.Owner != nil && *user.Owner == owner {
+		return true, nil, nil
+	}
+
+	scopes, err := permissions.ListScopesByUser(ctx, user.UserId, aclcore.MainOwnerScope, aclcore.AlbumVisitorScope)
+	if err != nil {
+		return false, nil, errors.Wrapf(err, "failed to check permissions for user %s", user.UserId)
+	}
+
+	var shared []catalog.AlbumId
+	for _, scope := range scopes {
+		if scope.ResourceOwner != owner {
+			continue
+		}
+
+		if scope.Type == aclcore.MainOwnerScope {
+			return true, nil, nil
+		}
+		shared = append(shared, catalog.AlbumId{Owner: owner, FolderName: catalog.NewFolderName(scope.ResourceId)})
+	}
+
+	return false, shared, nil
+}
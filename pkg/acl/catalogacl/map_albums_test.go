@@ -0,0 +1,98 @@
+package catalogacl
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/thomasduchatelle/dphoto/pkg/acl/aclcore"
+	"github.com/thomasduchatelle/dphoto/pkg/catalog"
+	"github.com/thomasduchatelle/dphoto/pkg/ownermodel"
+	"github.com/thomasduchatelle/dphoto/pkg/usermodel"
+	"testing"
+)
+
+func TestMapAlbums_ListAlbumsOnMap(t *testing.T) {
+	owner1 := ownermodel.Owner("owner-1")
+	owner2 := ownermodel.Owner("owner-2")
+	authenticatedOwner1 := usermodel.CurrentUser{UserId: "user-1", Owner: &owner1}
+	visitor2 := usermodel.CurrentUser{UserId: "user-2"}
+	album1 := catalog.AlbumId{Owner: owner1, FolderName: catalog.NewFolderName("/folder-1")}
+	album2 := catalog.AlbumId{Owner: owner1, FolderName: catalog.NewFolderName("/folder-2")}
+	album3 := catalog.AlbumId{Owner: owner2, FolderName: catalog.NewFolderName("/folder-3")}
+	accessDenied := func(t assert.TestingT, err error, i ...interface{}) bool {
+		return assert.ErrorIs(t, err, ErrAccessDenied, i...)
+	}
+
+	albumsByOwner := catalog.FindAlbumsByOwnerFunc(func(ctx context.Context, owner ownermodel.Owner) ([]*catalog.Album, error) {
+		var albums []*catalog.Album
+		for _, albumId := range []catalog.AlbumId{album1, album2, album3} {
+			if albumId.Owner == owner {
+				albums = append(albums, &catalog.Album{AlbumId: albumId})
+			}
+		}
+		return albums, nil
+	})
+
+	tests := []struct {
+		name    string
+		scopes  []*aclcore.Scope
+		user    usermodel.CurrentUser
+		owner   ownermodel.Owner
+		want    []catalog.AlbumId
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{
+			name:    "it should display all the albums of the owner on his map",
+			user:    authenticatedOwner1,
+			owner:   owner1,
+			want:    []catalog.AlbumId{album1, album2},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "it should display all the albums of an owner to the users having the main owner scope",
+			scopes:  []*aclcore.Scope{ownerPermission(visitor2.UserId, owner1)},
+			user:    visitor2,
+			owner:   owner1,
+			want:    []catalog.AlbumId{album1, album2},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "it should only display the albums shared with a visitor",
+			scopes:  []*aclcore.Scope{visitorAlbumPermission(visitor2.UserId, album2), visitorAlbumPermission(visitor2.UserId, album3)},
+			user:    visitor2,
+			owner:   owner1,
+			want:    []catalog.AlbumId{album2},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "it should deny access to the map of an owner who didn't share any album",
+			scopes:  []*aclcore.Scope{visitorAlbumPermission(visitor2.UserId, album3)},
+			user:    visitor2,
+			owner:   owner1,
+			wantErr: accessDenied,
+		},
+		{
+			name:    "it should deny access to the map of another owner",
+			user:    authenticatedOwner1,
+			owner:   owner2,
+			wantErr: accessDenied,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			permissions := &aclcore.ScopeReadRepositoryInMemory{Scopes: tt.scopes}
+
+			mapAlbums := &MapAlbums{
+				HasPermissionPort:     permissions,
+				FindAlbumsByOwnerPort: albumsByOwner,
+			}
+			got, err := mapAlbums.ListAlbumsOnMap(context.Background(), tt.user, tt.owner)
+			if tt.wantErr(t, err) {
+				assert.Equal(t, tt.want, got)
+			}
+
+			authorizer := &CatalogAuthorizer{HasPermissionPort: permissions}
+			tt.wantErr(t, authorizer.IsAuthorisedToViewMap(context.Background(), tt.user, tt.owner), "IsAuthorisedToViewMap")
+		})
+	}
+}
@@ -19,6 +19,8 @@ func Init(repository ARepositoryAdapter, store StoreAdapter, cache CacheAdapter,
 	storePort = store
 	cachePort = cache
 	asyncJobPort = jobQueue
+	tieringRepositoryPort = nil
+	tieringStorePort = nil
 }
 
 // ARepositoryAdapter is storing the mapping between keys in the main storage and the media ids.
@@ -47,7 +49,7 @@ type StoreAdapter interface {
 	// Upload stores online the file and return the final key used
 	Upload(values DestructuredKey, content io.Reader) (string, error)
 
-	// Copy copied the file to a different location, without overriding existing file
+	// Copy copied the file to a different location, without overriding existing file ; the copy keeps the storage class of the origin
 	Copy(origin string, destination DestructuredKey) (string, error)
 
 	// Delete permanently stored files (certainly after having been moved.
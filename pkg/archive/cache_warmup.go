@@ -16,7 +16,8 @@ func SupportResize(filename string) bool {
 	return supported
 }
 
-// WarmUpCacheByFolder list medias missing in the analysiscache and load them
+// WarmUpCacheByFolder list medias missing in the analysiscache and load them ; originals in cold storage are skipped: their restore is only requested
+// when they are viewed.
 func WarmUpCacheByFolder(owner, missedStoreKey string, width int) error {
 	if !strings.HasPrefix(missedStoreKey, owner) {
 		return errors.Errorf("cannot load the cache: %s is not the owner of %s media", owner, missedStoreKey)
@@ -38,6 +39,20 @@ func WarmUpCacheByFolder(owner, missedStoreKey string, width int) error {
 		return errors.Wrapf(err, "walking cache with prefix %s", generateCacheId(owner, "", width))
 	}
 
+	missingIds := make([]string, 0, len(ids))
+	for mediaId := range ids {
+		missingIds = append(missingIds, mediaId)
+	}
+	states, err := findStorageStates(owner, missingIds)
+	if err != nil {
+		return errors.Wrapf(err, "finding storage states of medias with prefix %s", parent)
+	}
+	for mediaId, state := range states {
+		if state != StorageStateHot {
+			delete(ids, mediaId)
+		}
+	}
+
 	var images []*ImageToResize
 	for mediaId, storeKey := range ids {
 		if SupportResize(storeKey) {
@@ -107,6 +122,11 @@ func contentOpener(owner, mediaId string, storeKey *string) func() (io.ReadClose
 			}
 		}
 
+		err = checkOriginalIsReadable(owner, mediaId, *storeKey)
+		if err != nil {
+			return nil, err
+		}
+
 		return storePort.Download(*storeKey)
 	}
 }
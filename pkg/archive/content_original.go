@@ -1,15 +1,52 @@
 package archive
 
-import "time"
+import (
+	"io"
+	"mime"
+	"path"
+	"time"
+)
 
 const DownloadUrlValidityDuration = 5 * time.Minute
 
 // GetMediaOriginalURL returns a pre-signed URL to download the content. URL only valid a certain time.
+// RestoreInProgressError is returned when the original is in cold storage: it will be available once restored.
 func GetMediaOriginalURL(owner, mediaId string) (string, error) {
 	key, err := repositoryPort.FindById(owner, mediaId)
 	if err != nil {
 		return "", err
 	}
 
+	err = checkOriginalIsReadable(owner, mediaId, key)
+	if err != nil {
+		return "", err
+	}
+
 	return storePort.SignedURL(key, DownloadUrlValidityDuration)
 }
+
+// GetMediaOriginal returns the content of the original, decrypted if necessary, and its media type. It is used when GetMediaOriginalURL
+// returns SignedURLNotSupportedError: the content must be streamed to the client as it is read.
+func GetMediaOriginal(owner, mediaId string) (io.ReadCloser, string, error) {
+	key, err := repositoryPort.FindById(owner, mediaId)
+	if err != nil {
+		return nil, "", err
+	}
+
+	err = checkOriginalIsReadable(owner, mediaId, key)
+	if err != nil {
+		return nil, "", err
+	}
+
+	reader, err := storePort.Download(key)
+	return reader, mediaTypeFromKey(key), err
+}
+
+// mediaTypeFromKey guesses the MIME type from the extension of the key.
+func mediaTypeFromKey(key string) string {
+	mediaType := mime.TypeByExtension(path.Ext(key))
+	if mediaType == "" {
+		return "application/octet-stream"
+	}
+	return mediaType
+}
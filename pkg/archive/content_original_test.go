@@ -1,10 +1,12 @@
 package archive_test
 
 import (
+	"bytes"
 	"fmt"
 	"github.com/stretchr/testify/assert"
 	mocks2 "github.com/thomasduchatelle/dphoto/internal/mocks"
 	"github.com/thomasduchatelle/dphoto/pkg/archive"
+	"io"
 	"testing"
 )
 
@@ -59,3 +61,25 @@ func TestGetMediaOriginalURL(t *testing.T) {
 		})
 	}
 }
+
+func TestGetMediaOriginal(t *testing.T) {
+	const owner = "ironman"
+	largeOriginal := bytes.Repeat([]byte("0123456789abcdef"), 512*1024) // 8 MB: more than a lambda can return in one response
+
+	repository := mocks2.NewARepositoryAdapter(t)
+	store := mocks2.NewStoreAdapter(t)
+	archive.Init(repository, store, mocks2.NewCacheAdapter(t), mocks2.NewAsyncJobAdapter(t))
+
+	repository.On("FindById", owner, "id-01").Once().Return(owner+"/2024-01/key-01.mp4", nil)
+	store.On("Download", owner+"/2024-01/key-01.mp4").Once().Return(io.NopCloser(bytes.NewReader(largeOriginal)), nil)
+
+	reader, mediaType, err := archive.GetMediaOriginal(owner, "id-01")
+	if assert.NoError(t, err) {
+		defer reader.Close()
+
+		content, err := io.ReadAll(reader)
+		assert.NoError(t, err)
+		assert.Equal(t, largeOriginal, content)
+		assert.Equal(t, "video/mp4", mediaType)
+	}
+}
@@ -10,6 +10,7 @@ import (
 )
 
 // GetResizedImage returns the image in the requested size (or rounded up), and the media type.
+// RestoreInProgressError is returned when the image is not in the cache and its original is in cold storage: it will be available once restored.
 func GetResizedImage(owner, mediaId string, width int, maxBytes int) ([]byte, string, error) {
 	cachedWidth, err := findCacheableSize(width)
 	if err != nil {
@@ -27,6 +28,11 @@ func GetResizedImage(owner, mediaId string, width int, maxBytes int) ([]byte, st
 				return nil, "", err
 			}
 
+			err = checkOriginalIsReadable(owner, mediaId, key)
+			if err != nil {
+				return nil, "", err
+			}
+
 			log.WithFields(log.Fields{
 				"Owner": owner,
 			}).Infof("%s [%s] is missing in the cache at size %d (requested %d)", key, cacheKey, cachedWidth, width)
@@ -12,9 +12,12 @@ const (
 )
 
 var (
-	NotFoundError      = errors.New("media is not present in the archive")
-	MediaOverflowError = errors.New("media at the requested width is bigger that what the consumer can support")
-	CacheableWidths    = []int{MediumQualityCachedWidth, MiniatureCachedWidth} // CacheableWidths are the only resolution cached, array must be sorted DESC.
+	NotFoundError              = errors.New("media is not present in the archive")
+	MediaOverflowError         = errors.New("media at the requested width is bigger that what the consumer can support")
+	SignedURLNotSupportedError = errors.New("pre-signed URL are not supported by the store, the content must be streamed")
+	RestoreInProgressError     = errors.New("original is in cold storage, its restoration has been requested")
+	ChecksumMismatchError      = errors.New("uploaded content doesn't match its SHA-256")
+	CacheableWidths            = []int{MediumQualityCachedWidth, MiniatureCachedWidth} // CacheableWidths are the only resolution cached, array must be sorted DESC.
 
 	supportedExtensionsForResizing = map[string]interface{}{
 		".jpg":  nil,
@@ -25,8 +28,9 @@ var (
 
 // DestructuredKey indicates the preferred key: Prefix + Suffix. A counter can be added between the 2 to make the name unique.
 type DestructuredKey struct {
-	Prefix string
-	Suffix string
+	Prefix        string
+	Suffix        string
+	ContentSha256 string // ContentSha256 is optional ; when set, the store verifies the uploaded content against it (hexadecimal)
 }
 
 // StoreRequest is used to archive a media
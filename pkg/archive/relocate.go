@@ -8,17 +8,43 @@ import (
 	"strings"
 )
 
-// Relocate physically moves media to the target folder
+// Relocate physically moves media to the target folder.
+// Originals in cold storage can't be copied until they are restored: the restore is requested, the other medias are moved, and an error wrapping RestoreInProgressError is returned for the relocation to be retried later.
 func Relocate(owner string, ids []string, targetFolder string) error {
 	locations, err := repositoryPort.FindByIds(owner, ids)
 	if err != nil {
 		return errors.Wrapf(err, "cannot relocate medias")
 	}
 
+	states, err := findStorageStates(owner, ids)
+	if err != nil {
+		return errors.Wrapf(err, "cannot find storage states of medias to relocate")
+	}
+
 	newLocations := make(map[string]string)
 	var oldLocations []string
+	var copiedFromColdStorage []string
+	var restoring []string
 	for _, id := range ids {
 		if previousLocation, ok := locations[id]; ok && strings.HasPrefix(previousLocation, owner+"/") {
+			if path.Dir(previousLocation) == path.Join(owner, targetFolder) {
+				// a relocation retried after a restore has already moved this one
+				continue
+			}
+
+			if states[id] != StorageStateHot {
+				err = requestRestoreIfArchived(owner, id, previousLocation)
+				if errors.Is(err, RestoreInProgressError) {
+					restoring = append(restoring, id)
+					continue
+				}
+				if err != nil {
+					return errors.Wrapf(err, "failed to check if %s can be relocated", previousLocation)
+				}
+
+				copiedFromColdStorage = append(copiedFromColdStorage, id)
+			}
+
 			baseFilename := strings.TrimSuffix(path.Base(previousLocation), path.Ext(previousLocation))
 
 			matches := regexp.MustCompile("\\d{4}-\\d{2}-\\d{2}_\\d{2}-\\d{2}-\\d{2}_\\w{6,8}").Find([]byte(path.Base(previousLocation)))
@@ -45,15 +71,33 @@ func Relocate(owner string, ids []string, targetFolder string) error {
 		}
 	}
 
-	if len(newLocations) == 0 || len(oldLocations) == 0 {
-		return nil
+	if len(newLocations) > 0 && len(oldLocations) > 0 {
+		err = moveLocations(owner, newLocations, oldLocations, copiedFromColdStorage)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(restoring) > 0 {
+		return errors.Wrapf(RestoreInProgressError, "%d originals must be restored from cold storage before being relocated to %s", len(restoring), targetFolder)
 	}
+	return nil
+}
 
-	err = repositoryPort.UpdateLocations(owner, newLocations)
+// moveLocations indexes the new locations before deleting the old ones ; copies of originals restored from cold storage keep their storage class but are not restored.
+func moveLocations(owner string, newLocations map[string]string, oldLocations []string, copiedFromColdStorage []string) error {
+	err := repositoryPort.UpdateLocations(owner, newLocations)
 	if err != nil {
 		return errors.Wrapf(err, "failed to update media locations")
 	}
 
+	if len(copiedFromColdStorage) > 0 {
+		err = tieringRepositoryPort.UpdateStorageState(owner, copiedFromColdStorage, StorageStateCold)
+		if err != nil {
+			return errors.Wrapf(err, "failed to update storage state of relocated medias")
+		}
+	}
+
 	err = storePort.Delete(oldLocations)
 	return errors.Wrapf(err, "failed to remove moved files")
 }
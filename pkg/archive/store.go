@@ -43,8 +43,9 @@ func storeInArchive(request *StoreRequest) (string, bool, error) {
 	const dateFormatInFilename = "2006-01-02_15-04-05"
 	cleanedFolderName := strings.Trim(request.FolderName, "/")
 	key, err = storePort.Upload(DestructuredKey{
-		Prefix: fmt.Sprintf("%s/%s/%s_%s", request.Owner, cleanedFolderName, request.DateTime.Format(dateFormatInFilename), request.SignatureSha256[:8]),
-		Suffix: strings.ToLower(path.Ext(request.OriginalFilename)),
+		Prefix:        fmt.Sprintf("%s/%s/%s_%s", request.Owner, cleanedFolderName, request.DateTime.Format(dateFormatInFilename), request.SignatureSha256[:8]),
+		Suffix:        strings.ToLower(path.Ext(request.OriginalFilename)),
+		ContentSha256: request.SignatureSha256,
 	}, content)
 	if err != nil {
 		return "", false, err
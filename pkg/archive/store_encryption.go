@@ -0,0 +1,239 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/pkg/errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+const MasterKeySize = 32 // MasterKeySize is the size of the master key, and of the data keys (AES-256)
+
+// DataKeyRepositoryAdapter stores the data key of each owner, wrapped (encrypted) by the master key.
+type DataKeyRepositoryAdapter interface {
+	// FindDataKey returns the wrapped data key of the owner, or a NotFoundError
+	FindDataKey(owner string) ([]byte, error)
+
+	// InsertDataKey stores the wrapped data key of the owner, it must fail if the owner already has a data key
+	InsertDataKey(owner string, wrappedKey []byte) error
+}
+
+// NewEncryptedStore decorates the StoreAdapter to encrypt originals with a data key per owner (envelope encryption).
+func NewEncryptedStore(store StoreAdapter, repository DataKeyRepositoryAdapter, masterKey []byte) (*EncryptedStore, error) {
+	if len(masterKey) != MasterKeySize {
+		return nil, errors.Errorf("master key must be %d bytes long, got %d", MasterKeySize, len(masterKey))
+	}
+
+	return &EncryptedStore{
+		Store:      store,
+		Repository: repository,
+		masterKey:  masterKey,
+		dataKeys:   make(map[string][]byte),
+	}, nil
+}
+
+type EncryptedStore struct {
+	Store      StoreAdapter
+	Repository DataKeyRepositoryAdapter
+	masterKey  []byte
+	dataKeys   map[string][]byte // dataKeys is a cache of unwrapped keys per owner
+	lock       sync.Mutex
+}
+
+// Download decrypts the content on the fly ; originals stored before the encryption was enabled are returned as they are.
+func (e *EncryptedStore) Download(key string) (io.ReadCloser, error) {
+	reader, err := e.Store.Download(key)
+	if err != nil {
+		return nil, err
+	}
+
+	buffered := bufio.NewReader(reader)
+	header, err := buffered.Peek(len(encryptedMagicHeader) + encryptedNoncePrefix)
+	if !bytes.HasPrefix(header, encryptedMagicHeader) {
+		return readCloser{Reader: buffered, Closer: reader}, nil
+	}
+	if err != nil {
+		_ = reader.Close()
+		return nil, errors.Wrapf(err, "failed to read encryption header of %s", key)
+	}
+	noncePrefix := append([]byte{}, header[len(encryptedMagicHeader):]...)
+	_, _ = buffered.Discard(len(header))
+
+	dataKey, err := e.dataKey(ownerFromKey(key))
+	if err != nil {
+		_ = reader.Close()
+		return nil, err
+	}
+
+	decrypted, err := newDecryptingReader(dataKey, noncePrefix, buffered)
+	if err != nil {
+		_ = reader.Close()
+		return nil, err
+	}
+	return readCloser{Reader: decrypted, Closer: reader}, nil
+}
+
+func (e *EncryptedStore) Upload(values DestructuredKey, content io.Reader) (string, error) {
+	dataKey, err := e.dataKey(ownerFromKey(values.Prefix))
+	if err != nil {
+		return "", err
+	}
+
+	// the store receives the encrypted content: the checksum of the plain content is verified here, before any encrypted byte is sent,
+	// so the nonce prefix derived from the checksum is never used to encrypt a different content
+	expectedSha256 := values.ContentSha256
+	values.ContentSha256 = ""
+
+	if expectedSha256 != "" {
+		verified, err := spoolVerifiedContent(content, expectedSha256)
+		if err != nil {
+			return "", errors.Wrapf(err, "content of %s%s can't be uploaded", values.Prefix, values.Suffix)
+		}
+		defer closeAndRemove(verified)
+		content = verified
+	}
+
+	noncePrefix, err := newNoncePrefix(dataKey, values, expectedSha256)
+	if err != nil {
+		return "", err
+	}
+
+	encrypted, err := newEncryptingReader(dataKey, noncePrefix, content)
+	if err != nil {
+		return "", err
+	}
+
+	return e.Store.Upload(values, encrypted)
+}
+
+// spoolVerifiedContent copies the content in a temporary file while computing its SHA-256 ; the file is returned rewound when the checksum matches.
+func spoolVerifiedContent(content io.Reader, expectedSha256 string) (*os.File, error) {
+	spool, err := os.CreateTemp("", "dphoto-encryption-*")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create temporary file")
+	}
+
+	plainHash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(spool, plainHash), content)
+	if err == nil {
+		_, err = spool.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		closeAndRemove(spool)
+		return nil, errors.Wrapf(err, "failed to copy content in %s", spool.Name())
+	}
+
+	if got := hex.EncodeToString(plainHash.Sum(nil)); got != expectedSha256 {
+		closeAndRemove(spool)
+		return nil, errors.Wrapf(ChecksumMismatchError, "content has SHA-256 %s, expected %s", got, expectedSha256)
+	}
+	return spool, nil
+}
+
+func closeAndRemove(file *os.File) {
+	_ = file.Close()
+	_ = os.Remove(file.Name())
+}
+
+// Copy doesn't re-encrypt the content: the data key is the same for the owner.
+func (e *EncryptedStore) Copy(origin string, destination DestructuredKey) (string, error) {
+	return e.Store.Copy(origin, destination)
+}
+
+func (e *EncryptedStore) Delete(locations []string) error {
+	return e.Store.Delete(locations)
+}
+
+// SignedURL is not supported because the content would be served encrypted: GetMediaOriginal must be used instead.
+func (e *EncryptedStore) SignedURL(key string, duration time.Duration) (string, error) {
+	return "", SignedURLNotSupportedError
+}
+
+func (e *EncryptedStore) dataKey(owner string) ([]byte, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if dataKey, cached := e.dataKeys[owner]; cached {
+		return dataKey, nil
+	}
+
+	wrapped, err := e.Repository.FindDataKey(owner)
+	if errors.Is(err, NotFoundError) {
+		wrapped, err = e.createDataKey(owner)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get data key of %s", owner)
+	}
+
+	dataKey, err := e.unwrap(owner, wrapped)
+	if err != nil {
+		return nil, err
+	}
+
+	e.dataKeys[owner] = dataKey
+	return dataKey, nil
+}
+
+func (e *EncryptedStore) createDataKey(owner string) ([]byte, error) {
+	dataKey := make([]byte, MasterKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, errors.Wrapf(err, "failed to generate data key")
+	}
+
+	wrapped, err := e.wrap(owner, dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	err = e.Repository.InsertDataKey(owner, wrapped)
+	if err != nil {
+		// another process might have created it concurrently
+		existing, findErr := e.Repository.FindDataKey(owner)
+		if findErr == nil {
+			return existing, nil
+		}
+		return nil, err
+	}
+
+	return wrapped, nil
+}
+
+// wrap encrypts the data key with the master key ; owner is used as additional data so a wrapped key can't be used for another owner.
+func (e *EncryptedStore) wrap(owner string, dataKey []byte) ([]byte, error) {
+	aead, err := newAEAD(e.masterKey)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, errors.Wrapf(err, "failed to generate nonce")
+	}
+
+	return aead.Seal(nonce, nonce, dataKey, []byte(owner)), nil
+}
+
+func (e *EncryptedStore) unwrap(owner string, wrapped []byte) ([]byte, error) {
+	aead, err := newAEAD(e.masterKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(wrapped) < aead.NonceSize() {
+		return nil, errors.Errorf("wrapped data key of %s is corrupted", owner)
+	}
+
+	dataKey, err := aead.Open(nil, wrapped[:aead.NonceSize()], wrapped[aead.NonceSize():], []byte(owner))
+	return dataKey, errors.Wrapf(err, "failed to unwrap data key of %s, is the master key correct?", owner)
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,184 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"github.com/pkg/errors"
+	"io"
+)
+
+const (
+	encryptedChunkSize   = 64 * 1024 // encryptedChunkSize is the size of plain content sealed together ; each chunk is authenticated
+	encryptedNoncePrefix = 7         // encryptedNoncePrefix is unique per file ; the nonce is completed with the chunk counter and a last-chunk flag
+)
+
+var (
+	encryptedMagicHeader = []byte("DPE1") // encryptedMagicHeader starts every encrypted file, it is followed by the nonce prefix
+)
+
+// newNoncePrefix derives the nonce prefix from the key and the SHA-256 of the plain content: uploading the same content again produces the same encrypted
+// content, which lets an interrupted multipart upload resume. The content must have been verified against its SHA-256 first: the same nonce must never
+// be used for two different contents. It is random when the content is not known in advance.
+func newNoncePrefix(dataKey []byte, values DestructuredKey, contentSha256 string) ([]byte, error) {
+	if contentSha256 == "" {
+		noncePrefix := make([]byte, encryptedNoncePrefix)
+		if _, err := rand.Read(noncePrefix); err != nil {
+			return nil, errors.Wrapf(err, "failed to generate nonce")
+		}
+		return noncePrefix, nil
+	}
+
+	mac := hmac.New(sha256.New, dataKey)
+	mac.Write([]byte("dphoto-nonce-prefix\x00" + values.Prefix + "\x00" + values.Suffix + "\x00" + contentSha256))
+	return mac.Sum(nil)[:encryptedNoncePrefix], nil
+}
+
+// newEncryptingReader seals the content in chunks (AES-GCM) so the whole file never has to be loaded in memory.
+func newEncryptingReader(dataKey []byte, noncePrefix []byte, content io.Reader) (io.Reader, error) {
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	header := append(append([]byte{}, encryptedMagicHeader...), noncePrefix...)
+	return &encryptingReader{
+		aead:        aead,
+		noncePrefix: noncePrefix,
+		source:      bufio.NewReaderSize(content, encryptedChunkSize+1),
+		pending:     bytes.NewBuffer(header),
+		plain:       make([]byte, encryptedChunkSize),
+	}, nil
+}
+
+type encryptingReader struct {
+	aead        cipher.AEAD
+	noncePrefix []byte
+	source      *bufio.Reader
+	pending     *bytes.Buffer
+	plain       []byte
+	counter     uint32
+	done        bool
+}
+
+func (e *encryptingReader) Read(p []byte) (int, error) {
+	for e.pending.Len() == 0 {
+		if e.done {
+			return 0, io.EOF
+		}
+
+		if err := e.sealNextChunk(); err != nil {
+			return 0, err
+		}
+	}
+
+	return e.pending.Read(p)
+}
+
+func (e *encryptingReader) sealNextChunk() error {
+	n, err := io.ReadFull(e.source, e.plain)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return errors.Wrapf(err, "failed to read content to encrypt")
+	}
+
+	last := n < len(e.plain)
+	if !last {
+		_, peekErr := e.source.Peek(1)
+		last = peekErr == io.EOF
+	}
+
+	e.pending.Write(e.aead.Seal(nil, chunkNonce(e.noncePrefix, e.counter, last), e.plain[:n], nil))
+	e.counter++
+	e.done = last
+	return nil
+}
+
+// newDecryptingReader opens a content sealed by newEncryptingReader ; the header must have been read (and removed) already.
+func newDecryptingReader(dataKey []byte, noncePrefix []byte, sealed io.Reader) (io.Reader, error) {
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &decryptingReader{
+		aead:        aead,
+		noncePrefix: noncePrefix,
+		source:      bufio.NewReaderSize(sealed, encryptedChunkSize+aead.Overhead()+1),
+		pending:     new(bytes.Buffer),
+		sealed:      make([]byte, encryptedChunkSize+aead.Overhead()),
+	}, nil
+}
+
+type decryptingReader struct {
+	aead        cipher.AEAD
+	noncePrefix []byte
+	source      *bufio.Reader
+	pending     *bytes.Buffer
+	sealed      []byte
+	counter     uint32
+	done        bool
+}
+
+func (d *decryptingReader) Read(p []byte) (int, error) {
+	for d.pending.Len() == 0 {
+		if d.done {
+			return 0, io.EOF
+		}
+
+		if err := d.openNextChunk(); err != nil {
+			return 0, err
+		}
+	}
+
+	return d.pending.Read(p)
+}
+
+func (d *decryptingReader) openNextChunk() error {
+	n, err := io.ReadFull(d.source, d.sealed)
+	if err == io.EOF {
+		return errors.Errorf("encrypted content is truncated")
+	}
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return errors.Wrapf(err, "failed to read encrypted content")
+	}
+
+	last := n < len(d.sealed)
+	if !last {
+		_, peekErr := d.source.Peek(1)
+		last = peekErr == io.EOF
+	}
+
+	plain, err := d.aead.Open(nil, chunkNonce(d.noncePrefix, d.counter, last), d.sealed[:n], nil)
+	if err != nil {
+		return errors.Wrapf(err, "failed to decrypt chunk %d", d.counter)
+	}
+
+	d.pending.Write(plain)
+	d.counter++
+	d.done = last
+	return nil
+}
+
+func chunkNonce(prefix []byte, counter uint32, last bool) []byte {
+	nonce := make([]byte, encryptedNoncePrefix+5)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[encryptedNoncePrefix:], counter)
+	if last {
+		nonce[len(nonce)-1] = 1
+	}
+	return nonce
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid encryption key")
+	}
+
+	return cipher.NewGCM(block)
+}
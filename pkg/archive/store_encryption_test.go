@@ -0,0 +1,189 @@
+package archive_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/thomasduchatelle/dphoto/pkg/archive"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncryptedStore(t *testing.T) {
+	masterKey := bytes.Repeat([]byte{42}, archive.MasterKeySize)
+	largeContent := make([]byte, 200*1024+17)
+	_, _ = rand.Read(largeContent)
+
+	tests := []struct {
+		name    string
+		content []byte
+	}{
+		{"it should encrypt and decrypt an empty file", []byte{}},
+		{"it should encrypt and decrypt a small file", []byte("foobar")},
+		{"it should encrypt and decrypt a file of several chunks", largeContent},
+		{"it should encrypt and decrypt a file exactly one chunk long", largeContent[:64*1024]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := assert.New(t)
+			memory := NewStoreInMemory()
+			keys := make(DataKeyRepositoryFake)
+
+			store, err := archive.NewEncryptedStore(memory, keys, masterKey)
+			if !a.NoError(err) {
+				return
+			}
+
+			key, err := store.Upload(archive.DestructuredKey{Prefix: owner + "/folder-1/media", Suffix: ".jpg"}, bytes.NewReader(tt.content))
+			if !a.NoError(err) {
+				return
+			}
+
+			a.NotContains(string(memory.Content[key]), "foobar", "it should not store the content in plain")
+			a.Len(keys, 1, "it should have created a data key for the owner")
+
+			copied, err := store.Copy(key, archive.DestructuredKey{Prefix: owner + "/folder-2/media", Suffix: ".jpg"})
+			if !a.NoError(err) {
+				return
+			}
+
+			reopened, err := archive.NewEncryptedStore(memory, keys, masterKey)
+			if !a.NoError(err) {
+				return
+			}
+			reader, err := reopened.Download(copied)
+			if a.NoError(err) {
+				got, err := io.ReadAll(reader)
+				a.NoError(err)
+				a.Equal(tt.content, got, "it should decrypt copied content with a data key read from the repository")
+			}
+		})
+	}
+}
+
+func TestEncryptedStore_NoncePrefix(t *testing.T) {
+	a := assert.New(t)
+	memory := NewStoreInMemory()
+	store, _ := archive.NewEncryptedStore(memory, make(DataKeyRepositoryFake), bytes.Repeat([]byte{42}, archive.MasterKeySize))
+	sha256Foobar := "c3ab8ff13720e8ad9047dd39466b3c8974e592c2fa383d4a3960714caef0c4f2"
+
+	first, _ := store.Upload(archive.DestructuredKey{Prefix: owner + "/media", Suffix: ".jpg", ContentSha256: sha256Foobar}, strings.NewReader("foobar"))
+	retried, _ := store.Upload(archive.DestructuredKey{Prefix: owner + "/media", Suffix: ".jpg", ContentSha256: sha256Foobar}, strings.NewReader("foobar"))
+	a.Equal(memory.Content[first], memory.Content[retried], "it should encrypt the same way a retried upload for it to be resumed")
+
+	other, _ := store.Upload(archive.DestructuredKey{Prefix: owner + "/other", Suffix: ".jpg", ContentSha256: sha256Foobar}, strings.NewReader("foobar"))
+	a.NotEqual(memory.Content[first], memory.Content[other], "it should use a different nonce for a different key")
+
+	unknown1, _ := store.Upload(archive.DestructuredKey{Prefix: owner + "/unknown", Suffix: ".jpg"}, strings.NewReader("foobar"))
+	unknown2, _ := store.Upload(archive.DestructuredKey{Prefix: owner + "/unknown", Suffix: ".jpg"}, strings.NewReader("foobar"))
+	a.NotEqual(memory.Content[unknown1], memory.Content[unknown2], "it should use a random nonce when the content is not known in advance")
+}
+
+func TestEncryptedStore_Errors(t *testing.T) {
+	a := assert.New(t)
+	masterKey := bytes.Repeat([]byte{42}, archive.MasterKeySize)
+	memory := NewStoreInMemory()
+	keys := make(DataKeyRepositoryFake)
+	store, _ := archive.NewEncryptedStore(memory, keys, masterKey)
+
+	memory.Content[owner+"/legacy.jpg"] = []byte("plain content")
+	reader, err := store.Download(owner + "/legacy.jpg")
+	if a.NoError(err) {
+		got, _ := io.ReadAll(reader)
+		a.Equal("plain content", string(got), "it should serve originals stored before the encryption was enabled")
+	}
+
+	key, err := store.Upload(archive.DestructuredKey{Prefix: owner + "/media", Suffix: ".jpg"}, strings.NewReader("foobar"))
+	a.NoError(err)
+
+	memory.Content[key] = memory.Content[key][:len(memory.Content[key])-1]
+	reader, err = store.Download(key)
+	if a.NoError(err) {
+		_, err = io.ReadAll(reader)
+		a.Error(err, "it should detect a truncated content")
+	}
+
+	other, _ := archive.NewEncryptedStore(memory, keys, bytes.Repeat([]byte{7}, archive.MasterKeySize))
+	_, err = other.Download(owner + "/media-not-existing.jpg")
+	a.Equal(archive.NotFoundError, err)
+	key, _ = store.Upload(archive.DestructuredKey{Prefix: owner + "/media", Suffix: ".jpg"}, strings.NewReader("foobar"))
+	_, err = other.Download(key)
+	a.Error(err, "it should fail to unwrap the data key with a different master key")
+
+	storedCount := len(memory.Content)
+	_, err = store.Upload(archive.DestructuredKey{Prefix: owner + "/corrupted", Suffix: ".jpg", ContentSha256: "c3ab8ff13720e8ad9047dd39466b3c8974e592c2fa383d4a3960714caef0c4f2"}, strings.NewReader("foobaz"))
+	a.ErrorIs(err, archive.ChecksumMismatchError)
+	a.Len(memory.Content, storedCount, "it should not upload anything when the content doesn't match its checksum")
+	_, err = store.Upload(archive.DestructuredKey{Prefix: owner + "/verified", Suffix: ".jpg", ContentSha256: "c3ab8ff13720e8ad9047dd39466b3c8974e592c2fa383d4a3960714caef0c4f2"}, strings.NewReader("foobar"))
+	a.NoError(err, "it should verify the checksum of the plain content")
+
+	_, err = store.SignedURL(key, time.Minute)
+	a.Equal(archive.SignedURLNotSupportedError, err)
+
+	_, err = archive.NewEncryptedStore(memory, keys, []byte("too-short"))
+	a.Error(err)
+}
+
+type DataKeyRepositoryFake map[string][]byte
+
+func (d DataKeyRepositoryFake) FindDataKey(owner string) ([]byte, error) {
+	if key, ok := d[owner]; ok {
+		return key, nil
+	}
+	return nil, archive.NotFoundError
+}
+
+func (d DataKeyRepositoryFake) InsertDataKey(owner string, wrappedKey []byte) error {
+	if _, exists := d[owner]; exists {
+		return errors.Errorf("data key already exists for %s", owner)
+	}
+	d[owner] = wrappedKey
+	return nil
+}
+
+// StoreInMemory is a minimal archive.StoreAdapter
+type StoreInMemory struct {
+	Content map[string][]byte
+}
+
+func NewStoreInMemory() *StoreInMemory {
+	return &StoreInMemory{Content: make(map[string][]byte)}
+}
+
+func (s *StoreInMemory) Download(key string) (io.ReadCloser, error) {
+	content, ok := s.Content[key]
+	if !ok {
+		return nil, archive.NotFoundError
+	}
+	return io.NopCloser(bytes.NewReader(content)), nil
+}
+
+func (s *StoreInMemory) Upload(values archive.DestructuredKey, content io.Reader) (string, error) {
+	key := values.Prefix + values.Suffix
+	for i := 1; s.Content[key] != nil; i++ {
+		key = values.Prefix + "_" + string(rune('0'+i)) + values.Suffix
+	}
+
+	value, err := io.ReadAll(content)
+	s.Content[key] = value
+	return key, err
+}
+
+func (s *StoreInMemory) Copy(origin string, destination archive.DestructuredKey) (string, error) {
+	return s.Upload(destination, bytes.NewReader(s.Content[origin]))
+}
+
+func (s *StoreInMemory) Delete(locations []string) error {
+	for _, location := range locations {
+		delete(s.Content, location)
+	}
+	return nil
+}
+
+func (s *StoreInMemory) SignedURL(key string, duration time.Duration) (string, error) {
+	return "https://example.com/" + key, nil
+}
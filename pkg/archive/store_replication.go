@@ -0,0 +1,221 @@
+package archive
+
+import (
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	ReplicaStatusReplicated ReplicaStatus = "REPLICATED" // ReplicaStatusReplicated is set when the key has been copied on the replica
+	ReplicaStatusFailed     ReplicaStatus = "FAILED"     // ReplicaStatusFailed is set when the replication failed ; it is queued to be retried
+)
+
+type ReplicaStatus string
+
+// ReplicaAdapter is a secondary storage where originals are copied with the same key than in the primary StoreAdapter.
+type ReplicaAdapter interface {
+	// Put stores the content by overriding exiting file if any
+	Put(key string, mediaType string, content io.Reader) error
+
+	// Delete permanently stored files
+	Delete(locations []string) error
+}
+
+// Replica is a named ReplicaAdapter ; the name is used to record the replication status.
+type Replica struct {
+	Name    string
+	Adapter ReplicaAdapter
+}
+
+// ReplicaRepositoryAdapter records on which replicas each key has been replicated ; FAILED keys are the queue of replications to retry.
+type ReplicaRepositoryAdapter interface {
+	// UpdateReplicaStatus adds or overrides the status of the key on the replica
+	UpdateReplicaStatus(owner, replica, key string, status ReplicaStatus) error
+
+	// FindReplicaStatuses returns a map key -> status for all keys of the owner known on the replica
+	FindReplicaStatuses(owner, replica string) (map[string]ReplicaStatus, error)
+
+	// DeleteReplicaStatuses removes the status of the keys on the replica
+	DeleteReplicaStatuses(owner, replica string, keys []string) error
+}
+
+// StoreKeysWalker lists the keys physically present in the primary storage.
+type StoreKeysWalker interface {
+	WalkCacheByPrefix(prefix string, observer func(string)) error
+}
+
+// ReplicationReport counts the keys processed by a replication run.
+type ReplicationReport struct {
+	Replicated int
+	Failed     int
+}
+
+// NewReplicatedStore decorates the primary StoreAdapter to copy each new original on all the replicas. Replication failures do not fail the upload.
+func NewReplicatedStore(primary StoreAdapter, repository ReplicaRepositoryAdapter, replicas ...Replica) *ReplicatedStore {
+	return &ReplicatedStore{
+		Primary:    primary,
+		Repository: repository,
+		Replicas:   replicas,
+	}
+}
+
+type ReplicatedStore struct {
+	Primary    StoreAdapter
+	Repository ReplicaRepositoryAdapter
+	Replicas   []Replica
+}
+
+func (r *ReplicatedStore) Download(key string) (io.ReadCloser, error) {
+	return r.Primary.Download(key)
+}
+
+func (r *ReplicatedStore) Upload(values DestructuredKey, content io.Reader) (string, error) {
+	key, err := r.Primary.Upload(values, content)
+	if err != nil {
+		return key, err
+	}
+
+	r.replicateOnAll(key)
+	return key, nil
+}
+
+func (r *ReplicatedStore) Copy(origin string, destination DestructuredKey) (string, error) {
+	key, err := r.Primary.Copy(origin, destination)
+	if err != nil {
+		return key, err
+	}
+
+	r.replicateOnAll(key)
+	return key, nil
+}
+
+func (r *ReplicatedStore) Delete(locations []string) error {
+	err := r.Primary.Delete(locations)
+	if err != nil {
+		return err
+	}
+
+	perOwner := make(map[string][]string)
+	for _, location := range locations {
+		owner := ownerFromKey(location)
+		perOwner[owner] = append(perOwner[owner], location)
+	}
+
+	for _, replica := range r.Replicas {
+		err = replica.Adapter.Delete(locations)
+		if err != nil {
+			return errors.Wrapf(err, "failed to delete %d files from replica %s", len(locations), replica.Name)
+		}
+
+		for owner, keys := range perOwner {
+			err = r.Repository.DeleteReplicaStatuses(owner, replica.Name, keys)
+			if err != nil {
+				return errors.Wrapf(err, "failed to delete replication statuses from replica %s", replica.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r *ReplicatedStore) SignedURL(key string, duration time.Duration) (string, error) {
+	return r.Primary.SignedURL(key, duration)
+}
+
+// RetryFailedReplications processes the queue of failed replications of the owner.
+func (r *ReplicatedStore) RetryFailedReplications(owner string) (ReplicationReport, error) {
+	report := ReplicationReport{}
+
+	for _, replica := range r.Replicas {
+		statuses, err := r.Repository.FindReplicaStatuses(owner, replica.Name)
+		if err != nil {
+			return report, errors.Wrapf(err, "failed to find replication statuses on %s", replica.Name)
+		}
+
+		for key, status := range statuses {
+			if status == ReplicaStatusFailed {
+				report.add(r.replicate(replica, key))
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// CatchUp replicates all the keys of the owner present in the primary storage but not yet replicated.
+func (r *ReplicatedStore) CatchUp(owner string, walker StoreKeysWalker) (ReplicationReport, error) {
+	report := ReplicationReport{}
+
+	var keys []string
+	err := walker.WalkCacheByPrefix(owner+"/", func(key string) {
+		keys = append(keys, key)
+	})
+	if err != nil {
+		return report, errors.Wrapf(err, "failed to list keys of %s in primary storage", owner)
+	}
+
+	for _, replica := range r.Replicas {
+		statuses, err := r.Repository.FindReplicaStatuses(owner, replica.Name)
+		if err != nil {
+			return report, errors.Wrapf(err, "failed to find replication statuses on %s", replica.Name)
+		}
+
+		for _, key := range keys {
+			if statuses[key] != ReplicaStatusReplicated {
+				report.add(r.replicate(replica, key))
+			}
+		}
+	}
+
+	return report, nil
+}
+
+func (r *ReplicatedStore) replicateOnAll(key string) {
+	for _, replica := range r.Replicas {
+		r.replicate(replica, key)
+	}
+}
+
+// replicate copies the key from the primary to the replica and records the result ; errors are logged and return false.
+func (r *ReplicatedStore) replicate(replica Replica, key string) bool {
+	status := ReplicaStatusReplicated
+	err := r.copyToReplica(replica, key)
+	if err != nil {
+		status = ReplicaStatusFailed
+		log.WithError(err).WithField("Replica", replica.Name).Warnf("replication of %s failed, it will be retried later", key)
+	}
+
+	statusErr := r.Repository.UpdateReplicaStatus(ownerFromKey(key), replica.Name, key, status)
+	if statusErr != nil {
+		log.WithError(statusErr).WithField("Replica", replica.Name).Errorf("replication status %s of %s couldn't be recorded", status, key)
+	}
+
+	return err == nil
+}
+
+func (r *ReplicatedStore) copyToReplica(replica Replica, key string) error {
+	reader, err := r.Primary.Download(key)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read %s from primary storage", key)
+	}
+	defer reader.Close()
+
+	return errors.Wrapf(replica.Adapter.Put(key, mediaTypeFromKey(key), reader), "failed to write %s on replica %s", key, replica.Name)
+}
+
+func (r *ReplicationReport) add(success bool) {
+	if success {
+		r.Replicated++
+	} else {
+		r.Failed++
+	}
+}
+
+// ownerFromKey follows the key convention from Store: <owner>/<folder name>/<filename>
+func ownerFromKey(key string) string {
+	owner, _, _ := strings.Cut(key, "/")
+	return owner
+}
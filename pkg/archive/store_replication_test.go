@@ -0,0 +1,216 @@
+package archive_test
+
+import (
+	"bytes"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	mocks2 "github.com/thomasduchatelle/dphoto/internal/mocks"
+	"github.com/thomasduchatelle/dphoto/pkg/archive"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestReplicatedStore_Upload(t *testing.T) {
+	const key = owner + "/folder-1/2022-06-26_15-48-42_qwertyui.jpg"
+	keyHint := archive.DestructuredKey{Prefix: owner + "/folder-1/2022-06-26_15-48-42_qwertyui", Suffix: ".jpg"}
+
+	tests := []struct {
+		name          string
+		replicaErr    error
+		wantReplicas  map[string]string
+		wantStatuses  map[string]archive.ReplicaStatus
+		uploadErr     error
+		wantUploadErr bool
+	}{
+		{
+			name:         "it should copy the uploaded file on the replica with the same key",
+			wantReplicas: map[string]string{key: "foobar"},
+			wantStatuses: map[string]archive.ReplicaStatus{"nas:" + key: archive.ReplicaStatusReplicated},
+		},
+		{
+			name:         "it should queue the replication to retry it later when it failed",
+			replicaErr:   errors.Errorf("TEST - replica is not reachable"),
+			wantReplicas: map[string]string{},
+			wantStatuses: map[string]archive.ReplicaStatus{"nas:" + key: archive.ReplicaStatusFailed},
+		},
+		{
+			name:          "it should not replicate when the upload on primary failed",
+			uploadErr:     errors.Errorf("TEST - primary is not reachable"),
+			wantReplicas:  map[string]string{},
+			wantStatuses:  map[string]archive.ReplicaStatus{},
+			wantUploadErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primary := mocks2.NewStoreAdapter(t)
+			primary.On("Upload", keyHint, mock.Anything).Once().Return(key, tt.uploadErr)
+			if tt.uploadErr == nil {
+				primary.On("Download", key).Once().Return(io.NopCloser(bytes.NewReader([]byte("foobar"))), nil)
+			}
+
+			replica := &ReplicaFake{Content: make(map[string]string), Err: tt.replicaErr}
+			repository := make(ReplicaRepositoryFake)
+
+			store := archive.NewReplicatedStore(primary, repository, archive.Replica{Name: "nas", Adapter: replica})
+			got, err := store.Upload(keyHint, bytes.NewReader([]byte("foobar")))
+
+			if tt.wantUploadErr {
+				assert.Error(t, err)
+			} else if assert.NoError(t, err, "it should not fail the upload when the replication failed") {
+				assert.Equal(t, key, got)
+			}
+			assert.Equal(t, tt.wantReplicas, replica.Content)
+			assert.Equal(t, tt.wantStatuses, map[string]archive.ReplicaStatus(repository))
+		})
+	}
+}
+
+func TestReplicatedStore_CatchUp(t *testing.T) {
+	a := assert.New(t)
+	const key1 = owner + "/folder-1/image-01.jpg"
+	const key2 = owner + "/folder-1/image-02.jpg"
+	const key3 = owner + "/folder-1/image-03.jpg"
+
+	primary := mocks2.NewStoreAdapter(t)
+	primary.On("Download", key2).Once().Return(io.NopCloser(bytes.NewReader([]byte("content-2"))), nil)
+	primary.On("Download", key3).Once().Return(io.NopCloser(bytes.NewReader([]byte("content-3"))), nil)
+
+	replica := &ReplicaFake{Content: make(map[string]string)}
+	repository := ReplicaRepositoryFake{
+		"nas:" + key1: archive.ReplicaStatusReplicated,
+		"nas:" + key2: archive.ReplicaStatusFailed,
+	}
+
+	store := archive.NewReplicatedStore(primary, repository, archive.Replica{Name: "nas", Adapter: replica})
+	report, err := store.CatchUp(owner, StoreKeysWalkerFake{key1, key2, key3})
+
+	if a.NoError(err) {
+		a.Equal(archive.ReplicationReport{Replicated: 2}, report)
+		a.Equal(map[string]string{key2: "content-2", key3: "content-3"}, replica.Content, "it should replicate keys failed or never replicated")
+		a.Equal(ReplicaRepositoryFake{
+			"nas:" + key1: archive.ReplicaStatusReplicated,
+			"nas:" + key2: archive.ReplicaStatusReplicated,
+			"nas:" + key3: archive.ReplicaStatusReplicated,
+		}, repository)
+	}
+}
+
+func TestReplicatedStore_RetryFailedReplications(t *testing.T) {
+	a := assert.New(t)
+	const key1 = owner + "/folder-1/image-01.jpg"
+	const key2 = owner + "/folder-1/image-02.jpg"
+
+	primary := mocks2.NewStoreAdapter(t)
+	primary.On("Download", key2).Once().Return(io.NopCloser(bytes.NewReader([]byte("content-2"))), nil)
+
+	replica := &ReplicaFake{Content: make(map[string]string)}
+	repository := ReplicaRepositoryFake{
+		"nas:" + key1: archive.ReplicaStatusReplicated,
+		"nas:" + key2: archive.ReplicaStatusFailed,
+	}
+
+	store := archive.NewReplicatedStore(primary, repository, archive.Replica{Name: "nas", Adapter: replica})
+	report, err := store.RetryFailedReplications(owner)
+
+	if a.NoError(err) {
+		a.Equal(archive.ReplicationReport{Replicated: 1}, report)
+		a.Equal(map[string]string{key2: "content-2"}, replica.Content, "it should only replicate failed keys")
+	}
+}
+
+func TestReplicatedStore_Relocate(t *testing.T) {
+	a := assert.New(t)
+	const previousKey = owner + "/folder-1/2022-06-26_15-48-42_qwertyui.jpg"
+	const newKey = owner + "/folder-2/2022-06-26_15-48-42_qwertyui.jpg"
+
+	repositoryAdapter := mocks2.NewARepositoryAdapter(t)
+	repositoryAdapter.On("FindByIds", owner, []string{"id-01"}).Once().Return(map[string]string{"id-01": previousKey}, nil)
+	repositoryAdapter.On("UpdateLocations", owner, map[string]string{"id-01": newKey}).Once().Return(nil)
+
+	primary := mocks2.NewStoreAdapter(t)
+	primary.On("Copy", previousKey, archive.DestructuredKey{Prefix: owner + "/folder-2/2022-06-26_15-48-42_qwertyui", Suffix: ".jpg"}).Once().Return(newKey, nil)
+	primary.On("Download", newKey).Twice().Return(func(string) (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader([]byte("foobar"))), nil
+	})
+	primary.On("Delete", []string{previousKey}).Once().Return(nil)
+
+	nas := &ReplicaFake{Content: map[string]string{previousKey: "foobar"}}
+	offsite := &ReplicaFake{Content: map[string]string{previousKey: "foobar"}}
+	repository := ReplicaRepositoryFake{
+		"nas:" + previousKey:     archive.ReplicaStatusReplicated,
+		"offsite:" + previousKey: archive.ReplicaStatusReplicated,
+	}
+
+	store := archive.NewReplicatedStore(primary, repository, archive.Replica{Name: "nas", Adapter: nas}, archive.Replica{Name: "offsite", Adapter: offsite})
+	archive.Init(repositoryAdapter, store, mocks2.NewCacheAdapter(t), mocks2.NewAsyncJobAdapter(t))
+
+	err := archive.Relocate(owner, []string{"id-01"}, "/folder-2")
+	if a.NoError(err) {
+		a.Equal(map[string]string{newKey: "foobar"}, nas.Content, "it should move the file on the first replica")
+		a.Equal(map[string]string{newKey: "foobar"}, offsite.Content, "it should move the file on the second replica")
+		a.Equal(ReplicaRepositoryFake{
+			"nas:" + newKey:     archive.ReplicaStatusReplicated,
+			"offsite:" + newKey: archive.ReplicaStatusReplicated,
+		}, repository)
+	}
+}
+
+type ReplicaFake struct {
+	Content map[string]string
+	Err     error
+}
+
+func (r *ReplicaFake) Put(key string, mediaType string, content io.Reader) error {
+	if r.Err != nil {
+		return r.Err
+	}
+
+	value, err := io.ReadAll(content)
+	r.Content[key] = string(value)
+	return err
+}
+
+func (r *ReplicaFake) Delete(locations []string) error {
+	for _, location := range locations {
+		delete(r.Content, location)
+	}
+	return nil
+}
+
+// ReplicaRepositoryFake keys are "<replica>:<key>"
+type ReplicaRepositoryFake map[string]archive.ReplicaStatus
+
+func (r ReplicaRepositoryFake) UpdateReplicaStatus(owner, replica, key string, status archive.ReplicaStatus) error {
+	r[replica+":"+key] = status
+	return nil
+}
+
+func (r ReplicaRepositoryFake) FindReplicaStatuses(owner, replica string) (map[string]archive.ReplicaStatus, error) {
+	statuses := make(map[string]archive.ReplicaStatus)
+	for replicaKey, status := range r {
+		if name, key, _ := strings.Cut(replicaKey, ":"); name == replica {
+			statuses[key] = status
+		}
+	}
+	return statuses, nil
+}
+
+func (r ReplicaRepositoryFake) DeleteReplicaStatuses(owner, replica string, keys []string) error {
+	for _, key := range keys {
+		delete(r, replica+":"+key)
+	}
+	return nil
+}
+
+type StoreKeysWalkerFake []string
+
+func (s StoreKeysWalkerFake) WalkCacheByPrefix(prefix string, observer func(string)) error {
+	for _, key := range s {
+		observer(key)
+	}
+	return nil
+}
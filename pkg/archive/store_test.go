@@ -33,7 +33,7 @@ func TestStore(t *testing.T) {
 			mocksExpectation: func(repository *mocks2.ARepositoryAdapter, store *mocks2.StoreAdapter, cache *mocks2.CacheAdapter, resizer *mocks2.ResizerAdapter, asyncJob *mocks2.AsyncJobAdapter) {
 				repository.On("FindById", owner, "media-1").Once().Return("", archive.NotFoundError)
 				repository.On("AddLocation", owner, "media-1", owner+"/folder-1/my_choice.jpg").Once().Return(nil)
-				store.On("Upload", archive.DestructuredKey{Prefix: owner + "/folder-1/2022-06-26_15-48-42_qwertyui", Suffix: ".jpg"}, mock.Anything).Once().Return(owner+"/folder-1/my_choice.jpg", nil)
+				store.On("Upload", archive.DestructuredKey{Prefix: owner + "/folder-1/2022-06-26_15-48-42_qwertyui", Suffix: ".jpg", ContentSha256: "qwertyuiopasdfghjklzxcvbnm"}, mock.Anything).Once().Return(owner+"/folder-1/my_choice.jpg", nil)
 
 				asyncJob.On("LoadImagesInCache", mock.Anything).Once().Return(func(images ...*archive.ImageToResize) error {
 					if assert.Len(t, images, 1) {
@@ -95,7 +95,7 @@ func TestStore(t *testing.T) {
 			mocksExpectation: func(repository *mocks2.ARepositoryAdapter, store *mocks2.StoreAdapter, cache *mocks2.CacheAdapter, resizer *mocks2.ResizerAdapter, asyncJob *mocks2.AsyncJobAdapter) {
 				repository.On("FindById", owner, "video-1").Once().Return("", archive.NotFoundError)
 				repository.On("AddLocation", owner, "video-1", owner+"/folder-1/my_choice.mpeg").Once().Return(nil)
-				store.On("Upload", archive.DestructuredKey{Prefix: owner + "/folder-1/2022-06-26_15-48-42_qwertyui", Suffix: ".mpeg"}, mock.Anything).Once().Return(owner+"/folder-1/my_choice.mpeg", nil)
+				store.On("Upload", archive.DestructuredKey{Prefix: owner + "/folder-1/2022-06-26_15-48-42_qwertyui", Suffix: ".mpeg", ContentSha256: "qwertyuiopasdfghjklzxcvbnm"}, mock.Anything).Once().Return(owner+"/folder-1/my_choice.mpeg", nil)
 			},
 			request: &archive.StoreRequest{
 				DateTime:         time.Date(2022, 6, 26, 15, 48, 42, 0, time.UTC),
@@ -0,0 +1,192 @@
+package archive
+
+import (
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	StorageStateHot              StorageState = ""                  // StorageStateHot is the default: the original can be downloaded immediately
+	StorageStateCold             StorageState = "COLD"              // StorageStateCold is set when the original has been moved to the cold storage class
+	StorageStateRestoreRequested StorageState = "RESTORE_REQUESTED" // StorageStateRestoreRequested is set when a temporary copy of a cold original has been requested
+
+	DefaultRestoreDuration = 7 * 24 * time.Hour // DefaultRestoreDuration is how long a restored original stays readable
+)
+
+var (
+	tieringRepositoryPort TieringRepositoryAdapter
+	tieringStorePort      TieringStoreAdapter
+)
+
+// StorageState is recorded on the location of the media.
+type StorageState string
+
+// StorageStatus is the physical status of a key in the store.
+type StorageStatus struct {
+	StorageClass   string    // StorageClass is the class of the key, empty for the standard class
+	Archived       bool      // Archived is true when the content is not readable until it has been restored
+	RestoreOngoing bool      // RestoreOngoing is true when a restore has been requested and is not complete yet
+	RestoredUntil  time.Time // RestoredUntil is set when the temporary restored copy is readable
+}
+
+// IsReadable returns true when the content can be downloaded immediately.
+func (s StorageStatus) IsReadable() bool {
+	return !s.Archived || (!s.RestoreOngoing && !s.RestoredUntil.IsZero())
+}
+
+// TieringStoreAdapter is an extension of the StoreAdapter supporting storage classes.
+type TieringStoreAdapter interface {
+	// ChangeStorageClass moves the key to a different storage class, keeping the same key
+	ChangeStorageClass(key string, storageClass string) error
+
+	// GetStorageStatus returns the storage class and restore status of the key, or a NotFoundError
+	GetStorageStatus(key string) (StorageStatus, error)
+
+	// RequestRestore creates a temporary readable copy of an archived key ; it must be idempotent
+	RequestRestore(key string, duration time.Duration) error
+}
+
+// TieringRepositoryAdapter records the StorageState on the location of each media.
+type TieringRepositoryAdapter interface {
+	// FindStorageStates returns the state of each media found ; StorageStateHot if never tiered
+	FindStorageStates(owner string, ids []string) (map[string]StorageState, error)
+
+	// UpdateStorageState sets the state on the location of each media
+	UpdateStorageState(owner string, ids []string, state StorageState) error
+}
+
+// InitTiering enables storage tiering, it must be called after Init ; GetMediaOriginalURL ignores storage classes when not called.
+func InitTiering(repository TieringRepositoryAdapter, store TieringStoreAdapter) {
+	tieringRepositoryPort = repository
+	tieringStorePort = store
+}
+
+// TieringAlbum is the minimal information about an album required to apply the TieringPolicy.
+type TieringAlbum struct {
+	FolderName string
+	End        time.Time // End is the (exclusive) date of the last media of the album
+}
+
+// TieringPolicy moves the originals of old albums to a cold storage class.
+type TieringPolicy struct {
+	ColdAfter        time.Duration // ColdAfter is the age of an album (from its end) after which its originals are moved to cold storage
+	ColdStorageClass string        // ColdStorageClass is the class used for cold originals (ex: GLACIER, DEEP_ARCHIVE)
+	Now              func() time.Time
+	DryRun           bool // DryRun only reports what would be moved
+}
+
+// TieringReport counts the medias moved to cold storage per album folder.
+type TieringReport map[string]int
+
+// Apply moves the originals of the albums older than ColdAfter that are not already cold.
+func (p *TieringPolicy) Apply(owner string, albums []TieringAlbum) (TieringReport, error) {
+	if p.ColdStorageClass == "" {
+		return nil, errors.Errorf("cold storage class is mandatory")
+	}
+
+	now := time.Now()
+	if p.Now != nil {
+		now = p.Now()
+	}
+
+	report := make(TieringReport)
+	for _, album := range albums {
+		if album.End.IsZero() || now.Sub(album.End) < p.ColdAfter {
+			continue
+		}
+
+		count, err := p.moveAlbumToCold(owner, album.FolderName)
+		if err != nil {
+			return report, errors.Wrapf(err, "failed to move %s to cold storage", album.FolderName)
+		}
+		if count > 0 {
+			report[album.FolderName] = count
+		}
+	}
+
+	return report, nil
+}
+
+func (p *TieringPolicy) moveAlbumToCold(owner string, folderName string) (int, error) {
+	prefix := path.Join(owner, strings.Trim(folderName, "/"))
+	locations, err := repositoryPort.FindIdsFromKeyPrefix(prefix)
+	if err != nil || len(locations) == 0 {
+		return 0, err
+	}
+
+	ids := make([]string, 0, len(locations))
+	for id := range locations {
+		ids = append(ids, id)
+	}
+
+	states, err := tieringRepositoryPort.FindStorageStates(owner, ids)
+	if err != nil {
+		return 0, err
+	}
+
+	var moved []string
+	for id, key := range locations {
+		if states[id] != StorageStateHot {
+			continue
+		}
+
+		if !p.DryRun {
+			err = tieringStorePort.ChangeStorageClass(key, p.ColdStorageClass)
+			if err != nil {
+				return 0, err
+			}
+		}
+		moved = append(moved, id)
+	}
+
+	if len(moved) > 0 && !p.DryRun {
+		err = tieringRepositoryPort.UpdateStorageState(owner, moved, StorageStateCold)
+		log.WithField("Owner", owner).Infof("%d originals from %s moved to %s storage class", len(moved), prefix, p.ColdStorageClass)
+	}
+	return len(moved), err
+}
+
+// checkOriginalIsReadable returns RestoreInProgressError, and requests the restore, if the original is archived in cold storage.
+func checkOriginalIsReadable(owner, mediaId, key string) error {
+	states, err := findStorageStates(owner, []string{mediaId})
+	if err != nil || states[mediaId] == StorageStateHot {
+		return err
+	}
+
+	return requestRestoreIfArchived(owner, mediaId, key)
+}
+
+// findStorageStates returns the state of the medias that have been tiered ; it's empty when the tiering is not enabled.
+func findStorageStates(owner string, ids []string) (map[string]StorageState, error) {
+	if tieringRepositoryPort == nil {
+		return nil, nil
+	}
+
+	return tieringRepositoryPort.FindStorageStates(owner, ids)
+}
+
+// requestRestoreIfArchived returns RestoreInProgressError, and requests the restore once, until the archived original is readable.
+func requestRestoreIfArchived(owner, mediaId, key string) error {
+	status, err := tieringStorePort.GetStorageStatus(key)
+	if err != nil || status.IsReadable() {
+		return err
+	}
+
+	if !status.RestoreOngoing {
+		err = tieringStorePort.RequestRestore(key, DefaultRestoreDuration)
+		if err != nil {
+			return errors.Wrapf(err, "failed to request restore of %s", key)
+		}
+
+		err = tieringRepositoryPort.UpdateStorageState(owner, []string{mediaId}, StorageStateRestoreRequested)
+		if err != nil {
+			return err
+		}
+		log.WithField("Owner", owner).Infof("Restore of %s has been requested", key)
+	}
+
+	return RestoreInProgressError
+}
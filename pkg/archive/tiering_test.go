@@ -0,0 +1,348 @@
+package archive_test
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	mocks2 "github.com/thomasduchatelle/dphoto/internal/mocks"
+	"github.com/thomasduchatelle/dphoto/pkg/archive"
+	"testing"
+	"time"
+)
+
+func TestTieringPolicy_Apply(t *testing.T) {
+	const key1 = owner + "/2021-summer/2021-07-01_10-00-00_qwertyui.jpg"
+	const key2 = owner + "/2021-summer/2021-07-02_10-00-00_asdfghjk.jpg"
+	now := time.Date(2023, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		albums     []archive.TieringAlbum
+		states     map[string]archive.StorageState
+		dryRun     bool
+		want       archive.TieringReport
+		wantStates map[string]archive.StorageState
+		wantClass  map[string]string
+	}{
+		{
+			name:       "it should move the originals of albums older than the threshold to the cold storage",
+			albums:     []archive.TieringAlbum{{FolderName: "/2021-summer", End: time.Date(2021, 9, 1, 0, 0, 0, 0, time.UTC)}},
+			want:       archive.TieringReport{"/2021-summer": 2},
+			wantStates: map[string]archive.StorageState{"id-1": archive.StorageStateCold, "id-2": archive.StorageStateCold},
+			wantClass:  map[string]string{key1: "GLACIER", key2: "GLACIER"},
+		},
+		{
+			name:       "it should not move originals already in cold storage",
+			albums:     []archive.TieringAlbum{{FolderName: "/2021-summer", End: time.Date(2021, 9, 1, 0, 0, 0, 0, time.UTC)}},
+			states:     map[string]archive.StorageState{"id-1": archive.StorageStateCold},
+			want:       archive.TieringReport{"/2021-summer": 1},
+			wantStates: map[string]archive.StorageState{"id-1": archive.StorageStateCold, "id-2": archive.StorageStateCold},
+			wantClass:  map[string]string{key2: "GLACIER"},
+		},
+		{
+			name:       "it should not move the originals of recent albums",
+			albums:     []archive.TieringAlbum{{FolderName: "/2021-summer", End: time.Date(2022, 9, 1, 0, 0, 0, 0, time.UTC)}},
+			want:       archive.TieringReport{},
+			wantStates: map[string]archive.StorageState{},
+			wantClass:  map[string]string{},
+		},
+		{
+			name:       "it should only report the originals that would be moved in dry run",
+			albums:     []archive.TieringAlbum{{FolderName: "/2021-summer", End: time.Date(2021, 9, 1, 0, 0, 0, 0, time.UTC)}},
+			dryRun:     true,
+			want:       archive.TieringReport{"/2021-summer": 2},
+			wantStates: map[string]archive.StorageState{},
+			wantClass:  map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repository := mocks2.NewARepositoryAdapter(t)
+			repository.On("FindIdsFromKeyPrefix", owner+"/2021-summer").Maybe().Return(map[string]string{"id-1": key1, "id-2": key2}, nil)
+
+			tieringRepository := &TieringRepositoryFake{States: make(map[string]archive.StorageState)}
+			for id, state := range tt.states {
+				tieringRepository.States[id] = state
+			}
+			tieringStore := &TieringStoreFake{Classes: make(map[string]string)}
+
+			archive.Init(repository, mocks2.NewStoreAdapter(t), mocks2.NewCacheAdapter(t), mocks2.NewAsyncJobAdapter(t))
+			archive.InitTiering(tieringRepository, tieringStore)
+
+			policy := &archive.TieringPolicy{
+				ColdAfter:        365 * 24 * time.Hour,
+				ColdStorageClass: "GLACIER",
+				Now:              func() time.Time { return now },
+				DryRun:           tt.dryRun,
+			}
+			got, err := policy.Apply(owner, tt.albums)
+
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.want, got)
+				for id, state := range tt.states {
+					tt.wantStates[id] = state
+				}
+				assert.Equal(t, tt.wantStates, tieringRepository.States)
+				assert.Equal(t, tt.wantClass, tieringStore.Classes)
+			}
+		})
+	}
+}
+
+func TestGetMediaOriginalURL_tiering(t *testing.T) {
+	const key = owner + "/2021-summer/2021-07-01_10-00-00_qwertyui.jpg"
+
+	tests := []struct {
+		name             string
+		state            archive.StorageState
+		status           archive.StorageStatus
+		want             string
+		wantErr          error
+		wantRestore      bool
+		wantStorageState archive.StorageState
+	}{
+		{
+			name:  "it should sign the URL of originals not in cold storage",
+			state: archive.StorageStateHot,
+			want:  "/a/url?signed",
+		},
+		{
+			name:             "it should request the restore of an archived original",
+			state:            archive.StorageStateCold,
+			status:           archive.StorageStatus{StorageClass: "GLACIER", Archived: true},
+			wantErr:          archive.RestoreInProgressError,
+			wantRestore:      true,
+			wantStorageState: archive.StorageStateRestoreRequested,
+		},
+		{
+			name:             "it should not request the restore twice",
+			state:            archive.StorageStateRestoreRequested,
+			status:           archive.StorageStatus{StorageClass: "GLACIER", Archived: true, RestoreOngoing: true},
+			wantErr:          archive.RestoreInProgressError,
+			wantStorageState: archive.StorageStateRestoreRequested,
+		},
+		{
+			name:             "it should sign the URL of a restored original",
+			state:            archive.StorageStateRestoreRequested,
+			status:           archive.StorageStatus{StorageClass: "GLACIER", Archived: true, RestoredUntil: time.Now().Add(24 * time.Hour)},
+			want:             "/a/url?signed",
+			wantStorageState: archive.StorageStateRestoreRequested,
+		},
+		{
+			name:             "it should sign the URL of originals in an instant retrieval class",
+			state:            archive.StorageStateCold,
+			status:           archive.StorageStatus{StorageClass: "GLACIER_IR"},
+			want:             "/a/url?signed",
+			wantStorageState: archive.StorageStateCold,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repository := mocks2.NewARepositoryAdapter(t)
+			repository.On("FindById", owner, "id-1").Once().Return(key, nil)
+			store := mocks2.NewStoreAdapter(t)
+			if tt.wantErr == nil {
+				store.On("SignedURL", key, archive.DownloadUrlValidityDuration).Once().Return("/a/url?signed", nil)
+			}
+
+			tieringRepository := &TieringRepositoryFake{States: make(map[string]archive.StorageState)}
+			if tt.state != archive.StorageStateHot {
+				tieringRepository.States["id-1"] = tt.state
+			}
+			tieringStore := &TieringStoreFake{Classes: make(map[string]string), Statuses: map[string]archive.StorageStatus{key: tt.status}}
+
+			archive.Init(repository, store, mocks2.NewCacheAdapter(t), mocks2.NewAsyncJobAdapter(t))
+			archive.InitTiering(tieringRepository, tieringStore)
+
+			got, err := archive.GetMediaOriginalURL(owner, "id-1")
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else if assert.NoError(t, err) {
+				assert.Equal(t, tt.want, got)
+			}
+
+			if tt.wantRestore {
+				assert.Equal(t, []string{key}, tieringStore.Restores)
+			} else {
+				assert.Empty(t, tieringStore.Restores)
+			}
+			assert.Equal(t, tt.wantStorageState, tieringRepository.States["id-1"])
+		})
+	}
+}
+
+func TestRelocate_tiering(t *testing.T) {
+	const hotKey = owner + "/2021-summer/2021-07-01_10-00-00_hot00001.jpg"
+	const coldKey = owner + "/2021-summer/2021-07-01_11-00-00_cold0001.jpg"
+	hotDestination := archive.DestructuredKey{Prefix: owner + "/2021-july/2021-07-01_10-00-00_hot00001", Suffix: ".jpg"}
+	coldDestination := archive.DestructuredKey{Prefix: owner + "/2021-july/2021-07-01_11-00-00_cold0001", Suffix: ".jpg"}
+
+	tests := []struct {
+		name             string
+		locations        map[string]string
+		coldStatus       archive.StorageStatus
+		spec             func(repository *mocks2.ARepositoryAdapter, store *mocks2.StoreAdapter)
+		wantErr          error
+		wantRestores     []string
+		wantStorageState archive.StorageState
+	}{
+		{
+			name:       "it should request the restore of an archived original, relocate the others, and ask to retry",
+			locations:  map[string]string{"id-hot": hotKey, "id-cold": coldKey},
+			coldStatus: archive.StorageStatus{StorageClass: "DEEP_ARCHIVE", Archived: true},
+			spec: func(repository *mocks2.ARepositoryAdapter, store *mocks2.StoreAdapter) {
+				store.On("Copy", hotKey, hotDestination).Once().Return(hotDestination.Prefix+hotDestination.Suffix, nil)
+				repository.On("UpdateLocations", owner, map[string]string{"id-hot": hotDestination.Prefix + hotDestination.Suffix}).Once().Return(nil)
+				store.On("Delete", []string{hotKey}).Once().Return(nil)
+			},
+			wantErr:          archive.RestoreInProgressError,
+			wantRestores:     []string{coldKey},
+			wantStorageState: archive.StorageStateRestoreRequested,
+		},
+		{
+			name:       "it should relocate a restored original, the copy being in cold storage without being restored",
+			locations:  map[string]string{"id-cold": coldKey},
+			coldStatus: archive.StorageStatus{StorageClass: "GLACIER", Archived: true, RestoredUntil: time.Now().Add(24 * time.Hour)},
+			spec: func(repository *mocks2.ARepositoryAdapter, store *mocks2.StoreAdapter) {
+				store.On("Copy", coldKey, coldDestination).Once().Return(coldDestination.Prefix+coldDestination.Suffix, nil)
+				repository.On("UpdateLocations", owner, map[string]string{"id-cold": coldDestination.Prefix + coldDestination.Suffix}).Once().Return(nil)
+				store.On("Delete", []string{coldKey}).Once().Return(nil)
+			},
+			wantStorageState: archive.StorageStateCold,
+		},
+		{
+			name:       "it should not relocate again an original already moved by a previous attempt",
+			locations:  map[string]string{"id-hot": hotDestination.Prefix + hotDestination.Suffix, "id-cold": coldKey},
+			coldStatus: archive.StorageStatus{StorageClass: "GLACIER", Archived: true, RestoreOngoing: true},
+			spec: func(repository *mocks2.ARepositoryAdapter, store *mocks2.StoreAdapter) {
+			},
+			wantErr:          archive.RestoreInProgressError,
+			wantStorageState: archive.StorageStateCold,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ids []string
+			for _, id := range []string{"id-hot", "id-cold"} {
+				if _, found := tt.locations[id]; found {
+					ids = append(ids, id)
+				}
+			}
+
+			repository := mocks2.NewARepositoryAdapter(t)
+			repository.On("FindByIds", owner, ids).Once().Return(tt.locations, nil)
+			store := mocks2.NewStoreAdapter(t)
+			tt.spec(repository, store)
+
+			tieringRepository := &TieringRepositoryFake{States: map[string]archive.StorageState{"id-cold": archive.StorageStateCold}}
+			tieringStore := &TieringStoreFake{Classes: make(map[string]string), Statuses: map[string]archive.StorageStatus{coldKey: tt.coldStatus}}
+
+			archive.Init(repository, store, mocks2.NewCacheAdapter(t), mocks2.NewAsyncJobAdapter(t))
+			archive.InitTiering(tieringRepository, tieringStore)
+
+			err := archive.Relocate(owner, ids, "/2021-july")
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.wantRestores, tieringStore.Restores)
+			assert.Equal(t, tt.wantStorageState, tieringRepository.States["id-cold"])
+		})
+	}
+}
+
+func TestGetResizedImage_tiering(t *testing.T) {
+	const key = owner + "/2021-summer/2021-07-01_10-00-00_qwertyui.jpg"
+
+	repository := mocks2.NewARepositoryAdapter(t)
+	repository.On("FindById", owner, "id-1").Once().Return(key, nil)
+	cache := mocks2.NewCacheAdapter(t)
+	cache.On("Get", "miniatures/"+owner+"/id-1").Once().Return(nil, 0, "", archive.NotFoundError)
+
+	tieringRepository := &TieringRepositoryFake{States: map[string]archive.StorageState{"id-1": archive.StorageStateCold}}
+	tieringStore := &TieringStoreFake{Statuses: map[string]archive.StorageStatus{key: {StorageClass: "GLACIER", Archived: true}}}
+
+	archive.Init(repository, mocks2.NewStoreAdapter(t), cache, mocks2.NewAsyncJobAdapter(t))
+	archive.InitTiering(tieringRepository, tieringStore)
+
+	_, _, err := archive.GetResizedImage(owner, "id-1", archive.MiniatureCachedWidth, 0)
+	assert.ErrorIs(t, err, archive.RestoreInProgressError, "it should not download an archived original to resize it")
+	assert.Equal(t, []string{key}, tieringStore.Restores)
+	assert.Equal(t, archive.StorageStateRestoreRequested, tieringRepository.States["id-1"])
+}
+
+func TestWarmUpCacheByFolder_tiering(t *testing.T) {
+	const hotKey = owner + "/2021-summer/2021-07-01_10-00-00_hot00001.jpg"
+	const coldKey = owner + "/2021-summer/2021-07-01_11-00-00_cold0001.jpg"
+
+	repository := mocks2.NewARepositoryAdapter(t)
+	repository.On("FindIdsFromKeyPrefix", owner+"/2021-summer").Once().Return(map[string]string{"id-hot": hotKey, "id-cold": coldKey}, nil)
+	cache := mocks2.NewCacheAdapter(t)
+	cache.On("WalkCacheByPrefix", "miniatures/"+owner+"/", mock.Anything).Once().Return(nil)
+	asyncJob := mocks2.NewAsyncJobAdapter(t)
+	asyncJob.On("LoadImagesInCache", &archive.ImageToResize{Owner: owner, MediaId: "id-hot", StoreKey: hotKey, Widths: []int{archive.MiniatureCachedWidth}}).Once().Return(nil)
+
+	tieringRepository := &TieringRepositoryFake{States: map[string]archive.StorageState{"id-cold": archive.StorageStateCold}}
+	tieringStore := &TieringStoreFake{Statuses: map[string]archive.StorageStatus{coldKey: {StorageClass: "GLACIER", Archived: true}}}
+
+	archive.Init(repository, mocks2.NewStoreAdapter(t), cache, asyncJob)
+	archive.InitTiering(tieringRepository, tieringStore)
+
+	err := archive.WarmUpCacheByFolder(owner, hotKey, archive.MiniatureCachedWidth)
+	assert.NoError(t, err)
+	assert.Empty(t, tieringStore.Restores, "it should not request the restore of a whole folder to warm up the cache")
+
+	count, err := archive.LoadImagesInCache(context.Background(), &archive.ImageToResize{Owner: owner, MediaId: "id-cold", StoreKey: coldKey, Widths: []int{archive.MiniatureCachedWidth}})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, []string{coldKey}, tieringStore.Restores, "it should request the restore of an original explicitly queued instead of downloading it")
+}
+
+type TieringRepositoryFake struct {
+	States map[string]archive.StorageState // States are indexed by media id, the owner is ignored
+}
+
+func (t *TieringRepositoryFake) FindStorageStates(owner string, ids []string) (map[string]archive.StorageState, error) {
+	states := make(map[string]archive.StorageState)
+	for _, id := range ids {
+		if state, found := t.States[id]; found {
+			states[id] = state
+		}
+	}
+	return states, nil
+}
+
+func (t *TieringRepositoryFake) UpdateStorageState(owner string, ids []string, state archive.StorageState) error {
+	for _, id := range ids {
+		t.States[id] = state
+	}
+	return nil
+}
+
+type TieringStoreFake struct {
+	Classes  map[string]string
+	Statuses map[string]archive.StorageStatus
+	Restores []string
+}
+
+func (t *TieringStoreFake) ChangeStorageClass(key string, storageClass string) error {
+	t.Classes[key] = storageClass
+	return nil
+}
+
+func (t *TieringStoreFake) GetStorageStatus(key string) (archive.StorageStatus, error) {
+	if status, found := t.Statuses[key]; found {
+		return status, nil
+	}
+	return archive.StorageStatus{}, archive.NotFoundError
+}
+
+func (t *TieringStoreFake) RequestRestore(key string, duration time.Duration) error {
+	t.Restores = append(t.Restores, key)
+	return nil
+}
@@ -0,0 +1,57 @@
+package archivedynamo
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/pkg/errors"
+	"github.com/thomasduchatelle/dphoto/pkg/archive"
+)
+
+// NewDataKeyRepository stores the wrapped data keys in the same table than media locations.
+func NewDataKeyRepository(client *dynamodb.Client, tableName string) archive.DataKeyRepositoryAdapter {
+	return &repository{
+		db:    client,
+		table: tableName,
+	}
+}
+
+func (r *repository) FindDataKey(owner string) ([]byte, error) {
+	item, err := r.db.GetItem(context.TODO(), &dynamodb.GetItemInput{
+		Key:       DataKeyPk(owner).ToAttributes(),
+		TableName: &r.table,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "FindDataKey %s failed", owner)
+	}
+
+	if len(item.Item) == 0 {
+		return nil, archive.NotFoundError
+	}
+
+	var record DataKeyRecord
+	err = attributevalue.UnmarshalMap(item.Item, &record)
+	return record.WrappedDataKey, errors.Wrapf(err, "DataKeyRecord cannot be unmarshalled for %s", owner)
+}
+
+func (r *repository) InsertDataKey(owner string, wrappedKey []byte) error {
+	if isBlank(owner) || len(wrappedKey) == 0 {
+		return errors.Errorf("owner and wrapped key are mandatory")
+	}
+
+	item, err := attributevalue.MarshalMap(&DataKeyRecord{
+		TablePk:        DataKeyPk(owner),
+		WrappedDataKey: wrappedKey,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal data key")
+	}
+
+	_, err = r.db.PutItem(context.TODO(), &dynamodb.PutItemInput{
+		ConditionExpression: aws.String("attribute_not_exists(PK)"),
+		Item:                item,
+		TableName:           &r.table,
+	})
+	return errors.Wrapf(err, "failed to insert data key of %s", owner)
+}
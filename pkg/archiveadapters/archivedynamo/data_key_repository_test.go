@@ -0,0 +1,26 @@
+package archivedynamo
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/thomasduchatelle/dphoto/pkg/archive"
+	"github.com/thomasduchatelle/dphoto/pkg/awssupport/dynamotestutils"
+	"testing"
+)
+
+func TestDataKeys(t *testing.T) {
+	a := assert.New(t)
+	dyn := dynamotestutils.NewTestContext(context.Background(), t)
+	repo := NewDataKeyRepository(dyn.Client, dyn.Table)
+
+	_, err := repo.FindDataKey(owner)
+	a.Equal(archive.NotFoundError, err, "it should return NotFoundError when the owner has no data key")
+
+	a.NoError(repo.InsertDataKey(owner, []byte("wrapped-1")))
+	a.Error(repo.InsertDataKey(owner, []byte("wrapped-2")), "it should not override an existing data key")
+
+	got, err := repo.FindDataKey(owner)
+	if a.NoError(err) {
+		a.Equal([]byte("wrapped-1"), got)
+	}
+}
@@ -1,6 +1,7 @@
 package archivedynamo
 
 import (
+	"fmt"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	dynamodbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/pkg/errors"
@@ -14,6 +15,8 @@ type MediaLocationRecord struct {
 	LocationKeyPrefix string // LocationKeyPrefix is used for indexing
 	LocationId        string // LocationId is also part of the primary key
 	LocationKey       string // LocationKey is the physical location
+
+	LocationStorageState string `dynamodbav:",omitempty"` // LocationStorageState is set when the original has been moved to cold storage ; it is reset when the location is updated
 }
 
 func MediaLocationPk(owner, id string) appdynamodb.TablePk {
@@ -57,3 +60,32 @@ func unmarshalMediaLocation(attributes map[string]dynamodbtypes.AttributeValue)
 func isBlank(value string) bool {
 	return strings.Trim(value, " ") == ""
 }
+
+type ReplicaStatusRecord struct {
+	appdynamodb.TablePk
+	ReplicaKey    string // ReplicaKey is the key in the primary storage, and in the replica
+	ReplicaStatus string // ReplicaStatus is either REPLICATED or FAILED
+}
+
+func ReplicaStatusPk(owner, replica, key string) appdynamodb.TablePk {
+	return appdynamodb.TablePk{
+		PK: ReplicaStatusPK(owner, replica),
+		SK: fmt.Sprintf("KEY#%s", key),
+	}
+}
+
+func ReplicaStatusPK(owner, replica string) string {
+	return fmt.Sprintf("%s#REPLICA#%s", owner, replica)
+}
+
+type DataKeyRecord struct {
+	appdynamodb.TablePk
+	WrappedDataKey []byte // WrappedDataKey is the data key of the owner encrypted with the master key
+}
+
+func DataKeyPk(owner string) appdynamodb.TablePk {
+	return appdynamodb.TablePk{
+		PK: fmt.Sprintf("%s#ENCRYPTION", owner),
+		SK: "DATA_KEY#",
+	}
+}
@@ -0,0 +1,90 @@
+package archivedynamo
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/pkg/errors"
+	"github.com/thomasduchatelle/dphoto/pkg/archive"
+	"github.com/thomasduchatelle/dphoto/pkg/awssupport/dynamoutils"
+)
+
+// NewReplicaRepository stores the replication statuses in the same table than media locations.
+func NewReplicaRepository(client *dynamodb.Client, tableName string) archive.ReplicaRepositoryAdapter {
+	return &repository{
+		db:    client,
+		table: tableName,
+	}
+}
+
+func (r *repository) UpdateReplicaStatus(owner, replica, key string, status archive.ReplicaStatus) error {
+	if isBlank(owner) || isBlank(replica) || isBlank(key) {
+		return errors.Errorf("owner, replica, and key are mandatory [owner=%s ; replica=%s ; key=%s]", owner, replica, key)
+	}
+
+	item, err := attributevalue.MarshalMap(&ReplicaStatusRecord{
+		TablePk:       ReplicaStatusPk(owner, replica, key),
+		ReplicaKey:    key,
+		ReplicaStatus: string(status),
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal replica status")
+	}
+
+	_, err = r.db.PutItem(context.TODO(), &dynamodb.PutItemInput{
+		Item:      item,
+		TableName: &r.table,
+	})
+	return errors.Wrapf(err, "failed to upsert replica status %s - %s - %s", replica, key, status)
+}
+
+func (r *repository) FindReplicaStatuses(owner, replica string) (map[string]archive.ReplicaStatus, error) {
+	expr, err := expression.NewBuilder().
+		WithKeyCondition(expression.Key("PK").Equal(expression.Value(ReplicaStatusPK(owner, replica)))).
+		Build()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make(map[string]archive.ReplicaStatus)
+
+	paginator := dynamodb.NewQueryPaginator(r.db, &dynamodb.QueryInput{
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+		TableName:                 &r.table,
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to query replica statuses of %s on %s", owner, replica)
+		}
+
+		for _, item := range page.Items {
+			var record ReplicaStatusRecord
+			err = attributevalue.UnmarshalMap(item, &record)
+			if err != nil {
+				return nil, errors.Wrapf(err, "ReplicaStatusRecord cannot be unmarshalled from %+v", item)
+			}
+
+			statuses[record.ReplicaKey] = archive.ReplicaStatus(record.ReplicaStatus)
+		}
+	}
+
+	return statuses, nil
+}
+
+func (r *repository) DeleteReplicaStatuses(owner, replica string, keys []string) error {
+	requests := make([]types.WriteRequest, len(keys))
+	for i, key := range keys {
+		requests[i] = types.WriteRequest{
+			DeleteRequest: &types.DeleteRequest{
+				Key: ReplicaStatusPk(owner, replica, key).ToAttributes(),
+			},
+		}
+	}
+
+	return dynamoutils.BufferedWriteItems(context.TODO(), r.db, requests, r.table, dynamoutils.DynamoWriteBatchSize)
+}
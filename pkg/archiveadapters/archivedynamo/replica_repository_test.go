@@ -0,0 +1,40 @@
+package archivedynamo
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/thomasduchatelle/dphoto/pkg/archive"
+	"github.com/thomasduchatelle/dphoto/pkg/awssupport/dynamotestutils"
+	"testing"
+)
+
+func TestReplicaStatuses(t *testing.T) {
+	a := assert.New(t)
+	dyn := dynamotestutils.NewTestContext(context.Background(), t)
+	repo := NewReplicaRepository(dyn.Client, dyn.Table)
+
+	const key1 = owner + "/album-01/image-01.jpg"
+	const key2 = owner + "/album-01/image-02.jpg"
+
+	a.NoError(repo.UpdateReplicaStatus(owner, "nas", key1, archive.ReplicaStatusFailed))
+	a.NoError(repo.UpdateReplicaStatus(owner, "nas", key1, archive.ReplicaStatusReplicated))
+	a.NoError(repo.UpdateReplicaStatus(owner, "nas", key2, archive.ReplicaStatusFailed))
+	a.NoError(repo.UpdateReplicaStatus(owner, "bucket", key2, archive.ReplicaStatusReplicated))
+
+	got, err := repo.FindReplicaStatuses(owner, "nas")
+	if a.NoError(err) {
+		a.Equal(map[string]archive.ReplicaStatus{
+			key1: archive.ReplicaStatusReplicated,
+			key2: archive.ReplicaStatusFailed,
+		}, got, "it should return the last status of each key on the replica")
+	}
+
+	a.NoError(repo.DeleteReplicaStatuses(owner, "nas", []string{key1}))
+
+	got, err = repo.FindReplicaStatuses(owner, "nas")
+	if a.NoError(err) {
+		a.Equal(map[string]archive.ReplicaStatus{
+			key2: archive.ReplicaStatusFailed,
+		}, got, "it should not return deleted statuses")
+	}
+}
@@ -0,0 +1,75 @@
+package archivedynamo
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/pkg/errors"
+	"github.com/thomasduchatelle/dphoto/pkg/archive"
+	"github.com/thomasduchatelle/dphoto/pkg/awssupport/dynamoutils"
+)
+
+// NewTieringRepository stores the storage state as an attribute of the media location.
+func NewTieringRepository(client *dynamodb.Client, tableName string) archive.TieringRepositoryAdapter {
+	return &repository{
+		db:    client,
+		table: tableName,
+	}
+}
+
+func (r *repository) FindStorageStates(owner string, ids []string) (map[string]archive.StorageState, error) {
+	keys := make([]map[string]types.AttributeValue, len(ids))
+	for i, id := range ids {
+		keys[i] = marshalMediaLocationPK(owner, id)
+	}
+
+	states := make(map[string]archive.StorageState)
+
+	stream := dynamoutils.NewGetStream(context.TODO(), dynamoutils.NewGetBatchItem(r.db, r.table, ""), keys, dynamoutils.DynamoReadBatchSize)
+	for stream.HasNext() {
+		item := stream.Next()
+
+		var location MediaLocationRecord
+		err := attributevalue.UnmarshalMap(item, &location)
+		if err != nil {
+			return nil, errors.Wrapf(err, "MediaLocation cannot be unmarchaled from %+v", item)
+		}
+
+		states[location.LocationId] = archive.StorageState(location.LocationStorageState)
+	}
+
+	return states, stream.Error()
+}
+
+func (r *repository) UpdateStorageState(owner string, ids []string, state archive.StorageState) error {
+	update := expression.Set(expression.Name("LocationStorageState"), expression.Value(string(state)))
+	if state == archive.StorageStateHot {
+		update = expression.Remove(expression.Name("LocationStorageState"))
+	}
+
+	expr, err := expression.NewBuilder().
+		WithUpdate(update).
+		WithCondition(expression.AttributeExists(expression.Name("PK"))).
+		Build()
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		_, err = r.db.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
+			Key:                       marshalMediaLocationPK(owner, id),
+			TableName:                 &r.table,
+			ConditionExpression:       expr.Condition(),
+			ExpressionAttributeNames:  expr.Names(),
+			ExpressionAttributeValues: expr.Values(),
+			UpdateExpression:          expr.Update(),
+		})
+		if err != nil {
+			return errors.Wrapf(err, "failed to update storage state of %s/%s to '%s'", owner, id, state)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,43 @@
+package archivedynamo
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/thomasduchatelle/dphoto/pkg/archive"
+	"github.com/thomasduchatelle/dphoto/pkg/awssupport/dynamotestutils"
+	"testing"
+)
+
+func TestStorageStates(t *testing.T) {
+	a := assert.New(t)
+	dyn := dynamotestutils.NewTestContext(context.Background(), t)
+	locations := Must(New(dyn.Client, dyn.Table))
+	repo := NewTieringRepository(dyn.Client, dyn.Table)
+
+	a.NoError(locations.AddLocation(owner, "id-1", owner+"/album-01/image-01.jpg"))
+	a.NoError(locations.AddLocation(owner, "id-2", owner+"/album-01/image-02.jpg"))
+
+	a.NoError(repo.UpdateStorageState(owner, []string{"id-1", "id-2"}, archive.StorageStateCold))
+	a.NoError(repo.UpdateStorageState(owner, []string{"id-2"}, archive.StorageStateRestoreRequested))
+	a.Error(repo.UpdateStorageState(owner, []string{"id-3"}, archive.StorageStateCold), "it should not create a location that doesn't exist")
+
+	got, err := repo.FindStorageStates(owner, []string{"id-1", "id-2"})
+	if a.NoError(err) {
+		a.Equal(map[string]archive.StorageState{
+			"id-1": archive.StorageStateCold,
+			"id-2": archive.StorageStateRestoreRequested,
+		}, got)
+	}
+
+	a.NoError(locations.UpdateLocations(owner, map[string]string{"id-1": owner + "/album-02/image-01.jpg"}))
+
+	got, err = repo.FindStorageStates(owner, []string{"id-1"})
+	if a.NoError(err) {
+		a.Equal(map[string]archive.StorageState{"id-1": archive.StorageStateHot}, got, "it should reset the state when the media is relocated")
+	}
+
+	key, err := locations.FindById(owner, "id-2")
+	if a.NoError(err) {
+		a.Equal(owner+"/album-01/image-02.jpg", key, "it should keep the location when the state is updated")
+	}
+}
@@ -0,0 +1,90 @@
+// Package localstore implements archive.ReplicaAdapter on a local (or mounted) directory
+package localstore
+
+import (
+	"github.com/pkg/errors"
+	"github.com/thomasduchatelle/dphoto/pkg/archive"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// New creates the directory if it doesn't exist ; keys are used as relative paths from it.
+func New(directory string) (*Store, error) {
+	err := os.MkdirAll(directory, 0755)
+	return &Store{Directory: directory}, errors.Wrapf(err, "failed to create replica directory %s", directory)
+}
+
+type Store struct {
+	Directory string
+}
+
+// Download retrieves the file stored at this key, raise archive.NotFoundError if the key doesn't exist
+func (s *Store) Download(key string) (io.ReadCloser, error) {
+	absolutePath, err := s.absolutePath(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(absolutePath)
+	if os.IsNotExist(err) {
+		return nil, archive.NotFoundError
+	}
+	return file, errors.Wrapf(err, "failed to open %s", absolutePath)
+}
+
+// Put writes the content in a temporary file first to never leave a partial file behind
+func (s *Store) Put(key string, mediaType string, content io.Reader) error {
+	absolutePath, err := s.absolutePath(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(absolutePath), 0755)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create parent directory of %s", absolutePath)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(absolutePath), "."+filepath.Base(absolutePath)+".*")
+	if err != nil {
+		return errors.Wrapf(err, "failed to create temporary file for %s", absolutePath)
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = io.Copy(tmp, content)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to write %s", absolutePath)
+	}
+
+	return errors.Wrapf(os.Rename(tmp.Name(), absolutePath), "failed to move temporary file to %s", absolutePath)
+}
+
+// Delete ignores keys that do not exist
+func (s *Store) Delete(locations []string) error {
+	for _, key := range locations {
+		absolutePath, err := s.absolutePath(key)
+		if err != nil {
+			return err
+		}
+
+		err = os.Remove(absolutePath)
+		if err != nil && !os.IsNotExist(err) {
+			return errors.Wrapf(err, "failed to delete %s", absolutePath)
+		}
+	}
+
+	return nil
+}
+
+func (s *Store) absolutePath(key string) (string, error) {
+	cleaned := filepath.Clean("/" + key)
+	if cleaned == "/" || strings.Contains(key, "..") {
+		return "", errors.Errorf("key '%s' is not a valid file path", key)
+	}
+
+	return filepath.Join(s.Directory, cleaned), nil
+}
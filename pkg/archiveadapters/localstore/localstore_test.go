@@ -0,0 +1,46 @@
+package localstore
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/thomasduchatelle/dphoto/pkg/archive"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	a := assert.New(t)
+	directory := t.TempDir()
+
+	store, err := New(filepath.Join(directory, "replica"))
+	if !a.NoError(err) {
+		return
+	}
+
+	const key = "ironman/2022-06/2022-06-26_15-48-42_qwertyui.jpg"
+	err = store.Put(key, "image/jpeg", strings.NewReader("foobar"))
+	if a.NoError(err, "it should store the content under the key") {
+		content, err := os.ReadFile(filepath.Join(directory, "replica", key))
+		a.NoError(err)
+		a.Equal("foobar", string(content))
+
+		entries, err := os.ReadDir(filepath.Join(directory, "replica", "ironman/2022-06"))
+		a.NoError(err)
+		a.Len(entries, 1, "it should not leave temporary files behind")
+	}
+
+	reader, err := store.Download(key)
+	if a.NoError(err, "it should read the content back") {
+		content, _ := io.ReadAll(reader)
+		_ = reader.Close()
+		a.Equal("foobar", string(content))
+	}
+
+	a.NoError(store.Delete([]string{key, "ironman/2022-06/not-existing.jpg"}), "it should delete the key and ignore non-existing ones")
+	_, err = store.Download(key)
+	a.Equal(archive.NotFoundError, err, "it should return NotFoundError when the key doesn't exist")
+
+	a.Error(store.Put("../outside.jpg", "image/jpeg", strings.NewReader("foobar")), "it should refuse keys escaping the directory")
+}
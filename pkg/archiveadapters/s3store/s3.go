@@ -11,7 +11,6 @@ import (
 	"github.com/pkg/errors"
 	"github.com/thomasduchatelle/dphoto/pkg/archive"
 	"io"
-	"path"
 	"strings"
 	"time"
 )
@@ -21,21 +20,30 @@ type StoreAndCache interface {
 	archive.CacheAdapter
 }
 
+// StoreOption customises the store created by NewWithS3Client.
+type StoreOption func(store *store)
+
 func New(cfg aws.Config, bucketName string, optFns ...func(options *s3.Options)) (StoreAndCache, error) {
 	s3client := s3.NewFromConfig(cfg, optFns...)
 	return NewWithS3Client(s3client, bucketName), nil
 }
 
-func NewWithS3Client(s3client *s3.Client, bucketName string) StoreAndCache {
+func NewWithS3Client(s3client *s3.Client, bucketName string, options ...StoreOption) StoreAndCache {
 	presign := s3.NewPresignClient(s3client)
 	uploader := manager.NewUploader(s3client)
 
-	return &store{
+	s := &store{
 		client:     s3client,
 		presign:    presign,
 		s3Uploader: uploader,
 		bucketName: bucketName,
+		multipart:  newMultipartUploader(s3client, bucketName, MultipartOptions{}),
+	}
+	for _, option := range options {
+		option(s)
 	}
+
+	return s
 }
 
 func Must(storage StoreAndCache, err error) StoreAndCache {
@@ -51,6 +59,7 @@ type store struct {
 	s3Uploader *manager.Uploader
 	bucketName string
 	presign    *s3.PresignClient
+	multipart  *multipartUploader
 }
 
 func (s *store) Copy(origin string, destination archive.DestructuredKey) (string, error) {
@@ -63,12 +72,9 @@ func (s *store) Copy(origin string, destination archive.DestructuredKey) (string
 		return "", errors.Wrapf(err, "cannot find a unique destination key")
 	}
 
-	_, err = s.client.CopyObject(context.TODO(), &s3.CopyObjectInput{
-		Bucket:     &s.bucketName,
-		CopySource: aws.String(strings.Trim(path.Join(s.bucketName, origin), "/")),
-		Key:        &destinationKey,
-	})
-	return destinationKey, errors.Wrapf(err, "failed to copy %s -> %s", origin, destinationKey)
+	// the copy keeps the storage class of the origin: an original restored from cold storage stays in its class
+	err = copyObject(context.TODO(), s.client, s.bucketName, origin, destinationKey, "")
+	return destinationKey, err
 }
 
 func (s *store) Delete(keys []string) error {
@@ -106,9 +112,10 @@ func (s *store) Get(key string) (io.ReadCloser, int, string, error) {
 	return object.Body, int(*object.ContentLength), *object.ContentType, errors.Wrapf(err, "couldn't access key %s in %s bucket", key, s.bucketName)
 }
 
+// Put uses the S3 upload manager: content of unknown length, like the originals copied on replicas, is streamed by parts.
 func (s *store) Put(key string, mediaType string, content io.Reader) error {
-	_, err := s.client.PutObject(context.TODO(), &s3.PutObjectInput{
-		Body:        manager.ReadSeekCloser(content),
+	_, err := s.s3Uploader.Upload(context.TODO(), &s3.PutObjectInput{
+		Body:        content,
 		Bucket:      &s.bucketName,
 		ContentType: &mediaType,
 		Key:         &key,
@@ -132,17 +139,7 @@ func (s *store) Upload(keyHint archive.DestructuredKey, content io.Reader) (stri
 		return "", errors.Errorf("Prefix must not start with a '/' in key hint %+v", keyHint)
 	}
 
-	key, err := s.findUniqueFilename(keyHint)
-	if err != nil {
-		return "", err
-	}
-
-	_, err = s.s3Uploader.Upload(context.TODO(), &s3.PutObjectInput{
-		Body:   manager.ReadSeekCloser(content),
-		Bucket: &s.bucketName,
-		Key:    &key,
-	})
-
+	key, err := s.multipart.upload(context.TODO(), keyHint, content, s.findUniqueFilename)
 	return key, errors.Wrapf(err, "upload failed")
 }
 
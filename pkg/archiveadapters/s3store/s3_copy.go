@@ -0,0 +1,116 @@
+package s3store
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"strings"
+)
+
+const (
+	MaxSingleCopySize = 5 * 1024 * 1024 * 1024 // MaxSingleCopySize is the largest object S3 copies with a single CopyObject request
+	CopyPartSize      = 512 * 1024 * 1024      // CopyPartSize keeps a 5 TB object under the 10,000 parts limit of S3
+)
+
+// copyAPI is the subset of the S3 client used to copy objects within a bucket.
+type copyAPI interface {
+	HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error)
+	CopyObject(ctx context.Context, params *s3.CopyObjectInput, optFns ...func(*s3.Options)) (*s3.CopyObjectOutput, error)
+	CreateMultipartUpload(ctx context.Context, params *s3.CreateMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CreateMultipartUploadOutput, error)
+	UploadPartCopy(ctx context.Context, params *s3.UploadPartCopyInput, optFns ...func(*s3.Options)) (*s3.UploadPartCopyOutput, error)
+	CompleteMultipartUpload(ctx context.Context, params *s3.CompleteMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CompleteMultipartUploadOutput, error)
+	AbortMultipartUpload(ctx context.Context, params *s3.AbortMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error)
+}
+
+// copyObject copies origin to destination within the bucket, in the storageClass or in the class of origin when empty ; objects larger than MaxSingleCopySize are copied by parts.
+func copyObject(ctx context.Context, client copyAPI, bucketName, origin, destination string, storageClass types.StorageClass) error {
+	head, err := client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: &bucketName,
+		Key:    &origin,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to read the size of %s", origin)
+	}
+
+	if storageClass == "" {
+		storageClass = head.StorageClass
+	}
+	copySource := aws.String(strings.Trim(bucketName+"/"+strings.TrimLeft(origin, "/"), "/"))
+
+	if aws.ToInt64(head.ContentLength) <= MaxSingleCopySize {
+		_, err = client.CopyObject(ctx, &s3.CopyObjectInput{
+			Bucket:            &bucketName,
+			CopySource:        copySource,
+			Key:               &destination,
+			MetadataDirective: types.MetadataDirectiveCopy,
+			StorageClass:      storageClass,
+		})
+		return errors.Wrapf(err, "failed to copy %s -> %s", origin, destination)
+	}
+
+	return copyObjectByParts(ctx, client, bucketName, head, copySource, destination, storageClass)
+}
+
+// copyObjectByParts uses UploadPartCopy: the content doesn't transit through the client, and the copy fails if the origin is modified meanwhile.
+func copyObjectByParts(ctx context.Context, client copyAPI, bucketName string, head *s3.HeadObjectOutput, copySource *string, destination string, storageClass types.StorageClass) error {
+	created, err := client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:       &bucketName,
+		Key:          &destination,
+		ContentType:  head.ContentType,
+		Metadata:     head.Metadata,
+		StorageClass: storageClass,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to start the copy of %s to %s", aws.ToString(copySource), destination)
+	}
+
+	size := aws.ToInt64(head.ContentLength)
+	var parts []types.CompletedPart
+	for number, offset := int32(1), int64(0); offset < size; number, offset = number+1, offset+CopyPartSize {
+		var copied *s3.UploadPartCopyOutput
+		copied, err = client.UploadPartCopy(ctx, &s3.UploadPartCopyInput{
+			Bucket:            &bucketName,
+			Key:               &destination,
+			UploadId:          created.UploadId,
+			PartNumber:        aws.Int32(number),
+			CopySource:        copySource,
+			CopySourceRange:   aws.String(fmt.Sprintf("bytes=%d-%d", offset, min(offset+CopyPartSize, size)-1)),
+			CopySourceIfMatch: head.ETag,
+		})
+		if err != nil {
+			err = errors.Wrapf(err, "failed to copy part %d of %s to %s", number, aws.ToString(copySource), destination)
+			break
+		}
+
+		parts = append(parts, types.CompletedPart{
+			ETag:       copied.CopyPartResult.ETag,
+			PartNumber: aws.Int32(number),
+		})
+	}
+
+	if err == nil {
+		_, err = client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+			Bucket:          &bucketName,
+			Key:             &destination,
+			UploadId:        created.UploadId,
+			MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
+		})
+		err = errors.Wrapf(err, "failed to assemble the copy of %s to %s", aws.ToString(copySource), destination)
+	}
+
+	if err != nil {
+		_, abortErr := client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+			Bucket:   &bucketName,
+			Key:      &destination,
+			UploadId: created.UploadId,
+		})
+		if abortErr != nil {
+			log.WithError(abortErr).WithField("Key", destination).Warnln("Multipart copy couldn't be aborted")
+		}
+	}
+	return err
+}
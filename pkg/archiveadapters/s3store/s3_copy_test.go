@@ -0,0 +1,145 @@
+package s3store
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCopyObject(t *testing.T) {
+	const largeSize = 10*CopyPartSize + 100
+
+	tests := []struct {
+		name             string
+		size             int64
+		storageClass     types.StorageClass
+		failPart         int32
+		wantErr          bool
+		wantCopied       []string
+		wantStorageClass types.StorageClass
+		wantRanges       []string
+		wantAborted      bool
+	}{
+		{
+			name:             "it should copy a small object in a single request, keeping its storage class",
+			size:             1024,
+			wantCopied:       []string{"unit-bucket/2021/video.mp4"},
+			wantStorageClass: types.StorageClassGlacier,
+		},
+		{
+			name:             "it should copy a small object in the requested storage class",
+			size:             1024,
+			storageClass:     types.StorageClassDeepArchive,
+			wantCopied:       []string{"unit-bucket/2021/video.mp4"},
+			wantStorageClass: types.StorageClassDeepArchive,
+		},
+		{
+			name:             "it should copy an object over 5 GB by parts",
+			size:             largeSize,
+			storageClass:     types.StorageClassDeepArchive,
+			wantStorageClass: types.StorageClassDeepArchive,
+			wantRanges: []string{
+				fmt.Sprintf("bytes=0-%d", CopyPartSize-1),
+				fmt.Sprintf("bytes=%d-%d", CopyPartSize, 2*CopyPartSize-1),
+				fmt.Sprintf("bytes=%d-%d", 2*CopyPartSize, 3*CopyPartSize-1),
+				fmt.Sprintf("bytes=%d-%d", 3*CopyPartSize, 4*CopyPartSize-1),
+				fmt.Sprintf("bytes=%d-%d", 4*CopyPartSize, 5*CopyPartSize-1),
+				fmt.Sprintf("bytes=%d-%d", 5*CopyPartSize, 6*CopyPartSize-1),
+				fmt.Sprintf("bytes=%d-%d", 6*CopyPartSize, 7*CopyPartSize-1),
+				fmt.Sprintf("bytes=%d-%d", 7*CopyPartSize, 8*CopyPartSize-1),
+				fmt.Sprintf("bytes=%d-%d", 8*CopyPartSize, 9*CopyPartSize-1),
+				fmt.Sprintf("bytes=%d-%d", 9*CopyPartSize, 10*CopyPartSize-1),
+				fmt.Sprintf("bytes=%d-%d", 10*CopyPartSize, largeSize-1),
+			},
+		},
+		{
+			name:             "it should abort the copy by parts when a part failed",
+			size:             largeSize,
+			failPart:         2,
+			wantErr:          true,
+			wantStorageClass: types.StorageClassGlacier,
+			wantRanges:       []string{fmt.Sprintf("bytes=0-%d", CopyPartSize-1)},
+			wantAborted:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &S3CopyFake{Size: tt.size, StorageClass: types.StorageClassGlacier, FailPart: tt.failPart}
+
+			err := copyObject(context.Background(), client, "unit-bucket", "2021/video.mp4", "2022/video.mp4", tt.storageClass)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else if assert.NoError(t, err) {
+				assert.Equal(t, tt.wantRanges != nil, client.Completed, "it should assemble the parts")
+			}
+			assert.Equal(t, tt.wantCopied, client.Copied)
+			assert.Equal(t, tt.wantStorageClass, client.DestinationClass)
+			assert.Equal(t, tt.wantRanges, client.Ranges)
+			assert.Equal(t, tt.wantAborted, client.Aborted)
+		})
+	}
+}
+
+// S3CopyFake records the copy requests of an object of Size bytes.
+type S3CopyFake struct {
+	Size             int64
+	StorageClass     types.StorageClass
+	FailPart         int32
+	Copied           []string
+	DestinationClass types.StorageClass
+	Ranges           []string
+	Completed        bool
+	Aborted          bool
+}
+
+func (f *S3CopyFake) HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error) {
+	return &s3.HeadObjectOutput{
+		ContentLength: aws.Int64(f.Size),
+		ContentType:   aws.String("video/mp4"),
+		ETag:          aws.String(`"etag-01"`),
+		StorageClass:  f.StorageClass,
+	}, nil
+}
+
+func (f *S3CopyFake) CopyObject(ctx context.Context, params *s3.CopyObjectInput, optFns ...func(*s3.Options)) (*s3.CopyObjectOutput, error) {
+	f.Copied = append(f.Copied, aws.ToString(params.CopySource))
+	f.DestinationClass = params.StorageClass
+	return &s3.CopyObjectOutput{}, nil
+}
+
+func (f *S3CopyFake) CreateMultipartUpload(ctx context.Context, params *s3.CreateMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CreateMultipartUploadOutput, error) {
+	f.DestinationClass = params.StorageClass
+	return &s3.CreateMultipartUploadOutput{UploadId: aws.String("copy-upload")}, nil
+}
+
+func (f *S3CopyFake) UploadPartCopy(ctx context.Context, params *s3.UploadPartCopyInput, optFns ...func(*s3.Options)) (*s3.UploadPartCopyOutput, error) {
+	if aws.ToInt32(params.PartNumber) == f.FailPart {
+		return nil, errors.Errorf("TEST - part %d failed", f.FailPart)
+	}
+	if aws.ToString(params.CopySourceIfMatch) != `"etag-01"` {
+		return nil, errors.Errorf("TEST - copy must be conditional to the ETag of the origin")
+	}
+
+	f.Ranges = append(f.Ranges, aws.ToString(params.CopySourceRange))
+	return &s3.UploadPartCopyOutput{CopyPartResult: &types.CopyPartResult{ETag: aws.String(fmt.Sprintf("part-%d", aws.ToInt32(params.PartNumber)))}}, nil
+}
+
+func (f *S3CopyFake) CompleteMultipartUpload(ctx context.Context, params *s3.CompleteMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CompleteMultipartUploadOutput, error) {
+	if len(params.MultipartUpload.Parts) != len(f.Ranges) {
+		return nil, errors.Errorf("TEST - %d parts completed, %d copied", len(params.MultipartUpload.Parts), len(f.Ranges))
+	}
+	f.Completed = true
+	return &s3.CompleteMultipartUploadOutput{}, nil
+}
+
+func (f *S3CopyFake) AbortMultipartUpload(ctx context.Context, params *s3.AbortMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error) {
+	f.Aborted = true
+	return &s3.AbortMultipartUploadOutput{}, nil
+}
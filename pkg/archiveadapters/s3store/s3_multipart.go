@@ -0,0 +1,435 @@
+package s3store
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/thomasduchatelle/dphoto/pkg/archive"
+	"hash"
+	"io"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultPartSize    = 16 * 1024 * 1024   // DefaultPartSize is large enough for a 150 GB video with the 10,000 parts limit of S3
+	MinPartSize        = 5 * 1024 * 1024    // MinPartSize is the minimum accepted by S3 for all the parts but the last one
+	DefaultConcurrency = 4                  // DefaultConcurrency is the number of parts uploaded in parallel
+	DefaultStaleAfter  = 7 * 24 * time.Hour // DefaultStaleAfter is when an interrupted upload is aborted instead of being resumed
+)
+
+// MultipartOptions configures how Upload splits large files ; a zero value uses the defaults.
+type MultipartOptions struct {
+	PartSize    int64                 // PartSize is the size of each part, content smaller than a part is sent in a single request
+	Concurrency int                   // Concurrency is the number of parts uploaded in parallel
+	StaleAfter  time.Duration         // StaleAfter is the age after which an interrupted upload is aborted
+	States      UploadStateRepository // States persists the progress of the uploads to resume them ; they are kept in memory when nil
+}
+
+// WithMultipart overrides the default part size, concurrency, and where the progress of the uploads is persisted.
+func WithMultipart(options MultipartOptions) StoreOption {
+	return func(store *store) {
+		if options.PartSize > 0 {
+			options.PartSize = max(options.PartSize, MinPartSize)
+		}
+		store.multipart = newMultipartUploader(store.client, store.bucketName, options)
+	}
+}
+
+// multipartAPI is the subset of the S3 client used to upload files.
+type multipartAPI interface {
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+	DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error)
+	CreateMultipartUpload(ctx context.Context, params *s3.CreateMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CreateMultipartUploadOutput, error)
+	UploadPart(ctx context.Context, params *s3.UploadPartInput, optFns ...func(*s3.Options)) (*s3.UploadPartOutput, error)
+	CompleteMultipartUpload(ctx context.Context, params *s3.CompleteMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CompleteMultipartUploadOutput, error)
+	AbortMultipartUpload(ctx context.Context, params *s3.AbortMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error)
+	ListParts(ctx context.Context, params *s3.ListPartsInput, optFns ...func(*s3.Options)) (*s3.ListPartsOutput, error)
+	ListMultipartUploads(ctx context.Context, params *s3.ListMultipartUploadsInput, optFns ...func(*s3.Options)) (*s3.ListMultipartUploadsOutput, error)
+}
+
+// multipartUploader uploads files by parts ; a failed upload is resumed from the last part uploaded when retried with the same key hint.
+type multipartUploader struct {
+	client      multipartAPI
+	bucketName  string
+	options     MultipartOptions
+	now         func() time.Time
+	staleAborts sync.Once
+}
+
+func newMultipartUploader(client multipartAPI, bucketName string, options MultipartOptions) *multipartUploader {
+	if options.PartSize == 0 {
+		options.PartSize = DefaultPartSize
+	}
+	if options.Concurrency <= 0 {
+		options.Concurrency = DefaultConcurrency
+	}
+	if options.StaleAfter == 0 {
+		options.StaleAfter = DefaultStaleAfter
+	}
+	if options.States == nil {
+		options.States = NewInMemoryUploadStates()
+	}
+
+	return &multipartUploader{
+		client:     client,
+		bucketName: bucketName,
+		options:    options,
+		now:        time.Now,
+	}
+}
+
+// upload returns the key where the content has been stored ; findUniqueKey is only called when a new upload starts.
+func (u *multipartUploader) upload(ctx context.Context, keyHint archive.DestructuredKey, content io.Reader, findUniqueKey func(archive.DestructuredKey) (string, error)) (string, error) {
+	u.staleAborts.Do(func() {
+		if err := u.abortStaleUploads(ctx); err != nil {
+			log.WithError(err).Warnln("Stale multipart uploads couldn't be aborted")
+		}
+	})
+
+	fullHash := sha256.New()
+	firstPart, err := readPart(content, u.options.PartSize, fullHash)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read content to upload to %s", keyHint.Prefix)
+	}
+
+	if int64(len(firstPart)) < u.options.PartSize {
+		return u.uploadSinglePart(ctx, keyHint, firstPart, fullHash, findUniqueKey)
+	}
+
+	state, err := u.startOrResume(ctx, keyHint, findUniqueKey)
+	if err != nil {
+		return "", err
+	}
+
+	err = u.uploadParts(ctx, state, firstPart, content, fullHash)
+	if err != nil {
+		return "", err
+	}
+
+	return state.Key, u.complete(ctx, keyHint, state, fullHash)
+}
+
+func (u *multipartUploader) uploadSinglePart(ctx context.Context, keyHint archive.DestructuredKey, content []byte, fullHash hash.Hash, findUniqueKey func(archive.DestructuredKey) (string, error)) (string, error) {
+	err := verifyContentSha256(keyHint, fullHash)
+	if err != nil {
+		return "", err
+	}
+
+	key, err := findUniqueKey(keyHint)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = u.client.PutObject(ctx, &s3.PutObjectInput{
+		Body:              bytes.NewReader(content),
+		Bucket:            &u.bucketName,
+		Key:               &key,
+		ChecksumAlgorithm: types.ChecksumAlgorithmSha256,
+		ChecksumSHA256:    aws.String(base64.StdEncoding.EncodeToString(fullHash.Sum(nil))),
+	})
+	return key, errors.Wrapf(err, "upload of %s failed", key)
+}
+
+// startOrResume returns the state of the upload in progress for this key hint, or starts a new one ; stale and unknown uploads are restarted.
+func (u *multipartUploader) startOrResume(ctx context.Context, keyHint archive.DestructuredKey, findUniqueKey func(archive.DestructuredKey) (string, error)) (*UploadState, error) {
+	hint := uploadHint(keyHint)
+	state, err := u.options.States.FindUploadState(hint)
+	if err != nil {
+		return nil, err
+	}
+
+	if state != nil && (state.PartSize != u.options.PartSize || u.now().Sub(state.CreatedAt) > u.options.StaleAfter) {
+		u.abort(ctx, state)
+		state = nil
+	}
+
+	if state != nil {
+		state.Parts, err = u.listUploadedParts(ctx, state)
+		if errors.As(err, new(*types.NoSuchUpload)) {
+			log.WithField("Key", state.Key).Infoln("Upload to resume doesn't exist anymore, restarting it")
+			u.abort(ctx, state)
+			state = nil
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	if state != nil {
+		log.WithField("Key", state.Key).Infof("Resuming upload: %d parts already uploaded", len(state.Parts))
+		return state, nil
+	}
+
+	key, err := findUniqueKey(keyHint)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := u.client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:            &u.bucketName,
+		Key:               &key,
+		ChecksumAlgorithm: types.ChecksumAlgorithmSha256,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to start the upload of %s", key)
+	}
+
+	state = &UploadState{
+		Hint:      hint,
+		Key:       key,
+		UploadID:  aws.ToString(created.UploadId),
+		PartSize:  u.options.PartSize,
+		CreatedAt: u.now(),
+	}
+	return state, u.options.States.SaveUploadState(*state)
+}
+
+// listUploadedParts keeps the parts known locally that S3 also has ; others are uploaded again.
+func (u *multipartUploader) listUploadedParts(ctx context.Context, state *UploadState) ([]UploadedPart, error) {
+	remote := make(map[int32]string)
+
+	var marker *string
+	for {
+		listed, err := u.client.ListParts(ctx, &s3.ListPartsInput{
+			Bucket:           &u.bucketName,
+			Key:              &state.Key,
+			UploadId:         &state.UploadID,
+			PartNumberMarker: marker,
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list the parts uploaded to %s", state.Key)
+		}
+
+		for _, part := range listed.Parts {
+			remote[aws.ToInt32(part.PartNumber)] = aws.ToString(part.ETag)
+		}
+
+		if !aws.ToBool(listed.IsTruncated) {
+			break
+		}
+		marker = listed.NextPartNumberMarker
+	}
+
+	return slices.DeleteFunc(state.Parts, func(part UploadedPart) bool {
+		return remote[part.Number] != part.ETag
+	}), nil
+}
+
+// uploadParts reads the content sequentially and uploads the parts in parallel ; parts already uploaded with the same checksum are skipped.
+func (u *multipartUploader) uploadParts(ctx context.Context, state *UploadState, firstPart []byte, content io.Reader, fullHash hash.Hash) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type partToUpload struct {
+		number   int32
+		content  []byte
+		checksum string
+	}
+
+	var lock sync.Mutex
+	var uploadErr error
+	parts := make(chan partToUpload, u.options.Concurrency)
+	wait := sync.WaitGroup{}
+	for i := 0; i < u.options.Concurrency; i++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			for part := range parts {
+				uploaded, err := u.client.UploadPart(ctx, &s3.UploadPartInput{
+					Body:              bytes.NewReader(part.content),
+					Bucket:            &u.bucketName,
+					Key:               &state.Key,
+					UploadId:          &state.UploadID,
+					PartNumber:        aws.Int32(part.number),
+					ChecksumAlgorithm: types.ChecksumAlgorithmSha256,
+					ChecksumSHA256:    &part.checksum,
+				})
+
+				lock.Lock()
+				if err != nil && uploadErr == nil {
+					uploadErr = errors.Wrapf(err, "failed to upload part %d of %s", part.number, state.Key)
+					cancel()
+				}
+				if err == nil {
+					state.setPart(UploadedPart{Number: part.number, ETag: aws.ToString(uploaded.ETag), ChecksumSHA256: part.checksum})
+					if saveErr := u.options.States.SaveUploadState(*state); saveErr != nil {
+						log.WithError(saveErr).Warnf("Progress of the upload of %s couldn't be saved", state.Key)
+					}
+				}
+				lock.Unlock()
+			}
+		}()
+	}
+
+	var readErr error
+	partContent := firstPart
+	for number := int32(1); len(partContent) > 0 && ctx.Err() == nil; number++ {
+		partHash := sha256.Sum256(partContent)
+		checksum := base64.StdEncoding.EncodeToString(partHash[:])
+
+		lock.Lock()
+		alreadyUploaded := state.hasPart(number, checksum)
+		lock.Unlock()
+
+		if !alreadyUploaded {
+			parts <- partToUpload{number: number, content: partContent, checksum: checksum}
+		}
+
+		if int64(len(partContent)) < u.options.PartSize {
+			break
+		}
+		partContent, readErr = readPart(content, u.options.PartSize, fullHash)
+		if readErr != nil {
+			readErr = errors.Wrapf(readErr, "failed to read content to upload to %s", state.Key)
+			break
+		}
+	}
+
+	close(parts)
+	wait.Wait()
+
+	lock.Lock()
+	defer lock.Unlock()
+	if uploadErr != nil {
+		return uploadErr
+	}
+	return readErr
+}
+
+// complete assembles the parts, and verifies the assembled object against the checksums ; a corrupted upload is discarded.
+func (u *multipartUploader) complete(ctx context.Context, keyHint archive.DestructuredKey, state *UploadState, fullHash hash.Hash) error {
+	err := verifyContentSha256(keyHint, fullHash)
+	if err != nil {
+		u.abort(ctx, state)
+		return err
+	}
+
+	compositeHash := sha256.New()
+	completed := make([]types.CompletedPart, len(state.Parts))
+	for i, part := range state.Parts {
+		completed[i] = types.CompletedPart{
+			ETag:           aws.String(part.ETag),
+			PartNumber:     aws.Int32(part.Number),
+			ChecksumSHA256: aws.String(part.ChecksumSHA256),
+		}
+
+		rawChecksum, _ := base64.StdEncoding.DecodeString(part.ChecksumSHA256)
+		compositeHash.Write(rawChecksum)
+	}
+
+	output, err := u.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          &u.bucketName,
+		Key:             &state.Key,
+		UploadId:        &state.UploadID,
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: completed},
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to assemble the parts of %s", state.Key)
+	}
+
+	expected := fmt.Sprintf("%s-%d", base64.StdEncoding.EncodeToString(compositeHash.Sum(nil)), len(completed))
+	if output.ChecksumSHA256 != nil && *output.ChecksumSHA256 != expected {
+		_, _ = u.client.DeleteObject(ctx, &s3.DeleteObjectInput{Bucket: &u.bucketName, Key: &state.Key})
+		_ = u.options.States.DeleteUploadState(state.Hint)
+		return errors.Errorf("assembled object %s is corrupted: checksum %s doesn't match %s", state.Key, *output.ChecksumSHA256, expected)
+	}
+
+	return u.options.States.DeleteUploadState(state.Hint)
+}
+
+// abort discards the parts uploaded and forgets the upload ; errors are only logged because the upload is restarted anyway.
+func (u *multipartUploader) abort(ctx context.Context, state *UploadState) {
+	_, err := u.client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   &u.bucketName,
+		Key:      &state.Key,
+		UploadId: &state.UploadID,
+	})
+	if err != nil && !errors.As(err, new(*types.NoSuchUpload)) {
+		log.WithError(err).WithField("Key", state.Key).Warnln("Multipart upload couldn't be aborted")
+	}
+
+	err = u.options.States.DeleteUploadState(state.Hint)
+	if err != nil {
+		log.WithError(err).WithField("Key", state.Key).Warnln("Upload state couldn't be deleted")
+	}
+}
+
+// abortStaleUploads aborts the uploads of the bucket started before StaleAfter, their parts are otherwise billed forever.
+func (u *multipartUploader) abortStaleUploads(ctx context.Context) error {
+	staleBefore := u.now().Add(-u.options.StaleAfter)
+
+	states, err := u.options.States.ListUploadStates()
+	if err != nil {
+		return err
+	}
+	for _, state := range states {
+		if state.CreatedAt.Before(staleBefore) {
+			u.abort(ctx, &state)
+		}
+	}
+
+	var keyMarker, uploadIdMarker *string
+	for {
+		listed, err := u.client.ListMultipartUploads(ctx, &s3.ListMultipartUploadsInput{
+			Bucket:         &u.bucketName,
+			KeyMarker:      keyMarker,
+			UploadIdMarker: uploadIdMarker,
+		})
+		if err != nil {
+			return errors.Wrapf(err, "failed to list multipart uploads of %s", u.bucketName)
+		}
+
+		for _, upload := range listed.Uploads {
+			if upload.Initiated != nil && upload.Initiated.Before(staleBefore) {
+				log.WithField("Key", aws.ToString(upload.Key)).Infof("Aborting multipart upload started on %s", upload.Initiated.Format(time.DateTime))
+				_, err = u.client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+					Bucket:   &u.bucketName,
+					Key:      upload.Key,
+					UploadId: upload.UploadId,
+				})
+				if err != nil && !errors.As(err, new(*types.NoSuchUpload)) {
+					return errors.Wrapf(err, "failed to abort multipart upload of %s", aws.ToString(upload.Key))
+				}
+			}
+		}
+
+		if !aws.ToBool(listed.IsTruncated) {
+			return nil
+		}
+		keyMarker, uploadIdMarker = listed.NextKeyMarker, listed.NextUploadIdMarker
+	}
+}
+
+// readPart returns less than partSize bytes only at the end of the content.
+func readPart(content io.Reader, partSize int64, fullHash hash.Hash) ([]byte, error) {
+	buffer := new(bytes.Buffer)
+	_, err := buffer.ReadFrom(io.LimitReader(content, partSize))
+
+	fullHash.Write(buffer.Bytes())
+	return buffer.Bytes(), err
+}
+
+func verifyContentSha256(keyHint archive.DestructuredKey, fullHash hash.Hash) error {
+	if keyHint.ContentSha256 == "" {
+		return nil
+	}
+
+	got := hex.EncodeToString(fullHash.Sum(nil))
+	if got != keyHint.ContentSha256 {
+		return errors.Wrapf(archive.ChecksumMismatchError, "content uploaded to %s has SHA-256 %s, expected %s", keyHint.Prefix, got, keyHint.ContentSha256)
+	}
+	return nil
+}
+
+func uploadHint(keyHint archive.DestructuredKey) string {
+	return keyHint.Prefix + keyHint.Suffix
+}
@@ -0,0 +1,422 @@
+package s3store
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/thomasduchatelle/dphoto/pkg/archive"
+	"io"
+	"slices"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMultipartUploader_upload(t *testing.T) {
+	largeContent := []byte("0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJ")
+	largeSha256 := sha256Hex(largeContent)
+
+	tests := []struct {
+		name       string
+		content    []byte
+		sha256     string
+		corrupt    bool
+		wantErr    error
+		wantObject []byte
+		wantParts  []int32
+	}{
+		{"it should upload a small content in a single request", []byte("foobar"), sha256Hex([]byte("foobar")), false, nil, []byte("foobar"), nil},
+		{"it should upload a large content by parts", largeContent, largeSha256, false, nil, largeContent, []int32{1, 2, 3, 4, 5}},
+		{"it should upload a large content without checksum to verify", largeContent, "", false, nil, largeContent, []int32{1, 2, 3, 4, 5}},
+		{"it should reject a small content not matching its checksum", []byte("foobaz"), sha256Hex([]byte("foobar")), false, archive.ChecksumMismatchError, nil, nil},
+		{"it should abort a large content not matching its checksum", largeContent[1:], largeSha256, false, archive.ChecksumMismatchError, nil, []int32{1, 2, 3, 4, 5}},
+		{"it should delete an assembled object with a wrong checksum", largeContent, largeSha256, true, errors.New("assembled object unit/media.jpg is corrupted"), nil, []int32{1, 2, 3, 4, 5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewS3MultipartFake()
+			client.CorruptComplete = tt.corrupt
+			states := NewInMemoryUploadStates()
+			uploader := newMultipartUploader(client, "unit-bucket", MultipartOptions{PartSize: 10, Concurrency: 2, States: states})
+
+			keys := new(UniqueKeyFake)
+			key, err := uploader.upload(context.Background(), archive.DestructuredKey{Prefix: "unit/media", Suffix: ".jpg", ContentSha256: tt.sha256}, bytes.NewReader(tt.content), keys.FindUniqueKey)
+
+			if tt.wantErr != nil {
+				assert.ErrorContains(t, err, tt.wantErr.Error())
+				assert.Empty(t, client.Objects, "it should not keep an object")
+			} else if assert.NoError(t, err) {
+				assert.Equal(t, "unit/media.jpg", key)
+				assert.Equal(t, map[string][]byte{key: tt.wantObject}, client.Objects)
+			}
+
+			assert.Equal(t, tt.wantParts, client.sortedPartCalls())
+			assert.Empty(t, client.Uploads, "it should not leave an upload in progress")
+			remaining, _ := states.ListUploadStates()
+			assert.Empty(t, remaining, "it should forget the state of the upload")
+		})
+	}
+}
+
+func TestMultipartUploader_resume(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 5)
+	keyHint := archive.DestructuredKey{Prefix: "unit/video", Suffix: ".mp4", ContentSha256: sha256Hex(content)}
+
+	client := NewS3MultipartFake()
+	client.FailPart = 3
+	states := NewInMemoryUploadStates()
+	keys := new(UniqueKeyFake)
+	uploader := newMultipartUploader(client, "unit-bucket", MultipartOptions{PartSize: 10, Concurrency: 1, States: states})
+
+	_, err := uploader.upload(context.Background(), keyHint, bytes.NewReader(content), keys.FindUniqueKey)
+	assert.ErrorContains(t, err, "failed to upload part 3 of unit/video.mp4")
+
+	state, _ := states.FindUploadState("unit/video.mp4")
+	if assert.NotNil(t, state, "it should keep the state to resume the upload") {
+		assert.Equal(t, "unit/video.mp4", state.Key)
+		assert.Len(t, state.Parts, 2)
+	}
+
+	client.PartCalls = nil
+	key, err := uploader.upload(context.Background(), keyHint, bytes.NewReader(content), keys.FindUniqueKey)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "unit/video.mp4", key)
+		assert.Equal(t, content, client.Objects[key])
+		assert.Equal(t, []int32{3, 4, 5}, client.sortedPartCalls(), "it should only upload the parts not already uploaded")
+		assert.Equal(t, 1, keys.Calls, "it should not look for a unique key again when resuming")
+	}
+
+	remaining, _ := states.ListUploadStates()
+	assert.Empty(t, remaining)
+}
+
+func TestMultipartUploader_resumeEncrypted(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 5)
+	keyHint := archive.DestructuredKey{Prefix: "unit/video", Suffix: ".mp4", ContentSha256: sha256Hex(content)}
+
+	client := NewS3MultipartFake()
+	client.FailPart = 3
+	states := NewInMemoryUploadStates()
+	multipartStore := &MultipartStoreStub{
+		uploader: newMultipartUploader(client, "unit-bucket", MultipartOptions{PartSize: 10, Concurrency: 1, States: states}),
+		client:   client,
+		keys:     new(UniqueKeyFake),
+	}
+	encryptedStore, err := archive.NewEncryptedStore(multipartStore, make(DataKeyRepositoryFake), bytes.Repeat([]byte{42}, archive.MasterKeySize))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	_, err = encryptedStore.Upload(keyHint, bytes.NewReader(content))
+	assert.ErrorContains(t, err, "failed to upload part 3 of unit/video.mp4")
+
+	client.PartCalls = nil
+	key, err := encryptedStore.Upload(keyHint, bytes.NewReader(content))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []int32{3, 4, 5, 6, 7, 8}, client.sortedPartCalls(), "it should encrypt the content the same way and only upload the parts not already uploaded")
+
+	reader, err := encryptedStore.Download(key)
+	if assert.NoError(t, err) {
+		decrypted, err := io.ReadAll(reader)
+		assert.NoError(t, err)
+		assert.Equal(t, content, decrypted)
+	}
+}
+
+func TestMultipartUploader_staleUploads(t *testing.T) {
+	now := time.Date(2024, 3, 15, 9, 0, 0, 0, time.UTC)
+	content := bytes.Repeat([]byte("0123456789"), 2)
+
+	client := NewS3MultipartFake()
+	client.Uploads["forgotten-upload"] = &UploadFake{key: "unit/forgotten.mp4", initiated: now.Add(-30 * 24 * time.Hour), parts: map[int32][]byte{}}
+	client.Uploads["recent-upload"] = &UploadFake{key: "unit/recent.mp4", initiated: now.Add(-time.Hour), parts: map[int32][]byte{}}
+
+	states := NewInMemoryUploadStates()
+	_ = states.SaveUploadState(UploadState{Hint: "unit/video.mp4", Key: "unit/video.mp4", UploadID: "stale-upload", PartSize: 10, CreatedAt: now.Add(-8 * 24 * time.Hour)})
+
+	uploader := newMultipartUploader(client, "unit-bucket", MultipartOptions{PartSize: 10, States: states})
+	uploader.now = func() time.Time {
+		return now
+	}
+
+	keys := new(UniqueKeyFake)
+	_, err := uploader.upload(context.Background(), archive.DestructuredKey{Prefix: "unit/video", Suffix: ".mp4"}, bytes.NewReader(content), keys.FindUniqueKey)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"recent-upload"}, client.uploadIds(), "it should abort uploads older than StaleAfter")
+		assert.Equal(t, content, client.Objects["unit/video.mp4"], "it should restart the upload from scratch")
+		assert.Equal(t, 1, keys.Calls)
+	}
+}
+
+func TestFileUploadStates(t *testing.T) {
+	states := NewFileUploadStates(t.TempDir())
+
+	state := UploadState{
+		Hint:      "owner/album/2024-03-15_09-00-00_12345678.mp4",
+		Key:       "owner/album/2024-03-15_09-00-00_12345678.mp4",
+		UploadID:  "upload-1",
+		PartSize:  DefaultPartSize,
+		CreatedAt: time.Date(2024, 3, 15, 9, 0, 0, 0, time.UTC),
+		Parts:     []UploadedPart{{Number: 1, ETag: `"etag-1"`, ChecksumSHA256: "c2hhLTE="}},
+	}
+	assert.NoError(t, states.SaveUploadState(state))
+
+	found, err := states.FindUploadState(state.Hint)
+	if assert.NoError(t, err) {
+		assert.Equal(t, &state, found)
+	}
+
+	listed, err := states.ListUploadStates()
+	if assert.NoError(t, err) {
+		assert.Equal(t, []UploadState{state}, listed)
+	}
+
+	assert.NoError(t, states.DeleteUploadState(state.Hint))
+	found, err = states.FindUploadState(state.Hint)
+	assert.NoError(t, err)
+	assert.Nil(t, found, "it should return nil when the state doesn't exist")
+	assert.NoError(t, states.DeleteUploadState(state.Hint), "it should ignore states already deleted")
+}
+
+func sha256Hex(content []byte) string {
+	hash := sha256.Sum256(content)
+	return hex.EncodeToString(hash[:])
+}
+
+type UniqueKeyFake struct {
+	Calls int
+}
+
+func (u *UniqueKeyFake) FindUniqueKey(keyHint archive.DestructuredKey) (string, error) {
+	u.Calls++
+	return keyHint.Prefix + keyHint.Suffix, nil
+}
+
+type UploadFake struct {
+	key       string
+	initiated time.Time
+	parts     map[int32][]byte
+}
+
+// S3MultipartFake behaves like S3: it verifies the checksums of the parts and computes the checksum of the assembled object.
+type S3MultipartFake struct {
+	lock            sync.Mutex
+	Objects         map[string][]byte
+	Uploads         map[string]*UploadFake
+	PartCalls       []int32
+	FailPart        int32 // FailPart is the number of a part failing once
+	CorruptComplete bool  // CorruptComplete returns a checksum different from the one expected when the parts are assembled
+	nextID          int
+}
+
+func NewS3MultipartFake() *S3MultipartFake {
+	return &S3MultipartFake{
+		Objects: make(map[string][]byte),
+		Uploads: make(map[string]*UploadFake),
+	}
+}
+
+func (f *S3MultipartFake) sortedPartCalls() []int32 {
+	if len(f.PartCalls) == 0 {
+		return nil
+	}
+	calls := slices.Clone(f.PartCalls)
+	slices.Sort(calls)
+	return calls
+}
+
+func (f *S3MultipartFake) uploadIds() []string {
+	var ids []string
+	for id := range f.Uploads {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (f *S3MultipartFake) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	content, _ := io.ReadAll(params.Body)
+	if checksum := sha256.Sum256(content); aws.ToString(params.ChecksumSHA256) != base64.StdEncoding.EncodeToString(checksum[:]) {
+		return nil, errors.Errorf("BadDigest: checksum of %s doesn't match", aws.ToString(params.Key))
+	}
+
+	f.Objects[aws.ToString(params.Key)] = content
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (f *S3MultipartFake) DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	delete(f.Objects, aws.ToString(params.Key))
+	return &s3.DeleteObjectOutput{}, nil
+}
+
+func (f *S3MultipartFake) CreateMultipartUpload(ctx context.Context, params *s3.CreateMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CreateMultipartUploadOutput, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.nextID++
+	id := fmt.Sprintf("upload-%d", f.nextID)
+	f.Uploads[id] = &UploadFake{key: aws.ToString(params.Key), initiated: time.Now(), parts: make(map[int32][]byte)}
+	return &s3.CreateMultipartUploadOutput{UploadId: &id}, nil
+}
+
+func (f *S3MultipartFake) UploadPart(ctx context.Context, params *s3.UploadPartInput, optFns ...func(*s3.Options)) (*s3.UploadPartOutput, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	number := aws.ToInt32(params.PartNumber)
+	f.PartCalls = append(f.PartCalls, number)
+	if number == f.FailPart {
+		f.FailPart = 0
+		return nil, errors.New("TEST connection reset by peer")
+	}
+
+	upload, exists := f.Uploads[aws.ToString(params.UploadId)]
+	if !exists {
+		return nil, &types.NoSuchUpload{}
+	}
+
+	content, _ := io.ReadAll(params.Body)
+	if checksum := sha256.Sum256(content); aws.ToString(params.ChecksumSHA256) != base64.StdEncoding.EncodeToString(checksum[:]) {
+		return nil, errors.Errorf("BadDigest: checksum of part %d doesn't match", number)
+	}
+
+	upload.parts[number] = content
+	return &s3.UploadPartOutput{ETag: aws.String(fmt.Sprintf(`"etag-%d-%s"`, number, aws.ToString(params.ChecksumSHA256)[:8]))}, nil
+}
+
+func (f *S3MultipartFake) CompleteMultipartUpload(ctx context.Context, params *s3.CompleteMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CompleteMultipartUploadOutput, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	upload, exists := f.Uploads[aws.ToString(params.UploadId)]
+	if !exists {
+		return nil, &types.NoSuchUpload{}
+	}
+
+	var content []byte
+	composite := sha256.New()
+	for i, part := range params.MultipartUpload.Parts {
+		if aws.ToInt32(part.PartNumber) != int32(i+1) {
+			return nil, errors.Errorf("InvalidPartOrder: part %d at position %d", aws.ToInt32(part.PartNumber), i)
+		}
+		partContent := upload.parts[aws.ToInt32(part.PartNumber)]
+		partChecksum := sha256.Sum256(partContent)
+		composite.Write(partChecksum[:])
+		content = append(content, partContent...)
+	}
+	if f.CorruptComplete {
+		composite.Write([]byte("corrupted"))
+	}
+
+	delete(f.Uploads, aws.ToString(params.UploadId))
+	f.Objects[upload.key] = content
+	checksum := fmt.Sprintf("%s-%d", base64.StdEncoding.EncodeToString(composite.Sum(nil)), len(params.MultipartUpload.Parts))
+	return &s3.CompleteMultipartUploadOutput{ChecksumSHA256: &checksum}, nil
+}
+
+func (f *S3MultipartFake) AbortMultipartUpload(ctx context.Context, params *s3.AbortMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if _, exists := f.Uploads[aws.ToString(params.UploadId)]; !exists {
+		return nil, &types.NoSuchUpload{}
+	}
+	delete(f.Uploads, aws.ToString(params.UploadId))
+	return &s3.AbortMultipartUploadOutput{}, nil
+}
+
+func (f *S3MultipartFake) ListParts(ctx context.Context, params *s3.ListPartsInput, optFns ...func(*s3.Options)) (*s3.ListPartsOutput, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	upload, exists := f.Uploads[aws.ToString(params.UploadId)]
+	if !exists {
+		return nil, &types.NoSuchUpload{}
+	}
+
+	output := &s3.ListPartsOutput{IsTruncated: aws.Bool(false)}
+	for number, content := range upload.parts {
+		checksum := sha256.Sum256(content)
+		encoded := base64.StdEncoding.EncodeToString(checksum[:])
+		output.Parts = append(output.Parts, types.Part{
+			PartNumber: aws.Int32(number),
+			ETag:       aws.String(fmt.Sprintf(`"etag-%d-%s"`, number, encoded[:8])),
+		})
+	}
+	return output, nil
+}
+
+func (f *S3MultipartFake) ListMultipartUploads(ctx context.Context, params *s3.ListMultipartUploadsInput, optFns ...func(*s3.Options)) (*s3.ListMultipartUploadsOutput, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	output := &s3.ListMultipartUploadsOutput{IsTruncated: aws.Bool(false)}
+	for _, id := range f.uploadIds() {
+		upload := f.Uploads[id]
+		output.Uploads = append(output.Uploads, types.MultipartUpload{
+			Key:       aws.String(upload.key),
+			UploadId:  aws.String(id),
+			Initiated: aws.Time(upload.initiated),
+		})
+	}
+	return output, nil
+}
+
+// MultipartStoreStub is the part of the store uploading with the multipart uploader, used to be decorated by the EncryptedStore.
+type MultipartStoreStub struct {
+	archive.StoreAdapter
+	uploader *multipartUploader
+	client   *S3MultipartFake
+	keys     *UniqueKeyFake
+}
+
+func (m *MultipartStoreStub) Upload(values archive.DestructuredKey, content io.Reader) (string, error) {
+	return m.uploader.upload(context.Background(), values, content, m.keys.FindUniqueKey)
+}
+
+func (m *MultipartStoreStub) Download(key string) (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader(m.client.Objects[key])), nil
+}
+
+func (m *MultipartStoreStub) Delete(locations []string) error {
+	for _, location := range locations {
+		delete(m.client.Objects, location)
+	}
+	return nil
+}
+
+type DataKeyRepositoryFake map[string][]byte
+
+func (d DataKeyRepositoryFake) FindDataKey(owner string) ([]byte, error) {
+	if wrapped, ok := d[owner]; ok {
+		return wrapped, nil
+	}
+	return nil, archive.NotFoundError
+}
+
+func (d DataKeyRepositoryFake) InsertDataKey(owner string, wrappedKey []byte) error {
+	d[owner] = wrappedKey
+	return nil
+}
@@ -0,0 +1,31 @@
+package s3store
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/pkg/errors"
+	"github.com/thomasduchatelle/dphoto/pkg/archive"
+	"io"
+)
+
+// NewReplica creates an archive.ReplicaAdapter on a secondary bucket, potentially from a different endpoint or region.
+func NewReplica(s3client *s3.Client, bucketName string) archive.ReplicaAdapter {
+	return &replica{
+		store: NewWithS3Client(s3client, bucketName).(*store),
+	}
+}
+
+type replica struct {
+	*store
+}
+
+// Put uses the multipart uploader because the content is streamed from the primary storage and is not seekable.
+func (r *replica) Put(key string, mediaType string, content io.Reader) error {
+	_, err := r.s3Uploader.Upload(context.TODO(), &s3.PutObjectInput{
+		Body:        content,
+		Bucket:      &r.bucketName,
+		ContentType: &mediaType,
+		Key:         &key,
+	})
+	return errors.Wrapf(err, "failed to upload %s in bucket %s", key, r.bucketName)
+}
@@ -342,3 +342,42 @@ func TestWalkCacheByPrefix(t *testing.T) {
 		})
 	}
 }
+
+func TestTieringStore(t *testing.T) {
+	a := assert.New(t)
+	adapter, clean := newMockedStore("tiering")
+	defer clean()
+
+	const key = "unittest/2021/img-2021-1.jpg"
+	_, err := adapter.client.PutObject(ctx, &s3.PutObjectInput{
+		Body:   manager.ReadSeekCloser(strings.NewReader("content of " + key)),
+		Bucket: &adapter.bucketName,
+		Key:    aws.String(key),
+	})
+	if !a.NoError(err) {
+		return
+	}
+
+	tiering := NewTieringStore(adapter.client, adapter.bucketName)
+
+	_, err = tiering.GetStorageStatus("unittest/2021/not-found.jpg")
+	a.ErrorIs(err, archive.NotFoundError)
+
+	a.NoError(tiering.ChangeStorageClass(key, "GLACIER"))
+
+	status, err := tiering.GetStorageStatus(key)
+	if a.NoError(err) {
+		a.Equal("GLACIER", status.StorageClass)
+		a.True(status.Archived)
+	}
+
+	a.NoError(tiering.RequestRestore(key, 7*24*time.Hour))
+	a.NoError(tiering.RequestRestore(key, 7*24*time.Hour), "it should be idempotent")
+
+	status, err = tiering.GetStorageStatus(key)
+	if a.NoError(err) {
+		a.True(status.RestoreOngoing || !status.RestoredUntil.IsZero(), "it should have started the restore: %+v", status)
+	}
+
+	_ = adapter.Delete([]string{key})
+}
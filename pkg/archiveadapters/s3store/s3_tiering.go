@@ -0,0 +1,91 @@
+package s3store
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	"github.com/pkg/errors"
+	"github.com/thomasduchatelle/dphoto/pkg/archive"
+	"math"
+	"net/http"
+	"regexp"
+	"time"
+)
+
+var (
+	restoreHeaderRegex = regexp.MustCompile(`ongoing-request="(\w+)"(?:,\s*expiry-date="([^"]+)")?`)
+)
+
+// NewTieringStore creates an archive.TieringStoreAdapter on the bucket used as main storage.
+func NewTieringStore(s3client *s3.Client, bucketName string) archive.TieringStoreAdapter {
+	return &tieringStore{
+		store: NewWithS3Client(s3client, bucketName).(*store),
+	}
+}
+
+type tieringStore struct {
+	*store
+}
+
+// ChangeStorageClass copies the object onto itself, S3 doesn't support changing the class of an object in place.
+func (t *tieringStore) ChangeStorageClass(key string, storageClass string) error {
+	err := copyObject(context.TODO(), t.client, t.bucketName, key, key, types.StorageClass(storageClass))
+	return errors.Wrapf(err, "failed to change storage class of %s to %s", key, storageClass)
+}
+
+func (t *tieringStore) GetStorageStatus(key string) (archive.StorageStatus, error) {
+	head, err := t.client.HeadObject(context.TODO(), &s3.HeadObjectInput{
+		Bucket: &t.bucketName,
+		Key:    &key,
+	})
+	var notFound *types.NotFound
+	if errors.As(err, &notFound) || t.isNotFound(err) {
+		return archive.StorageStatus{}, archive.NotFoundError
+	}
+	if err != nil {
+		return archive.StorageStatus{}, errors.Wrapf(err, "failed to get storage status of %s", key)
+	}
+
+	status := archive.StorageStatus{
+		StorageClass: string(head.StorageClass),
+		Archived:     head.StorageClass == types.StorageClassGlacier || head.StorageClass == types.StorageClassDeepArchive,
+	}
+
+	if head.Restore != nil {
+		if match := restoreHeaderRegex.FindStringSubmatch(*head.Restore); match != nil {
+			status.RestoreOngoing = match[1] == "true"
+			if expiry, err := time.Parse(http.TimeFormat, match[2]); err == nil {
+				status.RestoredUntil = expiry
+			}
+		}
+	}
+
+	return status, nil
+}
+
+// RequestRestore uses the standard retrieval tier ; a restore already in progress is not an error.
+func (t *tieringStore) RequestRestore(key string, duration time.Duration) error {
+	days := int32(math.Ceil(duration.Hours() / 24))
+	if days < 1 {
+		days = 1
+	}
+
+	_, err := t.client.RestoreObject(context.TODO(), &s3.RestoreObjectInput{
+		Bucket: &t.bucketName,
+		Key:    &key,
+		RestoreRequest: &types.RestoreRequest{
+			Days: aws.Int32(days),
+			GlacierJobParameters: &types.GlacierJobParameters{
+				Tier: types.TierStandard,
+			},
+		},
+	})
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && apiErr.ErrorCode() == "RestoreAlreadyInProgress" {
+		return nil
+	}
+	return errors.Wrapf(err, "failed to request restore of %s", key)
+}
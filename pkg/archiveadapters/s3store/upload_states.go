@@ -0,0 +1,194 @@
+package s3store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// UploadState is the progress of a multipart upload, used to resume it.
+type UploadState struct {
+	Hint      string         `json:"hint"` // Hint is the key hint (prefix + suffix) the upload has been requested with
+	Key       string         `json:"key"`  // Key is the unique key chosen when the upload started
+	UploadID  string         `json:"uploadId"`
+	PartSize  int64          `json:"partSize"`
+	CreatedAt time.Time      `json:"createdAt"`
+	Parts     []UploadedPart `json:"parts"` // Parts are sorted by number
+}
+
+type UploadedPart struct {
+	Number         int32  `json:"number"`
+	ETag           string `json:"etag"`
+	ChecksumSHA256 string `json:"checksumSha256"` // ChecksumSHA256 is encoded in base 64, as S3 does
+}
+
+func (s *UploadState) setPart(part UploadedPart) {
+	s.Parts = slices.DeleteFunc(s.Parts, func(existing UploadedPart) bool {
+		return existing.Number == part.Number
+	})
+	s.Parts = append(s.Parts, part)
+	slices.SortFunc(s.Parts, func(a, b UploadedPart) int {
+		return int(a.Number - b.Number)
+	})
+}
+
+func (s *UploadState) hasPart(number int32, checksum string) bool {
+	return slices.ContainsFunc(s.Parts, func(part UploadedPart) bool {
+		return part.Number == number && part.ChecksumSHA256 == checksum
+	})
+}
+
+// UploadStateRepository persists the progress of the multipart uploads.
+type UploadStateRepository interface {
+	// FindUploadState returns nil when there is no upload in progress for this hint
+	FindUploadState(hint string) (*UploadState, error)
+	SaveUploadState(state UploadState) error
+	DeleteUploadState(hint string) error
+	ListUploadStates() ([]UploadState, error)
+}
+
+// NewInMemoryUploadStates is used when uploads are not resumed after a restart.
+func NewInMemoryUploadStates() UploadStateRepository {
+	return &inMemoryUploadStates{states: make(map[string]UploadState)}
+}
+
+type inMemoryUploadStates struct {
+	lock   sync.Mutex
+	states map[string]UploadState
+}
+
+func (m *inMemoryUploadStates) FindUploadState(hint string) (*UploadState, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	state, found := m.states[hint]
+	if !found {
+		return nil, nil
+	}
+	state.Parts = slices.Clone(state.Parts)
+	return &state, nil
+}
+
+func (m *inMemoryUploadStates) SaveUploadState(state UploadState) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	state.Parts = slices.Clone(state.Parts)
+	m.states[state.Hint] = state
+	return nil
+}
+
+func (m *inMemoryUploadStates) DeleteUploadState(hint string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	delete(m.states, hint)
+	return nil
+}
+
+func (m *inMemoryUploadStates) ListUploadStates() ([]UploadState, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	var states []UploadState
+	for _, state := range m.states {
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+// NewFileUploadStates persists each upload in progress in a JSON file of the directory, to resume it when the backup is retried.
+func NewFileUploadStates(directory string) UploadStateRepository {
+	return &fileUploadStates{directory: directory}
+}
+
+type fileUploadStates struct {
+	directory string
+}
+
+func (f *fileUploadStates) FindUploadState(hint string) (*UploadState, error) {
+	content, err := os.ReadFile(f.file(hint))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the upload state of %s", hint)
+	}
+
+	state := new(UploadState)
+	err = json.Unmarshal(content, state)
+	if err != nil {
+		// a corrupted state is restarted from scratch
+		return nil, nil
+	}
+	return state, nil
+}
+
+func (f *fileUploadStates) SaveUploadState(state UploadState) error {
+	err := os.MkdirAll(f.directory, 0700)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create upload states directory %s", f.directory)
+	}
+
+	content, err := json.Marshal(state)
+	if err != nil {
+		return errors.Wrapf(err, "failed to serialise the upload state of %s", state.Hint)
+	}
+
+	file := f.file(state.Hint)
+	err = os.WriteFile(file+".tmp", content, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "failed to write the upload state of %s", state.Hint)
+	}
+	return errors.Wrapf(os.Rename(file+".tmp", file), "failed to replace the upload state of %s", state.Hint)
+}
+
+func (f *fileUploadStates) DeleteUploadState(hint string) error {
+	err := os.Remove(f.file(hint))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return errors.Wrapf(err, "failed to delete the upload state of %s", hint)
+	}
+	return nil
+}
+
+func (f *fileUploadStates) ListUploadStates() ([]UploadState, error) {
+	entries, err := os.ReadDir(f.directory)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list upload states in %s", f.directory)
+	}
+
+	var states []UploadState
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+
+		content, err := os.ReadFile(filepath.Join(f.directory, entry.Name()))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read upload state %s", entry.Name())
+		}
+
+		var state UploadState
+		if json.Unmarshal(content, &state) == nil {
+			states = append(states, state)
+		}
+	}
+
+	return states, nil
+}
+
+// file names are hashed because hints are paths.
+func (f *fileUploadStates) file(hint string) string {
+	hash := sha256.Sum256([]byte(hint))
+	return filepath.Join(f.directory, hex.EncodeToString(hash[:16])+".json")
+}
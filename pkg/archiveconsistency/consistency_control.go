@@ -0,0 +1,290 @@
+package archiveconsistency
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/thomasduchatelle/dphoto/pkg/catalog"
+	"github.com/thomasduchatelle/dphoto/pkg/ownermodel"
+	"path"
+	"sort"
+	"strings"
+)
+
+type FindAlbumsByOwnerPort interface {
+	FindAlbumsByOwner(ctx context.Context, owner ownermodel.Owner) ([]*catalog.Album, error)
+}
+
+type ListMediasPort interface {
+	ListMedias(ctx context.Context, albumId catalog.AlbumId) ([]*catalog.MediaMeta, error)
+}
+
+// FindLocationsPort is implemented by archive.ARepositoryAdapter
+type FindLocationsPort interface {
+	FindByIds(owner string, ids []string) (map[string]string, error)
+}
+
+// StoreWalkerPort lists the keys physically present in the main storage ; s3store implements it for any bucket.
+type StoreWalkerPort interface {
+	WalkCacheByPrefix(prefix string, observer func(string)) error
+}
+
+// RelocateMediasPort is implemented by the archive relocators used by the catalog (sync or async)
+type RelocateMediasPort interface {
+	OnTransferredMedias(ctx context.Context, transfers catalog.TransferredMedias) error
+}
+
+// DeleteStoredKeysPort is implemented by archive.StoreAdapter
+type DeleteStoredKeysPort interface {
+	Delete(locations []string) error
+}
+
+// ConfirmDeletionPort is called before deleting orphan objects, deletion is skipped if it returns false.
+type ConfirmDeletionPort interface {
+	ConfirmDeletion(ctx context.Context, keys []string) (bool, error)
+}
+
+type ConfirmDeletionFunc func(ctx context.Context, keys []string) (bool, error)
+
+func (f ConfirmDeletionFunc) ConfirmDeletion(ctx context.Context, keys []string) (bool, error) {
+	return f(ctx, keys)
+}
+
+type DriftOption struct {
+	observer DriftObserver
+	repairer *DriftRepairObserver
+}
+
+// DriftOptionObserver adds a custom observer.
+func DriftOptionObserver(observer DriftObserver) DriftOption {
+	return DriftOption{observer: observer}
+}
+
+// DriftOptionRepair re-queues the relocation of misplaced medias, and deletes orphan objects once confirmed.
+func DriftOptionRepair(relocator RelocateMediasPort, deleter DeleteStoredKeysPort, confirmation ConfirmDeletionPort) DriftOption {
+	return DriftOption{repairer: &DriftRepairObserver{
+		RelocateMediasPort:   relocator,
+		DeleteStoredKeysPort: deleter,
+		ConfirmDeletionPort:  confirmation,
+	}}
+}
+
+// DriftOptionDryMode enable or not the DRY mode.
+func DriftOptionDryMode(dry bool, relocator RelocateMediasPort, deleter DeleteStoredKeysPort, confirmation ConfirmDeletionPort) DriftOption {
+	if !dry {
+		return DriftOptionRepair(relocator, deleter, confirmation)
+	}
+	return DriftOptionObserver(nil)
+}
+
+func (o *DriftOption) Observer() DriftObserver {
+	switch {
+	case o.observer != nil:
+		return o.observer
+	case o.repairer != nil:
+		return o.repairer
+	default:
+		return nil
+	}
+}
+
+// NewConsistencyReconciler creates a new OwnerConsistencyReconciler in DRY mode ; use the option DriftOptionRepair to reconcile.
+func NewConsistencyReconciler(
+	findAlbumsByOwnerPort FindAlbumsByOwnerPort,
+	listMediasPort ListMediasPort,
+	findLocationsPort FindLocationsPort,
+	storeWalkerPort StoreWalkerPort,
+	options ...DriftOption,
+) *OwnerConsistencyReconciler {
+	observers := []DriftObserver{
+		new(LoggerDriftObserver),
+	}
+	for _, option := range options {
+		observer := option.Observer()
+		if observer != nil {
+			observers = append(observers, observer)
+		}
+	}
+
+	return &OwnerConsistencyReconciler{
+		FindAlbumsByOwnerPort: findAlbumsByOwnerPort,
+		ListMediasPort:        listMediasPort,
+		FindLocationsPort:     findLocationsPort,
+		StoreWalkerPort:       storeWalkerPort,
+		DriftObservers:        observers,
+	}
+}
+
+// OwnerConsistencyReconciler compares, for one owner, the medias from the catalog, their locations in the archive index, and the keys physically stored.
+type OwnerConsistencyReconciler struct {
+	FindAlbumsByOwnerPort FindAlbumsByOwnerPort
+	ListMediasPort        ListMediasPort
+	FindLocationsPort     FindLocationsPort
+	StoreWalkerPort       StoreWalkerPort
+	DriftObservers        []DriftObserver
+}
+
+// Reconcile detects the drifts of the owner and notifies the observers with them.
+func (r *OwnerConsistencyReconciler) Reconcile(ctx context.Context, owner ownermodel.Owner) error {
+	drifts, err := r.DetectDrifts(ctx, owner)
+	if err != nil || len(drifts) == 0 {
+		return err
+	}
+
+	for _, observer := range r.DriftObservers {
+		err = observer.OnDetectedDrifts(ctx, drifts)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DetectDrifts returns all drifts found for the owner, without notifying the observers.
+func (r *OwnerConsistencyReconciler) DetectDrifts(ctx context.Context, owner ownermodel.Owner) ([]Drift, error) {
+	albums, err := r.FindAlbumsByOwnerPort.FindAlbumsByOwner(ctx, owner)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list albums of %s", owner)
+	}
+
+	mediaAlbums := make(map[string]catalog.AlbumId)
+	var ids []string
+	for _, album := range albums {
+		medias, err := r.ListMediasPort.ListMedias(ctx, album.AlbumId)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list medias of %s", album.AlbumId)
+		}
+
+		for _, media := range medias {
+			mediaAlbums[string(media.Id)] = album.AlbumId
+			ids = append(ids, string(media.Id))
+		}
+	}
+
+	var locations map[string]string
+	if len(ids) > 0 {
+		locations, err = r.FindLocationsPort.FindByIds(owner.String(), ids)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to find locations of %d medias", len(ids))
+		}
+	}
+
+	storedKeys := make(map[string]interface{})
+	err = r.StoreWalkerPort.WalkCacheByPrefix(owner.String()+"/", func(key string) {
+		storedKeys[key] = nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list stored keys of %s", owner)
+	}
+
+	var drifts []Drift
+	referencedKeys := make(map[string]interface{})
+	for _, id := range ids {
+		albumId := mediaAlbums[id]
+		key, found := locations[id]
+
+		switch {
+		case !found:
+			drifts = append(drifts, NewMissingLocationDrift(albumId, catalog.MediaId(id)))
+
+		default:
+			referencedKeys[key] = nil
+
+			if _, stored := storedKeys[key]; !stored {
+				drifts = append(drifts, NewMissingObjectDrift(albumId, catalog.MediaId(id), key))
+			} else if !isStoredInAlbumFolder(key, albumId) {
+				drifts = append(drifts, NewMisplacedMediaDrift(albumId, catalog.MediaId(id), key))
+			}
+		}
+	}
+
+	var orphans []string
+	for key := range storedKeys {
+		if _, referenced := referencedKeys[key]; !referenced {
+			orphans = append(orphans, key)
+		}
+	}
+	sort.Strings(orphans)
+	for _, key := range orphans {
+		drifts = append(drifts, NewOrphanObjectDrift(owner, key))
+	}
+
+	return drifts, nil
+}
+
+// isStoredInAlbumFolder follows the key convention from archive.Store: <owner>/<folder name>/<filename>
+func isStoredInAlbumFolder(key string, albumId catalog.AlbumId) bool {
+	return path.Dir(key) == path.Join(albumId.Owner.String(), strings.Trim(albumId.FolderName.String(), "/"))
+}
+
+type LoggerDriftObserver struct{}
+
+func (l LoggerDriftObserver) OnDetectedDrifts(ctx context.Context, drifts []Drift) error {
+	for _, drift := range drifts {
+		switch {
+		case drift.MissingLocation != nil:
+			log.Infof("drift: %-20s | %-30s | %-40s | %s", "MISSING_LOCATION", drift.MissingLocation.AlbumId, drift.MissingLocation.MediaId, "")
+		case drift.MissingObject != nil:
+			log.Infof("drift: %-20s | %-30s | %-40s | %s", "MISSING_OBJECT", drift.MissingObject.AlbumId, drift.MissingObject.MediaId, drift.MissingObject.Key)
+		case drift.MisplacedMedia != nil:
+			log.Infof("drift: %-20s | %-30s | %-40s | %s", "MISPLACED", drift.MisplacedMedia.AlbumId, drift.MisplacedMedia.MediaId, drift.MisplacedMedia.Key)
+		case drift.OrphanObject != nil:
+			log.Infof("drift: %-20s | %-30s | %-40s | %s", "ORPHAN", drift.OrphanObject.Owner, "", drift.OrphanObject.Key)
+		}
+	}
+	return nil
+}
+
+// DriftRepairObserver re-queues relocation of misplaced medias and deletes orphans ; missing locations and objects can only be reported.
+type DriftRepairObserver struct {
+	RelocateMediasPort   RelocateMediasPort
+	DeleteStoredKeysPort DeleteStoredKeysPort
+	ConfirmDeletionPort  ConfirmDeletionPort
+}
+
+func (d *DriftRepairObserver) OnDetectedDrifts(ctx context.Context, drifts []Drift) error {
+	transfers := catalog.NewTransferredMedias()
+	var orphans []string
+
+	for _, drift := range drifts {
+		switch {
+		case drift.MisplacedMedia != nil:
+			albumId := drift.MisplacedMedia.AlbumId
+			transfers.Transfers[albumId] = append(transfers.Transfers[albumId], drift.MisplacedMedia.MediaId)
+
+		case drift.OrphanObject != nil:
+			orphans = append(orphans, drift.OrphanObject.Key)
+
+		case drift.MissingLocation != nil || drift.MissingObject != nil:
+			log.Warnf("drift %+v cannot be repaired automatically, media must be backed up again", drift)
+
+		default:
+			return errors.Errorf("Drift not supported: %+v", drift)
+		}
+	}
+
+	if !transfers.IsEmpty() {
+		err := d.RelocateMediasPort.OnTransferredMedias(ctx, transfers)
+		if err != nil {
+			return errors.Wrapf(err, "failed to re-queue relocation of misplaced medias")
+		}
+	}
+
+	if len(orphans) > 0 {
+		confirmed, err := d.ConfirmDeletionPort.ConfirmDeletion(ctx, orphans)
+		if err != nil {
+			return errors.Wrapf(err, "failed to confirm the deletion of %d orphan objects", len(orphans))
+		}
+		if !confirmed {
+			return nil
+		}
+
+		err = d.DeleteStoredKeysPort.Delete(orphans)
+		if err != nil {
+			return errors.Wrapf(err, "failed to delete %d orphan objects", len(orphans))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,206 @@
+package archiveconsistency
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/thomasduchatelle/dphoto/pkg/catalog"
+	"github.com/thomasduchatelle/dphoto/pkg/ownermodel"
+	"testing"
+)
+
+func TestNewConsistencyReconcilerAcceptance(t *testing.T) {
+	owner1 := ownermodel.Owner("owner1")
+	album1 := catalog.AlbumId{Owner: owner1, FolderName: "/folder-1"}
+	album2 := catalog.AlbumId{Owner: owner1, FolderName: "/folder-2"}
+
+	type fields struct {
+		albums    []*catalog.Album
+		medias    map[catalog.AlbumId][]catalog.MediaId
+		locations map[string]string
+		stored    []string
+	}
+	type args struct {
+		dry        bool
+		confirm    bool
+		confirmErr error
+	}
+	tests := []struct {
+		name          string
+		fields        fields
+		args          args
+		wantDrifts    []Drift
+		wantRelocated map[catalog.AlbumId][]catalog.MediaId
+		wantDeleted   []string
+		wantErr       assert.ErrorAssertionFunc
+	}{
+		{
+			name: "it should not detect any drift when catalog and archive are consistent",
+			fields: fields{
+				albums: []*catalog.Album{{AlbumId: album1}},
+				medias: map[catalog.AlbumId][]catalog.MediaId{album1: {"media-1"}},
+				locations: map[string]string{
+					"media-1": "owner1/folder-1/2022-06-19_15-02-10_16c6dfa0.jpg",
+				},
+				stored: []string{"owner1/folder-1/2022-06-19_15-02-10_16c6dfa0.jpg"},
+			},
+			args:    args{dry: false, confirm: true},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "it should detect and repair the 4 types of drifts",
+			fields: fields{
+				albums: []*catalog.Album{{AlbumId: album1}, {AlbumId: album2}},
+				medias: map[catalog.AlbumId][]catalog.MediaId{
+					album1: {"media-1", "media-2"},
+					album2: {"media-3"},
+				},
+				locations: map[string]string{
+					"media-2": "owner1/folder-1/media-2.jpg",
+					"media-3": "owner1/folder-1/media-3.jpg",
+				},
+				stored: []string{"owner1/folder-1/media-3.jpg", "owner1/folder-2/media-3.jpg"},
+			},
+			args: args{dry: false, confirm: true},
+			wantDrifts: []Drift{
+				NewMissingLocationDrift(album1, "media-1"),
+				NewMissingObjectDrift(album1, "media-2", "owner1/folder-1/media-2.jpg"),
+				NewMisplacedMediaDrift(album2, "media-3", "owner1/folder-1/media-3.jpg"),
+				NewOrphanObjectDrift(owner1, "owner1/folder-2/media-3.jpg"),
+			},
+			wantRelocated: map[catalog.AlbumId][]catalog.MediaId{album2: {"media-3"}},
+			wantDeleted:   []string{"owner1/folder-2/media-3.jpg"},
+			wantErr:       assert.NoError,
+		},
+		{
+			name: "it should not delete orphans when deletion is not confirmed",
+			fields: fields{
+				stored: []string{"owner1/folder-2/media-3.jpg"},
+			},
+			args: args{dry: false, confirm: false},
+			wantDrifts: []Drift{
+				NewOrphanObjectDrift(owner1, "owner1/folder-2/media-3.jpg"),
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "it should not delete orphans when the confirmation failed",
+			fields: fields{
+				stored: []string{"owner1/folder-2/media-3.jpg"},
+			},
+			args: args{dry: false, confirm: true, confirmErr: errors.New("TEST prompt failed")},
+			wantDrifts: []Drift{
+				NewOrphanObjectDrift(owner1, "owner1/folder-2/media-3.jpg"),
+			},
+			wantErr: assert.Error,
+		},
+		{
+			name: "it should not do anything on dry mode",
+			fields: fields{
+				albums: []*catalog.Album{{AlbumId: album2}},
+				medias: map[catalog.AlbumId][]catalog.MediaId{album2: {"media-3"}},
+				locations: map[string]string{
+					"media-3": "owner1/folder-1/media-3.jpg",
+				},
+				stored: []string{"owner1/folder-1/media-3.jpg", "owner1/folder-2/media-3.jpg"},
+			},
+			args: args{dry: true, confirm: true},
+			wantDrifts: []Drift{
+				NewMisplacedMediaDrift(album2, "media-3", "owner1/folder-1/media-3.jpg"),
+				NewOrphanObjectDrift(owner1, "owner1/folder-2/media-3.jpg"),
+			},
+			wantErr: assert.NoError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			catalogFake := &CatalogFake{Albums: tt.fields.albums, Medias: tt.fields.medias}
+			store := &StoreFake{Keys: tt.fields.stored}
+			relocator := &RelocateMediasFake{}
+			drifts := new(DriftObserverFake)
+
+			reconciler := NewConsistencyReconciler(
+				catalogFake,
+				catalogFake,
+				LocationsFake(tt.fields.locations),
+				store,
+				DriftOptionObserver(drifts),
+				DriftOptionDryMode(tt.args.dry, relocator, store, ConfirmDeletionFunc(func(ctx context.Context, keys []string) (bool, error) {
+					return tt.args.confirm, tt.args.confirmErr
+				})),
+			)
+
+			err := reconciler.Reconcile(context.Background(), owner1)
+			if tt.wantErr(t, err) {
+				assert.Equal(t, tt.wantDrifts, drifts.Drifts)
+				assert.Equal(t, tt.wantRelocated, relocator.Transfers)
+				assert.Equal(t, tt.wantDeleted, store.Deleted)
+			}
+		})
+	}
+}
+
+type CatalogFake struct {
+	Albums []*catalog.Album
+	Medias map[catalog.AlbumId][]catalog.MediaId
+}
+
+func (c *CatalogFake) FindAlbumsByOwner(ctx context.Context, owner ownermodel.Owner) ([]*catalog.Album, error) {
+	return c.Albums, nil
+}
+
+func (c *CatalogFake) ListMedias(ctx context.Context, albumId catalog.AlbumId) ([]*catalog.MediaMeta, error) {
+	var medias []*catalog.MediaMeta
+	for _, id := range c.Medias[albumId] {
+		medias = append(medias, &catalog.MediaMeta{Id: id})
+	}
+	return medias, nil
+}
+
+type LocationsFake map[string]string
+
+func (l LocationsFake) FindByIds(owner string, ids []string) (map[string]string, error) {
+	locations := make(map[string]string)
+	for _, id := range ids {
+		if key, ok := l[id]; ok {
+			locations[id] = key
+		}
+	}
+	return locations, nil
+}
+
+type StoreFake struct {
+	Keys    []string
+	Deleted []string
+}
+
+func (s *StoreFake) WalkCacheByPrefix(prefix string, observer func(string)) error {
+	for _, key := range s.Keys {
+		observer(key)
+	}
+	return nil
+}
+
+func (s *StoreFake) Delete(locations []string) error {
+	s.Deleted = append(s.Deleted, locations...)
+	return nil
+}
+
+type RelocateMediasFake struct {
+	Transfers map[catalog.AlbumId][]catalog.MediaId
+}
+
+func (r *RelocateMediasFake) OnTransferredMedias(ctx context.Context, transfers catalog.TransferredMedias) error {
+	r.Transfers = transfers.Transfers
+	return nil
+}
+
+type DriftObserverFake struct {
+	Drifts []Drift
+}
+
+func (d *DriftObserverFake) OnDetectedDrifts(ctx context.Context, drifts []Drift) error {
+	d.Drifts = append(d.Drifts, drifts...)
+	return nil
+}
@@ -0,0 +1,80 @@
+// Package archiveconsistency detects and repairs drifts between the catalog (medias and albums) and the archive (locations index and physical storage).
+package archiveconsistency
+
+import (
+	"context"
+	"github.com/thomasduchatelle/dphoto/pkg/catalog"
+	"github.com/thomasduchatelle/dphoto/pkg/ownermodel"
+)
+
+type DriftObserver interface {
+	OnDetectedDrifts(ctx context.Context, drifts []Drift) error
+}
+
+// Drift has exactly one of its fields set.
+type Drift struct {
+	MissingLocation *MissingLocationDrift // MissingLocation is a media in the catalog that has no location in the archive index
+	MissingObject   *MissingObjectDrift   // MissingObject is a location pointing to a key that doesn't exist in the store
+	OrphanObject    *OrphanObjectDrift    // OrphanObject is a key in the store that no media of the catalog references
+	MisplacedMedia  *MisplacedMediaDrift  // MisplacedMedia is a media stored in a folder that doesn't match its album (interrupted relocation)
+}
+
+type MissingLocationDrift struct {
+	AlbumId catalog.AlbumId
+	MediaId catalog.MediaId
+}
+
+type MissingObjectDrift struct {
+	AlbumId catalog.AlbumId
+	MediaId catalog.MediaId
+	Key     string
+}
+
+type OrphanObjectDrift struct {
+	Owner ownermodel.Owner
+	Key   string
+}
+
+type MisplacedMediaDrift struct {
+	AlbumId catalog.AlbumId // AlbumId is the album in which the media is in the catalog, and the folder where it should be stored
+	MediaId catalog.MediaId
+	Key     string // Key is the current location of the media
+}
+
+func NewMissingLocationDrift(albumId catalog.AlbumId, mediaId catalog.MediaId) Drift {
+	return Drift{
+		MissingLocation: &MissingLocationDrift{
+			AlbumId: albumId,
+			MediaId: mediaId,
+		},
+	}
+}
+
+func NewMissingObjectDrift(albumId catalog.AlbumId, mediaId catalog.MediaId, key string) Drift {
+	return Drift{
+		MissingObject: &MissingObjectDrift{
+			AlbumId: albumId,
+			MediaId: mediaId,
+			Key:     key,
+		},
+	}
+}
+
+func NewOrphanObjectDrift(owner ownermodel.Owner, key string) Drift {
+	return Drift{
+		OrphanObject: &OrphanObjectDrift{
+			Owner: owner,
+			Key:   key,
+		},
+	}
+}
+
+func NewMisplacedMediaDrift(albumId catalog.AlbumId, mediaId catalog.MediaId, key string) Drift {
+	return Drift{
+		MisplacedMedia: &MisplacedMediaDrift{
+			AlbumId: albumId,
+			MediaId: mediaId,
+			Key:     key,
+		},
+	}
+}
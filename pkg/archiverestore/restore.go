@@ -0,0 +1,390 @@
+// Package archiverestore rebuilds a local tree of albums from the originals stored in the archive.
+package archiverestore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/thomasduchatelle/dphoto/pkg/catalog"
+	"github.com/thomasduchatelle/dphoto/pkg/ownermodel"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const DefaultParallel = 4
+
+type FindAlbumsByOwnerPort interface {
+	FindAlbumsByOwner(ctx context.Context, owner ownermodel.Owner) ([]*catalog.Album, error)
+}
+
+type ListMediasPort interface {
+	ListMedias(ctx context.Context, albumId catalog.AlbumId) ([]*catalog.MediaMeta, error)
+}
+
+// FindLocationsPort is implemented by archive.ARepositoryAdapter
+type FindLocationsPort interface {
+	FindByIds(owner string, ids []string) (map[string]string, error)
+}
+
+// DownloadPort is implemented by archive.StoreAdapter ; originals are decrypted when the store is encrypted
+type DownloadPort interface {
+	Download(key string) (io.ReadCloser, error)
+}
+
+// RestoreObserver is notified after each media has been processed, successfully or not.
+type RestoreObserver interface {
+	OnMediaRestored(ctx context.Context, restored RestoredMedia)
+}
+
+type RestoreObserverFunc func(ctx context.Context, restored RestoredMedia)
+
+func (f RestoreObserverFunc) OnMediaRestored(ctx context.Context, restored RestoredMedia) {
+	f(ctx, restored)
+}
+
+// RestoreRequest selects the medias to restore and where.
+type RestoreRequest struct {
+	Owner       ownermodel.Owner
+	Destination string               // Destination is the local directory where album folders are created
+	Albums      []catalog.FolderName // Albums is optional, all albums of the owner are restored when empty
+	Since       time.Time            // Since is optional, medias taken before are not restored
+	Parallel    int                  // Parallel is the number of concurrent downloads, DefaultParallel is used when 0
+}
+
+type RestoreStatus string
+
+const (
+	RestoreStatusRestored RestoreStatus = "RESTORED" // RestoreStatusRestored is used when the original has been downloaded and verified
+	RestoreStatusSkipped  RestoreStatus = "SKIPPED"  // RestoreStatusSkipped is used when the original was already present in the destination
+	RestoreStatusFailed   RestoreStatus = "FAILED"   // RestoreStatusFailed is used when the original couldn't be downloaded, or didn't match its signature
+)
+
+// RestoredMedia is the result of the restoration of one media.
+type RestoredMedia struct {
+	AlbumId catalog.AlbumId
+	MediaId catalog.MediaId
+	Path    string // Path is the local file
+	Size    int
+	Status  RestoreStatus
+	Error   error // Error is set when Status is RestoreStatusFailed
+}
+
+// RestoreReport counts the medias per status.
+type RestoreReport struct {
+	Restored int
+	Skipped  int
+	Failed   []RestoredMedia
+}
+
+// Restorer downloads originals from the archive into a local tree: <destination>/<album folder>/<original filename>
+type Restorer struct {
+	FindAlbumsByOwnerPort FindAlbumsByOwnerPort
+	ListMediasPort        ListMediasPort
+	FindLocationsPort     FindLocationsPort
+	DownloadPort          DownloadPort
+	Observers             []RestoreObserver
+}
+
+type restoreTask struct {
+	albumId  catalog.AlbumId
+	media    *catalog.MediaMeta
+	key      string
+	filePath string
+}
+
+// Restore downloads the requested medias ; failures of individual medias are in the report and do not stop the restoration.
+func (r *Restorer) Restore(ctx context.Context, request RestoreRequest) (*RestoreReport, error) {
+	if request.Destination == "" {
+		return nil, errors.Errorf("destination is mandatory")
+	}
+
+	albums, err := r.selectAlbums(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	var tasks []restoreTask
+	for _, album := range albums {
+		albumTasks, err := r.planAlbum(ctx, request, album)
+		if err != nil {
+			return nil, err
+		}
+
+		tasks = append(tasks, albumTasks...)
+	}
+
+	return r.execute(ctx, request, tasks), nil
+}
+
+func (r *Restorer) selectAlbums(ctx context.Context, request RestoreRequest) ([]*catalog.Album, error) {
+	albums, err := r.FindAlbumsByOwnerPort.FindAlbumsByOwner(ctx, request.Owner)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list albums of %s", request.Owner)
+	}
+
+	var selected []*catalog.Album
+	requested := make(map[catalog.FolderName]bool)
+	for _, folderName := range request.Albums {
+		requested[folderName] = false
+	}
+
+	for _, album := range albums {
+		if _, isRequested := requested[album.FolderName]; len(request.Albums) > 0 {
+			if !isRequested {
+				continue
+			}
+			requested[album.FolderName] = true
+		}
+
+		if !request.Since.IsZero() && !album.End.IsZero() && album.End.Before(request.Since) {
+			continue
+		}
+		selected = append(selected, album)
+	}
+
+	for folderName, found := range requested {
+		if !found {
+			return nil, errors.Wrapf(catalog.AlbumNotFoundErr, "album %s doesn't exist for %s", folderName, request.Owner)
+		}
+	}
+
+	return selected, nil
+}
+
+// planAlbum lists the medias of the album and gives them a unique file name ; order is deterministic so the same media gets the same name on each run.
+func (r *Restorer) planAlbum(ctx context.Context, request RestoreRequest, album *catalog.Album) ([]restoreTask, error) {
+	medias, err := r.ListMediasPort.ListMedias(ctx, album.AlbumId)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list medias of %s", album.AlbumId)
+	}
+
+	var selected []*catalog.MediaMeta
+	var ids []string
+	for _, media := range medias {
+		if request.Since.IsZero() || !media.Details.DateTime.Before(request.Since) {
+			selected = append(selected, media)
+			ids = append(ids, media.Id.Value())
+		}
+	}
+	if len(selected) == 0 {
+		return nil, nil
+	}
+
+	sort.Slice(selected, func(i, j int) bool {
+		if selected[i].Details.DateTime.Equal(selected[j].Details.DateTime) {
+			return selected[i].Id < selected[j].Id
+		}
+		return selected[i].Details.DateTime.Before(selected[j].Details.DateTime)
+	})
+
+	locations, err := r.FindLocationsPort.FindByIds(album.Owner.Value(), ids)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to find locations of medias in %s", album.AlbumId)
+	}
+
+	directory := filepath.Join(request.Destination, filepath.FromSlash(strings.Trim(album.FolderName.String(), "/")))
+	usedNames := make(map[string]bool)
+
+	tasks := make([]restoreTask, 0, len(selected))
+	for _, media := range selected {
+		name := uniqueFileName(usedNames, fileName(media))
+		tasks = append(tasks, restoreTask{
+			albumId:  album.AlbumId,
+			media:    media,
+			key:      locations[media.Id.Value()],
+			filePath: filepath.Join(directory, name),
+		})
+	}
+
+	return tasks, nil
+}
+
+func (r *Restorer) execute(ctx context.Context, request RestoreRequest, tasks []restoreTask) *RestoreReport {
+	parallel := request.Parallel
+	if parallel <= 0 {
+		parallel = DefaultParallel
+	}
+
+	queue := make(chan restoreTask)
+	results := make(chan RestoredMedia)
+
+	workers := sync.WaitGroup{}
+	for i := 0; i < parallel; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for task := range queue {
+				results <- r.restoreMedia(task)
+			}
+		}()
+	}
+
+	go func() {
+		defer close(queue)
+		for _, task := range tasks {
+			select {
+			case queue <- task:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		workers.Wait()
+		close(results)
+	}()
+
+	report := new(RestoreReport)
+	for result := range results {
+		switch result.Status {
+		case RestoreStatusRestored:
+			report.Restored++
+		case RestoreStatusSkipped:
+			report.Skipped++
+		default:
+			report.Failed = append(report.Failed, result)
+		}
+
+		for _, observer := range r.Observers {
+			observer.OnMediaRestored(ctx, result)
+		}
+	}
+
+	log.WithField("Owner", request.Owner).Infof("Restoration completed in %s: %d restored, %d skipped, %d failed", request.Destination, report.Restored, report.Skipped, len(report.Failed))
+	return report
+}
+
+func (r *Restorer) restoreMedia(task restoreTask) RestoredMedia {
+	result := RestoredMedia{
+		AlbumId: task.albumId,
+		MediaId: task.media.Id,
+		Path:    task.filePath,
+		Size:    task.media.Signature.SignatureSize,
+		Status:  RestoreStatusRestored,
+	}
+
+	skip, err := isAlreadyRestored(task.filePath, task.media.Signature)
+	if err != nil {
+		result.Status, result.Error = RestoreStatusFailed, err
+		return result
+	}
+	if skip {
+		result.Status = RestoreStatusSkipped
+		return result
+	}
+
+	if task.key == "" {
+		result.Status, result.Error = RestoreStatusFailed, errors.Errorf("media %s has no location in the archive", task.media.Id)
+		return result
+	}
+
+	err = r.download(task)
+	if err != nil {
+		result.Status, result.Error = RestoreStatusFailed, err
+	}
+	return result
+}
+
+// download writes the original in a temporary file, and moves it to its final name once its hash has been verified.
+func (r *Restorer) download(task restoreTask) error {
+	err := os.MkdirAll(filepath.Dir(task.filePath), 0755)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create directory for %s", task.filePath)
+	}
+
+	reader, err := r.DownloadPort.Download(task.key)
+	if err != nil {
+		return errors.Wrapf(err, "failed to download %s", task.key)
+	}
+	defer reader.Close()
+
+	temp, err := os.CreateTemp(filepath.Dir(task.filePath), ".restore-*")
+	if err != nil {
+		return errors.Wrapf(err, "failed to create temporary file for %s", task.filePath)
+	}
+	defer os.Remove(temp.Name())
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(temp, hash), reader)
+	closeErr := temp.Close()
+	if err != nil {
+		return errors.Wrapf(err, "failed to download %s", task.key)
+	}
+	if closeErr != nil {
+		return errors.Wrapf(closeErr, "failed to write %s", temp.Name())
+	}
+
+	signature := catalog.MediaSignature{SignatureSha256: hex.EncodeToString(hash.Sum(nil)), SignatureSize: int(size)}
+	if signature != task.media.Signature {
+		return errors.Errorf("downloaded content of %s doesn't match its signature: expected %s, got %s", task.key, task.media.Signature, signature)
+	}
+
+	err = os.Rename(temp.Name(), task.filePath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to move restored file to %s", task.filePath)
+	}
+
+	if dateTime := task.media.Details.DateTime; !dateTime.IsZero() {
+		err = os.Chtimes(task.filePath, dateTime, dateTime)
+	}
+	return errors.Wrapf(err, "failed to set modification time of %s", task.filePath)
+}
+
+// isAlreadyRestored returns true if the file exists with the expected signature ; a file with a different content is an error: it is never overridden.
+func isAlreadyRestored(filePath string, expected catalog.MediaSignature) (bool, error) {
+	stat, err := os.Stat(filePath)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to read %s", filePath)
+	}
+
+	if int(stat.Size()) == expected.SignatureSize {
+		file, err := os.Open(filePath)
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to read %s", filePath)
+		}
+		defer file.Close()
+
+		hash := sha256.New()
+		if _, err = io.Copy(hash, file); err != nil {
+			return false, errors.Wrapf(err, "failed to read %s", filePath)
+		}
+
+		if hex.EncodeToString(hash.Sum(nil)) == expected.SignatureSha256 {
+			return true, nil
+		}
+	}
+
+	return false, errors.Errorf("%s already exists with a different content", filePath)
+}
+
+func fileName(media *catalog.MediaMeta) string {
+	name := path.Base(filepath.ToSlash(media.Filename))
+	if name == "." || name == "/" || name == "" {
+		name = media.Id.Value()
+	}
+	return name
+}
+
+func uniqueFileName(used map[string]bool, name string) string {
+	candidate := name
+	ext := filepath.Ext(name)
+	for index := 1; used[strings.ToLower(candidate)]; index++ {
+		candidate = fmt.Sprintf("%s_%02d%s", strings.TrimSuffix(name, ext), index, ext)
+	}
+
+	used[strings.ToLower(candidate)] = true
+	return candidate
+}
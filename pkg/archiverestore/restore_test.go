@@ -0,0 +1,241 @@
+package archiverestore_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/stretchr/testify/assert"
+	"github.com/thomasduchatelle/dphoto/pkg/archive"
+	"github.com/thomasduchatelle/dphoto/pkg/archiverestore"
+	"github.com/thomasduchatelle/dphoto/pkg/catalog"
+	"github.com/thomasduchatelle/dphoto/pkg/ownermodel"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+const owner = ownermodel.Owner("ironman")
+
+var (
+	summer     = catalog.AlbumId{Owner: owner, FolderName: "/2022-summer"}
+	christmas  = catalog.AlbumId{Owner: owner, FolderName: "/2022-christmas"}
+	summerDate = time.Date(2022, 7, 14, 10, 0, 0, 0, time.UTC)
+	xmasDate   = time.Date(2022, 12, 25, 10, 0, 0, 0, time.UTC)
+)
+
+func TestRestorer_Restore(t *testing.T) {
+	tests := []struct {
+		name         string
+		request      archiverestore.RestoreRequest
+		existing     map[string]string // existing is a map path -> content created before the restore
+		corrupted    bool
+		wantFiles    map[string]string
+		wantRestored int
+		wantSkipped  int
+		wantFailed   []string
+	}{
+		{
+			name:    "it should restore all albums with original filenames and solve name conflicts",
+			request: archiverestore.RestoreRequest{Owner: owner},
+			wantFiles: map[string]string{
+				"2022-summer/IMG_001.jpg":    "beach",
+				"2022-summer/IMG_001_01.jpg": "sunset",
+				"2022-christmas/IMG_100.jpg": "tree",
+			},
+			wantRestored: 3,
+		},
+		{
+			name:    "it should only restore the requested album",
+			request: archiverestore.RestoreRequest{Owner: owner, Albums: []catalog.FolderName{christmas.FolderName}},
+			wantFiles: map[string]string{
+				"2022-christmas/IMG_100.jpg": "tree",
+			},
+			wantRestored: 1,
+		},
+		{
+			name:    "it should only restore the medias taken after since date",
+			request: archiverestore.RestoreRequest{Owner: owner, Since: time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)},
+			wantFiles: map[string]string{
+				"2022-christmas/IMG_100.jpg": "tree",
+			},
+			wantRestored: 1,
+		},
+		{
+			name:     "it should skip the files already present, and never override a different file",
+			request:  archiverestore.RestoreRequest{Owner: owner},
+			existing: map[string]string{"2022-summer/IMG_001.jpg": "beach", "2022-christmas/IMG_100.jpg": "not a tree"},
+			wantFiles: map[string]string{
+				"2022-summer/IMG_001.jpg":    "beach",
+				"2022-summer/IMG_001_01.jpg": "sunset",
+				"2022-christmas/IMG_100.jpg": "not a tree",
+			},
+			wantRestored: 1,
+			wantSkipped:  1,
+			wantFailed:   []string{"2022-christmas/IMG_100.jpg"},
+		},
+		{
+			name:      "it should not keep files which doesn't match their signature",
+			request:   archiverestore.RestoreRequest{Owner: owner, Albums: []catalog.FolderName{christmas.FolderName}},
+			corrupted: true,
+			wantFiles: map[string]string{},
+			wantFailed: []string{
+				"2022-christmas/IMG_100.jpg",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			destination := t.TempDir()
+			for name, content := range tt.existing {
+				writeFile(t, filepath.Join(destination, name), content)
+			}
+
+			store := map[string]string{
+				"ironman/2022-summer/beach.jpg":   "beach",
+				"ironman/2022-summer/sunset.jpg":  "sunset",
+				"ironman/2022-christmas/xmas.jpg": "tree",
+			}
+			if tt.corrupted {
+				store["ironman/2022-christmas/xmas.jpg"] = "corrupted tree"
+			}
+
+			restorer := &archiverestore.Restorer{
+				FindAlbumsByOwnerPort: albumsFake{{AlbumId: summer, End: summerDate.Add(24 * time.Hour)}, {AlbumId: christmas, End: xmasDate.Add(24 * time.Hour)}},
+				ListMediasPort: mediasFake{
+					summer: {
+						newMedia("id-sunset", "IMG_001.jpg", "sunset", summerDate.Add(time.Hour)),
+						newMedia("id-beach", "IMG_001.jpg", "beach", summerDate),
+					},
+					christmas: {newMedia("id-tree", "IMG_100.jpg", "tree", xmasDate)},
+				},
+				FindLocationsPort: locationsFake{
+					"id-beach":  "ironman/2022-summer/beach.jpg",
+					"id-sunset": "ironman/2022-summer/sunset.jpg",
+					"id-tree":   "ironman/2022-christmas/xmas.jpg",
+				},
+				DownloadPort: downloadFake(store),
+			}
+
+			tt.request.Destination = destination
+			report, err := restorer.Restore(context.Background(), tt.request)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Equal(t, tt.wantRestored, report.Restored)
+			assert.Equal(t, tt.wantSkipped, report.Skipped)
+			var failed []string
+			for _, failure := range report.Failed {
+				rel, _ := filepath.Rel(destination, failure.Path)
+				failed = append(failed, filepath.ToSlash(rel))
+			}
+			sort.Strings(failed)
+			assert.Equal(t, tt.wantFailed, failed)
+
+			assert.Equal(t, tt.wantFiles, readFiles(t, destination))
+		})
+	}
+}
+
+func TestRestorer_Restore_mtime(t *testing.T) {
+	destination := t.TempDir()
+	restorer := &archiverestore.Restorer{
+		FindAlbumsByOwnerPort: albumsFake{{AlbumId: christmas}},
+		ListMediasPort:        mediasFake{christmas: {newMedia("id-tree", "IMG_100.jpg", "tree", xmasDate)}},
+		FindLocationsPort:     locationsFake{"id-tree": "ironman/2022-christmas/xmas.jpg"},
+		DownloadPort:          downloadFake{"ironman/2022-christmas/xmas.jpg": "tree"},
+	}
+
+	_, err := restorer.Restore(context.Background(), archiverestore.RestoreRequest{Owner: owner, Destination: destination})
+	if assert.NoError(t, err) {
+		stat, err := os.Stat(filepath.Join(destination, "2022-christmas", "IMG_100.jpg"))
+		if assert.NoError(t, err) {
+			assert.True(t, stat.ModTime().Equal(xmasDate), "it should restore the modification time to the capture date, got %s", stat.ModTime())
+		}
+	}
+}
+
+func TestRestorer_Restore_unknownAlbum(t *testing.T) {
+	restorer := &archiverestore.Restorer{
+		FindAlbumsByOwnerPort: albumsFake{{AlbumId: christmas}},
+	}
+
+	_, err := restorer.Restore(context.Background(), archiverestore.RestoreRequest{Owner: owner, Destination: t.TempDir(), Albums: []catalog.FolderName{"/not-found"}})
+	assert.ErrorIs(t, err, catalog.AlbumNotFoundErr)
+}
+
+func newMedia(id, filename, content string, dateTime time.Time) *catalog.MediaMeta {
+	hash := sha256.Sum256([]byte(content))
+	return &catalog.MediaMeta{
+		Id:        catalog.MediaId(id),
+		Signature: catalog.MediaSignature{SignatureSha256: hex.EncodeToString(hash[:]), SignatureSize: len(content)},
+		Filename:  filename,
+		Type:      catalog.MediaType("IMAGE"),
+		Details:   catalog.MediaDetails{DateTime: dateTime},
+	}
+}
+
+func writeFile(t *testing.T, filePath, content string) {
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFiles(t *testing.T, root string) map[string]string {
+	files := make(map[string]string)
+	err := filepath.Walk(root, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		content, err := os.ReadFile(filePath)
+		rel, _ := filepath.Rel(root, filePath)
+		files[filepath.ToSlash(rel)] = string(content)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+type albumsFake []*catalog.Album
+
+func (a albumsFake) FindAlbumsByOwner(ctx context.Context, owner ownermodel.Owner) ([]*catalog.Album, error) {
+	return a, nil
+}
+
+type mediasFake map[catalog.AlbumId][]*catalog.MediaMeta
+
+func (m mediasFake) ListMedias(ctx context.Context, albumId catalog.AlbumId) ([]*catalog.MediaMeta, error) {
+	return m[albumId], nil
+}
+
+type locationsFake map[string]string
+
+func (l locationsFake) FindByIds(owner string, ids []string) (map[string]string, error) {
+	locations := make(map[string]string)
+	for _, id := range ids {
+		if key, found := l[id]; found {
+			locations[id] = key
+		}
+	}
+	return locations, nil
+}
+
+type downloadFake map[string]string
+
+func (d downloadFake) Download(key string) (io.ReadCloser, error) {
+	if content, found := d[key]; found {
+		return io.NopCloser(bytes.NewReader([]byte(content))), nil
+	}
+	return nil, archive.NotFoundError
+}
@@ -37,6 +37,29 @@ type DetailsReader interface {
 	ReadDetails(reader io.Reader, options DetailsReaderOptions) (*MediaDetails, error)
 }
 
+// TimeZoneFinder deduces the time zone in which a media has been captured from its GPS coordinates.
+type TimeZoneFinder interface {
+	FindTimeZone(latitude, longitude float64) (*time.Location, error)
+}
+
+// PlaceFinder names the city the nearest to GPS coordinates.
+type PlaceFinder interface {
+	// FindCity returns an empty string when the location is not near any known city.
+	FindCity(latitude, longitude float64) (string, error)
+}
+
+// DetailsReaderWithFileDate is an optional extension of DetailsReader for formats not recording when the media has been captured (ex: MPEG program streams).
+type DetailsReaderWithFileDate interface {
+	// FallbackOnFileDate returns true when the last modification date of the file must be used when no date has been read from its content.
+	FallbackOnFileDate() bool
+}
+
+// DetailsReaderOfFoundMedia is an optional extension of DetailsReader for readers needing more than the content of the file (ex: its sidecar).
+type DetailsReaderOfFoundMedia interface {
+	// ReadDetailsOfFoundMedia is used instead of ReadDetails: the reader is the content of the found media.
+	ReadDetailsOfFoundMedia(found FoundMedia, reader io.Reader, options DetailsReaderOptions) (*MediaDetails, error)
+}
+
 type DetailsReaderOptions struct {
 	Fast bool // Fast true indicate the parser should focus at extracting the date, nothing else TODO can be retired
 }
@@ -5,6 +5,7 @@ import (
 	"context"
 	"github.com/pkg/errors"
 	"github.com/thomasduchatelle/dphoto/pkg/backup/chain"
+	"github.com/thomasduchatelle/dphoto/pkg/bandwidth"
 	"github.com/thomasduchatelle/dphoto/pkg/ownermodel"
 	"slices"
 )
@@ -14,6 +15,7 @@ type BatchBackup struct {
 	DetailsReaders    []DetailsReader
 	InsertMediaPort   InsertMediaPort
 	ArchivePort       ArchiveMediaPort
+	TimeZoneFinder    TimeZoneFinder // TimeZoneFinder is optional, the default time zone is used for all medias without it
 }
 
 // Backup is analysing each media and is backing it up if not already in the catalog.
@@ -41,20 +43,34 @@ func (b *BatchBackup) prepareVolumeBackup(ctx context.Context, options Options,
 	config := &backupConfiguration{
 		scanConfiguration: scanConfiguration{
 			Analyser:                 options.GetAnalyserDecorator().Decorate(newDefaultAnalyser(b.DetailsReaders...), tracker),
+			TimeZones:                newTimeZoneResolver(b.TimeZoneFinder, options),
 			Cataloguer:               cataloguer,
 			ScanCompleteObserver:     tracker,
 			PostAnalyserRejects:      []RejectedMediaObserver{scanLogger, tracker, report},
 			PostCatalogFiltersIn:     []CatalogReferencerObserver{scanLogger, tracker},
 			PostCataloguerFiltersOut: []CataloguerFilterObserver{scanLogger, tracker, report},
 			Wrappers:                 []chain.CloserFunc{tracker.NoMoreEvents},
+			RetryPolicy:              options.GetRetryPolicy(),
 		},
 		Uploader: &uploader{
 			Owner:             owner,
 			InsertMediaPort:   b.InsertMediaPort,
 			ArchivePort:       b.ArchivePort,
 			UploaderObservers: []uploaderObserver{tracker, report},
+			RetryPolicy:       options.GetRetryPolicy(),
 		},
 	}
+	config.withBandwidth(options.Bandwidth, tracker)
+	config.withDetailedReport(options.DetailedReport)
+	if options.QuarantineFile != "" {
+		err = config.withQuarantine(options.QuarantineFile, scanLogger, tracker, report)
+		if err != nil {
+			return nil, nil, err
+		}
+		if options.DetailedReport != nil {
+			config.QuarantineObservers = append(config.QuarantineObservers, options.DetailedReport)
+		}
+	}
 	if !options.SkipRejects && options.RejectDir == "" {
 		config.PostAnalyserRejects = append(config.PostAnalyserRejects, new(analyserFailsFastObserver))
 	}
@@ -81,20 +97,48 @@ type backupConfiguration struct {
 	Uploader *uploader
 }
 
+// withBandwidth limits the upload rate when parameters are set ; the observer is notified of the rate applied.
+func (c *backupConfiguration) withBandwidth(parameters *BandwidthParameters, observer bandwidthObserver) {
+	if parameters == nil {
+		return
+	}
+
+	c.Uploader.GlobalLimiter = bandwidth.NewLimiter(parameters.Global)
+	c.Uploader.RoutineBandwidth = parameters.PerRoutine
+	c.Uploader.BandwidthObservers = append(c.Uploader.BandwidthObservers, observer)
+}
+
+// withQuarantine lists in the file the medias still failing after the retries, and notifies the observers, instead of stopping the backup.
+func (c *backupConfiguration) withQuarantine(file string, observers ...quarantineObserver) error {
+	quarantineFile, err := newQuarantineFile(file)
+	if err != nil {
+		return err
+	}
+
+	c.QuarantineObservers = append(c.QuarantineObservers, observers...)
+	c.QuarantineObservers = append(c.QuarantineObservers, quarantineFile)
+	c.Uploader.QuarantineObservers = c.QuarantineObservers
+	return nil
+}
+
 func multithreadedBackupRuntime(ctxNonCancelable context.Context, options Options, config *backupConfiguration) (analyserLauncher, error) {
 	ctx, cancelFunc := context.WithCancel(ctxNonCancelable)
 
 	launcher := scanAndBackupCommonLauncher(&config.scanConfiguration, options,
 		&chain.ReBufferLink[BackingUpMediaRequest]{
 			BufferLink: chain.BufferLink[BackingUpMediaRequest]{
-				BufferCapacity: options.BatchSize,
-				ChannelSize:    options.ChannelSize,
+				Name:            linkBatchCatalogued,
+				Instrumentation: options.Instrumentation,
+				BufferCapacity:  options.BatchSize,
+				ChannelSize:     options.ChannelSize,
 				Next: &chain.MultithreadedLink[[]BackingUpMediaRequest, []BackingUpMediaRequest]{
+					Name:             string(StageUpload),
+					Instrumentation:  options.Instrumentation,
 					NumberOfRoutines: options.ConcurrencyParameters.NumberOfConcurrentUploaderRoutines(),
 					ConsumerBuilder: func(consumer chain.Consumer[[]BackingUpMediaRequest]) chain.Consumer[[]BackingUpMediaRequest] {
 						observers := CatalogReferencerObservers(slices.Concat(
 							config.PostCatalogFiltersIn,
-							CatalogReferencerObservers{config.Uploader, CatalogReferencerObserverFunc(consumer.Consume)},
+							CatalogReferencerObservers{config.Uploader.newRoutine(), CatalogReferencerObserverFunc(consumer.Consume)},
 						))
 
 						return chain.ConsumerFunc[[]BackingUpMediaRequest](func(ctx context.Context, consumed []BackingUpMediaRequest) error {
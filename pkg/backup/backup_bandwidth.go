@@ -0,0 +1,52 @@
+package backup
+
+import (
+	"context"
+	"github.com/thomasduchatelle/dphoto/pkg/bandwidth"
+	"io"
+)
+
+// BandwidthParameters limits the rate at which the uploader stage reads the medias it archives.
+type BandwidthParameters struct {
+	Global     bandwidth.Schedule // Global is shared by all the uploader routines
+	PerRoutine bandwidth.Schedule // PerRoutine applies to each uploader routine independently
+}
+
+// BandwidthLimit is the upload rate currently applied, in bytes per second ; 0 is unlimited.
+type BandwidthLimit struct {
+	Global     int64
+	PerRoutine int64
+}
+
+// TrackBandwidth is notified when the upload rate limit changes, like when a time window starts.
+type TrackBandwidth interface {
+	OnBandwidthLimit(limit BandwidthLimit)
+}
+
+type bandwidthObserver interface {
+	OnUploadBandwidth(ctx context.Context, limit BandwidthLimit) error
+}
+
+// throttledMedia reads the content of the media no faster than the limiters allow.
+type throttledMedia struct {
+	FoundMedia
+	ctx      context.Context
+	limiters []*bandwidth.Limiter
+}
+
+func (m *throttledMedia) ReadMedia() (io.ReadCloser, error) {
+	reader, err := m.FoundMedia.ReadMedia()
+	if err != nil {
+		return nil, err
+	}
+
+	return &throttledReadCloser{
+		Reader: bandwidth.NewReader(m.ctx, reader, m.limiters...),
+		Closer: reader,
+	}, nil
+}
+
+type throttledReadCloser struct {
+	io.Reader
+	io.Closer
+}
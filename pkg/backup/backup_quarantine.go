@@ -0,0 +1,64 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"os"
+	"path"
+	"strings"
+	"sync"
+)
+
+// quarantineObserver is notified of the medias that still fail after the retries ; they are not backed up but the rest of the volume is.
+type quarantineObserver interface {
+	OnQuarantinedMedia(ctx context.Context, stage ReportStage, media AnalysedMedia, cause error) error
+}
+
+type quarantineObservers []quarantineObserver
+
+// quarantine notifies the observers of each media, or returns the cause if the quarantine is not enabled.
+func (q quarantineObservers) quarantine(ctx context.Context, stage ReportStage, medias []*AnalysedMedia, cause error) error {
+	if len(q) == 0 {
+		return cause
+	}
+
+	for _, media := range medias {
+		for _, observer := range q {
+			err := observer.OnQuarantinedMedia(ctx, stage, *media, cause)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func newQuarantineFile(filePath string) (*quarantineFile, error) {
+	err := os.MkdirAll(path.Dir(filePath), 0755)
+	return &quarantineFile{
+		FilePath: filePath,
+	}, errors.Wrapf(err, "failed to create the directory of the quarantine file %s", filePath)
+}
+
+// quarantineFile appends one line per failing media: its path, the stage that failed, and the cause ; separated by tabs.
+type quarantineFile struct {
+	lock     sync.Mutex
+	FilePath string
+}
+
+func (q *quarantineFile) OnQuarantinedMedia(ctx context.Context, stage ReportStage, media AnalysedMedia, cause error) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	file, err := os.OpenFile(q.FilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open quarantine file %s", q.FilePath)
+	}
+	defer file.Close()
+
+	cleanCause := strings.Join(strings.Fields(cause.Error()), " ")
+	_, err = fmt.Fprintf(file, "%s\t%s\t%s\n", media.FoundMedia.MediaPath().Absolute(), stage, cleanCause)
+	return errors.Wrapf(err, "failed to write in quarantine file %s", q.FilePath)
+}
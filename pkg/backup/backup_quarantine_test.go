@@ -0,0 +1,262 @@
+package backup
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/thomasduchatelle/dphoto/pkg/backup/chain"
+	"github.com/thomasduchatelle/dphoto/pkg/ownermodel"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestUploader_retryAndQuarantine(t *testing.T) {
+	throttled := errors.New("TEST throttled")
+	fastRetries := chain.RetryPolicy{MaxAttempts: 3, InitialDelay: time.Nanosecond, IsTransient: func(err error) bool {
+		return errors.Is(err, throttled)
+	}}
+
+	tests := []struct {
+		name            string
+		archiveErrors   map[string][]error
+		indexErrors     []error
+		quarantine      bool
+		wantErr         string
+		wantIndexed     []string
+		wantQuarantined []string
+	}{
+		{
+			name:          "it should retry transient errors from the archive",
+			archiveErrors: map[string][]error{"file_1.jpg": {throttled, throttled}},
+			wantIndexed:   []string{"file_1.jpg", "file_2.jpg"},
+		},
+		{
+			name:          "it should return the first failure when the quarantine is disabled",
+			archiveErrors: map[string][]error{"file_1.jpg": {errors.New("TEST corrupted")}},
+			wantErr:       "archiving media RAM/folder1/file_1.jpg [10 bytes] failed: TEST corrupted",
+		},
+		{
+			name:            "it should quarantine a media still failing and catalog the others",
+			archiveErrors:   map[string][]error{"file_1.jpg": {throttled, throttled, throttled}},
+			quarantine:      true,
+			wantIndexed:     []string{"file_2.jpg"},
+			wantQuarantined: []string{"/ram/folder1/file_1.jpg\tupload\tarchiving media RAM/folder1/file_1.jpg [10 bytes] failed: still failing after 3 attempts: TEST throttled"},
+		},
+		{
+			name:        "it should retry transient errors from the catalog",
+			indexErrors: []error{throttled},
+			wantIndexed: []string{"file_1.jpg", "file_2.jpg"},
+		},
+		{
+			name:        "it should quarantine all the medias of the batch when they can't be catalogued",
+			indexErrors: []error{errors.New("TEST validation error")},
+			quarantine:  true,
+			wantQuarantined: []string{
+				"/ram/folder1/file_1.jpg\tupload\tfailed to catalog medias: TEST validation error",
+				"/ram/folder1/file_2.jpg\tupload\tfailed to catalog medias: TEST validation error",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			insertMedia := &FailingInsertMediaPortFake{InsertMediaPortFake: newInsertMediaPortFake(), Errors: tt.indexErrors}
+			report := newBackupReportBuilder()
+			uploader := &uploader{
+				Owner:             "ironman",
+				InsertMediaPort:   insertMedia,
+				ArchivePort:       &FailingArchiveFake{Errors: tt.archiveErrors},
+				UploaderObservers: []uploaderObserver{report},
+				RetryPolicy:       fastRetries,
+			}
+
+			quarantineFilePath := path.Join(t.TempDir(), "quarantine", "failures.tsv")
+			if tt.quarantine {
+				file, err := newQuarantineFile(quarantineFilePath)
+				if !assert.NoError(t, err) {
+					return
+				}
+				uploader.QuarantineObservers = quarantineObservers{report, file}
+			}
+
+			err := uploader.newRoutine().OnMediaCatalogued(context.Background(), []BackingUpMediaRequest{
+				newBackingUpMediaRequest("file_1.jpg"),
+				newBackingUpMediaRequest("file_2.jpg"),
+			})
+
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			var indexed []string
+			for _, entry := range insertMedia.Got {
+				indexed = append(indexed, entry.ArchiveFilename)
+			}
+			assert.Equal(t, tt.wantIndexed, indexed)
+			if album, found := report.CountPerAlbum()["/album1"]; len(tt.wantIndexed) > 0 && assert.True(t, found) {
+				assert.Equal(t, len(tt.wantIndexed), album.Total().Count, "it should only report the medias catalogued")
+			}
+			assert.Equal(t, len(tt.wantQuarantined), report.Quarantined().Count)
+
+			if len(tt.wantQuarantined) > 0 {
+				content, err := os.ReadFile(quarantineFilePath)
+				if assert.NoError(t, err) {
+					assert.Equal(t, linesOf(tt.wantQuarantined), string(content))
+				}
+			}
+		})
+	}
+}
+
+func TestCataloguerAggregate_retryAndQuarantine(t *testing.T) {
+	medias := []*AnalysedMedia{
+		{FoundMedia: NewInMemoryMedia("folder1/file_1.jpg", time.Now(), []byte("1")), Sha256Hash: "sha-1", Details: &MediaDetails{}},
+		{FoundMedia: NewInMemoryMedia("folder1/file_2.jpg", time.Now(), []byte("2")), Sha256Hash: "sha-2", Details: &MediaDetails{}},
+	}
+
+	cataloguer := &FailingCataloguerFake{Errors: []error{errors.New("TEST 503"), errors.New("TEST 503")}}
+	downstream := new(CataloguerObserverFake)
+	tracker := new(QuarantineObserverFake)
+	aggregate := &cataloguerAggregate{
+		cataloguer: cataloguer,
+		observerWithFilters: applyFiltersOnCataloguer{
+			CatalogReferencerObservers: []CatalogReferencerObserver{downstream},
+		},
+		retryPolicy: chain.RetryPolicy{MaxAttempts: 2, InitialDelay: time.Nanosecond, IsTransient: func(err error) bool {
+			return true
+		}},
+		quarantine: quarantineObservers{tracker},
+	}
+
+	err := aggregate.OnBatchOfAnalysedMedia(context.Background(), medias)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 2, cataloguer.Attempts)
+		assert.Equal(t, []string{"catalogue /ram/folder1/file_1.jpg", "catalogue /ram/folder1/file_2.jpg"}, tracker.Quarantined)
+		assert.Empty(t, downstream.Got)
+	}
+
+	cataloguer = &FailingCataloguerFake{Errors: []error{errors.New("TEST 503")}}
+	aggregate.cataloguer = cataloguer
+	tracker.Quarantined = nil
+	err = aggregate.OnBatchOfAnalysedMedia(context.Background(), medias)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 2, cataloguer.Attempts)
+		assert.Empty(t, tracker.Quarantined)
+		assert.Len(t, downstream.Got, 2, "it should pass the medias to the next stage once the retry succeeded")
+	}
+}
+
+func newBackingUpMediaRequest(filename string) BackingUpMediaRequest {
+	return BackingUpMediaRequest{
+		AnalysedMedia: &AnalysedMedia{
+			FoundMedia: NewInMemoryMedia("folder1/"+filename, time.Now(), []byte(filename)),
+			Type:       MediaTypeImage,
+			Sha256Hash: "sha-" + filename,
+			Details:    &MediaDetails{},
+		},
+		CatalogReference: &CatalogReferenceStub{MediaIdValue: "id-" + filename, AlbumFolderNameValue: "/album1"},
+	}
+}
+
+func linesOf(lines []string) string {
+	content := ""
+	for _, line := range lines {
+		content += line + "\n"
+	}
+	return content
+}
+
+// FailingArchiveFake returns the errors, in order, for each filename before succeeding.
+type FailingArchiveFake struct {
+	Errors map[string][]error
+}
+
+func (a *FailingArchiveFake) ArchiveMedia(owner string, media *BackingUpMediaRequest) (string, error) {
+	filename := media.AnalysedMedia.FoundMedia.MediaPath().Filename
+	if errs := a.Errors[filename]; len(errs) > 0 {
+		a.Errors[filename] = errs[1:]
+		return "", errs[0]
+	}
+
+	return filename, nil
+}
+
+// FailingInsertMediaPortFake returns the errors, in order, before indexing the medias.
+type FailingInsertMediaPortFake struct {
+	*InsertMediaPortFake
+	Errors []error
+}
+
+func (i *FailingInsertMediaPortFake) IndexMedias(ctx context.Context, owner ownermodel.Owner, requests []*CatalogMediaRequest) error {
+	if len(i.Errors) > 0 {
+		err := i.Errors[0]
+		i.Errors = i.Errors[1:]
+		return err
+	}
+
+	return i.InsertMediaPortFake.IndexMedias(ctx, owner, requests)
+}
+
+type FailingCataloguerFake struct {
+	Errors   []error
+	Attempts int
+}
+
+func (c *FailingCataloguerFake) Reference(ctx context.Context, medias []*AnalysedMedia, observer CatalogReferencerObserver) error {
+	c.Attempts++
+	if c.Attempts <= len(c.Errors) {
+		return c.Errors[c.Attempts-1]
+	}
+
+	var requests []BackingUpMediaRequest
+	for _, media := range medias {
+		requests = append(requests, BackingUpMediaRequest{AnalysedMedia: media, CatalogReference: &CatalogReferenceStub{MediaIdValue: media.Sha256Hash}})
+	}
+	return observer.OnMediaCatalogued(ctx, requests)
+}
+
+type QuarantineObserverFake struct {
+	Quarantined []string
+}
+
+func (q *QuarantineObserverFake) OnQuarantinedMedia(ctx context.Context, stage ReportStage, media AnalysedMedia, cause error) error {
+	q.Quarantined = append(q.Quarantined, string(stage)+" "+media.FoundMedia.MediaPath().Absolute())
+	return nil
+}
+
+func TestTracker_quarantine(t *testing.T) {
+	listener := new(TrackUploadedFake)
+	observer, _ := newTrackerV2(OptionsWithListener(listener))
+	ctx := context.Background()
+
+	media := func(filename string) AnalysedMedia {
+		return AnalysedMedia{FoundMedia: NewInMemoryMedia(filename, time.Now(), []byte("1234"))}
+	}
+	request := newBackingUpMediaRequest("file_1.jpg")
+
+	assert.NoError(t, observer.OnScanComplete(ctx, 3, 12))
+	assert.NoError(t, observer.OnQuarantinedMedia(ctx, StageCatalogue, media("file_2.jpg"), errors.New("TEST")))
+	assert.NoError(t, observer.OnMediaCatalogued(ctx, []BackingUpMediaRequest{request, newBackingUpMediaRequest("file_3.jpg")}))
+	assert.NoError(t, observer.OnQuarantinedMedia(ctx, StageUpload, *request.AnalysedMedia, errors.New("TEST")))
+	assert.NoError(t, observer.OnBackingUpMediaRequestUploaded(ctx, newBackingUpMediaRequest("file_3.jpg")))
+	observer.NoMoreEvents()
+
+	assert.Equal(t, [][2]MediaCounter{
+		{MediaCounterZero, NewMediaCounter(1, 10)},
+		{NewMediaCounter(1, 10), NewMediaCounter(1, 10)},
+	}, listener.Events, "it should exclude the quarantined medias from the total to upload")
+}
+
+type TrackUploadedFake struct {
+	Events [][2]MediaCounter
+}
+
+func (t *TrackUploadedFake) OnUploaded(done, total MediaCounter) {
+	t.Events = append(t.Events, [2]MediaCounter{done, total})
+}
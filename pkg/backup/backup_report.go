@@ -2,6 +2,7 @@ package backup
 
 import (
 	"context"
+	"github.com/pkg/errors"
 	"sync"
 )
 
@@ -12,6 +13,10 @@ var (
 type Report interface {
 	Skipped() MediaCounter
 	CountPerAlbum() map[string]*AlbumReport
+	// FilteredOut is the count of medias not backed up because of a user defined rule, indexed by reason. They are also counted in Skipped.
+	FilteredOut() map[string]MediaCounter
+	// Quarantined is the count of medias that still failed after the retries ; they are not counted in Skipped.
+	Quarantined() MediaCounter
 }
 
 type MediaCounter struct {
@@ -71,6 +76,8 @@ type backupReportBuilder struct {
 	lock          sync.Mutex
 	skipped       MediaCounter
 	countPerAlbum map[string]*AlbumReport
+	filteredOut   map[string]MediaCounter
+	quarantined   MediaCounter
 }
 
 func (r *backupReportBuilder) OnRejectedMedia(ctx context.Context, found FoundMedia, cause error) error {
@@ -88,6 +95,14 @@ func (r *backupReportBuilder) OnFilteredOut(ctx context.Context, media AnalysedM
 
 	r.skipped = r.skipped.Add(1, media.FoundMedia.Size())
 
+	var filteredOutErr FilteredOutByRuleError
+	if errors.As(cause, &filteredOutErr) {
+		if r.filteredOut == nil {
+			r.filteredOut = make(map[string]MediaCounter)
+		}
+		r.filteredOut[filteredOutErr.Reason] = r.filteredOut[filteredOutErr.Reason].Add(1, media.FoundMedia.Size())
+	}
+
 	return nil
 }
 
@@ -118,6 +133,14 @@ func (r *backupReportBuilder) OnBackingUpMediaRequestUploaded(ctx context.Contex
 	return nil
 }
 
+func (r *backupReportBuilder) OnQuarantinedMedia(ctx context.Context, stage ReportStage, media AnalysedMedia, cause error) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.quarantined = r.quarantined.Add(1, media.FoundMedia.Size())
+	return nil
+}
+
 func (r *backupReportBuilder) Skipped() MediaCounter {
 	return r.skipped
 }
@@ -126,6 +149,14 @@ func (r *backupReportBuilder) CountPerAlbum() map[string]*AlbumReport {
 	return r.countPerAlbum
 }
 
+func (r *backupReportBuilder) FilteredOut() map[string]MediaCounter {
+	return r.filteredOut
+}
+
+func (r *backupReportBuilder) Quarantined() MediaCounter {
+	return r.quarantined
+}
+
 type AlbumReport struct {
 	isNew bool
 	image MediaCounter
@@ -29,25 +29,25 @@ func TestBackupAcceptance(t *testing.T) {
 			FoundMedia: NewInMemoryMedia("folder1/file_1.jpg", time.Now(), []byte("2022-06-18")),
 			Type:       MediaTypeImage,
 			Sha256Hash: "3e7574e8b640104d97597b200fd516c589f34be540e0a81a272fd488d12acaec",
-			Details:    &MediaDetails{DateTime: time.Date(2022, 6, 18, 0, 0, 0, 0, time.UTC)},
+			Details:    &MediaDetails{DateTime: time.Date(2022, 6, 18, 0, 0, 0, 0, time.UTC), TimeReference: TimeReferenceZoned},
 		},
 		{
 			FoundMedia: NewInMemoryMedia("folder1/file_2.jpg", time.Now(), []byte("2022-06-19AB")),
 			Type:       MediaTypeImage,
 			Sha256Hash: "28f046d0ebae98f45512f98d581e7cdded28dd9cf50e7712615970dc15221cb3",
-			Details:    &MediaDetails{DateTime: time.Date(2022, 6, 19, 0, 0, 0, 0, time.UTC)},
+			Details:    &MediaDetails{DateTime: time.Date(2022, 6, 19, 0, 0, 0, 0, time.UTC), TimeReference: TimeReferenceZoned},
 		},
 		{
 			FoundMedia: NewInMemoryMedia("folder1/file_3.mp4", time.Now(), []byte("2022-06-19ABC")),
 			Type:       MediaTypeVideo,
 			Sha256Hash: "e06a1b537d665585efa76f51a778bbb75473c984aa3ea3fbbcb8837db467d176",
-			Details:    &MediaDetails{DateTime: time.Date(2022, 6, 19, 0, 0, 0, 0, time.UTC)},
+			Details:    &MediaDetails{DateTime: time.Date(2022, 6, 19, 0, 0, 0, 0, time.UTC), TimeReference: TimeReferenceZoned},
 		},
 		{
 			FoundMedia: NewInMemoryMedia("folder1/file_4.txt", time.Now(), []byte("2022-06-19ABCD")),
 			Type:       MediaTypeOther,
 			Sha256Hash: "ac94013eeb4c74b6f8c1ef2cea3bec85161732fe6161ba5d7e485efb28cb9e9e",
-			Details:    &MediaDetails{DateTime: time.Date(2022, 6, 19, 0, 0, 0, 0, time.UTC)},
+			Details:    &MediaDetails{DateTime: time.Date(2022, 6, 19, 0, 0, 0, 0, time.UTC), TimeReference: TimeReferenceZoned},
 		},
 	}
 	doesNotExistReference1 := &CatalogReferenceStub{MediaIdValue: "media-id-1", AlbumFolderNameValue: "/album1"}
@@ -316,6 +316,46 @@ func TestBackupAcceptance(t *testing.T) {
 			},
 			wantErr: assert.NoError,
 		},
+		{
+			name: "it should report the medias filtered out by rules with their reason",
+			fields: fields{
+				detailsReaders: new(DetailsReaderAdapterStub),
+				archive:        newArchiveMediaPortFake(),
+				cataloguerFactory: &ReferencerFactoryFake{
+					Cataloguer: &CatalogReferencerFake{
+						analysedMedias[0]: doesNotExistReference1,
+						analysedMedias[1]: doesNotExistReference2,
+					},
+				},
+				insertMedia: newInsertMediaPortFake(),
+			},
+			args: args{
+				owner: owner,
+				volume: &InMemorySourceVolume{
+					analysedMedias[0].FoundMedia,
+					analysedMedias[1].FoundMedia,
+				},
+				optionsSlice: []Options{
+					OptionsWithPostAnalyseFilters(PostAnalyseFilterFake{"file_2.jpg": "screenshot"}),
+				},
+			},
+			want: &backupReportBuilder{
+				skipped: NewMediaCounter(1, analysedMedias[1].FoundMedia.Size()),
+				countPerAlbum: map[string]*AlbumReport{
+					doesNotExistReference1.AlbumFolderNameValue: countOfMedias(analysedMedias[0]),
+				},
+				filteredOut: map[string]MediaCounter{
+					"screenshot": NewMediaCounter(1, analysedMedias[1].FoundMedia.Size()),
+				},
+			},
+			wantEvents: map[trackEvent]eventSummary{
+				trackScanComplete:      {SumCount: 2, SumSize: 22},
+				trackFilteredOutByRule: {SumCount: 1, SumSize: 12},
+				trackCatalogued:        {SumCount: 1, SumSize: 10},
+				trackUploaded:          {SumCount: 1, SumSize: 10, Albums: []string{"/album1"}},
+			},
+			wantErr: assert.NoError,
+		},
 		{
 			name: "it should use the Analyser decorator, and report accordingly",
 			fields: fields{
@@ -3,6 +3,8 @@ package backup
 import (
 	"context"
 	"github.com/pkg/errors"
+	"github.com/thomasduchatelle/dphoto/pkg/backup/chain"
+	"github.com/thomasduchatelle/dphoto/pkg/bandwidth"
 	"github.com/thomasduchatelle/dphoto/pkg/ownermodel"
 )
 
@@ -11,34 +13,119 @@ type uploaderObserver interface {
 }
 
 type uploader struct {
-	Owner             ownermodel.Owner
-	InsertMediaPort   InsertMediaPort
-	ArchivePort       ArchiveMediaPort
-	UploaderObservers []uploaderObserver // UploaderObservers are called after the media is uploaded, but before the media is catalogued
+	Owner               ownermodel.Owner
+	InsertMediaPort     InsertMediaPort
+	ArchivePort         ArchiveMediaPort
+	UploaderObservers   []uploaderObserver  // UploaderObservers are called after the media is uploaded and catalogued
+	BandwidthObservers  []bandwidthObserver // BandwidthObservers are notified of the rate limit applied before each batch when a limit is configured
+	GlobalLimiter       *bandwidth.Limiter  // GlobalLimiter is shared by all the routines ; nil when unlimited
+	RoutineBandwidth    bandwidth.Schedule  // RoutineBandwidth is the schedule of the limiter created for each routine
+	RetryPolicy         chain.RetryPolicy   // RetryPolicy is used for transient errors from the archive and the catalog
+	QuarantineObservers quarantineObservers // QuarantineObservers are notified of the medias that still fail after the retries ; the first failure is returned when there is none
+	routineLimiter      *bandwidth.Limiter
+}
+
+// newRoutine returns an uploader with its own limiter, to be used by a single routine.
+func (u *uploader) newRoutine() *uploader {
+	routine := *u
+	routine.routineLimiter = bandwidth.NewLimiter(u.RoutineBandwidth)
+	return &routine
 }
 
 func (u *uploader) OnMediaCatalogued(ctx context.Context, requests []BackingUpMediaRequest) error {
-	catalogRequests := make([]*CatalogMediaRequest, len(requests), len(requests))
+	catalogRequests := make([]*CatalogMediaRequest, 0, len(requests))
+	uploadedMedias := make([]*AnalysedMedia, 0, len(requests))
+
+	err := u.notifyBandwidth(ctx)
+	if err != nil {
+		return err
+	}
 
-	for i, request := range requests {
-		newFilename, err := u.ArchivePort.ArchiveMedia(u.Owner.Value(), &request)
+	for _, request := range requests {
+		newFilename, err := u.archive(ctx, request)
 		if err != nil {
-			return errors.Wrapf(err, "archiving media %s failed", request.AnalysedMedia.FoundMedia.String())
+			err = u.QuarantineObservers.quarantine(ctx, StageUpload, []*AnalysedMedia{request.AnalysedMedia}, errors.Wrapf(err, "archiving media %s failed", request.AnalysedMedia.FoundMedia.String()))
+			if err != nil {
+				return err
+			}
+			continue
 		}
 
-		catalogRequests[i] = &CatalogMediaRequest{
+		catalogRequests = append(catalogRequests, &CatalogMediaRequest{
 			BackingUpMediaRequest: &request,
 			ArchiveFilename:       newFilename,
-		}
+		})
+		uploadedMedias = append(uploadedMedias, request.AnalysedMedia)
+	}
+
+	if len(catalogRequests) == 0 {
+		return nil
+	}
+
+	err = u.RetryPolicy.Do(ctx, func(ctx context.Context) error {
+		return u.InsertMediaPort.IndexMedias(ctx, u.Owner, catalogRequests)
+	})
+	if err != nil {
+		return u.QuarantineObservers.quarantine(ctx, StageUpload, uploadedMedias, errors.Wrapf(err, "failed to catalog medias"))
+	}
 
+	for _, catalogRequest := range catalogRequests {
 		for _, observer := range u.UploaderObservers {
-			err = observer.OnBackingUpMediaRequestUploaded(ctx, request)
+			err = observer.OnBackingUpMediaRequestUploaded(ctx, *catalogRequest.BackingUpMediaRequest)
 			if err != nil {
 				return err
 			}
 		}
 	}
 
-	err := u.InsertMediaPort.IndexMedias(ctx, u.Owner, catalogRequests)
-	return errors.Wrapf(err, "failed to catalog medias")
+	return nil
+}
+
+// archive retries the upload of the media as long as it fails with a transient error.
+func (u *uploader) archive(ctx context.Context, request BackingUpMediaRequest) (string, error) {
+	archived := u.throttled(ctx, request)
+
+	var newFilename string
+	err := u.RetryPolicy.Do(ctx, func(ctx context.Context) (err error) {
+		newFilename, err = u.ArchivePort.ArchiveMedia(u.Owner.Value(), &archived)
+		return err
+	})
+	return newFilename, err
+}
+
+// throttled returns a copy of the request reading the media through the limiters ; the original request is kept for the catalog and the observers.
+func (u *uploader) throttled(ctx context.Context, request BackingUpMediaRequest) BackingUpMediaRequest {
+	if u.GlobalLimiter == nil && u.routineLimiter == nil {
+		return request
+	}
+
+	analysedMedia := *request.AnalysedMedia
+	analysedMedia.FoundMedia = &throttledMedia{
+		FoundMedia: analysedMedia.FoundMedia,
+		ctx:        ctx,
+		limiters:   []*bandwidth.Limiter{u.GlobalLimiter, u.routineLimiter},
+	}
+	return BackingUpMediaRequest{
+		AnalysedMedia:    &analysedMedia,
+		CatalogReference: request.CatalogReference,
+	}
+}
+
+func (u *uploader) notifyBandwidth(ctx context.Context) error {
+	if u.GlobalLimiter == nil && u.routineLimiter == nil {
+		return nil
+	}
+
+	limit := BandwidthLimit{
+		Global:     u.GlobalLimiter.Rate(),
+		PerRoutine: u.routineLimiter.Rate(),
+	}
+	for _, observer := range u.BandwidthObservers {
+		err := observer.OnUploadBandwidth(ctx, limit)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
@@ -0,0 +1,95 @@
+package backup
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/thomasduchatelle/dphoto/pkg/bandwidth"
+	"github.com/thomasduchatelle/dphoto/pkg/ownermodel"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestUploader_bandwidth(t *testing.T) {
+	found := NewInMemoryMedia("folder1/file_1.jpg", time.Now(), []byte("2022-06-18"))
+	request := BackingUpMediaRequest{
+		AnalysedMedia:    &AnalysedMedia{FoundMedia: found, Type: MediaTypeImage, Sha256Hash: "sha-1", Details: &MediaDetails{}},
+		CatalogReference: &CatalogReferenceStub{MediaIdValue: "media-id-1", AlbumFolderNameValue: "/album1"},
+	}
+
+	archive := new(ReadingArchiveFake)
+	insertMedia := newInsertMediaPortFake()
+	observer := new(BandwidthObserverFake)
+	globalUploader := &uploader{
+		Owner:              ownermodel.Owner("ironman"),
+		InsertMediaPort:    insertMedia,
+		ArchivePort:        archive,
+		BandwidthObservers: []bandwidthObserver{observer},
+		GlobalLimiter:      bandwidth.NewLimiter(bandwidth.Schedule{BytesPerSecond: 1_000_000}),
+		RoutineBandwidth:   bandwidth.Schedule{BytesPerSecond: 500_000},
+	}
+
+	err := globalUploader.newRoutine().OnMediaCatalogued(context.Background(), []BackingUpMediaRequest{request})
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"2022-06-18"}, archive.Contents)
+		assert.IsType(t, &throttledMedia{}, archive.Medias[0], "it should read the media through the limiters")
+		assert.Same(t, found, request.AnalysedMedia.FoundMedia, "it should not change the original request")
+		assert.Equal(t, []BandwidthLimit{{Global: 1_000_000, PerRoutine: 500_000}}, observer.Limits)
+		assert.Len(t, insertMedia.Got, 1)
+	}
+
+	archive = new(ReadingArchiveFake)
+	unlimited := &uploader{Owner: "ironman", InsertMediaPort: newInsertMediaPortFake(), ArchivePort: archive, BandwidthObservers: []bandwidthObserver{observer}}
+	err = unlimited.newRoutine().OnMediaCatalogued(context.Background(), []BackingUpMediaRequest{request})
+	if assert.NoError(t, err) {
+		assert.Same(t, found, archive.Medias[0], "it should not wrap the media when no limit is configured")
+		assert.Len(t, observer.Limits, 1, "it should not notify the bandwidth when no limit is configured")
+	}
+}
+
+func TestTracker_bandwidth(t *testing.T) {
+	listener := new(TrackBandwidthFake)
+	observer, _ := newTrackerV2(OptionsWithListener(listener))
+
+	for _, limit := range []BandwidthLimit{{Global: 1000}, {Global: 1000}, {Global: 0}, {Global: 0}} {
+		assert.NoError(t, observer.OnUploadBandwidth(context.Background(), limit))
+	}
+	observer.NoMoreEvents()
+
+	assert.Equal(t, []BandwidthLimit{{Global: 1000}, {Global: 0}}, listener.Limits, "it should only notify listeners when the limit changes")
+}
+
+type ReadingArchiveFake struct {
+	Medias   []FoundMedia
+	Contents []string
+}
+
+func (a *ReadingArchiveFake) ArchiveMedia(owner string, media *BackingUpMediaRequest) (string, error) {
+	reader, err := media.AnalysedMedia.FoundMedia.ReadMedia()
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(reader)
+	a.Medias = append(a.Medias, media.AnalysedMedia.FoundMedia)
+	a.Contents = append(a.Contents, string(content))
+	return media.AnalysedMedia.FoundMedia.MediaPath().Filename, err
+}
+
+type BandwidthObserverFake struct {
+	Limits []BandwidthLimit
+}
+
+func (b *BandwidthObserverFake) OnUploadBandwidth(ctx context.Context, limit BandwidthLimit) error {
+	b.Limits = append(b.Limits, limit)
+	return nil
+}
+
+type TrackBandwidthFake struct {
+	Limits []BandwidthLimit
+}
+
+func (t *TrackBandwidthFake) OnBandwidthLimit(limit BandwidthLimit) {
+	t.Limits = append(t.Limits, limit)
+}
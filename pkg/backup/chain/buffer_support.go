@@ -7,15 +7,18 @@ import (
 
 // BufferLink use a channel to collect items and release them as soon as the BufferCapacity is reached. Next is running on a single thread.
 type BufferLink[Consumed any] struct {
-	BufferCapacity int
-	ChannelSize    int // ChannelSize is the size of the internal channel. Default is 2048.
-	Next           Link[[]Consumed]
-	channel        chan Consumed
-	buffer         *buffer.Buffer[Consumed]
+	BufferCapacity  int
+	ChannelSize     int // ChannelSize is the size of the internal channel. Default is 2048.
+	Next            Link[[]Consumed]
+	Name            string          // Name identifies the link in the measures of the Instrumentation
+	Instrumentation Instrumentation // Instrumentation is optional and measures the queue and the time waiting for Next to accept each batch
+	channel         chan Consumed
+	buffer          *buffer.Buffer[Consumed]
 }
 
 func (l *BufferLink[Consumed]) Consume(ctx context.Context, consumed Consumed) error {
 	l.channel <- consumed
+	notifyQueued(l.Instrumentation, l.Name, l.channel)
 	return nil
 }
 
@@ -24,7 +27,7 @@ func (l *BufferLink[Consumed]) Starts(ctx context.Context, collector ChainableEr
 		l.ChannelSize = 2048
 	}
 	l.channel = make(chan Consumed, l.ChannelSize)
-	l.buffer = buffer.NewBuffer(l.BufferCapacity, l.Next.Consume)
+	l.buffer = buffer.NewBuffer(l.BufferCapacity, instrumented[[]Consumed](l.Next, l.Name, l.Instrumentation).Consume)
 
 	go func() {
 		defer l.Next.NotifyUpstreamCompleted()
@@ -46,6 +46,8 @@ type MultithreadedLink[Consumed any, Produced any] struct {
 	Cancellable      bool                                        // Cancellable is true if the cancelled context should stop the routine. Default is false.
 	ChannelSize      int                                         // ChannelSize is defaulted to 2048
 	Next             Link[Produced]                              // Next will receive the product of the ConsumerBuilder returned method. It is mandatory to have one, use EndOfTheChain to end the chain.
+	Name             string                                      // Name identifies the link in the measures of the Instrumentation
+	Instrumentation  Instrumentation                             // Instrumentation is optional and measures the queue and the processing of each item
 	channel          chan Consumed
 }
 
@@ -70,7 +72,7 @@ func (l *MultithreadedLink[Consumed, Produced]) Starts(ctx context.Context, coll
 		return err
 	}
 
-	consumer := l.ConsumerBuilder(l.Next)
+	consumer := instrumentedBuilder(l.ConsumerBuilder, l.Next, l.Name, l.Instrumentation)
 
 	var routine func(ctx context.Context)
 	if l.Cancellable {
@@ -123,6 +125,7 @@ func (l *MultithreadedLink[Consumed, Produced]) Consume(ctx context.Context, con
 	} else {
 		blockingAddsToChannel(ctx, l.channel, consumed)
 	}
+	notifyQueued(l.Instrumentation, l.Name, l.channel)
 	return nil
 }
 
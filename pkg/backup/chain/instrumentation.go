@@ -0,0 +1,124 @@
+package chain
+
+import (
+	"context"
+	"reflect"
+	"sync/atomic"
+	"time"
+)
+
+// Instrumentation measures the activity of the links ; implementations must be thread-safe.
+type Instrumentation interface {
+	// OnQueued is called each time an item is added to the channel of a link, with the number of items waiting in it.
+	OnQueued(link string, depth, capacity int)
+
+	// OnConsumed is called each time a link has processed an item.
+	OnConsumed(ctx context.Context, measure Measure)
+}
+
+// Measure is the processing of one item by a link.
+type Measure struct {
+	Link    string        // Link is the name of the link
+	Items   int           // Items is the length of the item when it is a slice (a batch), 1 otherwise
+	Start   time.Time     // Start is when the link started to process the item
+	End     time.Time     // End is when the link completed, products accepted by the next link
+	Blocked time.Duration // Blocked is the time spent waiting for the next link to accept the products
+	Err     error         // Err is the error reported by the link, if any
+}
+
+// Latency is the time spent processing the item, without the time blocked by the next link.
+func (m Measure) Latency() time.Duration {
+	return m.End.Sub(m.Start) - m.Blocked
+}
+
+// Instrumentations dispatches the measures to each instrumentation.
+type Instrumentations []Instrumentation
+
+func (i Instrumentations) OnQueued(link string, depth, capacity int) {
+	for _, instrumentation := range i {
+		instrumentation.OnQueued(link, depth, capacity)
+	}
+}
+
+func (i Instrumentations) OnConsumed(ctx context.Context, measure Measure) {
+	for _, instrumentation := range i {
+		instrumentation.OnConsumed(ctx, measure)
+	}
+}
+
+// blockedKey is the context key of the *atomic.Int64 accumulating the nanoseconds an item waited on the next link.
+type blockedKey struct{}
+
+// instrumentedConsumer measures each item consumed by a link.
+type instrumentedConsumer[Consumed any] struct {
+	Consumer[Consumed]
+	link            string
+	instrumentation Instrumentation
+}
+
+func instrumented[Consumed any](consumer Consumer[Consumed], link string, instrumentation Instrumentation) Consumer[Consumed] {
+	if instrumentation == nil {
+		return consumer
+	}
+
+	return &instrumentedConsumer[Consumed]{
+		Consumer:        consumer,
+		link:            link,
+		instrumentation: instrumentation,
+	}
+}
+
+// instrumentedBuilder measures the consumer built for a link, excluding the time its products wait to be accepted by next.
+func instrumentedBuilder[Consumed, Produced any](builder func(Consumer[Produced]) Consumer[Consumed], next Consumer[Produced], link string, instrumentation Instrumentation) Consumer[Consumed] {
+	if instrumentation == nil {
+		return builder(next)
+	}
+
+	return instrumented(builder(&blockingConsumer[Produced]{Consumer: next}), link, instrumentation)
+}
+
+func (c *instrumentedConsumer[Consumed]) Consume(ctx context.Context, consumed Consumed) error {
+	blocked := new(atomic.Int64)
+	start := time.Now()
+	err := c.Consumer.Consume(context.WithValue(ctx, blockedKey{}, blocked), consumed)
+
+	c.instrumentation.OnConsumed(ctx, Measure{
+		Link:    c.link,
+		Items:   countItems(consumed),
+		Start:   start,
+		End:     time.Now(),
+		Blocked: time.Duration(blocked.Load()),
+		Err:     err,
+	})
+	return err
+}
+
+// blockingConsumer adds the time spent by the next link to accept a product to the item being measured.
+type blockingConsumer[Produced any] struct {
+	Consumer[Produced]
+}
+
+func (c *blockingConsumer[Produced]) Consume(ctx context.Context, produced Produced) error {
+	start := time.Now()
+	err := c.Consumer.Consume(ctx, produced)
+
+	if blocked, ok := ctx.Value(blockedKey{}).(*atomic.Int64); ok {
+		blocked.Add(int64(time.Since(start)))
+	}
+	return err
+}
+
+func notifyQueued[Consumed any](instrumentation Instrumentation, link string, channel chan Consumed) {
+	if instrumentation != nil {
+		instrumentation.OnQueued(link, len(channel), cap(channel))
+	}
+}
+
+func countItems(consumed any) int {
+	value := reflect.ValueOf(consumed)
+	if value.Kind() == reflect.Slice {
+		return value.Len()
+	}
+
+	return 1
+}
@@ -0,0 +1,143 @@
+package chain
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstrumentation(t *testing.T) {
+	instrumentation := new(InstrumentationFake)
+	ctx := context.Background()
+
+	launcher := &SingleLauncher[[]int, int]{
+		Function: func(ctx context.Context, consumed []int) ([]int, error) {
+			return consumed, nil
+		},
+		Next: &MultithreadedLink[int, int]{
+			Name:            "double",
+			Instrumentation: instrumentation,
+			ConsumerBuilder: func(next Consumer[int]) Consumer[int] {
+				return ConsumerFunc[int](func(ctx context.Context, consumed int) error {
+					if consumed == 3 {
+						return errors.New("TEST three is not supported")
+					}
+					return next.Consume(ctx, consumed*2)
+				})
+			},
+			Next: &BufferLink[int]{
+				Name:            "batch",
+				Instrumentation: instrumentation,
+				BufferCapacity:  2,
+				Next: &MultithreadedLink[[]int, []int]{
+					ConsumerBuilder: PassThrough[[]int](),
+					Next:            EndOfTheChain[[]int](),
+				},
+			},
+		},
+	}
+
+	err := launcher.Starts(ctx, NewErrorCollector())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	err = <-launcher.Process(ctx, []int{1, 2, 3, 4, 5})
+	assert.ErrorContains(t, err, "TEST three is not supported")
+
+	assert.Equal(t, 5, instrumentation.Queued["double"])
+	assert.Equal(t, 4, instrumentation.Queued["batch"])
+	assert.Equal(t, map[string][]int{
+		"double": {1, 1, 1, 1, 1},
+		"batch":  {2, 2},
+	}, instrumentation.Items)
+	assert.Equal(t, map[string]int{"double": 1}, instrumentation.Errors)
+}
+
+func TestInstrumentation_blocked(t *testing.T) {
+	instrumentation := new(InstrumentationFake)
+	ctx := context.Background()
+	release := make(chan struct{})
+
+	launcher := &SingleLauncher[[]int, int]{
+		Function: func(ctx context.Context, consumed []int) ([]int, error) {
+			return consumed, nil
+		},
+		Next: &MultithreadedLink[int, int]{
+			Name:            "fast",
+			Instrumentation: instrumentation,
+			ConsumerBuilder: PassThrough[int](),
+			Next: &MultithreadedLink[int, int]{
+				Name:            "slow",
+				Instrumentation: instrumentation,
+				ChannelSize:     1,
+				ConsumerBuilder: func(next Consumer[int]) Consumer[int] {
+					return ConsumerFunc[int](func(ctx context.Context, consumed int) error {
+						<-release
+						return next.Consume(ctx, consumed)
+					})
+				},
+				Next: EndOfTheChain[int](),
+			},
+		},
+	}
+
+	err := launcher.Starts(ctx, NewErrorCollector())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+	}()
+	err = <-launcher.Process(ctx, []int{1, 2, 3})
+	assert.NoError(t, err)
+
+	for _, measure := range instrumentation.Measures["fast"] {
+		assert.Less(t, measure.Latency(), 40*time.Millisecond, "it should exclude the time waiting for the slow link to accept the item")
+		assert.Equal(t, measure.End.Sub(measure.Start), measure.Latency()+measure.Blocked)
+	}
+	assert.Greater(t, instrumentation.Measures["fast"][len(instrumentation.Measures["fast"])-1].Blocked, 40*time.Millisecond)
+}
+
+type InstrumentationFake struct {
+	lock     sync.Mutex
+	Queued   map[string]int
+	Items    map[string][]int
+	Errors   map[string]int
+	Measures map[string][]Measure
+}
+
+func (i *InstrumentationFake) OnQueued(link string, depth, capacity int) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	if i.Queued == nil {
+		i.Queued = make(map[string]int)
+	}
+	i.Queued[link]++
+}
+
+func (i *InstrumentationFake) OnConsumed(ctx context.Context, measure Measure) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	if i.Items == nil {
+		i.Items = make(map[string][]int)
+		i.Errors = make(map[string]int)
+		i.Measures = make(map[string][]Measure)
+	}
+	i.Measures[measure.Link] = append(i.Measures[measure.Link], measure)
+	i.Items[measure.Link] = append(i.Items[measure.Link], measure.Items)
+	if measure.Err != nil {
+		i.Errors[measure.Link]++
+	}
+	if measure.End.Before(measure.Start) {
+		panic("measure must end after it started")
+	}
+}
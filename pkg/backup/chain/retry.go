@@ -0,0 +1,158 @@
+package chain
+
+import (
+	"context"
+	"math/rand"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultMaxAttempts  = 4
+	DefaultInitialDelay = 500 * time.Millisecond
+	DefaultMaxDelay     = 20 * time.Second
+)
+
+var (
+	// transientErrorCodes are the codes of AWS errors worth retrying: throttling of DynamoDB and S3, and server side failures.
+	transientErrorCodes = map[string]interface{}{
+		"ProvisionedThroughputExceededException": nil,
+		"ThrottlingException":                    nil,
+		"Throttling":                             nil,
+		"RequestLimitExceeded":                   nil,
+		"TooManyRequestsException":               nil,
+		"TransactionInProgressException":         nil,
+		"SlowDown":                               nil,
+		"RequestTimeout":                         nil,
+		"InternalError":                          nil,
+		"InternalServerError":                    nil,
+		"ServiceUnavailable":                     nil,
+	}
+)
+
+// RetryPolicy retries operations failing with a transient error, waiting exponentially longer between each attempt (with jitter).
+type RetryPolicy struct {
+	MaxAttempts  int                  // MaxAttempts includes the first attempt ; 1 disables the retries. Default is DefaultMaxAttempts.
+	InitialDelay time.Duration        // InitialDelay is the longest wait before the second attempt, it doubles after each attempt. Default is DefaultInitialDelay.
+	MaxDelay     time.Duration        // MaxDelay caps the wait between two attempts. Default is DefaultMaxDelay.
+	IsTransient  func(err error) bool // IsTransient decides if an error is worth retrying. Default is IsTransientError.
+	sleep        func(ctx context.Context, delay time.Duration) error
+	random       func() float64
+}
+
+// Do calls the operation until it succeeds, fails with a permanent error, or the attempts are exhausted ; the last error is returned.
+func (p RetryPolicy) Do(ctx context.Context, operation func(ctx context.Context) error) error {
+	p = p.withDefaults()
+
+	for attempt := 1; ; attempt++ {
+		err := operation(ctx)
+		if err == nil || !p.IsTransient(err) {
+			return err
+		}
+		if attempt >= p.MaxAttempts {
+			return errors.Wrapf(err, "still failing after %d attempts", attempt)
+		}
+
+		if sleepErr := p.sleep(ctx, p.delay(attempt)); sleepErr != nil {
+			return err
+		}
+	}
+}
+
+// delay is picked randomly between half and the full exponential backoff to spread the retries of concurrent routines.
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	backoff := p.InitialDelay << (attempt - 1)
+	if backoff > p.MaxDelay || backoff <= 0 {
+		backoff = p.MaxDelay
+	}
+
+	return backoff/2 + time.Duration(p.random()*float64(backoff/2))
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = DefaultMaxAttempts
+	}
+	if p.InitialDelay <= 0 {
+		p.InitialDelay = DefaultInitialDelay
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = DefaultMaxDelay
+	}
+	if p.IsTransient == nil {
+		p.IsTransient = IsTransientError
+	}
+	if p.sleep == nil {
+		p.sleep = sleepUnlessCancelled
+	}
+	if p.random == nil {
+		p.random = rand.Float64
+	}
+	return p
+}
+
+func sleepUnlessCancelled(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Permanent marks an error as not worth retrying, whatever its cause.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err}
+}
+
+type permanentError struct {
+	error
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.error
+}
+
+type errorWithCode interface {
+	ErrorCode() string
+}
+
+type errorWithHTTPStatus interface {
+	HTTPStatusCode() int
+}
+
+// IsTransientError returns true for throttling, server side (5xx), and network errors ; errors are recognised without depending on the AWS SDK.
+func IsTransientError(err error) bool {
+	var permanent *permanentError
+	if err == nil || errors.As(err, &permanent) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var withCode errorWithCode
+	if errors.As(err, &withCode) {
+		if _, transient := transientErrorCodes[withCode.ErrorCode()]; transient {
+			return true
+		}
+	}
+
+	var withStatus errorWithHTTPStatus
+	if errors.As(err, &withStatus) && (withStatus.HTTPStatusCode() >= 500 || withStatus.HTTPStatusCode() == 429) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.EPIPE)
+}
@@ -0,0 +1,166 @@
+package chain
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicy_Do(t *testing.T) {
+	throttled := &APIErrorStub{Code: "ProvisionedThroughputExceededException"}
+	notFound := &APIErrorStub{Code: "ResourceNotFoundException"}
+
+	tests := []struct {
+		name         string
+		policy       RetryPolicy
+		errs         []error
+		wantAttempts int
+		wantErr      string
+		wantDelays   []time.Duration
+	}{
+		{
+			name:         "it should not retry a successful operation",
+			errs:         []error{nil},
+			wantAttempts: 1,
+		},
+		{
+			name:         "it should retry transient errors with an exponential backoff",
+			errs:         []error{throttled, throttled, nil},
+			wantAttempts: 3,
+			wantDelays:   []time.Duration{500 * time.Millisecond, time.Second},
+		},
+		{
+			name:         "it should not retry permanent errors",
+			errs:         []error{notFound, nil},
+			wantAttempts: 1,
+			wantErr:      "ResourceNotFoundException",
+		},
+		{
+			name:         "it should not retry errors marked as permanent",
+			errs:         []error{Permanent(throttled), nil},
+			wantAttempts: 1,
+			wantErr:      "ProvisionedThroughputExceededException",
+		},
+		{
+			name:         "it should give up after the maximum attempts",
+			policy:       RetryPolicy{MaxAttempts: 2, InitialDelay: time.Second},
+			errs:         []error{throttled, throttled, nil},
+			wantAttempts: 2,
+			wantErr:      "still failing after 2 attempts: ProvisionedThroughputExceededException",
+			wantDelays:   []time.Duration{time.Second},
+		},
+		{
+			name:         "it should cap the delay between attempts",
+			policy:       RetryPolicy{InitialDelay: 3 * time.Second, MaxDelay: 5 * time.Second},
+			errs:         []error{throttled, throttled, throttled, nil},
+			wantAttempts: 4,
+			wantDelays:   []time.Duration{3 * time.Second, 5 * time.Second, 5 * time.Second},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var delays []time.Duration
+			tt.policy.random = func() float64 {
+				return 1
+			}
+			tt.policy.sleep = func(ctx context.Context, delay time.Duration) error {
+				delays = append(delays, delay)
+				return nil
+			}
+
+			attempts := 0
+			err := tt.policy.Do(context.Background(), func(ctx context.Context) error {
+				attempts++
+				return tt.errs[attempts-1]
+			})
+
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantAttempts, attempts)
+			assert.Equal(t, tt.wantDelays, delays)
+		})
+	}
+}
+
+func TestRetryPolicy_DoCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	attempts := 0
+	err := RetryPolicy{InitialDelay: time.Hour}.Do(ctx, func(ctx context.Context) error {
+		attempts++
+		return &APIErrorStub{Code: "SlowDown"}
+	})
+
+	assert.EqualError(t, err, "SlowDown")
+	assert.Equal(t, 1, attempts, "it should stop waiting when the context is cancelled")
+}
+
+func TestRetryPolicy_delay(t *testing.T) {
+	policy := RetryPolicy{InitialDelay: time.Second, random: func() float64 { return 0 }}.withDefaults()
+
+	assert.Equal(t, 500*time.Millisecond, policy.delay(1), "it should wait at least half of the backoff")
+	assert.Equal(t, 4*time.Second, policy.delay(4))
+	assert.Equal(t, DefaultMaxDelay/2, policy.delay(80), "it should not overflow")
+}
+
+func TestIsTransientError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{errors.New("invalid media"), false},
+		{&APIErrorStub{Code: "ProvisionedThroughputExceededException"}, true},
+		{&APIErrorStub{Code: "ThrottlingException"}, true},
+		{errors.Wrapf(&APIErrorStub{Code: "SlowDown"}, "upload failed"), true},
+		{&APIErrorStub{Code: "ConditionalCheckFailedException", Status: 400}, false},
+		{&APIErrorStub{Code: "NoSuchKey", Status: 404}, false},
+		{&APIErrorStub{Status: 503}, true},
+		{&APIErrorStub{Status: 500}, true},
+		{&APIErrorStub{Status: 429}, true},
+		{&net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, true},
+		{&net.DNSError{IsTimeout: true}, true},
+		{errors.Wrapf(syscall.ECONNRESET, "read failed"), true},
+		{context.Canceled, false},
+		{errors.Wrapf(context.DeadlineExceeded, "upload failed"), false},
+		{Permanent(&APIErrorStub{Status: 503}), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%v", tt.err), func(t *testing.T) {
+			assert.Equal(t, tt.want, IsTransientError(tt.err))
+		})
+	}
+}
+
+// APIErrorStub has the same methods as the errors of the AWS SDK.
+type APIErrorStub struct {
+	Code   string
+	Status int
+}
+
+func (e *APIErrorStub) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("status %d", e.Status)
+	}
+	return e.Code
+}
+
+func (e *APIErrorStub) ErrorCode() string {
+	return e.Code
+}
+
+func (e *APIErrorStub) HTTPStatusCode() int {
+	return e.Status
+}
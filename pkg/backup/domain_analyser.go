@@ -18,8 +18,14 @@ var SupportedExtensions = map[string]MediaType{
 	"jpeg": MediaTypeImage,
 	"png":  MediaTypeImage,
 	"gif":  MediaTypeImage,
+	"heic": MediaTypeImage,
+	"heif": MediaTypeImage,
 	"webp": MediaTypeImage,
 	"raw":  MediaTypeImage,
+	"cr2":  MediaTypeImage,
+	"nef":  MediaTypeImage,
+	"arw":  MediaTypeImage,
+	"dng":  MediaTypeImage,
 	//"bmp":  backupmodel.MediaTypeImage,
 	"svg": MediaTypeImage,
 	"eps": MediaTypeImage,
@@ -102,7 +108,11 @@ func (a *AnalyserFromMediaDetails) extractDetails(found FoundMedia, reader io.Re
 		loadedReaders[i] = fmt.Sprint(detailsReader)
 
 		if detailsReader.Supports(found, mediaType) {
-			details, err := detailsReader.ReadDetails(reader, options)
+			details, err := readDetails(detailsReader, found, reader, options)
+			if fallback, ok := detailsReader.(DetailsReaderWithFileDate); ok && err == nil && details != nil && details.DateTime.IsZero() && fallback.FallbackOnFileDate() {
+				details.DateTime = found.LastModification()
+				details.TimeReference = TimeReferenceInstant
+			}
 			return mediaType, details, errors.Wrapf(err, "failed to analyse %s file", found)
 		}
 	}
@@ -110,6 +120,14 @@ func (a *AnalyserFromMediaDetails) extractDetails(found FoundMedia, reader io.Re
 	return MediaTypeOther, nil, errors.Wrapf(ErrAnalyserNotSupported, "none of the details readers [%s] can parse %s", strings.Join(loadedReaders, ", "), found)
 }
 
+func readDetails(detailsReader DetailsReader, found FoundMedia, reader io.Reader, options DetailsReaderOptions) (*MediaDetails, error) {
+	if ofFoundMedia, ok := detailsReader.(DetailsReaderOfFoundMedia); ok {
+		return ofFoundMedia.ReadDetailsOfFoundMedia(found, reader, options)
+	}
+
+	return detailsReader.ReadDetails(reader, options)
+}
+
 type hashSpy struct {
 	reader    io.Reader
 	shaWriter hash.Hash
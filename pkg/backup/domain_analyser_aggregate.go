@@ -21,6 +21,7 @@ type RejectedMediaObserver interface {
 
 type analyserAggregate struct {
 	analyser               Analyser
+	timeZones              *timeZoneResolver // timeZones is optional: DateTime are used as read by the parsers without it
 	analysedMediaObservers AnalysedMediaObservers
 	rejectedMediaObservers RejectedMediaObservers
 }
@@ -31,6 +32,7 @@ func (a *analyserAggregate) OnFoundMedia(ctx context.Context, media FoundMedia)
 		return a.rejectedMediaObservers.OnRejectedMedia(ctx, media, err)
 	}
 
+	analyseMedia.Details = a.timeZones.resolve(analyseMedia.Details)
 	if analyseMedia.Details.DateTime.IsZero() {
 		return a.rejectedMediaObservers.OnRejectedMedia(ctx, media, ErrAnalyserNoDateTime)
 	}
@@ -21,6 +21,7 @@ func Test_analyserAggregate_OnFoundMedia(t *testing.T) {
 	noDate := time.Time{}
 	image1 := NewInMemoryMedia("image-1.jpg", time.Time{}, []byte("nice picture"))
 	video2 := NewInMemoryMedia("video-2.mp4", time.Time{}, []byte("nice video"))
+	video3 := NewInMemoryMedia("video-3.mpeg", now, []byte("old video"))
 
 	type fields struct {
 		detailReaders []DetailsReader
@@ -98,6 +99,22 @@ func Test_analyserAggregate_OnFoundMedia(t *testing.T) {
 			},
 			wantErr: assert.NoError,
 		},
+		{
+			name: "it should use the date of the file when the format doesn't record it",
+			fields: fields{
+				detailReaders: []DetailsReader{&DetailsReaderWithFileDateFake{DetailsReaderFake{Details: detailsWithDate(noDate)}}},
+			},
+			args: args{
+				media: video3,
+			},
+			wantObserved: []AnalysedMedia{{
+				FoundMedia: video3,
+				Type:       MediaTypeVideo,
+				Sha256Hash: "20a36b5b97aefeecdca4d4594e3ce0cbab96d77b85f6c9a8c56c32f53cb6776e",
+				Details:    &MediaDetails{DateTime: now, TimeReference: TimeReferenceInstant},
+			}},
+			wantErr: assert.NoError,
+		},
 		{
 			name: "it should reject media an error if the details reader fails",
 			fields: fields{
@@ -165,6 +182,14 @@ func (d *DetailsReaderFake) ReadDetails(reader io.Reader, options DetailsReaderO
 	return d.Details, nil
 }
 
+type DetailsReaderWithFileDateFake struct {
+	DetailsReaderFake
+}
+
+func (d *DetailsReaderWithFileDateFake) FallbackOnFileDate() bool {
+	return true
+}
+
 type AnalyserObserverFake struct {
 	Analysed []AnalysedMedia
 	Rejected map[string]error
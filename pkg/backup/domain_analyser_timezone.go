@@ -0,0 +1,56 @@
+package backup
+
+import (
+	log "github.com/sirupsen/logrus"
+	"time"
+)
+
+// timeZoneResolver expresses the DateTime of the analysed medias in the zone they have been captured: read by the parser, deduced from the GPS coordinates, or the default one.
+type timeZoneResolver struct {
+	finder      TimeZoneFinder // finder is optional, only the default zone is used without it
+	defaultZone *time.Location
+}
+
+func newTimeZoneResolver(finder TimeZoneFinder, options Options) *timeZoneResolver {
+	return &timeZoneResolver{
+		finder:      finder,
+		defaultZone: options.GetDefaultTimeZone(),
+	}
+}
+
+// resolve returns a copy of the details with a ZONED TimeReference ; the details are returned as they are if they can't be resolved.
+func (r *timeZoneResolver) resolve(details *MediaDetails) *MediaDetails {
+	if r == nil || details == nil || details.DateTime.IsZero() || details.TimeReference == TimeReferenceZoned {
+		return details
+	}
+
+	resolved := *details
+	zone := r.zoneOf(details)
+	switch details.TimeReference {
+	case TimeReferenceInstant:
+		resolved.DateTime = details.DateTime.In(zone)
+
+	default:
+		wallClock := details.DateTime
+		resolved.DateTime = time.Date(wallClock.Year(), wallClock.Month(), wallClock.Day(), wallClock.Hour(), wallClock.Minute(), wallClock.Second(), wallClock.Nanosecond(), zone)
+	}
+
+	resolved.TimeReference = TimeReferenceZoned
+	return &resolved
+}
+
+func (r *timeZoneResolver) zoneOf(details *MediaDetails) *time.Location {
+	if r.finder != nil && (details.GPSLatitude != 0 || details.GPSLongitude != 0) {
+		zone, err := r.finder.FindTimeZone(details.GPSLatitude, details.GPSLongitude)
+		if err == nil && zone != nil {
+			return zone
+		}
+
+		log.WithError(err).Warnf("Time zone couldn't be found at %f, %f ; default zone %s is used.", details.GPSLatitude, details.GPSLongitude, r.defaultZone)
+	}
+
+	if r.defaultZone != nil {
+		return r.defaultZone
+	}
+	return time.UTC
+}
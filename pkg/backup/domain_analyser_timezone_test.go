@@ -0,0 +1,101 @@
+package backup
+
+import (
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestTimeZoneResolver_resolve(t *testing.T) {
+	paris, _ := time.LoadLocation("Europe/Paris")
+	sydney, _ := time.LoadLocation("Australia/Sydney")
+	plusTwo := time.FixedZone("", 2*3600)
+
+	finder := TimeZoneFinderFake{
+		{-33.87, 151.21}: sydney,
+	}
+
+	tests := []struct {
+		name    string
+		finder  TimeZoneFinder
+		details *MediaDetails
+		want    *MediaDetails
+	}{
+		{
+			name:    "it should keep the zone read by the parser",
+			finder:  finder,
+			details: &MediaDetails{DateTime: time.Date(2023, 7, 14, 18, 30, 0, 0, plusTwo), TimeReference: TimeReferenceZoned, GPSLatitude: -33.87, GPSLongitude: 151.21},
+			want:    &MediaDetails{DateTime: time.Date(2023, 7, 14, 18, 30, 0, 0, plusTwo), TimeReference: TimeReferenceZoned, GPSLatitude: -33.87, GPSLongitude: 151.21},
+		},
+		{
+			name:    "it should keep the wall-clock and use the zone of the GPS coordinates",
+			finder:  finder,
+			details: &MediaDetails{DateTime: time.Date(2023, 7, 14, 18, 30, 0, 0, time.UTC), GPSLatitude: -33.87, GPSLongitude: 151.21},
+			want:    &MediaDetails{DateTime: time.Date(2023, 7, 14, 18, 30, 0, 0, sydney), TimeReference: TimeReferenceZoned, GPSLatitude: -33.87, GPSLongitude: 151.21},
+		},
+		{
+			name:    "it should convert an instant into the zone of the GPS coordinates",
+			finder:  finder,
+			details: &MediaDetails{DateTime: time.Date(2023, 7, 14, 8, 30, 0, 0, time.UTC), TimeReference: TimeReferenceInstant, GPSLatitude: -33.87, GPSLongitude: 151.21},
+			want:    &MediaDetails{DateTime: time.Date(2023, 7, 14, 18, 30, 0, 0, sydney), TimeReference: TimeReferenceZoned, GPSLatitude: -33.87, GPSLongitude: 151.21},
+		},
+		{
+			name:    "it should use the default zone without GPS coordinates",
+			finder:  finder,
+			details: &MediaDetails{DateTime: time.Date(2023, 7, 14, 16, 30, 0, 0, time.UTC), TimeReference: TimeReferenceInstant},
+			want:    &MediaDetails{DateTime: time.Date(2023, 7, 14, 18, 30, 0, 0, paris), TimeReference: TimeReferenceZoned},
+		},
+		{
+			name:    "it should use the default zone when the zone of the GPS coordinates is unknown",
+			finder:  finder,
+			details: &MediaDetails{DateTime: time.Date(2023, 7, 14, 18, 30, 0, 0, time.UTC), GPSLatitude: 48.85, GPSLongitude: 2.35},
+			want:    &MediaDetails{DateTime: time.Date(2023, 7, 14, 18, 30, 0, 0, paris), TimeReference: TimeReferenceZoned, GPSLatitude: 48.85, GPSLongitude: 2.35},
+		},
+		{
+			name:    "it should use the default zone without finder",
+			details: &MediaDetails{DateTime: time.Date(2023, 7, 14, 18, 30, 0, 0, time.UTC), GPSLatitude: -33.87, GPSLongitude: 151.21},
+			want:    &MediaDetails{DateTime: time.Date(2023, 7, 14, 18, 30, 0, 0, paris), TimeReference: TimeReferenceZoned, GPSLatitude: -33.87, GPSLongitude: 151.21},
+		},
+		{
+			name:    "it should not resolve a media without date",
+			finder:  finder,
+			details: &MediaDetails{GPSLatitude: -33.87, GPSLongitude: 151.21},
+			want:    &MediaDetails{GPSLatitude: -33.87, GPSLongitude: 151.21},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolver := newTimeZoneResolver(tt.finder, OptionsWithDefaultTimeZone(paris))
+
+			got := resolver.resolve(tt.details)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMediaDetails_zonedViews(t *testing.T) {
+	sydney, _ := time.LoadLocation("Australia/Sydney")
+
+	details := &MediaDetails{DateTime: time.Date(2023, 7, 14, 18, 30, 0, 0, sydney), TimeReference: TimeReferenceZoned}
+	assert.Equal(t, time.Date(2023, 7, 14, 18, 30, 0, 0, time.UTC), details.LocalDateTime())
+	assert.Equal(t, "Australia/Sydney", details.TimeZone())
+
+	details = &MediaDetails{DateTime: time.Date(2023, 7, 14, 18, 30, 0, 0, time.FixedZone("", -3*3600)), TimeReference: TimeReferenceZoned}
+	assert.Equal(t, "-03:00", details.TimeZone())
+
+	details = &MediaDetails{DateTime: time.Date(2023, 7, 14, 18, 30, 0, 0, time.UTC)}
+	assert.Equal(t, "", details.TimeZone(), "zone is unknown before the resolution")
+}
+
+// TimeZoneFinderFake knows the zones of a few coordinates.
+type TimeZoneFinderFake map[[2]float64]*time.Location
+
+func (f TimeZoneFinderFake) FindTimeZone(latitude, longitude float64) (*time.Location, error) {
+	if zone, ok := f[[2]float64{latitude, longitude}]; ok {
+		return zone, nil
+	}
+
+	return nil, errors.Errorf("no zone known at %f, %f", latitude, longitude)
+}
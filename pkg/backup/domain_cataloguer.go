@@ -2,6 +2,8 @@ package backup
 
 import (
 	"context"
+	"github.com/pkg/errors"
+	"github.com/thomasduchatelle/dphoto/pkg/backup/chain"
 )
 
 type Cataloguer interface {
@@ -37,10 +39,24 @@ type CataloguerFilterObserver interface {
 type cataloguerAggregate struct {
 	cataloguer          Cataloguer
 	observerWithFilters applyFiltersOnCataloguer
+	retryPolicy         chain.RetryPolicy
+	quarantine          quarantineObservers
 }
 
+// OnBatchOfAnalysedMedia retries the batch as long as none of its medias have been passed to the observer.
 func (s *cataloguerAggregate) OnBatchOfAnalysedMedia(ctx context.Context, batch []*AnalysedMedia) error {
-	return s.cataloguer.Reference(ctx, batch, &s.observerWithFilters)
+	referenced := false
+	err := s.retryPolicy.Do(ctx, func(ctx context.Context) error {
+		return s.cataloguer.Reference(ctx, batch, CatalogReferencerObserverFunc(func(ctx context.Context, requests []BackingUpMediaRequest) error {
+			referenced = true
+			return chain.Permanent(s.observerWithFilters.OnMediaCatalogued(ctx, requests))
+		}))
+	})
+	if err == nil || referenced {
+		return err
+	}
+
+	return s.quarantine.quarantine(ctx, StageCatalogue, batch, errors.Wrapf(err, "failed to catalogue a batch of %d medias", len(batch)))
 }
 
 func postCataloguerFiltersList(options Options) []cataloguerFilter {
@@ -57,6 +73,10 @@ func postCataloguerFiltersList(options Options) []cataloguerFilter {
 		filters = append(filters, mustBeInAlbum(albumFolderNames...))
 	}
 
+	if len(options.PostAnalyseFilters) > 0 {
+		filters = append(filters, mustBeAcceptedByRules(options.PostAnalyseFilters...))
+	}
+
 	return filters
 }
 
@@ -2,6 +2,7 @@ package backup
 
 import (
 	"context"
+	"fmt"
 	"github.com/pkg/errors"
 	"slices"
 	"sync"
@@ -11,8 +12,24 @@ var (
 	ErrCatalogerFilterMustBeInAlbum        = errors.New("media must be in album")
 	ErrCatalogerFilterMustNotAlreadyExists = errors.New("media must not already exists")
 	ErrMediaMustNotBeDuplicated            = errors.New("media is present twice in the volume")
+	ErrMediaFilteredOutByRule              = errors.New("media filtered out by a rule")
 )
 
+const defaultFilteredOutReason = "filtered out"
+
+// FilteredOutByRuleError is the cause given when a PostAnalyseFilter didn't accept a media ; it matches ErrMediaFilteredOutByRule.
+type FilteredOutByRuleError struct {
+	Reason string
+}
+
+func (e FilteredOutByRuleError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrMediaFilteredOutByRule.Error(), e.Reason)
+}
+
+func (e FilteredOutByRuleError) Is(target error) bool {
+	return target == ErrMediaFilteredOutByRule
+}
+
 type cataloguerFilter interface {
 	// FilterOut returns an error if the media must be filtered out
 	FilterOut(ctx context.Context, media AnalysedMedia, reference CatalogReference) error
@@ -33,6 +50,10 @@ func mustBeUniqueInVolume() cataloguerFilter {
 	}
 }
 
+func mustBeAcceptedByRules(filters ...PostAnalyseFilter) cataloguerFilter {
+	return &postAnalyseCataloguerFilter{filters: filters}
+}
+
 type mustBeInAlbumCatalogerFilter struct {
 	albumFolderNames []string
 }
@@ -71,3 +92,22 @@ func (u *uniqueFilter) FilterOut(ctx context.Context, media AnalysedMedia, refer
 	u.uniqueIndexes[uniqueId] = nil
 	return nil
 }
+
+type postAnalyseCataloguerFilter struct {
+	filters []PostAnalyseFilter
+}
+
+func (p *postAnalyseCataloguerFilter) FilterOut(ctx context.Context, media AnalysedMedia, reference CatalogReference) error {
+	for _, filter := range p.filters {
+		if withReason, ok := filter.(PostAnalyseFilterWithReason); ok {
+			if reason := withReason.FilterOutReason(&media, reference.AlbumFolderName()); reason != "" {
+				return FilteredOutByRuleError{Reason: reason}
+			}
+
+		} else if !filter.AcceptAnalysedMedia(&media, reference.AlbumFolderName()) {
+			return FilteredOutByRuleError{Reason: defaultFilteredOutReason}
+		}
+	}
+
+	return nil
+}
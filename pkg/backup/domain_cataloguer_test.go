@@ -108,6 +108,34 @@ func TestNewCataloguerAcceptance(t *testing.T) {
 			},
 			wantErr: assert.NoError,
 		},
+		{
+			name: "it should filter out medias not accepted by the rules, with their reason",
+			fields: fields{
+				CatalogReferencer: CatalogReferencerFake{
+					analysedMedia1: reference1IsNew,
+					analysedMedia2: reference2,
+					analysedMedia3: reference3,
+				},
+				options: OptionsWithPostAnalyseFilters(
+					PostAnalyseFilterFake{"file2.jpg": "too small"},
+					rejectAlbumFilterFake("album3"),
+				),
+			},
+			args: args{
+				medias: []*AnalysedMedia{analysedMedia1, analysedMedia2, analysedMedia3},
+			},
+			want: []BackingUpMediaRequest{
+				{
+					AnalysedMedia:    analysedMedia1,
+					CatalogReference: reference1IsNew,
+				},
+			},
+			wantFiltered: map[string]assert.ErrorAssertionFunc{
+				analysedMedia2.FoundMedia.MediaPath().Filename: errorIs(FilteredOutByRuleError{Reason: "too small"}),
+				analysedMedia3.FoundMedia.MediaPath().Filename: errorIs(FilteredOutByRuleError{Reason: defaultFilteredOutReason}),
+			},
+			wantErr: assert.NoError,
+		},
 	}
 
 	for _, tt := range tests {
@@ -146,6 +174,24 @@ func errorIs(expectedErr error) func(t assert.TestingT, err error, i ...interfac
 	}
 }
 
+// PostAnalyseFilterFake rejects the medias by filename, with a reason
+type PostAnalyseFilterFake map[string]string
+
+func (p PostAnalyseFilterFake) AcceptAnalysedMedia(media *AnalysedMedia, folderName string) bool {
+	return p.FilterOutReason(media, folderName) == ""
+}
+
+func (p PostAnalyseFilterFake) FilterOutReason(media *AnalysedMedia, folderName string) string {
+	return p[media.FoundMedia.MediaPath().Filename]
+}
+
+// rejectAlbumFilterFake rejects the medias of an album, without reason
+type rejectAlbumFilterFake string
+
+func (r rejectAlbumFilterFake) AcceptAnalysedMedia(media *AnalysedMedia, folderName string) bool {
+	return folderName != string(r)
+}
+
 func newAnalysedMedia(filename string, mediaDateTime time.Time, size int) *AnalysedMedia {
 	return &AnalysedMedia{
 		FoundMedia: NewInMemoryMedia(filename, mediaDateTime, []byte(filename+": "+strings.Repeat("a", size-len(filename)-2))),
@@ -0,0 +1,56 @@
+package backup
+
+import (
+	"path"
+	"strings"
+)
+
+// SidecarExtensions are the extensions of the files describing a media, next to it, written by photo editors (ex: Lightroom, Darktable).
+var SidecarExtensions = map[string]interface{}{
+	"xmp": nil,
+}
+
+// FoundMediaWithSidecar is an optional extension of FoundMedia implemented by the SourceVolume adapters supporting sidecar files.
+type FoundMediaWithSidecar interface {
+	// Sidecar returns the file describing the media (ex: 'IMG_001.xmp' for 'IMG_001.CR2'), or nil if there is none.
+	Sidecar() FoundMedia
+}
+
+// IsSidecar returns true if the file is a sidecar, based on its extension.
+func IsSidecar(filename string) bool {
+	_, isSidecar := SidecarExtensions[strings.ToLower(strings.TrimPrefix(path.Ext(filename), "."))]
+	return isSidecar
+}
+
+// SidecarCandidates returns the names the sidecar of a media can have, by order of preference: 'IMG_001.CR2.xmp' then 'IMG_001.xmp'.
+func SidecarCandidates(filename string) []string {
+	stem := strings.TrimSuffix(filename, path.Ext(filename))
+
+	var candidates []string
+	for _, base := range []string{filename, stem} {
+		for extension := range SidecarExtensions {
+			candidates = append(candidates, base+"."+extension, base+"."+strings.ToUpper(extension))
+		}
+	}
+
+	return candidates
+}
+
+// SidecarIndex is used by SourceVolume adapters to pair the medias with the sidecars found in the volume.
+type SidecarIndex map[string]FoundMedia
+
+// Add indexes a sidecar file.
+func (i SidecarIndex) Add(sidecar FoundMedia) {
+	i[sidecar.MediaPath().Absolute()] = sidecar
+}
+
+// Find returns the sidecar of the media, or nil.
+func (i SidecarIndex) Find(media MediaPath) FoundMedia {
+	for _, candidate := range SidecarCandidates(media.Filename) {
+		if sidecar, found := i[path.Join(media.ParentFullPath, candidate)]; found {
+			return sidecar
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,29 @@
+package backup
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestSidecarIndex_Find(t *testing.T) {
+	rawWithExtension := NewInMemoryMedia("IMG_001.CR2.xmp", time.Now(), nil)
+	rawWithoutExtension := NewInMemoryMedia("IMG_001.xmp", time.Now(), nil)
+	upperCase := NewInMemoryMedia("IMG_002.XMP", time.Now(), nil)
+
+	index := make(SidecarIndex)
+	index.Add(rawWithoutExtension)
+	index.Add(rawWithExtension)
+	index.Add(upperCase)
+
+	assert.Equal(t, rawWithExtension, index.Find(NewInMemoryMedia("IMG_001.CR2", time.Now(), nil).MediaPath()), "it should prefer the sidecar named after the full name of the media")
+	assert.Equal(t, rawWithoutExtension, index.Find(NewInMemoryMedia("IMG_001.JPG", time.Now(), nil).MediaPath()), "it should find the sidecar named after the media without its extension")
+	assert.Equal(t, upperCase, index.Find(NewInMemoryMedia("IMG_002.NEF", time.Now(), nil).MediaPath()), "it should find a sidecar with an upper case extension")
+	assert.Nil(t, index.Find(NewInMemoryMedia("IMG_003.CR2", time.Now(), nil).MediaPath()), "it should return nil when there is no sidecar")
+}
+
+func TestIsSidecar(t *testing.T) {
+	assert.True(t, IsSidecar("/photos/IMG_001.xmp"))
+	assert.True(t, IsSidecar("/photos/IMG_001.CR2.XMP"))
+	assert.False(t, IsSidecar("/photos/IMG_001.CR2"))
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"path"
+	"strings"
 	"time"
 )
 
@@ -17,6 +18,10 @@ const (
 	OrientationLowerRight ImageOrientation = "LOWER_RIGHT"
 	OrientationUpperRight ImageOrientation = "UPPER_RIGHT"
 	OrientationLowerLeft  ImageOrientation = "LOWER_LEFT"
+
+	TimeReferenceWallClock TimeReference = ""        // TimeReferenceWallClock is the time displayed by the camera, the location of DateTime is meaningless (EXIF without offset, AVI, ...)
+	TimeReferenceInstant   TimeReference = "INSTANT" // TimeReferenceInstant is a moment recorded in UTC (MP4, MKV, ...), the wall-clock of the camera is unknown
+	TimeReferenceZoned     TimeReference = "ZONED"   // TimeReferenceZoned is when both the moment and the wall-clock of the camera are known
 )
 
 type SourceVolume interface {
@@ -30,6 +35,9 @@ type MediaType string
 // ImageOrientation is teh start point of stored data
 type ImageOrientation string
 
+// TimeReference is how the DateTime read from the media must be interpreted.
+type TimeReference string
+
 // MediaPath is a breakdown of an absolute path, or URL, agnostic of its origin.
 type MediaPath struct {
 	ParentFullPath string // ParentFullPath is the absolute path of the media folder (URL = ParentFullPath + Filename)
@@ -80,17 +88,40 @@ type ClosableFoundMedia interface {
 
 type MediaDetails struct {
 	Width, Height             int
-	DateTime                  time.Time
+	DateTime                  time.Time     // DateTime is when the media has been captured, its location is only relevant when TimeReference is ZONED
+	TimeReference             TimeReference // TimeReference is set to ZONED during the analysis, by deducing the time zone when the parser couldn't read it
 	Orientation               ImageOrientation
 	Make                      string
 	Model                     string
 	GPSLatitude, GPSLongitude float64
-	Duration                  int64  // Duration is the length, in milliseconds, of a video
-	VideoEncoding             string // VideoEncoding is the codec used to encode the video (ex: 'H264')
+	GPSInferred               bool     // GPSInferred is true when the coordinates have been deduced from the capture time (ex: from a GPX track)
+	Duration                  int64    // Duration is the length, in milliseconds, of a video
+	VideoEncoding             string   // VideoEncoding is the codec used to encode the video (ex: 'H264')
+	Rating                    int      // Rating is from 1 to 5 stars, -1 when the media has been rejected, 0 when not rated (ex: from a XMP sidecar)
+	Keywords                  []string // Keywords are tags given by the user in a photo editor (ex: from a XMP sidecar)
+	LivePhotoId               string   // LivePhotoId is shared by the photo and the video of an Apple Live Photo (ContentIdentifier)
+	BurstId                   string   // BurstId is shared by the photos captured in the same burst (Apple BurstUUID)
 }
 
 func (s *MediaDetails) String() string {
-	return fmt.Sprintf("[Width=%d,Height=%d,DateTime=%s,Orientation=%s,Make=%s,Model=%s,GPSLatitude=%f,GPSLongitude=%f,Duration=%d,VideoEncoding=%s]", s.Width, s.Height, s.DateTime, s.Orientation, s.Make, s.Model, s.GPSLatitude, s.GPSLongitude, s.Duration, s.VideoEncoding)
+	return fmt.Sprintf("[Width=%d,Height=%d,DateTime=%s,TimeReference=%s,Orientation=%s,Make=%s,Model=%s,GPSLatitude=%f,GPSLongitude=%f,GPSInferred=%t,Duration=%d,VideoEncoding=%s,Rating=%d,Keywords=%s,LivePhotoId=%s,BurstId=%s]", s.Width, s.Height, s.DateTime, s.TimeReference, s.Orientation, s.Make, s.Model, s.GPSLatitude, s.GPSLongitude, s.GPSInferred, s.Duration, s.VideoEncoding, s.Rating, strings.Join(s.Keywords, ","), s.LivePhotoId, s.BurstId)
+}
+
+// LocalDateTime is the wall-clock of the camera when the media has been captured, with a UTC location: it's comparable with the dates of the albums.
+func (s *MediaDetails) LocalDateTime() time.Time {
+	return time.Date(s.DateTime.Year(), s.DateTime.Month(), s.DateTime.Day(), s.DateTime.Hour(), s.DateTime.Minute(), s.DateTime.Second(), s.DateTime.Nanosecond(), time.UTC)
+}
+
+// TimeZone is the name of the zone of DateTime, or its offset (ex: '+02:00') when the zone has no name.
+func (s *MediaDetails) TimeZone() string {
+	if s.TimeReference != TimeReferenceZoned {
+		return ""
+	}
+
+	if location := s.DateTime.Location().String(); location != "" && location != "Local" {
+		return location
+	}
+	return s.DateTime.Format("-07:00")
 }
 
 // FullMediaSignature is the business key of the media, unique per user
@@ -108,6 +139,13 @@ type PostAnalyseFilter interface {
 	AcceptAnalysedMedia(media *AnalysedMedia, folderName string) bool
 }
 
+// PostAnalyseFilterWithReason is an optional extension of PostAnalyseFilter used to report why a media has been filtered out.
+type PostAnalyseFilterWithReason interface {
+	PostAnalyseFilter
+	// FilterOutReason returns why the media must not be backed up, or an empty string if it must be.
+	FilterOutReason(media *AnalysedMedia, folderName string) string
+}
+
 func byteCountIEC(b int64) string {
 	const unit = 1024
 	if b < unit {
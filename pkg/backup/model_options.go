@@ -1,14 +1,28 @@
 package backup
 
+import (
+	"github.com/thomasduchatelle/dphoto/pkg/backup/chain"
+	"time"
+)
+
 type Options struct {
 	RestrictedAlbumFolderName map[string]interface{} // RestrictedAlbumFolderName will restrict the media to only back up medias that are in one of these albums
 	Listener                  interface{}            // Listener will receive progress events.
 	SkipRejects               bool                   // SkipRejects mode will report any analysis error, or missing timestamp, and continue.
-	AnalyserDecorator         AnalyserDecorator      // AnalyserDecorator is an optional decorator to add concept like caching (might be nil)
+	AnalyserDecorator         AnalyserDecorator      // AnalyserDecorator is an optional decorator to add concept like caching (might be nil) ; decorators given later are wrapping the previous ones
 	ConcurrencyParameters     ConcurrencyParameters
-	BatchSize                 int    // BatchSize is the number of items to read from the database at once (used by analyser) ; default to the maximum DynamoDB can handle
-	RejectDir                 string // RejectDir is the directory where rejected files will be copied
-	ChannelSize               int    // ChannelSize is a hint of the size of the channels to use. Default is set in the `chain` package (2048).
+	BatchSize                 int                        // BatchSize is the number of items to read from the database at once (used by analyser) ; default to the maximum DynamoDB can handle
+	RejectDir                 string                     // RejectDir is the directory where rejected files will be copied
+	ChannelSize               int                        // ChannelSize is a hint of the size of the channels to use. Default is set in the `chain` package (2048).
+	EventClustering           *EventClusteringParameters // EventClustering groups scanned medias by events (time gaps and distance) instead of by folder when set
+	PostAnalyseFilters        []PostAnalyseFilter        // PostAnalyseFilters are user defined rules ; medias must be accepted by all of them to be backed up
+	DetailedReport            *DetailedReport            // DetailedReport is filled with every skipped media and the timings of each stage when set
+	RecordPlan                *Plan                      // RecordPlan is filled, during a scan, with the medias that would be backed up
+	Bandwidth                 *BandwidthParameters       // Bandwidth limits the upload rate when set
+	RetryPolicy               *chain.RetryPolicy         // RetryPolicy overrides how transient errors from the catalog and the archive are retried
+	Instrumentation           chain.Instrumentation      // Instrumentation measures the throughput, the queues, and the latency of each stage when set
+	QuarantineFile            string                     // QuarantineFile lists the medias still failing after the retries ; the rest of the volume is backed up instead of stopping at the first failure
+	DefaultTimeZone           *time.Location             // DefaultTimeZone is used for medias without time zone nor GPS coordinates ; default to UTC
 }
 
 func ReduceOptions(requestedOptions ...Options) Options {
@@ -24,13 +38,48 @@ func ReduceOptions(requestedOptions ...Options) Options {
 			aggregated.Listener = original.Listener
 		}
 
-		if original.AnalyserDecorator != nil {
+		if original.AnalyserDecorator != nil && aggregated.AnalyserDecorator != nil {
+			aggregated.AnalyserDecorator = AnalyserDecorators{aggregated.AnalyserDecorator, original.AnalyserDecorator}
+		} else if original.AnalyserDecorator != nil {
 			aggregated.AnalyserDecorator = original.AnalyserDecorator
 		}
 
+		if original.EventClustering != nil {
+			aggregated.EventClustering = original.EventClustering
+		}
+
+		if original.DetailedReport != nil {
+			aggregated.DetailedReport = original.DetailedReport
+		}
+
+		if original.RecordPlan != nil {
+			aggregated.RecordPlan = original.RecordPlan
+		}
+
+		if original.Bandwidth != nil {
+			aggregated.Bandwidth = original.Bandwidth
+		}
+
+		if original.RetryPolicy != nil {
+			aggregated.RetryPolicy = original.RetryPolicy
+		}
+
+		if original.DefaultTimeZone != nil {
+			aggregated.DefaultTimeZone = original.DefaultTimeZone
+		}
+
+		if original.Instrumentation != nil && aggregated.Instrumentation != nil {
+			aggregated.Instrumentation = chain.Instrumentations{aggregated.Instrumentation, original.Instrumentation}
+		} else if original.Instrumentation != nil {
+			aggregated.Instrumentation = original.Instrumentation
+		}
+
+		aggregated.PostAnalyseFilters = append(aggregated.PostAnalyseFilters, original.PostAnalyseFilters...)
+
 		aggregated.SkipRejects = aggregated.SkipRejects || original.SkipRejects
 
 		aggregated.RejectDir = mergeStringOption(aggregated.RejectDir, original.RejectDir)
+		aggregated.QuarantineFile = mergeStringOption(aggregated.QuarantineFile, original.QuarantineFile)
 		aggregated.ConcurrencyParameters.ConcurrentAnalyserRoutines = mergeIntOption(aggregated.ConcurrencyParameters.ConcurrentAnalyserRoutines, original.ConcurrencyParameters.ConcurrentAnalyserRoutines)
 		aggregated.ConcurrencyParameters.ConcurrentCataloguerRoutines = mergeIntOption(aggregated.ConcurrencyParameters.ConcurrentCataloguerRoutines, original.ConcurrencyParameters.ConcurrentCataloguerRoutines)
 		aggregated.ConcurrencyParameters.ConcurrentUploaderRoutines = mergeIntOption(aggregated.ConcurrencyParameters.ConcurrentUploaderRoutines, original.ConcurrencyParameters.ConcurrentUploaderRoutines)
@@ -94,13 +143,105 @@ func OptionsSkipRejects(skip bool) Options {
 	}
 }
 
-// OptionsAnalyserDecorator adds a decorator on analysis function ; argument can be nil. Used to add a cache.
+// OptionsAnalyserDecorator adds a decorator on analysis function ; argument can be nil. Used to add a cache, or to complete the details of the medias.
 func OptionsAnalyserDecorator(analyserDecorator AnalyserDecorator) Options {
 	return Options{
 		AnalyserDecorator: analyserDecorator,
 	}
 }
 
+// OptionsGroupByEvents makes the scan suggest one album per event (trip, party, ...) instead of one per folder.
+func OptionsGroupByEvents(parameters EventClusteringParameters) Options {
+	return Options{
+		EventClustering: &parameters,
+	}
+}
+
+// OptionsWithPostAnalyseFilters filters out the medias not accepted by one of the filters (nil filters are ignored).
+func OptionsWithPostAnalyseFilters(filters ...PostAnalyseFilter) Options {
+	options := Options{}
+	for _, filter := range filters {
+		if filter != nil {
+			options.PostAnalyseFilters = append(options.PostAnalyseFilters, filter)
+		}
+	}
+
+	return options
+}
+
+// OptionsWithDetailedReport collects the skipped medias and the timings of each stage into the report.
+func OptionsWithDetailedReport(report *DetailedReport) Options {
+	return Options{
+		DetailedReport:  report,
+		Instrumentation: report,
+	}
+}
+
+// OptionsRecordPlan records in the plan the medias the scan would back up ; the plan can be executed later with PlanBackup.
+func OptionsRecordPlan(plan *Plan) Options {
+	return Options{
+		RecordPlan: plan,
+	}
+}
+
+// OptionsWithBandwidth limits the upload rate of all the uploader routines together, and of each of them.
+func OptionsWithBandwidth(parameters BandwidthParameters) Options {
+	return Options{
+		Bandwidth: &parameters,
+	}
+}
+
+// OptionsWithRetryPolicy overrides the default retries of transient errors (throttling, 5xx, network errors).
+func OptionsWithRetryPolicy(policy chain.RetryPolicy) Options {
+	return Options{
+		RetryPolicy: &policy,
+	}
+}
+
+// OptionsWithQuarantineFile lists in the file the medias still failing after the retries, and continues with the rest of the volume.
+func OptionsWithQuarantineFile(file string) Options {
+	return Options{
+		QuarantineFile: file,
+	}
+}
+
+// OptionsWithInstrumentation measures each stage of the chain ; several instrumentations can be given.
+func OptionsWithInstrumentation(instrumentations ...chain.Instrumentation) Options {
+	switch len(instrumentations) {
+	case 0:
+		return Options{}
+	case 1:
+		return Options{Instrumentation: instrumentations[0]}
+	default:
+		return Options{Instrumentation: chain.Instrumentations(instrumentations)}
+	}
+}
+
+// OptionsWithDefaultTimeZone sets the zone in which the camera was when it can't be deduced from the media (usually the zone of the owner's home).
+func OptionsWithDefaultTimeZone(zone *time.Location) Options {
+	return Options{
+		DefaultTimeZone: zone,
+	}
+}
+
+// GetDefaultTimeZone is returning the DefaultTimeZone, or UTC.
+func (o Options) GetDefaultTimeZone() *time.Location {
+	if o.DefaultTimeZone != nil {
+		return o.DefaultTimeZone
+	}
+
+	return time.UTC
+}
+
+// GetRetryPolicy is returning the RetryPolicy, or the default one.
+func (o Options) GetRetryPolicy() chain.RetryPolicy {
+	if o.RetryPolicy != nil {
+		return *o.RetryPolicy
+	}
+
+	return chain.RetryPolicy{}
+}
+
 // GetAnalyserDecorator is returning the AnalyserDecorator or NopeAnalyserDecorator, never nil.
 func (o Options) GetAnalyserDecorator() AnalyserDecorator {
 	if o.AnalyserDecorator != nil {
@@ -169,6 +310,17 @@ func (n *NopeAnalyserDecorator) Decorate(analyseFunc Analyser, observers ...Anal
 	return analyseFunc
 }
 
+// AnalyserDecorators applies each decorator in order: the first one is the closest to the original Analyser.
+type AnalyserDecorators []AnalyserDecorator
+
+func (d AnalyserDecorators) Decorate(analyser Analyser, observers ...AnalyserDecoratorObserver) Analyser {
+	for _, decorator := range d {
+		analyser = decorator.Decorate(analyser, observers...)
+	}
+
+	return analyser
+}
+
 func mergeIntOption(current, value int) int {
 	if current > 0 {
 		return current
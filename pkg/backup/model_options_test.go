@@ -72,6 +72,16 @@ func TestOption_ReduceOptions(t *testing.T) {
 				ChannelSize:               3,
 			},
 		},
+		{
+			name: "it should chain the analyser decorators in the given order",
+			args: args{
+				option: ReduceOptions(OptionsAnalyserDecorator(new(NopeAnalyserDecorator)), OptionsAnalyserDecorator(nil), OptionsAnalyserDecorator(&plannedAnalyser{})),
+			},
+			want: Options{
+				RestrictedAlbumFolderName: noRestrictedAlbumFolder,
+				AnalyserDecorator:         AnalyserDecorators{new(NopeAnalyserDecorator), &plannedAnalyser{}},
+			},
+		},
 	}
 
 	for _, tt := range tests {
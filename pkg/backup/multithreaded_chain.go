@@ -3,6 +3,12 @@ package backup
 import (
 	"context"
 	"github.com/thomasduchatelle/dphoto/pkg/backup/chain"
+	"time"
+)
+
+const (
+	linkBatchAnalysed   = "batch-analysed"   // linkBatchAnalysed is the link grouping analysed medias before cataloguing them
+	linkBatchCatalogued = "batch-catalogued" // linkBatchCatalogued is the link grouping catalogued medias before uploading them
 )
 
 type scanCompleteObserver interface {
@@ -16,7 +22,11 @@ type analyserLauncher interface {
 func scanAndBackupCommonLauncher(config *scanConfiguration, options Options, next chain.Link[[]BackingUpMediaRequest]) *chain.SingleLauncher[SourceVolume, FoundMedia] {
 	return &chain.SingleLauncher[SourceVolume, FoundMedia]{
 		Function: func(ctx context.Context, volume SourceVolume) ([]FoundMedia, error) {
+			start := time.Now()
 			medias, err := volume.FindMedias(ctx)
+			if options.Instrumentation != nil {
+				options.Instrumentation.OnConsumed(ctx, chain.Measure{Link: string(StageFind), Items: len(medias), Start: start, End: time.Now(), Err: err})
+			}
 			if err != nil || config.ScanCompleteObserver == nil {
 				return medias, err
 			}
@@ -24,21 +34,28 @@ func scanAndBackupCommonLauncher(config *scanConfiguration, options Options, nex
 			return medias, config.ScanCompleteObserver.OnScanComplete(ctx, len(medias), sizeOfAllMedias(medias))
 		},
 		Next: &chain.MultithreadedLink[FoundMedia, *AnalysedMedia]{
+			Name:             string(StageAnalyse),
+			Instrumentation:  options.Instrumentation,
 			NumberOfRoutines: options.ConcurrencyParameters.NumberOfConcurrentAnalyserRoutines(),
 			ChannelSize:      options.ChannelSize,
 			Cancellable:      true,
 			ConsumerBuilder: func(consumer chain.Consumer[*AnalysedMedia]) chain.Consumer[FoundMedia] {
 				analyser := &analyserAggregate{
 					analyser:               config.Analyser,
+					timeZones:              config.TimeZones,
 					analysedMediaObservers: []AnalysedMediaObserver{AnalysedMediaObserverFunc(consumer.Consume)},
 					rejectedMediaObservers: config.PostAnalyserRejects,
 				}
 				return chain.ConsumerFunc[FoundMedia](analyser.OnFoundMedia)
 			},
 			Next: &chain.BufferLink[*AnalysedMedia]{
-				BufferCapacity: options.BatchSize,
-				ChannelSize:    options.ChannelSize,
+				Name:            linkBatchAnalysed,
+				Instrumentation: options.Instrumentation,
+				BufferCapacity:  options.BatchSize,
+				ChannelSize:     options.ChannelSize,
 				Next: &chain.MultithreadedLink[[]*AnalysedMedia, []BackingUpMediaRequest]{
+					Name:             string(StageCatalogue),
+					Instrumentation:  options.Instrumentation,
 					NumberOfRoutines: options.ConcurrencyParameters.NumberOfConcurrentCataloguerRoutines(),
 					ConsumerBuilder: func(consumer chain.Consumer[[]BackingUpMediaRequest]) chain.Consumer[[]*AnalysedMedia] {
 						adapter := &cataloguerAggregate{
@@ -48,6 +65,8 @@ func scanAndBackupCommonLauncher(config *scanConfiguration, options Options, nex
 								CataloguerFilterObservers:  config.PostCataloguerFiltersOut,
 								CataloguerFilters:          postCataloguerFiltersList(options),
 							},
+							retryPolicy: config.RetryPolicy,
+							quarantine:  config.QuarantineObservers,
 						}
 						return chain.ConsumerFunc[[]*AnalysedMedia](adapter.OnBatchOfAnalysedMedia)
 					},
@@ -0,0 +1,262 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/thomasduchatelle/dphoto/pkg/ownermodel"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	PlanVersion = 1 // PlanVersion is increased when the format of the plan is changing in a non-compatible way
+
+	planOutdatedMaxDetails = 10
+)
+
+var (
+	ErrPlanOutdated = errors.New("the plan is outdated")
+)
+
+// Plan is the list of medias a backup will upload ; it is recorded during a scan (OptionsRecordPlan) and executed by PlanBackup.
+type Plan struct {
+	lock           sync.Mutex
+	Version        int
+	Owner          string
+	Volume         string
+	CreatedAt      time.Time
+	Medias         []PlannedMedia
+	AlbumsToCreate []string // AlbumsToCreate are the albums that doesn't exist yet
+}
+
+// PlannedMedia is a media that will be backed up, with the result of its analysis.
+type PlannedMedia struct {
+	AbsolutePath     string
+	RelativePath     string
+	Size             int
+	LastModification time.Time
+	Type             MediaType
+	Sha256           string
+	Details          MediaDetails
+	AlbumFolderName  string // AlbumFolderName is the album in which the media will be
+	NewAlbum         bool   // NewAlbum is set when the album will be created by the backup
+}
+
+// PlanOutdatedError lists what changed since the plan has been made ; it matches ErrPlanOutdated.
+type PlanOutdatedError struct {
+	Changes []string
+}
+
+func (e PlanOutdatedError) Error() string {
+	details := e.Changes
+	if len(details) > planOutdatedMaxDetails {
+		details = append(slices.Clone(details[:planOutdatedMaxDetails]), fmt.Sprintf("... and %d more", len(e.Changes)-planOutdatedMaxDetails))
+	}
+
+	return fmt.Sprintf("%s, %d change(s): %s", ErrPlanOutdated.Error(), len(e.Changes), strings.Join(details, " ; "))
+}
+
+func (e PlanOutdatedError) Is(target error) bool {
+	return target == ErrPlanOutdated
+}
+
+func NewPlan(owner ownermodel.Owner, volume string) *Plan {
+	return &Plan{
+		Version:   PlanVersion,
+		Owner:     owner.Value(),
+		Volume:    volume,
+		CreatedAt: time.Now(),
+	}
+}
+
+// OnMediaCatalogued records the medias that passed all the filters.
+func (p *Plan) OnMediaCatalogued(ctx context.Context, requests []BackingUpMediaRequest) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for _, request := range requests {
+		mediaPath := request.AnalysedMedia.FoundMedia.MediaPath()
+		planned := PlannedMedia{
+			AbsolutePath:     mediaPath.Absolute(),
+			RelativePath:     path.Join(mediaPath.Path, mediaPath.Filename),
+			Size:             request.AnalysedMedia.FoundMedia.Size(),
+			LastModification: request.AnalysedMedia.FoundMedia.LastModification(),
+			Type:             request.AnalysedMedia.Type,
+			Sha256:           request.AnalysedMedia.Sha256Hash,
+			AlbumFolderName:  request.CatalogReference.AlbumFolderName(),
+			NewAlbum:         request.CatalogReference.AlbumCreated(),
+		}
+		if request.AnalysedMedia.Details != nil {
+			planned.Details = *request.AnalysedMedia.Details
+		}
+		p.Medias = append(p.Medias, planned)
+
+		if planned.NewAlbum && !slices.Contains(p.AlbumsToCreate, planned.AlbumFolderName) {
+			p.AlbumsToCreate = append(p.AlbumsToCreate, planned.AlbumFolderName)
+		}
+	}
+
+	slices.Sort(p.AlbumsToCreate)
+	slices.SortFunc(p.Medias, func(a, b PlannedMedia) int {
+		return strings.Compare(a.AbsolutePath, b.AbsolutePath)
+	})
+	return nil
+}
+
+// PlanBackup executes a Plan after validating it's still accurate: files haven't changed, and they would be in the same albums.
+type PlanBackup struct {
+	BatchBackup
+	DryRunCataloguerFactory CataloguerFactory // DryRunCataloguerFactory must not have side effects: it is used to validate the plan before anything is uploaded
+}
+
+func (p *PlanBackup) Backup(ctx context.Context, owner ownermodel.Owner, volume SourceVolume, plan *Plan, optionsSlice ...Options) (Report, error) {
+	if plan.Version != PlanVersion {
+		return nil, errors.Errorf("plan version %d is not supported, expected version %d", plan.Version, PlanVersion)
+	}
+	if plan.Owner != owner.Value() {
+		return nil, errors.Errorf("plan has been made for %s, it can't be executed for %s", plan.Owner, owner)
+	}
+
+	options := ReduceOptions(optionsSlice...)
+
+	medias, err := p.validateFiles(ctx, volume, plan)
+	if err != nil {
+		return nil, err
+	}
+
+	err = p.validateAlbums(ctx, owner, medias, plan, options.GetBatchSize())
+	if err != nil {
+		return nil, err
+	}
+
+	// the planned analysis replaces the original one, other decorators are wrapping it
+	backupOptions := append([]Options{OptionsAnalyserDecorator(&plannedAnalyser{medias: medias})}, optionsSlice...)
+	return p.BatchBackup.Backup(ctx, owner, &plannedVolume{SourceVolume: volume, medias: medias}, backupOptions...)
+}
+
+// validateFiles returns the analysed medias, in the same order than in the plan, if all the files are unchanged.
+func (p *PlanBackup) validateFiles(ctx context.Context, volume SourceVolume, plan *Plan) ([]*AnalysedMedia, error) {
+	found, err := volume.FindMedias(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]FoundMedia)
+	for _, media := range found {
+		index[media.MediaPath().Absolute()] = media
+	}
+
+	var changes []string
+	medias := make([]*AnalysedMedia, 0, len(plan.Medias))
+	for _, planned := range plan.Medias {
+		media, exists := index[planned.AbsolutePath]
+		switch {
+		case !exists:
+			changes = append(changes, fmt.Sprintf("%s has been removed", planned.RelativePath))
+		case media.Size() != planned.Size:
+			changes = append(changes, fmt.Sprintf("%s size changed from %d to %d bytes", planned.RelativePath, planned.Size, media.Size()))
+		case !media.LastModification().Equal(planned.LastModification):
+			changes = append(changes, fmt.Sprintf("%s has been modified on %s", planned.RelativePath, media.LastModification().Format(time.RFC3339)))
+		default:
+			details := planned.Details
+			medias = append(medias, &AnalysedMedia{
+				FoundMedia: media,
+				Type:       planned.Type,
+				Sha256Hash: planned.Sha256,
+				Details:    &details,
+			})
+		}
+	}
+
+	if len(changes) > 0 {
+		return nil, PlanOutdatedError{Changes: changes}
+	}
+	return medias, nil
+}
+
+// validateAlbums uses the dry-run cataloguer to check that medias would still be in their planned album.
+func (p *PlanBackup) validateAlbums(ctx context.Context, owner ownermodel.Owner, medias []*AnalysedMedia, plan *Plan, batchSize int) error {
+	cataloguer, err := p.DryRunCataloguerFactory.NewOwnerScopedCataloguer(ctx, owner)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create a dry-run cataloguer for %s", owner)
+	}
+
+	references := make(map[string]CatalogReference)
+	collector := CatalogReferencerObserverFunc(func(ctx context.Context, requests []BackingUpMediaRequest) error {
+		for _, request := range requests {
+			references[request.AnalysedMedia.FoundMedia.MediaPath().Absolute()] = request.CatalogReference
+		}
+		return nil
+	})
+
+	for batch := range slices.Chunk(medias, max(batchSize, 1)) {
+		err = cataloguer.Reference(ctx, batch, collector)
+		if err != nil {
+			return errors.Wrapf(err, "failed to validate the albums of the plan")
+		}
+	}
+
+	var changes []string
+	for _, planned := range plan.Medias {
+		reference, found := references[planned.AbsolutePath]
+		switch {
+		case !found:
+			changes = append(changes, fmt.Sprintf("%s couldn't be catalogued", planned.RelativePath))
+		case planned.NewAlbum && !reference.AlbumCreated():
+			changes = append(changes, fmt.Sprintf("%s would now be in existing album %s", planned.RelativePath, reference.AlbumFolderName()))
+		case !planned.NewAlbum && reference.AlbumCreated():
+			changes = append(changes, fmt.Sprintf("%s would now be in new album %s", planned.RelativePath, reference.AlbumFolderName()))
+		case reference.AlbumFolderName() != planned.AlbumFolderName:
+			changes = append(changes, fmt.Sprintf("%s would now be in album %s instead of %s", planned.RelativePath, reference.AlbumFolderName(), planned.AlbumFolderName))
+		}
+	}
+
+	if len(changes) > 0 {
+		return PlanOutdatedError{Changes: changes}
+	}
+	return nil
+}
+
+// plannedVolume only returns the medias of the plan.
+type plannedVolume struct {
+	SourceVolume
+	medias []*AnalysedMedia
+}
+
+func (v *plannedVolume) FindMedias(ctx context.Context) ([]FoundMedia, error) {
+	found := make([]FoundMedia, len(v.medias))
+	for i, media := range v.medias {
+		found[i] = media.FoundMedia
+	}
+	return found, nil
+}
+
+// plannedAnalyser replaces the analysis by the one made when the plan has been recorded.
+type plannedAnalyser struct {
+	medias []*AnalysedMedia
+}
+
+func (a *plannedAnalyser) Decorate(analyser Analyser, observers ...AnalyserDecoratorObserver) Analyser {
+	index := make(map[FoundMedia]*AnalysedMedia)
+	for _, media := range a.medias {
+		index[media.FoundMedia] = media
+	}
+
+	return &plannedAnalyserIndex{index: index}
+}
+
+type plannedAnalyserIndex struct {
+	index map[FoundMedia]*AnalysedMedia
+}
+
+func (a *plannedAnalyserIndex) Analyse(ctx context.Context, found FoundMedia) (*AnalysedMedia, error) {
+	if media, planned := a.index[found]; planned {
+		return media, nil
+	}
+
+	return nil, errors.Errorf("%s is not part of the plan", found)
+}
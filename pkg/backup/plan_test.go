@@ -0,0 +1,165 @@
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/thomasduchatelle/dphoto/pkg/ownermodel"
+	"testing"
+	"time"
+)
+
+func TestPlan_recordedDuringScan(t *testing.T) {
+	owner := ownermodel.Owner("ironman")
+	lastModification := time.Date(2024, 7, 14, 10, 0, 0, 0, time.UTC)
+	newMedia := NewInMemoryMedia("folder1/file_1.jpg", lastModification, []byte("2022-06-18"))
+	existingMedia := NewInMemoryMedia("folder1/file_2.jpg", lastModification, []byte("2022-06-19AB"))
+
+	scanner := &BatchScanner{
+		CataloguerFactory: &ReferencerFactoryFake{
+			Cataloguer: &CatalogReferencerFake{
+				&AnalysedMedia{FoundMedia: newMedia}:      &CatalogReferenceStub{MediaIdValue: "media-id-1", AlbumFolderNameValue: "/2022-Q2", AlbumCreatedValue: true},
+				&AnalysedMedia{FoundMedia: existingMedia}: &CatalogReferenceStub{MediaIdValue: "media-id-2", AlbumFolderNameValue: "/album1", ExistsValue: true},
+			},
+		},
+		DetailsReaders: []DetailsReader{new(DetailsReaderAdapterStub)},
+	}
+
+	plan := NewPlan(owner, "/ram")
+	_, err := scanner.Scan(context.Background(), owner, &InMemorySourceVolume{newMedia, existingMedia}, OptionsRecordPlan(plan))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, []PlannedMedia{
+		{
+			AbsolutePath:     "/ram/folder1/file_1.jpg",
+			RelativePath:     "folder1/file_1.jpg",
+			Size:             10,
+			LastModification: lastModification,
+			Type:             MediaTypeImage,
+			Sha256:           "3e7574e8b640104d97597b200fd516c589f34be540e0a81a272fd488d12acaec",
+			Details:          MediaDetails{DateTime: time.Date(2022, 6, 18, 0, 0, 0, 0, time.UTC), TimeReference: TimeReferenceZoned},
+			AlbumFolderName:  "/2022-Q2",
+			NewAlbum:         true,
+		},
+	}, plan.Medias)
+	assert.Equal(t, []string{"/2022-Q2"}, plan.AlbumsToCreate)
+
+	content, err := json.Marshal(plan)
+	if assert.NoError(t, err) {
+		var read Plan
+		if assert.NoError(t, json.Unmarshal(content, &read)) {
+			assert.Equal(t, plan.Medias, read.Medias)
+		}
+	}
+}
+
+func TestPlanBackup_Backup(t *testing.T) {
+	owner := ownermodel.Owner("ironman")
+	lastModification := time.Date(2024, 7, 14, 10, 0, 0, 0, time.UTC)
+	media1 := NewInMemoryMedia("folder1/file_1.jpg", lastModification, []byte("2022-06-18"))
+	media2 := NewInMemoryMedia("folder1/file_2.jpg", lastModification, []byte("2022-06-19AB"))
+
+	newPlan := func() *Plan {
+		plan := NewPlan(owner, "/ram")
+		plan.Medias = []PlannedMedia{
+			{AbsolutePath: "/ram/folder1/file_1.jpg", RelativePath: "folder1/file_1.jpg", Size: 10, LastModification: lastModification, Type: MediaTypeImage, Sha256: "sha-1", Details: MediaDetails{DateTime: time.Date(2022, 6, 18, 0, 0, 0, 0, time.UTC)}, AlbumFolderName: "/album1"},
+			{AbsolutePath: "/ram/folder1/file_2.jpg", RelativePath: "folder1/file_2.jpg", Size: 12, LastModification: lastModification, Type: MediaTypeImage, Sha256: "sha-2", Details: MediaDetails{DateTime: time.Date(2022, 6, 19, 0, 0, 0, 0, time.UTC)}, AlbumFolderName: "/2022-Q2", NewAlbum: true},
+		}
+		return plan
+	}
+	references := CatalogReferencerFake{
+		&AnalysedMedia{FoundMedia: media1}: &CatalogReferenceStub{MediaIdValue: "media-id-1", AlbumFolderNameValue: "/album1"},
+		&AnalysedMedia{FoundMedia: media2}: &CatalogReferenceStub{MediaIdValue: "media-id-2", AlbumFolderNameValue: "/2022-Q2", AlbumCreatedValue: true},
+	}
+
+	tests := []struct {
+		name         string
+		volume       SourceVolume
+		dryRun       CatalogReferencerFake
+		owner        ownermodel.Owner
+		wantUploaded []string
+		wantErr      error
+	}{
+		{
+			name:         "it should backup the medias of the plan without analysing them again",
+			volume:       &InMemorySourceVolume{media1, media2, NewInMemoryMedia("folder1/file_3.jpg", lastModification, []byte("2022-06-20ABC"))},
+			dryRun:       references,
+			owner:        owner,
+			wantUploaded: []string{"file_1.jpg", "file_2.jpg"},
+		},
+		{
+			name:    "it should refuse the plan if a file has been modified",
+			volume:  &InMemorySourceVolume{media1, NewInMemoryMedia("folder1/file_2.jpg", lastModification.Add(time.Hour), []byte("2022-06-19AB"))},
+			dryRun:  references,
+			owner:   owner,
+			wantErr: ErrPlanOutdated,
+		},
+		{
+			name:    "it should refuse the plan if a file has been removed",
+			volume:  &InMemorySourceVolume{media1},
+			dryRun:  references,
+			owner:   owner,
+			wantErr: ErrPlanOutdated,
+		},
+		{
+			name:   "it should refuse the plan if a media would be in a different album",
+			volume: &InMemorySourceVolume{media1, media2},
+			dryRun: CatalogReferencerFake{
+				&AnalysedMedia{FoundMedia: media1}: &CatalogReferenceStub{MediaIdValue: "media-id-1", AlbumFolderNameValue: "/album2"},
+				&AnalysedMedia{FoundMedia: media2}: &CatalogReferenceStub{MediaIdValue: "media-id-2", AlbumFolderNameValue: "/2022-Q2", AlbumCreatedValue: true},
+			},
+			owner:   owner,
+			wantErr: ErrPlanOutdated,
+		},
+		{
+			name:   "it should refuse the plan if the album has been created since",
+			volume: &InMemorySourceVolume{media1, media2},
+			dryRun: CatalogReferencerFake{
+				&AnalysedMedia{FoundMedia: media1}: &CatalogReferenceStub{MediaIdValue: "media-id-1", AlbumFolderNameValue: "/album1"},
+				&AnalysedMedia{FoundMedia: media2}: &CatalogReferenceStub{MediaIdValue: "media-id-2", AlbumFolderNameValue: "/album3"},
+			},
+			owner:   owner,
+			wantErr: ErrPlanOutdated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			archive := newArchiveMediaPortFake()
+			planBackup := &PlanBackup{
+				BatchBackup: BatchBackup{
+					CataloguerFactory: &ReferencerFactoryFake{Cataloguer: references},
+					InsertMediaPort:   newInsertMediaPortFake(),
+					ArchivePort:       archive,
+				},
+				DryRunCataloguerFactory: &ReferencerFactoryFake{Cataloguer: tt.dryRun},
+			}
+
+			_, err := planBackup.Backup(context.Background(), tt.owner, tt.volume, newPlan())
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Empty(t, archive.got)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			var uploaded []string
+			for _, request := range archive.got[tt.owner] {
+				uploaded = append(uploaded, request.AnalysedMedia.FoundMedia.MediaPath().Filename)
+				assert.NotEmpty(t, request.AnalysedMedia.Sha256Hash)
+			}
+			assert.ElementsMatch(t, tt.wantUploaded, uploaded)
+		})
+	}
+
+	t.Run("it should refuse a plan made for another owner", func(t *testing.T) {
+		planBackup := &PlanBackup{DryRunCataloguerFactory: &ReferencerFactoryFake{Cataloguer: references}}
+
+		_, err := planBackup.Backup(context.Background(), "pepper", &InMemorySourceVolume{media1, media2}, newPlan())
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,178 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/thomasduchatelle/dphoto/pkg/backup/chain"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	StageFind      ReportStage = "find"      // StageFind is the listing of the medias on the volume
+	StageAnalyse   ReportStage = "analyse"   // StageAnalyse is the extraction of the details and the hash of each media
+	StageCatalogue ReportStage = "catalogue" // StageCatalogue is finding (or creating) the album of each media
+	StageUpload    ReportStage = "upload"    // StageUpload is archiving the medias and indexing them in the catalog
+
+	SkipReasonAnalysisError      SkipReason = "analysis-error"       // SkipReasonAnalysisError is used when the media couldn't be read, or has no date
+	SkipReasonWrongAlbum         SkipReason = "wrong-album"          // SkipReasonWrongAlbum is used when the backup is restricted to other albums
+	SkipReasonAlreadyExists      SkipReason = "already-exists"       // SkipReasonAlreadyExists is used when the media is already in the catalog
+	SkipReasonDuplicatedInVolume SkipReason = "duplicated-in-volume" // SkipReasonDuplicatedInVolume is used when the same media has been found earlier in the volume
+	SkipReasonFilteredOutByRule  SkipReason = "filtered-out-by-rule" // SkipReasonFilteredOutByRule is used when the media is not accepted by a PostAnalyseFilter
+	SkipReasonQuarantined        SkipReason = "quarantined"          // SkipReasonQuarantined is used when the media still failed to be catalogued or uploaded after the retries
+	SkipReasonOther              SkipReason = "other"
+)
+
+var stagesOrder = []ReportStage{StageFind, StageAnalyse, StageCatalogue, StageUpload}
+
+type ReportStage string
+
+type SkipReason string
+
+// SkippedMedia is a media that has not been backed up.
+type SkippedMedia struct {
+	Path    string     // Path is the absolute path of the media on the volume
+	Size    int        // Size is in bytes
+	Reason  SkipReason // Reason is the category
+	Details string     // Details is the cause of the rejection, for a human
+	Album   string     // Album is the folder name of the album the media would have been in ; empty if the analysis failed
+}
+
+// StageTiming is the time spent on one stage of the scan or backup.
+type StageTiming struct {
+	Stage      ReportStage
+	Count      int           // Count is the number of medias processed by the stage
+	Busy       time.Duration // Busy is the sum of the time spent by all the routines of the stage
+	Start, End time.Time     // Start and End are the first time the stage has been used, and the last time it completed
+}
+
+// Elapsed is the wall-clock time during which the stage was active.
+func (s StageTiming) Elapsed() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// DetailedReport is collecting every skipped media and the timings of each stage ; it is filled when given with OptionsWithDetailedReport.
+// Timings are built from the same measures as the other chain.Instrumentation, like the profile report.
+type DetailedReport struct {
+	lock    sync.Mutex
+	skipped []SkippedMedia
+	stages  map[ReportStage]*StageTiming
+}
+
+func NewDetailedReport() *DetailedReport {
+	return &DetailedReport{
+		stages: make(map[ReportStage]*StageTiming),
+	}
+}
+
+// SkippedMedias are sorted by path.
+func (r *DetailedReport) SkippedMedias() []SkippedMedia {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	skipped := slices.Clone(r.skipped)
+	slices.SortFunc(skipped, func(a, b SkippedMedia) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return skipped
+}
+
+// Timings are in the order of the chain ; stages not used are omitted.
+func (r *DetailedReport) Timings() []StageTiming {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var timings []StageTiming
+	for _, stage := range stagesOrder {
+		if timing, found := r.stages[stage]; found {
+			timings = append(timings, *timing)
+		}
+	}
+	return timings
+}
+
+func (r *DetailedReport) OnRejectedMedia(ctx context.Context, found FoundMedia, cause error) error {
+	r.pushSkipped(SkippedMedia{
+		Path:    found.MediaPath().Absolute(),
+		Size:    found.Size(),
+		Reason:  SkipReasonAnalysisError,
+		Details: cause.Error(),
+	})
+	return nil
+}
+
+func (r *DetailedReport) OnFilteredOut(ctx context.Context, media AnalysedMedia, reference CatalogReference, cause error) error {
+	r.pushSkipped(SkippedMedia{
+		Path:    media.FoundMedia.MediaPath().Absolute(),
+		Size:    media.FoundMedia.Size(),
+		Reason:  skipReasonOf(cause),
+		Details: cause.Error(),
+		Album:   reference.AlbumFolderName(),
+	})
+	return nil
+}
+
+func (r *DetailedReport) OnQuarantinedMedia(ctx context.Context, stage ReportStage, media AnalysedMedia, cause error) error {
+	r.pushSkipped(SkippedMedia{
+		Path:    media.FoundMedia.MediaPath().Absolute(),
+		Size:    media.FoundMedia.Size(),
+		Reason:  SkipReasonQuarantined,
+		Details: fmt.Sprintf("%s: %s", stage, cause),
+	})
+	return nil
+}
+
+func (r *DetailedReport) pushSkipped(skipped SkippedMedia) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.skipped = append(r.skipped, skipped)
+}
+
+// OnQueued implements chain.Instrumentation ; the queues are not reported.
+func (r *DetailedReport) OnQueued(link string, depth, capacity int) {
+}
+
+// OnConsumed implements chain.Instrumentation: the measures of the links named after a stage are added to its timing.
+func (r *DetailedReport) OnConsumed(ctx context.Context, measure chain.Measure) {
+	stage := ReportStage(measure.Link)
+	if !slices.Contains(stagesOrder, stage) {
+		return
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	timing, found := r.stages[stage]
+	if !found {
+		timing = &StageTiming{Stage: stage, Start: measure.Start, End: measure.End}
+		r.stages[stage] = timing
+	}
+
+	timing.Count += measure.Items
+	timing.Busy += measure.Latency()
+	if measure.Start.Before(timing.Start) {
+		timing.Start = measure.Start
+	}
+	if measure.End.After(timing.End) {
+		timing.End = measure.End
+	}
+}
+
+func skipReasonOf(cause error) SkipReason {
+	switch {
+	case errors.Is(cause, ErrCatalogerFilterMustBeInAlbum):
+		return SkipReasonWrongAlbum
+	case errors.Is(cause, ErrCatalogerFilterMustNotAlreadyExists):
+		return SkipReasonAlreadyExists
+	case errors.Is(cause, ErrMediaMustNotBeDuplicated):
+		return SkipReasonDuplicatedInVolume
+	case errors.Is(cause, ErrMediaFilteredOutByRule):
+		return SkipReasonFilteredOutByRule
+	default:
+		return SkipReasonOther
+	}
+}
@@ -0,0 +1,64 @@
+package backup
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/thomasduchatelle/dphoto/pkg/backup/chain"
+	"github.com/thomasduchatelle/dphoto/pkg/ownermodel"
+	"testing"
+	"time"
+)
+
+func TestDetailedReport_Backup(t *testing.T) {
+	newMedia := NewInMemoryMedia("folder1/file_1.jpg", time.Now(), []byte("2022-06-18"))
+	existingMedia := NewInMemoryMedia("folder1/file_2.jpg", time.Now(), []byte("2022-06-19AB"))
+	noDateMedia := NewInMemoryMedia("folder2/file_3.jpg", time.Now(), []byte("no date"))
+
+	batch := &BatchBackup{
+		CataloguerFactory: &ReferencerFactoryFake{
+			Cataloguer: &CatalogReferencerFake{
+				&AnalysedMedia{FoundMedia: newMedia}:      &CatalogReferenceStub{MediaIdValue: "media-id-1", AlbumFolderNameValue: "/album1"},
+				&AnalysedMedia{FoundMedia: existingMedia}: &CatalogReferenceStub{MediaIdValue: "media-id-2", AlbumFolderNameValue: "/album1", ExistsValue: true},
+			},
+		},
+		DetailsReaders:  []DetailsReader{new(DetailsReaderAdapterStub)},
+		InsertMediaPort: newInsertMediaPortFake(),
+		ArchivePort:     newArchiveMediaPortFake(),
+	}
+
+	report := NewDetailedReport()
+	_, err := batch.Backup(context.Background(), ownermodel.Owner("ironman"), &InMemorySourceVolume{newMedia, existingMedia, noDateMedia}, OptionsSkipRejects(true), OptionsWithDetailedReport(report))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, []SkippedMedia{
+		{Path: "/ram/folder1/file_2.jpg", Size: 12, Reason: SkipReasonAlreadyExists, Details: ErrCatalogerFilterMustNotAlreadyExists.Error(), Album: "/album1"},
+		{Path: "/ram/folder2/file_3.jpg", Size: 7, Reason: SkipReasonAnalysisError, Details: ErrAnalyserNoDateTime.Error()},
+	}, report.SkippedMedias())
+
+	var stages []ReportStage
+	counts := make(map[ReportStage]int)
+	for _, timing := range report.Timings() {
+		stages = append(stages, timing.Stage)
+		counts[timing.Stage] = timing.Count
+		assert.False(t, timing.End.Before(timing.Start), "stage %s must end after it started", timing.Stage)
+	}
+	assert.Equal(t, []ReportStage{StageFind, StageAnalyse, StageCatalogue, StageUpload}, stages)
+	assert.Equal(t, map[ReportStage]int{StageFind: 3, StageAnalyse: 3, StageCatalogue: 2, StageUpload: 1}, counts)
+}
+
+func TestDetailedReport_OnConsumed(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+
+	report := NewDetailedReport()
+	report.OnConsumed(ctx, chain.Measure{Link: string(StageAnalyse), Items: 1, Start: start.Add(time.Second), End: start.Add(3 * time.Second), Blocked: time.Second})
+	report.OnConsumed(ctx, chain.Measure{Link: string(StageAnalyse), Items: 1, Start: start, End: start.Add(2 * time.Second)})
+	report.OnConsumed(ctx, chain.Measure{Link: linkBatchAnalysed, Items: 2, Start: start, End: start.Add(5 * time.Second)})
+
+	assert.Equal(t, []StageTiming{
+		{Stage: StageAnalyse, Count: 2, Busy: 3 * time.Second, Start: start, End: start.Add(3 * time.Second)},
+	}, report.Timings(), "it should exclude the time blocked by the next link, and ignore the links that are not a stage")
+	assert.Equal(t, 3*time.Second, report.Timings()[0].Elapsed())
+}
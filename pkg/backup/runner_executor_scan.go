@@ -2,19 +2,28 @@ package backup
 
 import (
 	"context"
+	log "github.com/sirupsen/logrus"
 	"slices"
 	"sync"
+	"unicode"
 )
 
-func newScanReportBuilder() *scanReportBuilder {
+func newScanReportBuilder(options Options, placeFinder PlaceFinder) *scanReportBuilder {
 	return &scanReportBuilder{
-		albums: make(map[string]*ScannedFolder),
+		albums:          make(map[string]*ScannedFolder),
+		places:          make(map[string]placeCounter),
+		eventClustering: options.EventClustering,
+		placeFinder:     placeFinder,
 	}
 }
 
 type scanReportBuilder struct {
-	lock   sync.Mutex
-	albums map[string]*ScannedFolder
+	lock            sync.Mutex
+	albums          map[string]*ScannedFolder
+	places          map[string]placeCounter    // places are the cities where the medias of each folder have been captured
+	eventClustering *EventClusteringParameters // eventClustering is set to group medias by events instead of by folder
+	placeFinder     PlaceFinder                // placeFinder is optional
+	points          []scannedPoint
 }
 
 func (s *scanReportBuilder) OnMediaCatalogued(ctx context.Context, requests []BackingUpMediaRequest) error {
@@ -23,12 +32,47 @@ func (s *scanReportBuilder) OnMediaCatalogued(ctx context.Context, requests []Ba
 
 	for _, request := range requests {
 		scannedFolder := s.getOrCreateScannedFolder(request.AnalysedMedia.FoundMedia)
-		scannedFolder.PushBoundaries(request.AnalysedMedia.Details.DateTime, request.AnalysedMedia.FoundMedia.Size())
+		scannedFolder.PushBoundaries(request.AnalysedMedia.Details.LocalDateTime(), request.AnalysedMedia.FoundMedia.Size())
+
+		city := s.findCity(request.AnalysedMedia.Details)
+		if city != "" {
+			mediaPath := request.AnalysedMedia.FoundMedia.MediaPath()
+			if _, exists := s.places[mediaPath.Path]; !exists {
+				s.places[mediaPath.Path] = make(placeCounter)
+			}
+			s.places[mediaPath.Path].push(city)
+		}
+
+		if s.eventClustering != nil {
+			s.points = append(s.points, scannedPoint{
+				mediaPath: request.AnalysedMedia.FoundMedia.MediaPath(),
+				dateTime:  request.AnalysedMedia.Details.LocalDateTime(),
+				size:      request.AnalysedMedia.FoundMedia.Size(),
+				latitude:  request.AnalysedMedia.Details.GPSLatitude,
+				longitude: request.AnalysedMedia.Details.GPSLongitude,
+				city:      city,
+			})
+		}
 	}
 
 	return nil
 }
 
+// findCity returns an empty string when the media has no GPS coordinates, or when its place is unknown.
+func (s *scanReportBuilder) findCity(details *MediaDetails) string {
+	if s.placeFinder == nil || (details.GPSLatitude == 0 && details.GPSLongitude == 0) {
+		return ""
+	}
+
+	city, err := s.placeFinder.FindCity(details.GPSLatitude, details.GPSLongitude)
+	if err != nil {
+		log.WithError(err).Warnf("City couldn't be found at %f, %f ; it is not used to suggest the album name.", details.GPSLatitude, details.GPSLongitude)
+		return ""
+	}
+
+	return city
+}
+
 func (s *scanReportBuilder) OnRejectedMedia(ctx context.Context, found FoundMedia, cause error) error {
 	s.lock.Lock()
 	defer s.lock.Unlock()
@@ -50,8 +94,15 @@ func (s *scanReportBuilder) getOrCreateScannedFolder(foundMedia FoundMedia) *Sca
 
 func (s *scanReportBuilder) build() []*ScannedFolder {
 	suggestions := make([]*ScannedFolder, 0, len(s.albums))
-	for _, album := range s.albums {
-		suggestions = append(suggestions, album)
+	if s.eventClustering != nil {
+		suggestions = append(suggestions, clusterEvents(*s.eventClustering, s.points)...)
+		suggestions = append(suggestions, s.foldersWithOnlyRejects()...)
+	} else {
+		for relativePath, album := range s.albums {
+			album.Place = s.places[relativePath].dominant()
+			album.Name = withPlace(album.Name, album.Place)
+			suggestions = append(suggestions, album)
+		}
 	}
 
 	slices.SortFunc(suggestions, func(i, j *ScannedFolder) int {
@@ -65,6 +116,33 @@ func (s *scanReportBuilder) build() []*ScannedFolder {
 	return suggestions
 }
 
+// foldersWithOnlyRejects keeps track of rejected medias when medias are grouped by events: rejects have no date to be clustered on.
+func (s *scanReportBuilder) foldersWithOnlyRejects() []*ScannedFolder {
+	var rejects []*ScannedFolder
+	for _, folder := range s.albums {
+		if folder.RejectsCount > 0 {
+			rejects = append(rejects, &ScannedFolder{
+				Name:         folder.Name,
+				RelativePath: folder.RelativePath,
+				FolderName:   folder.FolderName,
+				AbsolutePath: folder.AbsolutePath,
+				Distribution: make(map[string]MediaCounter),
+				RejectsCount: folder.RejectsCount,
+			})
+		}
+	}
+	return rejects
+}
+
+// withPlace completes the folder names that have no letter (ex: '2023-07-14') with the place.
+func withPlace(name, place string) string {
+	if place == "" || slices.ContainsFunc([]rune(name), unicode.IsLetter) {
+		return name
+	}
+
+	return name + " " + place
+}
+
 func (s *scanReportBuilder) newFoundAlbum(mediaPath MediaPath) *ScannedFolder {
 	return &ScannedFolder{
 		Name:         mediaPath.ParentDir,
@@ -11,6 +11,8 @@ import (
 type BatchScanner struct {
 	CataloguerFactory CataloguerFactory
 	DetailsReaders    []DetailsReader
+	TimeZoneFinder    TimeZoneFinder // TimeZoneFinder is optional, the default time zone is used for all medias without it
+	PlaceFinder       PlaceFinder    // PlaceFinder is optional, suggested album names are not completed with the place the medias have been captured without it
 }
 
 func (s *BatchScanner) Scan(ctx context.Context, owner ownermodel.Owner, volume SourceVolume, optionSlice ...Options) ([]*ScannedFolder, error) {
@@ -30,17 +32,30 @@ func (s *BatchScanner) Scan(ctx context.Context, owner ownermodel.Owner, volume
 // scanListeners list the listeners that will be notified during the scan process.
 type scanConfiguration struct {
 	Analyser                 Analyser
+	TimeZones                *timeZoneResolver // TimeZones expresses the DateTime of the analysed medias in the zone they have been captured
 	Cataloguer               Cataloguer
 	ScanCompleteObserver     scanCompleteObserver
 	PostAnalyserRejects      []RejectedMediaObserver
 	PostCatalogFiltersIn     []CatalogReferencerObserver
 	PostCataloguerFiltersOut []CataloguerFilterObserver
 	Wrappers                 []chain.CloserFunc
+	RetryPolicy              chain.RetryPolicy   // RetryPolicy is used for transient errors from the cataloguer
+	QuarantineObservers      quarantineObservers // QuarantineObservers are notified of the medias that still fail after the retries ; the backup stops on the first failure when there is none
+}
+
+// withDetailedReport registers the report to be notified of skipped medias ; the time spent on each stage is measured by the chain.Instrumentation.
+func (c *scanConfiguration) withDetailedReport(report *DetailedReport) {
+	if report == nil {
+		return
+	}
+
+	c.PostAnalyserRejects = append(c.PostAnalyserRejects, report)
+	c.PostCataloguerFiltersOut = append(c.PostCataloguerFiltersOut, report)
 }
 
 func (s *BatchScanner) prepareVolumeScan(ctx context.Context, options Options, volumeName string, owner ownermodel.Owner) (analyserLauncher, *scanReportBuilder, error) {
 	tracker, _ := newTrackerV2(options)
-	reportBuilder := newScanReportBuilder()
+	reportBuilder := newScanReportBuilder(options, s.PlaceFinder)
 	scanLogger := newLogger(volumeName)
 
 	cataloguer, err := s.CataloguerFactory.NewOwnerScopedCataloguer(ctx, owner)
@@ -50,12 +65,18 @@ func (s *BatchScanner) prepareVolumeScan(ctx context.Context, options Options, v
 
 	config := &scanConfiguration{
 		Analyser:                 options.GetAnalyserDecorator().Decorate(newDefaultAnalyser(s.DetailsReaders...), tracker),
+		TimeZones:                newTimeZoneResolver(s.TimeZoneFinder, options),
 		Cataloguer:               cataloguer,
 		ScanCompleteObserver:     tracker,
 		PostAnalyserRejects:      []RejectedMediaObserver{scanLogger, tracker},
 		PostCatalogFiltersIn:     []CatalogReferencerObserver{scanLogger, tracker, reportBuilder},
 		PostCataloguerFiltersOut: []CataloguerFilterObserver{scanLogger, tracker},
 		Wrappers:                 []chain.CloserFunc{tracker.NoMoreEvents},
+		RetryPolicy:              options.GetRetryPolicy(),
+	}
+	config.withDetailedReport(options.DetailedReport)
+	if options.RecordPlan != nil {
+		config.PostCatalogFiltersIn = append(config.PostCatalogFiltersIn, options.RecordPlan)
 	}
 	if !options.SkipRejects {
 		config.PostAnalyserRejects = append(config.PostAnalyserRejects, new(analyserFailsFastObserver))
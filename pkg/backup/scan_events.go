@@ -0,0 +1,234 @@
+package backup
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+const earthRadiusKm = 6371
+
+// EventClusteringParameters are the thresholds used to split a flat list of medias into events (trips, parties, ...).
+type EventClusteringParameters struct {
+	MaxTimeGap             time.Duration // MaxTimeGap is the longest period without media within an event
+	MaxDistanceKm          float64       // MaxDistanceKm starts a new event when 2 consecutive medias are further apart, if both have GPS coordinates
+	MinTimeGapWithDistance time.Duration // MinTimeGapWithDistance is the minimum time gap required to split on distance (a road trip is a single event)
+	MinEventSize           int           // MinEventSize is the minimum number of medias to make an event
+	MinDailyDensity        float64       // MinDailyDensity is the minimum average number of medias per active day for an event lasting several days
+}
+
+// DefaultEventClusteringParameters are tuned for phone camera rolls.
+func DefaultEventClusteringParameters() EventClusteringParameters {
+	return EventClusteringParameters{
+		MaxTimeGap:             24 * time.Hour,
+		MaxDistanceKm:          50,
+		MinTimeGapWithDistance: 2 * time.Hour,
+		MinEventSize:           10,
+		MinDailyDensity:        5,
+	}
+}
+
+// scannedPoint is what is kept from a media to cluster it.
+type scannedPoint struct {
+	mediaPath           MediaPath
+	dateTime            time.Time
+	size                int
+	latitude, longitude float64
+	city                string // city is empty when the place of the media is unknown
+}
+
+func (p scannedPoint) hasCoordinates() bool {
+	return p.latitude != 0 || p.longitude != 0
+}
+
+// clusterEvents splits the points into events ; the medias that are not part of an event are grouped by month.
+func clusterEvents(parameters EventClusteringParameters, points []scannedPoint) []*ScannedFolder {
+	slices.SortFunc(points, func(a, b scannedPoint) int {
+		return a.dateTime.Compare(b.dateTime)
+	})
+
+	var segments [][]scannedPoint
+	for i, point := range points {
+		if i == 0 || parameters.isNewEvent(points[i-1], point) {
+			segments = append(segments, nil)
+		}
+		segments[len(segments)-1] = append(segments[len(segments)-1], point)
+	}
+
+	var events []*eventBuilder
+	monthly := make(map[string]*eventBuilder)
+	names := make(map[string]int)
+
+	for _, segment := range segments {
+		if parameters.isEvent(segment) {
+			event := newEventBuilder(uniqueEventName(names, eventName(segment, dominantCity(segment))))
+			for _, point := range segment {
+				event.push(point)
+			}
+			events = append(events, event)
+			continue
+		}
+
+		for _, point := range segment {
+			month := point.dateTime.Format("2006-01")
+			event, exists := monthly[month]
+			if !exists {
+				event = newEventBuilder(month)
+				monthly[month] = event
+				events = append(events, event)
+			}
+			event.push(point)
+		}
+	}
+
+	folders := make([]*ScannedFolder, len(events))
+	for i, event := range events {
+		folders[i] = event.build()
+	}
+	return folders
+}
+
+func (p EventClusteringParameters) isNewEvent(previous, current scannedPoint) bool {
+	gap := current.dateTime.Sub(previous.dateTime)
+	if gap > p.MaxTimeGap {
+		return true
+	}
+
+	return gap > p.MinTimeGapWithDistance &&
+		previous.hasCoordinates() && current.hasCoordinates() &&
+		distanceKm(previous, current) > p.MaxDistanceKm
+}
+
+func (p EventClusteringParameters) isEvent(segment []scannedPoint) bool {
+	if len(segment) < p.MinEventSize {
+		return false
+	}
+
+	days := make(map[string]interface{})
+	for _, point := range segment {
+		days[distributionKey(point.dateTime)] = nil
+	}
+
+	return len(days) == 1 || float64(len(segment))/float64(len(days)) >= p.MinDailyDensity
+}
+
+// eventName is "YYYY-MM <city> trip" for events lasting several days, "YYYY-MM-DD <city> event" otherwise ; the city is omitted when unknown.
+func eventName(segment []scannedPoint, city string) string {
+	first, last := segment[0].dateTime, segment[len(segment)-1].dateTime
+	kind, date := "trip", first.Format("2006-01")
+	if distributionKey(first) == distributionKey(last) {
+		kind, date = "event", first.Format("2006-01-02")
+	}
+
+	return strings.Join(nonEmptyStrings(date, city, kind), " ")
+}
+
+func dominantCity(segment []scannedPoint) string {
+	cities := make(placeCounter)
+	for _, point := range segment {
+		cities.push(point.city)
+	}
+	return cities.dominant()
+}
+
+// placeCounter counts the medias captured in each city.
+type placeCounter map[string]int
+
+func (c placeCounter) push(city string) {
+	if city != "" {
+		c[city]++
+	}
+}
+
+// dominant is the city with the most medias, the first in alphabetical order in case of equality ; it is empty when no city is known.
+func (c placeCounter) dominant() string {
+	dominant := ""
+	for city, count := range c {
+		if count > c[dominant] || (count == c[dominant] && city < dominant) {
+			dominant = city
+		}
+	}
+	return dominant
+}
+
+func nonEmptyStrings(values ...string) []string {
+	var filtered []string
+	for _, value := range values {
+		if value != "" {
+			filtered = append(filtered, value)
+		}
+	}
+	return filtered
+}
+
+func uniqueEventName(names map[string]int, name string) string {
+	names[name]++
+	if count := names[name]; count > 1 {
+		return fmt.Sprintf("%s %d", name, count)
+	}
+	return name
+}
+
+// eventBuilder creates a ScannedFolder for an event, its path is the deepest directory containing all its medias.
+type eventBuilder struct {
+	folder     *ScannedFolder
+	cities     placeCounter
+	root       string
+	commonPath []string
+	started    bool
+}
+
+func newEventBuilder(name string) *eventBuilder {
+	return &eventBuilder{
+		folder: &ScannedFolder{
+			Name:         name,
+			FolderName:   name,
+			Distribution: make(map[string]MediaCounter),
+		},
+		cities: make(placeCounter),
+	}
+}
+
+func (e *eventBuilder) push(point scannedPoint) {
+	e.folder.PushBoundaries(point.dateTime, point.size)
+	e.cities.push(point.city)
+
+	segments := strings.FieldsFunc(point.mediaPath.Path, func(r rune) bool { return r == '/' })
+	if !e.started {
+		e.started = true
+		e.root = point.mediaPath.Root
+		e.commonPath = segments
+		return
+	}
+
+	common := 0
+	for common < len(e.commonPath) && common < len(segments) && e.commonPath[common] == segments[common] {
+		common++
+	}
+	e.commonPath = e.commonPath[:common]
+}
+
+func (e *eventBuilder) build() *ScannedFolder {
+	relative := path.Join(e.commonPath...)
+	e.folder.Place = e.cities.dominant()
+	e.folder.RelativePath = relative
+	e.folder.AbsolutePath = e.root
+	if relative != "" {
+		e.folder.AbsolutePath = strings.TrimSuffix(e.root, "/") + "/" + relative
+	}
+
+	return e.folder
+}
+
+// distanceKm uses the haversine formula
+func distanceKm(a, b scannedPoint) float64 {
+	lat1, lat2 := a.latitude*math.Pi/180, b.latitude*math.Pi/180
+	deltaLat := lat2 - lat1
+	deltaLon := (b.longitude - a.longitude) * math.Pi / 180
+
+	h := math.Sin(deltaLat/2)*math.Sin(deltaLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(deltaLon/2)*math.Sin(deltaLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
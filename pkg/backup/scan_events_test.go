@@ -0,0 +1,135 @@
+package backup
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestClusterEvents(t *testing.T) {
+	const paris, nice = "paris", "nice"
+	coordinates := map[string][2]float64{
+		paris: {48.8566, 2.3522},
+		nice:  {43.7102, 7.2620},
+	}
+	cities := map[string]string{
+		paris: "Paris",
+		nice:  "Nice",
+	}
+
+	type burst struct {
+		start    string // start is YYYY-MM-DDTHH:MM
+		count    int
+		interval time.Duration
+		place    string
+		path     string
+	}
+	tests := []struct {
+		name  string
+		burst []burst
+		want  []string // want is the list of "<name> [<start> -> <end>] <count> in <relative path>"
+	}{
+		{
+			name: "it should split a flat camera roll into events separated by time gaps",
+			burst: []burst{
+				{"2024-07-10T09:00", 30, 2 * time.Hour, "", "DCIM/Camera"},
+				{"2024-07-20T18:00", 12, 10 * time.Minute, "", "DCIM/Camera"},
+			},
+			want: []string{
+				"2024-07 trip [2024-07-10 -> 2024-07-13] 30 in DCIM/Camera",
+				"2024-07-20 event [2024-07-20 -> 2024-07-21] 12 in DCIM/Camera",
+			},
+		},
+		{
+			name: "it should group sparse medias per month",
+			burst: []burst{
+				{"2024-07-01T12:00", 3, 20 * time.Hour, "", "DCIM/Camera"},
+				{"2024-07-20T12:00", 2, time.Hour, "", "DCIM/Camera"},
+				{"2024-08-05T12:00", 1, time.Hour, "", "DCIM/Camera"},
+			},
+			want: []string{
+				"2024-07 [2024-07-01 -> 2024-07-21] 5 in DCIM/Camera",
+				"2024-08 [2024-08-05 -> 2024-08-06] 1 in DCIM/Camera",
+			},
+		},
+		{
+			name: "it should not make an event of medias taken every day with a low density",
+			burst: []burst{
+				{"2024-07-01T12:00", 20, 23 * time.Hour, "", "DCIM/Camera"},
+			},
+			want: []string{
+				"2024-07 [2024-07-01 -> 2024-07-20] 20 in DCIM/Camera",
+			},
+		},
+		{
+			name: "it should split on distance when GPS coordinates are available",
+			burst: []burst{
+				{"2024-07-10T08:00", 10, 10 * time.Minute, paris, "DCIM/Camera"},
+				{"2024-07-10T18:00", 10, 10 * time.Minute, nice, "DCIM/Camera"},
+			},
+			want: []string{
+				"2024-07-10 Paris event [2024-07-10 -> 2024-07-11] 10 in DCIM/Camera",
+				"2024-07-10 Nice event [2024-07-10 -> 2024-07-11] 10 in DCIM/Camera",
+			},
+		},
+		{
+			name: "it should name the events after the city where most of their medias have been captured",
+			burst: []burst{
+				{"2024-07-10T09:00", 20, 2 * time.Hour, nice, "DCIM/Camera"},
+				{"2024-07-12T01:00", 10, 2 * time.Hour, paris, "DCIM/Camera"},
+				{"2024-07-20T18:00", 6, 10 * time.Minute, "", "DCIM/Camera"},
+				{"2024-07-20T19:00", 6, 10 * time.Minute, "", "DCIM/Camera"},
+			},
+			want: []string{
+				"2024-07 Nice trip [2024-07-10 -> 2024-07-13] 30 in DCIM/Camera",
+				"2024-07-20 event [2024-07-20 -> 2024-07-21] 12 in DCIM/Camera",
+			},
+		},
+		{
+			name: "it should use the common parent folder of the medias",
+			burst: []burst{
+				{"2024-07-10T08:00", 5, 10 * time.Minute, "", "phone/DCIM/Camera"},
+				{"2024-07-10T09:00", 5, 10 * time.Minute, "", "phone/Pictures/Whatsapp"},
+			},
+			want: []string{
+				"2024-07-10 event [2024-07-10 -> 2024-07-11] 10 in phone",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var points []scannedPoint
+			for _, b := range tt.burst {
+				start, err := time.Parse("2006-01-02T15:04", b.start)
+				if err != nil {
+					panic(err)
+				}
+
+				for i := 0; i < b.count; i++ {
+					points = append(points, scannedPoint{
+						mediaPath: MediaPath{Root: "/mnt/volume", Path: b.path},
+						dateTime:  start.Add(time.Duration(i) * b.interval),
+						size:      42,
+						latitude:  coordinates[b.place][0],
+						longitude: coordinates[b.place][1],
+						city:      cities[b.place],
+					})
+				}
+			}
+
+			var got []string
+			for _, folder := range clusterEvents(DefaultEventClusteringParameters(), points) {
+				count := 0
+				for _, counter := range folder.Distribution {
+					count += counter.Count
+				}
+				got = append(got, fmt.Sprintf("%s [%s -> %s] %d in %s", folder.Name, folder.Start.Format(layout), folder.End.Format(layout), count, folder.RelativePath))
+				assert.Equal(t, "/mnt/volume/"+folder.RelativePath, folder.AbsolutePath)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
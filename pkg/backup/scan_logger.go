@@ -42,6 +42,15 @@ func (l *logger) OnRejectedMedia(ctx context.Context, found FoundMedia, cause er
 	return nil
 }
 
+func (l *logger) OnQuarantinedMedia(ctx context.Context, stage ReportStage, media AnalysedMedia, cause error) error {
+	l.mdc.WithFields(log.Fields{
+		"Media": media.FoundMedia.String(),
+		"Stage": stage,
+		"Cause": cause,
+	}).Errorf("Media quarantined")
+	return nil
+}
+
 func (l *logger) OnMediaCatalogued(ctx context.Context, requests []BackingUpMediaRequest) error {
 	for _, request := range requests {
 		l.mdc.Infof("Media catalogued %s > %s", request.CatalogReference.AlbumFolderName(), request.AnalysedMedia.FoundMedia)
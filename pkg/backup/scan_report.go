@@ -13,6 +13,7 @@ type ScannedFolder struct {
 	Start, End   time.Time               // Start and End are the beginning of the day of the first media, and the beginning of the day following the last media.
 	Distribution map[string]MediaCounter // Distribution is the number of media found for each day (format YYYY-MM-DD)
 	RejectsCount int                     // RejectsCount is the number of media that have been rejected or filtered out in the folder
+	Place        string                  // Place is the city where most of the medias with GPS coordinates have been captured, empty when unknown
 }
 
 // PushBoundaries is updating the ScannedFolder dates, and update the counter.
@@ -267,6 +267,66 @@ func TestScanAcceptance(t *testing.T) {
 	}
 }
 
+func TestScan_places(t *testing.T) {
+	owner := ownermodel.Owner("tony@stark.com")
+	paris := [2]float64{48.8566, 2.3522}
+	nice := [2]float64{43.7102, 7.2620}
+
+	newMedia := func(path string, content string, coordinates [2]float64) *AnalysedMedia {
+		return &AnalysedMedia{
+			FoundMedia: NewInMemoryMedia(path, time.Now(), []byte(content)),
+			Type:       MediaTypeImage,
+			Details:    &MediaDetails{DateTime: time.Date(2022, 6, 18, 0, 0, 0, 0, time.UTC), GPSLatitude: coordinates[0], GPSLongitude: coordinates[1]},
+		}
+	}
+	analysedMedias := []*AnalysedMedia{
+		newMedia("2022-06-18/file_1.jpg", "A", nice),
+		newMedia("2022-06-18/file_2.jpg", "B", paris),
+		newMedia("2022-06-18/file_3.jpg", "C", paris),
+		newMedia("2022-06-18/file_4.jpg", "D", [2]float64{}),
+		newMedia("Holidays/file_5.jpg", "E", nice),
+		newMedia("2022/file_6.jpg", "F", [2]float64{}),
+	}
+
+	volume := make(InMemorySourceVolume, 0)
+	cached := make(map[string]*AnalysedMedia)
+	cataloguer := make(CatalogReferencerFake)
+	for i, media := range analysedMedias {
+		volume = append(volume, media.FoundMedia)
+		cached[media.FoundMedia.MediaPath().Filename] = media
+		cataloguer[media] = &CatalogReferenceStub{MediaIdValue: fmt.Sprintf("media-id-%d", i), AlbumFolderNameValue: "/album1"}
+	}
+
+	scanner := &BatchScanner{
+		CataloguerFactory: &ReferencerFactoryFake{Cataloguer: cataloguer},
+		DetailsReaders:    []DetailsReader{new(DetailsReaderAdapterStub)},
+		PlaceFinder: PlaceFinderStub{
+			paris: "Paris",
+			nice:  "Nice",
+		},
+	}
+	gotFolders, err := scanner.Scan(context.Background(), owner, &volume, OptionsAnalyserDecorator(&AnalyserDecoratorFake{Cached: cached}))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	got := make(map[string][]string)
+	for _, folder := range gotFolders {
+		got[folder.RelativePath] = []string{folder.Name, folder.Place}
+	}
+	assert.Equal(t, map[string][]string{
+		"2022-06-18": {"2022-06-18 Paris", "Paris"},
+		"Holidays":   {"Holidays", "Nice"},
+		"2022":       {"2022", ""},
+	}, got, "it should complete the names without letter with the city where most of the medias have been captured")
+}
+
+type PlaceFinderStub map[[2]float64]string
+
+func (p PlaceFinderStub) FindCity(latitude, longitude float64) (string, error) {
+	return p[[2]float64{latitude, longitude}], nil
+}
+
 type DetailsReaderAdapterStub struct {
 }
 
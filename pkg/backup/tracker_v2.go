@@ -15,19 +15,24 @@ const (
 	trackWrongAlbum             trackEvent = "wrong-album"               // trackWrongAlbum count files in filtered out albums (if filter used), subtracted from trackScanComplete
 	trackAlreadyExistsInCatalog trackEvent = "already-exists-in-catalog" // trackAlreadyExistsInCatalog count files already known in catalog, subtracted from trackScanComplete
 	trackDuplicatedInVolume     trackEvent = "duplicated-in-volume"      // trackDuplicatedInVolume count files present twice in this backup/scan process, subtracted from trackScanComplete
-	trackCatalogued             trackEvent = "catalogued"                // trackCatalogued files remaining after analysis, cataloguing, and filters: trackCatalogued = trackScanComplete - trackDuplicatedInVolume - trackAlreadyExistsInCatalog - trackWrongAlbum
-	trackUploaded               trackEvent = "uploaded"                  // trackUploaded files uploaded, is equals to trackCatalogued when complete
+	trackFilteredOutByRule      trackEvent = "filtered-out-by-rule"      // trackFilteredOutByRule count files not accepted by user defined rules, subtracted from trackScanComplete
+	trackCatalogued             trackEvent = "catalogued"                // trackCatalogued files remaining after analysis, cataloguing, and filters: trackCatalogued = trackScanComplete - trackDuplicatedInVolume - trackAlreadyExistsInCatalog - trackWrongAlbum - trackFilteredOutByRule
+	trackCatalogueFailed        trackEvent = "catalogue-failed"          // trackCatalogueFailed count files quarantined because they couldn't be catalogued, subtracted from trackScanComplete
+	trackUploaded               trackEvent = "uploaded"                  // trackUploaded files uploaded, is equals to trackCatalogued - trackUploadFailed when complete
+	trackUploadFailed           trackEvent = "upload-failed"             // trackUploadFailed count files quarantined because they couldn't be uploaded or indexed, subtracted from trackCatalogued
 	trackAlbumCreated           trackEvent = "album-created"             // trackAlbumCreated notify when a new album is created
+	trackBandwidth              trackEvent = "bandwidth"                 // trackBandwidth notify the upload rate limit applied, listeners are only called when it changes
 )
 
 type trackEvent string
 
 type progressEvent struct {
-	Type      trackEvent // Type defines what's count, and size are about ; some might not be used.
-	Count     int        // Count is the number of media
-	Size      int        // Size is the sum of the size of the media concerned by this event
-	Album     string     // Album is the folder name of the medias concerned by this event
-	MediaType MediaType  // MediaType is the type of media ; only mandatory with 'uploaded' event
+	Type      trackEvent     // Type defines what's count, and size are about ; some might not be used.
+	Count     int            // Count is the number of media
+	Size      int            // Size is the sum of the size of the media concerned by this event
+	Album     string         // Album is the folder name of the medias concerned by this event
+	MediaType MediaType      // MediaType is the type of media ; only mandatory with 'uploaded' event
+	Bandwidth BandwidthLimit // Bandwidth is only used with 'bandwidth' event
 }
 
 type TrackAnalysed interface {
@@ -48,8 +53,10 @@ type TrackScanComplete interface {
 }
 
 type ExtraCounts struct {
-	Cached   MediaCounter
-	Rejected MediaCounter
+	Cached      MediaCounter
+	Rejected    MediaCounter
+	FilteredOut MediaCounter // FilteredOut are the medias not accepted by user defined rules
+	Quarantined MediaCounter // Quarantined are the medias that still failed after the retries
 }
 
 func (c ExtraCounts) String() interface{} {
@@ -61,6 +68,12 @@ func (c ExtraCounts) String() interface{} {
 	if c.Rejected.Count > 0 {
 		extraDetails = append(extraDetails, fmt.Sprintf("rejected: %d", c.Rejected.Count))
 	}
+	if c.FilteredOut.Count > 0 {
+		extraDetails = append(extraDetails, fmt.Sprintf("filtered out: %d", c.FilteredOut.Count))
+	}
+	if c.Quarantined.Count > 0 {
+		extraDetails = append(extraDetails, fmt.Sprintf("quarantined: %d", c.Quarantined.Count))
+	}
 
 	cachedExplanation := ""
 	if len(extraDetails) > 0 {
@@ -110,6 +123,10 @@ func panicIfDoesNotImplementsTrackerInterface(listener interface{}) (listeners [
 		return
 	}
 
+	if _, ok := listener.(TrackBandwidth); ok {
+		return
+	}
+
 	panic("listener must implement at least one of the tracker interfaces")
 }
 
@@ -117,6 +134,7 @@ func panicIfDoesNotImplementsTrackerInterface(listener interface{}) (listeners [
 type trackerV2 struct {
 	listeners  []interface{} // listeners will receive aggregated and typed updates
 	eventCount map[trackEvent]MediaCounter
+	bandwidth  *BandwidthLimit // bandwidth is the last limit sent to the listeners
 }
 
 func (t *trackerV2) consume(progressChannel chan *progressEvent) {
@@ -134,12 +152,17 @@ func (t *trackerV2) consume(progressChannel chan *progressEvent) {
 			trackAlreadyExistsInCatalog,
 			trackDuplicatedInVolume,
 			trackWrongAlbum,
+			trackFilteredOutByRule,
+			trackCatalogueFailed,
 			trackCatalogued:
 			t.fireAnalysedEvent()
 
-		case trackUploaded:
+		case trackUploaded, trackUploadFailed:
 			t.fireUploadedEvent()
 
+		case trackBandwidth:
+			t.fireBandwidthEvent(event.Bandwidth)
+
 		case trackAlbumCreated:
 		case trackAnalysedFromCache:
 			// nothing
@@ -163,16 +186,20 @@ func (t *trackerV2) fireAnalysedEvent() {
 	exists, _ := t.eventCount[trackAlreadyExistsInCatalog]
 	duplicates, _ := t.eventCount[trackDuplicatedInVolume]
 	wrongAlbum, _ := t.eventCount[trackWrongAlbum]
+	filteredOut, _ := t.eventCount[trackFilteredOutByRule]
 	rejected, _ := t.eventCount[trackAnalysisFailed]
 	analysedFromCache, _ := t.eventCount[trackAnalysedFromCache]
+	catalogueFailed, _ := t.eventCount[trackCatalogueFailed]
 
-	done := passed.AddCounter(exists).AddCounter(duplicates).AddCounter(wrongAlbum).AddCounter(rejected)
+	done := passed.AddCounter(exists).AddCounter(duplicates).AddCounter(wrongAlbum).AddCounter(filteredOut).AddCounter(rejected).AddCounter(catalogueFailed)
 
 	for _, listener := range t.listeners {
 		if dispatch, ok := listener.(TrackAnalysed); ok {
 			dispatch.OnAnalysed(done, t.eventCount[trackScanComplete], ExtraCounts{
-				Cached:   analysedFromCache,
-				Rejected: rejected,
+				Cached:      analysedFromCache,
+				Rejected:    rejected,
+				FilteredOut: filteredOut,
+				Quarantined: catalogueFailed,
 			})
 		}
 	}
@@ -184,13 +211,16 @@ func (t *trackerV2) fireUploadedEvent() {
 	exists, _ := t.eventCount[trackAlreadyExistsInCatalog]
 	duplicates, _ := t.eventCount[trackDuplicatedInVolume]
 	wrongAlbum, _ := t.eventCount[trackWrongAlbum]
+	filteredOut, _ := t.eventCount[trackFilteredOutByRule]
 	ready, _ := t.eventCount[trackCatalogued]
 	uploaded, _ := t.eventCount[trackUploaded]
+	catalogueFailed, _ := t.eventCount[trackCatalogueFailed]
+	uploadFailed, _ := t.eventCount[trackUploadFailed]
 
 	total := MediaCounterZero
-	if ready.AddCounter(duplicates).AddCounter(exists).AddCounter(wrongAlbum).AddCounter(rejected).Count == scanned.Count {
+	if ready.AddCounter(duplicates).AddCounter(exists).AddCounter(wrongAlbum).AddCounter(filteredOut).AddCounter(rejected).AddCounter(catalogueFailed).Count == scanned.Count {
 		// total-to-upload is confirmed
-		total = ready
+		total = ready.Add(-uploadFailed.Count, -uploadFailed.Size)
 	}
 
 	for _, listener := range t.listeners {
@@ -200,6 +230,19 @@ func (t *trackerV2) fireUploadedEvent() {
 	}
 }
 
+func (t *trackerV2) fireBandwidthEvent(limit BandwidthLimit) {
+	if t.bandwidth != nil && *t.bandwidth == limit {
+		return
+	}
+	t.bandwidth = &limit
+
+	for _, listener := range t.listeners {
+		if dispatch, ok := listener.(TrackBandwidth); ok {
+			dispatch.OnBandwidthLimit(limit)
+		}
+	}
+}
+
 func (t *trackerV2) fireRawEvent(event *progressEvent) {
 	for _, listener := range t.listeners {
 		if dispatch, ok := listener.(TrackEvents); ok {
@@ -261,12 +304,30 @@ func (p *trackerObserver) OnFilteredOut(ctx context.Context, media AnalysedMedia
 	case errors.Is(cause, ErrMediaMustNotBeDuplicated):
 		p.channel <- &progressEvent{Type: trackDuplicatedInVolume, Count: 1, Size: media.FoundMedia.Size()}
 		return nil
+	case errors.Is(cause, ErrMediaFilteredOutByRule):
+		p.channel <- &progressEvent{Type: trackFilteredOutByRule, Count: 1, Size: media.FoundMedia.Size()}
+		return nil
 
 	default:
 		return errors.Wrapf(cause, "filter error is not supported. Media: %s", media.FoundMedia)
 	}
 }
 
+func (p *trackerObserver) OnQuarantinedMedia(ctx context.Context, stage ReportStage, media AnalysedMedia, cause error) error {
+	eventType := trackUploadFailed
+	if stage == StageCatalogue {
+		eventType = trackCatalogueFailed
+	}
+
+	p.channel <- &progressEvent{Type: eventType, Count: 1, Size: media.FoundMedia.Size()}
+	return nil
+}
+
+func (p *trackerObserver) OnUploadBandwidth(ctx context.Context, limit BandwidthLimit) error {
+	p.channel <- &progressEvent{Type: trackBandwidth, Bandwidth: limit}
+	return nil
+}
+
 func (p *trackerObserver) OnBackingUpMediaRequestUploaded(ctx context.Context, request BackingUpMediaRequest) error {
 	p.channel <- &progressEvent{
 		Type:      trackUploaded,
@@ -0,0 +1,153 @@
+// Package asf parses the header of ASF files (WMV, ASF) to retrieve the creation date, duration, resolution and codec of the video.
+// References:
+// - https://learn.microsoft.com/en-us/windows/win32/wmformat/asf-file-structure
+// - http://drang.s4.xrea.com/program/tips/id3tag/wmp/10_asf_guids.html
+package asf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/thomasduchatelle/dphoto/pkg/backup"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	objectHeaderSize     = 24                   // objectHeaderSize is the GUID (16 bytes) and the size (8 bytes) of an object
+	headerObjectSize     = objectHeaderSize + 6 // headerObjectSize includes the number of objects (4 bytes) and 2 reserved bytes
+	maxHeaderSize        = 16 * 1024 * 1024
+	filePropertiesSize   = 80
+	broadcastFlag        = 0x01 // broadcastFlag is set when the creation date and the durations are not valid
+	streamPropertiesSize = 54
+)
+
+var (
+	// GUIDs are stored in their binary form: the 3 first fields are little-endian
+	guidHeader           = guid("75B22630-668E-11CF-A6D9-00AA0062CE6C")
+	guidFileProperties   = guid("8CABDCA1-A947-11CF-8EE4-00C00C205365")
+	guidStreamProperties = guid("B7DC0791-A9B7-11CF-8EE6-00C00C205365")
+	guidVideoMedia       = guid("BC19EFC0-5B4D-11CF-A8FD-00805F5C442B")
+
+	// fileTimeEpoch is the origin of the FILETIME dates, counted in 100 nanoseconds ; it's too far for a time.Duration.
+	fileTimeEpoch = time.Date(1601, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+)
+
+type Parser struct {
+	Debug bool // Debug can be set to true to print in the console the objects read.
+}
+
+func (p *Parser) Supports(media backup.FoundMedia, mediaType backup.MediaType) bool {
+	ext := strings.ToUpper(path.Ext(media.MediaPath().Filename))
+	return mediaType == backup.MediaTypeVideo && (ext == ".WMV" || ext == ".ASF")
+}
+
+// ReadDetails reads the Header Object, which is at the beginning of the file ; the data is not read.
+func (p *Parser) ReadDetails(reader io.Reader, options backup.DetailsReaderOptions) (*backup.MediaDetails, error) {
+	header := make([]byte, headerObjectSize)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return nil, errors.Wrapf(err, "invalid ASF file")
+	}
+	if !bytes.Equal(header[:16], guidHeader) {
+		return nil, errors.Errorf("ASF file must start with the Header Object, not %s", hex.EncodeToString(header[:16]))
+	}
+
+	size := binary.LittleEndian.Uint64(header[16:24])
+	if size < headerObjectSize || size > maxHeaderSize {
+		return nil, errors.Errorf("ASF Header Object size is invalid: %d bytes", size)
+	}
+
+	payload := make([]byte, size-headerObjectSize)
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		return nil, errors.Wrapf(err, "ASF Header Object is truncated")
+	}
+
+	details := new(backup.MediaDetails)
+	for offset := 0; offset+objectHeaderSize <= len(payload); {
+		objectSize := binary.LittleEndian.Uint64(payload[offset+16 : offset+24])
+		if objectSize < objectHeaderSize || uint64(offset)+objectSize > uint64(len(payload)) {
+			return nil, errors.Errorf("ASF object %s at offset %d overflows the Header Object", hex.EncodeToString(payload[offset:offset+16]), offset)
+		}
+
+		objectID := payload[offset : offset+16]
+		data := payload[offset+objectHeaderSize : offset+int(objectSize)]
+		if p.Debug {
+			fmt.Printf("'%s' object [%d]\n", hex.EncodeToString(objectID), objectSize)
+		}
+
+		switch {
+		case bytes.Equal(objectID, guidFileProperties):
+			p.parseFileProperties(details, data)
+
+		case bytes.Equal(objectID, guidStreamProperties):
+			p.parseStreamProperties(details, data)
+		}
+
+		offset += int(objectSize)
+	}
+
+	return details, nil
+}
+
+// parseFileProperties reads the creation date and the play duration, from which the preroll must be removed.
+func (p *Parser) parseFileProperties(details *backup.MediaDetails, data []byte) {
+	if len(data) < filePropertiesSize {
+		return
+	}
+
+	flags := binary.LittleEndian.Uint32(data[64:68])
+	if flags&broadcastFlag != 0 {
+		return
+	}
+
+	if creation := binary.LittleEndian.Uint64(data[24:32]); creation > 0 {
+		details.DateTime = time.Unix(fileTimeEpoch+int64(creation/10000000), int64(creation%10000000)*100).UTC()
+		details.TimeReference = backup.TimeReferenceInstant
+	}
+
+	playDuration := int64(binary.LittleEndian.Uint64(data[40:48]) / 10000)
+	preroll := int64(binary.LittleEndian.Uint64(data[56:64]))
+	details.Duration = max(0, playDuration-preroll)
+}
+
+// parseStreamProperties reads the resolution and codec of the first video stream ; audio streams are ignored.
+func (p *Parser) parseStreamProperties(details *backup.MediaDetails, data []byte) {
+	if len(data) < streamPropertiesSize || !bytes.Equal(data[:16], guidVideoMedia) || details.VideoEncoding != "" {
+		return
+	}
+
+	typeSpecificLength := int(binary.LittleEndian.Uint32(data[40:44]))
+	typeSpecific := data[streamPropertiesSize:]
+	if len(typeSpecific) < typeSpecificLength || typeSpecificLength < 11 {
+		return
+	}
+	typeSpecific = typeSpecific[:typeSpecificLength]
+
+	details.Width = int(binary.LittleEndian.Uint32(typeSpecific[0:4]))
+	details.Height = int(binary.LittleEndian.Uint32(typeSpecific[4:8]))
+
+	// BITMAPINFOHEADER follows: the compression is a FOURCC at offset 16
+	formatData := typeSpecific[11:]
+	if len(formatData) >= 20 {
+		details.VideoEncoding = strings.Trim(string(formatData[16:20]), "\x00 ")
+	}
+}
+
+// guid converts the textual representation of a GUID into its binary form.
+func guid(text string) []byte {
+	raw, err := hex.DecodeString(strings.ReplaceAll(text, "-", ""))
+	if err != nil || len(raw) != 16 {
+		panic(fmt.Sprintf("invalid GUID %s", text))
+	}
+
+	for _, field := range [][2]int{{0, 4}, {4, 6}, {6, 8}} {
+		for i, j := field[0], field[1]-1; i < j; i, j = i+1, j-1 {
+			raw[i], raw[j] = raw[j], raw[i]
+		}
+	}
+	return raw
+}
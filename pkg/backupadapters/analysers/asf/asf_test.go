@@ -0,0 +1,60 @@
+package asf
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/thomasduchatelle/dphoto/pkg/backup"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestAsfDetailsExtraction(t *testing.T) {
+	a := assert.New(t)
+
+	reader, err := os.Open("../../../test_resources/scan/sample.wmv")
+	if !a.NoError(err) {
+		return
+	}
+	defer reader.Close()
+
+	parser := &Parser{Debug: true}
+	details, err := parser.ReadDetails(reader, backup.DetailsReaderOptions{})
+	if a.NoError(err) {
+		a.Equal(&backup.MediaDetails{
+			DateTime:      time.Date(2008, 12, 24, 16, 45, 12, 0, time.UTC),
+			TimeReference: backup.TimeReferenceInstant,
+			Duration:      9700,
+			Width:         640,
+			Height:        480,
+			VideoEncoding: "WMV3",
+		}, details)
+	}
+}
+
+func TestAsfDetailsExtraction_invalid(t *testing.T) {
+	content, err := os.ReadFile("../../../test_resources/scan/sample.wmv")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	tests := []struct {
+		name    string
+		content []byte
+		wantErr string
+	}{
+		{"it should reject files not starting with the Header Object", append([]byte("RIFF"), content[4:]...), "must start with the Header Object"},
+		{"it should fail when the Header Object is truncated", content[:200], "ASF Header Object is truncated"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := new(Parser).ReadDetails(bytes.NewReader(tt.content), backup.DetailsReaderOptions{})
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func Test_guid(t *testing.T) {
+	assert.Equal(t, []byte{0x30, 0x26, 0xB2, 0x75, 0x8E, 0x66, 0xCF, 0x11, 0xA6, 0xD9, 0x00, 0xAA, 0x00, 0x62, 0xCE, 0x6C}, guid("75B22630-668E-11CF-A6D9-00AA0062CE6C"))
+}
@@ -0,0 +1,49 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/rwcarlsen/goexif/exif"
+	"github.com/rwcarlsen/goexif/tiff"
+)
+
+const (
+	ContentIdentifier exif.FieldName = "ContentIdentifier"
+	BurstUUID         exif.FieldName = "BurstUUID"
+
+	// appleMakerNoteHeader starts the maker notes written by iPhones ; it's followed by a version (2 bytes) and the byte order (2 bytes), the offsets are relative to the start of the maker note.
+	appleMakerNoteHeader = "Apple iOS\x00"
+)
+
+// appleMakerNoteFields are the tags of Apple maker notes linking the medias captured together (see https://exiftool.org/TagNames/Apple.html)
+var appleMakerNoteFields = map[uint16]exif.FieldName{
+	0x000b: BurstUUID,
+	0x0011: ContentIdentifier,
+}
+
+// appleMakerNoteParser loads the identifiers of Live Photos and bursts from the maker note of the EXIF sub-IFD.
+type appleMakerNoteParser struct{}
+
+func (a appleMakerNoteParser) Parse(x *exif.Exif) error {
+	tag, err := x.Get(exif.MakerNote)
+	if err != nil || len(tag.Val) < len(appleMakerNoteHeader)+4 || string(tag.Val[:len(appleMakerNoteHeader)]) != appleMakerNoteHeader {
+		return nil
+	}
+
+	var order binary.ByteOrder = binary.BigEndian
+	if string(tag.Val[len(appleMakerNoteHeader)+2:len(appleMakerNoteHeader)+4]) == "II" {
+		order = binary.LittleEndian
+	}
+
+	reader := bytes.NewReader(tag.Val)
+	if _, err = reader.Seek(int64(len(appleMakerNoteHeader)+4), 0); err != nil {
+		return nil
+	}
+	makerNote, _, err := tiff.DecodeDir(reader, order)
+	if err != nil {
+		return nil // maker notes are proprietary: they are ignored when they can't be read
+	}
+
+	x.LoadTags(makerNote, appleMakerNoteFields, false)
+	return nil
+}
@@ -16,7 +16,7 @@ import (
 
 func init() {
 	// note - Canon parser is failing on 2007 photos from a Canon camera
-	exif.RegisterParsers()
+	exif.RegisterParsers(offsetTimeParser{}, appleMakerNoteParser{})
 }
 
 type Parser struct{}
@@ -41,15 +41,19 @@ func (p *Parser) ReadDetails(reader io.Reader, _ backup.DetailsReaderOptions) (*
 		longitude = 0
 	}
 
+	dateTime, timeReference := p.readDateTime(x, time.Time{})
 	return &backup.MediaDetails{
-		Width:        p.getIntOrIgnore(x, exif.ImageWidth),
-		Height:       p.getIntOrIgnore(x, exif.ImageLength),
-		Orientation:  p.readOrientation(x),
-		DateTime:     p.readDateTime(x, time.Time{}),
-		Make:         p.getStringOrIgnore(x, exif.Make),
-		Model:        p.getStringOrIgnore(x, exif.Model),
-		GPSLatitude:  latitude,
-		GPSLongitude: longitude,
+		Width:         p.getIntOrIgnore(x, exif.ImageWidth),
+		Height:        p.getIntOrIgnore(x, exif.ImageLength),
+		Orientation:   p.readOrientation(x),
+		DateTime:      dateTime,
+		TimeReference: timeReference,
+		Make:          p.getStringOrIgnore(x, exif.Make),
+		Model:         p.getStringOrIgnore(x, exif.Model),
+		GPSLatitude:   latitude,
+		GPSLongitude:  longitude,
+		LivePhotoId:   p.getStringOrIgnore(x, ContentIdentifier),
+		BurstId:       p.getStringOrIgnore(x, BurstUUID),
 	}, nil
 }
 
@@ -66,22 +70,31 @@ func (p *Parser) readOrientation(x *exif.Exif) backup.ImageOrientation {
 	}
 }
 
-func (p *Parser) readDateTime(x *exif.Exif, defaultDate time.Time) time.Time {
+// readDateTime returns the date as a wall-clock, or ZONED when its offset has been recorded by the camera.
+func (p *Parser) readDateTime(x *exif.Exif, defaultDate time.Time) (time.Time, backup.TimeReference) {
 	datetime := p.getStringOrIgnore(x, exif.DateTime)
+	offset := p.getStringOrIgnore(x, OffsetTime)
 	if datetime == "" {
 		datetime = p.getStringOrIgnore(x, exif.DateTimeOriginal)
+		offset = p.getStringOrIgnore(x, OffsetTimeOriginal)
+	}
+	if offset == "" {
+		offset = p.getStringOrIgnore(x, OffsetTimeOriginal)
 	}
 
 	if datetime != "" {
 		exifTime, err := time.Parse("2006:01:02 15:04:05", datetime)
 		if err == nil {
-			return exifTime.UTC()
+			if zone := parseOffset(offset); zone != nil {
+				return time.Date(exifTime.Year(), exifTime.Month(), exifTime.Day(), exifTime.Hour(), exifTime.Minute(), exifTime.Second(), 0, zone), backup.TimeReferenceZoned
+			}
+			return exifTime.UTC(), backup.TimeReferenceWallClock
 		} else {
 			log.WithField("MediaAnalyser", "Exif").Warnf("Unsupported dfate format: %s", datetime)
 		}
 	}
 
-	return defaultDate
+	return defaultDate, backup.TimeReferenceWallClock
 }
 
 func (p *Parser) getStringOrIgnore(x *exif.Exif, model exif.FieldName) string {
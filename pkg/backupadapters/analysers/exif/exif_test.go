@@ -40,14 +40,62 @@ func TestFileWithExif(t *testing.T) {
 
 	if a.NoError(err) {
 		a.Equal(&backup.MediaDetails{
-			Width:        4048,
-			Height:       3036,
-			DateTime:     time.Unix(1574694084, 0).UTC(),
-			Orientation:  backup.OrientationUpperLeft,
-			Make:         "Google",
-			Model:        "Pixel",
-			GPSLatitude:  51.50363055555555,
-			GPSLongitude: -0.11583333333333334,
+			Width:         4048,
+			Height:        3036,
+			DateTime:      time.Unix(1574694084, 0).In(time.FixedZone("", 0)),
+			TimeReference: backup.TimeReferenceZoned,
+			Orientation:   backup.OrientationUpperLeft,
+			Make:          "Google",
+			Model:         "Pixel",
+			GPSLatitude:   51.50363055555555,
+			GPSLongitude:  -0.11583333333333334,
 		}, details)
 	}
 }
+
+func TestFileWithOffsetTime(t *testing.T) {
+	a := assert.New(t)
+
+	exifAdapter := new(Parser)
+	reader, err := os.Open("../../../test_resources/scan/golang-logo-offset.jpg")
+	if !a.NoError(err) {
+		panic(err.Error())
+	}
+
+	details, err := exifAdapter.ReadDetails(reader, backup.DetailsReaderOptions{})
+	if a.NoError(err) {
+		a.Equal(backup.TimeReferenceZoned, details.TimeReference)
+		a.Equal(time.Date(2023, 7, 14, 8, 30, 5, 0, time.UTC), details.DateTime.UTC())
+		a.Equal(time.Date(2023, 7, 14, 18, 30, 5, 0, time.UTC), details.LocalDateTime())
+		a.Equal("+10:00", details.TimeZone())
+	}
+}
+
+func TestFileWithAppleMakerNote(t *testing.T) {
+	tests := []struct {
+		name            string
+		file            string
+		wantLivePhotoId string
+		wantBurstId     string
+	}{
+		{"it should read the content identifier of a Live Photo", "iphone_live_photo.jpg", "7E1F8A2C-3B4D-4E5F-9A6B-1C2D3E4F5A6B", ""},
+		{"it should read the identifier of a burst", "iphone_burst.jpg", "", "0B6F4C1D-92E3-4A57-8D21-5C7E3F9A1B04"},
+		{"it should ignore medias without maker note", "golang-logo-offset.jpg", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, err := os.Open("../../../test_resources/scan/" + tt.file)
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer reader.Close()
+
+			details, err := new(Parser).ReadDetails(reader, backup.DetailsReaderOptions{})
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.wantLivePhotoId, details.LivePhotoId)
+				assert.Equal(t, tt.wantBurstId, details.BurstId)
+			}
+		})
+	}
+}
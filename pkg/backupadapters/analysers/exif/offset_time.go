@@ -0,0 +1,56 @@
+package exif
+
+import (
+	"bytes"
+	"github.com/rwcarlsen/goexif/exif"
+	"github.com/rwcarlsen/goexif/tiff"
+	"time"
+)
+
+const (
+	OffsetTime         exif.FieldName = "OffsetTime"
+	OffsetTimeOriginal exif.FieldName = "OffsetTimeOriginal"
+)
+
+// offsetTimeFields are the tags from EXIF 2.31 not known by goexif: the offset from UTC of DateTime and DateTimeOriginal.
+var offsetTimeFields = map[uint16]exif.FieldName{
+	0x9010: OffsetTime,
+	0x9011: OffsetTimeOriginal,
+}
+
+// offsetTimeParser loads the offsets of the dates from the EXIF sub-IFD.
+type offsetTimeParser struct{}
+
+func (o offsetTimeParser) Parse(x *exif.Exif) error {
+	tag, err := x.Get(exif.ExifIFDPointer)
+	if err != nil {
+		return nil
+	}
+	offset, err := tag.Int64(0)
+	if err != nil {
+		return nil
+	}
+
+	reader := bytes.NewReader(x.Raw)
+	if _, err = reader.Seek(offset, 0); err != nil {
+		return nil
+	}
+	subDir, _, err := tiff.DecodeDir(reader, x.Tiff.Order)
+	if err != nil {
+		return nil // already reported by the default parser
+	}
+
+	x.LoadTags(subDir, offsetTimeFields, false)
+	return nil
+}
+
+// parseOffset reads an offset formatted like '+02:00' ; it returns nil when the offset is missing or invalid.
+func parseOffset(offset string) *time.Location {
+	zone, err := time.Parse("-07:00", offset)
+	if err != nil {
+		return nil
+	}
+
+	_, seconds := zone.Zone()
+	return time.FixedZone("", seconds)
+}
@@ -0,0 +1,129 @@
+// Package heif parse HEIC/HEIF images to retrieve the dimensions of the primary image, and the details from its EXIF block.
+// References:
+// - ISO/IEC 14496-12 (ISO base media file format) and ISO/IEC 23008-12 (HEIF)
+// - https://github.com/exiftool/exiftool/blob/master/lib/Image/ExifTool/QuickTime.pm
+package heif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/pkg/errors"
+	"github.com/thomasduchatelle/dphoto/pkg/backup"
+	"github.com/thomasduchatelle/dphoto/pkg/backupadapters/analysers/exif"
+	"io"
+	"path"
+	"strings"
+)
+
+const (
+	// maxMetaSize is the maximum size of the 'meta' box ; images split in tiles have one item per tile.
+	maxMetaSize = 1024 * 1024
+	// maxExifSize is the maximum size of the EXIF block
+	maxExifSize = 1024 * 1024
+)
+
+type Parser struct{}
+
+func (p *Parser) Supports(media backup.FoundMedia, mediaType backup.MediaType) bool {
+	ext := strings.ToUpper(path.Ext(media.MediaPath().Filename))
+	return ext == ".HEIC" || ext == ".HEIF"
+}
+
+func (p *Parser) ReadDetails(reader io.Reader, options backup.DetailsReaderOptions) (*backup.MediaDetails, error) {
+	var position uint64
+	for {
+		code, size, header, err := readBoxHeader(reader)
+		if err == io.EOF {
+			return nil, errors.Errorf("no 'meta' box found in HEIF file")
+		} else if err != nil {
+			return nil, err
+		}
+		position += header
+
+		if code != "meta" {
+			if _, err = io.CopyN(io.Discard, reader, int64(size-header)); err != nil {
+				return nil, errors.Wrapf(err, "failed to skip '%s' box", code)
+			}
+			position += size - header
+			continue
+		}
+
+		if size-header > maxMetaSize {
+			return nil, errors.Errorf("'meta' box is too big: %d bytes", size)
+		}
+		payload := make([]byte, size-header)
+		if _, err = io.ReadFull(reader, payload); err != nil {
+			return nil, errors.Wrapf(err, "failed to read 'meta' box")
+		}
+		position += size - header
+
+		meta := parseMeta(payload)
+		details, err := p.readExif(reader, position, meta, options)
+		if err != nil {
+			return nil, err
+		}
+
+		details.Width, details.Height = meta.width, meta.height
+		return details, nil
+	}
+}
+
+// readExif reads the EXIF block following the 'meta' box, or from it, and decodes it.
+func (p *Parser) readExif(reader io.Reader, position uint64, meta *metaBox, options backup.DetailsReaderOptions) (*backup.MediaDetails, error) {
+	var block []byte
+	switch location := meta.exif; {
+	case location == nil || location.length < 4 || location.length > maxExifSize:
+		return &backup.MediaDetails{Orientation: backup.OrientationUpperLeft}, nil
+
+	case location.inMeta:
+		if location.offset+location.length > uint64(len(meta.data)) {
+			return nil, errors.Errorf("EXIF block is out of the 'idat' box")
+		}
+		block = meta.data[location.offset : location.offset+location.length]
+
+	default:
+		if location.offset < position {
+			return nil, errors.Errorf("EXIF block before the end of the 'meta' box is not supported")
+		}
+		if _, err := io.CopyN(io.Discard, reader, int64(location.offset-position)); err != nil {
+			return nil, errors.Wrapf(err, "failed to reach the EXIF block")
+		}
+		block = make([]byte, location.length)
+		if _, err := io.ReadFull(reader, block); err != nil {
+			return nil, errors.Wrapf(err, "failed to read the EXIF block")
+		}
+	}
+
+	// the block starts with the offset of the TIFF header, usually after 'Exif\0\0'
+	tiffOffset := uint64(binary.BigEndian.Uint32(block[:4])) + 4
+	if tiffOffset >= uint64(len(block)) {
+		return nil, errors.Errorf("invalid offset of the TIFF header in the EXIF block: %d", tiffOffset)
+	}
+
+	details, err := new(exif.Parser).ReadDetails(bytes.NewReader(block[tiffOffset:]), options)
+	return details, errors.Wrapf(err, "failed to decode the EXIF block")
+}
+
+// readBoxHeader returns the code of the box, its total size, and the size of the header that has been read.
+func readBoxHeader(reader io.Reader) (string, uint64, uint64, error) {
+	buffer := make([]byte, 8)
+	if _, err := io.ReadFull(reader, buffer); err != nil {
+		return "", 0, 0, err
+	}
+
+	code := string(buffer[4:8])
+	size := uint64(binary.BigEndian.Uint32(buffer[:4]))
+	header := uint64(8)
+	if size == 1 {
+		if _, err := io.ReadFull(reader, buffer); err != nil {
+			return "", 0, 0, err
+		}
+		size = binary.BigEndian.Uint64(buffer)
+		header = 16
+	}
+
+	if size < header {
+		return "", 0, 0, errors.Errorf("invalid size of the '%s' box: %d", code, size)
+	}
+	return code, size, header, nil
+}
@@ -0,0 +1,45 @@
+package heif
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/thomasduchatelle/dphoto/pkg/backup"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestHeifDetailsExtraction(t *testing.T) {
+	a := assert.New(t)
+
+	reader, err := os.Open("../../../test_resources/scan/iphone_live_photo.heic")
+	if !a.NoError(err) {
+		panic(err.Error())
+	}
+	defer reader.Close()
+
+	details, err := new(Parser).ReadDetails(reader, backup.DetailsReaderOptions{})
+	if a.NoError(err) {
+		a.Equal(time.Date(2023, 7, 14, 16, 30, 5, 0, time.UTC), details.DateTime.UTC())
+		a.Equal(&backup.MediaDetails{
+			Width:         4032,
+			Height:        3024,
+			DateTime:      details.DateTime,
+			TimeReference: backup.TimeReferenceZoned,
+			Orientation:   backup.OrientationUpperLeft,
+			Make:          "Apple",
+			Model:         "iPhone 14 Pro",
+			LivePhotoId:   "7E1F8A2C-3B4D-4E5F-9A6B-1C2D3E4F5A6B",
+		}, details)
+	}
+}
+
+func TestHeifDetailsExtraction_notHeif(t *testing.T) {
+	reader, err := os.Open("../../../test_resources/scan/golang-logo.jpeg")
+	if !assert.NoError(t, err) {
+		panic(err.Error())
+	}
+	defer reader.Close()
+
+	_, err = new(Parser).ReadDetails(reader, backup.DetailsReaderOptions{})
+	assert.Error(t, err, "it should fail when the file is not a HEIF image")
+}
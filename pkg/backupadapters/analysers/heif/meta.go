@@ -0,0 +1,255 @@
+package heif
+
+import (
+	"encoding/binary"
+)
+
+// metaBox is what is used from the 'meta' box: the dimensions of the primary item, and where the EXIF block is.
+type metaBox struct {
+	width, height int
+	exif          *itemLocation
+	data          []byte // data is the content of the 'idat' box
+}
+
+// itemLocation is where the content of an item is ; only items with a single extent are supported.
+type itemLocation struct {
+	inMeta bool // inMeta is true when the offset is relative to the 'idat' box, it's absolute within the file otherwise
+	offset uint64
+	length uint64
+}
+
+// parseMeta reads the boxes of interest, malformed ones are ignored.
+func parseMeta(payload []byte) *metaBox {
+	meta := new(metaBox)
+	if len(payload) < 4 {
+		return meta
+	}
+
+	var primary uint32
+	var exifItem uint32
+	var locations map[uint32]itemLocation
+	var properties [][]byte
+	associations := make(map[uint32][]int)
+
+	walkBoxes(payload[4:], func(code string, content []byte) {
+		switch code {
+		case "pitm":
+			primary = readFullBoxItemId(content)
+		case "iinf":
+			exifItem = findExifItem(content)
+		case "iloc":
+			locations = parseItemLocations(content)
+		case "idat":
+			meta.data = content
+		case "iprp":
+			walkBoxes(content, func(code string, content []byte) {
+				switch code {
+				case "ipco":
+					walkBoxes(content, func(code string, content []byte) {
+						if code == "ispe" {
+							properties = append(properties, content)
+						} else {
+							properties = append(properties, nil)
+						}
+					})
+				case "ipma":
+					parseItemPropertyAssociations(content, associations)
+				}
+			})
+		}
+	})
+
+	for _, index := range associations[primary] {
+		if index > 0 && index <= len(properties) && len(properties[index-1]) >= 12 {
+			meta.width = int(binary.BigEndian.Uint32(properties[index-1][4:8]))
+			meta.height = int(binary.BigEndian.Uint32(properties[index-1][8:12]))
+		}
+	}
+
+	if location, found := locations[exifItem]; found && exifItem > 0 {
+		meta.exif = &location
+	}
+	return meta
+}
+
+// readFullBoxItemId reads the item id of a 'pitm' box
+func readFullBoxItemId(content []byte) uint32 {
+	cursor := &byteCursor{content: content}
+	version := cursor.read(1)
+	cursor.read(3)
+	if version == 0 {
+		return uint32(cursor.read(2))
+	}
+	return uint32(cursor.read(4))
+}
+
+// findExifItem returns the id of the item which type is 'Exif', 0 if none.
+func findExifItem(content []byte) uint32 {
+	cursor := &byteCursor{content: content}
+	version := cursor.read(1)
+	cursor.read(3)
+	if version == 0 {
+		cursor.read(2)
+	} else {
+		cursor.read(4)
+	}
+	if cursor.failed {
+		return 0
+	}
+
+	var exifItem uint32
+	walkBoxes(content[cursor.offset:], func(code string, content []byte) {
+		entry := &byteCursor{content: content}
+		version := entry.read(1)
+		entry.read(3)
+		if code != "infe" || version < 2 {
+			return
+		}
+
+		var id uint32
+		if version == 2 {
+			id = uint32(entry.read(2))
+		} else {
+			id = uint32(entry.read(4))
+		}
+		entry.read(2) // protection index
+		if itemType := entry.bytes(4); !entry.failed && string(itemType) == "Exif" {
+			exifItem = id
+		}
+	})
+
+	return exifItem
+}
+
+// parseItemLocations reads the 'iloc' box ; items from other files, or split in several extents, are ignored.
+func parseItemLocations(content []byte) map[uint32]itemLocation {
+	locations := make(map[uint32]itemLocation)
+
+	cursor := &byteCursor{content: content}
+	version := cursor.read(1)
+	cursor.read(3)
+	sizes := cursor.read(2)
+	offsetSize, lengthSize, baseOffsetSize, indexSize := int(sizes>>12), int(sizes>>8&0xf), int(sizes>>4&0xf), 0
+	if version == 1 || version == 2 {
+		indexSize = int(sizes & 0xf)
+	}
+
+	var count uint64
+	if version < 2 {
+		count = cursor.read(2)
+	} else {
+		count = cursor.read(4)
+	}
+
+	for i := uint64(0); i < count && !cursor.failed; i++ {
+		var id uint32
+		if version < 2 {
+			id = uint32(cursor.read(2))
+		} else {
+			id = uint32(cursor.read(4))
+		}
+
+		constructionMethod := uint64(0)
+		if version == 1 || version == 2 {
+			constructionMethod = cursor.read(2) & 0xf
+		}
+		dataReferenceIndex := cursor.read(2)
+		baseOffset := cursor.read(baseOffsetSize)
+
+		extentCount := cursor.read(2)
+		var location itemLocation
+		for extent := uint64(0); extent < extentCount; extent++ {
+			cursor.read(indexSize)
+			location.offset = baseOffset + cursor.read(offsetSize)
+			location.length = cursor.read(lengthSize)
+		}
+
+		if extentCount == 1 && dataReferenceIndex == 0 && constructionMethod <= 1 {
+			location.inMeta = constructionMethod == 1
+			locations[id] = location
+		}
+	}
+
+	if cursor.failed {
+		return nil
+	}
+	return locations
+}
+
+// parseItemPropertyAssociations reads the 'ipma' box: the 1-based indexes of the properties of each item.
+func parseItemPropertyAssociations(content []byte, associations map[uint32][]int) {
+	cursor := &byteCursor{content: content}
+	version := cursor.read(1)
+	flags := cursor.read(3)
+
+	count := cursor.read(4)
+	for i := uint64(0); i < count && !cursor.failed; i++ {
+		var id uint32
+		if version < 1 {
+			id = uint32(cursor.read(2))
+		} else {
+			id = uint32(cursor.read(4))
+		}
+
+		associationCount := cursor.read(1)
+		for association := uint64(0); association < associationCount && !cursor.failed; association++ {
+			if flags&1 == 1 {
+				associations[id] = append(associations[id], int(cursor.read(2)&0x7fff))
+			} else {
+				associations[id] = append(associations[id], int(cursor.read(1)&0x7f))
+			}
+		}
+	}
+}
+
+// walkBoxes calls visit for each box found in the payload ; it stops on the first malformed one.
+func walkBoxes(payload []byte, visit func(code string, content []byte)) {
+	for len(payload) >= 8 {
+		size := uint64(binary.BigEndian.Uint32(payload[:4]))
+		header := uint64(8)
+		switch size {
+		case 0:
+			size = uint64(len(payload))
+		case 1:
+			if len(payload) < 16 {
+				return
+			}
+			size = binary.BigEndian.Uint64(payload[8:16])
+			header = 16
+		}
+
+		if size < header || size > uint64(len(payload)) {
+			return
+		}
+
+		visit(string(payload[4:8]), payload[header:size])
+		payload = payload[size:]
+	}
+}
+
+// byteCursor reads big-endian integers ; failed is set once the content is too short, and zeros are returned.
+type byteCursor struct {
+	content []byte
+	offset  int
+	failed  bool
+}
+
+// read returns an unsigned integer of 0 to 8 bytes.
+func (c *byteCursor) read(size int) uint64 {
+	var value uint64
+	for _, b := range c.bytes(size) {
+		value = value<<8 | uint64(b)
+	}
+	return value
+}
+
+func (c *byteCursor) bytes(size int) []byte {
+	if c.failed || c.offset+size > len(c.content) {
+		c.failed = true
+		return nil
+	}
+
+	value := c.content[c.offset : c.offset+size]
+	c.offset += size
+	return value
+}
@@ -2,17 +2,26 @@ package analysers
 
 import (
 	"github.com/thomasduchatelle/dphoto/pkg/backup"
+	"github.com/thomasduchatelle/dphoto/pkg/backupadapters/analysers/asf"
 	"github.com/thomasduchatelle/dphoto/pkg/backupadapters/analysers/avi"
 	"github.com/thomasduchatelle/dphoto/pkg/backupadapters/analysers/exif"
+	"github.com/thomasduchatelle/dphoto/pkg/backupadapters/analysers/heif"
 	"github.com/thomasduchatelle/dphoto/pkg/backupadapters/analysers/m2ts"
+	"github.com/thomasduchatelle/dphoto/pkg/backupadapters/analysers/mkv"
 	"github.com/thomasduchatelle/dphoto/pkg/backupadapters/analysers/mp4"
+	"github.com/thomasduchatelle/dphoto/pkg/backupadapters/analysers/mpegps"
+	"github.com/thomasduchatelle/dphoto/pkg/backupadapters/analysers/xmp"
 )
 
 func ListDetailReaders() []backup.DetailsReader {
-	return []backup.DetailsReader{
+	return xmp.Decorate(
+		new(asf.Parser),
 		new(avi.Parser),
+		new(heif.Parser), // before exif: HEIF images are images with EXIF blocks that exif parser can't find
 		new(exif.Parser),
 		new(m2ts.Parser),
+		new(mkv.Parser),
 		new(mp4.Parser),
-	}
+		new(mpegps.Parser),
+	)
 }
@@ -0,0 +1,164 @@
+package mkv
+
+import (
+	"encoding/binary"
+	"github.com/pkg/errors"
+	"io"
+	"math"
+)
+
+const (
+	// UnknownSize is the size of an element which is only known once its parent, or the file, ends (live streams).
+	UnknownSize int64 = -1
+
+	maxIDLength   = 4
+	maxSizeLength = 8
+)
+
+// Element is the header of an EBML element: an ID and the size of its payload.
+type Element struct {
+	ID   uint32
+	Size int64 // Size is the length of the payload in bytes, UnknownSize if not known
+}
+
+// ChildElement is a child decoded from the payload of a master element, its Data is the raw payload.
+type ChildElement struct {
+	ID   uint32
+	Data []byte
+}
+
+// ReadElement reads the header of the next element ; the reader is positioned at the start of its payload.
+func ReadElement(reader io.Reader) (Element, error) {
+	id, _, err := readVint(reader, maxIDLength, true)
+	if err != nil {
+		return Element{}, err
+	}
+
+	size, length, err := readVint(reader, maxSizeLength, false)
+	if err != nil {
+		return Element{}, noEOF(err)
+	}
+
+	element := Element{ID: uint32(id), Size: int64(size)}
+	if size == 1<<(7*length)-1 {
+		element.Size = UnknownSize
+	}
+	return element, nil
+}
+
+// ParseChildren decodes all the elements contained in the payload of a master element.
+func ParseChildren(payload []byte) ([]ChildElement, error) {
+	var children []ChildElement
+	for offset := 0; offset < len(payload); {
+		reader := &countingReader{data: payload[offset:]}
+		element, err := ReadElement(reader)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid EBML element at offset %d", offset)
+		}
+
+		start := offset + reader.read
+		if element.Size == UnknownSize || start+int(element.Size) > len(payload) {
+			return nil, errors.Errorf("EBML element %X at offset %d overflows its parent", element.ID, offset)
+		}
+
+		children = append(children, ChildElement{ID: element.ID, Data: payload[start : start+int(element.Size)]})
+		offset = start + int(element.Size)
+	}
+
+	return children, nil
+}
+
+// Uint decodes an unsigned integer element (big-endian, 0 to 8 bytes).
+func (c ChildElement) Uint() uint64 {
+	var value uint64
+	for _, b := range c.Data {
+		value = value<<8 | uint64(b)
+	}
+	return value
+}
+
+// Int decodes a signed integer element (big-endian two's complement, 0 to 8 bytes).
+func (c ChildElement) Int() int64 {
+	if len(c.Data) == 0 {
+		return 0
+	}
+
+	value := c.Uint()
+	shift := 64 - 8*uint(len(c.Data))
+	return int64(value<<shift) >> shift
+}
+
+// Float decodes a 4 or 8 bytes IEEE-754 float element.
+func (c ChildElement) Float() float64 {
+	switch len(c.Data) {
+	case 4:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(c.Data)))
+	case 8:
+		return math.Float64frombits(binary.BigEndian.Uint64(c.Data))
+	default:
+		return 0
+	}
+}
+
+// String decodes a string element, null padding is removed.
+func (c ChildElement) String() string {
+	end := len(c.Data)
+	for end > 0 && c.Data[end-1] == 0 {
+		end--
+	}
+	return string(c.Data[:end])
+}
+
+// readVint reads a variable size integer ; the length marker is kept for IDs and removed for sizes.
+func readVint(reader io.Reader, maxLength int, keepMarker bool) (uint64, int, error) {
+	first := make([]byte, 1)
+	if _, err := io.ReadFull(reader, first); err != nil {
+		return 0, 0, err
+	}
+
+	length := 1
+	for mask := byte(0x80); length <= maxLength && first[0]&mask == 0; mask >>= 1 {
+		length++
+	}
+	if length > maxLength {
+		return 0, 0, errors.Errorf("invalid EBML variable size integer starting with %#02x", first[0])
+	}
+
+	value := uint64(first[0])
+	if !keepMarker {
+		value &= uint64(0xff >> length)
+	}
+
+	rest := make([]byte, length-1)
+	if _, err := io.ReadFull(reader, rest); err != nil {
+		return 0, 0, noEOF(err)
+	}
+	for _, b := range rest {
+		value = value<<8 | uint64(b)
+	}
+
+	return value, length, nil
+}
+
+// noEOF is used when the file ends in the middle of an element.
+func noEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+type countingReader struct {
+	data []byte
+	read int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	if r.read >= len(r.data) {
+		return 0, io.EOF
+	}
+
+	n := copy(p, r.data[r.read:])
+	r.read += n
+	return n, nil
+}
@@ -0,0 +1,261 @@
+// Package mkv parses Matroska and WebM files to retrieve the creation date, duration, resolution and codec of the video.
+// The file is streamed: only the Segment Info and Tracks elements are loaded, clusters are skipped.
+// References:
+// - https://www.matroska.org/technical/elements.html
+// - https://www.rfc-editor.org/rfc/rfc8794 (EBML)
+package mkv
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/thomasduchatelle/dphoto/pkg/backup"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	idEBML           = 0x1A45DFA3
+	idDocType        = 0x4282
+	idSegment        = 0x18538067
+	idInfo           = 0x1549A966
+	idTimestampScale = 0x2AD7B1
+	idDuration       = 0x4489
+	idDateUTC        = 0x4461
+	idTracks         = 0x1654AE6B
+	idTrackEntry     = 0xAE
+	idTrackType      = 0x83
+	idCodecID        = 0x86
+	idVideo          = 0xE0
+	idPixelWidth     = 0xB0
+	idPixelHeight    = 0xBA
+	idCluster        = 0x1F43B675
+
+	trackTypeVideo        = 1
+	defaultTimestampScale = 1000000          // defaultTimestampScale is in nanoseconds: timestamps are in milliseconds by default
+	maxMetadataSize       = 16 * 1024 * 1024 // maxMetadataSize protects from loading a corrupted element in memory
+)
+
+var (
+	// dateUTCEpoch is the origin of DateUTC: the millennium
+	dateUTCEpoch = time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	codecNames = map[string]string{
+		"V_MPEG4/ISO/AVC":  "H264",
+		"V_MPEGH/ISO/HEVC": "H265",
+		"V_VP8":            "VP8",
+		"V_VP9":            "VP9",
+		"V_AV1":            "AV1",
+		"V_MPEG4/ISO/ASP":  "MPEG4",
+		"V_MPEG2":          "MPEG2",
+		"V_MJPEG":          "MJPEG",
+		"V_THEORA":         "THEORA",
+	}
+)
+
+type Parser struct {
+	Debug bool // Debug can be set to true to print in the console the elements read.
+}
+
+func (p *Parser) Supports(media backup.FoundMedia, mediaType backup.MediaType) bool {
+	ext := strings.ToUpper(path.Ext(media.MediaPath().Filename))
+	return mediaType == backup.MediaTypeVideo && (ext == ".MKV" || ext == ".WEBM")
+}
+
+// ReadDetails reads the top level elements of the Segment until both Info and Tracks have been parsed.
+func (p *Parser) ReadDetails(reader io.Reader, options backup.DetailsReaderOptions) (*backup.MediaDetails, error) {
+	details := new(backup.MediaDetails)
+
+	header, err := ReadElement(reader)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid Matroska file")
+	}
+	if header.ID != idEBML {
+		return nil, errors.Errorf("Matroska file must start with an EBML header, not %X", header.ID)
+	}
+	payload, err := p.readPayload(reader, header)
+	if err != nil {
+		return nil, err
+	}
+	if err = p.checkDocType(payload); err != nil {
+		return nil, err
+	}
+
+	infoParsed, tracksParsed := false, false
+	for !infoParsed || !tracksParsed {
+		element, err := ReadElement(reader)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		if p.Debug {
+			fmt.Printf("'%X' element [%d]\n", element.ID, element.Size)
+		}
+
+		switch element.ID {
+		case idSegment:
+			// children of the segment are read as if they were top level elements
+
+		case idInfo:
+			payload, err = p.readPayload(reader, element)
+			if err != nil {
+				return nil, err
+			}
+			if err = p.parseInfo(details, payload); err != nil {
+				return nil, err
+			}
+			infoParsed = true
+
+			if options.Fast && !details.DateTime.IsZero() {
+				return details, nil
+			}
+
+		case idTracks:
+			payload, err = p.readPayload(reader, element)
+			if err != nil {
+				return nil, err
+			}
+			if err = p.parseTracks(details, payload); err != nil {
+				return nil, err
+			}
+			tracksParsed = true
+
+		default:
+			if element.Size == UnknownSize {
+				if element.ID == idCluster {
+					// live streams have clusters of unknown size, metadata is not expected after them
+					return details, nil
+				}
+				return nil, errors.Errorf("Matroska element %X has an unknown size and can't be skipped", element.ID)
+			}
+
+			_, err = io.CopyN(io.Discard, reader, element.Size)
+			if err != nil {
+				return nil, noEOF(err)
+			}
+		}
+	}
+
+	return details, nil
+}
+
+func (p *Parser) readPayload(reader io.Reader, element Element) ([]byte, error) {
+	if element.Size == UnknownSize || element.Size > maxMetadataSize {
+		return nil, errors.Errorf("Matroska element %X is too large to be read [size: %d]", element.ID, element.Size)
+	}
+
+	payload := make([]byte, element.Size)
+	_, err := io.ReadFull(reader, payload)
+	return payload, noEOF(err)
+}
+
+func (p *Parser) checkDocType(payload []byte) error {
+	children, err := ParseChildren(payload)
+	if err != nil {
+		return err
+	}
+
+	for _, child := range children {
+		if child.ID == idDocType && child.String() != "matroska" && child.String() != "webm" {
+			return errors.Errorf("'%s' EBML document is not a Matroska file", child.String())
+		}
+	}
+
+	return nil
+}
+
+func (p *Parser) parseInfo(details *backup.MediaDetails, payload []byte) error {
+	children, err := ParseChildren(payload)
+	if err != nil {
+		return err
+	}
+
+	timestampScale := uint64(defaultTimestampScale)
+	var duration float64
+	for _, child := range children {
+		switch child.ID {
+		case idTimestampScale:
+			if scale := child.Uint(); scale > 0 {
+				timestampScale = scale
+			}
+
+		case idDuration:
+			duration = child.Float()
+
+		case idDateUTC:
+			if len(child.Data) == 8 {
+				details.DateTime = dateUTCEpoch.Add(time.Duration(child.Int()))
+				details.TimeReference = backup.TimeReferenceInstant
+			}
+		}
+	}
+
+	details.Duration = int64(duration * float64(timestampScale) / float64(time.Millisecond))
+	return nil
+}
+
+// parseTracks uses the first video track ; audio and subtitles tracks are ignored.
+func (p *Parser) parseTracks(details *backup.MediaDetails, payload []byte) error {
+	entries, err := ParseChildren(payload)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.ID != idTrackEntry {
+			continue
+		}
+
+		children, err := ParseChildren(entry.Data)
+		if err != nil {
+			return err
+		}
+
+		var trackType uint64
+		var codec string
+		var width, height int
+		for _, child := range children {
+			switch child.ID {
+			case idTrackType:
+				trackType = child.Uint()
+
+			case idCodecID:
+				codec = child.String()
+
+			case idVideo:
+				video, err := ParseChildren(child.Data)
+				if err != nil {
+					return err
+				}
+				for _, dimension := range video {
+					switch dimension.ID {
+					case idPixelWidth:
+						width = int(dimension.Uint())
+					case idPixelHeight:
+						height = int(dimension.Uint())
+					}
+				}
+			}
+		}
+
+		if trackType == trackTypeVideo {
+			details.Width = width
+			details.Height = height
+			details.VideoEncoding = codecName(codec)
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// codecName converts Matroska codec IDs into the names used by the other parsers (ex: 'V_MPEG4/ISO/AVC' -> 'H264').
+func codecName(codecID string) string {
+	if name, found := codecNames[codecID]; found {
+		return name
+	}
+	return strings.TrimPrefix(codecID, "V_")
+}
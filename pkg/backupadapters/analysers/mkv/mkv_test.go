@@ -0,0 +1,125 @@
+package mkv
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/thomasduchatelle/dphoto/pkg/backup"
+	"io"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMkvDetailsExtraction(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		options backup.DetailsReaderOptions
+		want    *backup.MediaDetails
+	}{
+		{
+			name: "it should read date, duration, resolution and codec of a Matroska video",
+			file: "../../../test_resources/scan/sample.mkv",
+			want: &backup.MediaDetails{
+				DateTime:      time.Date(2021, 7, 14, 18, 32, 5, 0, time.UTC),
+				TimeReference: backup.TimeReferenceInstant,
+				Duration:      12500,
+				Width:         1920,
+				Height:        1080,
+				VideoEncoding: "H264",
+			},
+		},
+		{
+			name: "it should read a WebM stream with an unknown segment size and the audio track first",
+			file: "../../../test_resources/scan/sample.webm",
+			want: &backup.MediaDetails{
+				DateTime:      time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC),
+				TimeReference: backup.TimeReferenceInstant,
+				Duration:      3200,
+				Width:         640,
+				Height:        360,
+				VideoEncoding: "VP9",
+			},
+		},
+		{
+			name:    "it should stop after the Segment Info when only the date is requested",
+			file:    "../../../test_resources/scan/sample.mkv",
+			options: backup.DetailsReaderOptions{Fast: true},
+			want: &backup.MediaDetails{
+				DateTime:      time.Date(2021, 7, 14, 18, 32, 5, 0, time.UTC),
+				TimeReference: backup.TimeReferenceInstant,
+				Duration:      12500,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, err := os.Open(tt.file)
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer reader.Close()
+
+			parser := &Parser{Debug: true}
+			got, err := parser.ReadDetails(reader, tt.options)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestMkvDetailsExtraction_invalid(t *testing.T) {
+	content, err := os.ReadFile("../../../test_resources/scan/sample.mkv")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	tests := []struct {
+		name    string
+		content []byte
+		wantErr string
+	}{
+		{"it should reject files not starting with an EBML header", []byte("RIFF\x00\x00\x00\x00AVI "), "must start with an EBML header"},
+		{"it should reject EBML documents which are not Matroska", []byte("\x1a\x45\xdf\xa3\x84\x42\x82\x81x"), "'x' EBML document is not a Matroska file"},
+		{"it should fail when the file is truncated within the metadata", content[:120], "unexpected EOF"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := new(Parser).ReadDetails(bytes.NewReader(tt.content), backup.DetailsReaderOptions{})
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestReadElement(t *testing.T) {
+	tests := []struct {
+		name    string
+		content []byte
+		want    Element
+		wantErr error
+	}{
+		{"it should read a 1 byte ID and size", []byte{0xAE, 0x85}, Element{ID: 0xAE, Size: 5}, nil},
+		{"it should read a 4 bytes ID and a 8 bytes size", []byte{0x18, 0x53, 0x80, 0x67, 0x01, 0, 0, 0, 0, 0, 0x01, 0x02}, Element{ID: idSegment, Size: 0x0102}, nil},
+		{"it should flag unknown sizes", []byte{0x1F, 0x43, 0xB6, 0x75, 0xFF}, Element{ID: idCluster, Size: UnknownSize}, nil},
+		{"it should return EOF at the end of the file", nil, Element{}, io.EOF},
+		{"it should return unexpected EOF when the header is truncated", []byte{0x2A, 0xD7}, Element{}, io.ErrUnexpectedEOF},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadElement(bytes.NewReader(tt.content))
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestChildElement_values(t *testing.T) {
+	assert.Equal(t, uint64(1000000), ChildElement{Data: []byte{0x0F, 0x42, 0x40}}.Uint())
+	assert.Equal(t, int64(-2), ChildElement{Data: []byte{0xFF, 0xFE}}.Int())
+	assert.Equal(t, 3200.0, ChildElement{Data: []byte{0x45, 0x48, 0x00, 0x00}}.Float())
+	assert.Equal(t, "webm", ChildElement{Data: []byte("webm\x00\x00")}.String())
+}
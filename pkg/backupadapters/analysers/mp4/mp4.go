@@ -18,6 +18,9 @@ import (
 	"time"
 )
 
+// maxMetadataSize is the maximum size read from 'udta' and 'meta' atoms.
+const maxMetadataSize = 64 * 1024
+
 type Parser struct {
 	Debug bool
 }
@@ -30,15 +33,17 @@ func (p *Parser) Supports(media backup.FoundMedia, mediaType backup.MediaType) b
 func (p *Parser) ReadDetails(reader io.Reader, options backup.DetailsReaderOptions) (*backup.MediaDetails, error) {
 	details := new(backup.MediaDetails)
 
+	moovRead := false
 	decoder := NewAtomDecoder(reader)
 	for {
 		atom, payload, err := decoder.Next()
-		if err == io.EOF || options.Fast && !details.DateTime.IsZero() || !details.DateTime.IsZero() && details.VideoEncoding != "" && details.Width > 0 && details.Height > 0 && details.GPSLongitude != 0 && details.GPSLatitude != 0 {
-			// end of file (EOF is expected to notify the end of the file) ; or all details already extracted
+		if err == io.EOF || options.Fast && !details.DateTime.IsZero() || moovRead && atom != nil && !strings.Contains(atom.Path, ".") {
+			// end of file (EOF is expected to notify the end of the file) ; or all details already extracted: they are all in 'moov'
 			break
 		} else if err != nil {
 			return nil, err
 		}
+		moovRead = moovRead || atom.Code == "moov"
 
 		if p.Debug {
 			fmt.Printf("'%s' atom [%d]:\n", atom.Path, atom.Size)
@@ -71,12 +76,19 @@ func (p *Parser) ReadDetails(reader io.Reader, options backup.DetailsReaderOptio
 			p.parseTKHD(details, buffer)
 
 		case "moov.udta":
-			buffer, err := payload.Next(1024)
+			buffer, err := payload.Next(maxMetadataSize)
 			if err != nil {
 				return nil, err
 			}
 			p.parseUDTA(details, buffer)
 
+		case "moov.meta":
+			buffer, err := payload.Next(maxMetadataSize)
+			if err != nil {
+				return nil, err
+			}
+			p.parseMeta(details, buffer)
+
 		default:
 			if p.Debug {
 				if !atom.IsParent {
@@ -116,7 +128,11 @@ func (p *Parser) parseMVHD(details *backup.MediaDetails, payload []byte) {
 	}
 
 	if timestampsFrom1904 > 0 && timescale > 0 {
-		details.DateTime = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(timestampsFrom1904) * time.Second)
+		if details.TimeReference != backup.TimeReferenceZoned {
+			// the creation date from QuickTime metadata is more accurate: it has the offset of the camera
+			details.DateTime = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(timestampsFrom1904) * time.Second)
+			details.TimeReference = backup.TimeReferenceInstant
+		}
 		details.Duration = int64(1000 * duration / uint64(timescale))
 	}
 }
@@ -136,18 +152,11 @@ func (p *Parser) parseTKHD(details *backup.MediaDetails, buffer []byte) {
 	details.Height = int(binary.BigEndian.Uint16(buffer[4:6]))
 }
 
-func (p *Parser) parseUDTA(details *backup.MediaDetails, payload []byte) {
-	if len(payload) >= 30 && binary.BigEndian.Uint32(payload[4:8]) == binary.BigEndian.Uint32([]byte("\xa9xyz")) {
-		gps := string(payload[12:30])
-		details.GPSLongitude, details.GPSLatitude = parseISO6709(gps)
-	}
-}
-
-// parseISO6709 parse a ISO-6709 GPS coordinates
+// parseISO6709 parse a ISO-6709 GPS coordinates, latitude first
 func parseISO6709(gps string) (float64, float64) {
-	degMatcher := regexp.MustCompile("(?P<LON_DEG>[+-]\\d{1,2}(\\.\\d*)?)(?P<LAT_DEG>[+-]\\d{1,3}(\\.\\d*)?)")
-	minutesMatcher := regexp.MustCompile("(?P<LON_DEG>[+-]\\d{1,2})(?P<LON_MIN>\\d{2}(\\.\\d*)?)(?P<LAT_DEG>[+-]\\d{1,3})(?P<LAT_MIN>\\d{2}(\\.\\d*)?)")
-	secMatcher := regexp.MustCompile("(?P<LON_DEG>[+-]\\d{1,2})(?P<LON_MIN>\\d{2})(?P<LON_SEC>\\d{2}(\\.\\d*)?)(?P<LAT_DEG>[+-]\\d{1,3})(?P<LAT_MIN>\\d{2})(?P<LAT_SEC>\\d{2}(\\.\\d*)?)")
+	degMatcher := regexp.MustCompile("(?P<LAT_DEG>[+-]\\d{1,2}(\\.\\d*)?)(?P<LON_DEG>[+-]\\d{1,3}(\\.\\d*)?)")
+	minutesMatcher := regexp.MustCompile("(?P<LAT_DEG>[+-]\\d{1,2})(?P<LAT_MIN>\\d{2}(\\.\\d*)?)(?P<LON_DEG>[+-]\\d{1,3})(?P<LON_MIN>\\d{2}(\\.\\d*)?)")
+	secMatcher := regexp.MustCompile("(?P<LAT_DEG>[+-]\\d{1,2})(?P<LAT_MIN>\\d{2})(?P<LAT_SEC>\\d{2}(\\.\\d*)?)(?P<LON_DEG>[+-]\\d{1,3})(?P<LON_MIN>\\d{2})(?P<LON_SEC>\\d{2}(\\.\\d*)?)")
 
 	submatch := degMatcher.FindStringSubmatch(gps)
 	expNames := degMatcher.SubexpNames()
@@ -186,7 +195,7 @@ func parseISO6709(gps string) (float64, float64) {
 			}
 		}
 
-		return lonDeg + lonMin/60 + lonSec/3600, latDeg + latMin/60 + latSec/3600
+		return latDeg + latMin/60 + latSec/3600, lonDeg + lonMin/60 + lonSec/3600
 	}
 
 	return 0, 0
@@ -26,6 +26,7 @@ func TestMp4DetailsExtraction(t *testing.T) {
 		fmt.Printf("Parsed date is %s\n", details.DateTime.Format("2006-01-02 15:04:05"))
 		a.Equal(&backup.MediaDetails{
 			DateTime:      time.Date(2019, 8, 18, 9, 57, 55, 0, time.UTC),
+			TimeReference: backup.TimeReferenceInstant,
 			Duration:      1801,
 			Height:        1080,
 			VideoEncoding: "MP4",
@@ -51,12 +52,13 @@ func TestMp4DetailsExtraction_android8(t *testing.T) {
 		fmt.Printf("Parsed date is %s\n", details.DateTime.Format("2006-01-02 15:04:05"))
 		a.Equal(&backup.MediaDetails{
 			DateTime:      time.Date(2018, 3, 6, 15, 22, 25, 0, time.UTC),
+			TimeReference: backup.TimeReferenceInstant,
 			Duration:      13071,
 			Height:        1080,
 			VideoEncoding: "MP4",
 			Width:         1920,
-			GPSLatitude:   0.2952,
-			GPSLongitude:  42.8127,
+			GPSLatitude:   42.8127,
+			GPSLongitude:  0.2952,
 		}, details)
 	}
 }
@@ -67,19 +69,63 @@ func TestParseISO6709(t *testing.T) {
 	tests := []struct {
 		name    string
 		gps     string
-		wantLon float64
 		wantLat float64
+		wantLon float64
 	}{
-		{"it should parse simple GPS lon / lat", "+48.8577+002.295/", 48.8577, 2.295},
+		{"it should parse simple GPS lat / lon", "+48.8577+002.295/", 48.8577, 2.295},
 		{"it should not parse an invalid coordinates", "POLE NORTH > Santa Village", 0, 0},
 		{"it should parse DDMM.M format", "+4851.462+00217.7/", 48.8577, 2.295},
 		{"it should parse DDMMSS.S format", "+485127.72+0021742/", 48.8577, 2.295},
 	}
 
 	for _, tt := range tests {
-		gotLon, gotLat := parseISO6709(tt.gps)
-		a.Equal(tt.wantLon, gotLon, tt.name)
+		gotLat, gotLon := parseISO6709(tt.gps)
 		a.Equal(tt.wantLat, gotLat, tt.name)
+		a.Equal(tt.wantLon, gotLon, tt.name)
+	}
+
+}
+
+func TestMp4DetailsExtraction_quicktimeMetadata(t *testing.T) {
+	a := assert.New(t)
+
+	reader, err := os.Open("../../../test_resources/scan/sample_iphone.mov")
+	if !a.NoError(err) {
+		panic(err.Error())
+	}
+
+	details, err := new(Parser).ReadDetails(reader, backup.DetailsReaderOptions{})
+	if a.NoError(err) {
+		a.Equal(time.Date(2023, 7, 14, 16, 30, 5, 0, time.UTC), details.DateTime.UTC(), "it should use the creation date with its offset")
+		a.Equal(time.Date(2023, 7, 14, 18, 30, 5, 0, time.UTC), details.LocalDateTime())
+		a.Equal(backup.TimeReferenceZoned, details.TimeReference)
+		a.Equal("+02:00", details.TimeZone())
+		a.Equal(&backup.MediaDetails{
+			DateTime:      details.DateTime,
+			TimeReference: backup.TimeReferenceZoned,
+			Width:         1920,
+			Height:        1080,
+			Make:          "Apple",
+			Model:         "iPhone 14 Pro",
+			GPSLatitude:   48.8584,
+			GPSLongitude:  2.2945,
+			Duration:      5500,
+			VideoEncoding: "qt",
+		}, details)
+	}
+}
+
+func TestMp4DetailsExtraction_livePhoto(t *testing.T) {
+	a := assert.New(t)
+
+	reader, err := os.Open("../../../test_resources/scan/iphone_live_photo.mov")
+	if !a.NoError(err) {
+		panic(err.Error())
 	}
 
+	details, err := new(Parser).ReadDetails(reader, backup.DetailsReaderOptions{})
+	if a.NoError(err) {
+		a.Equal("7E1F8A2C-3B4D-4E5F-9A6B-1C2D3E4F5A6B", details.LivePhotoId, "it should read the content identifier shared with the photo")
+		a.Equal("iPhone 14 Pro", details.Model)
+	}
 }
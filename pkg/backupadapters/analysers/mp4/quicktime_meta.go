@@ -0,0 +1,171 @@
+package mp4
+
+import (
+	"encoding/binary"
+	"github.com/thomasduchatelle/dphoto/pkg/backup"
+	"strings"
+	"time"
+)
+
+const (
+	metaKeyCreationDate = "com.apple.quicktime.creationdate"
+	metaKeyLocation     = "com.apple.quicktime.location.ISO6709"
+	metaKeyMake         = "com.apple.quicktime.make"
+	metaKeyModel        = "com.apple.quicktime.model"
+	metaKeyLivePhotoId  = "com.apple.quicktime.content.identifier" // metaKeyLivePhotoId is the ContentIdentifier shared with the photo of a Live Photo
+
+	dataTypeUTF8 = 1 // dataTypeUTF8 is the 'well-known type' of the text values in a 'data' atom
+)
+
+var creationDateLayouts = []string{
+	"2006-01-02T15:04:05-0700",
+	"2006-01-02T15:04:05Z07:00",
+	"2006-01-02T15:04:05.000-0700",
+}
+
+// parseUDTA reads the user data atoms: '©xyz' (location), '©mak' (make), '©mod' (model), and an iTunes style 'meta' atom.
+func (p *Parser) parseUDTA(details *backup.MediaDetails, payload []byte) {
+	walkAtoms(payload, func(code string, content []byte) {
+		switch code {
+		case "\xa9xyz":
+			if latitude, longitude := parseISO6709(readUserDataText(content)); latitude != 0 || longitude != 0 {
+				details.GPSLatitude, details.GPSLongitude = latitude, longitude
+			}
+		case "\xa9mak":
+			details.Make = readUserDataText(content)
+		case "\xa9mod":
+			details.Model = readUserDataText(content)
+		case "meta":
+			p.parseMeta(details, content)
+		}
+	})
+}
+
+// parseMeta reads the QuickTime metadata: a 'keys' atom listing the names of the values stored in the 'ilst' atom.
+func (p *Parser) parseMeta(details *backup.MediaDetails, payload []byte) {
+	if len(payload) >= 12 && binary.BigEndian.Uint32(payload[:4]) == 0 && string(payload[8:12]) == "hdlr" {
+		payload = payload[4:] // MP4 'meta' atoms are starting by a version and flags ; QuickTime ones are not.
+	}
+
+	var keys []string
+	var items []byte
+	walkAtoms(payload, func(code string, content []byte) {
+		switch code {
+		case "keys":
+			keys = parseKeys(content)
+		case "ilst":
+			items = content
+		}
+	})
+
+	walkAtoms(items, func(code string, content []byte) {
+		index := int(binary.BigEndian.Uint32([]byte(code)))
+		if index < 1 || index > len(keys) {
+			return
+		}
+
+		value, isText := readMetaValue(content)
+		if !isText {
+			return
+		}
+
+		switch keys[index-1] {
+		case metaKeyCreationDate:
+			if creationDate, ok := parseCreationDate(value); ok {
+				details.DateTime = creationDate
+				details.TimeReference = backup.TimeReferenceZoned
+			}
+		case metaKeyLocation:
+			if latitude, longitude := parseISO6709(value); latitude != 0 || longitude != 0 {
+				details.GPSLatitude, details.GPSLongitude = latitude, longitude
+			}
+		case metaKeyMake:
+			details.Make = value
+		case metaKeyModel:
+			details.Model = value
+		case metaKeyLivePhotoId:
+			details.LivePhotoId = value
+		}
+	})
+}
+
+// parseKeys returns the names of the keys, the first one has the index 1 in 'ilst'.
+func parseKeys(payload []byte) []string {
+	if len(payload) < 8 {
+		return nil
+	}
+
+	count := int(binary.BigEndian.Uint32(payload[4:8]))
+	keys := make([]string, 0, count)
+	for offset := 8; len(keys) < count && offset+8 <= len(payload); {
+		size := int(binary.BigEndian.Uint32(payload[offset : offset+4]))
+		if size < 8 || offset+size > len(payload) {
+			break
+		}
+
+		keys = append(keys, string(payload[offset+8:offset+size]))
+		offset += size
+	}
+
+	return keys
+}
+
+// readMetaValue returns the value of the 'data' atom of an item, if it's a text.
+func readMetaValue(item []byte) (value string, isText bool) {
+	walkAtoms(item, func(code string, content []byte) {
+		if code == "data" && len(content) >= 8 && binary.BigEndian.Uint32(content[:4])&0xffffff == dataTypeUTF8 {
+			value, isText = strings.TrimRight(string(content[8:]), "\x00"), true
+		}
+	})
+
+	return
+}
+
+// readUserDataText reads a text from a '©...' user data atom: 2 bytes for the length, 2 bytes for the language, and the text.
+func readUserDataText(content []byte) string {
+	if len(content) < 4 {
+		return ""
+	}
+
+	length := int(binary.BigEndian.Uint16(content[:2]))
+	if length > len(content)-4 {
+		length = len(content) - 4
+	}
+	return strings.TrimRight(string(content[4:4+length]), "\x00")
+}
+
+// parseCreationDate reads the date recorded by the camera, with its offset.
+func parseCreationDate(value string) (time.Time, bool) {
+	for _, layout := range creationDateLayouts {
+		if creationDate, err := time.Parse(layout, value); err == nil {
+			return creationDate, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// walkAtoms calls visit for each atom found in the payload ; it stops on the first malformed one.
+func walkAtoms(payload []byte, visit func(code string, content []byte)) {
+	for len(payload) >= 8 {
+		size := uint64(binary.BigEndian.Uint32(payload[:4]))
+		header := uint64(8)
+		switch size {
+		case 0:
+			size = uint64(len(payload))
+		case 1:
+			if len(payload) < 16 {
+				return
+			}
+			size = binary.BigEndian.Uint64(payload[8:16])
+			header = 16
+		}
+
+		if size < header || size > uint64(len(payload)) {
+			return
+		}
+
+		visit(string(payload[4:8]), payload[header:size])
+		payload = payload[size:]
+	}
+}
@@ -0,0 +1,242 @@
+// Package mpegps parses MPEG-1 and MPEG-2 program streams (MPEG, MPG) to retrieve the duration, resolution and encoding of the video.
+// Program streams do not record when they have been captured: the date of the file is used instead.
+// References:
+// - https://en.wikipedia.org/wiki/MPEG_program_stream
+// - http://dvd.sourceforge.net/dvdinfo/packhdr.html
+// - http://dvd.sourceforge.net/dvdinfo/mpeghdrs.html
+package mpegps
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/thomasduchatelle/dphoto/pkg/backup"
+	"io"
+	"path"
+	"strings"
+)
+
+const (
+	codePackHeader     = 0xBA
+	codeProgramEnd     = 0xB9
+	codeSystemHeader   = 0xBB
+	codeVideoStreamMin = 0xE0
+	codeVideoStreamMax = 0xEF
+
+	scrClock             = 90 // scrClock is the frequency of the System Clock Reference base, in kHz
+	maxVideoPayloadsSize = 64 * 1024
+)
+
+var (
+	startCodePrefix   = []byte{0, 0, 1}
+	sequenceHeaderTag = []byte{0, 0, 1, 0xB3}
+)
+
+type Parser struct {
+	Debug bool // Debug can be set to true to print in the console the packets read.
+}
+
+func (p *Parser) Supports(media backup.FoundMedia, mediaType backup.MediaType) bool {
+	ext := strings.ToUpper(path.Ext(media.MediaPath().Filename))
+	return mediaType == backup.MediaTypeVideo && (ext == ".MPEG" || ext == ".MPG")
+}
+
+// FallbackOnFileDate is always true: program streams do not have any date.
+func (p *Parser) FallbackOnFileDate() bool {
+	return true
+}
+
+// ReadDetails reads all the packs: the duration is the sum of the differences between the first and the last System Clock Reference of each segment.
+// A new segment starts after a program end code, or when the System Clock Reference goes backward (streams concatenated, or clock restarted).
+// The resolution is read from the first sequence header found in a video stream.
+func (p *Parser) ReadDetails(reader io.Reader, options backup.DetailsReaderOptions) (*backup.MediaDetails, error) {
+	details := new(backup.MediaDetails)
+	buffered := bufio.NewReader(reader)
+
+	prefix := make([]byte, 4)
+	if _, err := io.ReadFull(buffered, prefix); err != nil {
+		return nil, errors.Wrapf(err, "invalid MPEG program stream")
+	}
+	if !bytes.Equal(prefix[:3], startCodePrefix) || prefix[3] != codePackHeader {
+		return nil, errors.Errorf("MPEG program stream must start with a pack header, not %x", prefix)
+	}
+
+	var duration, segmentSCR, lastSCR uint64
+	packs := 0
+	newSegment := true
+	var videoPayloads []byte
+
+	code := prefix[3]
+	for {
+		var err error
+		switch {
+		case code == codePackHeader:
+			var scr uint64
+			var encoding string
+			scr, encoding, err = readPackHeader(buffered)
+			if err == nil {
+				if packs == 0 {
+					details.VideoEncoding = encoding
+				}
+				if newSegment || scr < lastSCR {
+					duration += lastSCR - segmentSCR
+					segmentSCR = scr
+					newSegment = false
+				}
+				lastSCR = scr
+				packs++
+			}
+
+		case code == codeProgramEnd:
+			// streams can be concatenated after the end code, their clock is independent
+			newSegment = true
+
+		case code >= codeSystemHeader:
+			var payload []byte
+			payload, err = readPacket(buffered)
+			if err == nil && code >= codeVideoStreamMin && code <= codeVideoStreamMax && details.Width == 0 && len(videoPayloads) < maxVideoPayloadsSize {
+				videoPayloads = append(videoPayloads, pesData(payload)...)
+				p.parseSequenceHeader(details, videoPayloads)
+			}
+
+			if p.Debug {
+				fmt.Printf("'%02X' packet [%d]\n", code, len(payload))
+			}
+
+		default:
+			// not expected in a program stream: the next start code will be searched
+		}
+
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			// truncated files, common with camcorders, are still read
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		if options.Fast && details.Width > 0 {
+			break
+		}
+
+		code, err = nextStartCode(buffered)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	duration += lastSCR - segmentSCR
+	details.Duration = int64(duration / scrClock)
+	return details, nil
+}
+
+// parseSequenceHeader reads the 12 bits width and 12 bits height following the sequence header code.
+func (p *Parser) parseSequenceHeader(details *backup.MediaDetails, payload []byte) {
+	index := bytes.Index(payload, sequenceHeaderTag)
+	if index < 0 || index+len(sequenceHeaderTag)+3 > len(payload) {
+		return
+	}
+
+	dimensions := payload[index+len(sequenceHeaderTag):]
+	details.Width = int(dimensions[0])<<4 | int(dimensions[1])>>4
+	details.Height = int(dimensions[1]&0x0F)<<8 | int(dimensions[2])
+}
+
+// readPackHeader returns the System Clock Reference base (90kHz) and the encoding deduced from the version of the pack header.
+func readPackHeader(reader *bufio.Reader) (uint64, string, error) {
+	version, err := reader.Peek(1)
+	if err != nil {
+		return 0, "", err
+	}
+
+	switch {
+	case version[0]&0xC0 == 0x40:
+		// MPEG-2: '01' SCR[32..30] '1' SCR[29..15] '1' SCR[14..0] '1' SCR_ext[8..0] '1' mux_rate[22] '11' reserved[5] stuffing_length[3]
+		header := make([]byte, 10)
+		if _, err = io.ReadFull(reader, header); err != nil {
+			return 0, "", err
+		}
+
+		scr := uint64(header[0]&0x38)<<27 | uint64(header[0]&0x03)<<28 | uint64(header[1])<<20 | uint64(header[2]&0xF8)<<12 | uint64(header[2]&0x03)<<13 | uint64(header[3])<<5 | uint64(header[4])>>3
+		_, err = reader.Discard(int(header[9] & 0x07))
+		return scr, "MPEG2", err
+
+	case version[0]&0xF0 == 0x20:
+		// MPEG-1: '0010' SCR[32..30] '1' SCR[29..15] '1' SCR[14..0] '1' '1' mux_rate[22] '1'
+		header := make([]byte, 8)
+		if _, err = io.ReadFull(reader, header); err != nil {
+			return 0, "", err
+		}
+
+		scr := uint64(header[0]&0x0E)<<29 | uint64(header[1])<<22 | uint64(header[2]&0xFE)<<14 | uint64(header[3])<<7 | uint64(header[4])>>1
+		return scr, "MPEG1", nil
+
+	default:
+		return 0, "", errors.Errorf("MPEG pack header version is not supported: %08b", version[0])
+	}
+}
+
+// readPacket reads the payload of system header and PES packets, which are prefixed by their length.
+func readPacket(reader *bufio.Reader) ([]byte, error) {
+	length := make([]byte, 2)
+	if _, err := io.ReadFull(reader, length); err != nil {
+		return nil, err
+	}
+
+	payload := make([]byte, binary.BigEndian.Uint16(length))
+	_, err := io.ReadFull(reader, payload)
+	return payload, err
+}
+
+// pesData removes the header of a PES packet: MPEG-2 headers declare their length, MPEG-1 headers are decoded.
+func pesData(payload []byte) []byte {
+	if len(payload) >= 3 && payload[0]&0xC0 == 0x80 {
+		return payload[min(len(payload), 3+int(payload[2])):]
+	}
+
+	i := 0
+	for i < len(payload) && payload[i] == 0xFF {
+		i++ // stuffing
+	}
+	if i < len(payload) && payload[i]&0xC0 == 0x40 {
+		i += 2 // STD buffer
+	}
+	switch {
+	case i >= len(payload):
+		return nil
+	case payload[i]&0xF0 == 0x20:
+		i += 5 // PTS
+	case payload[i]&0xF0 == 0x30:
+		i += 10 // PTS and DTS
+	default:
+		i++ // '0000 1111' when there is no timestamp
+	}
+	return payload[min(len(payload), i):]
+}
+
+// nextStartCode skips bytes until the next '00 00 01' prefix and returns the code following it.
+func nextStartCode(reader *bufio.Reader) (byte, error) {
+	zeros := 0
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+
+		switch {
+		case b == 0:
+			zeros++
+		case b == 1 && zeros >= 2:
+			code, err := reader.ReadByte()
+			if err == io.EOF {
+				return 0, io.EOF
+			}
+			return code, err
+		default:
+			zeros = 0
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package mpegps
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/thomasduchatelle/dphoto/pkg/backup"
+	"os"
+	"testing"
+)
+
+func TestMpegDetailsExtraction(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		options backup.DetailsReaderOptions
+		want    *backup.MediaDetails
+	}{
+		{
+			name: "it should read the duration and resolution of a truncated MPEG-2 program stream",
+			file: "../../../test_resources/scan/sample.mpeg",
+			want: &backup.MediaDetails{
+				Duration:      6840,
+				Width:         720,
+				Height:        576,
+				VideoEncoding: "MPEG2",
+			},
+		},
+		{
+			name: "it should read the duration and resolution of a MPEG-1 program stream",
+			file: "../../../test_resources/scan/sample_mpeg1.mpg",
+			want: &backup.MediaDetails{
+				Duration:      2040,
+				Width:         352,
+				Height:        288,
+				VideoEncoding: "MPEG1",
+			},
+		},
+		{
+			name:    "it should stop after the first sequence header when only the date is requested",
+			file:    "../../../test_resources/scan/sample.mpeg",
+			options: backup.DetailsReaderOptions{Fast: true},
+			want: &backup.MediaDetails{
+				Duration:      400,
+				Width:         720,
+				Height:        576,
+				VideoEncoding: "MPEG2",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, err := os.Open(tt.file)
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer reader.Close()
+
+			parser := &Parser{Debug: true}
+			got, err := parser.ReadDetails(reader, tt.options)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestMpegDetailsExtraction_concatenated(t *testing.T) {
+	content, err := os.ReadFile("../../../test_resources/scan/sample_mpeg1.mpg")
+	if !assert.NoError(t, err) {
+		return
+	}
+	withoutEndCode := bytes.TrimSuffix(content, []byte{0, 0, 1, codeProgramEnd})
+
+	tests := []struct {
+		name    string
+		content []byte
+	}{
+		{"it should add up the duration of streams concatenated after their end code", append(append([]byte{}, content...), content...)},
+		{"it should add up the duration of streams when the clock goes backward", append(append([]byte{}, withoutEndCode...), withoutEndCode...)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := new(Parser).ReadDetails(bytes.NewReader(tt.content), backup.DetailsReaderOptions{})
+			if assert.NoError(t, err) {
+				assert.Equal(t, int64(2*2040), got.Duration)
+			}
+		})
+	}
+}
+
+func TestMpegDetailsExtraction_invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content []byte
+		wantErr string
+	}{
+		{"it should reject files not starting with a pack header", []byte{0, 0, 1, 0xB3, 0x2d, 0x02, 0x40}, "must start with a pack header"},
+		{"it should reject unknown pack header versions", []byte{0, 0, 1, 0xBA, 0x80, 0, 0, 0, 0, 0, 0, 0, 0, 0}, "version is not supported"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := new(Parser).ReadDetails(bytes.NewReader(tt.content), backup.DetailsReaderOptions{})
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
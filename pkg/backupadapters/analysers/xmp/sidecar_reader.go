@@ -0,0 +1,95 @@
+package xmp
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/thomasduchatelle/dphoto/pkg/backup"
+	"io"
+)
+
+// SidecarDetailsReader decorates a backup.DetailsReader to overlay the values read from the XMP sidecar of the media, when it has one.
+type SidecarDetailsReader struct {
+	Delegate backup.DetailsReader
+}
+
+// Decorate adds the sidecar support to each reader.
+func Decorate(readers ...backup.DetailsReader) []backup.DetailsReader {
+	decorated := make([]backup.DetailsReader, len(readers))
+	for i, reader := range readers {
+		decorated[i] = &SidecarDetailsReader{Delegate: reader}
+	}
+
+	return decorated
+}
+
+func (s *SidecarDetailsReader) Supports(media backup.FoundMedia, mediaType backup.MediaType) bool {
+	return s.Delegate.Supports(media, mediaType)
+}
+
+func (s *SidecarDetailsReader) ReadDetails(reader io.Reader, options backup.DetailsReaderOptions) (*backup.MediaDetails, error) {
+	return s.Delegate.ReadDetails(reader, options)
+}
+
+func (s *SidecarDetailsReader) ReadDetailsOfFoundMedia(found backup.FoundMedia, reader io.Reader, options backup.DetailsReaderOptions) (*backup.MediaDetails, error) {
+	details, err := s.Delegate.ReadDetails(reader, options)
+	if err != nil || details == nil {
+		return details, err
+	}
+
+	withSidecar, ok := found.(backup.FoundMediaWithSidecar)
+	if !ok || withSidecar.Sidecar() == nil {
+		return details, nil
+	}
+
+	sidecar, err := readSidecar(withSidecar.Sidecar())
+	if err != nil {
+		log.WithError(err).WithField("Media", found).Warnf("Sidecar %s is ignored", withSidecar.Sidecar())
+		return details, nil
+	}
+
+	overlay(details, sidecar)
+	return details, nil
+}
+
+// FallbackOnFileDate is delegated to keep the behaviour of the decorated reader.
+func (s *SidecarDetailsReader) FallbackOnFileDate() bool {
+	fallback, ok := s.Delegate.(backup.DetailsReaderWithFileDate)
+	return ok && fallback.FallbackOnFileDate()
+}
+
+func (s *SidecarDetailsReader) String() string {
+	return fmt.Sprintf("xmp(%s)", fmt.Sprint(s.Delegate))
+}
+
+func readSidecar(file backup.FoundMedia) (*Sidecar, error) {
+	reader, err := file.ReadMedia()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return Parse(reader)
+}
+
+// overlay replaces the details by the values edited by the user.
+func overlay(details *backup.MediaDetails, sidecar *Sidecar) {
+	if !sidecar.DateTime.IsZero() {
+		details.DateTime = sidecar.DateTime
+		details.TimeReference = backup.TimeReferenceWallClock
+		if sidecar.HasTimeZone {
+			details.TimeReference = backup.TimeReferenceZoned
+		}
+	}
+
+	if sidecar.HasGPS {
+		details.GPSLatitude, details.GPSLongitude = sidecar.GPSLatitude, sidecar.GPSLongitude
+	}
+
+	if sidecar.HasRating {
+		details.Rating = sidecar.Rating
+	}
+
+	if len(sidecar.Keywords) > 0 {
+		details.Keywords = sidecar.Keywords
+	}
+}
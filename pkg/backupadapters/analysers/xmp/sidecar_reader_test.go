@@ -0,0 +1,108 @@
+package xmp
+
+import (
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/thomasduchatelle/dphoto/pkg/backup"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestSidecarDetailsReader_ReadDetailsOfFoundMedia(t *testing.T) {
+	const sidecarContent = `<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+  <rdf:Description xmlns:exif="http://ns.adobe.com/exif/1.0/" xmlns:xmp="http://ns.adobe.com/xap/1.0/" xmlns:dc="http://purl.org/dc/elements/1.1/"
+    exif:DateTimeOriginal="2022-08-01T10:00:00+02:00" xmp:Rating="5">
+   <dc:subject><rdf:Bag><rdf:li>holidays</rdf:li></rdf:Bag></dc:subject>
+  </rdf:Description>
+</rdf:RDF></x:xmpmeta>`
+
+	readDetails := func() *backup.MediaDetails {
+		return &backup.MediaDetails{
+			Width:        4000,
+			DateTime:     time.Date(2022, 7, 31, 23, 0, 0, 0, time.UTC),
+			GPSLatitude:  51.5,
+			GPSLongitude: -0.12,
+		}
+	}
+
+	tests := []struct {
+		name     string
+		delegate backup.DetailsReader
+		found    backup.FoundMedia
+		want     *backup.MediaDetails
+		wantErr  assert.ErrorAssertionFunc
+	}{
+		{
+			name:     "it should return the details of the media when it has no sidecar",
+			delegate: &DetailsReaderStub{details: readDetails()},
+			found:    backup.NewInMemoryMedia("IMG_001.CR2", time.Now(), []byte("raw")),
+			want:     readDetails(),
+			wantErr:  assert.NoError,
+		},
+		{
+			name:     "it should overlay the values of the sidecar",
+			delegate: &DetailsReaderStub{details: readDetails()},
+			found:    &FoundMediaWithSidecarStub{FoundMedia: backup.NewInMemoryMedia("IMG_001.CR2", time.Now(), []byte("raw")), sidecar: backup.NewInMemoryMedia("IMG_001.xmp", time.Now(), []byte(sidecarContent))},
+			want: &backup.MediaDetails{
+				Width:         4000,
+				DateTime:      time.Date(2022, 8, 1, 10, 0, 0, 0, time.FixedZone("", 2*3600)),
+				TimeReference: backup.TimeReferenceZoned,
+				GPSLatitude:   51.5,
+				GPSLongitude:  -0.12,
+				Rating:        5,
+				Keywords:      []string{"holidays"},
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name:     "it should ignore an invalid sidecar",
+			delegate: &DetailsReaderStub{details: readDetails()},
+			found:    &FoundMediaWithSidecarStub{FoundMedia: backup.NewInMemoryMedia("IMG_001.CR2", time.Now(), []byte("raw")), sidecar: backup.NewInMemoryMedia("IMG_001.xmp", time.Now(), []byte("<x:xmpmeta>"))},
+			want:     readDetails(),
+			wantErr:  assert.NoError,
+		},
+		{
+			name:     "it should return the error of the decorated reader",
+			delegate: &DetailsReaderStub{err: errors.New("corrupted file")},
+			found:    &FoundMediaWithSidecarStub{FoundMedia: backup.NewInMemoryMedia("IMG_001.CR2", time.Now(), []byte("raw")), sidecar: backup.NewInMemoryMedia("IMG_001.xmp", time.Now(), []byte(sidecarContent))},
+			wantErr:  assert.Error,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := &SidecarDetailsReader{Delegate: tt.delegate}
+
+			content, _ := tt.found.ReadMedia()
+			got, err := reader.ReadDetailsOfFoundMedia(tt.found, content, backup.DetailsReaderOptions{})
+			if tt.wantErr(t, err) && err == nil {
+				assert.Equal(t, tt.want.DateTime.UTC(), got.DateTime.UTC())
+				tt.want.DateTime = got.DateTime
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+type DetailsReaderStub struct {
+	details *backup.MediaDetails
+	err     error
+}
+
+func (d *DetailsReaderStub) Supports(backup.FoundMedia, backup.MediaType) bool {
+	return true
+}
+
+func (d *DetailsReaderStub) ReadDetails(io.Reader, backup.DetailsReaderOptions) (*backup.MediaDetails, error) {
+	return d.details, d.err
+}
+
+type FoundMediaWithSidecarStub struct {
+	backup.FoundMedia
+	sidecar backup.FoundMedia
+}
+
+func (f *FoundMediaWithSidecarStub) Sidecar() backup.FoundMedia {
+	return f.sidecar
+}
@@ -0,0 +1,175 @@
+// Package xmp reads the XMP sidecars written by photo editors (Lightroom, Darktable, ...) next to the medias, and overlays their values on the details read from the medias.
+// References:
+// - https://developer.adobe.com/xmp/docs/XMPNamespaces/
+// - https://exiftool.org/TagNames/XMP.html
+package xmp
+
+import (
+	"encoding/xml"
+	"github.com/pkg/errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	namespaceRDF       = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+	namespaceXMP       = "http://ns.adobe.com/xap/1.0/"
+	namespaceEXIF      = "http://ns.adobe.com/exif/1.0/"
+	namespacePhotoshop = "http://ns.adobe.com/photoshop/1.0/"
+	namespaceDC        = "http://purl.org/dc/elements/1.1/"
+)
+
+var (
+	propertyDateTimeOriginal = xml.Name{Space: namespaceEXIF, Local: "DateTimeOriginal"}
+	propertyDateCreated      = xml.Name{Space: namespacePhotoshop, Local: "DateCreated"}
+	propertyGPSLatitude      = xml.Name{Space: namespaceEXIF, Local: "GPSLatitude"}
+	propertyGPSLongitude     = xml.Name{Space: namespaceEXIF, Local: "GPSLongitude"}
+	propertyRating           = xml.Name{Space: namespaceXMP, Local: "Rating"}
+	propertySubject          = xml.Name{Space: namespaceDC, Local: "subject"}
+
+	// zonedDateLayouts and wallClockDateLayouts are the formats of XMP dates, with and without time zone.
+	zonedDateLayouts     = []string{"2006-01-02T15:04:05.999999999Z07:00", "2006-01-02T15:04Z07:00"}
+	wallClockDateLayouts = []string{"2006-01-02T15:04:05.999999999", "2006-01-02T15:04", "2006-01-02"}
+)
+
+// Sidecar are the values read from a XMP file ; the zero values are used for the properties not found.
+type Sidecar struct {
+	DateTime                  time.Time // DateTime is when the media has been captured, it is only zoned when HasTimeZone is true
+	HasTimeZone               bool
+	GPSLatitude, GPSLongitude float64
+	HasGPS                    bool
+	Rating                    int // Rating is from 1 to 5 stars, -1 when the media has been rejected
+	HasRating                 bool
+	Keywords                  []string
+}
+
+// Parse reads the properties of the 'rdf:Description' elements, written either as attributes or as child elements.
+func Parse(reader io.Reader) (*Sidecar, error) {
+	properties := make(map[xml.Name]string)
+	var keywords []string
+
+	decoder := xml.NewDecoder(reader)
+	var stack []xml.Name
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid XMP document")
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			if element.Name.Space == namespaceRDF && element.Name.Local == "Description" {
+				for _, attr := range element.Attr {
+					properties[attr.Name] = attr.Value
+				}
+			}
+			stack = append(stack, element.Name)
+
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+
+		case xml.CharData:
+			text := strings.TrimSpace(string(element))
+			if text == "" || len(stack) == 0 {
+				continue
+			}
+
+			if isKeyword(stack) {
+				keywords = append(keywords, text)
+			} else {
+				properties[stack[len(stack)-1]] = text
+			}
+		}
+	}
+
+	return newSidecar(properties, keywords), nil
+}
+
+// isKeyword returns true for the items of 'dc:subject' bag.
+func isKeyword(stack []xml.Name) bool {
+	for _, name := range stack {
+		if name == propertySubject {
+			return stack[len(stack)-1] == xml.Name{Space: namespaceRDF, Local: "li"}
+		}
+	}
+
+	return false
+}
+
+func newSidecar(properties map[xml.Name]string, keywords []string) *Sidecar {
+	sidecar := &Sidecar{
+		Keywords: keywords,
+	}
+
+	for _, property := range []xml.Name{propertyDateTimeOriginal, propertyDateCreated} {
+		if value, found := properties[property]; found {
+			if sidecar.DateTime, sidecar.HasTimeZone = parseDate(value); !sidecar.DateTime.IsZero() {
+				break
+			}
+		}
+	}
+
+	latitude, latitudeErr := parseGPSCoordinate(properties[propertyGPSLatitude])
+	longitude, longitudeErr := parseGPSCoordinate(properties[propertyGPSLongitude])
+	if latitudeErr == nil && longitudeErr == nil {
+		sidecar.GPSLatitude, sidecar.GPSLongitude, sidecar.HasGPS = latitude, longitude, true
+	}
+
+	if rating, err := strconv.ParseFloat(properties[propertyRating], 64); err == nil {
+		sidecar.Rating, sidecar.HasRating = int(rating), true
+	}
+
+	return sidecar
+}
+
+// parseDate returns a zero time if the format is not supported ; dates without time zone are returned as UTC.
+func parseDate(value string) (time.Time, bool) {
+	for _, layout := range zonedDateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, true
+		}
+	}
+	for _, layout := range wallClockDateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, false
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// parseGPSCoordinate reads XMP coordinates: 'DDD,MM,SSk' or 'DDD,MM.mmk' where k is N, S, E, or W.
+func parseGPSCoordinate(value string) (float64, error) {
+	if len(value) < 2 {
+		return 0, errors.Errorf("'%s' is not a GPS coordinate", value)
+	}
+
+	sign := 1.
+	switch strings.ToUpper(value[len(value)-1:]) {
+	case "N", "E":
+	case "S", "W":
+		sign = -1
+	default:
+		return 0, errors.Errorf("'%s' is not a GPS coordinate: it must end with N, S, E, or W", value)
+	}
+
+	coordinate := 0.
+	for i, part := range strings.Split(value[:len(value)-1], ",") {
+		if i > 2 {
+			return 0, errors.Errorf("'%s' is not a GPS coordinate: too many parts", value)
+		}
+
+		number, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return 0, errors.Wrapf(err, "'%s' is not a GPS coordinate", value)
+		}
+		coordinate += number / []float64{1, 60, 3600}[i]
+	}
+
+	return sign * coordinate, nil
+}
@@ -0,0 +1,86 @@
+package xmp
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	a := assert.New(t)
+
+	reader, err := os.Open("../../../test_resources/scan/golang-logo.xmp")
+	if !a.NoError(err) {
+		panic(err.Error())
+	}
+	defer reader.Close()
+
+	sidecar, err := Parse(reader)
+	if a.NoError(err) {
+		a.Equal(time.Date(2021, 5, 2, 12, 3, 12, 500000000, time.UTC), sidecar.DateTime.UTC())
+		a.True(sidecar.HasTimeZone)
+		a.True(sidecar.HasGPS)
+		a.InDelta(48.8577, sidecar.GPSLatitude, 0.0001)
+		a.InDelta(2.295, sidecar.GPSLongitude, 0.0001)
+		a.Equal(4, sidecar.Rating)
+		a.True(sidecar.HasRating)
+		a.Equal([]string{"Paris", "Eiffel Tower"}, sidecar.Keywords)
+	}
+}
+
+func TestParse_elements(t *testing.T) {
+	const darktable = `<?xml version="1.0" encoding="UTF-8"?>
+<x:xmpmeta xmlns:x="adobe:ns:meta/" x:xmptk="XMP Core 4.4.0-Exiv2">
+ <rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+  <rdf:Description rdf:about="" xmlns:exif="http://ns.adobe.com/exif/1.0/" xmlns:xmp="http://ns.adobe.com/xap/1.0/" xmlns:dc="http://purl.org/dc/elements/1.1/">
+   <exif:DateTimeOriginal>2019-12-24T20:15:00</exif:DateTimeOriginal>
+   <exif:GPSLatitude>33,52,10.8S</exif:GPSLatitude>
+   <exif:GPSLongitude>151,12,30W</exif:GPSLongitude>
+   <xmp:Rating>-1</xmp:Rating>
+  </rdf:Description>
+ </rdf:RDF>
+</x:xmpmeta>`
+
+	sidecar, err := Parse(strings.NewReader(darktable))
+	if assert.NoError(t, err) {
+		assert.Equal(t, &Sidecar{
+			DateTime:     time.Date(2019, 12, 24, 20, 15, 0, 0, time.UTC),
+			GPSLatitude:  -33.869666666666667,
+			GPSLongitude: -151.20833333333331,
+			HasGPS:       true,
+			Rating:       -1,
+			HasRating:    true,
+		}, sidecar)
+	}
+}
+
+func TestParse_invalid(t *testing.T) {
+	_, err := Parse(strings.NewReader("<x:xmpmeta><rdf:RDF>"))
+	assert.Error(t, err)
+}
+
+func TestParseGPSCoordinate(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    float64
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{"it should parse degrees and decimal minutes", "48,51.462N", 48.8577, assert.NoError},
+		{"it should parse degrees, minutes, and seconds", "48,51,27.72N", 48.8577, assert.NoError},
+		{"it should use a negative value for the west", "0,7.5W", -0.125, assert.NoError},
+		{"it should reject a coordinate without direction", "48,51.462", 0, assert.Error},
+		{"it should reject an empty coordinate", "", 0, assert.Error},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseGPSCoordinate(tt.value)
+			if tt.wantErr(t, err) {
+				assert.InDelta(t, tt.want, got, 0.0001)
+			}
+		})
+	}
+}
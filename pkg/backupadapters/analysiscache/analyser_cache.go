@@ -5,6 +5,7 @@ import (
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"github.com/thomasduchatelle/dphoto/pkg/backup"
+	"time"
 )
 
 func NewAnalyserCache(localDatabase string) (*AnalyserCache, error) {
@@ -52,7 +53,7 @@ func (d *AnalyserCache) RestoreCache(found backup.FoundMedia) (*backup.AnalysedM
 		return nil, false, errors.Wrapf(err, "failed to restore cache for key=%s", key)
 	}
 
-	if d.lastModificationMatchOrIsIgnored(found, payload) {
+	if d.lastModificationMatchOrIsIgnored(found, payload) || payload.Version < payloadVersion || !sidecarModification(found).Equal(payload.SidecarModified) {
 		return nil, true, nil
 	}
 
@@ -73,13 +74,24 @@ func (d *AnalyserCache) StoreCache(analysedMedia *backup.AnalysedMedia) error {
 	}
 
 	return d.set(key, Payload{
+		Version:          payloadVersion,
 		LastModification: analysedMedia.FoundMedia.LastModification(),
 		Type:             string(analysedMedia.Type),
 		Sha256Hash:       analysedMedia.Sha256Hash,
 		Details:          details,
+		SidecarModified:  sidecarModification(analysedMedia.FoundMedia),
 	})
 }
 
+// sidecarModification returns the last modification of the sidecar of the media, or a zero time.
+func sidecarModification(found backup.FoundMedia) time.Time {
+	if withSidecar, ok := found.(backup.FoundMediaWithSidecar); ok && withSidecar.Sidecar() != nil {
+		return withSidecar.Sidecar().LastModification()
+	}
+
+	return time.Time{}
+}
+
 func (d *AnalyserCache) lastModificationMatchOrIsIgnored(found backup.FoundMedia, payload *Payload) bool {
 	return !found.LastModification().IsZero() && !found.LastModification().Equal(payload.LastModification)
 }
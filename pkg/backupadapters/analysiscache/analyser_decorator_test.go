@@ -22,9 +22,14 @@ func TestDecoratorInstance_Analyse(t *testing.T) {
 	const cachedMediaHash = "cached-sha256-images"
 	sometime := time.Date(2024, 3, 9, 23, 10, 11, 0, time.UTC)
 	foundMedia := backup.NewInMemoryMedia("/avengers/ironman/stark-tower-01.png", sometime, []byte("some content"))
+	foundMediaWithEditedSidecar := &FoundMediaWithSidecarStub{
+		FoundMedia: foundMedia,
+		sidecar:    backup.NewInMemoryMedia("/avengers/ironman/stark-tower-01.xmp", sometime.Add(1*time.Hour), []byte("<x:xmpmeta/>")),
+	}
 
 	const badgerKey = "/ram/avengers/ironman/stark-tower-01.png##12"
 	const badgerPayload = `{
+  "version": 2,
   "lastModification": "2024-03-09T23:10:11.000Z",
   "type": "IMAGE",
   "sha256Hash": "cached-sha256-images",
@@ -35,6 +40,7 @@ func TestDecoratorInstance_Analyse(t *testing.T) {
 }`
 	var recordHasBeenStored = map[string]analysiscache.Payload{
 		badgerKey: {
+			Version:          2,
 			LastModification: sometime,
 			Type:             "IMAGE",
 			Sha256Hash:       computedMediaHash,
@@ -46,6 +52,7 @@ func TestDecoratorInstance_Analyse(t *testing.T) {
 	}
 	var recordHasBeenKept = map[string]analysiscache.Payload{
 		badgerKey: {
+			Version:          2,
 			LastModification: sometime,
 			Type:             "IMAGE",
 			Sha256Hash:       cachedMediaHash,
@@ -56,6 +63,16 @@ func TestDecoratorInstance_Analyse(t *testing.T) {
 		},
 	}
 
+	const legacyBadgerPayload = `{
+  "lastModification": "2024-03-09T23:10:11.000Z",
+  "type": "IMAGE",
+  "sha256Hash": "cached-sha256-images",
+  "details": {
+    "width": 120,
+    "height": 42
+  }
+}`
+
 	doesNotHaveARecordInCache := func(t *testing.T, db *badger.DB) error {
 		return nil
 	}
@@ -65,6 +82,12 @@ func TestDecoratorInstance_Analyse(t *testing.T) {
 		})
 	}
 
+	hasALegacyRecordInCache := func(t *testing.T, db *badger.DB) error {
+		return db.Update(func(txn *badger.Txn) error {
+			return txn.Set([]byte(badgerKey), []byte(legacyBadgerPayload))
+		})
+	}
+
 	delegateFailsToAnalyse := new(AnalyserFake)
 	delegateReturnsAnalysedMedia := &AnalyserFake{
 		medias: map[string]backup.AnalysedMedia{
@@ -156,6 +179,7 @@ func TestDecoratorInstance_Analyse(t *testing.T) {
 			},
 			wantDB: map[string]analysiscache.Payload{
 				badgerKey: {
+					Version:          2,
 					LastModification: sometime.Add(1 * time.Minute),
 					Type:             "IMAGE",
 					Sha256Hash:       computedMediaHash,
@@ -167,6 +191,60 @@ func TestDecoratorInstance_Analyse(t *testing.T) {
 			},
 			wantErr: assert.NoError,
 		},
+		{
+			name: "it should call the delegate and override the result when it has been cached by a previous version",
+			init: hasALegacyRecordInCache,
+			fields: fields{
+				Delegate: delegateReturnsAnalysedMedia,
+			},
+			args: args{
+				found: foundMedia,
+			},
+			want: &backup.AnalysedMedia{
+				FoundMedia: foundMedia,
+				Type:       backup.MediaTypeImage,
+				Sha256Hash: computedMediaHash,
+				Details: &backup.MediaDetails{
+					Width:  120,
+					Height: 42,
+				},
+			},
+			wantDB:  recordHasBeenStored,
+			wantErr: assert.NoError,
+		},
+		{
+			name: "it should call the delegate and override the result when the sidecar has been edited",
+			init: hasARecordInCache,
+			fields: fields{
+				Delegate: delegateReturnsAnalysedMedia,
+			},
+			args: args{
+				found: foundMediaWithEditedSidecar,
+			},
+			want: &backup.AnalysedMedia{
+				FoundMedia: foundMediaWithEditedSidecar,
+				Type:       backup.MediaTypeImage,
+				Sha256Hash: computedMediaHash,
+				Details: &backup.MediaDetails{
+					Width:  120,
+					Height: 42,
+				},
+			},
+			wantDB: map[string]analysiscache.Payload{
+				badgerKey: {
+					Version:          2,
+					LastModification: sometime,
+					Type:             "IMAGE",
+					Sha256Hash:       computedMediaHash,
+					Details: backup.MediaDetails{
+						Width:  120,
+						Height: 42,
+					},
+					SidecarModified: sometime.Add(1 * time.Hour),
+				},
+			},
+			wantErr: assert.NoError,
+		},
 		{
 			name: "it should use the cache and ignore last modification if ZERO is requested (mean not supported)",
 			init: hasARecordInCache,
@@ -269,3 +347,12 @@ func (a *AnalyserFake) Analyse(ctx context.Context, found backup.FoundMedia) (*b
 	analysed.FoundMedia = found
 	return &analysed, nil
 }
+
+type FoundMediaWithSidecarStub struct {
+	backup.FoundMedia
+	sidecar backup.FoundMedia
+}
+
+func (f *FoundMediaWithSidecarStub) Sidecar() backup.FoundMedia {
+	return f.sidecar
+}
@@ -19,9 +19,16 @@ func (k Key) String() string {
 	return string(k.SerialisedKey())
 }
 
+// payloadVersion is incremented when the analysis of a media is changed ; payloads of previous versions are analysed again.
+//   - version 1: details have a TimeReference
+//   - version 2: details have the LivePhotoId and the BurstId of Apple medias
+const payloadVersion = 2
+
 type Payload struct {
+	Version          int                 `json:"version,omitempty"`
 	LastModification time.Time           `json:"lastModification,omitempty"`
 	Type             string              `json:"type,omitempty"`
 	Sha256Hash       string              `json:"sha256Hash,omitempty"`
 	Details          backup.MediaDetails `json:"details,omitempty"`
+	SidecarModified  time.Time           `json:"sidecarModified,omitempty"` // SidecarModified is the last modification of the sidecar when the media had one
 }
@@ -14,7 +14,7 @@ func New() backup.ArchiveMediaPort {
 
 func (a *adapter) ArchiveMedia(owner string, media *backup.BackingUpMediaRequest) (string, error) {
 	return archive.Store(&archive.StoreRequest{
-		DateTime:         media.AnalysedMedia.Details.DateTime,
+		DateTime:         media.AnalysedMedia.Details.LocalDateTime(),
 		FolderName:       media.CatalogReference.AlbumFolderName(),
 		Id:               media.CatalogReference.MediaId(),
 		Open:             media.AnalysedMedia.FoundMedia.ReadMedia,
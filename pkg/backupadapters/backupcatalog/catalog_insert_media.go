@@ -33,14 +33,20 @@ func (a *InsertMediaAdapter) IndexMedias(ctx context.Context, owner ownermodel.O
 			Details: catalog.MediaDetails{
 				Width:         request.BackingUpMediaRequest.AnalysedMedia.Details.Width,
 				Height:        request.BackingUpMediaRequest.AnalysedMedia.Details.Height,
-				DateTime:      request.BackingUpMediaRequest.AnalysedMedia.Details.DateTime,
+				DateTime:      request.BackingUpMediaRequest.AnalysedMedia.Details.LocalDateTime(),
+				DateTimeUTC:   request.BackingUpMediaRequest.AnalysedMedia.Details.DateTime.UTC(),
+				TimeZone:      request.BackingUpMediaRequest.AnalysedMedia.Details.TimeZone(),
 				Orientation:   catalog.MediaOrientation(request.BackingUpMediaRequest.AnalysedMedia.Details.Orientation),
 				Make:          request.BackingUpMediaRequest.AnalysedMedia.Details.Make,
 				Model:         request.BackingUpMediaRequest.AnalysedMedia.Details.Model,
 				GPSLatitude:   request.BackingUpMediaRequest.AnalysedMedia.Details.GPSLatitude,
 				GPSLongitude:  request.BackingUpMediaRequest.AnalysedMedia.Details.GPSLongitude,
+				GPSInferred:   request.BackingUpMediaRequest.AnalysedMedia.Details.GPSInferred,
 				Duration:      request.BackingUpMediaRequest.AnalysedMedia.Details.Duration,
 				VideoEncoding: request.BackingUpMediaRequest.AnalysedMedia.Details.VideoEncoding,
+				Rating:        request.BackingUpMediaRequest.AnalysedMedia.Details.Rating,
+				Keywords:      request.BackingUpMediaRequest.AnalysedMedia.Details.Keywords,
+				GroupId:       catalog.NewMediaGroupId(request.BackingUpMediaRequest.AnalysedMedia.Details.LivePhotoId, request.BackingUpMediaRequest.AnalysedMedia.Details.BurstId),
 			},
 		}
 	}
@@ -49,7 +49,7 @@ func (c *CatalogReferencerAdapter) Reference(ctx context.Context, analysedMedias
 
 	for _, mediaReference := range mediaReferences {
 		medias := analysedMediasBySignature[mediaReference.Signature]
-		albumReference, err := c.StatefulAlbumReferencer.FindReference(ctx, medias[0].Details.DateTime)
+		albumReference, err := c.StatefulAlbumReferencer.FindReference(ctx, medias[0].Details.LocalDateTime())
 		if err != nil {
 			return errors.Wrapf(err, "failed to find album reference for media at time %s", medias[0].Details.DateTime)
 		}
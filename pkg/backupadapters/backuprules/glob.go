@@ -0,0 +1,121 @@
+package backuprules
+
+import (
+	"github.com/pkg/errors"
+	"path"
+	"strings"
+)
+
+// glob is a case-insensitive pattern with '**' matching any number of directories.
+type glob struct {
+	pattern  string   // pattern is how it has been declared, used for reporting
+	negated  bool     // negated is set when the pattern starts with '!'
+	dirOnly  bool     // dirOnly is set when the pattern ends with '/'
+	segments []string // segments is the pattern split on '/'
+}
+
+// newGlobs parses path patterns: like in .gitignore, a pattern without '/' matches at any depth, and a pattern matching a directory matches all its content.
+func newGlobs(patterns []string, negationSupported bool) ([]*glob, error) {
+	var globs []*glob
+	for _, pattern := range patterns {
+		g := &glob{pattern: strings.TrimSpace(pattern)}
+		value := strings.ToLower(g.pattern)
+
+		if negationSupported && strings.HasPrefix(value, "!") {
+			g.negated = true
+			value = strings.TrimPrefix(value, "!")
+		}
+		g.dirOnly = strings.HasSuffix(value, "/")
+
+		if !strings.Contains(strings.TrimSuffix(value, "/"), "/") {
+			value = "**/" + value
+		}
+		value = strings.Trim(value, "/")
+		if value == "" || value == "**" {
+			return nil, errors.Errorf("pattern '%s' is empty", pattern)
+		}
+
+		g.segments = strings.Split(value, "/")
+		if err := validateSegments(g.segments); err != nil {
+			return nil, errors.Wrapf(err, "pattern '%s' is invalid", pattern)
+		}
+
+		globs = append(globs, g)
+	}
+
+	return globs, nil
+}
+
+// newCameraGlobs parses patterns matched on a single value.
+func newCameraGlobs(patterns []string) ([]*glob, error) {
+	var globs []*glob
+	for _, pattern := range patterns {
+		g := &glob{
+			pattern:  strings.TrimSpace(pattern),
+			segments: []string{strings.ToLower(strings.TrimSpace(pattern))},
+		}
+		if err := validateSegments(g.segments); err != nil {
+			return nil, errors.Wrapf(err, "pattern '%s' is invalid", pattern)
+		}
+
+		globs = append(globs, g)
+	}
+
+	return globs, nil
+}
+
+func validateSegments(segments []string) error {
+	for _, segment := range segments {
+		if _, err := path.Match(segment, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// match returns true if the lower case path, or one of its parent directories, matches the pattern.
+func (g *glob) match(lowerCasePath string) bool {
+	names := strings.Split(lowerCasePath, "/")
+	for length := len(names); length > 0; length-- {
+		if g.dirOnly && length == len(names) {
+			continue
+		}
+
+		if matchSegments(g.segments, names[:length]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchSegments(pattern, names []string) bool {
+	if len(pattern) == 0 {
+		return len(names) == 0
+	}
+
+	if pattern[0] == "**" {
+		for skip := 0; skip <= len(names); skip++ {
+			if matchSegments(pattern[1:], names[skip:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(names) == 0 {
+		return false
+	}
+
+	matched, _ := path.Match(pattern[0], names[0])
+	return matched && matchSegments(pattern[1:], names[1:])
+}
+
+func anyMatch(globs []*glob, value string) bool {
+	for _, g := range globs {
+		if g.match(value) {
+			return true
+		}
+	}
+	return false
+}
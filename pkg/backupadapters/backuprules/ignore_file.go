@@ -0,0 +1,36 @@
+package backuprules
+
+import (
+	"bufio"
+	"github.com/pkg/errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// IgnoreFileName is the file, at the root of a volume, listing the patterns to exclude from the backup.
+const IgnoreFileName = ".dphotoignore"
+
+// ReadIgnoreFile reads the IgnoreFileName at the root of the volume: one pattern per line, '#' starts a comment, and '!' re-includes. Empty rules are returned if the file doesn't exist.
+func ReadIgnoreFile(volumePath string) (Rules, error) {
+	filePath := filepath.Join(volumePath, IgnoreFileName)
+	file, err := os.Open(filePath)
+	if os.IsNotExist(err) {
+		return Rules{}, nil
+	}
+	if err != nil {
+		return Rules{}, errors.Wrapf(err, "failed to open %s", filePath)
+	}
+	defer file.Close()
+
+	rules := Rules{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			rules.Exclude = append(rules.Exclude, line)
+		}
+	}
+
+	return rules, errors.Wrapf(scanner.Err(), "failed to read %s", filePath)
+}
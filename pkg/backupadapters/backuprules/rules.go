@@ -0,0 +1,297 @@
+// Package backuprules is a rule engine, configured by the user, deciding which medias are backed up.
+package backuprules
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/thomasduchatelle/dphoto/pkg/backup"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	screenshotMinAspectRatio = 1.7 // screenshotMinAspectRatio is slightly under 16:9 ; cameras are usually 4:3 or 3:2, phone screens are from 16:9 to 21:9
+	dateLayout               = "2006-01-02"
+)
+
+// Rules are declared in the configuration under 'backup.rules' ; a media must comply with all of them to be backed up.
+type Rules struct {
+	Include            []string      `mapstructure:"include"`            // Include are globs ; when set, only the medias matching one of them are backed up
+	Exclude            []string      `mapstructure:"exclude"`            // Exclude are globs with the .gitignore semantic: the last matching one wins, and '!' re-includes
+	MinWidth           int           `mapstructure:"minWidth"`           // MinWidth is the minimum size of the largest side of the media, regardless of its orientation
+	MinHeight          int           `mapstructure:"minHeight"`          // MinHeight is the minimum size of the smallest side of the media, regardless of its orientation
+	MaxDuration        time.Duration `mapstructure:"maxDuration"`        // MaxDuration excludes the longest videos
+	Cameras            []string      `mapstructure:"cameras"`            // Cameras are case-insensitive globs on '<make> <model>' ; when set, only the medias from one of these cameras are backed up
+	ExcludeCameras     []string      `mapstructure:"excludeCameras"`     // ExcludeCameras are case-insensitive globs on '<make> <model>'
+	DateWindows        []DateWindow  `mapstructure:"dateWindows"`        // DateWindows are periods ; when set, only the medias taken during one of them are backed up
+	ExcludeDateWindows []DateWindow  `mapstructure:"excludeDateWindows"` // ExcludeDateWindows are periods during which medias are not backed up
+	ExcludeScreenshots bool          `mapstructure:"excludeScreenshots"` // ExcludeScreenshots excludes images without camera information and with the aspect ratio of a phone screen
+}
+
+// DateWindow is inclusive ; dates are either 'YYYY-MM-DD' (the whole day) or RFC3339 timestamps. One of the boundaries can be omitted.
+type DateWindow struct {
+	From string `mapstructure:"from"`
+	To   string `mapstructure:"to"`
+}
+
+// Merge creates new Rules where the lists are concatenated, and the other values are overridden when set.
+func (r Rules) Merge(other Rules) Rules {
+	merged := Rules{
+		Include:            concat(r.Include, other.Include),
+		Exclude:            concat(r.Exclude, other.Exclude),
+		MinWidth:           r.MinWidth,
+		MinHeight:          r.MinHeight,
+		MaxDuration:        r.MaxDuration,
+		Cameras:            concat(r.Cameras, other.Cameras),
+		ExcludeCameras:     concat(r.ExcludeCameras, other.ExcludeCameras),
+		DateWindows:        concat(r.DateWindows, other.DateWindows),
+		ExcludeDateWindows: concat(r.ExcludeDateWindows, other.ExcludeDateWindows),
+		ExcludeScreenshots: r.ExcludeScreenshots || other.ExcludeScreenshots,
+	}
+
+	if other.MinWidth > 0 {
+		merged.MinWidth = other.MinWidth
+	}
+	if other.MinHeight > 0 {
+		merged.MinHeight = other.MinHeight
+	}
+	if other.MaxDuration > 0 {
+		merged.MaxDuration = other.MaxDuration
+	}
+
+	return merged
+}
+
+// IsEmpty returns true when no rule is declared: all medias would be accepted.
+func (r Rules) IsEmpty() bool {
+	return len(r.Include) == 0 && len(r.Exclude) == 0 && r.MinWidth == 0 && r.MinHeight == 0 && r.MaxDuration == 0 &&
+		len(r.Cameras) == 0 && len(r.ExcludeCameras) == 0 && len(r.DateWindows) == 0 && len(r.ExcludeDateWindows) == 0 && !r.ExcludeScreenshots
+}
+
+// Filter is the compiled version of Rules, it implements backup.PostAnalyseFilterWithReason.
+type Filter struct {
+	include            []*glob
+	exclude            []*glob
+	minLargestSide     int
+	minSmallestSide    int
+	maxDuration        time.Duration
+	cameras            []*glob
+	excludeCameras     []*glob
+	dateWindows        []period
+	excludeDateWindows []period
+	excludeScreenshots bool
+}
+
+// NewFilter compiles the rules, and returns an error if one of them is invalid.
+func NewFilter(rules Rules) (*Filter, error) {
+	filter := &Filter{
+		maxDuration:        rules.MaxDuration,
+		excludeScreenshots: rules.ExcludeScreenshots,
+		minLargestSide:     max(rules.MinWidth, rules.MinHeight),
+		minSmallestSide:    min(rules.MinWidth, rules.MinHeight),
+	}
+
+	var err error
+	if filter.include, err = newGlobs(rules.Include, false); err != nil {
+		return nil, errors.Wrapf(err, "invalid 'include' rule")
+	}
+	if filter.exclude, err = newGlobs(rules.Exclude, true); err != nil {
+		return nil, errors.Wrapf(err, "invalid 'exclude' rule")
+	}
+	if filter.cameras, err = newCameraGlobs(rules.Cameras); err != nil {
+		return nil, errors.Wrapf(err, "invalid 'cameras' rule")
+	}
+	if filter.excludeCameras, err = newCameraGlobs(rules.ExcludeCameras); err != nil {
+		return nil, errors.Wrapf(err, "invalid 'excludeCameras' rule")
+	}
+	if filter.dateWindows, err = newPeriods(rules.DateWindows); err != nil {
+		return nil, errors.Wrapf(err, "invalid 'dateWindows' rule")
+	}
+	if filter.excludeDateWindows, err = newPeriods(rules.ExcludeDateWindows); err != nil {
+		return nil, errors.Wrapf(err, "invalid 'excludeDateWindows' rule")
+	}
+
+	return filter, nil
+}
+
+func (f *Filter) AcceptAnalysedMedia(media *backup.AnalysedMedia, folderName string) bool {
+	return f.FilterOutReason(media, folderName) == ""
+}
+
+// FilterOutReason returns the first rule the media doesn't comply with.
+func (f *Filter) FilterOutReason(media *backup.AnalysedMedia, folderName string) string {
+	mediaPath := media.FoundMedia.MediaPath()
+	relativePath := strings.ToLower(path.Join(mediaPath.Path, mediaPath.Filename))
+
+	if len(f.include) > 0 && !anyMatch(f.include, relativePath) {
+		return "not included by any pattern"
+	}
+
+	if pattern, excluded := f.excludedBy(relativePath); excluded {
+		return fmt.Sprintf("excluded by '%s'", pattern)
+	}
+
+	details := media.Details
+	if details == nil {
+		details = new(backup.MediaDetails)
+	}
+
+	if reason := f.filterOutOnDimensions(details); reason != "" {
+		return reason
+	}
+
+	if f.maxDuration > 0 && media.Type == backup.MediaTypeVideo && time.Duration(details.Duration)*time.Millisecond > f.maxDuration {
+		return fmt.Sprintf("longer than %s", f.maxDuration)
+	}
+
+	if reason := f.filterOutOnCamera(details); reason != "" {
+		return reason
+	}
+
+	if len(f.dateWindows) > 0 && !anyContains(f.dateWindows, details.LocalDateTime()) {
+		return "outside date windows"
+	}
+	for _, window := range f.excludeDateWindows {
+		if window.contains(details.LocalDateTime()) {
+			return fmt.Sprintf("taken during %s", window)
+		}
+	}
+
+	if f.excludeScreenshots && media.Type == backup.MediaTypeImage && isScreenshotLike(details) {
+		return "screenshot"
+	}
+
+	return ""
+}
+
+// excludedBy applies the patterns in order: the last pattern matching decides if the media is excluded or not.
+func (f *Filter) excludedBy(relativePath string) (string, bool) {
+	excluded := false
+	excludedBy := ""
+	for _, pattern := range f.exclude {
+		if pattern.match(relativePath) {
+			excluded = !pattern.negated
+			excludedBy = pattern.pattern
+		}
+	}
+
+	return excludedBy, excluded
+}
+
+func (f *Filter) filterOutOnDimensions(details *backup.MediaDetails) string {
+	if f.minLargestSide == 0 || details.Width == 0 || details.Height == 0 {
+		return ""
+	}
+
+	if max(details.Width, details.Height) < f.minLargestSide || min(details.Width, details.Height) < f.minSmallestSide {
+		return fmt.Sprintf("smaller than %dx%d", f.minLargestSide, f.minSmallestSide)
+	}
+	return ""
+}
+
+// filterOutOnCamera ignores medias without camera information.
+func (f *Filter) filterOutOnCamera(details *backup.MediaDetails) string {
+	camera := strings.ToLower(strings.TrimSpace(details.Make + " " + details.Model))
+	if camera == "" {
+		return ""
+	}
+
+	if len(f.cameras) > 0 && !anyMatch(f.cameras, camera) {
+		return fmt.Sprintf("camera '%s' not included", camera)
+	}
+
+	for _, pattern := range f.excludeCameras {
+		if pattern.match(camera) {
+			return fmt.Sprintf("camera excluded by '%s'", pattern.pattern)
+		}
+	}
+
+	return ""
+}
+
+func isScreenshotLike(details *backup.MediaDetails) bool {
+	if details.Make != "" || details.Model != "" || details.Width == 0 || details.Height == 0 {
+		return false
+	}
+
+	return float64(max(details.Width, details.Height))/float64(min(details.Width, details.Height)) >= screenshotMinAspectRatio
+}
+
+type period struct {
+	label    string
+	from, to time.Time // from and to are inclusive ; zero when open-ended
+}
+
+func newPeriods(windows []DateWindow) ([]period, error) {
+	periods := make([]period, len(windows))
+	for i, window := range windows {
+		from, _, err := parseDate(window.From)
+		if err != nil {
+			return nil, err
+		}
+
+		to, wholeDay, err := parseDate(window.To)
+		if err != nil {
+			return nil, err
+		}
+		if wholeDay {
+			to = to.Add(24*time.Hour - time.Nanosecond)
+		}
+
+		if from.IsZero() && to.IsZero() {
+			return nil, errors.Errorf("date window must have at least one boundary")
+		}
+		if !from.IsZero() && !to.IsZero() && to.Before(from) {
+			return nil, errors.Errorf("date window %s -> %s ends before it starts", window.From, window.To)
+		}
+
+		periods[i] = period{
+			label: fmt.Sprintf("%s -> %s", window.From, window.To),
+			from:  from,
+			to:    to,
+		}
+	}
+
+	return periods, nil
+}
+
+// parseDate returns TRUE when the date is a day without time.
+func parseDate(value string) (time.Time, bool, error) {
+	if value == "" {
+		return time.Time{}, false, nil
+	}
+
+	if date, err := time.Parse(dateLayout, value); err == nil {
+		return date, true, nil
+	}
+
+	date, err := time.Parse(time.RFC3339, value)
+	return date, false, errors.Wrapf(err, "'%s' must be a date (YYYY-MM-DD) or a RFC3339 timestamp", value)
+}
+
+func (p period) contains(dateTime time.Time) bool {
+	// local dates are compared without time zone conversion: that's how they are labelled in albums
+	dateTime = time.Date(dateTime.Year(), dateTime.Month(), dateTime.Day(), dateTime.Hour(), dateTime.Minute(), dateTime.Second(), dateTime.Nanosecond(), time.UTC)
+	return (p.from.IsZero() || !dateTime.Before(p.from)) && (p.to.IsZero() || !dateTime.After(p.to))
+}
+
+func (p period) String() string {
+	return p.label
+}
+
+func anyContains(periods []period, dateTime time.Time) bool {
+	for _, p := range periods {
+		if p.contains(dateTime) {
+			return true
+		}
+	}
+	return false
+}
+
+func concat[T any](a, b []T) []T {
+	if len(a) == 0 && len(b) == 0 {
+		return nil
+	}
+	return append(append(make([]T, 0, len(a)+len(b)), a...), b...)
+}
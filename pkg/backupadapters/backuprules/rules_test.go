@@ -0,0 +1,126 @@
+package backuprules
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/thomasduchatelle/dphoto/pkg/backup"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFilter_FilterOutReason(t *testing.T) {
+	summer := time.Date(2023, 7, 14, 18, 30, 0, 0, time.UTC)
+
+	type media struct {
+		path          string
+		mediaType     backup.MediaType
+		width, height int
+		duration      time.Duration
+		camera        [2]string
+		dateTime      time.Time
+	}
+	photo := func(path string) media {
+		return media{path: path, mediaType: backup.MediaTypeImage, width: 4032, height: 3024, camera: [2]string{"Apple", "iPhone 12"}, dateTime: summer}
+	}
+	tests := []struct {
+		name  string
+		rules Rules
+		media media
+		want  string
+	}{
+		{"it should accept any media without rule", Rules{}, photo("DCIM/IMG_001.jpg"), ""},
+		{"it should exclude a file matching a pattern at any depth", Rules{Exclude: []string{"*.gif"}}, photo("DCIM/Animations/IMG_001.GIF"), "excluded by '*.gif'"},
+		{"it should exclude the content of a directory", Rules{Exclude: []string{"WhatsApp/"}}, photo("Pictures/WhatsApp/Sent/IMG_001.jpg"), "excluded by 'WhatsApp/'"},
+		{"it should exclude a path anchored to the volume root with a double star", Rules{Exclude: []string{"Pictures/**/thumbnails"}}, photo("Pictures/2023/summer/thumbnails/IMG_001.jpg"), "excluded by 'Pictures/**/thumbnails'"},
+		{"it should not exclude an anchored path from another root", Rules{Exclude: []string{"Pictures/**/thumbnails"}}, photo("DCIM/thumbnails/IMG_001.jpg"), ""},
+		{"it should re-include a media excluded by a previous pattern", Rules{Exclude: []string{"WhatsApp/", "!WhatsApp/Family/"}}, photo("WhatsApp/Family/IMG_001.jpg"), ""},
+		{"it should only accept included medias", Rules{Include: []string{"DCIM/"}}, photo("Download/IMG_001.jpg"), "not included by any pattern"},
+		{"it should accept included medias", Rules{Include: []string{"DCIM/"}}, photo("DCIM/Camera/IMG_001.jpg"), ""},
+		{"it should exclude small medias", Rules{MinWidth: 800, MinHeight: 600}, media{path: "IMG.jpg", mediaType: backup.MediaTypeImage, width: 640, height: 480}, "smaller than 800x600"},
+		{"it should accept small medias in portrait orientation", Rules{MinWidth: 800, MinHeight: 600}, media{path: "IMG.jpg", mediaType: backup.MediaTypeImage, width: 600, height: 800}, ""},
+		{"it should accept medias with unknown dimensions", Rules{MinWidth: 800, MinHeight: 600}, media{path: "IMG.jpg", mediaType: backup.MediaTypeImage}, ""},
+		{"it should exclude long videos", Rules{MaxDuration: 10 * time.Minute}, media{path: "VID.mp4", mediaType: backup.MediaTypeVideo, duration: time.Hour}, "longer than 10m0s"},
+		{"it should accept short videos", Rules{MaxDuration: 10 * time.Minute}, media{path: "VID.mp4", mediaType: backup.MediaTypeVideo, duration: time.Minute}, ""},
+		{"it should exclude medias from an excluded camera", Rules{ExcludeCameras: []string{"apple iphone 1*"}}, photo("IMG.jpg"), "camera excluded by 'apple iphone 1*'"},
+		{"it should exclude medias not from an included camera", Rules{Cameras: []string{"canon *"}}, photo("IMG.jpg"), "camera 'apple iphone 12' not included"},
+		{"it should accept medias without camera information when cameras are restricted", Rules{Cameras: []string{"canon *"}}, media{path: "IMG.jpg", mediaType: backup.MediaTypeImage, dateTime: summer}, ""},
+		{"it should exclude medias outside date windows", Rules{DateWindows: []DateWindow{{From: "2024-01-01"}}}, photo("IMG.jpg"), "outside date windows"},
+		{"it should accept medias taken the last day of a date window", Rules{DateWindows: []DateWindow{{From: "2023-07-01", To: "2023-07-14"}}}, photo("IMG.jpg"), ""},
+		{"it should exclude medias taken during an excluded date window", Rules{ExcludeDateWindows: []DateWindow{{From: "2023-07-14T00:00:00Z", To: "2023-07-14T23:00:00Z"}}}, photo("IMG.jpg"), "taken during 2023-07-14T00:00:00Z -> 2023-07-14T23:00:00Z"},
+		{"it should exclude screenshots", Rules{ExcludeScreenshots: true}, media{path: "Screenshots/IMG.png", mediaType: backup.MediaTypeImage, width: 1170, height: 2532, dateTime: summer}, "screenshot"},
+		{"it should accept panoramic photos from a camera", Rules{ExcludeScreenshots: true}, media{path: "IMG.jpg", mediaType: backup.MediaTypeImage, width: 8000, height: 3000, camera: [2]string{"Apple", "iPhone 12"}}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := NewFilter(tt.rules)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			analysedMedia := &backup.AnalysedMedia{
+				FoundMedia: backup.NewInMemoryMedia(tt.media.path, tt.media.dateTime, []byte("content")),
+				Type:       tt.media.mediaType,
+				Details: &backup.MediaDetails{
+					Width:    tt.media.width,
+					Height:   tt.media.height,
+					DateTime: tt.media.dateTime,
+					Make:     tt.media.camera[0],
+					Model:    tt.media.camera[1],
+					Duration: tt.media.duration.Milliseconds(),
+				},
+			}
+
+			assert.Equal(t, tt.want, filter.FilterOutReason(analysedMedia, "/an-album"))
+			assert.Equal(t, tt.want == "", filter.AcceptAnalysedMedia(analysedMedia, "/an-album"))
+		})
+	}
+}
+
+func TestNewFilter_invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules Rules
+	}{
+		{"it should reject malformed patterns", Rules{Exclude: []string{"[a-"}}},
+		{"it should reject empty patterns", Rules{Include: []string{"/"}}},
+		{"it should reject malformed dates", Rules{DateWindows: []DateWindow{{From: "14/07/2023"}}}},
+		{"it should reject date windows without boundaries", Rules{ExcludeDateWindows: []DateWindow{{}}}},
+		{"it should reject date windows ending before they start", Rules{DateWindows: []DateWindow{{From: "2023-07-14", To: "2023-07-01"}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewFilter(tt.rules)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestRules_Merge(t *testing.T) {
+	configured := Rules{Exclude: []string{"*.gif"}, MinWidth: 800, ExcludeScreenshots: true}
+	volume := Rules{Exclude: []string{"WhatsApp/"}, MinWidth: 1024}
+
+	assert.Equal(t, Rules{Exclude: []string{"*.gif", "WhatsApp/"}, MinWidth: 1024, ExcludeScreenshots: true}, configured.Merge(volume))
+	assert.True(t, Rules{}.Merge(Rules{}).IsEmpty())
+}
+
+func TestReadIgnoreFile(t *testing.T) {
+	volume := t.TempDir()
+
+	got, err := ReadIgnoreFile(volume)
+	if assert.NoError(t, err, "it should not fail when the file doesn't exist") {
+		assert.True(t, got.IsEmpty())
+	}
+
+	err = os.WriteFile(filepath.Join(volume, IgnoreFileName), []byte("# messaging apps\nWhatsApp/\n\n  *.gif  \n!WhatsApp/Family/\n"), 0644)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	got, err = ReadIgnoreFile(volume)
+	if assert.NoError(t, err) {
+		assert.Equal(t, Rules{Exclude: []string{"WhatsApp/", "*.gif", "!WhatsApp/Family/"}}, got)
+	}
+}
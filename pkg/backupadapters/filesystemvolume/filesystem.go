@@ -21,6 +21,17 @@ func New(path string) backup.SourceVolume {
 	}
 }
 
+// NewFiles is a volume rooted on path that only contains the given files (absolute paths) ; files not found, or not supported, are ignored.
+func NewFiles(path string, files []string) backup.SourceVolume {
+	return &filesVolume{
+		volume: volume{
+			path:                path,
+			supportedExtensions: backup.SupportedExtensions,
+		},
+		files: files,
+	}
+}
+
 type volume struct {
 	path                string
 	supportedExtensions map[string]backup.MediaType
@@ -36,7 +47,8 @@ func (v *volume) FindMedias(context.Context) ([]backup.FoundMedia, error) {
 		return nil, errors.Wrapf(err, "invalid volume path")
 	}
 
-	var medias []backup.FoundMedia
+	var medias []*fsMedia
+	sidecars := make(backup.SidecarIndex)
 	err = filepath.WalkDir(absRootPath, func(filePath string, entry fs.DirEntry, err error) error {
 		if err != nil {
 			return err
@@ -54,32 +66,112 @@ func (v *volume) FindMedias(context.Context) ([]backup.FoundMedia, error) {
 			return err
 		}
 
-		ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(filePath), "."))
-		if _, ok := v.supportedExtensions[ext]; !ok {
-			return nil
-		}
-
-		medias = append(medias, &fsMedia{
+		media := &fsMedia{
 			volumeAbsolutePath:   absRootPath,
 			absolutePath:         filePath,
 			size:                 int(info.Size()),
 			lastModificationDate: info.ModTime(),
-		})
+		}
+
+		ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(filePath), "."))
+		if _, ok := v.supportedExtensions[ext]; ok {
+			medias = append(medias, media)
+		} else if backup.IsSidecar(filePath) {
+			sidecars.Add(media)
+		}
 		return nil
 	})
 
-	return medias, errors.Wrapf(err, "walking directory %s", v.path)
+	foundMedias := make([]backup.FoundMedia, len(medias))
+	for i, media := range medias {
+		media.sidecar = sidecars.Find(media.MediaPath())
+		foundMedias[i] = media
+	}
+
+	return foundMedias, errors.Wrapf(err, "walking directory %s", v.path)
 }
 
 func (v *volume) Children(path backup.MediaPath) (backup.SourceVolume, error) {
 	return New(path.ParentFullPath), nil
 }
 
+type filesVolume struct {
+	volume
+	files []string
+}
+
+func (v *filesVolume) FindMedias(context.Context) ([]backup.FoundMedia, error) {
+	absRootPath, err := filepath.Abs(v.path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid volume path")
+	}
+
+	var medias []backup.FoundMedia
+	for _, filePath := range v.files {
+		ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(filePath), "."))
+		if _, ok := v.supportedExtensions[ext]; !ok {
+			continue
+		}
+
+		info, err := os.Stat(filePath)
+		if errors.Is(err, fs.ErrNotExist) || (err == nil && info.IsDir()) {
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading %s", filePath)
+		}
+
+		media := &fsMedia{
+			volumeAbsolutePath:   absRootPath,
+			absolutePath:         filePath,
+			size:                 int(info.Size()),
+			lastModificationDate: info.ModTime(),
+		}
+		media.sidecar, err = findSidecar(absRootPath, filePath)
+		if err != nil {
+			return nil, err
+		}
+
+		medias = append(medias, media)
+	}
+
+	return medias, nil
+}
+
+// findSidecar looks for the sidecar of a media next to it, it returns nil if there is none.
+func findSidecar(absRootPath, filePath string) (backup.FoundMedia, error) {
+	for _, candidate := range backup.SidecarCandidates(filepath.Base(filePath)) {
+		sidecarPath := filepath.Join(filepath.Dir(filePath), candidate)
+
+		info, err := os.Stat(sidecarPath)
+		if errors.Is(err, fs.ErrNotExist) || (err == nil && info.IsDir()) {
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading %s", sidecarPath)
+		}
+
+		return &fsMedia{
+			volumeAbsolutePath:   absRootPath,
+			absolutePath:         sidecarPath,
+			size:                 int(info.Size()),
+			lastModificationDate: info.ModTime(),
+		}, nil
+	}
+
+	return nil, nil
+}
+
 type fsMedia struct {
 	volumeAbsolutePath   string
 	absolutePath         string
 	size                 int
 	lastModificationDate time.Time
+	sidecar              backup.FoundMedia // sidecar is nil when the media has no sidecar
+}
+
+func (f *fsMedia) Sidecar() backup.FoundMedia {
+	return f.sidecar
 }
 
 func (f *fsMedia) LastModification() time.Time {
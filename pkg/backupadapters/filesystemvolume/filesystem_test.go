@@ -71,6 +71,12 @@ func TestScanner(t *testing.T) {
 
 		a.Equal(path.Join(abspath, "a_text.TXT"), medias[0].String(), "it should have String() returning the full URL")
 
+		name = "it should pair the medias with their sidecar"
+		if sidecar := medias[3].(backup.FoundMediaWithSidecar).Sidecar(); a.NotNil(sidecar, name) {
+			a.Equal(path.Join(abspath, "scan/golang-logo.xmp"), sidecar.String(), name)
+		}
+		a.Nil(medias[2].(backup.FoundMediaWithSidecar).Sidecar(), name)
+
 		name = "it should generate a valid children"
 		childVolume, err := fs.Children(medias[1].MediaPath())
 		if a.NoError(err, name) {
@@ -87,3 +93,39 @@ func TestScanner(t *testing.T) {
 		}
 	}
 }
+
+func TestFilesVolume(t *testing.T) {
+	root, _ := filepath.Abs("../../test_resources")
+
+	volume := &filesVolume{
+		volume: volume{
+			path: "../../test_resources",
+			supportedExtensions: map[string]backup.MediaType{
+				"txt":  backup.MediaTypeOther,
+				"jpeg": backup.MediaTypeImage,
+			},
+		},
+		files: []string{
+			path.Join(root, "scan/folder1/another.txt"),
+			path.Join(root, "scan/not-existing.jpeg"),
+			path.Join(root, "scan/folder1"),
+			path.Join(root, "scan/golang-logo.jpeg"),
+		},
+	}
+
+	medias, err := volume.FindMedias(context.TODO())
+	if assert.NoError(t, err) {
+		var relativePaths []string
+		for _, media := range medias {
+			relativePaths = append(relativePaths, path.Join(media.MediaPath().Path, media.MediaPath().Filename))
+		}
+
+		assert.Equal(t, []string{"scan/folder1/another.txt", "scan/golang-logo.jpeg"}, relativePaths, "it should only return the existing files with a supported extension")
+		assert.Equal(t, root, medias[0].MediaPath().Root)
+
+		assert.Nil(t, medias[0].(backup.FoundMediaWithSidecar).Sidecar())
+		if sidecar := medias[1].(backup.FoundMediaWithSidecar).Sidecar(); assert.NotNil(t, sidecar, "it should find the sidecar of the media") {
+			assert.Equal(t, path.Join(root, "scan/golang-logo.xmp"), sidecar.String())
+		}
+	}
+}
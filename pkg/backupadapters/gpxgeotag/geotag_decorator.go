@@ -0,0 +1,71 @@
+// Package gpxgeotag locates the medias captured without GPS (ex: by a DSLR) from the tracks recorded by a GPS logger.
+package gpxgeotag
+
+import (
+	"context"
+	"github.com/thomasduchatelle/dphoto/pkg/backup"
+	"time"
+)
+
+// LocationFinder is implemented by geo.Geotagger.
+type LocationFinder interface {
+	// LocationAt returns the position of a media captured when the clock of the camera was displaying cameraTime ; found is false when unknown.
+	LocationAt(cameraTime time.Time) (latitude, longitude float64, found bool)
+}
+
+// GeotagDecorator completes the details of the analysed medias lacking GPS coordinates.
+type GeotagDecorator struct {
+	Locations       LocationFinder
+	DefaultTimeZone *time.Location // DefaultTimeZone is the zone of the camera clock when the media only recorded its wall-clock ; default to UTC
+}
+
+func (g *GeotagDecorator) Decorate(analyser backup.Analyser, observers ...backup.AnalyserDecoratorObserver) backup.Analyser {
+	return &geotagAnalyser{
+		GeotagDecorator: g,
+		delegate:        analyser,
+	}
+}
+
+type geotagAnalyser struct {
+	*GeotagDecorator
+	delegate backup.Analyser
+}
+
+func (a *geotagAnalyser) Analyse(ctx context.Context, found backup.FoundMedia) (*backup.AnalysedMedia, error) {
+	media, err := a.delegate.Analyse(ctx, found)
+	if err != nil || media == nil || media.Details == nil || media.Details.DateTime.IsZero() || hasGPS(media.Details) {
+		return media, err
+	}
+
+	latitude, longitude, located := a.Locations.LocationAt(a.cameraTime(media.Details))
+	if !located {
+		return media, nil
+	}
+
+	details := *media.Details
+	details.GPSLatitude = latitude
+	details.GPSLongitude = longitude
+	details.GPSInferred = true
+
+	geotagged := *media
+	geotagged.Details = &details
+	return &geotagged, nil
+}
+
+// cameraTime is the instant displayed by the camera clock ; the wall-clock is assumed to be in the default time zone.
+func (a *geotagAnalyser) cameraTime(details *backup.MediaDetails) time.Time {
+	if details.TimeReference != backup.TimeReferenceWallClock {
+		return details.DateTime
+	}
+
+	zone := a.DefaultTimeZone
+	if zone == nil {
+		zone = time.UTC
+	}
+	wallClock := details.DateTime
+	return time.Date(wallClock.Year(), wallClock.Month(), wallClock.Day(), wallClock.Hour(), wallClock.Minute(), wallClock.Second(), wallClock.Nanosecond(), zone)
+}
+
+func hasGPS(details *backup.MediaDetails) bool {
+	return details.GPSLatitude != 0 || details.GPSLongitude != 0
+}
@@ -0,0 +1,82 @@
+package gpxgeotag
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/thomasduchatelle/dphoto/pkg/backup"
+	"testing"
+	"time"
+)
+
+func TestGeotagDecorator_Decorate(t *testing.T) {
+	paris := time.FixedZone("", 7200)
+	lastKnownTime := time.Date(2023, 7, 14, 10, 0, 0, 0, time.UTC)
+	media := backup.NewInMemoryMedia("DSC_0001.jpg", time.Time{}, []byte("nice picture"))
+
+	tests := []struct {
+		name        string
+		details     *backup.MediaDetails
+		wantDetails *backup.MediaDetails
+	}{
+		{
+			name:        "it should locate a media with a wall-clock date in the default time zone",
+			details:     &backup.MediaDetails{DateTime: time.Date(2023, 7, 14, 12, 0, 0, 0, time.UTC)},
+			wantDetails: &backup.MediaDetails{DateTime: time.Date(2023, 7, 14, 12, 0, 0, 0, time.UTC), GPSLatitude: 45.9, GPSLongitude: 6.8, GPSInferred: true},
+		},
+		{
+			name:        "it should locate a media with a zoned date",
+			details:     &backup.MediaDetails{DateTime: time.Date(2023, 7, 14, 12, 0, 0, 0, paris), TimeReference: backup.TimeReferenceZoned},
+			wantDetails: &backup.MediaDetails{DateTime: time.Date(2023, 7, 14, 12, 0, 0, 0, paris), TimeReference: backup.TimeReferenceZoned, GPSLatitude: 45.9, GPSLongitude: 6.8, GPSInferred: true},
+		},
+		{
+			name:        "it should not locate a media with an instant date captured outside the track",
+			details:     &backup.MediaDetails{DateTime: time.Date(2023, 7, 14, 12, 0, 0, 0, time.UTC), TimeReference: backup.TimeReferenceInstant},
+			wantDetails: &backup.MediaDetails{DateTime: time.Date(2023, 7, 14, 12, 0, 0, 0, time.UTC), TimeReference: backup.TimeReferenceInstant},
+		},
+		{
+			name:        "it should keep the coordinates read from the media",
+			details:     &backup.MediaDetails{DateTime: time.Date(2023, 7, 14, 12, 0, 0, 0, paris), TimeReference: backup.TimeReferenceZoned, GPSLatitude: 48.8584, GPSLongitude: 2.2945},
+			wantDetails: &backup.MediaDetails{DateTime: time.Date(2023, 7, 14, 12, 0, 0, 0, paris), TimeReference: backup.TimeReferenceZoned, GPSLatitude: 48.8584, GPSLongitude: 2.2945},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := *tt.details
+			decorator := &GeotagDecorator{
+				Locations:       LocationFinderFake{lastKnownTime: {45.9, 6.8}},
+				DefaultTimeZone: paris,
+			}
+
+			analyser := decorator.Decorate(&AnalyserStub{Details: tt.details})
+			got, err := analyser.Analyse(context.Background(), media)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.wantDetails, got.Details)
+				assert.Equal(t, media, got.FoundMedia)
+				assert.Equal(t, original, *tt.details, "it should not change the details of the decorated analyser")
+			}
+		})
+	}
+}
+
+type LocationFinderFake map[time.Time][2]float64
+
+func (l LocationFinderFake) LocationAt(cameraTime time.Time) (latitude, longitude float64, found bool) {
+	if location, ok := l[cameraTime.UTC()]; ok {
+		return location[0], location[1], true
+	}
+
+	return 0, 0, false
+}
+
+type AnalyserStub struct {
+	Details *backup.MediaDetails
+}
+
+func (a *AnalyserStub) Analyse(ctx context.Context, found backup.FoundMedia) (*backup.AnalysedMedia, error) {
+	return &backup.AnalysedMedia{
+		FoundMedia: found,
+		Type:       backup.MediaTypeImage,
+		Details:    a.Details,
+	}, nil
+}
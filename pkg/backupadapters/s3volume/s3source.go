@@ -58,7 +58,8 @@ func (s *volume) String() string {
 }
 
 func (s *volume) FindMedias(context.Context) ([]backup.FoundMedia, error) {
-	var medias []backup.FoundMedia
+	var medias []*s3Media
+	sidecars := make(backup.SidecarIndex)
 
 	paginator := s3.NewListObjectsV2Paginator(s.s3, &s3.ListObjectsV2Input{
 		Bucket: &s.bucket,
@@ -71,20 +72,30 @@ func (s *volume) FindMedias(context.Context) ([]backup.FoundMedia, error) {
 		}
 
 		for _, obj := range page.Contents {
+			media := &s3Media{
+				bucket:           s.bucket,
+				keyPrefix:        s.keyPrefix,
+				s3:               s.s3,
+				S3Object:         &obj,
+				lastModification: *obj.LastModified,
+			}
+
 			ext := strings.TrimPrefix(strings.ToLower(path.Ext(*obj.Key)), ".")
 			if _, supported := s.supportedExtensions[ext]; supported {
-				medias = append(medias, &s3Media{
-					bucket:           s.bucket,
-					keyPrefix:        s.keyPrefix,
-					s3:               s.s3,
-					S3Object:         &obj,
-					lastModification: *obj.LastModified,
-				})
+				medias = append(medias, media)
+			} else if backup.IsSidecar(*obj.Key) {
+				sidecars.Add(media)
 			}
 		}
 	}
 
-	return medias, nil
+	foundMedias := make([]backup.FoundMedia, len(medias))
+	for i, media := range medias {
+		media.sidecar = sidecars.Find(media.MediaPath())
+		foundMedias[i] = media
+	}
+
+	return foundMedias, nil
 }
 
 type s3Media struct {
@@ -93,6 +104,11 @@ type s3Media struct {
 	s3               *s3.Client
 	S3Object         *types.Object
 	lastModification time.Time
+	sidecar          backup.FoundMedia // sidecar is nil when the media has no sidecar
+}
+
+func (s *s3Media) Sidecar() backup.FoundMedia {
+	return s.sidecar
 }
 
 func (s *s3Media) LastModification() time.Time {
@@ -0,0 +1,163 @@
+// Package bandwidth limits the throughput of uploads, with a rate that can depend on the time of the day.
+package bandwidth
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// Unlimited is the rate when no limit applies.
+	Unlimited int64 = 0
+
+	minChunkSize = 1024 // minChunkSize prevents reading byte by byte on very low rates
+)
+
+// Window overrides the rate of a Schedule between two times of the day ; To can be before From for windows over midnight.
+type Window struct {
+	From           time.Duration // From is the time of the day, since midnight, when the window starts (inclusive)
+	To             time.Duration // To is the time of the day, since midnight, when the window ends (exclusive)
+	BytesPerSecond int64         // BytesPerSecond is the rate during the window ; 0 is unlimited
+}
+
+func (w Window) contains(timeOfDay time.Duration) bool {
+	if w.From <= w.To {
+		return timeOfDay >= w.From && timeOfDay < w.To
+	}
+
+	return timeOfDay >= w.From || timeOfDay < w.To
+}
+
+// Schedule is a rate in bytes per second, overridden during windows of the day.
+type Schedule struct {
+	BytesPerSecond int64    // BytesPerSecond is the rate outside the windows ; 0 is unlimited
+	Windows        []Window // Windows are evaluated in order, the first matching one wins
+}
+
+// IsUnlimited returns true when the schedule never limits the rate.
+func (s Schedule) IsUnlimited() bool {
+	if s.BytesPerSecond > 0 {
+		return false
+	}
+
+	for _, window := range s.Windows {
+		if window.BytesPerSecond > 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// RateAt returns the rate applicable at the given time (local time of the day is used).
+func (s Schedule) RateAt(now time.Time) int64 {
+	hour, minute, second := now.Clock()
+	timeOfDay := time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute + time.Duration(second)*time.Second
+
+	for _, window := range s.Windows {
+		if window.contains(timeOfDay) {
+			return window.BytesPerSecond
+		}
+	}
+
+	return s.BytesPerSecond
+}
+
+// Limiter is a token bucket shared by the readers it throttles ; it starts full and allows bursts of one second.
+type Limiter struct {
+	lock     sync.Mutex
+	schedule Schedule
+	now      func() time.Time
+	sleep    func(ctx context.Context, duration time.Duration) error
+	rate     int64
+	tokens   float64
+	last     time.Time
+}
+
+// NewLimiter returns nil when the schedule is unlimited ; a nil Limiter never waits.
+func NewLimiter(schedule Schedule) *Limiter {
+	if schedule.IsUnlimited() {
+		return nil
+	}
+
+	return &Limiter{
+		schedule: schedule,
+		now:      time.Now,
+		sleep:    sleepWithContext,
+	}
+}
+
+// Rate returns the rate currently applied, in bytes per second ; 0 is unlimited.
+func (l *Limiter) Rate() int64 {
+	if l == nil {
+		return Unlimited
+	}
+
+	return l.schedule.RateAt(l.now())
+}
+
+// WaitN blocks until n bytes can be sent without exceeding the rate, or until the context is cancelled.
+func (l *Limiter) WaitN(ctx context.Context, n int) error {
+	if l == nil || n <= 0 {
+		return nil
+	}
+
+	wait := l.reserve(n)
+	if wait <= 0 {
+		return nil
+	}
+
+	return l.sleep(ctx, wait)
+}
+
+// reserve takes n tokens, the bucket can go negative: the returned duration is the time for it to be positive again.
+func (l *Limiter) reserve(n int) time.Duration {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := l.now()
+	rate := l.schedule.RateAt(now)
+	if l.last.IsZero() {
+		l.tokens, l.last = float64(rate), now
+	}
+	if rate != l.rate {
+		l.rate = rate
+		l.tokens = min(l.tokens, float64(rate))
+		l.last = now
+	}
+	if rate == Unlimited {
+		return 0
+	}
+
+	l.tokens = min(l.tokens+now.Sub(l.last).Seconds()*float64(rate), float64(rate))
+	l.last = now
+	l.tokens -= float64(n)
+
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / float64(rate) * float64(time.Second))
+}
+
+// chunkSize is the maximum number of bytes to read at once to not exceed the burst.
+func (l *Limiter) chunkSize() int {
+	rate := l.Rate()
+	if rate == Unlimited {
+		return 0
+	}
+
+	return int(max(rate, minChunkSize))
+}
+
+func sleepWithContext(ctx context.Context, duration time.Duration) error {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,124 @@
+package bandwidth
+
+import (
+	"bytes"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestSchedule_RateAt(t *testing.T) {
+	schedule := Schedule{
+		BytesPerSecond: 1000,
+		Windows: []Window{
+			{From: 22 * time.Hour, To: 7 * time.Hour, BytesPerSecond: Unlimited},
+			{From: 12 * time.Hour, To: 14 * time.Hour, BytesPerSecond: 5000},
+		},
+	}
+	day := func(hour, minute int) time.Time {
+		return time.Date(2024, 3, 15, hour, minute, 0, 0, time.Local)
+	}
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want int64
+	}{
+		{"it should use the default rate outside windows", day(9, 30), 1000},
+		{"it should use the rate of a window over midnight before midnight", day(23, 0), Unlimited},
+		{"it should use the rate of a window over midnight after midnight", day(3, 0), Unlimited},
+		{"it should include the start of a window", day(22, 0), Unlimited},
+		{"it should exclude the end of a window", day(7, 0), 1000},
+		{"it should use the rate of a window during the day", day(13, 59), 5000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, schedule.RateAt(tt.now))
+		})
+	}
+}
+
+func TestSchedule_IsUnlimited(t *testing.T) {
+	assert.True(t, Schedule{}.IsUnlimited())
+	assert.True(t, Schedule{Windows: []Window{{From: time.Hour, To: 2 * time.Hour}}}.IsUnlimited())
+	assert.False(t, Schedule{Windows: []Window{{From: time.Hour, To: 2 * time.Hour, BytesPerSecond: 10}}}.IsUnlimited())
+	assert.Nil(t, NewLimiter(Schedule{}))
+}
+
+func TestLimiter_WaitN(t *testing.T) {
+	clock := &ClockFake{now: time.Date(2024, 3, 15, 9, 0, 0, 0, time.Local)}
+	limiter := clock.limiter(Schedule{BytesPerSecond: 1000, Windows: []Window{{From: 22 * time.Hour, To: 24 * time.Hour}}})
+
+	assert.NoError(t, limiter.WaitN(context.Background(), 1000))
+	assert.Equal(t, time.Duration(0), clock.slept, "it should allow a burst of one second")
+
+	assert.NoError(t, limiter.WaitN(context.Background(), 500))
+	assert.Equal(t, 500*time.Millisecond, clock.slept, "it should wait once the burst is consumed")
+
+	clock.Advance(10 * time.Second)
+	clock.slept = 0
+	assert.NoError(t, limiter.WaitN(context.Background(), 1500))
+	assert.Equal(t, 500*time.Millisecond, clock.slept, "it should not accumulate more than one second of tokens")
+
+	clock.now = time.Date(2024, 3, 15, 22, 30, 0, 0, time.Local)
+	clock.slept = 0
+	assert.NoError(t, limiter.WaitN(context.Background(), 1_000_000))
+	assert.Equal(t, time.Duration(0), clock.slept, "it should not wait during an unlimited window")
+	assert.Equal(t, Unlimited, limiter.Rate())
+}
+
+func TestLimiter_WaitN_cancelled(t *testing.T) {
+	limiter := NewLimiter(Schedule{BytesPerSecond: 10})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.NoError(t, limiter.WaitN(ctx, 10))
+	assert.ErrorIs(t, limiter.WaitN(ctx, 10), context.Canceled)
+}
+
+func TestNewReader(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 500)
+
+	clock := &ClockFake{now: time.Date(2024, 3, 15, 9, 0, 0, 0, time.Local)}
+	global := clock.limiter(Schedule{BytesPerSecond: 2000})
+	perRoutine := clock.limiter(Schedule{BytesPerSecond: 1000})
+
+	read, err := io.ReadAll(NewReader(context.Background(), bytes.NewReader(content), global, nil, perRoutine))
+	if assert.NoError(t, err) {
+		assert.Equal(t, content, read)
+		assert.Equal(t, 4*time.Second, clock.slept, "it should be throttled by the lowest limit: 5000 bytes at 1000 B/s, after a burst of 1000 bytes")
+	}
+
+	reader := bytes.NewReader(content)
+	assert.Same(t, reader, NewReader(context.Background(), reader, nil), "it should not wrap the reader when no limit applies")
+}
+
+// ClockFake moves the time forward when the limiter sleeps.
+type ClockFake struct {
+	now   time.Time
+	slept time.Duration
+}
+
+func (c *ClockFake) limiter(schedule Schedule) *Limiter {
+	limiter := NewLimiter(schedule)
+	limiter.now = func() time.Time {
+		return c.now
+	}
+	limiter.sleep = func(ctx context.Context, duration time.Duration) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		c.Advance(duration)
+		c.slept += duration
+		return nil
+	}
+	return limiter
+}
+
+func (c *ClockFake) Advance(duration time.Duration) {
+	c.now = c.now.Add(duration)
+}
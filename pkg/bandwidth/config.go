@@ -0,0 +1,113 @@
+package bandwidth
+
+import (
+	"github.com/pkg/errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var rateUnits = map[string]int64{
+	"":    1,
+	"b":   1,
+	"k":   1000,
+	"kb":  1000,
+	"kib": 1024,
+	"m":   1000 * 1000,
+	"mb":  1000 * 1000,
+	"mib": 1024 * 1024,
+	"g":   1000 * 1000 * 1000,
+	"gb":  1000 * 1000 * 1000,
+	"gib": 1024 * 1024 * 1024,
+}
+
+// Config is declared in the configuration under 'backup.bandwidth' ; rates are like '512KiB', '2MB', or 'unlimited'.
+type Config struct {
+	Limit      string         `mapstructure:"limit"`      // Limit is shared by all the uploads
+	PerRoutine string         `mapstructure:"perRoutine"` // PerRoutine is the limit of each upload routine
+	Windows    []WindowConfig `mapstructure:"windows"`    // Windows are times of the day when other limits apply, like full speed at night
+}
+
+// WindowConfig overrides both limits between two times of the day ('HH:MM') ; a limit not set in a window is unlimited.
+type WindowConfig struct {
+	From       string `mapstructure:"from"`
+	To         string `mapstructure:"to"`
+	Limit      string `mapstructure:"limit"`
+	PerRoutine string `mapstructure:"perRoutine"`
+}
+
+// Schedules parses the configuration into the global schedule and the schedule of each routine.
+func (c Config) Schedules() (global Schedule, perRoutine Schedule, err error) {
+	if global.BytesPerSecond, err = ParseRate(c.Limit); err != nil {
+		return Schedule{}, Schedule{}, errors.Wrapf(err, "invalid 'limit'")
+	}
+	if perRoutine.BytesPerSecond, err = ParseRate(c.PerRoutine); err != nil {
+		return Schedule{}, Schedule{}, errors.Wrapf(err, "invalid 'perRoutine'")
+	}
+
+	for i, windowConfig := range c.Windows {
+		var window Window
+		if window.From, err = ParseTimeOfDay(windowConfig.From); err != nil {
+			return Schedule{}, Schedule{}, errors.Wrapf(err, "invalid 'from' in window %d", i+1)
+		}
+		if window.To, err = ParseTimeOfDay(windowConfig.To); err != nil {
+			return Schedule{}, Schedule{}, errors.Wrapf(err, "invalid 'to' in window %d", i+1)
+		}
+
+		routineWindow := window
+		if window.BytesPerSecond, err = ParseRate(windowConfig.Limit); err != nil {
+			return Schedule{}, Schedule{}, errors.Wrapf(err, "invalid 'limit' in window %d", i+1)
+		}
+		if routineWindow.BytesPerSecond, err = ParseRate(windowConfig.PerRoutine); err != nil {
+			return Schedule{}, Schedule{}, errors.Wrapf(err, "invalid 'perRoutine' in window %d", i+1)
+		}
+
+		global.Windows = append(global.Windows, window)
+		perRoutine.Windows = append(perRoutine.Windows, routineWindow)
+	}
+
+	return global, perRoutine, nil
+}
+
+// ParseRate reads a rate in bytes per second like '500000', '512KiB', '2MB/s', or '1.5M' ; empty, '0' and 'unlimited' are Unlimited.
+func ParseRate(value string) (int64, error) {
+	value = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(value)), "/s")
+	if value == "" || value == "unlimited" {
+		return Unlimited, nil
+	}
+
+	unitIndex := strings.IndexFunc(value, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	number, unit := value, ""
+	if unitIndex >= 0 {
+		number, unit = value[:unitIndex], strings.TrimSpace(value[unitIndex:])
+	}
+
+	multiplier, supported := rateUnits[unit]
+	if !supported {
+		return 0, errors.Errorf("unit of '%s' is not supported, use B, KB, KiB, MB, MiB, GB, or GiB", value)
+	}
+
+	quantity, err := strconv.ParseFloat(number, 64)
+	if err != nil || quantity < 0 {
+		return 0, errors.Errorf("'%s' is not a rate", value)
+	}
+
+	return int64(math.Round(quantity * float64(multiplier))), nil
+}
+
+// ParseTimeOfDay reads 'HH:MM' into the duration since midnight ; '24:00' is accepted as the end of the day.
+func ParseTimeOfDay(value string) (time.Duration, error) {
+	if strings.TrimSpace(value) == "24:00" {
+		return 24 * time.Hour, nil
+	}
+
+	parsed, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, errors.Errorf("'%s' is not a time of the day, use HH:MM", value)
+	}
+
+	return time.Duration(parsed.Hour())*time.Hour + time.Duration(parsed.Minute())*time.Minute, nil
+}
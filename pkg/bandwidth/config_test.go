@@ -0,0 +1,68 @@
+package bandwidth
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{"", Unlimited, false},
+		{"unlimited", Unlimited, false},
+		{"0", Unlimited, false},
+		{"500000", 500_000, false},
+		{"512KiB", 512 * 1024, false},
+		{"2MB/s", 2_000_000, false},
+		{"1.5 MiB", 1024 * 1024 * 3 / 2, false},
+		{"1g", 1_000_000_000, false},
+		{"12 parsecs", 0, true},
+		{"fast", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseRate(tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else if assert.NoError(t, err) {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestConfig_Schedules(t *testing.T) {
+	global, perRoutine, err := Config{
+		Limit:      "1MB",
+		PerRoutine: "256KB",
+		Windows: []WindowConfig{
+			{From: "22:00", To: "06:30"},
+			{From: "12:00", To: "24:00", Limit: "2MB"},
+		},
+	}.Schedules()
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, Schedule{
+			BytesPerSecond: 1_000_000,
+			Windows: []Window{
+				{From: 22 * time.Hour, To: 6*time.Hour + 30*time.Minute, BytesPerSecond: Unlimited},
+				{From: 12 * time.Hour, To: 24 * time.Hour, BytesPerSecond: 2_000_000},
+			},
+		}, global)
+		assert.Equal(t, Schedule{
+			BytesPerSecond: 256_000,
+			Windows: []Window{
+				{From: 22 * time.Hour, To: 6*time.Hour + 30*time.Minute, BytesPerSecond: Unlimited},
+				{From: 12 * time.Hour, To: 24 * time.Hour, BytesPerSecond: Unlimited},
+			},
+		}, perRoutine)
+	}
+
+	_, _, err = Config{Windows: []WindowConfig{{From: "10pm", To: "06:00"}}}.Schedules()
+	assert.ErrorContains(t, err, "invalid 'from' in window 1")
+}
@@ -0,0 +1,45 @@
+package bandwidth
+
+import (
+	"context"
+	"io"
+)
+
+// NewReader throttles the reader with all the limiters (nil limiters are ignored) ; it returns the reader itself when none applies.
+func NewReader(ctx context.Context, reader io.Reader, limiters ...*Limiter) io.Reader {
+	var applicable []*Limiter
+	for _, limiter := range limiters {
+		if limiter != nil {
+			applicable = append(applicable, limiter)
+		}
+	}
+
+	if len(applicable) == 0 {
+		return reader
+	}
+
+	return &throttledReader{ctx: ctx, reader: reader, limiters: applicable}
+}
+
+type throttledReader struct {
+	ctx      context.Context
+	reader   io.Reader
+	limiters []*Limiter
+}
+
+func (r *throttledReader) Read(p []byte) (int, error) {
+	for _, limiter := range r.limiters {
+		if chunk := limiter.chunkSize(); chunk > 0 && chunk < len(p) {
+			p = p[:chunk]
+		}
+	}
+
+	n, err := r.reader.Read(p)
+	for _, limiter := range r.limiters {
+		if waitErr := limiter.WaitN(r.ctx, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+
+	return n, err
+}
@@ -135,29 +135,34 @@ type AlbumAutoCreateLookupStrategy struct {
 }
 
 func (a *AlbumAutoCreateLookupStrategy) LookupAlbum(ctx context.Context, owner ownermodel.Owner, timeline *TimelineAggregate, mediaTime time.Time) (AlbumReference, error) {
+	albumId, err := a.Delegate.Create(ctx, timeline, autoCreatedAlbumRequest(owner, mediaTime))
+	return AlbumReference{
+		AlbumId:          albumId,
+		AlbumJustCreated: true,
+	}, err
+}
+
+// autoCreatedAlbumRequest is the quarterly album created when a media doesn't fit in any existing album.
+func autoCreatedAlbumRequest(owner ownermodel.Owner, mediaTime time.Time) CreateAlbumRequest {
+	mediaTime = wallClock(mediaTime)
 	year := mediaTime.Year()
 	quarter := (mediaTime.Month() - 1) / 3
 
-	createRequest := CreateAlbumRequest{
+	return CreateAlbumRequest{
 		Owner:            owner,
 		Name:             fmt.Sprintf("Q%d %d", quarter+1, year),
 		Start:            time.Date(year, quarter*3+1, 1, 0, 0, 0, 0, time.UTC),
 		End:              time.Date(year, (quarter+1)*3+1, 1, 0, 0, 0, 0, time.UTC),
 		ForcedFolderName: fmt.Sprintf("/%d-Q%d", year, quarter+1),
 	}
-
-	albumId, err := a.Delegate.Create(ctx, timeline, createRequest)
-	return AlbumReference{
-		AlbumId:          albumId,
-		AlbumJustCreated: true,
-	}, err
 }
 
+// DryRunLookupStrategy projects the album AlbumAutoCreateLookupStrategy would create, without creating it.
 type DryRunLookupStrategy struct{}
 
 func (d *DryRunLookupStrategy) LookupAlbum(ctx context.Context, owner ownermodel.Owner, timeline *TimelineAggregate, mediaTime time.Time) (AlbumReference, error) {
 	return AlbumReference{
-		AlbumId:          &AlbumId{owner, NewFolderName("new-album")},
+		AlbumId:          &AlbumId{owner, NewFolderName(autoCreatedAlbumRequest(owner, mediaTime).ForcedFolderName)},
 		AlbumJustCreated: true,
 	}, nil
 }
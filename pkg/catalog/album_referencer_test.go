@@ -243,7 +243,7 @@ func TestNewAlbumDryRunReferencer(t *testing.T) {
 			wantErr: assert.NoError,
 		},
 		{
-			name: "it should makeup a reference when the album has not been found",
+			name: "it should makeup a reference to the album that would be created when the album has not been found",
 			fields: fields{
 				owner:             owner,
 				findAlbumsByOwner: make(FindAlbumsByOwnerPortFake),
@@ -252,7 +252,7 @@ func TestNewAlbumDryRunReferencer(t *testing.T) {
 				mediaTime: jan24,
 			},
 			want: AlbumReference{
-				AlbumId:          &AlbumId{Owner: owner, FolderName: NewFolderName("/new-album")},
+				AlbumId:          &AlbumId{Owner: owner, FolderName: NewFolderName("/2024-Q1")},
 				AlbumJustCreated: true,
 			},
 			wantErr: assert.NoError,
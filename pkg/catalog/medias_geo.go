@@ -0,0 +1,190 @@
+package catalog
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// MaxGeoZoom is the deepest zoom level supported by the usual map tiles providers.
+	MaxGeoZoom = 22
+	// geoClusterCellsPerTile splits each map tile (256 pixels) into cells of 64 pixels: medias within a cell are displayed as a single marker.
+	geoClusterCellsPerTile = 4
+	// geoClusterRepresentatives is the number of medias returned with each cluster to illustrate it.
+	geoClusterRepresentatives = 3
+	// maxMercatorLatitude is the latitude where Web Mercator projection stops.
+	maxMercatorLatitude = 85.05112878
+)
+
+// BoundingBox is the area displayed on a map ; West is greater than East when the box is crossing the antimeridian.
+type BoundingBox struct {
+	West, South, East, North float64
+}
+
+// ParseBoundingBox reads a box in the format 'west,south,east,north' (the one used by GeoJSON).
+func ParseBoundingBox(value string) (BoundingBox, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 4 {
+		return BoundingBox{}, errors.Errorf("bounding box must be 'west,south,east,north', got '%s'", value)
+	}
+
+	var coordinates [4]float64
+	for i, part := range parts {
+		coordinate, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return BoundingBox{}, errors.Wrapf(err, "invalid coordinate '%s' in bounding box '%s'", part, value)
+		}
+		coordinates[i] = coordinate
+	}
+
+	box := BoundingBox{West: coordinates[0], South: coordinates[1], East: coordinates[2], North: coordinates[3]}
+	return box, box.Validate()
+}
+
+func (b BoundingBox) Validate() error {
+	if b.South < -90 || b.North > 90 || b.South > b.North {
+		return errors.Errorf("invalid latitudes in bounding box: south=%f, north=%f", b.South, b.North)
+	}
+	if b.West < -180 || b.West > 180 || b.East < -180 || b.East > 180 {
+		return errors.Errorf("invalid longitudes in bounding box: west=%f, east=%f", b.West, b.East)
+	}
+
+	return nil
+}
+
+func (b BoundingBox) Contains(latitude, longitude float64) bool {
+	if latitude < b.South || latitude > b.North {
+		return false
+	}
+
+	if b.West <= b.East {
+		return longitude >= b.West && longitude <= b.East
+	}
+	return longitude >= b.West || longitude <= b.East
+}
+
+// GeoMedia is a media located on the map.
+type GeoMedia struct {
+	AlbumId   AlbumId
+	Id        MediaId
+	Latitude  float64
+	Longitude float64
+	DateTime  time.Time
+}
+
+// GeoCluster is a group of medias close enough to be displayed as a single marker at the requested zoom level.
+type GeoCluster struct {
+	Latitude        float64    // Latitude is the centroid of the medias of the cluster
+	Longitude       float64    // Longitude is the centroid of the medias of the cluster
+	Count           int        // Count is the total number of medias in the cluster
+	Representatives []GeoMedia // Representatives are the most recent medias of the cluster
+}
+
+type GeoMediasRequest struct {
+	Albums      []AlbumId // Albums are the albums to display on the map, they can belong to different owners
+	BoundingBox BoundingBox
+	Zoom        int // Zoom is the level of the map tiles, from 0 (whole world) to MaxGeoZoom
+}
+
+// GeoMediaQueries locates the medias on a map.
+type GeoMediaQueries struct {
+	SearchMediasRepository SearchMediasRepositoryPort
+}
+
+// FindGeoClusters groups the medias with GPS coordinates within the bounding box, the biggest clusters first.
+// Only the location of the medias within the bounding box is read from each album.
+func (q *GeoMediaQueries) FindGeoClusters(ctx context.Context, request GeoMediasRequest) ([]*GeoCluster, error) {
+	err := request.BoundingBox.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	filter := MediaSearchFilter{Albums: request.Albums, BoundingBox: &request.BoundingBox}
+	var located []GeoMedia
+	for _, albumId := range request.Albums {
+		medias, err := q.SearchMediasRepository.SearchAlbumMedias(ctx, albumId, filter, AlbumSearchQuery{LocationOnly: true})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to find medias from %s", albumId)
+		}
+
+		located = append(located, FilterGeoMedias(albumId, medias, request.BoundingBox)...)
+	}
+
+	return ClusterGeoMedias(located, request.Zoom), nil
+}
+
+// FilterGeoMedias keeps the medias that have GPS coordinates within the bounding box.
+func FilterGeoMedias(albumId AlbumId, medias []*MediaMeta, box BoundingBox) []GeoMedia {
+	var located []GeoMedia
+	for _, media := range medias {
+		latitude, longitude := media.Details.GPSLatitude, media.Details.GPSLongitude
+		if (latitude == 0 && longitude == 0) || !box.Contains(latitude, longitude) {
+			continue
+		}
+
+		located = append(located, GeoMedia{
+			AlbumId:   albumId,
+			Id:        media.Id,
+			Latitude:  latitude,
+			Longitude: longitude,
+			DateTime:  media.Details.DateTime,
+		})
+	}
+
+	return located
+}
+
+// ClusterGeoMedias groups the medias on a grid in the Web Mercator projection: the cells are 64 pixels wide at the given zoom level.
+func ClusterGeoMedias(medias []GeoMedia, zoom int) []*GeoCluster {
+	zoom = max(0, min(zoom, MaxGeoZoom))
+	cellsPerAxis := float64(uint64(geoClusterCellsPerTile) << uint(zoom))
+
+	type cell struct{ x, y int64 }
+	var clusters []*GeoCluster
+	members := make(map[cell][]GeoMedia)
+	for _, media := range medias {
+		key := cell{x: mercatorX(media.Longitude, cellsPerAxis), y: mercatorY(media.Latitude, cellsPerAxis)}
+		members[key] = append(members[key], media)
+	}
+
+	for _, cellMedias := range members {
+		cluster := &GeoCluster{Count: len(cellMedias)}
+		for _, media := range cellMedias {
+			cluster.Latitude += media.Latitude / float64(len(cellMedias))
+			cluster.Longitude += media.Longitude / float64(len(cellMedias))
+		}
+
+		slices.SortFunc(cellMedias, func(a, b GeoMedia) int {
+			if compare := b.DateTime.Compare(a.DateTime); compare != 0 {
+				return compare
+			}
+			return strings.Compare(string(a.Id), string(b.Id))
+		})
+		cluster.Representatives = cellMedias[:min(len(cellMedias), geoClusterRepresentatives)]
+
+		clusters = append(clusters, cluster)
+	}
+
+	slices.SortFunc(clusters, func(a, b *GeoCluster) int {
+		if a.Count != b.Count {
+			return b.Count - a.Count
+		}
+		return strings.Compare(string(a.Representatives[0].Id), string(b.Representatives[0].Id))
+	})
+	return clusters
+}
+
+func mercatorX(longitude, cellsPerAxis float64) int64 {
+	return int64(math.Min(cellsPerAxis-1, math.Floor((longitude+180)/360*cellsPerAxis)))
+}
+
+func mercatorY(latitude, cellsPerAxis float64) int64 {
+	phi := math.Max(-maxMercatorLatitude, math.Min(maxMercatorLatitude, latitude)) * math.Pi / 180
+	y := (1 - math.Log(math.Tan(phi)+1/math.Cos(phi))/math.Pi) / 2
+	return int64(math.Min(cellsPerAxis-1, math.Floor(y*cellsPerAxis)))
+}
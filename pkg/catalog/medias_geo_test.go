@@ -0,0 +1,152 @@
+package catalog_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/thomasduchatelle/dphoto/pkg/catalog"
+	"testing"
+	"time"
+)
+
+func TestParseBoundingBox(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    catalog.BoundingBox
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{"it should read the box in the GeoJSON order", "-5.2, 41.3,9.6,51.1", catalog.BoundingBox{West: -5.2, South: 41.3, East: 9.6, North: 51.1}, assert.NoError},
+		{"it should accept boxes crossing the antimeridian", "170,-50,-170,-10", catalog.BoundingBox{West: 170, South: -50, East: -170, North: -10}, assert.NoError},
+		{"it should reject boxes without 4 coordinates", "1,2,3", catalog.BoundingBox{}, assert.Error},
+		{"it should reject coordinates that are not numbers", "1,2,3,north", catalog.BoundingBox{}, assert.Error},
+		{"it should reject a south greater than the north", "1,50,3,40", catalog.BoundingBox{West: 1, South: 50, East: 3, North: 40}, assert.Error},
+		{"it should reject longitudes out of range", "-190,40,3,50", catalog.BoundingBox{West: -190, South: 40, East: 3, North: 50}, assert.Error},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := catalog.ParseBoundingBox(tt.value)
+			if tt.wantErr(t, err) {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestBoundingBox_Contains(t *testing.T) {
+	france := catalog.BoundingBox{West: -5.2, South: 41.3, East: 9.6, North: 51.1}
+	fiji := catalog.BoundingBox{West: 170, South: -50, East: -170, North: -10}
+
+	assert.True(t, france.Contains(48.8584, 2.2945), "it should contain Paris")
+	assert.False(t, france.Contains(51.5007, -0.1246), "it should not contain London")
+	assert.True(t, fiji.Contains(-16.79, 179.98), "it should contain a location on the west of the antimeridian")
+	assert.True(t, fiji.Contains(-16.8, -179.98), "it should contain a location on the east of the antimeridian")
+	assert.False(t, fiji.Contains(-13.83, -160), "it should not contain locations outside a box crossing the antimeridian")
+}
+
+func TestGeoMediaQueries_FindGeoClusters(t *testing.T) {
+	const owner = "ironman"
+	paris := catalog.AlbumId{Owner: owner, FolderName: catalog.NewFolderName("/2023-07_Paris")}
+	nice := catalog.AlbumId{Owner: owner, FolderName: catalog.NewFolderName("/2023-08_Nice")}
+	shared := catalog.AlbumId{Owner: "pepper", FolderName: catalog.NewFolderName("/2023-07_Louvre")}
+	day := func(dayOfMonth int) time.Time {
+		return time.Date(2023, 7, dayOfMonth, 12, 0, 0, 0, time.UTC)
+	}
+	media := func(id catalog.MediaId, latitude, longitude float64, dateTime time.Time) *catalog.MediaMeta {
+		return &catalog.MediaMeta{Id: id, Details: catalog.MediaDetails{GPSLatitude: latitude, GPSLongitude: longitude, DateTime: dateTime}}
+	}
+
+	repository := &catalog.MediaQueriesInMemory{}
+	for albumId, medias := range map[catalog.AlbumId][]*catalog.MediaMeta{
+		paris: {
+			media("eiffel-tower", 48.8584, 2.2945, day(1)),
+			media("notre-dame", 48.8530, 2.3499, day(2)),
+			media("no-gps", 0, 0, day(3)),
+			media("sacre-coeur", 48.8867, 2.3431, day(4)),
+			media("versailles", 48.8049, 2.1204, day(5)),
+		},
+		nice: {
+			media("promenade", 43.6950, 7.2650, day(6)),
+			media("london", 51.5007, -0.1246, day(7)),
+		},
+		shared: {
+			media("louvre", 48.8606, 2.3376, day(8)),
+		},
+	} {
+		for _, meta := range medias {
+			repository.Medias = append(repository.Medias, catalog.InMemoryMedia{MediaMeta: *meta, AlbumId: albumId})
+		}
+	}
+	france := catalog.BoundingBox{West: -5.2, South: 41.3, East: 9.6, North: 51.1}
+
+	tests := []struct {
+		name    string
+		request catalog.GeoMediasRequest
+		want    []string // want is "<count> at <lat>,<lon>: <representatives>"
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{
+			name:    "it should group medias by region on a country map",
+			request: catalog.GeoMediasRequest{Albums: []catalog.AlbumId{paris, nice, shared}, BoundingBox: france, Zoom: 5},
+			want: []string{
+				"5 at 48.85,2.29: [pepper/2023-07_Louvre/louvre ironman/2023-07_Paris/versailles ironman/2023-07_Paris/sacre-coeur]",
+				"1 at 43.70,7.26: [ironman/2023-08_Nice/promenade]",
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "it should split the clusters when zooming in",
+			request: catalog.GeoMediasRequest{Albums: []catalog.AlbumId{paris}, BoundingBox: france, Zoom: 14},
+			want: []string{
+				"1 at 48.86,2.29: [ironman/2023-07_Paris/eiffel-tower]",
+				"1 at 48.85,2.35: [ironman/2023-07_Paris/notre-dame]",
+				"1 at 48.89,2.34: [ironman/2023-07_Paris/sacre-coeur]",
+				"1 at 48.80,2.12: [ironman/2023-07_Paris/versailles]",
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "it should only use the requested albums",
+			request: catalog.GeoMediasRequest{Albums: []catalog.AlbumId{nice}, BoundingBox: france, Zoom: 5},
+			want: []string{
+				"1 at 43.70,7.26: [ironman/2023-08_Nice/promenade]",
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "it should reject invalid bounding boxes",
+			request: catalog.GeoMediasRequest{Albums: []catalog.AlbumId{nice}, BoundingBox: catalog.BoundingBox{South: 10, North: -10}, Zoom: 5},
+			wantErr: assert.Error,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spy := &SearchMediasRepositorySpy{MediaQueriesInMemory: repository}
+			queries := &catalog.GeoMediaQueries{SearchMediasRepository: spy}
+
+			clusters, err := queries.FindGeoClusters(context.Background(), tt.request)
+			if !tt.wantErr(t, err) || err != nil {
+				return
+			}
+
+			for _, query := range spy.Queries {
+				assert.Equal(t, catalog.AlbumSearchQuery{LocationOnly: true}, query, "it should only read the location of the medias")
+			}
+			for _, filter := range spy.Filters {
+				assert.Equal(t, &tt.request.BoundingBox, filter.BoundingBox, "it should only read the medias within the bounding box")
+			}
+
+			var got []string
+			for _, cluster := range clusters {
+				var representatives []string
+				for _, representative := range cluster.Representatives {
+					representatives = append(representatives, representative.AlbumId.Owner.String()+"/"+representative.AlbumId.FolderName.String()[1:]+"/"+string(representative.Id))
+				}
+				got = append(got, fmt.Sprintf("%d at %.2f,%.2f: %v", cluster.Count, cluster.Latitude, cluster.Longitude, representatives))
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package catalog
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/thomasduchatelle/dphoto/pkg/ownermodel"
+	"time"
+)
+
+// MediaLocation is the position, deduced from its capture time, given to a media without GPS coordinates.
+type MediaLocation struct {
+	Id           MediaId
+	GPSLatitude  float64
+	GPSLongitude float64
+}
+
+// MediaLocator is implemented by geo.Geotagger.
+type MediaLocator interface {
+	// LocationAt returns the position of a media captured when the clock of the camera was displaying cameraTime ; found is false when unknown.
+	LocationAt(cameraTime time.Time) (latitude, longitude float64, found bool)
+}
+
+// UpdateMediaLocationsPort sets the coordinates of the medias and flags them as inferred.
+type UpdateMediaLocationsPort interface {
+	UpdateMediaLocations(ctx context.Context, owner ownermodel.Owner, locations []MediaLocation) error
+}
+
+type UpdateMediaLocationsFunc func(ctx context.Context, owner ownermodel.Owner, locations []MediaLocation) error
+
+func (f UpdateMediaLocationsFunc) UpdateMediaLocations(ctx context.Context, owner ownermodel.Owner, locations []MediaLocation) error {
+	return f(ctx, owner, locations)
+}
+
+type GeotagMediasRequest struct {
+	AlbumId         AlbumId
+	Locator         MediaLocator
+	DefaultTimeZone *time.Location // DefaultTimeZone is the zone of the camera clock for medias backed up before the time zones were known ; default to UTC
+	DryRun          bool           // DryRun returns the locations without updating the medias
+}
+
+// GeotagMedias locates the medias of an album that have no GPS coordinates.
+type GeotagMedias struct {
+	MediaReadRepository  MediaReadRepository
+	UpdateMediaLocations UpdateMediaLocationsPort
+}
+
+// Geotag returns the locations given to the medias ; medias which already have coordinates are left untouched.
+func (g *GeotagMedias) Geotag(ctx context.Context, request GeotagMediasRequest) ([]MediaLocation, error) {
+	medias, err := g.MediaReadRepository.FindMedias(ctx, NewFindMediaRequest(request.AlbumId.Owner).WithAlbum(request.AlbumId.FolderName))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list medias of %s", request.AlbumId)
+	}
+
+	var locations []MediaLocation
+	for _, media := range medias {
+		if media.Details.GPSLatitude != 0 || media.Details.GPSLongitude != 0 {
+			continue
+		}
+
+		latitude, longitude, found := request.Locator.LocationAt(cameraTime(media.Details, request.DefaultTimeZone))
+		if found {
+			locations = append(locations, MediaLocation{
+				Id:           media.Id,
+				GPSLatitude:  latitude,
+				GPSLongitude: longitude,
+			})
+		}
+	}
+
+	log.WithField("AlbumId", request.AlbumId).Infof("%d medias out of %d located from their capture time", len(locations), len(medias))
+	if request.DryRun || len(locations) == 0 {
+		return locations, nil
+	}
+
+	err = g.UpdateMediaLocations.UpdateMediaLocations(ctx, request.AlbumId.Owner, locations)
+	return locations, errors.Wrapf(err, "failed to update locations of medias in %s", request.AlbumId)
+}
+
+// cameraTime is the instant the media has been captured, or its wall-clock in the default zone if the instant is unknown.
+func cameraTime(details MediaDetails, defaultZone *time.Location) time.Time {
+	if !details.DateTimeUTC.IsZero() {
+		return details.DateTimeUTC
+	}
+
+	if defaultZone == nil {
+		defaultZone = time.UTC
+	}
+	wallClock := details.DateTime
+	return time.Date(wallClock.Year(), wallClock.Month(), wallClock.Day(), wallClock.Hour(), wallClock.Minute(), wallClock.Second(), wallClock.Nanosecond(), defaultZone)
+}
@@ -0,0 +1,98 @@
+package catalog_test
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/thomasduchatelle/dphoto/pkg/catalog"
+	"github.com/thomasduchatelle/dphoto/pkg/ownermodel"
+	"testing"
+	"time"
+)
+
+func TestGeotagMedias_Geotag(t *testing.T) {
+	const owner = "ironman"
+	albumId := catalog.AlbumId{Owner: owner, FolderName: catalog.NewFolderName("/2023-07_Hike")}
+	paris := time.FixedZone("", 7200)
+	locator := MediaLocatorFake{
+		time.Date(2023, 7, 14, 10, 0, 0, 0, time.UTC): {45.9, 6.8},
+		time.Date(2023, 7, 14, 10, 2, 0, 0, time.UTC): {45.91, 6.82},
+	}
+
+	medias := []*catalog.MediaMeta{
+		{Id: "media-zoned", Details: catalog.MediaDetails{DateTime: time.Date(2023, 7, 14, 12, 0, 0, 0, time.UTC), DateTimeUTC: time.Date(2023, 7, 14, 10, 0, 0, 0, time.UTC)}},
+		{Id: "media-legacy", Details: catalog.MediaDetails{DateTime: time.Date(2023, 7, 14, 12, 2, 0, 0, time.UTC)}},
+		{Id: "media-with-gps", Details: catalog.MediaDetails{DateTimeUTC: time.Date(2023, 7, 14, 10, 0, 0, 0, time.UTC), GPSLatitude: 48.8584, GPSLongitude: 2.2945}},
+		{Id: "media-out-of-track", Details: catalog.MediaDetails{DateTimeUTC: time.Date(2023, 7, 14, 18, 0, 0, 0, time.UTC)}},
+	}
+	wantLocations := []catalog.MediaLocation{
+		{Id: "media-zoned", GPSLatitude: 45.9, GPSLongitude: 6.8},
+		{Id: "media-legacy", GPSLatitude: 45.91, GPSLongitude: 6.82},
+	}
+
+	tests := []struct {
+		name        string
+		dryRun      bool
+		wantUpdated []catalog.MediaLocation
+	}{
+		{"it should locate the medias without GPS, from their instant or their wall-clock in the default zone", false, wantLocations},
+		{"it should not update the medias when running dry", true, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var updated []catalog.MediaLocation
+			geotag := &catalog.GeotagMedias{
+				MediaReadRepository: &MediaReadRepositoryFake{albumId: medias},
+				UpdateMediaLocations: catalog.UpdateMediaLocationsFunc(func(ctx context.Context, updatedOwner ownermodel.Owner, locations []catalog.MediaLocation) error {
+					assert.Equal(t, ownermodel.Owner(owner), updatedOwner)
+					updated = append(updated, locations...)
+					return nil
+				}),
+			}
+
+			got, err := geotag.Geotag(context.Background(), catalog.GeotagMediasRequest{
+				AlbumId:         albumId,
+				Locator:         locator,
+				DefaultTimeZone: paris,
+				DryRun:          tt.dryRun,
+			})
+			if assert.NoError(t, err) {
+				assert.Equal(t, wantLocations, got)
+				assert.Equal(t, tt.wantUpdated, updated)
+			}
+		})
+	}
+}
+
+type MediaLocatorFake map[time.Time][2]float64
+
+func (m MediaLocatorFake) LocationAt(cameraTime time.Time) (latitude, longitude float64, found bool) {
+	if location, ok := m[cameraTime.UTC()]; ok {
+		return location[0], location[1], true
+	}
+
+	return 0, 0, false
+}
+
+type MediaReadRepositoryFake map[catalog.AlbumId][]*catalog.MediaMeta
+
+func (m MediaReadRepositoryFake) FindMedias(ctx context.Context, request *catalog.FindMediaRequest) ([]*catalog.MediaMeta, error) {
+	var medias []*catalog.MediaMeta
+	for folderName := range request.AlbumFolderNames {
+		medias = append(medias, m[catalog.AlbumId{Owner: request.Owner, FolderName: folderName}]...)
+	}
+
+	return medias, nil
+}
+
+func (m MediaReadRepositoryFake) FindMediaCurrentAlbum(ctx context.Context, owner ownermodel.Owner, mediaId catalog.MediaId) (*catalog.AlbumId, error) {
+	for albumId, medias := range m {
+		for _, media := range medias {
+			if albumId.Owner == owner && media.Id == mediaId {
+				return &albumId, nil
+			}
+		}
+	}
+
+	return nil, catalog.MediaNotFoundError
+}
@@ -0,0 +1,185 @@
+package catalog
+
+import (
+	"context"
+	"slices"
+	"strings"
+)
+
+const (
+	livePhotoGroupPrefix = "LIVE#"
+	burstGroupPrefix     = "BURST#"
+)
+
+// MediaGroupId links the medias captured together: the photo and the video of a Live Photo, or the photos of a burst.
+type MediaGroupId string
+
+// NewMediaGroupId returns the group from the identifiers written by the camera, a Live Photo taking precedence over a burst ; it's empty when the media is not part of a group.
+func NewMediaGroupId(livePhotoId, burstId string) MediaGroupId {
+	if livePhotoId = strings.TrimSpace(livePhotoId); livePhotoId != "" {
+		return MediaGroupId(livePhotoGroupPrefix + strings.ToUpper(livePhotoId))
+	}
+	if burstId = strings.TrimSpace(burstId); burstId != "" {
+		return MediaGroupId(burstGroupPrefix + strings.ToUpper(burstId))
+	}
+
+	return ""
+}
+
+func (g MediaGroupId) IsLivePhoto() bool {
+	return strings.HasPrefix(string(g), livePhotoGroupPrefix)
+}
+
+func (g MediaGroupId) IsBurst() bool {
+	return strings.HasPrefix(string(g), burstGroupPrefix)
+}
+
+func (g MediaGroupId) String() string {
+	return string(g)
+}
+
+// GroupedMedia is a media displayed on its own, or the primary media of its group.
+type GroupedMedia struct {
+	*MediaMeta
+	LiveVideo *MediaMeta   // LiveVideo is the video of a Live Photo, nil otherwise
+	Burst     []*MediaMeta // Burst are all the photos of the burst, including the primary one ; it's empty when the media is not part of a burst
+}
+
+// ListGroupedMedias lists the medias of an album where the medias of a group are represented by their primary media.
+func (q *MediaQueries) ListGroupedMedias(ctx context.Context, albumId AlbumId) ([]*GroupedMedia, error) {
+	medias, err := q.ListMedias(ctx, albumId)
+	if err != nil {
+		return nil, err
+	}
+
+	return GroupMedias(medias), nil
+}
+
+// GroupMedias replaces the medias of each group by their primary media, keeping the order of the primary medias.
+func GroupMedias(medias []*MediaMeta) []*GroupedMedia {
+	groups := indexMediaGroups(medias)
+
+	var grouped []*GroupedMedia
+	for _, media := range medias {
+		members, isGrouped := groups[media.Details.GroupId]
+		if !isGrouped {
+			grouped = append(grouped, &GroupedMedia{MediaMeta: media})
+			continue
+		}
+
+		if primary := PrimaryMediaOfGroup(members); primary.Id == media.Id {
+			groupedMedia := &GroupedMedia{MediaMeta: media}
+			if media.Details.GroupId.IsBurst() {
+				groupedMedia.Burst = members
+			} else if liveVideo := findLiveVideo(members, primary); liveVideo != nil {
+				groupedMedia.LiveVideo = liveVideo
+			}
+			grouped = append(grouped, groupedMedia)
+
+		} else if media.Details.GroupId.IsLivePhoto() && findLiveVideo(members, primary) != media {
+			// other photos sharing the identifier of a Live Photo (ex: edited copies) are displayed on their own
+			grouped = append(grouped, &GroupedMedia{MediaMeta: media})
+		}
+	}
+
+	return grouped
+}
+
+// PrimaryMediaOfGroup returns the media representing the group: the earliest image, or the earliest video when there is no image.
+func PrimaryMediaOfGroup(members []*MediaMeta) *MediaMeta {
+	var primary *MediaMeta
+	for _, member := range members {
+		if primary == nil || comparePrimaryCandidates(member, primary) < 0 {
+			primary = member
+		}
+	}
+
+	return primary
+}
+
+func comparePrimaryCandidates(a, b *MediaMeta) int {
+	if (a.Type == MediaTypeVideo) != (b.Type == MediaTypeVideo) {
+		if b.Type == MediaTypeVideo {
+			return -1
+		}
+		return 1
+	}
+	if comparison := a.Details.DateTime.Compare(b.Details.DateTime); comparison != 0 {
+		return comparison
+	}
+	return strings.Compare(string(a.Id), string(b.Id))
+}
+
+// KeepMediaGroupsTogether adjusts the transfers so the medias of a group follow their primary media: they are transferred to the album of the primary media, or not transferred at all when the primary media is not.
+// groupMembers are the medias of the groups having at least one transferred media ; they can contain transferred medias.
+func KeepMediaGroupsTogether(transfers map[AlbumId][]*MediaMeta, groupMembers []*MediaMeta) map[AlbumId][]MediaId {
+	all := slices.Clone(groupMembers)
+	destinations := make(map[MediaId]AlbumId)
+	for albumId, medias := range transfers {
+		for _, media := range medias {
+			destinations[media.Id] = albumId
+		}
+		all = append(all, medias...)
+	}
+	groups := indexMediaGroups(all)
+
+	adjusted := make(map[AlbumId][]MediaId)
+	for albumId, medias := range transfers {
+		for _, media := range medias {
+			if _, isGrouped := groups[media.Details.GroupId]; !isGrouped {
+				adjusted[albumId] = append(adjusted[albumId], media.Id)
+			}
+		}
+	}
+
+	var groupIds []MediaGroupId
+	for groupId := range groups {
+		groupIds = append(groupIds, groupId)
+	}
+	slices.Sort(groupIds)
+
+	for _, groupId := range groupIds {
+		members := groups[groupId]
+		destination, isTransferred := destinations[PrimaryMediaOfGroup(members).Id]
+		if !isTransferred {
+			continue
+		}
+
+		for _, member := range members {
+			adjusted[destination] = append(adjusted[destination], member.Id)
+		}
+	}
+
+	return adjusted
+}
+
+// indexMediaGroups indexes the medias that are part of a group ; each media is only once in its group, and members are sorted from the primary one.
+func indexMediaGroups(medias []*MediaMeta) map[MediaGroupId][]*MediaMeta {
+	groups := make(map[MediaGroupId][]*MediaMeta)
+	for _, media := range medias {
+		groupId := media.Details.GroupId
+		if groupId == "" {
+			continue
+		}
+
+		if !slices.ContainsFunc(groups[groupId], func(member *MediaMeta) bool { return member.Id == media.Id }) {
+			groups[groupId] = append(groups[groupId], media)
+		}
+	}
+
+	for _, members := range groups {
+		slices.SortFunc(members, comparePrimaryCandidates)
+	}
+	return groups
+}
+
+// findLiveVideo returns the earliest video of a Live Photo which is not the primary media.
+func findLiveVideo(members []*MediaMeta, primary *MediaMeta) *MediaMeta {
+	for _, member := range members {
+		if member.Type == MediaTypeVideo && member.Id != primary.Id {
+			return member
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package catalog_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/thomasduchatelle/dphoto/pkg/catalog"
+	"testing"
+	"time"
+)
+
+func TestNewMediaGroupId(t *testing.T) {
+	live := catalog.NewMediaGroupId("7e1f8a2c-3b4d-4e5f-9a6b-1c2d3e4f5a6b", "")
+	burst := catalog.NewMediaGroupId("", "0B6F4C1D-92E3-4A57-8D21-5C7E3F9A1B04")
+
+	assert.Equal(t, catalog.MediaGroupId("LIVE#7E1F8A2C-3B4D-4E5F-9A6B-1C2D3E4F5A6B"), live, "it should be case-insensitive")
+	assert.True(t, live.IsLivePhoto())
+	assert.False(t, live.IsBurst())
+	assert.Equal(t, catalog.MediaGroupId("BURST#0B6F4C1D-92E3-4A57-8D21-5C7E3F9A1B04"), burst)
+	assert.True(t, burst.IsBurst())
+	assert.True(t, catalog.NewMediaGroupId("7E1F8A2C", "0B6F4C1D").IsLivePhoto(), "it should prefer the Live Photo over the burst")
+	assert.Empty(t, catalog.NewMediaGroupId(" ", ""), "it should not group medias without identifiers")
+}
+
+func TestGroupMedias(t *testing.T) {
+	live := catalog.NewMediaGroupId("live-1", "")
+	burst := catalog.NewMediaGroupId("", "burst-1")
+
+	photo := newGroupMember("photo", catalog.MediaTypeImage, "10:00:00", "")
+	livePhoto := newGroupMember("live-photo", catalog.MediaTypeImage, "10:01:00", live)
+	liveVideo := newGroupMember("live-video", catalog.MediaTypeVideo, "10:01:00", live)
+	burst1 := newGroupMember("burst-1", catalog.MediaTypeImage, "10:02:00", burst)
+	burst2 := newGroupMember("burst-2", catalog.MediaTypeImage, "10:02:01", burst)
+	burst3 := newGroupMember("burst-3", catalog.MediaTypeImage, "10:02:02", burst)
+	orphanVideo := newGroupMember("orphan-video", catalog.MediaTypeVideo, "10:03:00", catalog.NewMediaGroupId("live-2", ""))
+
+	tests := []struct {
+		name   string
+		medias []*catalog.MediaMeta
+		want   []*catalog.GroupedMedia
+	}{
+		{
+			name:   "it should keep the medias that are not grouped",
+			medias: []*catalog.MediaMeta{photo},
+			want:   []*catalog.GroupedMedia{{MediaMeta: photo}},
+		},
+		{
+			name:   "it should represent a Live Photo by its photo",
+			medias: []*catalog.MediaMeta{photo, liveVideo, livePhoto},
+			want:   []*catalog.GroupedMedia{{MediaMeta: photo}, {MediaMeta: livePhoto, LiveVideo: liveVideo}},
+		},
+		{
+			name:   "it should represent a burst by its first photo",
+			medias: []*catalog.MediaMeta{burst3, burst1, photo, burst2},
+			want:   []*catalog.GroupedMedia{{MediaMeta: burst1, Burst: []*catalog.MediaMeta{burst1, burst2, burst3}}, {MediaMeta: photo}},
+		},
+		{
+			name:   "it should display the video of a Live Photo on its own when the photo is missing",
+			medias: []*catalog.MediaMeta{orphanVideo},
+			want:   []*catalog.GroupedMedia{{MediaMeta: orphanVideo}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, catalog.GroupMedias(tt.medias))
+		})
+	}
+}
+
+func TestKeepMediaGroupsTogether(t *testing.T) {
+	jan := catalog.AlbumId{Owner: "ironman", FolderName: catalog.NewFolderName("/2024-01")}
+	feb := catalog.AlbumId{Owner: "ironman", FolderName: catalog.NewFolderName("/2024-02")}
+	live := catalog.NewMediaGroupId("live-1", "")
+	burst := catalog.NewMediaGroupId("", "burst-1")
+
+	photo := newGroupMember("photo", catalog.MediaTypeImage, "10:00:00", "")
+	livePhoto := newGroupMember("live-photo", catalog.MediaTypeImage, "23:59:59", live)
+	liveVideo := newGroupMember("live-video", catalog.MediaTypeVideo, "23:59:59", live)
+	burst1 := newGroupMember("burst-1", catalog.MediaTypeImage, "23:59:58", burst)
+	burst2 := newGroupMember("burst-2", catalog.MediaTypeImage, "23:59:59", burst)
+	burst3 := newGroupMember("burst-3", catalog.MediaTypeImage, "23:59:59.500", burst)
+
+	tests := []struct {
+		name         string
+		transfers    map[catalog.AlbumId][]*catalog.MediaMeta
+		groupMembers []*catalog.MediaMeta
+		want         map[catalog.AlbumId][]catalog.MediaId
+	}{
+		{
+			name:      "it should transfer the medias that are not grouped",
+			transfers: map[catalog.AlbumId][]*catalog.MediaMeta{jan: {photo}},
+			want:      map[catalog.AlbumId][]catalog.MediaId{jan: {"photo"}},
+		},
+		{
+			name:         "it should transfer the video of a Live Photo with its photo",
+			transfers:    map[catalog.AlbumId][]*catalog.MediaMeta{jan: {photo, livePhoto}},
+			groupMembers: []*catalog.MediaMeta{livePhoto, liveVideo},
+			want:         map[catalog.AlbumId][]catalog.MediaId{jan: {"photo", "live-photo", "live-video"}},
+		},
+		{
+			name:         "it should not transfer the video of a Live Photo without its photo",
+			transfers:    map[catalog.AlbumId][]*catalog.MediaMeta{jan: {photo, liveVideo}},
+			groupMembers: []*catalog.MediaMeta{livePhoto, liveVideo},
+			want:         map[catalog.AlbumId][]catalog.MediaId{jan: {"photo"}},
+		},
+		{
+			name:         "it should transfer the whole burst where its first photo goes",
+			transfers:    map[catalog.AlbumId][]*catalog.MediaMeta{jan: {burst1}, feb: {burst2, burst3}},
+			groupMembers: []*catalog.MediaMeta{burst1, burst2, burst3},
+			want:         map[catalog.AlbumId][]catalog.MediaId{jan: {"burst-1", "burst-2", "burst-3"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, catalog.KeepMediaGroupsTogether(tt.transfers, tt.groupMembers))
+		})
+	}
+}
+
+func newGroupMember(id catalog.MediaId, mediaType catalog.MediaType, clock string, groupId catalog.MediaGroupId) *catalog.MediaMeta {
+	dateTime, err := time.Parse("2006-01-02T15:04:05.999", "2024-01-31T"+clock)
+	if err != nil {
+		panic(err)
+	}
+
+	return &catalog.MediaMeta{
+		Id:       id,
+		Type:     mediaType,
+		Filename: string(id) + ".jpg",
+		Details:  catalog.MediaDetails{DateTime: dateTime, GroupId: groupId},
+	}
+}
@@ -0,0 +1,98 @@
+package catalog
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/thomasduchatelle/dphoto/pkg/ownermodel"
+)
+
+// MediaPlace is the name of the location where a media has been captured.
+type MediaPlace struct {
+	Country string
+	Region  string
+	City    string
+}
+
+// ReverseGeocoderPort resolves GPS coordinates into the name of the place.
+type ReverseGeocoderPort interface {
+	// FindPlace returns nil when the location is not near any known place.
+	FindPlace(latitude, longitude float64) (*MediaPlace, error)
+}
+
+type FindMediasByIdsPort interface {
+	// FindMediasByIds only returns found medias
+	FindMediasByIds(ctx context.Context, owner ownermodel.Owner, ids []MediaId) ([]*MediaMeta, error)
+}
+
+// UpdateMediaPlacesPort sets the place in the details of each media.
+type UpdateMediaPlacesPort interface {
+	UpdateMediaPlaces(ctx context.Context, owner ownermodel.Owner, places map[MediaId]MediaPlace) error
+}
+
+type UpdateMediaPlacesFunc func(ctx context.Context, owner ownermodel.Owner, places map[MediaId]MediaPlace) error
+
+func (f UpdateMediaPlacesFunc) UpdateMediaPlaces(ctx context.Context, owner ownermodel.Owner, places map[MediaId]MediaPlace) error {
+	return f(ctx, owner, places)
+}
+
+// MediaPlacesResolver names the place of the inserted medias that have GPS coordinates.
+type MediaPlacesResolver struct {
+	FindMediasByIds   FindMediasByIdsPort
+	ReverseGeocoder   ReverseGeocoderPort
+	UpdateMediaPlaces UpdateMediaPlacesPort
+}
+
+func (r *MediaPlacesResolver) OnMediasInserted(ctx context.Context, medias map[AlbumId][]MediaId) error {
+	idsPerOwner := make(map[ownermodel.Owner][]MediaId)
+	for albumId, ids := range medias {
+		idsPerOwner[albumId.Owner] = append(idsPerOwner[albumId.Owner], ids...)
+	}
+
+	for owner, ids := range idsPerOwner {
+		inserted, err := r.FindMediasByIds.FindMediasByIds(ctx, owner, ids)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read the details of the inserted medias for %s", owner)
+		}
+
+		places := r.ResolvePlaces(inserted)
+		if len(places) == 0 {
+			continue
+		}
+
+		err = r.UpdateMediaPlaces.UpdateMediaPlaces(ctx, owner, places)
+		if err != nil {
+			return errors.Wrapf(err, "failed to update the places of %d medias for %s", len(places), owner)
+		}
+	}
+
+	return nil
+}
+
+// ResolvePlaces returns the places of the medias having GPS coordinates that are not named yet, or that are named differently by the current gazetteer:
+// a name resolved with a previous gazetteer is corrected, and removed when the location is no longer near any known place.
+// The medias that can't be resolved are ignored.
+func (r *MediaPlacesResolver) ResolvePlaces(medias []*MediaMeta) map[MediaId]MediaPlace {
+	places := make(map[MediaId]MediaPlace)
+	for _, media := range medias {
+		if media.Details.GPSLatitude == 0 && media.Details.GPSLongitude == 0 {
+			continue
+		}
+
+		place, err := r.ReverseGeocoder.FindPlace(media.Details.GPSLatitude, media.Details.GPSLongitude)
+		if err != nil {
+			log.WithError(err).WithField("MediaId", media.Id).Warnf("Place of media %s couldn't be resolved", media.Id)
+			continue
+		}
+		if place == nil {
+			place = new(MediaPlace)
+		}
+
+		current := MediaPlace{Country: media.Details.Country, Region: media.Details.Region, City: media.Details.City}
+		if *place != current {
+			places[media.Id] = *place
+		}
+	}
+
+	return places
+}
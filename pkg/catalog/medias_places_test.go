@@ -0,0 +1,96 @@
+package catalog_test
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/thomasduchatelle/dphoto/pkg/catalog"
+	"github.com/thomasduchatelle/dphoto/pkg/ownermodel"
+	"slices"
+	"testing"
+)
+
+func TestMediaPlacesResolver_OnMediasInserted(t *testing.T) {
+	const owner = "ironman"
+	albumId := catalog.AlbumId{Owner: owner, FolderName: catalog.NewFolderName("/2023-07_Paris")}
+	paris := catalog.MediaPlace{Country: "France", Region: "Île-de-France", City: "Paris"}
+
+	medias := FindMediasByIdsFake{
+		owner: {
+			{Id: "media-located", Details: catalog.MediaDetails{GPSLatitude: 48.8584, GPSLongitude: 2.2945}},
+			{Id: "media-without-gps", Details: catalog.MediaDetails{}},
+			{Id: "media-already-named", Details: catalog.MediaDetails{GPSLatitude: 48.8584, GPSLongitude: 2.2945, Country: "France", Region: "Île-de-France", City: "Paris"}},
+			{Id: "media-wrongly-named", Details: catalog.MediaDetails{GPSLatitude: 48.8584, GPSLongitude: 2.2945, Country: "France", Region: "Auvergne-Rhône-Alpes", City: "Lyon"}},
+			{Id: "media-named-at-sea", Details: catalog.MediaDetails{GPSLatitude: -30, GPSLongitude: -20, Country: "Brazil", City: "Rio de Janeiro"}},
+			{Id: "media-at-sea", Details: catalog.MediaDetails{GPSLatitude: -30, GPSLongitude: -20}},
+			{Id: "media-invalid-gps", Details: catalog.MediaDetails{GPSLatitude: 91, GPSLongitude: 2.2945}},
+		},
+	}
+	geocoder := ReverseGeocoderFake{
+		{48.8584, 2.2945}: &paris,
+	}
+
+	tests := []struct {
+		name        string
+		inserted    []catalog.MediaId
+		wantUpdated map[catalog.MediaId]catalog.MediaPlace
+	}{
+		{
+			name:        "it should name the place of the inserted medias with GPS coordinates",
+			inserted:    []catalog.MediaId{"media-located", "media-without-gps", "media-already-named", "media-at-sea", "media-invalid-gps"},
+			wantUpdated: map[catalog.MediaId]catalog.MediaPlace{"media-located": paris},
+		},
+		{
+			name:        "it should correct the places named by a previous gazetteer",
+			inserted:    []catalog.MediaId{"media-already-named", "media-wrongly-named", "media-named-at-sea"},
+			wantUpdated: map[catalog.MediaId]catalog.MediaPlace{"media-wrongly-named": paris, "media-named-at-sea": {}},
+		},
+		{
+			name:     "it should not update anything when no media can be named",
+			inserted: []catalog.MediaId{"media-without-gps", "media-at-sea"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var updated map[catalog.MediaId]catalog.MediaPlace
+			resolver := &catalog.MediaPlacesResolver{
+				FindMediasByIds: medias,
+				ReverseGeocoder: geocoder,
+				UpdateMediaPlaces: catalog.UpdateMediaPlacesFunc(func(ctx context.Context, updatedOwner ownermodel.Owner, places map[catalog.MediaId]catalog.MediaPlace) error {
+					assert.Equal(t, ownermodel.Owner(owner), updatedOwner)
+					updated = places
+					return nil
+				}),
+			}
+
+			err := resolver.OnMediasInserted(context.Background(), map[catalog.AlbumId][]catalog.MediaId{albumId: tt.inserted})
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.wantUpdated, updated)
+			}
+		})
+	}
+}
+
+type FindMediasByIdsFake map[ownermodel.Owner][]*catalog.MediaMeta
+
+func (f FindMediasByIdsFake) FindMediasByIds(ctx context.Context, owner ownermodel.Owner, ids []catalog.MediaId) ([]*catalog.MediaMeta, error) {
+	var medias []*catalog.MediaMeta
+	for _, media := range f[owner] {
+		if slices.Contains(ids, media.Id) {
+			medias = append(medias, media)
+		}
+	}
+
+	return medias, nil
+}
+
+type ReverseGeocoderFake map[[2]float64]*catalog.MediaPlace
+
+func (r ReverseGeocoderFake) FindPlace(latitude, longitude float64) (*catalog.MediaPlace, error) {
+	if latitude > 90 {
+		return nil, errors.Errorf("TEST invalid latitude %f", latitude)
+	}
+
+	return r[[2]float64{latitude, longitude}], nil
+}
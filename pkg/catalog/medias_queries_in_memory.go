@@ -3,6 +3,7 @@ package catalog
 import (
 	"context"
 	"github.com/thomasduchatelle/dphoto/pkg/ownermodel"
+	"slices"
 )
 
 type InMemoryMedia struct {
@@ -45,3 +46,31 @@ func (q *MediaQueriesInMemory) FindMediaOwnership(ctx context.Context, owner own
 
 	return nil, MediaNotFoundError
 }
+
+func (q *MediaQueriesInMemory) SearchAlbumMedias(ctx context.Context, albumId AlbumId, filter MediaSearchFilter, query AlbumSearchQuery) ([]*MediaMeta, error) {
+	var medias []*MediaMeta
+	for _, media := range q.Medias {
+		if media.AlbumId == albumId && filter.Matches(media.AlbumId, &media.MediaMeta) {
+			medias = append(medias, &media.MediaMeta)
+		}
+	}
+
+	slices.SortFunc(medias, func(a, b *MediaMeta) int {
+		if query.MostRecentFirst {
+			return NewSearchKey(b).Compare(NewSearchKey(a))
+		}
+		return NewSearchKey(a).Compare(NewSearchKey(b))
+	})
+
+	if query.From != nil {
+		medias = slices.DeleteFunc(medias, func(media *MediaMeta) bool {
+			comparison := NewSearchKey(media).Compare(*query.From)
+			return (query.MostRecentFirst && comparison > 0) || (!query.MostRecentFirst && comparison < 0)
+		})
+	}
+	if query.Limit > 0 && len(medias) > query.Limit {
+		medias = medias[:query.Limit]
+	}
+
+	return medias, nil
+}
@@ -0,0 +1,302 @@
+package catalog
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/thomasduchatelle/dphoto/pkg/ownermodel"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultSearchPageSize = 100
+	MaxSearchPageSize     = 1000
+)
+
+var (
+	InvalidSearchRequestError = errors.New("invalid search request")
+)
+
+// SearchOrientation is how a media is displayed, once rotated.
+type SearchOrientation string
+
+// SearchSort is the order of the search results.
+type SearchSort string
+
+const (
+	SearchLandscape SearchOrientation = "LANDSCAPE"
+	SearchPortrait  SearchOrientation = "PORTRAIT"
+	SearchSquare    SearchOrientation = "SQUARE"
+
+	SearchMostRecentFirst SearchSort = "DATE_DESC"
+	SearchOldestFirst     SearchSort = "DATE_ASC"
+)
+
+// MediaSearchFilter combines the criteria a media must match ; criteria with a zero value are not restricting the search.
+type MediaSearchFilter struct {
+	Albums           []AlbumId         // Albums are searched, they are usually all the albums the user can see
+	Types            []MediaType       // Types restricts the search to some types of media (ex: 'IMAGE', 'VIDEO')
+	Ranges           []TimeRange       // Ranges are the periods in which the media has been captured (any of them), on the wall-clock of the camera
+	Make             string            // Make of the camera, case-insensitive
+	Model            string            // Model of the camera, case-insensitive
+	Orientation      SearchOrientation // Orientation is the shape of the media once rotated
+	MinWidth         int               // MinWidth is inclusive, once the media is rotated
+	MaxWidth         int               // MaxWidth is inclusive, once the media is rotated
+	MinHeight        int               // MinHeight is inclusive, once the media is rotated
+	MaxHeight        int               // MaxHeight is inclusive, once the media is rotated
+	MinDuration      time.Duration     // MinDuration is inclusive, only videos have a duration
+	MaxDuration      time.Duration     // MaxDuration is inclusive
+	BoundingBox      *BoundingBox      // BoundingBox restricts the search to the medias captured within it
+	FilenameContains string            // FilenameContains is a case-insensitive substring of the filename
+}
+
+// MediaSearchRequest is a page of medias matching the filter.
+type MediaSearchRequest struct {
+	MediaSearchFilter
+	Sort      SearchSort // Sort is SearchMostRecentFirst by default
+	PageSize  int        // PageSize is DefaultSearchPageSize by default, and cannot exceed MaxSearchPageSize
+	PageToken string     // PageToken is the NextPage of the previous page, empty for the first page
+}
+
+// SearchedMedia is a media found by a search, with the album it belongs to.
+type SearchedMedia struct {
+	AlbumId AlbumId
+	*MediaMeta
+}
+
+// MediaSearchPage is the current page of the search, and the token of the next page
+type MediaSearchPage struct {
+	NextPage string // NextPage is empty if no other pages
+	Content  []*SearchedMedia
+}
+
+// SearchMediasRepositoryPort searches the medias album per album ; MediaSearch merges the results of the albums.
+type SearchMediasRepositoryPort interface {
+	// SearchAlbumMedias returns the first medias of the album matching the filter, sorted by SearchKey.
+	SearchAlbumMedias(ctx context.Context, albumId AlbumId, filter MediaSearchFilter, query AlbumSearchQuery) ([]*MediaMeta, error)
+}
+
+// AlbumSearchQuery is the part of the medias of an album that is read.
+type AlbumSearchQuery struct {
+	MostRecentFirst bool       // MostRecentFirst reverses the order of the SearchKey
+	From            *SearchKey // From is the first position (included) from which the medias are returned, all the medias are returned when nil
+	Limit           int        // Limit is the maximum number of medias returned, there is no limit when 0
+	LocationOnly    bool       // LocationOnly only reads the id, the date, and the GPS coordinates of the medias
+}
+
+// SearchKey is the order of the medias within an album: by date, to the second, then by id.
+type SearchKey struct {
+	DateTime time.Time
+	Id       MediaId
+}
+
+func NewSearchKey(media *MediaMeta) SearchKey {
+	return SearchKey{DateTime: media.Details.DateTime.Truncate(time.Second), Id: media.Id}
+}
+
+func (k SearchKey) Compare(other SearchKey) int {
+	if comparison := k.DateTime.Compare(other.DateTime); comparison != 0 {
+		return comparison
+	}
+	return strings.Compare(k.Id.Value(), other.Id.Value())
+}
+
+// MediaSearch finds medias across albums.
+type MediaSearch struct {
+	SearchMediasRepository SearchMediasRepositoryPort
+}
+
+// Search reads, in each album, the medias following the page token up to the size of the page: the albums are merged to keep the first ones.
+func (s *MediaSearch) Search(ctx context.Context, request MediaSearchRequest) (*MediaSearchPage, error) {
+	err := request.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	query := AlbumSearchQuery{
+		MostRecentFirst: request.Sort != SearchOldestFirst,
+		// the media of the token is read again, one more media tells if there is a next page
+		Limit: request.pageSize() + 2,
+	}
+	after, _ := decodeSearchPageToken(request.PageToken)
+	if after != nil {
+		query.From = &after.SearchKey
+	}
+
+	var medias []*SearchedMedia
+	for _, albumId := range request.Albums {
+		found, err := s.SearchMediasRepository.SearchAlbumMedias(ctx, albumId, request.MediaSearchFilter, query)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to search medias in %s", albumId)
+		}
+
+		for _, media := range found {
+			medias = append(medias, &SearchedMedia{AlbumId: albumId, MediaMeta: media})
+		}
+	}
+
+	return request.page(medias)
+}
+
+func (r MediaSearchRequest) Validate() error {
+	if r.Sort != "" && r.Sort != SearchMostRecentFirst && r.Sort != SearchOldestFirst {
+		return errors.Wrapf(InvalidSearchRequestError, "sort must be %s or %s, got '%s'", SearchMostRecentFirst, SearchOldestFirst, r.Sort)
+	}
+	if r.PageSize < 0 || r.PageSize > MaxSearchPageSize {
+		return errors.Wrapf(InvalidSearchRequestError, "page size must be between 1 and %d, got %d", MaxSearchPageSize, r.PageSize)
+	}
+	if r.Orientation != "" && r.Orientation != SearchLandscape && r.Orientation != SearchPortrait && r.Orientation != SearchSquare {
+		return errors.Wrapf(InvalidSearchRequestError, "orientation must be %s, %s, or %s, got '%s'", SearchLandscape, SearchPortrait, SearchSquare, r.Orientation)
+	}
+	if r.BoundingBox != nil {
+		if err := r.BoundingBox.Validate(); err != nil {
+			return errors.Wrapf(InvalidSearchRequestError, err.Error())
+		}
+	}
+	for _, timeRange := range r.Ranges {
+		if !timeRange.Start.IsZero() && !timeRange.End.IsZero() && !timeRange.Start.Before(timeRange.End) {
+			return errors.Wrapf(InvalidSearchRequestError, "range must end after its start: %s", timeRange)
+		}
+	}
+	if _, err := decodeSearchPageToken(r.PageToken); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Matches returns true when the media of the album satisfies all the criteria.
+func (f MediaSearchFilter) Matches(albumId AlbumId, media *MediaMeta) bool {
+	details := media.Details
+	width, height := details.Width, details.Height
+	if details.Orientation == "UPPER_RIGHT" || details.Orientation == "LOWER_LEFT" {
+		width, height = height, width
+	}
+	duration := time.Duration(details.Duration) * time.Millisecond
+
+	return (len(f.Albums) == 0 || slices.Contains(f.Albums, albumId)) &&
+		(len(f.Types) == 0 || slices.Contains(f.Types, media.Type)) &&
+		f.matchesRanges(details.DateTime) &&
+		(f.Make == "" || strings.EqualFold(f.Make, details.Make)) &&
+		(f.Model == "" || strings.EqualFold(f.Model, details.Model)) &&
+		f.matchesOrientation(width, height) &&
+		(f.MinWidth == 0 || width >= f.MinWidth) &&
+		(f.MaxWidth == 0 || width <= f.MaxWidth) &&
+		(f.MinHeight == 0 || height >= f.MinHeight) &&
+		(f.MaxHeight == 0 || height <= f.MaxHeight) &&
+		(f.MinDuration == 0 || duration >= f.MinDuration) &&
+		(f.MaxDuration == 0 || duration <= f.MaxDuration) &&
+		(f.BoundingBox == nil || (details.GPSLatitude != 0 || details.GPSLongitude != 0) && f.BoundingBox.Contains(details.GPSLatitude, details.GPSLongitude)) &&
+		(f.FilenameContains == "" || strings.Contains(strings.ToLower(media.Filename), strings.ToLower(f.FilenameContains)))
+}
+
+func (f MediaSearchFilter) matchesRanges(dateTime time.Time) bool {
+	if len(f.Ranges) == 0 {
+		return true
+	}
+
+	for _, timeRange := range f.Ranges {
+		if (timeRange.Start.IsZero() || !dateTime.Before(timeRange.Start)) && (timeRange.End.IsZero() || dateTime.Before(timeRange.End)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f MediaSearchFilter) matchesOrientation(width, height int) bool {
+	switch f.Orientation {
+	case SearchLandscape:
+		return width > height
+	case SearchPortrait:
+		return width < height
+	case SearchSquare:
+		return width == height && width > 0
+	default:
+		return true
+	}
+}
+
+func (r MediaSearchRequest) pageSize() int {
+	if r.PageSize == 0 {
+		return DefaultSearchPageSize
+	}
+	return r.PageSize
+}
+
+// page sorts the medias and keeps the ones following the page token.
+func (r MediaSearchRequest) page(medias []*SearchedMedia) (*MediaSearchPage, error) {
+	compare := func(a, b *SearchedMedia) int {
+		return compareSearchKeys(newSearchPageToken(a), newSearchPageToken(b))
+	}
+	if r.Sort == SearchOldestFirst {
+		slices.SortFunc(medias, compare)
+	} else {
+		slices.SortFunc(medias, func(a, b *SearchedMedia) int {
+			return compare(b, a)
+		})
+	}
+
+	after, _ := decodeSearchPageToken(r.PageToken)
+	start := 0
+	if after != nil {
+		start = len(medias)
+		for i, media := range medias {
+			comparison := compareSearchKeys(newSearchPageToken(media), *after)
+			if (r.Sort == SearchOldestFirst && comparison > 0) || (r.Sort != SearchOldestFirst && comparison < 0) {
+				start = i
+				break
+			}
+		}
+	}
+
+	pageSize := r.pageSize()
+	page := &MediaSearchPage{Content: medias[start:min(len(medias), start+pageSize)]}
+	if start+pageSize < len(medias) {
+		page.NextPage = newSearchPageToken(page.Content[len(page.Content)-1]).encode()
+	}
+	return page, nil
+}
+
+// searchPageToken is the sort key of the last media of a page: the next page starts after it.
+// The owner breaks the tie between albums of different owners sharing the same media.
+type searchPageToken struct {
+	SearchKey
+	owner ownermodel.Owner
+}
+
+func newSearchPageToken(media *SearchedMedia) searchPageToken {
+	return searchPageToken{SearchKey: NewSearchKey(media.MediaMeta), owner: media.AlbumId.Owner}
+}
+
+func compareSearchKeys(a, b searchPageToken) int {
+	if comparison := a.SearchKey.Compare(b.SearchKey); comparison != 0 {
+		return comparison
+	}
+	return strings.Compare(a.owner.Value(), b.owner.Value())
+}
+
+func (t searchPageToken) encode() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%s#%s#%s", t.DateTime.Format(time.RFC3339Nano), t.owner, t.Id)))
+}
+
+func decodeSearchPageToken(value string) (*searchPageToken, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	parts := strings.SplitN(string(decoded), "#", 3)
+	if err != nil || len(parts) != 3 {
+		return nil, errors.Wrapf(InvalidSearchRequestError, "page token '%s' is invalid", value)
+	}
+
+	dateTime, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return nil, errors.Wrapf(InvalidSearchRequestError, "page token '%s' is invalid", value)
+	}
+
+	return &searchPageToken{SearchKey: SearchKey{DateTime: dateTime, Id: MediaId(parts[2])}, owner: ownermodel.Owner(parts[1])}, nil
+}
@@ -0,0 +1,255 @@
+package catalog_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/thomasduchatelle/dphoto/pkg/catalog"
+	"testing"
+	"time"
+)
+
+func TestMediaSearch_Search(t *testing.T) {
+	holidays := catalog.AlbumId{Owner: "ironman", FolderName: catalog.NewFolderName("/2023-07_Holidays")}
+	party := catalog.AlbumId{Owner: "ironman", FolderName: catalog.NewFolderName("/2023-12_Party")}
+	shared := catalog.AlbumId{Owner: "pepper", FolderName: catalog.NewFolderName("/2023-07_Beach")}
+	newMedia := func(albumId catalog.AlbumId, id catalog.MediaId, mediaType catalog.MediaType, filename string, dateTime string, details catalog.MediaDetails) catalog.InMemoryMedia {
+		media := catalog.NewInMemoryMedia(id, albumId)
+		media.Type = mediaType
+		media.Filename = filename
+		media.Details = details
+		media.Details.DateTime = mustParseSearchDate(dateTime)
+		return media
+	}
+
+	repository := &catalog.MediaQueriesInMemory{
+		Medias: []catalog.InMemoryMedia{
+			newMedia(holidays, "landscape-canon", "IMAGE", "IMG_0001.jpg", "2023-07-10T10:00:00", catalog.MediaDetails{Width: 6000, Height: 4000, Make: "Canon", Model: "EOS R6", GPSLatitude: 43.6950, GPSLongitude: 7.2650}),
+			newMedia(holidays, "portrait-rotated", "IMAGE", "IMG_0002.jpg", "2023-07-11T10:00:00", catalog.MediaDetails{Width: 6000, Height: 4000, Orientation: "UPPER_RIGHT", Make: "Canon", Model: "EOS R6"}),
+			newMedia(holidays, "short-video", "VIDEO", "MOV_0003.mp4", "2023-07-12T10:00:00", catalog.MediaDetails{Width: 1920, Height: 1080, Duration: 8_000, Make: "Apple", Model: "iPhone 14"}),
+			newMedia(party, "long-video", "VIDEO", "MOV_0004.mp4", "2023-12-31T23:00:00", catalog.MediaDetails{Width: 3840, Height: 2160, Duration: 120_000, Make: "Apple", Model: "iPhone 14"}),
+			newMedia(party, "square", "IMAGE", "Party_Square.jpg", "2023-12-31T23:30:00", catalog.MediaDetails{Width: 1080, Height: 1080, Make: "apple", Model: "iphone 14", GPSLatitude: 48.8584, GPSLongitude: 2.2945}),
+			newMedia(shared, "beach", "IMAGE", "beach.jpg", "2023-07-10T12:00:00", catalog.MediaDetails{Width: 4000, Height: 3000, Make: "Canon", Model: "EOS R6", GPSLatitude: 43.7, GPSLongitude: 7.27}),
+			newMedia(catalog.AlbumId{Owner: "ironman", FolderName: catalog.NewFolderName("/not-visible")}, "not-visible", "IMAGE", "IMG_0001.jpg", "2023-07-10T10:00:00", catalog.MediaDetails{}),
+		},
+	}
+	visible := []catalog.AlbumId{holidays, party, shared}
+
+	tests := []struct {
+		name         string
+		request      catalog.MediaSearchRequest
+		wantIds      []catalog.MediaId
+		wantNextPage bool
+		wantErr      assert.ErrorAssertionFunc
+	}{
+		{
+			name:    "it should return all the medias of the albums, most recent first",
+			request: catalog.MediaSearchRequest{MediaSearchFilter: catalog.MediaSearchFilter{Albums: visible}},
+			wantIds: []catalog.MediaId{"square", "long-video", "short-video", "portrait-rotated", "beach", "landscape-canon"},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "it should sort the oldest first",
+			request: catalog.MediaSearchRequest{MediaSearchFilter: catalog.MediaSearchFilter{Albums: []catalog.AlbumId{holidays}}, Sort: catalog.SearchOldestFirst},
+			wantIds: []catalog.MediaId{"landscape-canon", "portrait-rotated", "short-video"},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "it should filter on the type",
+			request: catalog.MediaSearchRequest{MediaSearchFilter: catalog.MediaSearchFilter{Albums: visible, Types: []catalog.MediaType{"VIDEO"}}},
+			wantIds: []catalog.MediaId{"long-video", "short-video"},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "it should filter on any of the date ranges",
+			request: catalog.MediaSearchRequest{MediaSearchFilter: catalog.MediaSearchFilter{Albums: visible, Ranges: []catalog.TimeRange{
+				{Start: mustParseSearchDate("2023-07-10T00:00:00"), End: mustParseSearchDate("2023-07-11T00:00:00")},
+				{Start: mustParseSearchDate("2023-12-31T23:30:00")},
+			}}},
+			wantIds: []catalog.MediaId{"square", "beach", "landscape-canon"},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "it should filter on the camera, case-insensitive",
+			request: catalog.MediaSearchRequest{MediaSearchFilter: catalog.MediaSearchFilter{Albums: visible, Make: "APPLE", Model: "iPhone 14"}},
+			wantIds: []catalog.MediaId{"square", "long-video", "short-video"},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "it should filter on the orientation once the media is rotated",
+			request: catalog.MediaSearchRequest{MediaSearchFilter: catalog.MediaSearchFilter{Albums: visible, Orientation: catalog.SearchPortrait}},
+			wantIds: []catalog.MediaId{"portrait-rotated"},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "it should filter square medias",
+			request: catalog.MediaSearchRequest{MediaSearchFilter: catalog.MediaSearchFilter{Albums: visible, Orientation: catalog.SearchSquare}},
+			wantIds: []catalog.MediaId{"square"},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "it should filter on the dimensions once the media is rotated",
+			request: catalog.MediaSearchRequest{MediaSearchFilter: catalog.MediaSearchFilter{Albums: visible, MinWidth: 3000, MaxWidth: 4000, MinHeight: 2000}},
+			wantIds: []catalog.MediaId{"long-video", "portrait-rotated", "beach"},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "it should filter on the duration of the videos",
+			request: catalog.MediaSearchRequest{MediaSearchFilter: catalog.MediaSearchFilter{Albums: visible, MinDuration: 10 * time.Second, MaxDuration: 5 * time.Minute}},
+			wantIds: []catalog.MediaId{"long-video"},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "it should filter on the location",
+			request: catalog.MediaSearchRequest{MediaSearchFilter: catalog.MediaSearchFilter{Albums: visible, BoundingBox: &catalog.BoundingBox{West: 7, South: 43, East: 8, North: 44}}},
+			wantIds: []catalog.MediaId{"beach", "landscape-canon"},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "it should filter on a part of the filename, case-insensitive",
+			request: catalog.MediaSearchRequest{MediaSearchFilter: catalog.MediaSearchFilter{Albums: visible, FilenameContains: "party"}},
+			wantIds: []catalog.MediaId{"square"},
+			wantErr: assert.NoError,
+		},
+		{
+			name:         "it should return the first page",
+			request:      catalog.MediaSearchRequest{MediaSearchFilter: catalog.MediaSearchFilter{Albums: visible}, PageSize: 4},
+			wantIds:      []catalog.MediaId{"square", "long-video", "short-video", "portrait-rotated"},
+			wantNextPage: true,
+			wantErr:      assert.NoError,
+		},
+		{
+			name:    "it should not return anything when no album is visible",
+			request: catalog.MediaSearchRequest{},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "it should reject unknown sorts",
+			request: catalog.MediaSearchRequest{MediaSearchFilter: catalog.MediaSearchFilter{Albums: visible}, Sort: "NAME"},
+			wantErr: invalidSearchRequest,
+		},
+		{
+			name:    "it should reject page sizes too big",
+			request: catalog.MediaSearchRequest{MediaSearchFilter: catalog.MediaSearchFilter{Albums: visible}, PageSize: catalog.MaxSearchPageSize + 1},
+			wantErr: invalidSearchRequest,
+		},
+		{
+			name:    "it should reject invalid page tokens",
+			request: catalog.MediaSearchRequest{MediaSearchFilter: catalog.MediaSearchFilter{Albums: visible}, PageToken: "not-a-token"},
+			wantErr: invalidSearchRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			search := &catalog.MediaSearch{SearchMediasRepository: repository}
+
+			got, err := search.Search(context.Background(), tt.request)
+			if !tt.wantErr(t, err) || err != nil {
+				return
+			}
+
+			var gotIds []catalog.MediaId
+			for _, media := range got.Content {
+				gotIds = append(gotIds, media.Id)
+			}
+			assert.Equal(t, tt.wantIds, gotIds)
+			assert.Equal(t, tt.wantNextPage, got.NextPage != "", "NextPage = '%s'", got.NextPage)
+		})
+	}
+}
+
+func TestMediaSearch_Search_pages(t *testing.T) {
+	albumId := catalog.AlbumId{Owner: "ironman", FolderName: catalog.NewFolderName("/2023-07_Holidays")}
+	repository := &catalog.MediaQueriesInMemory{}
+	for _, id := range []catalog.MediaId{"media-1", "media-2", "media-3", "media-4", "media-5"} {
+		media := catalog.NewInMemoryMedia(id, albumId)
+		media.Details.DateTime = mustParseSearchDate("2023-07-10T10:00:00")
+		repository.Medias = append(repository.Medias, media)
+	}
+	search := &catalog.MediaSearch{SearchMediasRepository: repository}
+
+	var gotIds []catalog.MediaId
+	request := catalog.MediaSearchRequest{MediaSearchFilter: catalog.MediaSearchFilter{Albums: []catalog.AlbumId{albumId}}, Sort: catalog.SearchOldestFirst, PageSize: 2}
+	for pages := 0; pages < 5; pages++ {
+		page, err := search.Search(context.Background(), request)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		for _, media := range page.Content {
+			gotIds = append(gotIds, media.Id)
+		}
+		if page.NextPage == "" {
+			break
+		}
+		request.PageToken = page.NextPage
+	}
+
+	assert.Equal(t, []catalog.MediaId{"media-1", "media-2", "media-3", "media-4", "media-5"}, gotIds, "it should go through all the medias, page after page, even when they have the same date")
+}
+
+func TestMediaSearch_Search_pagesAcrossAlbums(t *testing.T) {
+	holidays := catalog.AlbumId{Owner: "ironman", FolderName: catalog.NewFolderName("/2023-07_Holidays")}
+	shared := catalog.AlbumId{Owner: "pepper", FolderName: catalog.NewFolderName("/2023-07_Beach")}
+	repository := &SearchMediasRepositorySpy{MediaQueriesInMemory: new(catalog.MediaQueriesInMemory)}
+	for i, id := range []catalog.MediaId{"media-1", "media-2", "media-3", "media-4"} {
+		for _, albumId := range []catalog.AlbumId{holidays, shared} {
+			media := catalog.NewInMemoryMedia(id, albumId)
+			media.Details.DateTime = mustParseSearchDate("2023-07-10T10:00:00").Add(time.Duration(i) * time.Hour)
+			repository.Medias = append(repository.Medias, media)
+		}
+	}
+	search := &catalog.MediaSearch{SearchMediasRepository: repository}
+
+	var got []string
+	request := catalog.MediaSearchRequest{MediaSearchFilter: catalog.MediaSearchFilter{Albums: []catalog.AlbumId{holidays, shared}}, PageSize: 3}
+	for pages := 0; pages < 5; pages++ {
+		page, err := search.Search(context.Background(), request)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		for _, media := range page.Content {
+			got = append(got, fmt.Sprintf("%s/%s", media.AlbumId.Owner, media.Id))
+		}
+		if page.NextPage == "" {
+			break
+		}
+		request.PageToken = page.NextPage
+	}
+
+	assert.Equal(t, []string{"pepper/media-4", "ironman/media-4", "pepper/media-3", "ironman/media-3", "pepper/media-2", "ironman/media-2", "pepper/media-1", "ironman/media-1"}, got, "it should merge the albums, the media shared by several owners included")
+	for _, query := range repository.Queries {
+		assert.Equal(t, 5, query.Limit, "it should only read the size of the page from each album")
+		assert.True(t, query.MostRecentFirst)
+	}
+	if assert.Len(t, repository.Queries, 6) {
+		assert.Nil(t, repository.Queries[0].From, "it should read the first page from the beginning")
+		assert.Equal(t, &catalog.SearchKey{DateTime: mustParseSearchDate("2023-07-10T12:00:00"), Id: "media-3"}, repository.Queries[2].From, "it should read the next page from the page token")
+	}
+}
+
+func invalidSearchRequest(t assert.TestingT, err error, i ...interface{}) bool {
+	return assert.ErrorIs(t, err, catalog.InvalidSearchRequestError, i...)
+}
+
+func mustParseSearchDate(value string) time.Time {
+	dateTime, err := time.Parse("2006-01-02T15:04:05", value)
+	if err != nil {
+		panic(err)
+	}
+	return dateTime
+}
+
+type SearchMediasRepositorySpy struct {
+	*catalog.MediaQueriesInMemory
+	Queries []catalog.AlbumSearchQuery
+	Filters []catalog.MediaSearchFilter
+}
+
+func (s *SearchMediasRepositorySpy) SearchAlbumMedias(ctx context.Context, albumId catalog.AlbumId, filter catalog.MediaSearchFilter, query catalog.AlbumSearchQuery) ([]*catalog.MediaMeta, error) {
+	s.Queries = append(s.Queries, query)
+	s.Filters = append(s.Filters, filter)
+	return s.MediaQueriesInMemory.SearchAlbumMedias(ctx, albumId, filter, query)
+}
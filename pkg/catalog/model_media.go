@@ -12,6 +12,11 @@ var (
 type MediaType string
 type MediaOrientation string
 
+const (
+	MediaTypeImage MediaType = "IMAGE"
+	MediaTypeVideo MediaType = "VIDEO"
+)
+
 type MediaSignature struct {
 	SignatureSha256 string
 	SignatureSize   int
@@ -47,13 +52,20 @@ type MediaMeta struct {
 // MediaDetails are extracted from the metadata within photos and videos and stored as it.
 type MediaDetails struct {
 	Width, Height             int
-	DateTime                  time.Time
+	DateTime                  time.Time // DateTime is the wall-clock of the camera when the media has been captured, with a UTC location ; it is used to find its album
+	DateTimeUTC               time.Time // DateTimeUTC is the instant the media has been captured, it is zero on medias backed up before the time zones were known
+	TimeZone                  string    // TimeZone is the name of the zone in which the media has been captured (ex: 'Europe/Paris'), or its offset (ex: '+02:00')
 	Orientation               MediaOrientation
 	Make                      string
 	Model                     string
 	GPSLatitude, GPSLongitude float64
-	Duration                  int64  // Duration is the length, in milliseconds, of a video
-	VideoEncoding             string // VideoEncoding is the codec used to encode the video (ex: 'H264')
+	GPSInferred               bool         // GPSInferred is true when the coordinates have been deduced from the capture time (ex: from a GPX track)
+	Country, Region, City     string       // Country, Region, and City are the names of the place nearest to the GPS coordinates
+	Duration                  int64        // Duration is the length, in milliseconds, of a video
+	VideoEncoding             string       // VideoEncoding is the codec used to encode the video (ex: 'H264')
+	Rating                    int          // Rating is from 1 to 5 stars, -1 when the media has been rejected, 0 when not rated
+	Keywords                  []string     // Keywords are tags given by the user in a photo editor
+	GroupId                   MediaGroupId // GroupId links the medias captured together (Live Photo, burst), it's empty for most medias
 }
 
 // MediaPage is the current page MediaMeta, and the token of the next page
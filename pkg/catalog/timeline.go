@@ -134,7 +134,9 @@ func electNewLeader(cursor *builderCursor, atTheTime time.Time, timeline *Timeli
 	}
 }
 
+// FindAllAt returns the albums containing the date ; only the wall-clock of the date is considered, whatever its location.
 func (t *Timeline) FindAllAt(date time.Time) []*Album {
+	date = wallClock(date)
 	index := sort.Search(len(t.segments), func(i int) bool {
 		return t.segments[i].to.After(date)
 	})
@@ -162,6 +164,11 @@ func (t *Timeline) FindAt(date time.Time) (*Album, bool) {
 	return nil, false
 }
 
+// wallClock keeps the date and time as they are displayed in the location of the date, and labels them UTC to be comparable with the dates of the albums.
+func wallClock(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), date.Hour(), date.Minute(), date.Second(), date.Nanosecond(), time.UTC)
+}
+
 func (t *Timeline) FindForAlbum(albumId AlbumId) (segments []PrioritySegment) {
 	for _, seg := range t.segments {
 		if seg.albums[0].AlbumId.IsEqual(albumId) {
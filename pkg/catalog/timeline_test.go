@@ -263,6 +263,16 @@ func TestFindAt_FindAllAt(t *testing.T) {
 			time.Date(2021, 1, 1, 10, 0, 0, 0, time.UTC),
 			Want{"/New_Year", []string{"/2021-Q1", "/Christmas_Holidays", "/New_Year"}},
 		},
+		{
+			"it should use the wall-clock of the date, not the instant (8am in Sydney is 10pm the day before in UTC)",
+			time.Date(2020, 10, 1, 8, 0, 0, 0, time.FixedZone("Australia/Sydney", 10*3600)),
+			Want{"/2020-Q4", []string{"/2020-Q4"}},
+		},
+		{
+			"it should use the wall-clock of the date, not the instant (5pm in New-York is 10pm in UTC)",
+			time.Date(2020, 12, 31, 17, 0, 0, 0, time.FixedZone("America/New_York", -5*3600)),
+			Want{"/Christmas_Holidays", []string{"/2020-Q4", "/Christmas_Holidays"}},
+		},
 	}
 
 	timeline, err := NewTimeline(AlbumCollection())
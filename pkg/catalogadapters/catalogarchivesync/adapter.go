@@ -4,6 +4,7 @@ package catalogarchivesync
 import (
 	"context"
 	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
 	"github.com/thomasduchatelle/dphoto/pkg/archive"
 	"github.com/thomasduchatelle/dphoto/pkg/catalog"
 )
@@ -19,6 +20,10 @@ func (a *ArchiveSyncRelocator) OnTransferredMedias(ctx context.Context, transfer
 		}
 
 		err := archive.Relocate(targetAlbumId.Owner.String(), convertedIds, targetAlbumId.FolderName.String())
+		if errors.Is(err, archive.RestoreInProgressError) {
+			log.WithError(err).Warnf("Originals in cold storage are being restored, run 'dphoto ops consistency --repair' later to relocate them to %s", targetAlbumId)
+			continue
+		}
 		if err != nil {
 			return errors.Wrapf(err, "failed to relocate images to %s", targetAlbumId)
 		}
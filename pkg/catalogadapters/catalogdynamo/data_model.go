@@ -40,6 +40,7 @@ type MediaRecord struct {
 	Id            string                 // Id is the unique identifier of the media
 	Type          string                 // Type is either PHOTO or VIDEO
 	DateTime      time.Time              // DateTime time used in AlbumIndexKey
+	DateTimeUTC   time.Time              `dynamodbav:",omitempty"` // DateTimeUTC is the instant the media has been captured, it has been added to the data structure with the time zones
 	Details       map[string]interface{} // Details are other attributes from domain model, stored as it
 	Filename      string                 // Filename is the original filename for display purpose only ; physical filename is in MediaLocationData
 	SignatureSize int
@@ -139,6 +140,7 @@ func marshalMedia(owner ownermodel.Owner, media *catalog.CreateMediaRequest) (ma
 		Id:            string(media.Id),
 		Type:          string(media.Type),
 		DateTime:      media.Details.DateTime,
+		DateTimeUTC:   media.Details.DateTimeUTC,
 		Details:       details,
 		Filename:      media.Filename,
 		SignatureSize: media.Signature.SignatureSize,
@@ -160,6 +162,7 @@ func unmarshalMediaMetaData(attributes map[string]types.AttributeValue) (*catalo
 	}
 
 	details.DateTime = data.DateTime // note: mapstructure do not support times
+	details.DateTimeUTC = data.DateTimeUTC
 	media := catalog.MediaMeta{
 		Id: catalog.MediaId(data.Id),
 		Signature: catalog.MediaSignature{
@@ -174,14 +177,6 @@ func unmarshalMediaMetaData(attributes map[string]types.AttributeValue) (*catalo
 	return &media, nil
 }
 
-func readMediaId(record map[string]types.AttributeValue) catalog.MediaId {
-	if id, ok := record["Id"].(*types.AttributeValueMemberS); ok && id.Value != "" {
-		return catalog.MediaId(id.Value)
-	}
-
-	return ""
-}
-
 // isBlank returns true is value is empty, or contains only spaces
 func isBlank(value string) bool {
 	return value == "" || strings.Trim(value, " ") == ""
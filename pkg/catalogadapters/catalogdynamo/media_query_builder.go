@@ -8,23 +8,24 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/thomasduchatelle/dphoto/pkg/catalog"
 	"github.com/thomasduchatelle/dphoto/pkg/ownermodel"
+	"time"
 )
 
 const (
 	albumIndex = "AlbumIndex"
 )
 
-func newMediaQueryBuilders(table string, request *catalog.FindMediaRequest, projectionName string, querySelect types.Select) ([]*dynamodb.QueryInput, error) {
+func newMediaQueryBuilders(table string, request *catalog.FindMediaRequest, projectionNames []string, querySelect types.Select) ([]*dynamodb.QueryInput, error) {
 	var queries []*dynamodb.QueryInput
 
-	if projectionName != "" {
+	if len(projectionNames) > 0 {
 		querySelect = types.SelectSpecificAttributes
 	}
 
 	builderWithProjection := func() expression.Builder {
 		builder := expression.NewBuilder()
-		if projectionName != "" {
-			return builder.WithProjection(expression.NamesList(expression.Name(projectionName)))
+		if len(projectionNames) > 0 {
+			return builder.WithProjection(namesList(projectionNames))
 		}
 
 		return builder
@@ -73,6 +74,15 @@ func newMediaQueryBuilders(table string, request *catalog.FindMediaRequest, proj
 	return queries, nil
 }
 
+// namesList creates the projection of the attributes, projectionNames must not be empty
+func namesList(projectionNames []string) expression.ProjectionBuilder {
+	names := make([]expression.NameBuilder, len(projectionNames))
+	for i, name := range projectionNames {
+		names[i] = expression.Name(name)
+	}
+	return expression.NamesList(names[0], names[1:]...)
+}
+
 func withinAlbum(owner ownermodel.Owner, folderName catalog.FolderName) expression.KeyConditionBuilder {
 	return expression.Key("AlbumIndexPK").Equal(expression.Value(AlbumIndexedKey(owner, folderName).AlbumIndexPK))
 }
@@ -83,7 +93,12 @@ func withExcludingMetaRecord() expression.KeyConditionBuilder {
 
 func withinRange(timeRange catalog.TimeRange) expression.KeyConditionBuilder {
 	return expression.Key("AlbumIndexSK").Between(
-		expression.Value(fmt.Sprintf("MEDIA#%s#", timeRange.Start.Format(IsoTime))),
-		expression.Value(fmt.Sprintf("MEDIA#%s#", timeRange.End.Format(IsoTime))), // exclusive
+		expression.Value(mediaSortKeyPrefix(timeRange.Start)),
+		expression.Value(mediaSortKeyPrefix(timeRange.End)), // exclusive
 	)
 }
+
+// mediaSortKeyPrefix is before the sort key of any media captured at this time (to the second).
+func mediaSortKeyPrefix(dateTime time.Time) string {
+	return fmt.Sprintf("MEDIA#%s#", dateTime.Format(IsoTime))
+}
@@ -138,7 +138,7 @@ func (r *Repository) CountMediasBySelectors(ctx context.Context, owner ownermode
 	}
 
 	request := r.convertSelectorsIntoMediaRequest(owner, selectors)
-	queries, err := newMediaQueryBuilders(r.table, request, "", types.SelectCount)
+	queries, err := newMediaQueryBuilders(r.table, request, nil, types.SelectCount)
 	if err != nil {
 		return 0, err
 	}
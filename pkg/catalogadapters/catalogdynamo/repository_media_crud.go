@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/pkg/errors"
@@ -33,7 +34,7 @@ func (r *Repository) InsertMedias(ctx context.Context, owner ownermodel.Owner, m
 }
 
 func (r *Repository) FindMedias(ctx context.Context, request *catalog.FindMediaRequest) ([]*catalog.MediaMeta, error) {
-	queries, err := newMediaQueryBuilders(r.table, request, "", types.SelectAllAttributes)
+	queries, err := newMediaQueryBuilders(r.table, request, nil, types.SelectAllAttributes)
 	if err != nil {
 		return nil, err
 	}
@@ -130,3 +131,109 @@ func (r *Repository) FindSignatures(ctx context.Context, owner ownermodel.Owner,
 
 	return found, stream.Error()
 }
+
+// UpdateMediaLocations sets the coordinates within the details of each media, and flags them as inferred.
+func (r *Repository) UpdateMediaLocations(ctx context.Context, owner ownermodel.Owner, locations []catalog.MediaLocation) error {
+	for _, location := range locations {
+		update, err := expression.NewBuilder().
+			WithUpdate(expression.
+				Set(expression.Name("Details.GPSLatitude"), expression.Value(location.GPSLatitude)).
+				Set(expression.Name("Details.GPSLongitude"), expression.Value(location.GPSLongitude)).
+				Set(expression.Name("Details.GPSInferred"), expression.Value(true))).
+			WithCondition(expression.AttributeExists(expression.Name("PK"))).
+			Build()
+		if err != nil {
+			return errors.Wrapf(err, "failed to build update location expression for media %s/%s", owner, location.Id)
+		}
+
+		key, err := attributevalue.MarshalMap(MediaPrimaryKey(owner, location.Id))
+		if err != nil {
+			return errors.Wrapf(err, "failed to marshal media key %s/%s", owner, location.Id)
+		}
+
+		_, err = r.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+			Key:                       key,
+			TableName:                 &r.table,
+			ConditionExpression:       update.Condition(),
+			ExpressionAttributeNames:  update.Names(),
+			ExpressionAttributeValues: update.Values(),
+			UpdateExpression:          update.Update(),
+		})
+		var conditionalCheckFailedException *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionalCheckFailedException) {
+			return errors.Wrapf(catalog.MediaNotFoundError, "media %s/%s", owner, location.Id)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to update location of media %s/%s", owner, location.Id)
+		}
+	}
+
+	return nil
+}
+
+func (r *Repository) FindMediasByIds(ctx context.Context, owner ownermodel.Owner, ids []catalog.MediaId) ([]*catalog.MediaMeta, error) {
+	var keys []map[string]types.AttributeValue
+	uniqueIds := make(map[catalog.MediaId]interface{})
+	for _, id := range ids {
+		if _, found := uniqueIds[id]; !found {
+			key, err := attributevalue.MarshalMap(MediaPrimaryKey(owner, id))
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to marshal media key %s/%s", owner, id)
+			}
+
+			keys = append(keys, key)
+		}
+		uniqueIds[id] = nil
+	}
+
+	stream := dynamoutils.NewGetStream(ctx, dynamoutils.NewGetBatchItem(r.client, r.table, ""), keys, dynamoutils.DynamoReadBatchSize)
+	var medias []*catalog.MediaMeta
+	for stream.HasNext() {
+		media, err := unmarshalMediaMetaData(stream.Next())
+		if err != nil {
+			return nil, err
+		}
+
+		medias = append(medias, media)
+	}
+
+	return medias, stream.Error()
+}
+
+func (r *Repository) UpdateMediaPlaces(ctx context.Context, owner ownermodel.Owner, places map[catalog.MediaId]catalog.MediaPlace) error {
+	for id, place := range places {
+		update, err := expression.NewBuilder().
+			WithUpdate(expression.
+				Set(expression.Name("Details.Country"), expression.Value(place.Country)).
+				Set(expression.Name("Details.Region"), expression.Value(place.Region)).
+				Set(expression.Name("Details.City"), expression.Value(place.City))).
+			WithCondition(expression.AttributeExists(expression.Name("PK"))).
+			Build()
+		if err != nil {
+			return errors.Wrapf(err, "failed to build update place expression for media %s/%s", owner, id)
+		}
+
+		key, err := attributevalue.MarshalMap(MediaPrimaryKey(owner, id))
+		if err != nil {
+			return errors.Wrapf(err, "failed to marshal media key %s/%s", owner, id)
+		}
+
+		_, err = r.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+			Key:                       key,
+			TableName:                 &r.table,
+			ConditionExpression:       update.Condition(),
+			ExpressionAttributeNames:  update.Names(),
+			ExpressionAttributeValues: update.Values(),
+			UpdateExpression:          update.Update(),
+		})
+		var conditionalCheckFailedException *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionalCheckFailedException) {
+			return errors.Wrapf(catalog.MediaNotFoundError, "media %s/%s", owner, id)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to update place of media %s/%s", owner, id)
+		}
+	}
+
+	return nil
+}
@@ -356,6 +356,158 @@ func (a *MediaCrudTestSuite) TestFindMediaCurrentAlbum() {
 	}
 }
 
+func (a *MediaCrudTestSuite) TestUpdateMediaLocations() {
+	hike := catalog.NewFolderName("/media/2021-hike")
+	signature := catalog.MediaSignature{
+		SignatureSha256: "2c2a1de7a22f3a1b0f3bbd5e9f0e4bb1ac55b0ee1b1e5e5ab3c4d2f9e0a7b6c5",
+		SignatureSize:   4096,
+	}
+	dsc001 := catalog.CreateMediaRequest{
+		Id:         mustGenerateMediaId(catalog.GenerateMediaId(signature)),
+		Signature:  signature,
+		FolderName: hike,
+		Filename:   "dsc001.jpeg",
+		Type:       "Image",
+		Details: catalog.MediaDetails{
+			DateTime: time.Date(2021, 3, 14, 10, 0, 0, 0, time.UTC),
+			Make:     "Nikon",
+		},
+	}
+	err := a.repo.InsertMedias(context.TODO(), a.owner, []catalog.CreateMediaRequest{dsc001})
+	if !a.NoError(err) {
+		return
+	}
+
+	name := "it should set the coordinates of the media and flag them as inferred"
+	err = a.repo.UpdateMediaLocations(context.TODO(), a.owner, []catalog.MediaLocation{{Id: dsc001.Id, GPSLatitude: 45.9, GPSLongitude: 6.8}})
+	if a.NoError(err, name) {
+		medias, err := a.repo.FindMedias(context.TODO(), catalog.NewFindMediaRequest(a.owner).WithAlbum(hike))
+		if a.NoError(err, name) && a.Len(medias, 1, name) {
+			a.Equal(catalog.MediaDetails{
+				DateTime:     time.Date(2021, 3, 14, 10, 0, 0, 0, time.UTC),
+				Make:         "Nikon",
+				GPSLatitude:  45.9,
+				GPSLongitude: 6.8,
+				GPSInferred:  true,
+			}, medias[0].Details, name)
+		}
+	}
+
+	err = a.repo.UpdateMediaLocations(context.TODO(), a.owner, []catalog.MediaLocation{{Id: "not-existing", GPSLatitude: 45.9, GPSLongitude: 6.8}})
+	a.ErrorIs(err, catalog.MediaNotFoundError, "it should not create medias that do not exist")
+}
+
+func (a *MediaCrudTestSuite) TestUpdateMediaPlaces() {
+	trip := catalog.NewFolderName("/media/2022-paris")
+	signature := catalog.MediaSignature{
+		SignatureSha256: "7f3b9c1e5d2a8f4b6c0e9d1a3b5c7e9f1a2b4c6d8e0f1a3b5c7d9e1f3a5b7c9d",
+		SignatureSize:   2048,
+	}
+	dsc001 := catalog.CreateMediaRequest{
+		Id:         mustGenerateMediaId(catalog.GenerateMediaId(signature)),
+		Signature:  signature,
+		FolderName: trip,
+		Filename:   "dsc001.jpeg",
+		Type:       "Image",
+		Details: catalog.MediaDetails{
+			DateTime:     time.Date(2022, 5, 1, 10, 0, 0, 0, time.UTC),
+			GPSLatitude:  48.8584,
+			GPSLongitude: 2.2945,
+		},
+	}
+	err := a.repo.InsertMedias(context.TODO(), a.owner, []catalog.CreateMediaRequest{dsc001})
+	if !a.NoError(err) {
+		return
+	}
+
+	name := "it should find the medias by their ids, ignoring the ones that do not exist"
+	medias, err := a.repo.FindMediasByIds(context.TODO(), a.owner, []catalog.MediaId{dsc001.Id, dsc001.Id, "not-existing"})
+	if a.NoError(err, name) && a.Len(medias, 1, name) {
+		a.Equal(dsc001.Id, medias[0].Id, name)
+	}
+
+	name = "it should set the place of the media"
+	err = a.repo.UpdateMediaPlaces(context.TODO(), a.owner, map[catalog.MediaId]catalog.MediaPlace{
+		dsc001.Id: {Country: "France", Region: "Île-de-France", City: "Paris"},
+	})
+	if a.NoError(err, name) {
+		medias, err = a.repo.FindMediasByIds(context.TODO(), a.owner, []catalog.MediaId{dsc001.Id})
+		if a.NoError(err, name) && a.Len(medias, 1, name) {
+			a.Equal(catalog.MediaDetails{
+				DateTime:     time.Date(2022, 5, 1, 10, 0, 0, 0, time.UTC),
+				GPSLatitude:  48.8584,
+				GPSLongitude: 2.2945,
+				Country:      "France",
+				Region:       "Île-de-France",
+				City:         "Paris",
+			}, medias[0].Details, name)
+		}
+	}
+
+	err = a.repo.UpdateMediaPlaces(context.TODO(), a.owner, map[catalog.MediaId]catalog.MediaPlace{"not-existing": {City: "Paris"}})
+	a.ErrorIs(err, catalog.MediaNotFoundError, "it should not create medias that do not exist")
+}
+
+func (a *MediaCrudTestSuite) TestSearchAlbumMedias() {
+	jan21 := catalog.AlbumId{Owner: a.owner, FolderName: a.jan21}
+	feb21 := catalog.AlbumId{Owner: a.owner, FolderName: a.feb21}
+
+	tests := []struct {
+		name   string
+		filter catalog.MediaSearchFilter
+		want   []string
+	}{
+		{"it should find all the medias of the albums", catalog.MediaSearchFilter{Albums: []catalog.AlbumId{jan21, feb21}}, []string{"/media/2021-jan/img001.jpeg", "/media/2021-jan/img003.jpeg", "/media/2021-feb/img002.jpeg"}},
+		{"it should find the medias within the ranges, once", catalog.MediaSearchFilter{Albums: []catalog.AlbumId{jan21, feb21}, Ranges: []catalog.TimeRange{newDateRange("2021-01-10", "2021-02-21"), {Start: mustParseDate("2021-02-01")}}}, []string{"/media/2021-jan/img003.jpeg", "/media/2021-feb/img002.jpeg"}},
+		{"it should find the medias within the bounding box", catalog.MediaSearchFilter{Albums: []catalog.AlbumId{jan21, feb21}, BoundingBox: &catalog.BoundingBox{West: 0, South: 0, East: 1, North: 1}}, []string{"/media/2021-jan/img001.jpeg"}},
+		{"it should find the medias from the camera, case-insensitive", catalog.MediaSearchFilter{Albums: []catalog.AlbumId{jan21, feb21}, Make: "google"}, []string{"/media/2021-jan/img001.jpeg"}},
+		{"it should not find medias of other types", catalog.MediaSearchFilter{Albums: []catalog.AlbumId{jan21, feb21}, Types: []catalog.MediaType{"VIDEO"}}, nil},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		for _, albumId := range tt.filter.Albums {
+			medias, err := a.repo.SearchAlbumMedias(context.TODO(), albumId, tt.filter, catalog.AlbumSearchQuery{})
+			if a.NoError(err, tt.name) {
+				got = append(got, extractFilenames(albumId.FolderName, medias)...)
+			}
+		}
+		a.ElementsMatch(tt.want, got, tt.name)
+	}
+}
+
+func (a *MediaCrudTestSuite) TestSearchAlbumMedias_pages() {
+	jan21 := catalog.AlbumId{Owner: a.owner, FolderName: a.jan21}
+
+	tests := []struct {
+		name   string
+		filter catalog.MediaSearchFilter
+		query  catalog.AlbumSearchQuery
+		want   []string
+	}{
+		{"it should return the first medias, oldest first", catalog.MediaSearchFilter{}, catalog.AlbumSearchQuery{Limit: 1}, []string{"/media/2021-jan/img001.jpeg"}},
+		{"it should return the first medias, most recent first", catalog.MediaSearchFilter{}, catalog.AlbumSearchQuery{MostRecentFirst: true, Limit: 1}, []string{"/media/2021-jan/img003.jpeg"}},
+		{"it should start from the position requested", catalog.MediaSearchFilter{}, catalog.AlbumSearchQuery{From: &catalog.SearchKey{DateTime: mustParseDate("2021-01-10")}}, []string{"/media/2021-jan/img003.jpeg"}},
+		{"it should start from the position requested within the ranges", catalog.MediaSearchFilter{Ranges: []catalog.TimeRange{newDateRange("2021-01-01", "2021-01-31")}}, catalog.AlbumSearchQuery{MostRecentFirst: true, From: &catalog.SearchKey{DateTime: mustParseDate("2021-01-10")}}, []string{"/media/2021-jan/img001.jpeg"}},
+	}
+
+	for _, tt := range tests {
+		tt.filter.Albums = []catalog.AlbumId{jan21}
+		medias, err := a.repo.SearchAlbumMedias(context.TODO(), jan21, tt.filter, tt.query)
+		if a.NoError(err, tt.name) {
+			a.Equal(tt.want, extractFilenames(jan21.FolderName, medias), tt.name)
+		}
+	}
+
+	medias, err := a.repo.SearchAlbumMedias(context.TODO(), jan21, catalog.MediaSearchFilter{BoundingBox: &catalog.BoundingBox{West: 0, South: 0, East: 1, North: 1}}, catalog.AlbumSearchQuery{LocationOnly: true})
+	if a.NoError(err) && a.Len(medias, 1) {
+		a.Equal(a.medias[0].Id, medias[0].Id)
+		a.Equal(a.medias[0].Details.DateTime, medias[0].Details.DateTime)
+		a.Equal(0.123, medias[0].Details.GPSLatitude)
+		a.Empty(medias[0].Filename, "it should only read the location of the medias")
+	}
+}
+
 func extractFilenames(albumFolderName catalog.FolderName, medias []*catalog.MediaMeta) []string {
 	filenames := make([]string, 0, len(medias))
 	for _, m := range medias {
@@ -0,0 +1,163 @@
+package catalogdynamo
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/pkg/errors"
+	"github.com/thomasduchatelle/dphoto/pkg/awssupport/dynamoutils"
+	"github.com/thomasduchatelle/dphoto/pkg/catalog"
+	"slices"
+	"time"
+)
+
+var (
+	// locationProjection are the attributes read to locate the medias on a map
+	locationProjection = []string{"Id", "DateTime", "Details.GPSLatitude", "Details.GPSLongitude"}
+)
+
+// SearchAlbumMedias queries the album index for each date range, from the first position requested ; criteria that can't be expressed exactly with DynamoDB
+// (case-insensitive, rotation, ...) are applied on the results. Each range is read until the limit is reached, the ranges are then merged.
+func (r *Repository) SearchAlbumMedias(ctx context.Context, albumId catalog.AlbumId, filter catalog.MediaSearchFilter, query catalog.AlbumSearchQuery) ([]*catalog.MediaMeta, error) {
+	queries, err := newMediaSearchQueries(r.table, albumId, filter, query)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to build search queries for album %s", albumId)
+	}
+
+	var medias []*catalog.MediaMeta
+	found := make(map[catalog.MediaId]interface{})
+	for _, rangeQuery := range queries {
+		matching := 0
+		crawler := dynamoutils.NewQueryStream(ctx, r.client, []*dynamodb.QueryInput{rangeQuery})
+		for crawler.HasNext() && (query.Limit == 0 || matching < query.Limit) {
+			media, err := unmarshalMediaMetaData(crawler.Next())
+			if err != nil {
+				return nil, err
+			}
+
+			if _, duplicated := found[media.Id]; !duplicated && filter.Matches(albumId, media) {
+				found[media.Id] = nil
+				medias = append(medias, media)
+				matching++
+			}
+		}
+		if crawler.Error() != nil {
+			return nil, errors.Wrapf(crawler.Error(), "failed to search medias in album %s", albumId)
+		}
+	}
+
+	if len(queries) > 1 {
+		slices.SortFunc(medias, func(a, b *catalog.MediaMeta) int {
+			if query.MostRecentFirst {
+				return catalog.NewSearchKey(b).Compare(catalog.NewSearchKey(a))
+			}
+			return catalog.NewSearchKey(a).Compare(catalog.NewSearchKey(b))
+		})
+	}
+	if query.Limit > 0 && len(medias) > query.Limit {
+		medias = medias[:query.Limit]
+	}
+	return medias, nil
+}
+
+func newMediaSearchQueries(table string, albumId catalog.AlbumId, filter catalog.MediaSearchFilter, query catalog.AlbumSearchQuery) ([]*dynamodb.QueryInput, error) {
+	bounds := [][2]string{{"$", "~"}} // all the medias are between these characters, the album record is not
+	if len(filter.Ranges) > 0 {
+		bounds = nil
+		for _, timeRange := range filter.Ranges {
+			if timeRange.End.IsZero() {
+				timeRange.End = time.Date(2200, 1, 1, 0, 0, 0, 0, time.UTC)
+			}
+			bounds = append(bounds, [2]string{mediaSortKeyPrefix(timeRange.Start), mediaSortKeyPrefix(timeRange.End)}) // end is exclusive
+		}
+	}
+
+	condition, withCondition := newMediaSearchCondition(filter)
+
+	var queries []*dynamodb.QueryInput
+	for _, bound := range bounds {
+		if query.From != nil {
+			from := MediaAlbumIndexedKey(albumId.Owner, albumId.FolderName, query.From.DateTime, query.From.Id).AlbumIndexSK
+			if query.MostRecentFirst {
+				bound[1] = min(bound[1], from)
+			} else {
+				bound[0] = max(bound[0], from)
+			}
+			if bound[0] > bound[1] {
+				continue
+			}
+		}
+
+		keyCondition := expression.KeyAnd(withinAlbum(albumId.Owner, albumId.FolderName), expression.Key("AlbumIndexSK").Between(expression.Value(bound[0]), expression.Value(bound[1])))
+		builder := expression.NewBuilder().WithKeyCondition(keyCondition)
+		if withCondition {
+			builder = builder.WithFilter(condition)
+		}
+		if query.LocationOnly {
+			builder = builder.WithProjection(namesList(locationProjection))
+		}
+
+		expr, err := builder.Build()
+		if err != nil {
+			return nil, err
+		}
+
+		input := &dynamodb.QueryInput{
+			TableName:                 &table,
+			ExpressionAttributeNames:  expr.Names(),
+			ExpressionAttributeValues: expr.Values(),
+			IndexName:                 aws.String(albumIndex),
+			KeyConditionExpression:    expr.KeyCondition(),
+			FilterExpression:          expr.Filter(),
+			ProjectionExpression:      expr.Projection(),
+			ScanIndexForward:          aws.Bool(!query.MostRecentFirst),
+		}
+		if query.Limit > 0 {
+			input.Limit = aws.Int32(int32(query.Limit))
+		}
+		queries = append(queries, input)
+	}
+
+	return queries, nil
+}
+
+// newMediaSearchCondition only filters on the criteria that are stored as they are compared ; the second value is false when there is no condition.
+func newMediaSearchCondition(filter catalog.MediaSearchFilter) (expression.ConditionBuilder, bool) {
+	var conditions []expression.ConditionBuilder
+
+	if len(filter.Types) > 0 {
+		types := make([]expression.OperandBuilder, len(filter.Types))
+		for i, mediaType := range filter.Types {
+			types[i] = expression.Value(string(mediaType))
+		}
+		conditions = append(conditions, expression.Name("Type").In(types[0], types[1:]...))
+	}
+
+	duration := expression.Name("Details.Duration")
+	if filter.MinDuration > 0 {
+		conditions = append(conditions, duration.GreaterThanEqual(expression.Value(filter.MinDuration.Milliseconds())))
+	}
+	if filter.MaxDuration > 0 {
+		conditions = append(conditions, expression.Or(duration.AttributeNotExists(), duration.LessThanEqual(expression.Value(filter.MaxDuration.Milliseconds()))))
+	}
+
+	if box := filter.BoundingBox; box != nil {
+		latitude, longitude := expression.Name("Details.GPSLatitude"), expression.Name("Details.GPSLongitude")
+		conditions = append(conditions, latitude.Between(expression.Value(box.South), expression.Value(box.North)))
+		if box.West <= box.East {
+			conditions = append(conditions, longitude.Between(expression.Value(box.West), expression.Value(box.East)))
+		} else {
+			conditions = append(conditions, expression.Or(longitude.GreaterThanEqual(expression.Value(box.West)), longitude.LessThanEqual(expression.Value(box.East))))
+		}
+	}
+
+	switch len(conditions) {
+	case 0:
+		return expression.ConditionBuilder{}, false
+	case 1:
+		return conditions[0], true
+	default:
+		return expression.And(conditions[0], conditions[1], conditions[2:]...), true
+	}
+}
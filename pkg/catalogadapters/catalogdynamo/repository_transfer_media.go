@@ -2,24 +2,51 @@ package catalogdynamo
 
 import (
 	"context"
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/pkg/errors"
 	"github.com/thomasduchatelle/dphoto/pkg/awssupport/dynamoutils"
 	"github.com/thomasduchatelle/dphoto/pkg/catalog"
 	"github.com/thomasduchatelle/dphoto/pkg/ownermodel"
+	"slices"
 )
 
+// transferProjection are the attributes needed to find the primary media of each group
+var transferProjection = []string{"Id", "Type", "DateTime", "Details.GroupId"}
+
+// maxGroupIdsPerQuery is the maximum number of operands of a DynamoDB 'IN' condition
+const maxGroupIdsPerQuery = 100
+
 func (r *Repository) TransferMediasFromRecords(ctx context.Context, records catalog.MediaTransferRecords) (catalog.TransferredMedias, error) {
 	medias := catalog.NewTransferredMedias()
 
+	selected := make(map[catalog.AlbumId][]*catalog.MediaMeta)
+	var origins []catalog.AlbumId
 	for albumId, selectors := range records {
-		mediaIds, err := r.findMediaIdsFromSelectors(ctx, albumId, selectors)
+		found, err := r.findMediasFromSelectors(ctx, albumId, selectors)
 		if err != nil {
 			return medias, err
 		}
+		selected[albumId] = found
 
+		for _, selector := range selectors {
+			for _, origin := range selector.FromAlbums {
+				if !slices.Contains(origins, origin) {
+					origins = append(origins, origin)
+				}
+			}
+		}
+	}
+
+	groupMembers, err := r.findGroupMembers(ctx, origins, selected)
+	if err != nil {
+		return medias, err
+	}
+
+	for albumId, mediaIds := range catalog.KeepMediaGroupsTogether(selected, groupMembers) {
 		if len(mediaIds) > 0 {
 			err = r.transferMedias(ctx, albumId, mediaIds)
 			if err != nil {
@@ -33,25 +60,75 @@ func (r *Repository) TransferMediasFromRecords(ctx context.Context, records cata
 	return medias, nil
 }
 
-func (r *Repository) findMediaIdsFromSelectors(ctx context.Context, targetAlbumId catalog.AlbumId, selectors []catalog.MediaSelector) ([]catalog.MediaId, error) {
+func (r *Repository) findMediasFromSelectors(ctx context.Context, targetAlbumId catalog.AlbumId, selectors []catalog.MediaSelector) ([]*catalog.MediaMeta, error) {
 	request := r.convertSelectorsIntoMediaRequest(targetAlbumId.Owner, selectors)
 
-	queries, err := newMediaQueryBuilders(r.table, request, "Id", types.SelectAllAttributes)
+	queries, err := newMediaQueryBuilders(r.table, request, transferProjection, types.SelectAllAttributes)
 	if err != nil {
 		return nil, err
 	}
 
-	var mediaIds []catalog.MediaId
+	return r.queryMedias(ctx, queries)
+}
+
+// findGroupMembers returns the medias, from the origin albums, that are in the same groups than the selected medias.
+func (r *Repository) findGroupMembers(ctx context.Context, origins []catalog.AlbumId, selected map[catalog.AlbumId][]*catalog.MediaMeta) ([]*catalog.MediaMeta, error) {
+	var groupIds []expression.OperandBuilder
+	found := make(map[catalog.MediaGroupId]interface{})
+	for _, medias := range selected {
+		for _, media := range medias {
+			if _, duplicated := found[media.Details.GroupId]; media.Details.GroupId != "" && !duplicated {
+				found[media.Details.GroupId] = nil
+				groupIds = append(groupIds, expression.Value(media.Details.GroupId.String()))
+			}
+		}
+	}
+
+	var queries []*dynamodb.QueryInput
+	for _, origin := range origins {
+		for start := 0; start < len(groupIds); start += maxGroupIdsPerQuery {
+			chunk := groupIds[start:min(len(groupIds), start+maxGroupIdsPerQuery)]
+			expr, err := expression.NewBuilder().
+				WithKeyCondition(expression.KeyAnd(withinAlbum(origin.Owner, origin.FolderName), withExcludingMetaRecord())).
+				WithFilter(expression.Name("Details.GroupId").In(chunk[0], chunk[1:]...)).
+				WithProjection(namesList(transferProjection)).
+				Build()
+			if err != nil {
+				return nil, err
+			}
+
+			queries = append(queries, &dynamodb.QueryInput{
+				TableName:                 &r.table,
+				ExpressionAttributeNames:  expr.Names(),
+				ExpressionAttributeValues: expr.Values(),
+				IndexName:                 aws.String(albumIndex),
+				KeyConditionExpression:    expr.KeyCondition(),
+				FilterExpression:          expr.Filter(),
+				ProjectionExpression:      expr.Projection(),
+			})
+		}
+	}
+
+	if len(queries) == 0 {
+		return nil, nil
+	}
+	return r.queryMedias(ctx, queries)
+}
+
+func (r *Repository) queryMedias(ctx context.Context, queries []*dynamodb.QueryInput) ([]*catalog.MediaMeta, error) {
+	var medias []*catalog.MediaMeta
 
 	crawler := dynamoutils.NewQueryStream(ctx, r.client, queries)
 	for crawler.HasNext() {
-		record := crawler.Next()
-		if mediaId := readMediaId(record); mediaId != "" {
-			mediaIds = append(mediaIds, mediaId)
+		media, err := unmarshalMediaMetaData(crawler.Next())
+		if err != nil {
+			return nil, err
 		}
+
+		medias = append(medias, media)
 	}
 
-	return mediaIds, nil
+	return medias, errors.Wrapf(crawler.Error(), "failed to find medias to transfer")
 }
 
 func (r *Repository) convertSelectorsIntoMediaRequest(owner ownermodel.Owner, selectors []catalog.MediaSelector) *catalog.FindMediaRequest {
@@ -26,6 +26,8 @@ func TestRepository_TransferMediasFromRecords(t *testing.T) {
 	middle := time.Date(2024, 1, 2, 12, 2, 42, 0, time.UTC)
 	end := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
 	media01Id := catalog.MediaId("media-01")
+	liveGroup := catalog.NewMediaGroupId("live-01", "")
+	after := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
 
 	type args struct {
 		records catalog.MediaTransferRecords
@@ -80,6 +82,34 @@ func TestRepository_TransferMediasFromRecords(t *testing.T) {
 			},
 			wantErr: assert.NoError,
 		},
+		{
+			name: "it should transfer the video of a Live Photo with its photo, even outside the selected dates",
+			args: args{
+				records: catalog.MediaTransferRecords{
+					album01: []catalog.MediaSelector{
+						{
+							FromAlbums: []catalog.AlbumId{album02},
+							Start:      start,
+							End:        end,
+						},
+					},
+				},
+			},
+			before: []map[string]types.AttributeValue{
+				groupedMediaAttributeMap(album02, middle, "live-photo", catalog.MediaTypeImage, liveGroup),
+				groupedMediaAttributeMap(album02, after, "live-video", catalog.MediaTypeVideo, liveGroup),
+			},
+			after: []map[string]types.AttributeValue{
+				groupedMediaAttributeMap(album01, middle, "live-photo", catalog.MediaTypeImage, liveGroup),
+				groupedMediaAttributeMap(album01, after, "live-video", catalog.MediaTypeVideo, liveGroup),
+			},
+			want: catalog.TransferredMedias{
+				Transfers: map[catalog.AlbumId][]catalog.MediaId{
+					album01: {"live-photo", "live-video"},
+				},
+			},
+			wantErr: assert.NoError,
+		},
 		{
 			name: "it should not transfer from the wrong album",
 			args: args{
@@ -166,3 +196,12 @@ func mediaAttributeMap(albumId catalog.AlbumId, dateTime time.Time, mediaId cata
 		"dateTime":     &types.AttributeValueMemberS{Value: dateTime.Format(time.RFC3339Nano)},
 	}
 }
+
+func groupedMediaAttributeMap(albumId catalog.AlbumId, dateTime time.Time, mediaId catalog.MediaId, mediaType catalog.MediaType, groupId catalog.MediaGroupId) map[string]types.AttributeValue {
+	attributes := mediaAttributeMap(albumId, dateTime, mediaId)
+	attributes["Type"] = &types.AttributeValueMemberS{Value: string(mediaType)}
+	attributes["Details"] = &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+		"GroupId": &types.AttributeValueMemberS{Value: groupId.String()},
+	}}
+	return attributes
+}
@@ -0,0 +1,23 @@
+// Package catalogplaces names the location of the medias with the embedded gazetteer of the geo package
+package catalogplaces
+
+import (
+	"github.com/thomasduchatelle/dphoto/pkg/catalog"
+	"github.com/thomasduchatelle/dphoto/pkg/geo"
+)
+
+type ReverseGeocoder struct {
+}
+
+func (r *ReverseGeocoder) FindPlace(latitude, longitude float64) (*catalog.MediaPlace, error) {
+	place, err := geo.PlaceAt(latitude, longitude)
+	if err != nil || place == nil {
+		return nil, err
+	}
+
+	return &catalog.MediaPlace{
+		Country: place.Country,
+		Region:  place.Region,
+		City:    place.City,
+	}, nil
+}
@@ -0,0 +1,61 @@
+package chainmetrics
+
+import (
+	"bufio"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+const prometheusPrefix = "dphoto_chain_"
+
+// WritePrometheus writes the measures of each link in the Prometheus text exposition format.
+func (r *Recorder) WritePrometheus(w io.Writer) error {
+	stats := r.Stats()
+	out := bufio.NewWriter(w)
+
+	writeFamily(out, "consumed_total", "counter", "Number of items processed by the link.", stats, func(s LinkStats) float64 { return float64(s.Consumed) })
+	writeFamily(out, "items_total", "counter", "Number of elements in the items processed by the link (medias in batches).", stats, func(s LinkStats) float64 { return float64(s.Items) })
+	writeFamily(out, "errors_total", "counter", "Number of items the link failed to process.", stats, func(s LinkStats) float64 { return float64(s.Errors) })
+	writeFamily(out, "queue_depth", "gauge", "Number of items waiting in the channel of the link.", stats, func(s LinkStats) float64 { return float64(s.QueueDepth) })
+	writeFamily(out, "queue_capacity", "gauge", "Size of the channel of the link.", stats, func(s LinkStats) float64 { return float64(s.QueueCapacity) })
+
+	name := prometheusPrefix + "latency_seconds"
+	fmt.Fprintf(out, "# HELP %s Time spent processing each item, including the time for the next link to accept it.\n", name)
+	fmt.Fprintf(out, "# TYPE %s histogram\n", name)
+	for _, s := range stats {
+		cumulated := 0
+		for i, bound := range LatencyBuckets {
+			cumulated += s.Buckets[i]
+			fmt.Fprintf(out, "%s_bucket{link=%q,le=%q} %d\n", name, s.Link, formatFloat(bound.Seconds()), cumulated)
+		}
+		fmt.Fprintf(out, "%s_bucket{link=%q,le=\"+Inf\"} %d\n", name, s.Link, s.Consumed)
+		fmt.Fprintf(out, "%s_sum{link=%q} %s\n", name, s.Link, formatFloat(s.Busy.Seconds()))
+		fmt.Fprintf(out, "%s_count{link=%q} %d\n", name, s.Link, s.Consumed)
+	}
+
+	return out.Flush()
+}
+
+// ServeHTTP exposes the measures to be scraped by Prometheus.
+func (r *Recorder) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := r.WritePrometheus(w); err != nil {
+		log.WithError(err).Warnln("Failed to write the metrics")
+	}
+}
+
+func writeFamily(out io.Writer, suffix, metricType, help string, stats []LinkStats, value func(LinkStats) float64) {
+	name := prometheusPrefix + suffix
+	fmt.Fprintf(out, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(out, "# TYPE %s %s\n", name, metricType)
+	for _, s := range stats {
+		fmt.Fprintf(out, "%s{link=%q} %s\n", name, s.Link, formatFloat(value(s)))
+	}
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
@@ -0,0 +1,154 @@
+// Package chainmetrics collects the measures of the backup chain links: throughput, queue depth, latency and errors ; and exposes them as Prometheus metrics and OpenTelemetry spans.
+package chainmetrics
+
+import (
+	"context"
+	"github.com/thomasduchatelle/dphoto/pkg/backup/chain"
+	"math"
+	"sync"
+	"time"
+)
+
+var (
+	// LatencyBuckets are the upper bounds of the latency histogram ; slower items are counted in an extra bucket.
+	LatencyBuckets = []time.Duration{
+		time.Millisecond,
+		5 * time.Millisecond,
+		10 * time.Millisecond,
+		50 * time.Millisecond,
+		100 * time.Millisecond,
+		500 * time.Millisecond,
+		time.Second,
+		5 * time.Second,
+		10 * time.Second,
+		30 * time.Second,
+		time.Minute,
+	}
+)
+
+// LinkStats are the measures aggregated for one link of the chain.
+type LinkStats struct {
+	Link          string
+	Consumed      int           // Consumed is the number of items processed by the link
+	Items         int           // Items is the number of elements in the items processed: medias when the link processes batches
+	Errors        int           // Errors is the number of items that failed
+	Busy          time.Duration // Busy is the sum of the latencies of all the routines of the link
+	First, Last   time.Time     // First is when the first item started to be processed, Last is when the last one completed
+	QueueDepth    int           // QueueDepth is the number of items waiting the last time one has been queued
+	MaxQueueDepth int           // MaxQueueDepth is the most items that have been waiting at once
+	QueueCapacity int           // QueueCapacity is the size of the channel of the link, 0 if it has none
+	Buckets       []int         // Buckets are the number of items processed within each LatencyBuckets (not cumulative), plus the slower ones
+}
+
+// Elapsed is the wall-clock time during which the link was active.
+func (s LinkStats) Elapsed() time.Duration {
+	return s.Last.Sub(s.First)
+}
+
+// Throughput is the number of elements processed per second while the link was active.
+func (s LinkStats) Throughput() float64 {
+	if s.Elapsed() <= 0 {
+		return 0
+	}
+	return float64(s.Items) / s.Elapsed().Seconds()
+}
+
+// MeanLatency is the average time spent processing an item.
+func (s LinkStats) MeanLatency() time.Duration {
+	if s.Consumed == 0 {
+		return 0
+	}
+	return s.Busy / time.Duration(s.Consumed)
+}
+
+// Percentile returns the upper bound of the bucket containing the percentile (between 0 and 1) of the latencies ; -1 if it's over the last bucket.
+func (s LinkStats) Percentile(percentile float64) time.Duration {
+	if s.Consumed == 0 {
+		return 0
+	}
+
+	rank := max(1, int(math.Ceil(percentile*float64(s.Consumed))))
+	cumulated := 0
+	for i, count := range s.Buckets {
+		cumulated += count
+		if cumulated >= rank && i < len(LatencyBuckets) {
+			return LatencyBuckets[i]
+		}
+	}
+	return -1
+}
+
+// Recorder aggregates the measures of each link ; it implements chain.Instrumentation.
+type Recorder struct {
+	lock  sync.Mutex
+	links map[string]*LinkStats
+	order []string
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{
+		links: make(map[string]*LinkStats),
+	}
+}
+
+func (r *Recorder) OnQueued(link string, depth, capacity int) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	stats := r.stats(link)
+	stats.QueueDepth = depth
+	stats.QueueCapacity = capacity
+	stats.MaxQueueDepth = max(stats.MaxQueueDepth, depth)
+}
+
+func (r *Recorder) OnConsumed(ctx context.Context, measure chain.Measure) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	stats := r.stats(measure.Link)
+	stats.Consumed++
+	stats.Items += measure.Items
+	if measure.Err != nil {
+		stats.Errors++
+	}
+
+	stats.Busy += measure.Latency()
+	if stats.First.IsZero() || measure.Start.Before(stats.First) {
+		stats.First = measure.Start
+	}
+	if measure.End.After(stats.Last) {
+		stats.Last = measure.End
+	}
+
+	bucket := len(LatencyBuckets)
+	for i, bound := range LatencyBuckets {
+		if measure.Latency() <= bound {
+			bucket = i
+			break
+		}
+	}
+	stats.Buckets[bucket]++
+}
+
+// Stats are in the order the links have been first measured.
+func (r *Recorder) Stats() []LinkStats {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	stats := make([]LinkStats, len(r.order))
+	for i, link := range r.order {
+		stats[i] = *r.links[link]
+		stats[i].Buckets = append([]int{}, r.links[link].Buckets...)
+	}
+	return stats
+}
+
+func (r *Recorder) stats(link string) *LinkStats {
+	stats, found := r.links[link]
+	if !found {
+		stats = &LinkStats{Link: link, Buckets: make([]int, len(LatencyBuckets)+1)}
+		r.links[link] = stats
+		r.order = append(r.order, link)
+	}
+	return stats
+}
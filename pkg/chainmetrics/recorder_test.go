@@ -0,0 +1,137 @@
+package chainmetrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/thomasduchatelle/dphoto/pkg/backup/chain"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2024, 3, 15, 9, 0, 0, 0, time.UTC)
+
+func measure(link string, items int, offset, latency time.Duration, err error) chain.Measure {
+	return chain.Measure{Link: link, Items: items, Start: start.Add(offset), End: start.Add(offset + latency), Err: err}
+}
+
+func TestRecorder_Stats(t *testing.T) {
+	recorder := NewRecorder()
+	ctx := context.Background()
+
+	recorder.OnQueued("analyse", 3, 10)
+	recorder.OnQueued("analyse", 7, 10)
+	recorder.OnQueued("analyse", 2, 10)
+	recorder.OnConsumed(ctx, measure("analyse", 1, 0, 3*time.Millisecond, nil))
+	recorder.OnConsumed(ctx, measure("analyse", 1, time.Second, 300*time.Millisecond, nil))
+	recorder.OnConsumed(ctx, measure("upload", 4, 500*time.Millisecond, 2*time.Minute, errors.New("TEST")))
+	recorder.OnConsumed(ctx, measure("analyse", 1, 1500*time.Millisecond, 500*time.Millisecond, nil))
+
+	stats := recorder.Stats()
+	if assert.Len(t, stats, 2) {
+		analyse := stats[0]
+		assert.Equal(t, "analyse", analyse.Link)
+		assert.Equal(t, 3, analyse.Consumed)
+		assert.Equal(t, 0, analyse.Errors)
+		assert.Equal(t, 2, analyse.QueueDepth)
+		assert.Equal(t, 7, analyse.MaxQueueDepth)
+		assert.Equal(t, 10, analyse.QueueCapacity)
+		assert.Equal(t, 2*time.Second, analyse.Elapsed())
+		assert.Equal(t, 1.5, analyse.Throughput())
+		assert.Equal(t, 803*time.Millisecond/3, analyse.MeanLatency())
+		assert.Equal(t, []int{0, 1, 0, 0, 0, 2, 0, 0, 0, 0, 0, 0}, analyse.Buckets)
+		assert.Equal(t, 5*time.Millisecond, analyse.Percentile(0.1))
+		assert.Equal(t, 500*time.Millisecond, analyse.Percentile(0.9))
+
+		upload := stats[1]
+		assert.Equal(t, "upload", upload.Link)
+		assert.Equal(t, 4, upload.Items)
+		assert.Equal(t, 1, upload.Errors)
+		assert.Equal(t, time.Duration(-1), upload.Percentile(0.5), "it should return -1 when latencies are over the last bucket")
+	}
+}
+
+func TestRecorder_WritePrometheus(t *testing.T) {
+	recorder := NewRecorder()
+	recorder.OnQueued("upload", 1, 2048)
+	recorder.OnConsumed(context.Background(), measure("upload", 3, 0, 2*time.Second, nil))
+	recorder.OnConsumed(context.Background(), measure("upload", 2, 0, 50*time.Millisecond, errors.New("TEST")))
+
+	buffer := new(bytes.Buffer)
+	err := recorder.WritePrometheus(buffer)
+	if assert.NoError(t, err) {
+		assert.Equal(t, `# HELP dphoto_chain_consumed_total Number of items processed by the link.
+# TYPE dphoto_chain_consumed_total counter
+dphoto_chain_consumed_total{link="upload"} 2
+# HELP dphoto_chain_items_total Number of elements in the items processed by the link (medias in batches).
+# TYPE dphoto_chain_items_total counter
+dphoto_chain_items_total{link="upload"} 5
+# HELP dphoto_chain_errors_total Number of items the link failed to process.
+# TYPE dphoto_chain_errors_total counter
+dphoto_chain_errors_total{link="upload"} 1
+# HELP dphoto_chain_queue_depth Number of items waiting in the channel of the link.
+# TYPE dphoto_chain_queue_depth gauge
+dphoto_chain_queue_depth{link="upload"} 1
+# HELP dphoto_chain_queue_capacity Size of the channel of the link.
+# TYPE dphoto_chain_queue_capacity gauge
+dphoto_chain_queue_capacity{link="upload"} 2048
+# HELP dphoto_chain_latency_seconds Time spent processing each item, including the time for the next link to accept it.
+# TYPE dphoto_chain_latency_seconds histogram
+dphoto_chain_latency_seconds_bucket{link="upload",le="0.001"} 0
+dphoto_chain_latency_seconds_bucket{link="upload",le="0.005"} 0
+dphoto_chain_latency_seconds_bucket{link="upload",le="0.01"} 0
+dphoto_chain_latency_seconds_bucket{link="upload",le="0.05"} 1
+dphoto_chain_latency_seconds_bucket{link="upload",le="0.1"} 1
+dphoto_chain_latency_seconds_bucket{link="upload",le="0.5"} 1
+dphoto_chain_latency_seconds_bucket{link="upload",le="1"} 1
+dphoto_chain_latency_seconds_bucket{link="upload",le="5"} 2
+dphoto_chain_latency_seconds_bucket{link="upload",le="10"} 2
+dphoto_chain_latency_seconds_bucket{link="upload",le="30"} 2
+dphoto_chain_latency_seconds_bucket{link="upload",le="60"} 2
+dphoto_chain_latency_seconds_bucket{link="upload",le="+Inf"} 2
+dphoto_chain_latency_seconds_sum{link="upload"} 2.05
+dphoto_chain_latency_seconds_count{link="upload"} 2
+`, buffer.String())
+	}
+}
+
+func TestTracer_WriteOTLP(t *testing.T) {
+	tracer := NewTracer("dphoto")
+
+	ctx, end := tracer.StartSpan(context.Background(), "backup", map[string]interface{}{"volume": "/media/sdcard"})
+	tracer.OnConsumed(ctx, measure("analyse", 1, 0, time.Second, nil))
+	tracer.OnConsumed(ctx, measure("upload", 2, time.Second, time.Second, errors.New("TEST failure")))
+	end(nil)
+
+	buffer := new(bytes.Buffer)
+	if !assert.NoError(t, tracer.WriteOTLP(buffer)) {
+		return
+	}
+
+	var request otlpExportRequest
+	if !assert.NoError(t, json.Unmarshal(buffer.Bytes(), &request)) || !assert.Len(t, request.ResourceSpans, 1) {
+		return
+	}
+	assert.Equal(t, "dphoto", *request.ResourceSpans[0].Resource.Attributes[0].Value.StringValue)
+
+	spans := request.ResourceSpans[0].ScopeSpans[0].Spans
+	if assert.Len(t, spans, 3) {
+		root := spans[2]
+		assert.Equal(t, "backup", root.Name)
+		assert.Empty(t, root.ParentSpanID)
+		assert.Len(t, root.TraceID, 32)
+		assert.Len(t, root.SpanID, 16)
+
+		assert.Equal(t, "analyse", spans[0].Name)
+		assert.Equal(t, root.SpanID, spans[0].ParentSpanID, "it should be a child of the span in the context")
+		assert.Equal(t, root.TraceID, spans[0].TraceID)
+		assert.Equal(t, "1710493200000000000", spans[0].StartTimeUnixNano)
+		assert.Equal(t, "1710493201000000000", spans[0].EndTimeUnixNano)
+		assert.Nil(t, spans[0].Status)
+
+		assert.Equal(t, "2", *spans[1].Attributes[0].Value.IntValue)
+		assert.Equal(t, &otlpStatus{Code: otlpStatusCodeError, Message: "TEST failure"}, spans[1].Status)
+	}
+}
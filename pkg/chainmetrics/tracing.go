@@ -0,0 +1,240 @@
+package chainmetrics
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/thomasduchatelle/dphoto/pkg/backup/chain"
+	"io"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	instrumentationScope = "github.com/thomasduchatelle/dphoto/pkg/backup/chain"
+
+	otlpSpanKindInternal = 1
+	otlpStatusCodeError  = 2
+)
+
+type spanContextKey struct{}
+
+// Span is an operation traced, following OpenTelemetry model.
+type Span struct {
+	TraceID      string // TraceID is shared by all the spans of a Tracer (hex encoded)
+	SpanID       string // SpanID is hex encoded
+	ParentSpanID string // ParentSpanID is empty for the root spans
+	Name         string
+	Start, End   time.Time
+	Attributes   map[string]interface{} // Attributes values are strings or integers
+	Error        string                 // Error is the message of the error when the operation failed
+}
+
+// Tracer records a span for each item processed by the links, child of the span started with StartSpan ; it implements chain.Instrumentation.
+type Tracer struct {
+	ServiceName string
+	lock        sync.Mutex
+	traceID     string
+	spans       []Span
+}
+
+func NewTracer(serviceName string) *Tracer {
+	return &Tracer{
+		ServiceName: serviceName,
+		traceID:     randomID(16),
+	}
+}
+
+// StartSpan returns a context in which the spans will be children of this one ; end must be called when the operation completes.
+func (t *Tracer) StartSpan(ctx context.Context, name string, attributes map[string]interface{}) (context.Context, func(err error)) {
+	span := Span{
+		TraceID:      t.traceID,
+		SpanID:       randomID(8),
+		ParentSpanID: parentSpanID(ctx),
+		Name:         name,
+		Start:        time.Now(),
+		Attributes:   attributes,
+	}
+
+	return context.WithValue(ctx, spanContextKey{}, span.SpanID), func(err error) {
+		span.End = time.Now()
+		if err != nil {
+			span.Error = err.Error()
+		}
+		t.push(span)
+	}
+}
+
+func (t *Tracer) OnQueued(link string, depth, capacity int) {
+	// queues are measured by the Recorder
+}
+
+func (t *Tracer) OnConsumed(ctx context.Context, measure chain.Measure) {
+	span := Span{
+		TraceID:      t.traceID,
+		SpanID:       randomID(8),
+		ParentSpanID: parentSpanID(ctx),
+		Name:         measure.Link,
+		Start:        measure.Start,
+		End:          measure.End,
+		Attributes:   map[string]interface{}{"items": measure.Items},
+	}
+	if measure.Err != nil {
+		span.Error = measure.Err.Error()
+	}
+
+	t.push(span)
+}
+
+// Spans are in the order they completed.
+func (t *Tracer) Spans() []Span {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return append([]Span{}, t.spans...)
+}
+
+// WriteText writes one line per span, to be read by a human.
+func (t *Tracer) WriteText(w io.Writer) error {
+	for _, span := range t.Spans() {
+		status := "ok"
+		if span.Error != "" {
+			status = "error: " + span.Error
+		}
+
+		_, err := fmt.Fprintf(w, "%s %s span=%s parent=%s start=%s duration=%s %v %s\n", span.TraceID, span.Name, span.SpanID, span.ParentSpanID, span.Start.Format(time.RFC3339Nano), span.End.Sub(span.Start), span.Attributes, status)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WriteOTLP writes the spans as an OTLP/JSON export request, the format used by OpenTelemetry file exporters and accepted by OTLP/HTTP collectors.
+func (t *Tracer) WriteOTLP(w io.Writer) error {
+	spans := t.Spans()
+	otlpSpans := make([]otlpSpan, len(spans))
+	for i, span := range spans {
+		otlpSpans[i] = otlpSpan{
+			TraceID:           span.TraceID,
+			SpanID:            span.SpanID,
+			ParentSpanID:      span.ParentSpanID,
+			Name:              span.Name,
+			Kind:              otlpSpanKindInternal,
+			StartTimeUnixNano: strconv.FormatInt(span.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(span.End.UnixNano(), 10),
+			Attributes:        otlpAttributes(span.Attributes),
+		}
+		if span.Error != "" {
+			otlpSpans[i].Status = &otlpStatus{Code: otlpStatusCodeError, Message: span.Error}
+		}
+	}
+
+	request := otlpExportRequest{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource: otlpResource{Attributes: otlpAttributes(map[string]interface{}{"service.name": t.ServiceName})},
+			ScopeSpans: []otlpScopeSpans{{
+				Scope: otlpScope{Name: instrumentationScope},
+				Spans: otlpSpans,
+			}},
+		}},
+	}
+
+	return json.NewEncoder(w).Encode(request)
+}
+
+func (t *Tracer) push(span Span) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.spans = append(t.spans, span)
+}
+
+func parentSpanID(ctx context.Context) string {
+	parent, _ := ctx.Value(spanContextKey{}).(string)
+	return parent
+}
+
+func randomID(size int) string {
+	id := make([]byte, size)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+type otlpExportRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            *otlpStatus    `json:"status,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"` // IntValue is a string because OTLP/JSON encodes 64 bits integers as strings
+}
+
+func otlpAttributes(attributes map[string]interface{}) []otlpKeyValue {
+	keys := make([]string, 0, len(attributes))
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	values := make([]otlpKeyValue, 0, len(attributes))
+	for _, key := range keys {
+		switch value := attributes[key].(type) {
+		case int:
+			encoded := strconv.Itoa(value)
+			values = append(values, otlpKeyValue{Key: key, Value: otlpValue{IntValue: &encoded}})
+		case int64:
+			encoded := strconv.FormatInt(value, 10)
+			values = append(values, otlpKeyValue{Key: key, Value: otlpValue{IntValue: &encoded}})
+		default:
+			encoded := fmt.Sprint(value)
+			values = append(values, otlpKeyValue{Key: key, Value: otlpValue{StringValue: &encoded}})
+		}
+	}
+	return values
+}
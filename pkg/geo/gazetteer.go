@@ -0,0 +1,180 @@
+package geo
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	_ "embed"
+	"github.com/pkg/errors"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//go:generate go run ./internal/gazetteergen -output .
+
+const (
+	// maxPlaceDistanceKm is the distance after which the nearest place is not used to name a location (at sea, in the wilderness, ...):
+	// naming a location after a town further away would be misleading.
+	maxPlaceDistanceKm = 20.0
+)
+
+var (
+	// citiesTabGz lists the populated places: name, latitude, longitude, country code, and region ; it's compressed with gzip.
+	//go:embed cities.tsv.gz
+	citiesTabGz []byte
+	// countriesTab has the name of each country code.
+	//go:embed countries.tsv
+	countriesTab []byte
+
+	defaultGazetteer     *Gazetteer
+	defaultGazetteerErr  error
+	defaultGazetteerOnce sync.Once
+)
+
+// Place is the nearest populated place to a location.
+type Place struct {
+	City        string
+	Region      string // Region is the first-level administrative division (ex: 'Auvergne-Rhône-Alpes')
+	Country     string
+	CountryCode string // CountryCode is the ISO 3166 code of the country
+}
+
+func (p Place) String() string {
+	return strings.Join(nonEmpty(p.City, p.Region, p.Country), ", ")
+}
+
+// Gazetteer resolves coordinates into places without network access.
+type Gazetteer struct {
+	places []Place
+	tree   *kdTree
+}
+
+// PlaceFinder implements the place lookup expected by the backup, from the embedded gazetteer.
+type PlaceFinder struct{}
+
+// FindCity returns the name of the nearest city, or an empty string when the location is too far from any.
+func (f PlaceFinder) FindCity(latitude, longitude float64) (string, error) {
+	place, err := PlaceAt(latitude, longitude)
+	if err != nil || place == nil {
+		return "", err
+	}
+
+	return place.City, nil
+}
+
+// PlaceAt uses the embedded gazetteer to find the nearest place ; nil is returned when the location is too far from any.
+func PlaceAt(latitude, longitude float64) (*Place, error) {
+	defaultGazetteerOnce.Do(func() {
+		var citiesTab []byte
+		citiesTab, defaultGazetteerErr = gunzip(citiesTabGz)
+		if defaultGazetteerErr == nil {
+			defaultGazetteer, defaultGazetteerErr = NewGazetteer(citiesTab, countriesTab)
+		}
+	})
+	if defaultGazetteerErr != nil {
+		return nil, defaultGazetteerErr
+	}
+
+	return defaultGazetteer.PlaceAt(latitude, longitude)
+}
+
+// NewGazetteer indexes the places of a TSV content with the format of the embedded one.
+func NewGazetteer(cities, countries []byte) (*Gazetteer, error) {
+	countryNames := make(map[string]string)
+	err := readTabSeparated(countries, 2, func(columns []string) error {
+		countryNames[columns[0]] = columns[1]
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid countries")
+	}
+
+	gazetteer := new(Gazetteer)
+	var coordinates [][2]float64
+	err = readTabSeparated(cities, 5, func(columns []string) error {
+		latitude, err := strconv.ParseFloat(columns[1], 64)
+		if err != nil {
+			return errors.Wrapf(err, "invalid latitude of %s", columns[0])
+		}
+		longitude, err := strconv.ParseFloat(columns[2], 64)
+		if err != nil {
+			return errors.Wrapf(err, "invalid longitude of %s", columns[0])
+		}
+
+		coordinates = append(coordinates, [2]float64{latitude, longitude})
+		gazetteer.places = append(gazetteer.places, Place{
+			City:        columns[0],
+			Region:      columns[4],
+			Country:     countryNames[columns[3]],
+			CountryCode: columns[3],
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid cities")
+	}
+
+	gazetteer.tree = newKdTree(coordinates)
+	return gazetteer, nil
+}
+
+// PlaceAt returns the nearest place, or nil when the location is further than 20km from any.
+func (g *Gazetteer) PlaceAt(latitude, longitude float64) (*Place, error) {
+	if latitude < -90 || latitude > 90 || longitude < -180 || longitude > 180 {
+		return nil, errors.Errorf("invalid GPS coordinates: %f, %f", latitude, longitude)
+	}
+
+	index, distance := g.tree.nearest(latitude, longitude)
+	if index < 0 || distance > maxPlaceDistanceKm {
+		return nil, nil
+	}
+
+	place := g.places[index]
+	return &place, nil
+}
+
+// readTabSeparated ignores the empty lines and the comments, and requires at least minColumns on each other line.
+func readTabSeparated(content []byte, minColumns int, consumer func(columns []string) error) error {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		columns := strings.Split(line, "\t")
+		if len(columns) < minColumns {
+			return errors.Errorf("expected %d columns: %s", minColumns, line)
+		}
+
+		err := consumer(columns)
+		if err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+func gunzip(content []byte) ([]byte, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid gzip content")
+	}
+	defer reader.Close()
+
+	plain, err := io.ReadAll(reader)
+	return plain, errors.Wrapf(err, "invalid gzip content")
+}
+
+func nonEmpty(values ...string) []string {
+	var filtered []string
+	for _, value := range values {
+		if value != "" {
+			filtered = append(filtered, value)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,94 @@
+package geo
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestPlaceAt(t *testing.T) {
+	tests := []struct {
+		name      string
+		latitude  float64
+		longitude float64
+		want      *Place
+		wantErr   assert.ErrorAssertionFunc
+	}{
+		{"it should find the Eiffel Tower in Paris", 48.8584, 2.2945, &Place{City: "Paris", Region: "Île-de-France", Country: "France", CountryCode: "FR"}, assert.NoError},
+		{"it should find the nearest town of a hike in the Alps", 45.8786, 6.8876, &Place{City: "Chamonix-Mont-Blanc", Region: "Auvergne-Rhône-Alpes", Country: "France", CountryCode: "FR"}, assert.NoError},
+		{"it should find the Opera House in Sydney", -33.8568, 151.2153, &Place{City: "Sydney", Region: "New South Wales", Country: "Australia", CountryCode: "AU"}, assert.NoError},
+		{"it should not name locations in the middle of the ocean", -30, -20, nil, assert.NoError},
+		{"it should reject invalid coordinates", 0, 181, nil, assert.Error},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := PlaceAt(tt.latitude, tt.longitude)
+			if tt.wantErr(t, err) {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPlaceAt_coverage(t *testing.T) {
+	_, err := PlaceAt(0, 0)
+	if assert.NoError(t, err) {
+		// GeoNames cities1000 has more than 150,000 places: a smaller extract leaves most of the locations unnamed within maxPlaceDistanceKm
+		assert.Greater(t, len(defaultGazetteer.places), 100_000, "cities.tsv.gz is not the cities1000 extract: run 'go generate ./pkg/geo' to download it")
+	}
+}
+
+func TestPlace_String(t *testing.T) {
+	assert.Equal(t, "Paris, Île-de-France, France", Place{City: "Paris", Region: "Île-de-France", Country: "France"}.String())
+	assert.Equal(t, "Monaco, Monaco", Place{City: "Monaco", Country: "Monaco"}.String())
+}
+
+func TestGazetteer_PlaceAt_antimeridian(t *testing.T) {
+	gazetteer, err := NewGazetteer([]byte("Waiyevo\t-16.7900\t179.9800\tFJ\tNorthern\nApia\t-13.8333\t-171.7667\tWS\tTuamasaga\n"), []byte("FJ\tFiji\nWS\tSamoa\n"))
+	if assert.NoError(t, err) {
+		got, err := gazetteer.PlaceAt(-16.8, -179.98)
+		if assert.NoError(t, err) && assert.NotNil(t, got) {
+			assert.Equal(t, &Place{City: "Waiyevo", Region: "Northern", Country: "Fiji", CountryCode: "FJ"}, got, "it should find the nearest place on the other side of the antimeridian")
+		}
+
+		got, err = gazetteer.PlaceAt(-15, -176)
+		if assert.NoError(t, err) {
+			assert.Nil(t, got, "it should not use places further than the maximum distance")
+		}
+	}
+}
+
+func Test_kdTree_nearest(t *testing.T) {
+	random := rand.New(rand.NewSource(42))
+	randomCoordinate := func() [2]float64 {
+		return [2]float64{math.Asin(2*random.Float64()-1) * 180 / math.Pi, random.Float64()*360 - 180}
+	}
+
+	coordinates := make([][2]float64, 500)
+	for i := range coordinates {
+		coordinates[i] = randomCoordinate()
+	}
+	tree := newKdTree(coordinates)
+
+	for i := 0; i < 200; i++ {
+		target := randomCoordinate()
+
+		want, wantDistance := -1, math.MaxFloat64
+		for index, coordinate := range coordinates {
+			if distance := DistanceKm(target[0], target[1], coordinate[0], coordinate[1]); distance < wantDistance {
+				want, wantDistance = index, distance
+			}
+		}
+
+		got, gotDistance := tree.nearest(target[0], target[1])
+		if !assert.Equal(t, want, got, "it should find the same point than a linear search for %v", target) {
+			return
+		}
+		assert.InDelta(t, wantDistance, gotDistance, 1e-6)
+	}
+
+	index, _ := newKdTree(nil).nearest(0, 0)
+	assert.Equal(t, -1, index, "it should support empty trees")
+}
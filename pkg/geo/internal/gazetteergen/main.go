@@ -0,0 +1,216 @@
+// Command gazetteergen generates the gazetteer embedded in the geo package from the GeoNames datasets (CC BY 4.0, geonames.org).
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"flag"
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	geonamesURL = "https://download.geonames.org/export/dump"
+	citiesFile  = "cities1000"
+)
+
+type city struct {
+	name, countryCode, region string
+	latitude, longitude       float64
+}
+
+func main() {
+	output := flag.String("output", ".", "directory in which cities.tsv.gz and countries.tsv are written")
+	flag.Parse()
+
+	err := generate(*output)
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+}
+
+func generate(output string) error {
+	countries, err := readCountries()
+	if err != nil {
+		return err
+	}
+
+	regions, err := readRegions()
+	if err != nil {
+		return err
+	}
+
+	cities, err := readCities(regions)
+	if err != nil {
+		return err
+	}
+
+	err = writeCountries(path.Join(output, "countries.tsv"), countries)
+	if err != nil {
+		return err
+	}
+
+	return writeCities(path.Join(output, "cities.tsv.gz"), cities)
+}
+
+func download(file string) ([]byte, error) {
+	response, err := http.Get(fmt.Sprintf("%s/%s", geonamesURL, file))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to download %s", file)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("failed to download %s: %s", file, response.Status)
+	}
+
+	return io.ReadAll(response.Body)
+}
+
+// readTSV calls the consumer for each line that is not a comment.
+func readTSV(reader io.Reader, consumer func(columns []string) error) error {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		err := consumer(strings.Split(line, "\t"))
+		if err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+// readCountries returns the names of the countries by their ISO code.
+func readCountries() (map[string]string, error) {
+	content, err := download("countryInfo.txt")
+	if err != nil {
+		return nil, err
+	}
+
+	countries := make(map[string]string)
+	err = readTSV(bytes.NewReader(content), func(columns []string) error {
+		if len(columns) > 4 {
+			countries[columns[0]] = columns[4]
+		}
+		return nil
+	})
+	return countries, err
+}
+
+// readRegions returns the names of the first-level administrative divisions by their code (ex: 'FR.84').
+func readRegions() (map[string]string, error) {
+	content, err := download("admin1CodesASCII.txt")
+	if err != nil {
+		return nil, err
+	}
+
+	regions := make(map[string]string)
+	err = readTSV(bytes.NewReader(content), func(columns []string) error {
+		if len(columns) > 1 {
+			regions[columns[0]] = columns[1]
+		}
+		return nil
+	})
+	return regions, err
+}
+
+func readCities(regions map[string]string) ([]city, error) {
+	content, err := download(citiesFile + ".zip")
+	if err != nil {
+		return nil, err
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid %s archive", citiesFile)
+	}
+
+	reader, err := archive.Open(citiesFile + ".txt")
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s archive doesn't contain the expected file", citiesFile)
+	}
+	defer reader.Close()
+
+	var cities []city
+	err = readTSV(reader, func(columns []string) error {
+		if len(columns) < 11 {
+			return errors.Errorf("invalid line in %s: %v", citiesFile, columns)
+		}
+
+		latitude, err := strconv.ParseFloat(columns[4], 64)
+		if err != nil {
+			return errors.Wrapf(err, "invalid latitude for %s", columns[1])
+		}
+		longitude, err := strconv.ParseFloat(columns[5], 64)
+		if err != nil {
+			return errors.Wrapf(err, "invalid longitude for %s", columns[1])
+		}
+
+		cities = append(cities, city{
+			name:        columns[1],
+			countryCode: columns[8],
+			region:      regions[columns[8]+"."+columns[10]],
+			latitude:    latitude,
+			longitude:   longitude,
+		})
+		return nil
+	})
+
+	sort.Slice(cities, func(i, j int) bool {
+		if cities[i].countryCode != cities[j].countryCode {
+			return cities[i].countryCode < cities[j].countryCode
+		}
+		return cities[i].name < cities[j].name
+	})
+	return cities, err
+}
+
+func writeCountries(file string, countries map[string]string) error {
+	codes := make([]string, 0, len(countries))
+	for code := range countries {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	content := bytes.NewBufferString("# Names of the countries by ISO 3166 code, from GeoNames 'countryInfo.txt' (CC BY 4.0, geonames.org).\n#\n")
+	for _, code := range codes {
+		_, _ = fmt.Fprintf(content, "%s\t%s\n", code, countries[code])
+	}
+
+	return errors.Wrapf(os.WriteFile(file, content.Bytes(), 0644), "failed to write %s", file)
+}
+
+// writeCities compresses the TSV: the 150,000 places of 1000+ inhabitants are embedded in the binaries.
+func writeCities(file string, cities []city) error {
+	content := new(bytes.Buffer)
+	compressed, err := gzip.NewWriterLevel(content, gzip.BestCompression)
+	if err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprint(compressed, "# Places of 1000+ inhabitants, from GeoNames 'cities1000' (CC BY 4.0, geonames.org).\n# Name, latitude, longitude, ISO 3166 country code, and name of the region.\n#\n")
+	for _, c := range cities {
+		_, _ = fmt.Fprintf(compressed, "%s\t%.4f\t%.4f\t%s\t%s\n", c.name, c.latitude, c.longitude, c.countryCode, c.region)
+	}
+	if err = compressed.Close(); err != nil {
+		return errors.Wrapf(err, "failed to compress %s", file)
+	}
+
+	return errors.Wrapf(os.WriteFile(file, content.Bytes(), 0644), "failed to write %s", file)
+}
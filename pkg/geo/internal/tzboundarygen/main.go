@@ -0,0 +1,185 @@
+// Command tzboundarygen generates the time zone boundaries embedded in the geo package from the timezone-boundary-builder release (ODbL,
+// github.com/evansiroky/timezone-boundary-builder). Polygons are simplified to keep the embedded file small.
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/thomasduchatelle/dphoto/pkg/geo"
+	"io"
+	"math"
+	"net/http"
+	"os"
+)
+
+const (
+	releasesURL = "https://github.com/evansiroky/timezone-boundary-builder/releases/download"
+	releaseFile = "timezones.geojson.zip"
+	geojsonFile = "combined.json"
+)
+
+type featureCollection struct {
+	Features []struct {
+		Properties struct {
+			TzID string `json:"tzid"`
+		} `json:"properties"`
+		Geometry struct {
+			Type        string          `json:"type"`
+			Coordinates json.RawMessage `json:"coordinates"`
+		} `json:"geometry"`
+	} `json:"features"`
+}
+
+func main() {
+	output := flag.String("output", "timezones.bin", "file in which the boundaries are written")
+	version := flag.String("version", "2024b", "release of timezone-boundary-builder")
+	tolerance := flag.Float64("tolerance", 0.005, "maximum distance, in degrees, between the simplified boundaries and the original ones")
+	flag.Parse()
+
+	err := generate(*output, *version, *tolerance)
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+}
+
+func generate(output, version string, tolerance float64) error {
+	collection, err := readRelease(version)
+	if err != nil {
+		return err
+	}
+
+	var polygons []geo.TimeZonePolygon
+	for _, feature := range collection.Features {
+		var coordinates [][][][2]float64
+		switch feature.Geometry.Type {
+		case "Polygon":
+			var polygon [][][2]float64
+			err = json.Unmarshal(feature.Geometry.Coordinates, &polygon)
+			coordinates = [][][][2]float64{polygon}
+		case "MultiPolygon":
+			err = json.Unmarshal(feature.Geometry.Coordinates, &coordinates)
+		default:
+			err = errors.Errorf("geometry %s is not supported", feature.Geometry.Type)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "invalid geometry of %s", feature.Properties.TzID)
+		}
+
+		for _, polygon := range coordinates {
+			if simplified := simplifyPolygon(polygon, tolerance); len(simplified) > 0 {
+				polygons = append(polygons, geo.TimeZonePolygon{Zone: feature.Properties.TzID, Rings: simplified})
+			}
+		}
+	}
+
+	content := geo.EncodeTimeZonePolygons(polygons)
+	return errors.Wrapf(os.WriteFile(output, content, 0644), "failed to write %s", output)
+}
+
+func readRelease(version string) (*featureCollection, error) {
+	url := fmt.Sprintf("%s/%s/%s", releasesURL, version, releaseFile)
+	response, err := http.Get(url)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to download %s", url)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("failed to download %s: %s", url, response.Status)
+	}
+
+	content, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to download %s", url)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid %s archive", releaseFile)
+	}
+
+	reader, err := archive.Open(geojsonFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s archive doesn't contain the expected file", releaseFile)
+	}
+	defer reader.Close()
+
+	collection := new(featureCollection)
+	err = json.NewDecoder(reader).Decode(collection)
+	return collection, errors.Wrapf(err, "invalid %s", geojsonFile)
+}
+
+// simplifyPolygon converts GeoJSON [longitude, latitude] points into [latitude, longitude], and drops the rings too small to be kept.
+// Nil is returned when the outer ring is dropped.
+func simplifyPolygon(polygon [][][2]float64, tolerance float64) [][][2]float64 {
+	var rings [][][2]float64
+	for i, ring := range polygon {
+		points := make([][2]float64, 0, len(ring))
+		for _, point := range ring {
+			points = append(points, [2]float64{point[1], point[0]})
+		}
+		if len(points) > 1 && points[0] == points[len(points)-1] {
+			points = points[:len(points)-1]
+		}
+
+		simplified := simplifyRing(points, tolerance)
+		if len(simplified) < 3 {
+			if i == 0 {
+				return nil
+			}
+			continue
+		}
+		rings = append(rings, simplified)
+	}
+
+	return rings
+}
+
+// simplifyRing uses the Ramer-Douglas-Peucker algorithm on the ring split in two halves (the first and the middle points are kept).
+func simplifyRing(points [][2]float64, tolerance float64) [][2]float64 {
+	if len(points) < 4 {
+		return points
+	}
+
+	middle := len(points) / 2
+	first := douglasPeucker(points[:middle+1], tolerance)
+	second := douglasPeucker(append(append([][2]float64{}, points[middle:]...), points[0]), tolerance)
+	return append(first[:len(first)-1], second[:len(second)-1]...)
+}
+
+// douglasPeucker returns a new slice: the points are not modified.
+func douglasPeucker(points [][2]float64, tolerance float64) [][2]float64 {
+	if len(points) < 3 {
+		return append([][2]float64{}, points...)
+	}
+
+	farthest, farthestDistance := 0, 0.0
+	for i := 1; i < len(points)-1; i++ {
+		if distance := distanceToSegment(points[i], points[0], points[len(points)-1]); distance > farthestDistance {
+			farthest, farthestDistance = i, distance
+		}
+	}
+
+	if farthestDistance <= tolerance {
+		return [][2]float64{points[0], points[len(points)-1]}
+	}
+
+	left := douglasPeucker(points[:farthest+1], tolerance)
+	right := douglasPeucker(points[farthest:], tolerance)
+	return append(left[:len(left)-1], right...)
+}
+
+func distanceToSegment(point, start, end [2]float64) float64 {
+	dx, dy := end[0]-start[0], end[1]-start[1]
+	if dx == 0 && dy == 0 {
+		return math.Hypot(point[0]-start[0], point[1]-start[1])
+	}
+
+	t := math.Max(0, math.Min(1, ((point[0]-start[0])*dx+(point[1]-start[1])*dy)/(dx*dx+dy*dy)))
+	return math.Hypot(point[0]-start[0]-t*dx, point[1]-start[1]-t*dy)
+}
@@ -0,0 +1,95 @@
+package geo
+
+import (
+	"math"
+	"sort"
+)
+
+// kdTree indexes points on the unit sphere (3D cartesian coordinates): the chord distance is growing with the great-circle distance,
+// the nearest point is then found without any special case around the poles or the antimeridian.
+// The tree is implicit: the median of each range of nodes is its root.
+type kdTree struct {
+	nodes []kdNode
+}
+
+type kdNode struct {
+	point [3]float64
+	index int // index is the position of the point in the list used to build the tree
+}
+
+func newKdTree(coordinates [][2]float64) *kdTree {
+	nodes := make([]kdNode, len(coordinates))
+	for i, coordinate := range coordinates {
+		nodes[i] = kdNode{point: toCartesian(coordinate[0], coordinate[1]), index: i}
+	}
+
+	buildKdTree(nodes, 0)
+	return &kdTree{nodes: nodes}
+}
+
+func buildKdTree(nodes []kdNode, axis int) {
+	if len(nodes) <= 1 {
+		return
+	}
+
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].point[axis] < nodes[j].point[axis]
+	})
+
+	median := len(nodes) / 2
+	buildKdTree(nodes[:median], (axis+1)%3)
+	buildKdTree(nodes[median+1:], (axis+1)%3)
+}
+
+// nearest returns the index of the closest point and its great-circle distance ; index is -1 when the tree is empty.
+func (t *kdTree) nearest(latitude, longitude float64) (index int, distanceKm float64) {
+	if len(t.nodes) == 0 {
+		return -1, 0
+	}
+
+	target := toCartesian(latitude, longitude)
+	best, bestSquaredDistance := -1, math.MaxFloat64
+	t.search(t.nodes, 0, target, &best, &bestSquaredDistance)
+
+	chord := math.Sqrt(bestSquaredDistance)
+	return t.nodes[best].index, 2 * earthRadiusKm * math.Asin(math.Min(1, chord/2))
+}
+
+func (t *kdTree) search(nodes []kdNode, axis int, target [3]float64, best *int, bestSquaredDistance *float64) {
+	if len(nodes) == 0 {
+		return
+	}
+
+	median := len(nodes) / 2
+	node := nodes[median]
+	if distance := squaredDistance(node.point, target); distance < *bestSquaredDistance {
+		*best = median + t.offset(nodes)
+		*bestSquaredDistance = distance
+	}
+
+	delta := target[axis] - node.point[axis]
+	near, far := nodes[:median], nodes[median+1:]
+	if delta > 0 {
+		near, far = far, near
+	}
+
+	t.search(near, (axis+1)%3, target, best, bestSquaredDistance)
+	if delta*delta < *bestSquaredDistance {
+		t.search(far, (axis+1)%3, target, best, bestSquaredDistance)
+	}
+}
+
+// offset is the position of the sub-slice within the tree.
+func (t *kdTree) offset(nodes []kdNode) int {
+	return cap(t.nodes) - cap(nodes)
+}
+
+func toCartesian(latitude, longitude float64) [3]float64 {
+	phi, lambda := latitude*math.Pi/180, longitude*math.Pi/180
+	return [3]float64{math.Cos(phi) * math.Cos(lambda), math.Cos(phi) * math.Sin(lambda), math.Sin(phi)}
+}
+
+func squaredDistance(a, b [3]float64) float64 {
+	dx, dy, dz := a[0]-b[0], a[1]-b[1], a[2]-b[2]
+	return dx*dx + dy*dy + dz*dz
+}
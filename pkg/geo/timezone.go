@@ -0,0 +1,198 @@
+// Package geo resolves information from GPS coordinates without any network access: the datasets are embedded in the binary.
+package geo
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"github.com/pkg/errors"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	_ "time/tzdata"
+)
+
+const (
+	earthRadiusKm = 6371.0
+	// maxZoneDistanceKm is the distance after which the nearest reference is ignored: the location is at sea and the nautical zone is used.
+	maxZoneDistanceKm = 1000.0
+)
+
+var (
+	// zoneTab is the tzdb 'zone.tab' (public domain): each time zone, per country, with the coordinates of its principal location.
+	//go:embed zone.tab
+	zoneTab []byte
+	// zoneExtraTab has the same format and adds locations where the principal ones are too sparse.
+	//go:embed zone_extra.tab
+	zoneExtraTab []byte
+	// timeZonesBin has the boundaries of the time zones from timezone-boundary-builder (ODbL), simplified and encoded by EncodeTimeZonePolygons.
+	//go:embed timezones.bin
+	timeZonesBin []byte
+
+	zoneBoundaries     *timeZoneBoundaries
+	zoneBoundariesErr  error
+	zoneBoundariesOnce sync.Once
+	zoneReferences     []zoneReference
+	zoneReferencesErr  error
+	zoneReferencesOnce sync.Once
+)
+
+type zoneReference struct {
+	latitude, longitude float64
+	name                string
+}
+
+// TimeZoneFinder implements the time zone lookup expected by the backup.
+type TimeZoneFinder struct{}
+
+// FindTimeZone delegates to TimeZoneAt.
+func (f TimeZoneFinder) FindTimeZone(latitude, longitude float64) (*time.Location, error) {
+	return TimeZoneAt(latitude, longitude)
+}
+
+// TimeZoneAt returns the time zone whose boundaries contain the coordinates.
+// Locations outside any boundary (on the coast simplified away, at sea, ...) use the time zone of the nearest reference location, and locations at sea,
+// far from any reference, are in the nautical zone of their longitude (ex: 'Etc/GMT-3').
+func TimeZoneAt(latitude, longitude float64) (*time.Location, error) {
+	if latitude < -90 || latitude > 90 || longitude < -180 || longitude > 180 {
+		return nil, errors.Errorf("invalid GPS coordinates: %f, %f", latitude, longitude)
+	}
+
+	boundaries, err := loadZoneBoundaries()
+	if err != nil {
+		return nil, err
+	}
+	if zone := boundaries.zoneAt(latitude, longitude); zone != "" {
+		return time.LoadLocation(zone)
+	}
+
+	references, err := loadZoneReferences()
+	if err != nil {
+		return nil, err
+	}
+
+	nearest, nearestDistance := "", math.MaxFloat64
+	for _, reference := range references {
+		if distance := DistanceKm(latitude, longitude, reference.latitude, reference.longitude); distance < nearestDistance {
+			nearest, nearestDistance = reference.name, distance
+		}
+	}
+
+	if nearestDistance > maxZoneDistanceKm {
+		return nauticalZone(longitude)
+	}
+	return time.LoadLocation(nearest)
+}
+
+// DistanceKm is the great-circle distance between two coordinates (haversine formula).
+func DistanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1, phi2 := lat1*math.Pi/180, lat2*math.Pi/180
+	deltaPhi := (lat2 - lat1) * math.Pi / 180
+	deltaLambda := (lon2 - lon1) * math.Pi / 180
+
+	a := math.Sin(deltaPhi/2)*math.Sin(deltaPhi/2) + math.Cos(phi1)*math.Cos(phi2)*math.Sin(deltaLambda/2)*math.Sin(deltaLambda/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// nauticalZone is 'Etc/GMT-N' for positive longitudes: the sign of the tzdb 'Etc' zones is inverted.
+func nauticalZone(longitude float64) (*time.Location, error) {
+	offset := int(math.Round(longitude / 15))
+	switch {
+	case offset == 0:
+		return time.UTC, nil
+	case offset > 0:
+		return time.LoadLocation("Etc/GMT-" + strconv.Itoa(min(offset, 12)))
+	default:
+		return time.LoadLocation("Etc/GMT+" + strconv.Itoa(min(-offset, 12)))
+	}
+}
+
+func loadZoneBoundaries() (*timeZoneBoundaries, error) {
+	zoneBoundariesOnce.Do(func() {
+		zoneBoundaries, zoneBoundariesErr = decodeTimeZoneBoundaries(timeZonesBin)
+	})
+	return zoneBoundaries, zoneBoundariesErr
+}
+
+func loadZoneReferences() ([]zoneReference, error) {
+	zoneReferencesOnce.Do(func() {
+		var extra []zoneReference
+		zoneReferences, zoneReferencesErr = parseZoneTab(zoneTab)
+		if zoneReferencesErr == nil {
+			extra, zoneReferencesErr = parseZoneTab(zoneExtraTab)
+			zoneReferences = append(zoneReferences, extra...)
+		}
+	})
+	return zoneReferences, zoneReferencesErr
+}
+
+// parseZoneTab reads lines 'country-code <TAB> coordinates <TAB> TZ [<TAB> comments]', comments starting with '#' are ignored.
+func parseZoneTab(content []byte) ([]zoneReference, error) {
+	var references []zoneReference
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") || strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		columns := strings.Split(line, "\t")
+		if len(columns) < 3 {
+			return nil, errors.Errorf("invalid zone.tab line: %s", line)
+		}
+
+		latitude, longitude, err := parseISO6709(columns[1])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid zone.tab line: %s", line)
+		}
+		references = append(references, zoneReference{latitude: latitude, longitude: longitude, name: columns[2]})
+	}
+
+	return references, scanner.Err()
+}
+
+// parseISO6709 parses coordinates in the form ±DDMM±DDDMM or ±DDMMSS±DDDMMSS.
+func parseISO6709(coordinates string) (float64, float64, error) {
+	split := strings.IndexAny(coordinates[1:], "+-") + 1
+	if split <= 0 {
+		return 0, 0, errors.Errorf("'%s' is not a ISO 6709 coordinate", coordinates)
+	}
+
+	latitude, err := parseSexagesimal(coordinates[:split], 2)
+	if err != nil {
+		return 0, 0, err
+	}
+	longitude, err := parseSexagesimal(coordinates[split:], 3)
+	return latitude, longitude, err
+}
+
+func parseSexagesimal(value string, degreesDigits int) (float64, error) {
+	sign := 1.0
+	if value[0] == '-' {
+		sign = -1
+	}
+
+	digits := value[1:]
+	if len(digits) != degreesDigits+2 && len(digits) != degreesDigits+4 {
+		return 0, errors.Errorf("'%s' is not a sexagesimal coordinate", value)
+	}
+
+	parts := []string{digits[:degreesDigits], digits[degreesDigits : degreesDigits+2], digits[degreesDigits+2:]}
+	var values [3]float64
+	for i, part := range parts {
+		if part == "" {
+			continue
+		}
+
+		number, err := strconv.Atoi(part)
+		if err != nil {
+			return 0, errors.Wrapf(err, "'%s' is not a sexagesimal coordinate", value)
+		}
+		values[i] = float64(number)
+	}
+
+	return sign * (values[0] + values[1]/60 + values[2]/3600), nil
+}
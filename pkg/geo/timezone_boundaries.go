@@ -0,0 +1,183 @@
+package geo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/pkg/errors"
+	"io"
+	"math"
+)
+
+//go:generate go run ./internal/tzboundarygen -output timezones.bin
+
+const (
+	// boundariesPrecision is the number of steps in a degree: coordinates are stored as integers, rounded to ~10 meters.
+	boundariesPrecision = 10_000
+)
+
+var (
+	boundariesMagicHeader = []byte("DPTZ1") // boundariesMagicHeader starts the content encoded by EncodeTimeZonePolygons
+)
+
+// TimeZonePolygon is an area of a time zone: the first ring is its outer boundary, the following ones are holes.
+// The points of the rings are [latitude, longitude] ; a ring doesn't need to repeat its first point at the end.
+type TimeZonePolygon struct {
+	Zone  string
+	Rings [][][2]float64
+}
+
+// timeZoneBoundaries is the decoded content ; polygons have a bounding box to only test the few surrounding the location.
+type timeZoneBoundaries struct {
+	zones    []string
+	polygons []boundaryPolygon
+}
+
+type boundaryPolygon struct {
+	zone           int
+	minLat, maxLat float64
+	minLon, maxLon float64
+	rings          [][][2]float64
+}
+
+// EncodeTimeZonePolygons writes the polygons in the compact format embedded in the binary: the name of each zone once, then each polygon with
+// the index of its zone, and the points of its rings as variable-length deltas from the previous point.
+func EncodeTimeZonePolygons(polygons []TimeZonePolygon) []byte {
+	var zones []string
+	zoneIndexes := make(map[string]int)
+	for _, polygon := range polygons {
+		if _, known := zoneIndexes[polygon.Zone]; !known {
+			zoneIndexes[polygon.Zone] = len(zones)
+			zones = append(zones, polygon.Zone)
+		}
+	}
+
+	content := bytes.NewBuffer(append([]byte{}, boundariesMagicHeader...))
+	writeUvarint(content, uint64(len(zones)))
+	for _, zone := range zones {
+		writeUvarint(content, uint64(len(zone)))
+		content.WriteString(zone)
+	}
+
+	writeUvarint(content, uint64(len(polygons)))
+	for _, polygon := range polygons {
+		writeUvarint(content, uint64(zoneIndexes[polygon.Zone]))
+		writeUvarint(content, uint64(len(polygon.Rings)))
+		for _, ring := range polygon.Rings {
+			writeUvarint(content, uint64(len(ring)))
+			var previousLat, previousLon int64
+			for _, point := range ring {
+				lat, lon := int64(math.Round(point[0]*boundariesPrecision)), int64(math.Round(point[1]*boundariesPrecision))
+				writeVarint(content, lat-previousLat)
+				writeVarint(content, lon-previousLon)
+				previousLat, previousLon = lat, lon
+			}
+		}
+	}
+
+	return content.Bytes()
+}
+
+func decodeTimeZoneBoundaries(content []byte) (*timeZoneBoundaries, error) {
+	if !bytes.HasPrefix(content, boundariesMagicHeader) {
+		return nil, errors.Errorf("time zone boundaries must start with '%s'", boundariesMagicHeader)
+	}
+	reader := bytes.NewReader(content[len(boundariesMagicHeader):])
+
+	boundaries := new(timeZoneBoundaries)
+	zonesCount, err := binary.ReadUvarint(reader)
+	for i := uint64(0); err == nil && i < zonesCount; i++ {
+		var length uint64
+		length, err = binary.ReadUvarint(reader)
+		if err == nil {
+			name := make([]byte, length)
+			_, err = io.ReadFull(reader, name)
+			boundaries.zones = append(boundaries.zones, string(name))
+		}
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid zones in time zone boundaries")
+	}
+
+	polygonsCount, err := binary.ReadUvarint(reader)
+	for i := uint64(0); err == nil && i < polygonsCount; i++ {
+		var polygon boundaryPolygon
+		polygon, err = decodeBoundaryPolygon(reader, len(boundaries.zones))
+		boundaries.polygons = append(boundaries.polygons, polygon)
+	}
+	return boundaries, errors.Wrapf(err, "invalid polygons in time zone boundaries")
+}
+
+func decodeBoundaryPolygon(reader *bytes.Reader, zonesCount int) (boundaryPolygon, error) {
+	polygon := boundaryPolygon{minLat: math.MaxFloat64, maxLat: -math.MaxFloat64, minLon: math.MaxFloat64, maxLon: -math.MaxFloat64}
+
+	zone, err := binary.ReadUvarint(reader)
+	if err != nil {
+		return polygon, err
+	}
+	if zone >= uint64(zonesCount) {
+		return polygon, errors.Errorf("zone %d doesn't exist", zone)
+	}
+	polygon.zone = int(zone)
+
+	ringsCount, err := binary.ReadUvarint(reader)
+	for i := uint64(0); err == nil && i < ringsCount; i++ {
+		var pointsCount uint64
+		pointsCount, err = binary.ReadUvarint(reader)
+
+		var lat, lon int64
+		var ring [][2]float64
+		for j := uint64(0); err == nil && j < pointsCount; j++ {
+			var deltaLat, deltaLon int64
+			deltaLat, err = binary.ReadVarint(reader)
+			if err == nil {
+				deltaLon, err = binary.ReadVarint(reader)
+			}
+
+			lat, lon = lat+deltaLat, lon+deltaLon
+			point := [2]float64{float64(lat) / boundariesPrecision, float64(lon) / boundariesPrecision}
+			ring = append(ring, point)
+
+			if i == 0 {
+				polygon.minLat, polygon.maxLat = math.Min(polygon.minLat, point[0]), math.Max(polygon.maxLat, point[0])
+				polygon.minLon, polygon.maxLon = math.Min(polygon.minLon, point[1]), math.Max(polygon.maxLon, point[1])
+			}
+		}
+		polygon.rings = append(polygon.rings, ring)
+	}
+
+	return polygon, err
+}
+
+// zoneAt returns the zone of the first polygon containing the location, or an empty string when none does (at sea).
+func (b *timeZoneBoundaries) zoneAt(latitude, longitude float64) string {
+	for _, polygon := range b.polygons {
+		if latitude >= polygon.minLat && latitude <= polygon.maxLat && longitude >= polygon.minLon && longitude <= polygon.maxLon && polygon.contains(latitude, longitude) {
+			return b.zones[polygon.zone]
+		}
+	}
+
+	return ""
+}
+
+// contains uses the even-odd rule: a location inside a hole crosses the outer ring and the ring of the hole.
+func (p *boundaryPolygon) contains(latitude, longitude float64) bool {
+	inside := false
+	for _, ring := range p.rings {
+		for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+			if (ring[i][0] > latitude) != (ring[j][0] > latitude) &&
+				longitude < (ring[j][1]-ring[i][1])*(latitude-ring[i][0])/(ring[j][0]-ring[i][0])+ring[i][1] {
+				inside = !inside
+			}
+		}
+	}
+
+	return inside
+}
+
+func writeUvarint(content *bytes.Buffer, value uint64) {
+	content.Write(binary.AppendUvarint(nil, value))
+}
+
+func writeVarint(content *bytes.Buffer, value int64) {
+	content.Write(binary.AppendVarint(nil, value))
+}
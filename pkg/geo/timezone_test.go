@@ -0,0 +1,130 @@
+package geo
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTimeZoneAt(t *testing.T) {
+	tests := []struct {
+		name      string
+		latitude  float64
+		longitude float64
+		want      string
+		wantErr   assert.ErrorAssertionFunc
+	}{
+		{"it should find the zone of London", 51.50363, -0.11583, "Europe/London", assert.NoError},
+		{"it should find the zone of a village in the Alps", 45.9237, 6.8694, "Europe/Paris", assert.NoError},
+		{"it should find the zone of Lyon rather than the closer Zurich", 45.7640, 4.8357, "Europe/Paris", assert.NoError},
+		{"it should find the zone of Sydney", -33.8568, 151.2153, "Australia/Sydney", assert.NoError},
+		{"it should find the zone of Denver", 39.7392, -104.9903, "America/Denver", assert.NoError},
+		{"it should find the zone of Hawaii", 19.8968, -155.5828, "Pacific/Honolulu", assert.NoError},
+		{"it should use the nautical zone in the middle of the Indian ocean", -30, 80, "Etc/GMT-5", assert.NoError},
+		{"it should use the nautical zone in the south Atlantic", -45, -20, "Etc/GMT+1", assert.NoError},
+		{"it should reject invalid coordinates", 91, 0, "", assert.Error},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := TimeZoneAt(tt.latitude, tt.longitude)
+			if tt.wantErr(t, err) && err == nil {
+				assert.Equal(t, tt.want, got.String())
+			}
+		})
+	}
+}
+
+func TestTimeZoneAt_borders(t *testing.T) {
+	boundaries, err := loadZoneBoundaries()
+	if !assert.NoError(t, err) || !assert.NotEmpty(t, boundaries.polygons, "timezones.bin doesn't have any boundary: run 'go generate ./pkg/geo' to download them") {
+		return
+	}
+
+	tests := []struct {
+		name      string
+		latitude  float64
+		longitude float64
+		want      string
+	}{
+		{"it should find the zone of Amarillo, closer to Denver than to Chicago", 35.22, -101.83, "America/Chicago"},
+		{"it should find the zone of El Paso, in Texas but on Mountain time", 31.7619, -106.4850, "America/Denver"},
+		{"it should find the zone of Coeur d'Alene, in Idaho but on Pacific time", 47.6777, -116.7805, "America/Los_Angeles"},
+		{"it should find the zone of Tijuana, next to San Diego", 32.5149, -117.0382, "America/Tijuana"},
+		{"it should find the zone of Geneva", 46.2044, 6.1432, "Europe/Zurich"},
+		{"it should find the zone of Annemasse, next to Geneva", 46.1934, 6.2342, "Europe/Paris"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := TimeZoneAt(tt.latitude, tt.longitude)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.want, got.String())
+			}
+		})
+	}
+}
+
+func TestTimeZoneBoundaries(t *testing.T) {
+	// synthetic boundaries: a western zone with an enclave, and an eastern one made of two polygons
+	content := EncodeTimeZonePolygons([]TimeZonePolygon{
+		{Zone: "America/Denver", Rings: [][][2]float64{{{30, -110}, {40, -110}, {40, -101.85}, {30, -101.85}}, {{34, -108}, {36, -108}, {36, -106}, {34, -106}}}},
+		{Zone: "America/Phoenix", Rings: [][][2]float64{{{34, -108}, {36, -108}, {36, -106}, {34, -106}}}},
+		{Zone: "America/Chicago", Rings: [][][2]float64{{{30, -101.85}, {40, -101.85}, {40, -95}, {30, -95}}}},
+		{Zone: "America/Chicago", Rings: [][][2]float64{{{25, -90.12345}, {26, -90.12345}, {25.5, -89}}}},
+	})
+
+	boundaries, err := decodeTimeZoneBoundaries(content)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []string{"America/Denver", "America/Phoenix", "America/Chicago"}, boundaries.zones)
+	assert.Equal(t, [2]float64{25, -90.1235}, boundaries.polygons[3].rings[0][0], "it should round the coordinates to 4 decimals")
+
+	tests := []struct {
+		name      string
+		latitude  float64
+		longitude float64
+		want      string
+	}{
+		{"it should find the zone of the polygon containing the location", 35.22, -101.83, "America/Chicago"},
+		{"it should find the zone on the other side of the border", 35.22, -101.87, "America/Denver"},
+		{"it should not use the zone of a polygon when the location is in one of its holes", 35, -107, "America/Phoenix"},
+		{"it should find the zone of the second polygon of a zone", 25.5, -89.5, "America/Chicago"},
+		{"it should not find any zone outside the polygons", 45, -100, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, boundaries.zoneAt(tt.latitude, tt.longitude))
+		})
+	}
+
+	_, err = decodeTimeZoneBoundaries(content[:len(content)-3])
+	assert.Error(t, err, "it should reject a truncated content")
+}
+
+func Test_parseISO6709(t *testing.T) {
+	tests := []struct {
+		coordinates   string
+		wantLatitude  float64
+		wantLongitude float64
+	}{
+		{"+4230+00131", 42.5, 1 + 31.0/60},
+		{"-7750+16636", -(77 + 50.0/60), 166 + 36.0/60},
+		{"+404251-0740023", 40 + 42.0/60 + 51.0/3600, -(74 + 23.0/3600)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.coordinates, func(t *testing.T) {
+			latitude, longitude, err := parseISO6709(tt.coordinates)
+			if assert.NoError(t, err) {
+				assert.InDelta(t, tt.wantLatitude, latitude, 1e-9)
+				assert.InDelta(t, tt.wantLongitude, longitude, 1e-9)
+			}
+		})
+	}
+}
+
+func TestDistanceKm(t *testing.T) {
+	assert.InDelta(t, 343.5, DistanceKm(51.5074, -0.1278, 48.8566, 2.3522), 1, "London to Paris")
+}
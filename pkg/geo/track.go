@@ -0,0 +1,160 @@
+package geo
+
+import (
+	"encoding/xml"
+	"github.com/pkg/errors"
+	"io"
+	"os"
+	"sort"
+	"time"
+)
+
+const (
+	// DefaultTrackMaxGap is the default maximum time between a media and the points of the track used to locate it.
+	DefaultTrackMaxGap = 5 * time.Minute
+)
+
+// TrackPoint is a position recorded by a GPS logger.
+type TrackPoint struct {
+	Time      time.Time
+	Latitude  float64
+	Longitude float64
+}
+
+// Track is a list of points sorted by time ; several GPX files can be merged into a single Track.
+type Track struct {
+	Points []TrackPoint
+}
+
+// Geotagger locates medias from their capture time on recorded tracks.
+type Geotagger struct {
+	Track             *Track
+	MaxGap            time.Duration // MaxGap is the maximum time between the media and the points of the track ; default to DefaultTrackMaxGap
+	CameraClockOffset time.Duration // CameraClockOffset is how much the clock of the camera is ahead of the GPS logger (negative when it's late)
+}
+
+type gpxDocument struct {
+	Tracks []struct {
+		Segments []struct {
+			Points []gpxPoint `xml:"trkpt"`
+		} `xml:"trkseg"`
+	} `xml:"trk"`
+}
+
+type gpxPoint struct {
+	Latitude  float64 `xml:"lat,attr"`
+	Longitude float64 `xml:"lon,attr"`
+	Time      string  `xml:"time"`
+}
+
+// ReadGPX reads the points of all the tracks of a GPX file ; points without time are ignored.
+func ReadGPX(reader io.Reader) (*Track, error) {
+	var document gpxDocument
+	err := xml.NewDecoder(reader).Decode(&document)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid GPX content")
+	}
+
+	track := new(Track)
+	for _, trk := range document.Tracks {
+		for _, segment := range trk.Segments {
+			for _, point := range segment.Points {
+				if point.Time == "" {
+					continue
+				}
+
+				pointTime, err := time.Parse(time.RFC3339Nano, point.Time)
+				if err != nil {
+					return nil, errors.Wrapf(err, "invalid time in GPX point [%f, %f]", point.Latitude, point.Longitude)
+				}
+
+				track.Points = append(track.Points, TrackPoint{
+					Time:      pointTime,
+					Latitude:  point.Latitude,
+					Longitude: point.Longitude,
+				})
+			}
+		}
+	}
+
+	track.sort()
+	return track, nil
+}
+
+// ReadGPXFiles reads and merges the tracks of each GPX file.
+func ReadGPXFiles(files ...string) (*Track, error) {
+	merged := new(Track)
+	for _, file := range files {
+		track, err := readGPXFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		merged.Points = append(merged.Points, track.Points...)
+	}
+
+	merged.sort()
+	return merged, nil
+}
+
+func readGPXFile(file string) (*Track, error) {
+	reader, err := os.Open(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open GPX file %s", file)
+	}
+	defer reader.Close()
+
+	track, err := ReadGPX(reader)
+	return track, errors.Wrapf(err, "failed to read GPX file %s", file)
+}
+
+func (t *Track) sort() {
+	sort.SliceStable(t.Points, func(i, j int) bool {
+		return t.Points[i].Time.Before(t.Points[j].Time)
+	})
+}
+
+// LocationAt interpolates the position at the instant between the two points surrounding it when they are both within maxGap ;
+// otherwise the nearest point within maxGap is used. Found is false when no point is close enough.
+func (t *Track) LocationAt(instant time.Time, maxGap time.Duration) (latitude, longitude float64, found bool) {
+	next := sort.Search(len(t.Points), func(i int) bool {
+		return !t.Points[i].Time.Before(instant)
+	})
+
+	var before, after *TrackPoint
+	if next > 0 && instant.Sub(t.Points[next-1].Time) <= maxGap {
+		before = &t.Points[next-1]
+	}
+	if next < len(t.Points) && t.Points[next].Time.Sub(instant) <= maxGap {
+		after = &t.Points[next]
+	}
+
+	switch {
+	case before != nil && after != nil:
+		span := after.Time.Sub(before.Time)
+		if span == 0 {
+			return after.Latitude, after.Longitude, true
+		}
+		ratio := float64(instant.Sub(before.Time)) / float64(span)
+		return before.Latitude + (after.Latitude-before.Latitude)*ratio, before.Longitude + (after.Longitude-before.Longitude)*ratio, true
+
+	case after != nil:
+		return after.Latitude, after.Longitude, true
+
+	case before != nil:
+		return before.Latitude, before.Longitude, true
+
+	default:
+		return 0, 0, false
+	}
+}
+
+// LocationAt returns the position on the track of a media captured when the clock of the camera was displaying cameraTime.
+func (g *Geotagger) LocationAt(cameraTime time.Time) (latitude, longitude float64, found bool) {
+	maxGap := g.MaxGap
+	if maxGap <= 0 {
+		maxGap = DefaultTrackMaxGap
+	}
+
+	return g.Track.LocationAt(cameraTime.Add(-g.CameraClockOffset), maxGap)
+}
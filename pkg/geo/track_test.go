@@ -0,0 +1,86 @@
+package geo
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sampleGPX = `<?xml version="1.0" encoding="UTF-8"?>
+<gpx version="1.1" creator="test" xmlns="http://www.topografix.com/GPX/1/1">
+  <trk>
+    <name>Hike</name>
+    <trkseg>
+      <trkpt lat="45.9000" lon="6.8000"><ele>1035</ele><time>2023-07-14T10:00:00Z</time></trkpt>
+      <trkpt lat="45.9100" lon="6.8200"><ele>1100</ele><time>2023-07-14T10:02:00Z</time></trkpt>
+      <trkpt lat="45.9500" lon="6.9000"><time>2023-07-14T10:30:00Z</time></trkpt>
+      <trkpt lat="46.0000" lon="7.0000"></trkpt>
+    </trkseg>
+  </trk>
+</gpx>`
+
+func TestReadGPX(t *testing.T) {
+	track, err := ReadGPX(strings.NewReader(sampleGPX))
+	if assert.NoError(t, err) {
+		assert.Equal(t, []TrackPoint{
+			{Time: time.Date(2023, 7, 14, 10, 0, 0, 0, time.UTC), Latitude: 45.9, Longitude: 6.8},
+			{Time: time.Date(2023, 7, 14, 10, 2, 0, 0, time.UTC), Latitude: 45.91, Longitude: 6.82},
+			{Time: time.Date(2023, 7, 14, 10, 30, 0, 0, time.UTC), Latitude: 45.95, Longitude: 6.9},
+		}, track.Points, "it should read the points with a time")
+	}
+
+	_, err = ReadGPX(strings.NewReader("<gpx><trk><trkseg><trkpt lat=\"1\" lon=\"2\"><time>yesterday</time></trkpt></trkseg></trk></gpx>"))
+	assert.Error(t, err, "it should reject invalid times")
+}
+
+func TestTrack_LocationAt(t *testing.T) {
+	track, err := ReadGPX(strings.NewReader(sampleGPX))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	tests := []struct {
+		name          string
+		instant       time.Time
+		wantLatitude  float64
+		wantLongitude float64
+		wantFound     bool
+	}{
+		{"it should use the point recorded at the same time", time.Date(2023, 7, 14, 10, 2, 0, 0, time.UTC), 45.91, 6.82, true},
+		{"it should interpolate between two close points", time.Date(2023, 7, 14, 10, 1, 30, 0, time.UTC), 45.9075, 6.815, true},
+		{"it should interpolate in the time zone of the instant", time.Date(2023, 7, 14, 12, 1, 30, 0, time.FixedZone("", 7200)), 45.9075, 6.815, true},
+		{"it should use the closest point when the other is too far", time.Date(2023, 7, 14, 10, 5, 0, 0, time.UTC), 45.91, 6.82, true},
+		{"it should use the first point shortly before the track", time.Date(2023, 7, 14, 9, 56, 0, 0, time.UTC), 45.9, 6.8, true},
+		{"it should use the last point shortly after the track", time.Date(2023, 7, 14, 10, 34, 0, 0, time.UTC), 45.95, 6.9, true},
+		{"it should not locate medias in a gap of the track", time.Date(2023, 7, 14, 10, 15, 0, 0, time.UTC), 0, 0, false},
+		{"it should not locate medias long after the track", time.Date(2023, 7, 14, 11, 0, 0, 0, time.UTC), 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			latitude, longitude, found := track.LocationAt(tt.instant, DefaultTrackMaxGap)
+			assert.Equal(t, tt.wantFound, found)
+			assert.InDelta(t, tt.wantLatitude, latitude, 1e-9)
+			assert.InDelta(t, tt.wantLongitude, longitude, 1e-9)
+		})
+	}
+}
+
+func TestGeotagger_LocationAt(t *testing.T) {
+	track, err := ReadGPX(strings.NewReader(sampleGPX))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	geotagger := &Geotagger{Track: track, CameraClockOffset: 3 * time.Minute}
+	latitude, longitude, found := geotagger.LocationAt(time.Date(2023, 7, 14, 10, 5, 0, 0, time.UTC))
+	if assert.True(t, found, "it should remove the offset of the camera clock") {
+		assert.InDelta(t, 45.91, latitude, 1e-9)
+		assert.InDelta(t, 6.82, longitude, 1e-9)
+	}
+
+	geotagger = &Geotagger{Track: track, MaxGap: 30 * time.Second}
+	_, _, found = geotagger.LocationAt(time.Date(2023, 7, 14, 10, 3, 0, 0, time.UTC))
+	assert.False(t, found, "it should use the requested maximum gap")
+}
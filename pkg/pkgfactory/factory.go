@@ -2,7 +2,9 @@ package pkgfactory
 
 import (
 	"context"
+	"github.com/pkg/errors"
 	"github.com/thomasduchatelle/dphoto/pkg/archive"
+	"github.com/thomasduchatelle/dphoto/pkg/archiveadapters/s3store"
 	"github.com/thomasduchatelle/dphoto/pkg/awssupport/awsfactory"
 	"github.com/thomasduchatelle/dphoto/pkg/singletons"
 )
@@ -39,11 +41,17 @@ type AWSCloud struct {
 	awsfactory.AWSFactory
 	ArchiveFactory
 	*SimpleCatalogFactory
-	Names AWSAdapterNames
+	Names            AWSAdapterNames
+	ArchiveReplicas  []ArchiveReplicaConfig   // ArchiveReplicas are secondary storages where originals are replicated (optional)
+	ArchiveMasterKey []byte                   // ArchiveMasterKey enables client-side encryption of originals when set (optional)
+	ArchiveMultipart s3store.MultipartOptions // ArchiveMultipart configures how large originals are uploaded on the main storage (optional)
 }
 
 type AWSCloudBuilder struct {
 	advancedAsyncFeatures bool
+	archiveReplicas       []ArchiveReplicaConfig
+	archiveMasterKey      []byte
+	archiveMultipart      s3store.MultipartOptions
 	names                 AWSAdapterNames
 	awsFactory            awsfactory.AWSFactory
 	err                   []error
@@ -72,6 +80,27 @@ func (a *AWSCloudBuilder) WithAdvancedAWSAsyncFeatures() *AWSCloudBuilder {
 	return a
 }
 
+// WithArchiveReplicas replicates originals on secondary storages (3-2-1 backup strategy)
+func (a *AWSCloudBuilder) WithArchiveReplicas(replicas ...ArchiveReplicaConfig) *AWSCloudBuilder {
+	a.archiveReplicas = append(a.archiveReplicas, replicas...)
+	return a
+}
+
+// WithArchiveEncryption encrypts originals with a data key per owner, itself wrapped by the master key (must be 32 bytes long)
+func (a *AWSCloudBuilder) WithArchiveEncryption(masterKey []byte) *AWSCloudBuilder {
+	if len(masterKey) > 0 && len(masterKey) != archive.MasterKeySize {
+		a.err = append(a.err, errors.Errorf("archive master key must be %d bytes long, got %d", archive.MasterKeySize, len(masterKey)))
+	}
+	a.archiveMasterKey = masterKey
+	return a
+}
+
+// WithArchiveMultipartUploads configures the part size and the parallelism of uploads on the main storage ; States must be persisted to resume an upload when the backup is retried
+func (a *AWSCloudBuilder) WithArchiveMultipartUploads(options s3store.MultipartOptions) *AWSCloudBuilder {
+	a.archiveMultipart = options
+	return a
+}
+
 // Build creates the application factory ; and set legacy global variables
 func (a *AWSCloudBuilder) Build(ctx context.Context) (*AWSCloud, error) {
 	if len(a.err) > 0 {
@@ -93,7 +122,10 @@ func (a *AWSCloudBuilder) Build(ctx context.Context) (*AWSCloud, error) {
 		SimpleCatalogFactory: &SimpleCatalogFactory{
 			ArchiveAdapterForCatalog: new(SyncArchiveAdapterForCatalog),
 		},
-		Names: a.names,
+		Names:            a.names,
+		ArchiveReplicas:  a.archiveReplicas,
+		ArchiveMasterKey: a.archiveMasterKey,
+		ArchiveMultipart: a.archiveMultipart,
 	}
 
 	if a.advancedAsyncFeatures {
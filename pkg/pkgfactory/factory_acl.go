@@ -47,3 +47,10 @@ func AclCatalogAuthoriser(ctx context.Context) *catalogacl.CatalogAuthorizer {
 		CatalogQueriesPort: CatalogMediaQueries(ctx),
 	}
 }
+
+func AclMapAlbums(ctx context.Context) *catalogacl.MapAlbums {
+	return &catalogacl.MapAlbums{
+		HasPermissionPort:     AclQueries(ctx),
+		FindAlbumsByOwnerPort: AlbumQueries(ctx),
+	}
+}
@@ -2,18 +2,25 @@ package pkgfactory
 
 import (
 	"context"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"github.com/thomasduchatelle/dphoto/pkg/archive"
 	"github.com/thomasduchatelle/dphoto/pkg/archiveadapters/archivedynamo"
 	"github.com/thomasduchatelle/dphoto/pkg/archiveadapters/asyncjobadapter"
+	"github.com/thomasduchatelle/dphoto/pkg/archiveadapters/localstore"
 	"github.com/thomasduchatelle/dphoto/pkg/archiveadapters/s3store"
+	"github.com/thomasduchatelle/dphoto/pkg/archiveconsistency"
+	"github.com/thomasduchatelle/dphoto/pkg/archiverestore"
 	"github.com/thomasduchatelle/dphoto/pkg/singletons"
+	"time"
 )
 
 func (a *AWSCloud) InitArchive(ctx context.Context) {
 	singletons.MustSingletonKey("InitArchive", func() (interface{}, error) {
 		repositoryAdapter := archivedynamo.Must(archivedynamo.New(AWSFactory(ctx).GetDynamoDBClient(), AWSNames.DynamoDBName()))
-		storeAdapter := s3store.NewWithS3Client(AWSFactory(ctx).GetS3Client(), AWSNames.ArchiveMainBucketName())
+		storeAdapter := ArchiveStore(ctx)
 		cacheAdapter := s3store.NewWithS3Client(AWSFactory(ctx).GetS3Client(), AWSNames.ArchiveCacheBucketName())
 		archiveAsyncAdapter := a.ArchiveFactory.ArchiveAsyncJobAdapter(ctx)
 		archive.Init(
@@ -22,11 +29,122 @@ func (a *AWSCloud) InitArchive(ctx context.Context) {
 			cacheAdapter,
 			archiveAsyncAdapter,
 		)
+		archive.InitTiering(
+			archivedynamo.NewTieringRepository(AWSFactory(ctx).GetDynamoDBClient(), AWSNames.DynamoDBName()),
+			s3store.NewTieringStore(AWSFactory(ctx).GetS3Client(), AWSNames.ArchiveMainBucketName()),
+		)
 
 		return new(interface{}), nil
 	})
 }
 
+// ArchiveStore is the main storage of originals, decorated to replicate them when ArchiveReplicas are configured, and to encrypt them when ArchiveMasterKey is set.
+func ArchiveStore(ctx context.Context) archive.StoreAdapter {
+	var store archive.StoreAdapter = archiveMainStore(ctx)
+	if len(factory.ArchiveReplicas) > 0 {
+		store = ArchiveReplicatedStore(ctx)
+	}
+
+	if len(factory.ArchiveMasterKey) == 0 {
+		return store
+	}
+
+	return singletons.MustSingletonKey("ArchiveEncryptedStore", func() (*archive.EncryptedStore, error) {
+		return archive.NewEncryptedStore(
+			store,
+			archivedynamo.NewDataKeyRepository(AWSFactory(ctx).GetDynamoDBClient(), AWSNames.DynamoDBName()),
+			factory.ArchiveMasterKey,
+		)
+	})
+}
+
+// ArchiveReplicatedStore returns the main storage decorated to replicate originals on each ArchiveReplicas ; it panics if a replica is misconfigured.
+func ArchiveReplicatedStore(ctx context.Context) *archive.ReplicatedStore {
+	return singletons.MustSingletonKey("ArchiveReplicatedStore", func() (*archive.ReplicatedStore, error) {
+		var replicas []archive.Replica
+		for _, replicaConfig := range factory.ArchiveReplicas {
+			replica, err := replicaConfig.newReplica(AWSFactory(ctx).GetCfg())
+			if err != nil {
+				return nil, err
+			}
+
+			replicas = append(replicas, replica)
+		}
+
+		return archive.NewReplicatedStore(
+			archiveMainStore(ctx),
+			archivedynamo.NewReplicaRepository(AWSFactory(ctx).GetDynamoDBClient(), AWSNames.DynamoDBName()),
+			replicas...,
+		), nil
+	})
+}
+
+func archiveMainStore(ctx context.Context) archive.StoreAdapter {
+	return s3store.NewWithS3Client(AWSFactory(ctx).GetS3Client(), AWSNames.ArchiveMainBucketName(), s3store.WithMultipart(factory.ArchiveMultipart))
+}
+
+// ArchiveTieringPolicy moves the originals of albums older than coldAfter to the storageClass of the main storage.
+func ArchiveTieringPolicy(ctx context.Context, coldAfter time.Duration, storageClass string, dry bool) *archive.TieringPolicy {
+	factory.InitArchive(ctx)
+
+	return &archive.TieringPolicy{
+		ColdAfter:        coldAfter,
+		ColdStorageClass: storageClass,
+		DryRun:           dry,
+	}
+}
+
+// ArchiveMainStoreWalker lists the keys of the main storage, used to catch-up replication.
+func ArchiveMainStoreWalker(ctx context.Context) archive.StoreKeysWalker {
+	return s3store.NewWithS3Client(AWSFactory(ctx).GetS3Client(), AWSNames.ArchiveMainBucketName())
+}
+
+// ArchiveReplicaConfig describes a secondary storage where originals are replicated: a local directory, or an S3 bucket.
+type ArchiveReplicaConfig struct {
+	Name       string // Name is used to record the replication status, it must not be changed
+	Directory  string // Directory is used for a local (or mounted) replica
+	BucketName string // BucketName is used for an S3 replica
+	Endpoint   string // Endpoint is optional and overrides the S3 endpoint (other region, S3 compatible storage, ...)
+	Region     string // Region is optional and overrides the region of the main storage
+}
+
+func (c ArchiveReplicaConfig) newReplica(cfg aws.Config) (archive.Replica, error) {
+	switch {
+	case c.Name == "":
+		return archive.Replica{}, errors.Errorf("replica name is mandatory: %+v", c)
+
+	case c.Directory != "":
+		store, err := localstore.New(c.Directory)
+		return archive.Replica{Name: c.Name, Adapter: store}, err
+
+	case c.BucketName != "":
+		client := s3.NewFromConfig(cfg, func(options *s3.Options) {
+			if c.Endpoint != "" {
+				options.BaseEndpoint = aws.String(c.Endpoint)
+				options.UsePathStyle = true
+			}
+			if c.Region != "" {
+				options.Region = c.Region
+			}
+		})
+		return archive.Replica{Name: c.Name, Adapter: s3store.NewReplica(client, c.BucketName)}, nil
+
+	default:
+		return archive.Replica{}, errors.Errorf("replica %s must have either a directory or a bucket name", c.Name)
+	}
+}
+
+// ArchiveRestorer downloads originals from the main storage, decrypted when the encryption is enabled.
+func ArchiveRestorer(ctx context.Context, observers ...archiverestore.RestoreObserver) *archiverestore.Restorer {
+	return &archiverestore.Restorer{
+		FindAlbumsByOwnerPort: AlbumQueries(ctx),
+		ListMediasPort:        CatalogMediaQueries(ctx),
+		FindLocationsPort:     archivedynamo.Must(archivedynamo.New(AWSFactory(ctx).GetDynamoDBClient(), AWSNames.DynamoDBName())),
+		DownloadPort:          ArchiveStore(ctx),
+		Observers:             observers,
+	}
+}
+
 type SyncArchiveFactory struct{}
 
 func (a *SyncArchiveFactory) ArchiveAsyncJobAdapter(ctx context.Context) archive.AsyncJobAdapter {
@@ -44,3 +162,22 @@ func (a *AsyncArchiveFactory) ArchiveAsyncJobAdapter(ctx context.Context) archiv
 		return asyncjobadapter.NewFromClients(AWSFactory(ctx).GetSNSClient(), AWSFactory(ctx).GetSQSClient(), AWSNames.ArchiveJobsSNSARN(), AWSNames.ArchiveJobsSQSURL(), asyncjobadapter.DefaultImagesPerMessage), nil
 	})
 }
+
+func ArchiveConsistencyReconciler(ctx context.Context, dry bool, confirmation archiveconsistency.ConfirmDeletionPort, options ...archiveconsistency.DriftOption) *archiveconsistency.OwnerConsistencyReconciler {
+	repositoryAdapter := archivedynamo.Must(archivedynamo.New(AWSFactory(ctx).GetDynamoDBClient(), AWSNames.DynamoDBName()))
+	storeAdapter := ArchiveStore(ctx)
+	storeWalker := ArchiveMainStoreWalker(ctx)
+	relocator := factory.ArchiveAdapterForCatalog.ArchiveTimelineMutationObserver(ctx)
+
+	drifts := make([]archiveconsistency.DriftOption, len(options)+1)
+	copy(drifts, options)
+	drifts[len(options)] = archiveconsistency.DriftOptionDryMode(dry, relocator, storeAdapter, confirmation)
+
+	return archiveconsistency.NewConsistencyReconciler(
+		AlbumQueries(ctx),
+		CatalogMediaQueries(ctx),
+		repositoryAdapter,
+		storeWalker,
+		drifts...,
+	)
+}
@@ -9,11 +9,14 @@ import (
 	"github.com/thomasduchatelle/dphoto/pkg/backupadapters/backuparchive"
 	"github.com/thomasduchatelle/dphoto/pkg/backupadapters/backupcatalog"
 	"github.com/thomasduchatelle/dphoto/pkg/catalog"
+	"github.com/thomasduchatelle/dphoto/pkg/geo"
 	"github.com/thomasduchatelle/dphoto/pkg/ownermodel"
 )
 
 type MultiFilesBackup func(ctx context.Context, owner ownermodel.Owner, volumeSource backup.SourceVolume, optionsSlice ...backup.Options) (backup.Report, error)
 
+type PlanBackup func(ctx context.Context, owner ownermodel.Owner, volumeSource backup.SourceVolume, plan *backup.Plan, optionsSlice ...backup.Options) (backup.Report, error)
+
 type MultiFilesScanner func(ctx context.Context, owner string, volume backup.SourceVolume, optionSlice ...backup.Options) ([]*backup.ScannedFolder, error)
 
 func NewMultiFilesBackup(ctx context.Context) MultiFilesBackup {
@@ -27,12 +30,35 @@ func NewMultiFilesBackup(ctx context.Context) MultiFilesBackup {
 			DetailsReaders:  analysers.ListDetailReaders(),
 			InsertMediaPort: NewInsertMediaAdapter(ctx),
 			ArchivePort:     backuparchive.New(),
+			TimeZoneFinder:  new(geo.TimeZoneFinder),
 		}
 
 		return batch.Backup(ctx, owner, volume, backupDefaultOptionsForAWS(optionsSlice)...)
 	}
 }
 
+// NewPlanBackup executes a plan recorded during a scan ; it fails without uploading anything if the plan is outdated.
+func NewPlanBackup(ctx context.Context) PlanBackup {
+	factory.InitArchive(ctx)
+
+	return func(ctx context.Context, owner ownermodel.Owner, volume backup.SourceVolume, plan *backup.Plan, optionsSlice ...backup.Options) (backup.Report, error) {
+		planBackup := &backup.PlanBackup{
+			BatchBackup: backup.BatchBackup{
+				CataloguerFactory: &AlbumCreatorCataloguerFactory{
+					archiveAdapterForCatalog: factory.SimpleCatalogFactory.ArchiveAdapterForCatalog,
+				},
+				DetailsReaders:  analysers.ListDetailReaders(),
+				InsertMediaPort: NewInsertMediaAdapter(ctx),
+				ArchivePort:     backuparchive.New(),
+				TimeZoneFinder:  new(geo.TimeZoneFinder),
+			},
+			DryRunCataloguerFactory: new(DryRunCataloguerFactory),
+		}
+
+		return planBackup.Backup(ctx, owner, volume, plan, backupDefaultOptionsForAWS(optionsSlice)...)
+	}
+}
+
 type AlbumCreatorCataloguerFactory struct {
 	archiveAdapterForCatalog ArchiveAdapterForCatalog
 }
@@ -88,6 +114,8 @@ func NewMultiFilesScanner(ctx context.Context) MultiFilesScanner {
 		batchScanner := &backup.BatchScanner{
 			CataloguerFactory: new(DryRunCataloguerFactory),
 			DetailsReaders:    analysers.ListDetailReaders(),
+			TimeZoneFinder:    new(geo.TimeZoneFinder),
+			PlaceFinder:       new(geo.PlaceFinder),
 		}
 		return batchScanner.Scan(ctx, ownermodel.Owner(owner), volume, backupDefaultOptionsForAWS(optionSlice)...)
 	}
@@ -7,6 +7,7 @@ import (
 	"github.com/thomasduchatelle/dphoto/pkg/catalogadapters/catalogarchiveasync"
 	"github.com/thomasduchatelle/dphoto/pkg/catalogadapters/catalogarchivesync"
 	"github.com/thomasduchatelle/dphoto/pkg/catalogadapters/catalogdynamo"
+	"github.com/thomasduchatelle/dphoto/pkg/catalogadapters/catalogplaces"
 	"github.com/thomasduchatelle/dphoto/pkg/singletons"
 )
 
@@ -63,6 +64,11 @@ func InsertMediasCase(ctx context.Context) *catalog.InsertMedias {
 	return catalog.NewInsertMedias(
 		repository,
 		CommandHandlerAlbumSize(ctx),
+		&catalog.MediaPlacesResolver{
+			FindMediasByIds:   repository,
+			ReverseGeocoder:   new(catalogplaces.ReverseGeocoder),
+			UpdateMediaPlaces: repository,
+		},
 	)
 }
 
@@ -74,6 +80,28 @@ func CatalogMediaQueries(ctx context.Context) *catalog.MediaQueries {
 	})
 }
 
+func CatalogGeoMediaQueries(ctx context.Context) *catalog.GeoMediaQueries {
+	return singletons.MustSingleton(func() (*catalog.GeoMediaQueries, error) {
+		return &catalog.GeoMediaQueries{
+			SearchMediasRepository: CatalogRepository(ctx),
+		}, nil
+	})
+}
+
+func CatalogMediaSearch(ctx context.Context) *catalog.MediaSearch {
+	return &catalog.MediaSearch{
+		SearchMediasRepository: CatalogRepository(ctx),
+	}
+}
+
+func GeotagMediasCase(ctx context.Context) *catalog.GeotagMedias {
+	repository := CatalogRepository(ctx)
+	return &catalog.GeotagMedias{
+		MediaReadRepository:  repository,
+		UpdateMediaLocations: repository,
+	}
+}
+
 type SimpleCatalogFactory struct {
 	ArchiveAdapterForCatalog ArchiveAdapterForCatalog
 }
@@ -17,12 +17,13 @@ var migrateArg = struct {
 	repopulate               bool
 	indexTransformation      bool
 	albumOwnerTransformation bool
+	placesTransformation     bool
 }{}
 
 // rootCmd represents the base command when called without any subcommands
 var migrateCmd = &cobra.Command{
 	Use:   "migrate",
-	Short: "Apply migration scripts on a DynamoDB table (index upgrades, v1 -> v2, album's owner fix, ...)",
+	Short: "Apply migration scripts on a DynamoDB table (index upgrades, v1 -> v2, album's owner fix, media places, ...)",
 	Run: func(cmd *cobra.Command, args []string) {
 		if migrateArg.tableName == "" {
 			printer.Error(errors.Errorf("--table is mandatory"), "")
@@ -48,6 +49,10 @@ func buildTransformationsSlice() (transformations []interface{}) {
 		transformations = append(transformations, new(migrator.TransformationAlbumOwner))
 	}
 
+	if migrateArg.placesTransformation {
+		transformations = append(transformations, new(migrator.TransformationMediaPlaces))
+	}
+
 	return
 }
 
@@ -60,4 +65,5 @@ func init() {
 
 	migrateCmd.Flags().BoolVar(&migrateArg.indexTransformation, "index", false, "update DynamoDB indexes")
 	migrateCmd.Flags().BoolVar(&migrateArg.albumOwnerTransformation, "album-owner", false, "add Owner field to albums missing it")
+	migrateCmd.Flags().BoolVar(&migrateArg.placesTransformation, "places", false, "name the country, region, and city of the medias with GPS coordinates, correcting the names resolved by a previous gazetteer")
 }
@@ -0,0 +1,78 @@
+package migrator
+
+import (
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	log "github.com/sirupsen/logrus"
+	"github.com/thomasduchatelle/dphoto/pkg/geo"
+	"strconv"
+	"strings"
+)
+
+// TransformationMediaPlaces names the country, region, and city of the medias having GPS coordinates ; the names resolved by a previous gazetteer are
+// corrected, or removed when the location is no longer near any known place.
+type TransformationMediaPlaces struct{}
+
+func (t *TransformationMediaPlaces) GeneratePatches(run *TransformationRun, item map[string]types.AttributeValue) ([]types.WriteRequest, error) {
+	pk, isPk := item["PK"].(*types.AttributeValueMemberS)
+	sk, isSk := item["SK"].(*types.AttributeValueMemberS)
+	if !isPk || !isSk || !strings.Contains(pk.Value, "#MEDIA#") || sk.Value != "#METADATA" {
+		return nil, nil
+	}
+	run.Counter.Inc("MEDIA", 1)
+
+	details, ok := item["Details"].(*types.AttributeValueMemberM)
+	if !ok {
+		return nil, nil
+	}
+
+	latitude, longitude := readNumber(details.Value["GPSLatitude"]), readNumber(details.Value["GPSLongitude"])
+	if latitude == 0 && longitude == 0 {
+		return nil, nil
+	}
+	run.Counter.Inc("MEDIA_WITH_GPS", 1)
+
+	place, err := geo.PlaceAt(latitude, longitude)
+	if err != nil {
+		log.WithError(err).Warnf("Place of media %s couldn't be resolved", pk.Value)
+		run.Counter.Inc("MEDIA_PLACE_INVALID", 1)
+		return nil, nil
+	}
+	if place == nil {
+		run.Counter.Inc("MEDIA_PLACE_UNKNOWN", 1)
+		place = new(geo.Place)
+	}
+	if readString(details.Value["Country"]) == place.Country && readString(details.Value["Region"]) == place.Region && readString(details.Value["City"]) == place.City {
+		return nil, nil
+	}
+
+	run.Counter.Inc("MEDIA_PLACE_RESOLVED", 1)
+	details.Value["Country"] = &types.AttributeValueMemberS{Value: place.Country}
+	details.Value["Region"] = &types.AttributeValueMemberS{Value: place.Region}
+	details.Value["City"] = &types.AttributeValueMemberS{Value: place.City}
+	return []types.WriteRequest{
+		{
+			PutRequest: &types.PutRequest{
+				Item: item,
+			},
+		},
+	}, nil
+}
+
+func readNumber(attr types.AttributeValue) float64 {
+	if number, ok := attr.(*types.AttributeValueMemberN); ok {
+		value, err := strconv.ParseFloat(number.Value, 64)
+		if err == nil {
+			return value
+		}
+	}
+
+	return 0
+}
+
+func readString(attr types.AttributeValue) string {
+	if value, ok := attr.(*types.AttributeValueMemberS); ok {
+		return value.Value
+	}
+
+	return ""
+}